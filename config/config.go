@@ -6,16 +6,320 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 )
 
 type ProviderConfig struct {
-	ID        string `json:"id"`
-	Type      string `json:"type"`
-	APIKey    string `json:"api_key"`
-	BaseURL   string `json:"base_url"`
-	Model     string `json:"model"`
-	Timeout   int    `json:"timeout"`
-	MaxTokens int    `json:"max_tokens"`
+	ID              string   `json:"id"`
+	Type            string   `json:"type"`
+	APIKey          string   `json:"api_key"`
+	BaseURL         string   `json:"base_url"`
+	Model           string   `json:"model"`
+	Timeout         int      `json:"timeout"`
+	MaxTokens       int      `json:"max_tokens"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"top_p,omitempty"`
+	Stop            []string `json:"stop,omitempty"`
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"`
+	ThinkingBudget  int      `json:"thinking_budget,omitempty"`
+	CacheTTL        int      `json:"cache_ttl,omitempty"`
+
+	// Vertex AI
+	Project     string `json:"project,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Credentials string `json:"credentials,omitempty"`
+
+	// Models declares models the catalog does not know, such as
+	// self-hosted ones, or overrides what it says about them.
+	Models []ModelConfig `json:"models,omitempty"`
+}
+
+// ModelCapabilities are the values of ModelConfig.Capabilities.
+var ModelCapabilities = []string{"temperature", "tool_call", "reasoning", "attachment"}
+
+// ModelConfig describes a model for cost accounting and request shaping.
+// Zero values leave what the catalog says in place.
+type ModelConfig struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// Context and Output are the context window and the output limit in
+	// tokens.
+	Context int `json:"context,omitempty"`
+	Output  int `json:"output,omitempty"`
+	// InputCost and OutputCost are USD per million tokens.
+	InputCost  float64 `json:"input_cost,omitempty"`
+	OutputCost float64 `json:"output_cost,omitempty"`
+	// Capabilities lists what the model supports, from ModelCapabilities.
+	// Without it, a model the catalog does not know is assumed to support
+	// temperature and tool calls.
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+type PersonaConfig struct {
+	Name         string   `json:"name"`
+	SystemPrompt string   `json:"system_prompt"`
+	Model        string   `json:"model"`
+	Tools        []string `json:"tools"`
+	Temperature  *float64 `json:"temperature,omitempty"`
+	TopP         *float64 `json:"top_p,omitempty"`
+}
+
+type ToolsConfig struct {
+	Disabled        []string                  `json:"disabled"`
+	HTTPCredentials map[string]HTTPCredential `json:"http_credentials"`
+	// Search lists websearch backends in fallback order.
+	Search   []SearchBackendConfig `json:"search"`
+	WebFetch WebFetchConfig        `json:"webfetch"`
+	// ApprovalTimeoutSeconds is how long a call waits for approval before
+	// it is rejected.
+	ApprovalTimeoutSeconds int `json:"approval_timeout_seconds"`
+	// CallTimeoutSeconds stops a tool call that runs longer; zero lets
+	// calls run as long as they take.
+	CallTimeoutSeconds int `json:"call_timeout_seconds"`
+	// DryRun makes shell and write_file report what they would do instead
+	// of doing it, unless a chat turns it off with /dryrun.
+	DryRun bool `json:"dry_run"`
+	// ReadOnly refuses shell, write_file, edit_file, and every call that
+	// would change something, such as running code or sending an email.
+	ReadOnly bool `json:"read_only"`
+	// SQL lists the databases the sql_query tool may use; without any, the
+	// tool is not offered.
+	SQL []SQLDatabase `json:"sql"`
+	// Actions are the webhooks the run_action tool can trigger; without
+	// any, the tool is not offered.
+	Actions []ActionConfig `json:"actions"`
+	// MaxResultChars is the length above which a tool result is stored as
+	// an artifact and only its head and tail are kept in the conversation.
+	// Zero uses the default of 16000.
+	MaxResultChars int `json:"max_result_chars"`
+	// PruneResultsAfter replaces a tool result with a one-line stub once
+	// the model has answered after it this many times. Zero keeps them.
+	PruneResultsAfter int `json:"prune_results_after"`
+}
+
+// ActionConfig is a named webhook. {{name}} placeholders in URL and Body
+// are filled from Params when the action runs.
+type ActionConfig struct {
+	Name         string              `json:"name"`
+	Description  string              `json:"description"`
+	URL          string              `json:"url"`
+	Method       string              `json:"method"`
+	Headers      map[string]string   `json:"headers"`
+	Body         string              `json:"body"`
+	Params       []ActionParamConfig `json:"params"`
+	Token        string              `json:"token"`
+	Secret       string              `json:"secret"`
+	SkipApproval bool                `json:"skip_approval"`
+}
+
+type ActionParamConfig struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Required    bool     `json:"required"`
+	Enum        []string `json:"enum"`
+}
+
+// SQLDatabase is a database for the sql_query tool. Driver defaults to
+// sqlite, whose DSN is the database file.
+type SQLDatabase struct {
+	Name        string `json:"name"`
+	Driver      string `json:"driver"`
+	DSN         string `json:"dsn"`
+	Description string `json:"description"`
+	AllowWrite  bool   `json:"allow_write"`
+}
+
+// WebFetchConfig tunes webfetch caching and politeness. Zero values use the
+// defaults; negative values disable a setting.
+type WebFetchConfig struct {
+	CacheDir         string `json:"cache_dir"`
+	CacheTTLMinutes  int    `json:"cache_ttl_minutes"`
+	DomainIntervalMs int    `json:"domain_interval_ms"`
+	MaxConcurrent    int    `json:"max_concurrent"`
+	TimeoutSeconds   int    `json:"timeout_seconds"`
+}
+
+type SearchBackendConfig struct {
+	Type    string `json:"type"`
+	APIKey  string `json:"api_key"`
+	BaseURL string `json:"base_url"`
+	CX      string `json:"cx"`
+}
+
+// HTTPCredential is a named credential the http_request tool can attach to
+// requests for the listed hosts.
+type HTTPCredential struct {
+	Type     string   `json:"type"`
+	Token    string   `json:"token"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Hosts    []string `json:"hosts"`
+}
+
+// CatalogConfig controls the remote model catalog that keeps model pricing,
+// limits, and capabilities current. It is fetched unless disabled.
+type CatalogConfig struct {
+	Disabled     bool   `json:"disabled"`
+	URL          string `json:"url"`
+	RefreshHours int    `json:"refresh_hours"`
+}
+
+type AdminConfig struct {
+	Enabled bool   `json:"enabled"`
+	Addr    string `json:"addr"`
+	Token   string `json:"token"`
+}
+
+type ReloadConfig struct {
+	Watch    bool `json:"watch"`
+	Interval int  `json:"interval"`
+}
+
+type RolesConfig struct {
+	Owners     []string `json:"owners"`
+	OwnerTools []string `json:"owner_tools"`
+}
+
+type RateLimitConfig struct {
+	UserPerMinute int `json:"user_per_minute"`
+	ChatPerMinute int `json:"chat_per_minute"`
+	MaxConcurrent int `json:"max_concurrent"`
+	BanAfter      int `json:"ban_after"`
+	BanMinutes    int `json:"ban_minutes"`
+}
+
+type BudgetConfig struct {
+	DailyUSD       float64 `json:"daily_usd"`
+	MonthlyUSD     float64 `json:"monthly_usd"`
+	ChatDailyUSD   float64 `json:"chat_daily_usd"`
+	ChatMonthlyUSD float64 `json:"chat_monthly_usd"`
+	DowngradeModel string  `json:"downgrade_model"`
+	OwnerChat      string  `json:"owner_chat"`
+	// TurnTokens caps the tokens one turn may consume across its model
+	// calls and subagents; the turn then answers with what it has.
+	TurnTokens int `json:"turn_tokens"`
+}
+
+type SubagentConfig struct {
+	Provider         string   `json:"provider"`
+	Model            string   `json:"model"`
+	Tools            []string `json:"tools"`
+	DenyTools        []string `json:"deny_tools"`
+	MaxIterations    int      `json:"max_iterations"`
+	TokenBudget      int      `json:"token_budget"`
+	MaxDepth         int      `json:"max_depth"`
+	TotalTokenBudget int      `json:"total_token_budget"`
+}
+
+// HeartbeatConfig sets up the proactive loop: every interval the agent runs
+// Prompt unattended and messages OwnerChat if something needs attention.
+type HeartbeatConfig struct {
+	Enabled         bool   `json:"enabled"`
+	OwnerChat       string `json:"owner_chat"`
+	IntervalMinutes int    `json:"interval_minutes"`
+	MaxPerDay       int    `json:"max_per_day"`
+	Prompt          string `json:"prompt"`
+	// ReviewTasks adds the owner chat's overdue and due tasks to the prompt.
+	ReviewTasks bool `json:"review_tasks"`
+}
+
+// UpdateConfig turns on the check for new releases, which only notifies
+// OwnerChat and never installs anything.
+type UpdateConfig struct {
+	Check         bool   `json:"check"`
+	OwnerChat     string `json:"owner_chat"`
+	IntervalHours int    `json:"interval_hours"`
+	// Repo is the GitHub repository whose releases are checked, as
+	// "owner/name".
+	Repo string `json:"repo"`
+}
+
+// PromptConfig adds layers to the system prompt after system_prompt or the
+// persona's prompt: a NENE.md or AGENTS.md file from WorkspaceDir, a list of
+// the available tools, and up to MemoryDigest core memories.
+type PromptConfig struct {
+	WorkspaceDir string `json:"workspace_dir"`
+	ToolList     bool   `json:"tool_list"`
+	MemoryDigest int    `json:"memory_digest"`
+}
+
+type FeedsConfig struct {
+	IntervalMinutes int `json:"interval_minutes"`
+}
+
+// KBConfig sets up the knowledge base. Without an embedding model, chunks
+// are ranked by full-text search.
+type KBConfig struct {
+	Sources      []string        `json:"sources"`
+	SyncMinutes  int             `json:"sync_minutes"`
+	ChunkSize    int             `json:"chunk_size"`
+	ChunkOverlap int             `json:"chunk_overlap"`
+	Embedding    EmbeddingConfig `json:"embedding"`
+}
+
+type EmbeddingConfig struct {
+	BaseURL string `json:"base_url"`
+	APIKey  string `json:"api_key"`
+	Model   string `json:"model"`
+}
+
+// EmailConfig enables the send_email tool (smtp) and the email channel
+// (imap), which answers mail from allow_from senders.
+type EmailConfig struct {
+	SMTP        SMTPConfig `json:"smtp"`
+	IMAP        IMAPConfig `json:"imap"`
+	AllowFrom   []string   `json:"allow_from"`
+	Owners      []string   `json:"owners"`
+	PollSeconds int        `json:"poll_seconds"`
+}
+
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+	Security string `json:"security"`
+}
+
+type IMAPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Mailbox  string `json:"mailbox"`
+	Security string `json:"security"`
+}
+
+// MattermostConfig enables the Mattermost or Rocket.Chat channel when url is
+// set.
+type MattermostConfig struct {
+	Flavor       string   `json:"flavor"`
+	URL          string   `json:"url"`
+	Token        string   `json:"token"`
+	UserID       string   `json:"user_id"`
+	Listen       string   `json:"listen"`
+	WebhookToken string   `json:"webhook_token"`
+	AllowFrom    []string `json:"allow_from"`
+	Owners       []string `json:"owners"`
+}
+
+// GRPCConfig enables the gRPC API, which is also the "grpc" channel.
+type GRPCConfig struct {
+	Enabled   bool     `json:"enabled"`
+	Listen    string   `json:"listen"`
+	Token     string   `json:"token"`
+	AllowFrom []string `json:"allow_from"`
+	Owners    []string `json:"owners"`
+}
+
+// BridgeDestination is a chat the send_to tool may post to from any other
+// chat.
+type BridgeDestination struct {
+	Name        string `json:"name"`
+	Channel     string `json:"channel"`
+	ChatID      string `json:"chat_id"`
+	Description string `json:"description"`
 }
 
 type Config struct {
@@ -24,26 +328,179 @@ type Config struct {
 		Proxy      string   `json:"proxy"`
 		AllowFrom  []string `json:"allow_from"`
 		StreamMode bool     `json:"stream_mode"`
+		Inline     bool     `json:"inline"`
+		ParseMode  string   `json:"parse_mode"`
+		Display    string   `json:"display"`
+		OffsetFile string   `json:"offset_file"`
+		DetailsDB  string   `json:"details_db"`
+		// DetailsTTLHours is how long "View Details" payloads are kept.
+		DetailsTTLHours int `json:"details_ttl_hours"`
+		// Stream edit pacing; see telegram.TelegramConfig.
+		EditIntervalMs    int `json:"edit_interval_ms"`
+		MaxEditsPerMinute int `json:"max_edits_per_minute"`
+		// Pairing lets unknown users ask owners for access.
+		Pairing     bool  `json:"pairing"`
+		PairingChat int64 `json:"pairing_chat"`
+		// MediaDir is where received photos and files are saved; empty
+		// leaves them out.
+		MediaDir string `json:"media_dir"`
+		// EmojiOnly decides whether emoji-only messages and stickers are
+		// answered: "answer", "private", or "ignore".
+		EmojiOnly string `json:"emoji_only"`
+		// Digest sums up tool calls under the answer instead of showing
+		// them as they run.
+		Digest bool `json:"digest"`
 	} `json:"telegram"`
+	Bus struct {
+		BufferSize     int    `json:"buffer_size"`
+		Overflow       string `json:"overflow"`
+		BlockTimeoutMs int    `json:"block_timeout_ms"`
+		// OutboundWaitMs is how long the end of a turn waits for the
+		// messages the agent sent during it.
+		OutboundWaitMs int `json:"outbound_wait_ms"`
+		// Workers is how many chats the agent serves at once.
+		Workers int `json:"workers"`
+		// Record is a file every bus message is written to as JSONL, for
+		// debugging and replay; empty turns recording off.
+		Record        string `json:"record"`
+		RecordMaxMB   int    `json:"record_max_mb"`
+		RecordBackups int    `json:"record_backups"`
+	} `json:"bus"`
 	Provider     ProviderConfig   `json:"provider"`
 	Providers    []ProviderConfig `json:"providers"`
+	Catalog      CatalogConfig    `json:"catalog"`
 	SystemPrompt string           `json:"system_prompt"`
+	Tools        ToolsConfig      `json:"tools"`
+	Admin        AdminConfig      `json:"admin"`
+	Reload       ReloadConfig     `json:"reload"`
+	Roles        RolesConfig      `json:"roles"`
+	RateLimit    RateLimitConfig  `json:"rate_limit"`
+	Budget       BudgetConfig     `json:"budget"`
+	Personas     []PersonaConfig  `json:"personas"`
+	Subagent     SubagentConfig   `json:"subagent"`
+	Feeds        FeedsConfig      `json:"feeds"`
+	Heartbeat    HeartbeatConfig  `json:"heartbeat"`
+	Update       UpdateConfig     `json:"update"`
+	KB           KBConfig         `json:"kb"`
+	Email        EmailConfig      `json:"email"`
+	Mattermost   MattermostConfig `json:"mattermost"`
+	GRPC         GRPCConfig       `json:"grpc"`
+	// Bridge lists the destinations of the send_to tool; without any, the
+	// tool is not offered.
+	Bridge []BridgeDestination `json:"bridge"`
+	// Locale is the language of chats whose users' language is unknown.
+	Locale string `json:"locale"`
+	// Messages replaces bundled status lines and notices, by locale ("*"
+	// for all) and message key; see i18n.SetOverrides.
+	Messages map[string]map[string]string `json:"messages"`
+	Prompt   PromptConfig                 `json:"prompt"`
+	// SessionSummaries stores a model-written summary of each conversation
+	// in memory when it is cleared, for the chat's next conversation.
+	SessionSummaries bool `json:"session_summaries"`
+	// IdleResetHours starts a chat's conversation over when a message
+	// arrives this many hours after the previous one; 0 never does. Chats
+	// can change it with /settings idle_reset.
+	IdleResetHours float64 `json:"idle_reset_hours"`
+	// UserProfiles learns each user's language, timezone, formatting
+	// preferences, and facts from their conversations.
+	UserProfiles bool `json:"user_profiles"`
+	// Timezone is the IANA timezone of chats that have not set their own;
+	// empty means the server's.
+	Timezone string `json:"timezone"`
+	// Encryption encrypts memory and saved conversations at rest.
+	Encryption EncryptionConfig `json:"encryption"`
+	Memory     MemoryConfig     `json:"memory"`
+	// Redact masks secrets in logs, recordings, saved conversations, tool
+	// results, and messages to chats.
+	Redact RedactConfig `json:"redact"`
 }
 
-func ConfigDir() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		home = "."
+// RedactConfig tunes secret masking. The credentials in this config and
+// common key formats are always masked unless Disabled is set.
+type RedactConfig struct {
+	Disabled bool `json:"disabled"`
+	// Patterns are extra regular expressions of secrets to mask.
+	Patterns []string `json:"patterns"`
+}
+
+// MemoryConfig tunes the long-term memory store.
+type MemoryConfig struct {
+	// Tokenizer is the full-text tokenizer: "unicode61" (default) or
+	// "trigram", which also finds words in Japanese and Chinese text.
+	Tokenizer string `json:"tokenizer"`
+	// Weights multiply the relevance of recalled memories by category,
+	// e.g. {"daily": 0.5}; categories left out keep their defaults (core
+	// 1, daily 0.6, conversation 0.4).
+	Weights map[string]float64 `json:"weights"`
+	// HalfLifeDays is how fast non-core memories fade from recall: their
+	// relevance halves every so many days since they were last updated.
+	// Zero keeps the default of 30; a negative value turns fading off.
+	HalfLifeDays float64 `json:"half_life_days"`
+	// Quota caps the results of a category in one recall, e.g.
+	// {"conversation": 2}.
+	Quota map[string]int `json:"quota"`
+	// Dedup makes memory_store update an existing entry of the same
+	// category instead of adding one under a new key when the two are at
+	// least this alike, from 0 to 1 (0.7 is a good start); 0 turns it off.
+	Dedup float64 `json:"dedup"`
+	// Reconcile has the model merge the two entries' contents when Dedup
+	// finds a match, rather than the new content replacing the old.
+	Reconcile bool `json:"reconcile"`
+}
+
+// EncryptionConfig turns on encryption at rest when Key is set, best as a
+// secret reference; see seal.New.
+type EncryptionConfig struct {
+	Key string `json:"key"`
+}
+
+// Channels returns the names of the channels whose config blocks are filled
+// in. They run side by side and share one agent.
+func (c *Config) Channels() []string {
+	var names []string
+	if c.Telegram.Token != "" {
+		names = append(names, "telegram")
 	}
-	return filepath.Join(home, ".nene")
+	if c.Email.IMAP.Host != "" {
+		names = append(names, "email")
+	}
+	if c.Mattermost.URL != "" {
+		flavor := c.Mattermost.Flavor
+		if flavor == "" {
+			flavor = "mattermost"
+		}
+		names = append(names, flavor)
+	}
+	if c.GRPC.Enabled {
+		names = append(names, "grpc")
+	}
+	return names
 }
 
-func ConfigPath() string {
-	return filepath.Join(ConfigDir(), "config.json")
+// ConfigDir is the directory of $NENE_CONFIG_PATH if it is set, and
+// ~/.nene otherwise.
+func ConfigDir() string {
+	if path := os.Getenv(ConfigPathEnv); path != "" {
+		return filepath.Dir(path)
+	}
+	return homeDir()
 }
 
+// DataDir is where databases and caches are kept by default: $NENE_DATA_DIR
+// if it is set, and ~/.nene otherwise.
 func DataDir() string {
-	return ConfigDir()
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return dir
+	}
+	return homeDir()
+}
+
+func homeDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".nene")
 }
 
 func Init() error {
@@ -85,20 +542,27 @@ func Init() error {
 
 func Load() (*Config, error) {
 	cfg := &Config{}
+	path := configSource()
 
-	if err := loadFromFile(cfg); err != nil && !os.IsNotExist(err) {
+	unknown, err := readConfig(cfg)
+	if err != nil && !os.IsNotExist(err) {
 		return nil, fmt.Errorf("load config file: %w", err)
 	}
 
+	problems := append(unknown, resolveSecrets(cfg)...)
 	overrideWithEnv(cfg)
 
-	if cfg.Telegram.Token == "" {
-		return nil, fmt.Errorf("telegram token is required (set TELEGRAM_BOT_TOKEN env or telegram.token in %s)", ConfigPath())
+	if err := withProblems(path, problems, cfg.Validate()); err != nil {
+		return nil, err
 	}
 
 	if cfg.Provider.Type == "" {
 		cfg.Provider.Type = "openai"
 	}
+	applyPreset(&cfg.Provider)
+	for i := range cfg.Providers {
+		applyPreset(&cfg.Providers[i])
+	}
 	if cfg.Provider.Model == "" {
 		cfg.Provider.Model = "gpt-4o"
 	}
@@ -118,26 +582,77 @@ func Load() (*Config, error) {
 		}
 	}
 
-	if cfg.SystemPrompt == "" {
-		cfg.SystemPrompt = DefaultSystemPrompt
+	if cfg.Catalog.URL == "" {
+		cfg.Catalog.URL = "https://models.dev/api.json"
+	}
+	if cfg.Catalog.RefreshHours == 0 {
+		cfg.Catalog.RefreshHours = 24
 	}
 
-	return cfg, nil
-}
+	if cfg.Admin.Addr == "" {
+		cfg.Admin.Addr = "127.0.0.1:8090"
+	}
 
-func loadFromFile(cfg *Config) error {
-	cfgPath := ConfigPath()
+	if cfg.Mattermost.Listen == "" {
+		cfg.Mattermost.Listen = "127.0.0.1:8091"
+	}
 
-	data, err := os.ReadFile(cfgPath)
-	if err != nil {
-		return err
+	if cfg.GRPC.Listen == "" {
+		cfg.GRPC.Listen = "127.0.0.1:8092"
 	}
 
-	if err := json.Unmarshal(data, cfg); err != nil {
-		return fmt.Errorf("parse config file: %w", err)
+	if cfg.Roles.OwnerTools == nil {
+		cfg.Roles.OwnerTools = []string{"shell", "run_code", "write_file"}
 	}
 
-	return nil
+	if cfg.Bus.Overflow == "" {
+		cfg.Bus.Overflow = "block"
+	}
+	if cfg.Bus.Workers == 0 {
+		cfg.Bus.Workers = 4
+	}
+
+	if cfg.Telegram.OffsetFile == "" {
+		cfg.Telegram.OffsetFile = filepath.Join(DataDir(), "telegram_offset.json")
+	}
+	if cfg.Telegram.DetailsDB == "" {
+		cfg.Telegram.DetailsDB = filepath.Join(DataDir(), "tooldetails.db")
+	}
+	if cfg.Telegram.DetailsTTLHours == 0 {
+		cfg.Telegram.DetailsTTLHours = 168
+	}
+
+	if cfg.Tools.WebFetch.CacheDir == "" {
+		cfg.Tools.WebFetch.CacheDir = filepath.Join(DataDir(), "webcache")
+	}
+
+	if cfg.Tools.ApprovalTimeoutSeconds == 0 {
+		cfg.Tools.ApprovalTimeoutSeconds = 300
+	}
+
+	if cfg.Feeds.IntervalMinutes == 0 {
+		cfg.Feeds.IntervalMinutes = 30
+	}
+
+	if cfg.Heartbeat.IntervalMinutes == 0 {
+		cfg.Heartbeat.IntervalMinutes = 30
+	}
+	if cfg.Heartbeat.MaxPerDay == 0 {
+		cfg.Heartbeat.MaxPerDay = 5
+	}
+
+	if cfg.KB.Sources == nil {
+		cfg.KB.Sources = []string{filepath.Join(DataDir(), "kb")}
+	}
+	if cfg.KB.SyncMinutes == 0 {
+		cfg.KB.SyncMinutes = 10
+	}
+
+	if cfg.SystemPrompt == "" {
+		cfg.SystemPrompt = DefaultSystemPrompt
+	}
+
+	return cfg, nil
 }
 
 func overrideWithEnv(cfg *Config) {
@@ -159,6 +674,7 @@ func overrideWithEnv(cfg *Config) {
 	if v := os.Getenv("NENE_PROVIDER_MODEL"); v != "" {
 		cfg.Provider.Model = v
 	}
+	presetKeysFromEnv(cfg)
 	if v := os.Getenv("OPENAI_API_KEY"); v != "" && cfg.Provider.APIKey == "" {
 		cfg.Provider.APIKey = v
 		cfg.Provider.Type = "openai"
@@ -177,9 +693,18 @@ func overrideWithEnv(cfg *Config) {
 		cfg.Provider.APIKey = v
 		cfg.Provider.Type = "azure"
 	}
+	if v := os.Getenv("NENE_BUS_OVERFLOW"); v != "" {
+		cfg.Bus.Overflow = v
+	}
+	if v := os.Getenv("NENE_ADMIN_TOKEN"); v != "" {
+		cfg.Admin.Token = v
+	}
 	if v := os.Getenv("NENE_SYSTEM_PROMPT"); v != "" {
 		cfg.SystemPrompt = v
 	}
+	if v, err := strconv.ParseBool(os.Getenv("NENE_READ_ONLY")); err == nil {
+		cfg.Tools.ReadOnly = v
+	}
 }
 
 func hostOS() string {
@@ -210,6 +735,7 @@ You have a long-term memory system. Use it to remember important information:
 - Use `+"`memory_store`"+` to save important facts, user preferences, personal details, or anything worth remembering for future conversations.
 - Use `+"`memory_recall`"+` to search and retrieve previously stored memories when relevant.
 - Use `+"`memory_forget`"+` to remove outdated or incorrect information.
+- Use `+"`memory_list`"+` and `+"`memory_update`"+` to review stored memories, rename keys, change categories, or forget a whole group of keys by prefix.
 
 When to store memories:
 - User tells you their name, preferences, or personal information
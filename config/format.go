@@ -0,0 +1,215 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configFiles are the file names looked up in ConfigDir, in order of
+// preference. Init always writes JSON.
+var configFiles = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
+// Environment variables for deployments without a home directory, such as
+// containers. ConfigPathEnv names the config file, which may be a mounted
+// secret; ConfigEnv holds the whole config document and takes precedence
+// over any file.
+const (
+	ConfigPathEnv = "NENE_CONFIG_PATH"
+	ConfigEnv     = "NENE_CONFIG"
+	DataDirEnv    = "NENE_DATA_DIR"
+)
+
+func ConfigPath() string {
+	if path := os.Getenv(ConfigPathEnv); path != "" {
+		return path
+	}
+	dir := ConfigDir()
+	for _, name := range configFiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, configFiles[0])
+}
+
+// configSource names where Load reads the config from, for messages.
+func configSource() string {
+	if os.Getenv(ConfigEnv) != "" {
+		return "$" + ConfigEnv
+	}
+	return ConfigPath()
+}
+
+// readConfig decodes the config from $NENE_CONFIG if it is set, or else
+// from ConfigPath.
+func readConfig(cfg *Config) (unknown []string, err error) {
+	if data := os.Getenv(ConfigEnv); data != "" {
+		return parseData(configSource(), "", []byte(data), cfg)
+	}
+	return parseFile(ConfigPath(), cfg)
+}
+
+// parseFile decodes a JSON, YAML, or TOML config file into cfg, chosen by
+// extension. Keys that do not map to a config field are returned rather than
+// silently ignored.
+func parseFile(path string, cfg *Config) (unknown []string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseData(filepath.Base(path), strings.ToLower(filepath.Ext(path)), data, cfg)
+}
+
+// parseData decodes a config document in the format of extension ext. A
+// document without one, such as $NENE_CONFIG or a mounted secret, is JSON if
+// it starts with "{" and YAML otherwise.
+func parseData(name, ext string, data []byte, cfg *Config) (unknown []string, err error) {
+	if ext == "" {
+		ext = ".yaml"
+		if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+			ext = ".json"
+		}
+	}
+
+	raw := map[string]interface{}{}
+	switch ext {
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config format %q (use .json, .yaml, .yml, or .toml)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+
+	// Round-trip through JSON so every format shares the json struct tags.
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	if err := json.Unmarshal(normalized, cfg); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("%s: %s must be %s, got %s", name, typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+
+	return unknownFields(raw, reflect.TypeOf(cfg).Elem(), ""), nil
+}
+
+// unknownFields walks a decoded document alongside the struct type it is
+// decoded into and describes every key without a matching json tag.
+func unknownFields(v interface{}, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var out []string
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := jsonFields(t)
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			name := key
+			if path != "" {
+				name = path + "." + key
+			}
+			ft, ok := fields[key]
+			if !ok {
+				msg := fmt.Sprintf("unknown field %q", name)
+				if s := suggest(key, fields); s != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", s)
+				}
+				out = append(out, msg)
+				continue
+			}
+			out = append(out, unknownFields(m[key], ft, name)...)
+		}
+	case reflect.Slice:
+		switch items := v.(type) {
+		case []interface{}:
+			for i, item := range items {
+				out = append(out, unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		case []map[string]interface{}:
+			for i, item := range items {
+				out = append(out, unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return out
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// suggest returns the known field closest to key when it is a plausible
+// typo, or "" otherwise.
+func suggest(key string, fields map[string]reflect.Type) string {
+	best, bestDist := "", 3
+	for name := range fields {
+		if strings.EqualFold(name, key) {
+			return name
+		}
+		if d := editDistance(strings.ToLower(key), name); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
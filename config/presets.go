@@ -0,0 +1,48 @@
+package config
+
+import "os"
+
+// ProviderPreset is an OpenAI-compatible service that can be used as a
+// provider type of its own, so only an API key has to be configured.
+type ProviderPreset struct {
+	BaseURL string
+	// Env is read for the API key when api_key is empty.
+	Env   string
+	Model string
+}
+
+var ProviderPresets = map[string]ProviderPreset{
+	"groq":     {BaseURL: "https://api.groq.com/openai/v1", Env: "GROQ_API_KEY", Model: "llama-3.3-70b-versatile"},
+	"mistral":  {BaseURL: "https://api.mistral.ai/v1", Env: "MISTRAL_API_KEY", Model: "mistral-large-latest"},
+	"xai":      {BaseURL: "https://api.x.ai/v1", Env: "XAI_API_KEY", Model: "grok-4"},
+	"deepseek": {BaseURL: "https://api.deepseek.com/v1", Env: "DEEPSEEK_API_KEY", Model: "deepseek-chat"},
+	"together": {BaseURL: "https://api.together.xyz/v1", Env: "TOGETHER_API_KEY", Model: "meta-llama/Llama-3.3-70B-Instruct-Turbo"},
+}
+
+// presetKeysFromEnv fills empty API keys of preset providers from the
+// preset's environment variable.
+func presetKeysFromEnv(cfg *Config) {
+	fill := func(p *ProviderConfig) {
+		if preset, ok := ProviderPresets[p.Type]; ok && p.APIKey == "" {
+			p.APIKey = os.Getenv(preset.Env)
+		}
+	}
+	fill(&cfg.Provider)
+	for i := range cfg.Providers {
+		fill(&cfg.Providers[i])
+	}
+}
+
+// applyPreset fills the base URL and model of a preset provider.
+func applyPreset(p *ProviderConfig) {
+	preset, ok := ProviderPresets[p.Type]
+	if !ok {
+		return
+	}
+	if p.BaseURL == "" {
+		p.BaseURL = preset.BaseURL
+	}
+	if p.Model == "" {
+		p.Model = preset.Model
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ReloadFunc applies a freshly loaded config. old is the config that was
+// active before the reload.
+type ReloadFunc func(old, cur *Config) error
+
+// Reloader re-reads the config file on demand, on SIGHUP, or when the file
+// changes, and hands the result to the registered handlers.
+type Reloader struct {
+	mu       sync.Mutex
+	current  *Config
+	modTime  time.Time
+	handlers []ReloadFunc
+}
+
+func NewReloader(cfg *Config) *Reloader {
+	r := &Reloader{current: cfg}
+	if info, err := os.Stat(ConfigPath()); err == nil {
+		r.modTime = info.ModTime()
+	}
+	return r
+}
+
+func (r *Reloader) OnReload(fn ReloadFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, fn)
+}
+
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload loads the config and applies it. An invalid config leaves the
+// current one in place. Handler errors are collected but do not stop the
+// remaining handlers.
+func (r *Reloader) Reload() error {
+	cfg, err := Load()
+	if err != nil {
+		return err
+	}
+
+	// Handlers run without the lock, so they may call Current or OnReload.
+	r.mu.Lock()
+	old := r.current
+	handlers := append([]ReloadFunc(nil), r.handlers...)
+	r.current = cfg
+	if info, err := os.Stat(ConfigPath()); err == nil {
+		r.modTime = info.ModTime()
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for _, fn := range handlers {
+		if err := fn(old, cfg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Watch reloads on SIGHUP and whenever the config file's modification time
+// changes, checking every interval, until ctx is done.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reloadAndLog("SIGHUP")
+		case <-ticker.C:
+			info, err := os.Stat(ConfigPath())
+			if err != nil {
+				continue
+			}
+			r.mu.Lock()
+			changed := info.ModTime().After(r.modTime)
+			r.mu.Unlock()
+			if changed {
+				r.reloadAndLog("file change")
+			}
+		}
+	}
+}
+
+func (r *Reloader) reloadAndLog(reason string) {
+	if err := r.Reload(); err != nil {
+		fmt.Printf("Config reload (%s) failed: %v\n", reason, err)
+		// Do not retry the same broken file on every tick.
+		if info, statErr := os.Stat(ConfigPath()); statErr == nil {
+			r.mu.Lock()
+			r.modTime = info.ModTime()
+			r.mu.Unlock()
+		}
+		return
+	}
+	fmt.Printf("Config reloaded (%s)\n", reason)
+}
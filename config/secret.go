@@ -0,0 +1,313 @@
+package config
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// Secret references can replace any credential in the config file:
+//
+//	${VAR}         read from the environment
+//	keychain:NAME  read from the OS keychain (macOS Keychain, Linux Secret Service)
+//	secret:NAME    read from the encrypted secrets file
+const (
+	keychainPrefix = "keychain:"
+	secretPrefix   = "secret:"
+
+	keychainService = "nene"
+
+	// PassphraseEnv holds the passphrase for the encrypted secrets file.
+	PassphraseEnv = "NENE_SECRET_PASSPHRASE"
+)
+
+func SecretsPath() string {
+	return filepath.Join(ConfigDir(), "secrets.enc")
+}
+
+// resolveSecrets replaces secret references in credential fields and
+// describes every reference that could not be resolved.
+func resolveSecrets(cfg *Config) []string {
+	var problems []string
+	eachSecret(cfg, func(path string, v *string) {
+		val, err := ResolveSecret(*v)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+			return
+		}
+		*v = val
+	})
+	return problems
+}
+
+// Secrets returns the values of every credential field that is set, for
+// masking wherever text could reveal them.
+func (c *Config) Secrets() []string {
+	var values []string
+	eachSecret(c, func(_ string, v *string) {
+		if *v != "" {
+			values = append(values, *v)
+		}
+	})
+	return values
+}
+
+// eachSecret calls fn with the path and value of every credential field.
+func eachSecret(cfg *Config, fn func(path string, v *string)) {
+	fn("telegram.token", &cfg.Telegram.Token)
+	fn("provider.api_key", &cfg.Provider.APIKey)
+	fn("provider.credentials", &cfg.Provider.Credentials)
+	for i := range cfg.Providers {
+		fn(fmt.Sprintf("providers[%d].api_key", i), &cfg.Providers[i].APIKey)
+		fn(fmt.Sprintf("providers[%d].credentials", i), &cfg.Providers[i].Credentials)
+	}
+	fn("admin.token", &cfg.Admin.Token)
+	for i := range cfg.Tools.Search {
+		fn(fmt.Sprintf("tools.search[%d].api_key", i), &cfg.Tools.Search[i].APIKey)
+	}
+	for name, cred := range cfg.Tools.HTTPCredentials {
+		fn(fmt.Sprintf("tools.http_credentials.%s.token", name), &cred.Token)
+		fn(fmt.Sprintf("tools.http_credentials.%s.password", name), &cred.Password)
+		cfg.Tools.HTTPCredentials[name] = cred
+	}
+	for i := range cfg.Tools.Actions {
+		fn(fmt.Sprintf("tools.actions[%d].token", i), &cfg.Tools.Actions[i].Token)
+		fn(fmt.Sprintf("tools.actions[%d].secret", i), &cfg.Tools.Actions[i].Secret)
+	}
+	fn("kb.embedding.api_key", &cfg.KB.Embedding.APIKey)
+	fn("email.smtp.password", &cfg.Email.SMTP.Password)
+	fn("email.imap.password", &cfg.Email.IMAP.Password)
+	fn("mattermost.token", &cfg.Mattermost.Token)
+	fn("mattermost.webhook_token", &cfg.Mattermost.WebhookToken)
+	fn("grpc.token", &cfg.GRPC.Token)
+	fn("encryption.key", &cfg.Encryption.Key)
+}
+
+// ResolveSecret returns the value a secret reference points to. Values that
+// are not references are returned unchanged.
+func ResolveSecret(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, "${") && strings.HasSuffix(ref, "}"):
+		name := ref[2 : len(ref)-1]
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+	case strings.HasPrefix(ref, keychainPrefix):
+		return keychainGet(strings.TrimPrefix(ref, keychainPrefix))
+	case strings.HasPrefix(ref, secretPrefix):
+		passphrase := os.Getenv(PassphraseEnv)
+		if passphrase == "" {
+			return "", fmt.Errorf("%s is required to read %s", PassphraseEnv, ref)
+		}
+		return NewSecretStore(SecretsPath(), passphrase).Get(strings.TrimPrefix(ref, secretPrefix))
+	default:
+		return ref, nil
+	}
+}
+
+// SetSecret stores a secret for `nene secret set` and returns the reference
+// to put in the config file. backend is "file" or "keychain".
+func SetSecret(backend, name, value string) (string, error) {
+	switch backend {
+	case "keychain":
+		if err := keychainSet(name, value); err != nil {
+			return "", err
+		}
+		return keychainPrefix + name, nil
+	case "file", "":
+		passphrase := os.Getenv(PassphraseEnv)
+		if passphrase == "" {
+			var err error
+			if passphrase, err = ReadPassphrase("Secrets passphrase: "); err != nil {
+				return "", err
+			}
+		}
+		if err := NewSecretStore(SecretsPath(), passphrase).Set(name, value); err != nil {
+			return "", err
+		}
+		return secretPrefix + name, nil
+	default:
+		return "", fmt.Errorf("unknown secret backend %q (use file or keychain)", backend)
+	}
+}
+
+// ReadPassphrase prompts on the terminal without echoing input.
+func ReadPassphrase(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no terminal to read the passphrase from; set %s", PassphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("read passphrase: %w", err)
+	}
+	if len(b) == 0 {
+		return "", errors.New("passphrase must not be empty")
+	}
+	return string(b), nil
+}
+
+// SecretStore keeps named secrets in a single file encrypted with NaCl
+// secretbox under a key derived from a passphrase with scrypt.
+//
+// File layout: salt (16 bytes) | nonce (24 bytes) | sealed JSON object.
+type SecretStore struct {
+	path       string
+	passphrase string
+}
+
+const (
+	saltSize  = 16
+	nonceSize = 24
+)
+
+func NewSecretStore(path, passphrase string) *SecretStore {
+	return &SecretStore{path: path, passphrase: passphrase}
+}
+
+func (s *SecretStore) Get(name string) (string, error) {
+	secrets, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	v, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found in %s (add it with `nene secret set %s`)", name, s.path, name)
+	}
+	return v, nil
+}
+
+func (s *SecretStore) Set(name, value string) error {
+	secrets, err := s.load()
+	if errors.Is(err, os.ErrNotExist) {
+		secrets = make(map[string]string)
+	} else if err != nil {
+		return err
+	}
+	secrets[name] = value
+	return s.save(secrets)
+}
+
+func (s *SecretStore) load() (map[string]string, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < saltSize+nonceSize+secretbox.Overhead {
+		return nil, fmt.Errorf("secrets file %s is corrupt", s.path)
+	}
+
+	salt := data[:saltSize]
+	var nonce [nonceSize]byte
+	copy(nonce[:], data[saltSize:saltSize+nonceSize])
+	key, err := s.key(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, ok := secretbox.Open(nil, data[saltSize+nonceSize:], &nonce, key)
+	if !ok {
+		return nil, fmt.Errorf("decrypt %s: wrong passphrase or corrupt file", s.path)
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.path, err)
+	}
+	return secrets, nil
+}
+
+func (s *SecretStore) save(secrets map[string]string) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltSize)
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return err
+	}
+	key, err := s.key(salt)
+	if err != nil {
+		return err
+	}
+
+	out := append(salt, nonce[:]...)
+	out = secretbox.Seal(out, plain, &nonce, key)
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("create secrets directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0600); err != nil {
+		return fmt.Errorf("write secrets: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *SecretStore) key(salt []byte) (*[32]byte, error) {
+	k, err := scrypt.Key([]byte(s.passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	var key [32]byte
+	copy(key[:], k)
+	return &key, nil
+}
+
+func keychainGet(name string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", name, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService, "account", name)
+	default:
+		return "", fmt.Errorf("keychain is not supported on %s", runtime.GOOS)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("keychain lookup %q: %w (add it with `nene secret set --keychain %s`)", name, err, name)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+func keychainSet(name, value string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// With -w last and no value, security prompts for the password
+		// and its confirmation on stdin, keeping it out of the process list.
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keychainService, "-a", name, "-w")
+		cmd.Stdin = strings.NewReader(value + "\n" + value + "\n")
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label", "nene "+name, "service", keychainService, "account", name)
+		cmd.Stdin = strings.NewReader(value)
+	default:
+		return fmt.Errorf("keychain is not supported on %s", runtime.GOOS)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("keychain store %q: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
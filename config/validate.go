@@ -0,0 +1,540 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationError lists every problem found in a config so they can be fixed
+// in one pass.
+type ValidationError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "invalid config %s:", e.Path)
+	for _, p := range e.Problems {
+		sb.WriteString("\n  - " + p)
+	}
+	return sb.String()
+}
+
+var providerTypes = []string{"openai", "openai-compatible", "anthropic", "azure", "vertex", "groq", "mistral", "xai", "deepseek", "together"}
+
+var overflowPolicies = []string{"block", "drop-oldest", "drop-new", "block-timeout"}
+
+var parseModes = []string{"html", "markdownv2"}
+
+var displayModes = []string{"quiet", "progress", "segments"}
+
+var emojiModes = []string{"answer", "private", "ignore"}
+
+// locales mirrors the translations bundled in pkg/i18n.
+var locales = []string{"en", "ja", "zh"}
+
+// Validate reports missing required keys and out-of-range values. It is run
+// by Load before defaults are applied.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if len(c.Channels()) == 0 {
+		add("no channel is configured: set telegram.token (or TELEGRAM_BOT_TOKEN), email.imap.host, mattermost.url, or grpc.enabled")
+	}
+
+	problems = append(problems, validateProvider("provider", c.Provider)...)
+
+	primaryID := c.Provider.ID
+	if primaryID == "" {
+		primaryID = "default"
+	}
+	ids := map[string]bool{primaryID: true}
+	for i, p := range c.Providers {
+		path := fmt.Sprintf("providers[%d]", i)
+		if p.ID == "" {
+			add("%s.id is required so models can be addressed as \"<id>/<model>\"", path)
+		} else if strings.Contains(p.ID, "/") {
+			add("%s.id %q must not contain \"/\"", path, p.ID)
+		} else if ids[p.ID] {
+			add("%s.id %q is already used by another provider (the primary provider's id is %q)", path, p.ID, primaryID)
+		}
+		ids[p.ID] = true
+		problems = append(problems, validateProvider(path, p)...)
+	}
+
+	if c.Telegram.ParseMode != "" && !slices.Contains(parseModes, strings.ToLower(c.Telegram.ParseMode)) {
+		add("telegram.parse_mode %q is not supported (use %s)", c.Telegram.ParseMode, strings.Join(parseModes, ", "))
+	}
+	if c.Locale != "" && !slices.Contains(locales, c.Locale) {
+		add("locale %q is not supported (use %s)", c.Locale, strings.Join(locales, ", "))
+	}
+	for locale := range c.Messages {
+		if locale != "*" && !slices.Contains(locales, locale) {
+			add("messages.%s: locale is not supported (use %s, or * for all)", locale, strings.Join(locales, ", "))
+		}
+	}
+	if c.Telegram.Display != "" && !slices.Contains(displayModes, c.Telegram.Display) {
+		add("telegram.display %q is not supported (use %s)", c.Telegram.Display, strings.Join(displayModes, ", "))
+	}
+	if c.Telegram.EmojiOnly != "" && !slices.Contains(emojiModes, c.Telegram.EmojiOnly) {
+		add("telegram.emoji_only %q is not supported (use %s)", c.Telegram.EmojiOnly, strings.Join(emojiModes, ", "))
+	}
+	if c.Telegram.DetailsTTLHours < 0 {
+		add("telegram.details_ttl_hours must not be negative")
+	}
+	if c.Telegram.EditIntervalMs < 0 || c.Telegram.MaxEditsPerMinute < 0 {
+		add("telegram.edit_interval_ms and telegram.max_edits_per_minute must not be negative")
+	}
+	sqlNames := make(map[string]bool)
+	for i, db := range c.Tools.SQL {
+		path := fmt.Sprintf("tools.sql[%d]", i)
+		switch {
+		case db.Name == "":
+			add("%s.name is required", path)
+		case sqlNames[db.Name]:
+			add("%s.name %q is used by another database", path, db.Name)
+		}
+		sqlNames[db.Name] = true
+		if db.DSN == "" {
+			add("%s.dsn is required", path)
+		}
+	}
+	if c.Tools.MaxResultChars < 0 || c.Tools.PruneResultsAfter < 0 {
+		add("tools.max_result_chars and tools.prune_results_after must not be negative")
+	}
+	actionNames := make(map[string]bool)
+	for i, a := range c.Tools.Actions {
+		path := fmt.Sprintf("tools.actions[%d]", i)
+		switch {
+		case a.Name == "":
+			add("%s.name is required", path)
+		case actionNames[a.Name]:
+			add("%s.name %q is used by another action", path, a.Name)
+		}
+		actionNames[a.Name] = true
+		if !strings.HasPrefix(a.URL, "http://") && !strings.HasPrefix(a.URL, "https://") {
+			add("%s.url must start with http:// or https://", path)
+		}
+		for j, p := range a.Params {
+			if p.Name == "" {
+				add("%s.params[%d].name is required", path, j)
+			}
+		}
+	}
+	if c.IdleResetHours < 0 {
+		add("idle_reset_hours must not be negative")
+	}
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			add("timezone %q is not a known IANA timezone", c.Timezone)
+		}
+	}
+	if c.Prompt.MemoryDigest < 0 {
+		add("prompt.memory_digest must not be negative")
+	}
+	if c.Bus.Overflow != "" && !slices.Contains(overflowPolicies, c.Bus.Overflow) {
+		add("bus.overflow %q is not supported (use %s)", c.Bus.Overflow, strings.Join(overflowPolicies, ", "))
+	}
+	if c.Bus.BufferSize < 0 {
+		add("bus.buffer_size must not be negative")
+	}
+	if c.Bus.BlockTimeoutMs < 0 {
+		add("bus.block_timeout_ms must not be negative")
+	}
+	if c.Bus.OutboundWaitMs < 0 {
+		add("bus.outbound_wait_ms must not be negative")
+	}
+	if c.Bus.Workers < 0 {
+		add("bus.workers must not be negative")
+	}
+	if c.Bus.RecordMaxMB < 0 || c.Bus.RecordBackups < 0 {
+		add("bus.record_max_mb and bus.record_backups must not be negative")
+	}
+	if c.Reload.Interval < 0 {
+		add("reload.interval must not be negative")
+	}
+	if rl := c.RateLimit; rl.UserPerMinute < 0 || rl.ChatPerMinute < 0 || rl.MaxConcurrent < 0 || rl.BanAfter < 0 || rl.BanMinutes < 0 {
+		add("rate_limit values must not be negative")
+	}
+
+	if b := c.Budget; b.DailyUSD < 0 || b.MonthlyUSD < 0 || b.ChatDailyUSD < 0 || b.ChatMonthlyUSD < 0 || b.TurnTokens < 0 {
+		add("budget limits must not be negative")
+	}
+	if c.Budget.OwnerChat != "" && !strings.Contains(c.Budget.OwnerChat, ":") {
+		add("budget.owner_chat %q must look like \"telegram:<chat id>\"", c.Budget.OwnerChat)
+	}
+
+	if c.Feeds.IntervalMinutes < 0 {
+		add("feeds.interval_minutes must not be negative")
+	}
+
+	if hb := c.Heartbeat; hb.IntervalMinutes < 0 || hb.MaxPerDay < 0 {
+		add("heartbeat values must not be negative")
+	}
+	if hb := c.Heartbeat; hb.Enabled {
+		channel, _, ok := strings.Cut(hb.OwnerChat, ":")
+		switch {
+		case hb.OwnerChat == "":
+			add("heartbeat.owner_chat is required when the heartbeat is enabled")
+		case !ok:
+			add("heartbeat.owner_chat %q must look like \"telegram:<chat id>\"", hb.OwnerChat)
+		case !slices.Contains(c.Channels(), channel):
+			add("heartbeat.owner_chat %q is not on a configured channel", hb.OwnerChat)
+		}
+	}
+
+	if u := c.Update; u.IntervalHours < 0 {
+		add("update.interval_hours must not be negative")
+	}
+	if u := c.Update; u.Check {
+		channel, _, ok := strings.Cut(u.OwnerChat, ":")
+		switch {
+		case u.OwnerChat == "":
+			add("update.owner_chat is required when update.check is on")
+		case !ok:
+			add("update.owner_chat %q must look like \"telegram:<chat id>\"", u.OwnerChat)
+		case !slices.Contains(c.Channels(), channel):
+			add("update.owner_chat %q is not on a configured channel", u.OwnerChat)
+		}
+	}
+	if r := c.Update.Repo; r != "" && strings.Count(r, "/") != 1 {
+		add("update.repo %q must look like \"owner/name\"", r)
+	}
+
+	if kb := c.KB; kb.SyncMinutes < 0 || kb.ChunkSize < 0 || kb.ChunkOverlap < 0 {
+		add("kb values must not be negative")
+	}
+	if c.KB.ChunkSize > 0 && c.KB.ChunkOverlap >= c.KB.ChunkSize {
+		add("kb.chunk_overlap must be smaller than kb.chunk_size")
+	}
+
+	for _, s := range []struct{ path, value string }{
+		{"email.smtp", c.Email.SMTP.Security},
+		{"email.imap", c.Email.IMAP.Security},
+	} {
+		switch s.value {
+		case "", "tls", "starttls", "none":
+		default:
+			add("%s.security must be tls, starttls, or none, got %q", s.path, s.value)
+		}
+	}
+	if c.Email.PollSeconds < 0 {
+		add("email.poll_seconds must not be negative")
+	}
+	if c.Email.SMTP.Host != "" && c.Email.SMTP.From == "" {
+		add("email.smtp.from is required")
+	}
+	if c.Email.IMAP.Host != "" {
+		if c.Email.SMTP.Host == "" {
+			add("email.smtp is required to answer mail read over email.imap")
+		}
+		if len(c.Email.AllowFrom) == 0 {
+			add("email.allow_from must list the addresses the email channel answers")
+		}
+	}
+
+	if m := c.Mattermost; m.URL != "" {
+		switch m.Flavor {
+		case "", "mattermost":
+		case "rocketchat":
+			if m.UserID == "" {
+				add("mattermost.user_id is required for rocketchat")
+			}
+		default:
+			add("mattermost.flavor must be mattermost or rocketchat, got %q", m.Flavor)
+		}
+		if m.Token == "" {
+			add("mattermost.token is required")
+		}
+		if m.WebhookToken == "" {
+			add("mattermost.webhook_token is required so that only the chat server can post to the webhook")
+		}
+	}
+
+	if c.Admin.Enabled && c.Admin.Token == "" {
+		add("admin.token is required when admin is enabled")
+	}
+	if c.GRPC.Enabled && c.GRPC.Token == "" {
+		add("grpc.token is required when grpc is enabled")
+	}
+
+	if c.Catalog.RefreshHours < 0 {
+		add("catalog.refresh_hours must not be negative")
+	}
+
+	if c.Tools.ApprovalTimeoutSeconds < 0 {
+		add("tools.approval_timeout_seconds must not be negative")
+	}
+	if c.Tools.CallTimeoutSeconds < 0 {
+		add("tools.call_timeout_seconds must not be negative")
+	}
+
+	channels := c.Channels()
+	bridgeNames := map[string]bool{}
+	for i, d := range c.Bridge {
+		path := fmt.Sprintf("bridge[%d]", i)
+		if d.Name == "" {
+			add("%s.name is required", path)
+		} else if bridgeNames[d.Name] {
+			add("%s.name %q is already used by another destination", path, d.Name)
+		}
+		bridgeNames[d.Name] = true
+		if d.ChatID == "" {
+			add("%s.chat_id is required", path)
+		}
+		if !slices.Contains(channels, d.Channel) {
+			add("%s.channel %q is not a configured channel (configured: %s)", path, d.Channel, strings.Join(channels, ", "))
+		}
+	}
+
+	for i, b := range c.Tools.Search {
+		path := fmt.Sprintf("tools.search[%d]", i)
+		switch b.Type {
+		case "duckduckgo":
+		case "brave":
+			if b.APIKey == "" {
+				add("%s.api_key is required for brave", path)
+			}
+		case "searxng":
+			if b.BaseURL == "" {
+				add("%s.base_url is required for searxng", path)
+			}
+		case "google":
+			if b.APIKey == "" || b.CX == "" {
+				add("%s.api_key and cx are required for google", path)
+			}
+		default:
+			add("%s.type must be one of duckduckgo, brave, searxng, google, got %q", path, b.Type)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Tools.HTTPCredentials)) {
+		cred := c.Tools.HTTPCredentials[name]
+		path := "tools.http_credentials." + name
+		switch cred.Type {
+		case "bearer":
+			if cred.Token == "" {
+				add("%s.token is required for bearer credentials", path)
+			}
+		case "basic":
+			if cred.Username == "" {
+				add("%s.username is required for basic credentials", path)
+			}
+		default:
+			add("%s.type must be bearer or basic, got %q", path, cred.Type)
+		}
+		if len(cred.Hosts) == 0 {
+			add("%s.hosts must list the hosts the credential may be sent to", path)
+		}
+	}
+
+	names := map[string]bool{}
+	for i, p := range c.Personas {
+		path := fmt.Sprintf("personas[%d]", i)
+		if p.Name == "" {
+			add("%s.name is required", path)
+		} else if names[p.Name] {
+			add("%s.name %q is used by more than one persona", path, p.Name)
+		}
+		names[p.Name] = true
+		if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 2) {
+			add("%s.temperature must be between 0 and 2", path)
+		}
+		if p.TopP != nil && (*p.TopP < 0 || *p.TopP > 1) {
+			add("%s.top_p must be between 0 and 1", path)
+		}
+	}
+
+	if c.Subagent.Provider != "" && !ids[c.Subagent.Provider] {
+		add("subagent.provider %q does not match any providers[].id", c.Subagent.Provider)
+	}
+	if c.Subagent.MaxIterations < 0 || c.Subagent.TokenBudget < 0 || c.Subagent.MaxDepth < 0 || c.Subagent.TotalTokenBudget < 0 {
+		add("subagent limits must not be negative")
+	}
+
+	if c.Telegram.Pairing && c.Telegram.PairingChat == 0 && !slices.ContainsFunc(c.Roles.Owners, func(o string) bool {
+		id, _, _ := strings.Cut(strings.TrimSpace(o), "|")
+		n, err := strconv.ParseInt(id, 10, 64)
+		return err == nil && n > 0
+	}) {
+		add("telegram.pairing needs telegram.pairing_chat or an owner listed by user ID in roles.owners to send access requests to")
+	}
+
+	switch c.Memory.Tokenizer {
+	case "", "unicode61", "trigram":
+	default:
+		add("memory.tokenizer must be unicode61 or trigram, got %q", c.Memory.Tokenizer)
+	}
+	for category, w := range c.Memory.Weights {
+		if w < 0 {
+			add("memory.weights.%s must not be negative", category)
+		}
+	}
+	if d := c.Memory.Dedup; d < 0 || d > 1 {
+		add("memory.dedup must be between 0 and 1, got %v", d)
+	}
+	for category, q := range c.Memory.Quota {
+		if q < 0 {
+			add("memory.quota.%s must not be negative", category)
+		}
+	}
+
+	// Mirrors seal.MinKeyLen.
+	if k := c.Encryption.Key; k != "" && len(k) < 16 {
+		add("encryption.key must be at least 16 characters; generate one with `openssl rand -base64 32`")
+	}
+
+	for i, p := range c.Redact.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			add("redact.patterns[%d]: %v", i, err)
+		}
+	}
+
+	for _, l := range []struct {
+		path string
+		list []string
+	}{
+		{"telegram.allow_from", c.Telegram.AllowFrom},
+		{"email.allow_from", c.Email.AllowFrom},
+		{"mattermost.allow_from", c.Mattermost.AllowFrom},
+		{"grpc.allow_from", c.GRPC.AllowFrom},
+		{"roles.owners", c.Roles.Owners},
+		{"email.owners", c.Email.Owners},
+		{"mattermost.owners", c.Mattermost.Owners},
+		{"grpc.owners", c.GRPC.Owners},
+	} {
+		problems = append(problems, validateAllowList(l.path, l.list)...)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Path: configSource(), Problems: problems}
+	}
+	return nil
+}
+
+// validateAllowList catches entries that would match nobody; see
+// channel.matchList for the format.
+func validateAllowList(path string, list []string) []string {
+	var problems []string
+	for i, entry := range list {
+		e := strings.TrimPrefix(strings.TrimSpace(entry), "!")
+		switch {
+		case e == "" || e == "@" || e == "chat:":
+			problems = append(problems, fmt.Sprintf("%s[%d] %q is empty", path, i, entry))
+		case strings.HasPrefix(e, "!"):
+			problems = append(problems, fmt.Sprintf("%s[%d] %q: use a single \"!\" to deny", path, i, entry))
+		case e == "*" && e != strings.TrimSpace(entry):
+			problems = append(problems, fmt.Sprintf("%s[%d] \"!*\" would deny everyone; leave the channel unconfigured instead", path, i))
+		}
+	}
+	return problems
+}
+
+func validateProvider(path string, p ProviderConfig) []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	typ := p.Type
+	if typ == "" {
+		typ = "openai"
+	}
+
+	switch typ {
+	case "openai", "anthropic":
+		if p.APIKey == "" {
+			add("%s.api_key is required for type %q", path, typ)
+		}
+	case "azure":
+		if p.APIKey == "" {
+			add("%s.api_key is required for type \"azure\"", path)
+		}
+		if p.BaseURL == "" || strings.Contains(p.BaseURL, "YOUR_RESOURCE") {
+			add("%s.base_url is required for type \"azure\" (e.g. https://my-resource.openai.azure.com)", path)
+		}
+		if p.Model == "" {
+			add("%s.model is required for type \"azure\" (the deployment name)", path)
+		}
+	case "openai-compatible":
+		if p.BaseURL == "" {
+			add("%s.base_url is required for type \"openai-compatible\" (e.g. http://localhost:11434/v1)", path)
+		}
+	case "groq", "mistral", "xai", "deepseek", "together":
+		if p.APIKey == "" {
+			add("%s.api_key is required for type %q (or set %s)", path, typ, ProviderPresets[typ].Env)
+		}
+	case "vertex":
+		if p.Model == "" {
+			add("%s.model is required for type \"vertex\" (e.g. gemini-2.5-flash)", path)
+		}
+	default:
+		add("%s.type %q is not supported (use %s)", path, p.Type, strings.Join(providerTypes, ", "))
+	}
+
+	if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 2) {
+		add("%s.temperature must be between 0 and 2", path)
+	}
+	if p.TopP != nil && (*p.TopP < 0 || *p.TopP > 1) {
+		add("%s.top_p must be between 0 and 1", path)
+	}
+	if p.Timeout < 0 || p.MaxTokens < 0 || p.ThinkingBudget < 0 || p.CacheTTL < 0 {
+		add("%s: timeout, max_tokens, thinking_budget, and cache_ttl must not be negative", path)
+	}
+
+	seen := make(map[string]bool)
+	for i, m := range p.Models {
+		mp := fmt.Sprintf("%s.models[%d]", path, i)
+		switch {
+		case m.ID == "":
+			add("%s.id is required", mp)
+		case seen[m.ID]:
+			add("%s: model %q is declared twice", mp, m.ID)
+		}
+		seen[m.ID] = true
+		if m.Context < 0 || m.Output < 0 || m.InputCost < 0 || m.OutputCost < 0 {
+			add("%s: context, output, input_cost, and output_cost must not be negative", mp)
+		}
+		for _, c := range m.Capabilities {
+			if !slices.Contains(ModelCapabilities, c) {
+				add("%s.capabilities: %q is not a capability (use %s)", mp, c, strings.Join(ModelCapabilities, ", "))
+			}
+		}
+	}
+	return problems
+}
+
+// ValidateFile checks the config at path the way Load would, including
+// secret references and environment overrides, and backs the `nene config validate` command.
+func ValidateFile(path string) error {
+	cfg := &Config{}
+	unknown, err := parseFile(path, cfg)
+	if err != nil {
+		return err
+	}
+	problems := append(unknown, resolveSecrets(cfg)...)
+	overrideWithEnv(cfg)
+	return withProblems(path, problems, cfg.Validate())
+}
+
+// withProblems merges unknown-field reports into a validation result.
+func withProblems(path string, problems []string, err error) error {
+	if verr, ok := err.(*ValidationError); ok {
+		problems = append(problems, verr.Problems...)
+	} else if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Path: path, Problems: problems}
+}
@@ -0,0 +1,461 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/agent"
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/channel"
+	"github.com/nene-agent/nene/pkg/doctor"
+	"github.com/nene-agent/nene/pkg/memory"
+	"github.com/nene-agent/nene/pkg/tool"
+)
+
+// StreamModeToggler is implemented by channels whose stream mode can be
+// switched at runtime.
+type StreamModeToggler interface {
+	StreamMode() bool
+	SetStreamMode(enabled bool)
+}
+
+// Server exposes runtime control over HTTP. Every endpoint but the health
+// probes requires the configured bearer token; with no token set, those
+// endpoints refuse every request.
+type Server struct {
+	addr  string
+	token string
+
+	sessions   *agent.SessionManager
+	tools      *tool.Manager
+	mem        memory.Memory
+	bus        *bus.MessageBus
+	streamMode StreamModeToggler
+	reload     func() error
+	budget     *agent.BudgetTracker
+	ready      []doctor.Check
+	pairing    *channel.PairingStore
+	toolStats  *tool.StatsStore
+
+	srv *http.Server
+}
+
+type Option func(*Server)
+
+func WithSessionManager(m *agent.SessionManager) Option {
+	return func(s *Server) { s.sessions = m }
+}
+
+func WithToolManager(m *tool.Manager) Option {
+	return func(s *Server) { s.tools = m }
+}
+
+func WithMemory(m memory.Memory) Option {
+	return func(s *Server) { s.mem = m }
+}
+
+func WithMessageBus(b *bus.MessageBus) Option {
+	return func(s *Server) { s.bus = b }
+}
+
+func WithStreamModeToggler(t StreamModeToggler) Option {
+	return func(s *Server) { s.streamMode = t }
+}
+
+func WithReloadFunc(fn func() error) Option {
+	return func(s *Server) { s.reload = fn }
+}
+
+func WithBudget(b *agent.BudgetTracker) Option {
+	return func(s *Server) { s.budget = b }
+}
+
+// WithReadyChecks adds checks /readyz runs; it reports ready only when all
+// of them pass.
+func WithReadyChecks(checks ...doctor.Check) Option {
+	return func(s *Server) { s.ready = append(s.ready, checks...) }
+}
+
+// WithPairingStore enables listing and revoking pairing decisions.
+func WithPairingStore(p *channel.PairingStore) Option {
+	return func(s *Server) { s.pairing = p }
+}
+
+// WithToolStats enables GET /stats/tools.
+func WithToolStats(st *tool.StatsStore) Option {
+	return func(s *Server) { s.toolStats = st }
+}
+
+func NewServer(addr, token string, opts ...Option) *Server {
+	s := &Server{addr: addr, token: token}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sessions", s.handleListSessions)
+	mux.HandleFunc("DELETE /sessions/{key}", s.handleClearSession)
+	mux.HandleFunc("GET /sessions/{key}/export", s.handleExportSession)
+	mux.HandleFunc("GET /sessions/{key}/prompt", s.handleSessionPrompt)
+	mux.HandleFunc("POST /config/reload", s.handleReload)
+	mux.HandleFunc("GET /tools", s.handleListTools)
+	mux.HandleFunc("GET /memory", s.handleListMemory)
+	mux.HandleFunc("GET /stream-mode", s.handleGetStreamMode)
+	mux.HandleFunc("PUT /stream-mode", s.handleSetStreamMode)
+	mux.HandleFunc("GET /stats", s.handleStats)
+	mux.HandleFunc("GET /stats/tools", s.handleToolStats)
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("GET /budget", s.handleGetBudget)
+	mux.HandleFunc("PUT /budget", s.handleSetBudget)
+	mux.HandleFunc("GET /pairings", s.handleListPairings)
+	mux.HandleFunc("DELETE /pairings/{channel}/{sender}", s.handleRemovePairing)
+
+	// Probes are answered without the token, so orchestrators can call
+	// them; they reveal only check names and outcomes.
+	probes := http.NewServeMux()
+	probes.HandleFunc("GET /healthz", s.handleHealthz)
+	probes.HandleFunc("GET /readyz", s.handleReadyz)
+	probes.Handle("/", s.authenticate(mux))
+	return probes
+}
+
+func (s *Server) Start() error {
+	s.srv = &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Printf("Admin API listening on %s\n", s.addr)
+	go func() {
+		if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("Admin API error: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	if s.srv == nil {
+		return nil
+	}
+	return s.srv.Shutdown(ctx)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Without a token nothing but the probes is served; a localhost bind
+		// alone does not keep out other local users or rebound DNS names.
+		if s.token == "" {
+			writeError(w, http.StatusUnauthorized, "admin.token is not set")
+			return
+		}
+		want := "Bearer " + s.token
+		got := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		writeError(w, http.StatusNotImplemented, "session manager not configured")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.sessions.Sessions())
+}
+
+func (s *Server) handleClearSession(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		writeError(w, http.StatusNotImplemented, "session manager not configured")
+		return
+	}
+	key := r.PathValue("key")
+	if !s.sessions.ClearSession(key) {
+		writeError(w, http.StatusNotFound, "session not found: "+key)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"cleared": key})
+}
+
+func (s *Server) handleExportSession(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		writeError(w, http.StatusNotImplemented, "session manager not configured")
+		return
+	}
+	format, err := agent.ParseExportFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	key := r.PathValue("key")
+	data, ok, err := s.sessions.Export(key, format)
+	if !ok {
+		writeError(w, http.StatusNotFound, "session not found: "+key)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	contentType := "text/markdown; charset=utf-8"
+	if format == agent.ExportHTML {
+		contentType = "text/html; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+// handleSessionPrompt shows the system prompt a new conversation under the
+// key would start with, layer by layer.
+func (s *Server) handleSessionPrompt(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		writeError(w, http.StatusNotImplemented, "session manager not configured")
+		return
+	}
+	c := s.sessions.SystemPromptFor(r.PathValue("key"))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sections": c.Sections,
+		"text":     c.Text(),
+	})
+}
+
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if s.reload == nil {
+		writeError(w, http.StatusNotImplemented, "config reload not configured")
+		return
+	}
+	if err := s.reload(); err != nil {
+		writeError(w, http.StatusInternalServerError, "reload config: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"reloaded": true})
+}
+
+func (s *Server) handleListTools(w http.ResponseWriter, r *http.Request) {
+	if s.tools == nil {
+		writeError(w, http.StatusNotImplemented, "tool manager not configured")
+		return
+	}
+
+	infos := s.tools.List()
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *Server) handleListMemory(w http.ResponseWriter, r *http.Request) {
+	if s.mem == nil {
+		writeError(w, http.StatusNotImplemented, "memory not configured")
+		return
+	}
+
+	req := &memory.ListRequest{}
+	if c := r.URL.Query().Get("category"); c != "" {
+		req.Category = memory.ParseCategory(c)
+	}
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid limit: "+l)
+			return
+		}
+		req.Limit = limit
+	}
+
+	if q := r.URL.Query().Get("q"); q != "" {
+		entries, err := s.mem.Recall(r.Context(), &memory.RecallRequest{Query: q, Limit: req.Limit})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, entries)
+		return
+	}
+
+	entries, err := s.mem.List(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) handleGetStreamMode(w http.ResponseWriter, r *http.Request) {
+	if s.streamMode == nil {
+		writeError(w, http.StatusNotImplemented, "stream mode toggle not configured")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"enabled": s.streamMode.StreamMode()})
+}
+
+func (s *Server) handleSetStreamMode(w http.ResponseWriter, r *http.Request) {
+	if s.streamMode == nil {
+		writeError(w, http.StatusNotImplemented, "stream mode toggle not configured")
+		return
+	}
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Enabled == nil {
+		writeError(w, http.StatusBadRequest, `expected body {"enabled": true|false}`)
+		return
+	}
+	s.streamMode.SetStreamMode(*body.Enabled)
+	writeJSON(w, http.StatusOK, map[string]bool{"enabled": *body.Enabled})
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if s.bus == nil {
+		writeError(w, http.StatusNotImplemented, "message bus not configured")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.bus.Stats())
+}
+
+// handleToolStats reports every tool's calls, over all time or the last
+// ?days=N days.
+func (s *Server) handleToolStats(w http.ResponseWriter, r *http.Request) {
+	if s.toolStats == nil {
+		writeError(w, http.StatusNotImplemented, "tool statistics not configured")
+		return
+	}
+	var since time.Time
+	if v := r.URL.Query().Get("days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days <= 0 {
+			writeError(w, http.StatusBadRequest, "days must be a positive number")
+			return
+		}
+		since = time.Now().AddDate(0, 0, -days)
+	}
+	stats, err := s.toolStats.Stats(r.Context(), since)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		writeError(w, http.StatusNotImplemented, "session manager not configured")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.sessions.Status(r.Context()))
+}
+
+func (s *Server) handleGetBudget(w http.ResponseWriter, r *http.Request) {
+	if s.budget == nil {
+		writeError(w, http.StatusNotImplemented, "budget not configured")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.budget.Status())
+}
+
+// handleSetBudget updates the limits given in the body; omitted fields keep
+// their current value.
+func (s *Server) handleSetBudget(w http.ResponseWriter, r *http.Request) {
+	if s.budget == nil {
+		writeError(w, http.StatusNotImplemented, "budget not configured")
+		return
+	}
+	limits := s.budget.Limits()
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid budget limits: "+err.Error())
+		return
+	}
+	if limits.DailyUSD < 0 || limits.MonthlyUSD < 0 || limits.ChatDailyUSD < 0 || limits.ChatMonthlyUSD < 0 {
+		writeError(w, http.StatusBadRequest, "budget limits must not be negative")
+		return
+	}
+	s.budget.SetLimits(limits)
+	writeJSON(w, http.StatusOK, limits)
+}
+
+func (s *Server) handleListPairings(w http.ResponseWriter, r *http.Request) {
+	if s.pairing == nil {
+		writeError(w, http.StatusNotImplemented, "pairing not configured")
+		return
+	}
+	pairings, err := s.pairing.List(r.Context(), r.URL.Query().Get("channel"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pairings)
+}
+
+// handleRemovePairing revokes an approved sender's access, or lets a
+// turned-away one ask again.
+func (s *Server) handleRemovePairing(w http.ResponseWriter, r *http.Request) {
+	if s.pairing == nil {
+		writeError(w, http.StatusNotImplemented, "pairing not configured")
+		return
+	}
+	channelName, sender := r.PathValue("channel"), r.PathValue("sender")
+	ok, err := s.pairing.Remove(r.Context(), channelName, sender)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "no pairing for "+channelName+"/"+sender)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"removed": channelName + "/" + sender})
+}
+
+// handleHealthz reports that the process is up and serving.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz runs the readiness checks, plus a check that the inbound
+// queue has room, and answers 503 when any fails.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	checks := s.ready
+	if s.bus != nil {
+		checks = append([]doctor.Check{{Name: "inbound queue", Run: func(ctx context.Context) (string, error) {
+			q := s.bus.Stats().Inbound
+			if q.Capacity > 0 && q.Depth >= q.Capacity {
+				return "", fmt.Errorf("full (%d messages)", q.Depth)
+			}
+			return fmt.Sprintf("%d of %d", q.Depth, q.Capacity), nil
+		}}}, checks...)
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	report := doctor.Run(ctx, checks...)
+	status := map[string]string{}
+	for _, res := range report.Results {
+		status[res.Name] = "ok"
+		if !res.OK {
+			status[res.Name] = "failed"
+		}
+	}
+	code := http.StatusOK
+	if !report.OK() {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, map[string]interface{}{"ready": report.OK(), "checks": status})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
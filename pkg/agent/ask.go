@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/model"
+)
+
+const (
+	askCacheTTL  = 10 * time.Minute
+	askCacheSize = 256
+	askMaxTokens = 512
+)
+
+const askPrompt = "\n\nAnswer in a few short sentences. You have no tools and this is a single question without follow-ups."
+
+// Ask answers a standalone question with a single model call: no tools, no
+// history, and a short answer. It is meant for quick lookups such as
+// Telegram inline queries, which fire repeatedly while the user types, so
+// answers are cached for a few minutes. Spend is charged to sessionKey.
+func (m *SessionManager) Ask(ctx context.Context, sessionKey, question string) (string, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return "", errors.New("empty question")
+	}
+	key := strings.ToLower(strings.Join(strings.Fields(question), " "))
+	if answer, ok := m.askCache.get(key); ok {
+		return answer, nil
+	}
+
+	if b := m.Budget(); b != nil {
+		if reason, _ := b.Exceeded(sessionKey); reason != "" {
+			return "", fmt.Errorf("the %s", reason)
+		}
+	}
+
+	m.mu.Lock()
+	provider := m.provider
+	p := m.defaults
+	m.mu.Unlock()
+
+	req := &model.Request{
+		Model: p.Model,
+		Messages: []model.Message{
+			{Role: "system", Content: p.SystemPrompt + askPrompt},
+			{Role: "user", Content: question},
+		},
+		Temperature: p.Temperature,
+		TopP:        p.TopP,
+		MaxTokens:   askMaxTokens,
+	}
+	resp, err := provider.Send(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	m.recordUsage(sessionKey, p.Model, resp.Usage)
+	if len(resp.Choices) == 0 {
+		return "", errors.New("empty response")
+	}
+
+	answer := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if answer == "" {
+		return "", errors.New("empty response")
+	}
+	m.askCache.put(key, answer)
+	return answer, nil
+}
+
+type askEntry struct {
+	answer  string
+	expires time.Time
+}
+
+// askCache is a small TTL cache of answers keyed by normalized question.
+type askCache struct {
+	mu      sync.Mutex
+	entries map[string]askEntry
+}
+
+func (c *askCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return "", false
+	}
+	return e.answer, true
+}
+
+func (c *askCache) put(key, answer string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.entries == nil {
+		c.entries = make(map[string]askEntry)
+	}
+	if len(c.entries) >= askCacheSize {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		// Still full: evict arbitrary entries rather than grow.
+		for k := range c.entries {
+			if len(c.entries) < askCacheSize {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = askEntry{answer: answer, expires: now.Add(askCacheTTL)}
+}
@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/model"
+	"github.com/nene-agent/nene/pkg/tool"
+)
+
+// Background runs one unattended turn for a "channel:chatID" session key
+// and returns the final answer instead of streaming it to the chat. The turn
+// uses the chat's persona, model, and tools, with an owner's role, but not
+// its history. Tool calls that need approval still ask in the chat, and
+// spend is charged to it.
+func (m *SessionManager) Background(ctx context.Context, sessionKey, prompt string) (string, error) {
+	channel, chatID, ok := strings.Cut(sessionKey, ":")
+	if !ok {
+		return "", fmt.Errorf("invalid session key %q", sessionKey)
+	}
+	if b := m.Budget(); b != nil {
+		if reason, _ := b.Exceeded(sessionKey); reason != "" {
+			return "", fmt.Errorf("the %s", reason)
+		}
+	}
+
+	m.mu.Lock()
+	p := m.personaLocked(sessionKey)
+	sampling := m.samplingLocked(sessionKey, p)
+	tools := m.toolsFor(p)
+	s := NewSession(m.provider,
+		WithModelName(m.modelLocked(sessionKey, p)),
+		WithSystemPrompt(m.composePromptLocked(sessionKey, p, tools).Text()),
+		WithTemperature(sampling.Temperature),
+		WithTopP(sampling.TopP),
+		WithToolManager(tools),
+		WithUsageFunc(func(ref string, u model.Usage) { m.recordUsage(sessionKey, ref, u) }),
+		WithTurnTokenBudget(m.turnTokens),
+		WithToolResultPruning(m.pruneAfter),
+	)
+	m.mu.Unlock()
+
+	ctx = tool.WithRole(ctx, tool.RoleOwner)
+	ctx = tool.WithLocation(ctx, m.TimezoneFor(sessionKey))
+	err := s.ProcessMessage(ctx, bus.InboundMessage{
+		Channel:    channel,
+		ChatID:     chatID,
+		SessionKey: sessionKey,
+		Content:    prompt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	msgs := s.Messages()
+	if len(msgs) == 0 || msgs[len(msgs)-1].Role != "assistant" {
+		return "", errors.New("no answer")
+	}
+	return strings.TrimSpace(msgs[len(msgs)-1].Content), nil
+}
@@ -0,0 +1,211 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BudgetLimits caps spending in USD. Zero disables a limit.
+type BudgetLimits struct {
+	DailyUSD       float64 `json:"daily_usd"`
+	MonthlyUSD     float64 `json:"monthly_usd"`
+	ChatDailyUSD   float64 `json:"chat_daily_usd"`
+	ChatMonthlyUSD float64 `json:"chat_monthly_usd"`
+	// DowngradeModel, when set, is used for turns over budget instead of
+	// refusing them.
+	DowngradeModel string `json:"downgrade_model"`
+	// OwnerChat is the "channel:chatID" session key notified when a limit
+	// is first exceeded in a period.
+	OwnerChat string `json:"owner_chat"`
+}
+
+type Spend struct {
+	Day   float64 `json:"day"`
+	Month float64 `json:"month"`
+}
+
+type BudgetStatus struct {
+	Limits BudgetLimits     `json:"limits"`
+	Day    string           `json:"day"`
+	Month  string           `json:"month"`
+	Global Spend            `json:"global"`
+	Chats  map[string]Spend `json:"chats"`
+}
+
+// BudgetTracker accumulates spend per session key and globally for the
+// current UTC day and month, persisting it so restarts do not reset budgets.
+type BudgetTracker struct {
+	mu       sync.Mutex
+	path     string
+	limits   BudgetLimits
+	day      string
+	month    string
+	global   Spend
+	chats    map[string]Spend
+	notified map[string]bool
+}
+
+func NewBudgetTracker(limits BudgetLimits, path string) *BudgetTracker {
+	b := &BudgetTracker{
+		path:     path,
+		limits:   limits,
+		chats:    make(map[string]Spend),
+		notified: make(map[string]bool),
+	}
+	b.load()
+	b.rollover(time.Now().UTC())
+	return b
+}
+
+func (b *BudgetTracker) Limits() BudgetLimits {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.limits
+}
+
+func (b *BudgetTracker) SetLimits(limits BudgetLimits) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.limits = limits
+	b.notified = make(map[string]bool)
+}
+
+// Record adds spend for a session key.
+func (b *BudgetTracker) Record(sessionKey string, usd float64) {
+	if usd <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover(time.Now().UTC())
+
+	b.global.Day += usd
+	b.global.Month += usd
+	chat := b.chats[sessionKey]
+	chat.Day += usd
+	chat.Month += usd
+	b.chats[sessionKey] = chat
+	b.save()
+}
+
+// Exceeded describes the first limit a session key is over, or returns ""
+// when it may spend more. notify is true the first time a limit is reported
+// in its period, so the owner is told once.
+func (b *BudgetTracker) Exceeded(sessionKey string) (reason string, notify bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover(time.Now().UTC())
+
+	chat := b.chats[sessionKey]
+	var scope string
+	switch {
+	case over(b.global.Day, b.limits.DailyUSD):
+		scope, reason = "global-day", fmt.Sprintf("daily budget of $%.2f reached", b.limits.DailyUSD)
+	case over(b.global.Month, b.limits.MonthlyUSD):
+		scope, reason = "global-month", fmt.Sprintf("monthly budget of $%.2f reached", b.limits.MonthlyUSD)
+	case over(chat.Day, b.limits.ChatDailyUSD):
+		scope, reason = sessionKey+"-day", fmt.Sprintf("daily budget of $%.2f for this chat reached", b.limits.ChatDailyUSD)
+	case over(chat.Month, b.limits.ChatMonthlyUSD):
+		scope, reason = sessionKey+"-month", fmt.Sprintf("monthly budget of $%.2f for this chat reached", b.limits.ChatMonthlyUSD)
+	default:
+		return "", false
+	}
+
+	if b.notified[scope] {
+		return reason, false
+	}
+	b.notified[scope] = true
+	return reason, true
+}
+
+func (b *BudgetTracker) Status() BudgetStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover(time.Now().UTC())
+
+	chats := make(map[string]Spend, len(b.chats))
+	for k, v := range b.chats {
+		chats[k] = v
+	}
+	return BudgetStatus{
+		Limits: b.limits,
+		Day:    b.day,
+		Month:  b.month,
+		Global: b.global,
+		Chats:  chats,
+	}
+}
+
+func over(spent, limit float64) bool {
+	return limit > 0 && spent >= limit
+}
+
+func (b *BudgetTracker) rollover(now time.Time) {
+	day := now.Format("2006-01-02")
+	month := now.Format("2006-01")
+	if month != b.month {
+		b.global = Spend{}
+		b.chats = make(map[string]Spend)
+		b.notified = make(map[string]bool)
+	} else if day != b.day {
+		b.global.Day = 0
+		for k, v := range b.chats {
+			v.Day = 0
+			b.chats[k] = v
+		}
+		for scope := range b.notified {
+			if strings.HasSuffix(scope, "-day") {
+				delete(b.notified, scope)
+			}
+		}
+	}
+	b.day, b.month = day, month
+}
+
+type budgetFile struct {
+	Day    string           `json:"day"`
+	Month  string           `json:"month"`
+	Global Spend            `json:"global"`
+	Chats  map[string]Spend `json:"chats"`
+}
+
+func (b *BudgetTracker) load() {
+	if b.path == "" {
+		return
+	}
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return
+	}
+	var f budgetFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		fmt.Printf("Ignoring unreadable budget file %s: %v\n", b.path, err)
+		return
+	}
+	b.day, b.month, b.global = f.Day, f.Month, f.Global
+	if f.Chats != nil {
+		b.chats = f.Chats
+	}
+}
+
+func (b *BudgetTracker) save() {
+	if b.path == "" {
+		return
+	}
+	data, err := json.Marshal(budgetFile{Day: b.day, Month: b.month, Global: b.global, Chats: b.chats})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		fmt.Printf("Failed to save budget: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(b.path, data, 0644); err != nil {
+		fmt.Printf("Failed to save budget: %v\n", err)
+	}
+}
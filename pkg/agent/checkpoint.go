@@ -0,0 +1,307 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/model"
+	"github.com/nene-agent/nene/pkg/redact"
+	"github.com/nene-agent/nene/pkg/seal"
+)
+
+// maxCheckpoints is how many checkpoints a chat keeps. Automatic ones are
+// dropped first, oldest first.
+const maxCheckpoints = 20
+
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// Checkpoint is a saved copy of a conversation. Auto checkpoints are taken
+// before a rewind so the abandoned branch can be restored.
+type Checkpoint struct {
+	ID       int             `json:"id"`
+	Name     string          `json:"name,omitempty"`
+	Auto     bool            `json:"auto,omitempty"`
+	Turns    int             `json:"turns"`
+	Created  time.Time       `json:"created"`
+	Messages []model.Message `json:"messages"`
+}
+
+func (c Checkpoint) String() string {
+	label := fmt.Sprintf("#%d", c.ID)
+	if c.Name != "" {
+		label += " " + c.Name
+	}
+	if c.Auto {
+		label += " (before rewind)"
+	}
+	return fmt.Sprintf("%s: %d turn(s), %s", label, c.Turns, c.Created.Local().Format("2006-01-02 15:04"))
+}
+
+// CheckpointStore keeps each chat's checkpoints in a JSON file of its own
+// under dir, so they survive restarts.
+type CheckpointStore struct {
+	dir      string
+	box      *seal.Box
+	redactor *redact.Redactor
+	mu       sync.Mutex
+}
+
+type checkpointFile struct {
+	NextID      int          `json:"next_id"`
+	Checkpoints []Checkpoint `json:"checkpoints"`
+}
+
+func NewCheckpointStore(dir string) *CheckpointStore {
+	return &CheckpointStore{dir: dir}
+}
+
+// SetCipher encrypts the checkpoint files written from now on. Files
+// written before stay readable and are encrypted when next changed.
+func (s *CheckpointStore) SetCipher(b *seal.Box) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.box = b
+}
+
+// SetRedactor masks secrets in the checkpoints saved from now on, so they
+// are not written to disk.
+func (s *CheckpointStore) SetRedactor(r *redact.Redactor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.redactor = r
+}
+
+// Save stores a copy of messages for a session key. name may be empty.
+func (s *CheckpointStore) Save(sessionKey, name string, auto bool, messages []model.Message) (Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.load(sessionKey)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if f.NextID == 0 {
+		f.NextID = 1
+	}
+	cp := Checkpoint{
+		ID:       f.NextID,
+		Name:     name,
+		Auto:     auto,
+		Turns:    countTurns(messages),
+		Created:  time.Now(),
+		Messages: redactMessages(s.redactor, messages),
+	}
+	f.NextID++
+	f.Checkpoints = append(f.Checkpoints, cp)
+	f.Checkpoints = pruneCheckpoints(f.Checkpoints)
+	return cp, s.save(sessionKey, f)
+}
+
+// redactMessages returns a copy of messages with secrets masked in their
+// text and tool call arguments.
+func redactMessages(r *redact.Redactor, messages []model.Message) []model.Message {
+	out := append([]model.Message(nil), messages...)
+	if r == nil {
+		return out
+	}
+	for i := range out {
+		out[i].Content = r.String(out[i].Content)
+		if len(out[i].ToolCalls) > 0 {
+			calls := append([]model.ToolCall(nil), out[i].ToolCalls...)
+			for j := range calls {
+				calls[j].Function.Arguments = r.String(calls[j].Function.Arguments)
+			}
+			out[i].ToolCalls = calls
+		}
+	}
+	return out
+}
+
+func pruneCheckpoints(cps []Checkpoint) []Checkpoint {
+	for len(cps) > maxCheckpoints {
+		drop := 0
+		for i, cp := range cps {
+			if cp.Auto {
+				drop = i
+				break
+			}
+		}
+		cps = append(cps[:drop], cps[drop+1:]...)
+	}
+	return cps
+}
+
+// List returns a session key's checkpoints, oldest first, without their
+// messages.
+func (s *CheckpointStore) List(sessionKey string) ([]Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.load(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	for i := range f.Checkpoints {
+		f.Checkpoints[i].Messages = nil
+	}
+	return f.Checkpoints, nil
+}
+
+// Get finds a checkpoint by "#id" or name; the newest wins when names
+// repeat.
+func (s *CheckpointStore) Get(sessionKey, ref string) (Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.load(sessionKey)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	i := findCheckpoint(f.Checkpoints, ref)
+	if i < 0 {
+		return Checkpoint{}, ErrCheckpointNotFound
+	}
+	return f.Checkpoints[i], nil
+}
+
+// Delete removes a checkpoint by "#id" or name and reports whether one was
+// found.
+func (s *CheckpointStore) Delete(sessionKey, ref string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.load(sessionKey)
+	if err != nil {
+		return false, err
+	}
+	i := findCheckpoint(f.Checkpoints, ref)
+	if i < 0 {
+		return false, nil
+	}
+	f.Checkpoints = append(f.Checkpoints[:i], f.Checkpoints[i+1:]...)
+	return true, s.save(sessionKey, f)
+}
+
+func findCheckpoint(cps []Checkpoint, ref string) int {
+	id, byID := 0, false
+	if rest, ok := strings.CutPrefix(ref, "#"); ok {
+		n, err := strconv.Atoi(rest)
+		id, byID = n, err == nil
+	}
+	for i := len(cps) - 1; i >= 0; i-- {
+		if (byID && cps[i].ID == id) || (!byID && cps[i].Name == ref) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *CheckpointStore) path(sessionKey string) string {
+	return filepath.Join(s.dir, url.QueryEscape(sessionKey)+".json")
+}
+
+func (s *CheckpointStore) load(sessionKey string) (checkpointFile, error) {
+	var f checkpointFile
+	data, err := os.ReadFile(s.path(sessionKey))
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	if data, err = s.box.Open(data); err != nil {
+		return f, fmt.Errorf("read checkpoints of %s: %w", sessionKey, err)
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("read checkpoints of %s: %w", sessionKey, err)
+	}
+	return f, nil
+}
+
+func (s *CheckpointStore) save(sessionKey string, f checkpointFile) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	if len(f.Checkpoints) == 0 {
+		err := os.Remove(s.path(sessionKey))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(sessionKey), s.box.Seal(data), 0600)
+}
+
+// countTurns counts the user messages of a conversation; each starts a turn.
+func countTurns(messages []model.Message) int {
+	n := 0
+	for _, m := range messages {
+		if m.Role == "user" {
+			n++
+		}
+	}
+	return n
+}
+
+// Turns returns how many turns the conversation has.
+func (s *Session) Turns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return countTurns(s.messages)
+}
+
+// Rewind drops the last n turns, with everything the agent did in them, and
+// returns how many remain.
+func (s *Session) Rewind(n int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n < 1 {
+		return 0, errors.New("the number of turns must be at least 1")
+	}
+	turns := countTurns(s.messages)
+	if n > turns {
+		return 0, fmt.Errorf("the conversation has only %d turn(s)", turns)
+	}
+	seen := 0
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].Role != "user" {
+			continue
+		}
+		if seen++; seen == n {
+			s.messages = s.messages[:i:i]
+			break
+		}
+	}
+	return turns - n, nil
+}
+
+// markCancelled ends a cancelled turn with an assistant message, so the
+// conversation still alternates and the model knows the turn was cut short.
+func (s *Session) markCancelled() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := len(s.messages); n > 0 && s.messages[n-1].Role == "assistant" && len(s.messages[n-1].ToolCalls) == 0 {
+		s.messages[n-1].Content += "\n\n[Stopped by the user.]"
+		return
+	}
+	s.messages = append(s.messages, model.Message{Role: "assistant", Content: "[Stopped by the user.]"})
+}
+
+// Restore replaces the conversation with a checkpoint's messages. The
+// current system prompt is kept.
+func (s *Session) Restore(messages []model.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append([]model.Message(nil), messages...)
+	if s.systemPrompt != "" && len(s.messages) > 0 && s.messages[0].Role == "system" {
+		s.messages[0].Content = s.systemPrompt
+	}
+}
@@ -0,0 +1,9 @@
+package agent
+
+import "github.com/nene-agent/nene/pkg/channel"
+
+// describeError is the code and safe description of the error event for
+// err; see channel.DescribeError.
+func describeError(err error) (code, text string) {
+	return channel.DescribeError(err)
+}
@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/model"
+)
+
+type ExportFormat string
+
+const (
+	ExportMarkdown ExportFormat = "md"
+	ExportHTML     ExportFormat = "html"
+)
+
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch strings.ToLower(s) {
+	case "", "md", "markdown":
+		return ExportMarkdown, nil
+	case "html":
+		return ExportHTML, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q (use md or html)", s)
+	}
+}
+
+// exportEntry is one rendered step of a conversation.
+type exportEntry struct {
+	Kind    string // user, assistant, tool_call, tool_result
+	Title   string
+	Content string
+}
+
+// Export renders the conversation, including tool calls with their inputs
+// and outputs, as a standalone document. The system prompt and memories
+// injected into user messages are left out.
+func (s *Session) Export(title string, format ExportFormat) ([]byte, error) {
+	entries := exportEntries(s.Messages())
+	switch format {
+	case ExportMarkdown:
+		return renderMarkdown(title, entries), nil
+	case ExportHTML:
+		return renderHTML(title, entries)
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func exportEntries(messages []model.Message) []exportEntry {
+	toolNames := make(map[string]string)
+	var entries []exportEntry
+	for _, msg := range messages {
+		switch msg.Role {
+		case "user":
+			content, _, _ := strings.Cut(msg.Content, "\n\n[Retrieved memories]\n")
+			entries = append(entries, exportEntry{Kind: "user", Title: "👤 User", Content: content})
+		case "assistant":
+			if strings.TrimSpace(msg.Content) != "" {
+				entries = append(entries, exportEntry{Kind: "assistant", Title: "🤖 Assistant", Content: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Function.Name
+				entries = append(entries, exportEntry{
+					Kind:    "tool_call",
+					Title:   "🔧 " + tc.Function.Name,
+					Content: prettyJSON(tc.Function.Arguments),
+				})
+			}
+		case "tool":
+			name := toolNames[msg.ToolCallID]
+			if name == "" {
+				name = msg.ToolCallID
+			}
+			entries = append(entries, exportEntry{Kind: "tool_result", Title: "📤 " + name + " result", Content: msg.Content})
+		}
+	}
+	return entries
+}
+
+func prettyJSON(s string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(s), "", "  "); err != nil {
+		return s
+	}
+	return buf.String()
+}
+
+func renderMarkdown(title string, entries []exportEntry) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n_Exported %s_\n", title, time.Now().UTC().Format("2006-01-02 15:04 UTC"))
+	for _, e := range entries {
+		switch e.Kind {
+		case "tool_call":
+			fmt.Fprintf(&sb, "\n### %s\n\n%s\n", e.Title, fence(e.Content, "json"))
+		case "tool_result":
+			fmt.Fprintf(&sb, "\n### %s\n\n%s\n", e.Title, fence(e.Content, ""))
+		default:
+			fmt.Fprintf(&sb, "\n## %s\n\n%s\n", e.Title, strings.TrimSpace(e.Content))
+		}
+	}
+	return []byte(sb.String())
+}
+
+// fence wraps content in a code fence longer than any backtick run inside it.
+func fence(content, lang string) string {
+	ticks := "```"
+	for strings.Contains(content, ticks) {
+		ticks += "`"
+	}
+	return ticks + lang + "\n" + strings.TrimRight(content, "\n") + "\n" + ticks
+}
+
+var exportTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 860px; margin: 2em auto; padding: 0 1em; color: #222; }
+.entry { border-radius: 8px; padding: 0.6em 1em; margin: 0.8em 0; }
+.user { background: #e8f0fe; }
+.assistant { background: #f1f3f4; }
+.tool_call, .tool_result { background: #fff8e1; font-size: 0.9em; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1em; margin: 0 0 0.4em; }
+pre { white-space: pre-wrap; word-wrap: break-word; margin: 0; font-family: inherit; }
+.tool_call pre, .tool_result pre { font-family: ui-monospace, monospace; }
+.meta { color: #666; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">Exported {{.Exported}}</p>
+{{range .Entries}}<div class="entry {{.Kind}}">
+<h2>{{.Title}}</h2>
+<pre>{{.Content}}</pre>
+</div>
+{{end}}</body>
+</html>
+`))
+
+func renderHTML(title string, entries []exportEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	err := exportTemplate.Execute(&buf, map[string]interface{}{
+		"Title":    title,
+		"Exported": time.Now().UTC().Format("2006-01-02 15:04 UTC"),
+		"Entries":  entries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("render export: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SetIdleReset makes a chat's conversation start over when a message
+// arrives d or more after the previous one, so a question asked days later
+// does not carry the old context along. With summaries on, the old
+// conversation is summarized into memory first. Zero keeps conversations
+// until they are cleared.
+func (m *SessionManager) SetIdleReset(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.idleReset = d
+}
+
+// SetChatIdleReset overrides the idle reset for one chat. A negative d
+// turns it off there; zero goes back to the default.
+func (m *SessionManager) SetChatIdleReset(sessionKey string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d == 0 {
+		delete(m.idleResets, sessionKey)
+	} else {
+		m.idleResets[sessionKey] = d
+	}
+}
+
+// idleResetLocked returns the chat's idle reset, 0 when off, and where it
+// comes from.
+func (m *SessionManager) idleResetLocked(sessionKey string) (time.Duration, string) {
+	if d, ok := m.idleResets[sessionKey]; ok {
+		if d < 0 {
+			return 0, "set for this chat"
+		}
+		return d, "set for this chat"
+	}
+	return m.idleReset, "default"
+}
+
+// resetIfIdle drops the chat's conversation when it has been idle for its
+// idle reset, and returns for how long it was; 0 means it was kept.
+func (m *SessionManager) resetIfIdle(sessionKey string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, _ := m.idleResetLocked(sessionKey)
+	s, ok := m.sessions[sessionKey]
+	if d <= 0 || !ok || s.Turns() == 0 {
+		return 0
+	}
+	idle := time.Since(s.LastActive())
+	if idle < d {
+		return 0
+	}
+	m.dropSessionLocked(sessionKey)
+	return idle
+}
+
+// idleResetSetting handles /settings idle_reset: a number of hours, a
+// duration such as 90m, off, or default.
+func (m *SessionManager) idleResetSetting(sessionKey, value string) string {
+	switch value {
+	case "default":
+		m.SetChatIdleReset(sessionKey, 0)
+		return "✅ This chat follows the default idle reset again."
+	case "off":
+		m.SetChatIdleReset(sessionKey, -1)
+		return "✅ This chat's conversation is no longer reset when idle."
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		hours, perr := strconv.ParseFloat(value, 64)
+		if perr != nil {
+			return "❌ not a number of hours: " + value
+		}
+		d = time.Duration(hours * float64(time.Hour))
+	}
+	if d < time.Minute {
+		return "❌ the idle reset must be at least a minute"
+	}
+	m.SetChatIdleReset(sessionKey, d)
+	return fmt.Sprintf("✅ This chat's conversation now starts over after %s without messages.", formatIdle(d))
+}
+
+// formatIdle renders an idle time in its largest whole unit or two.
+func formatIdle(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	switch {
+	case days > 0 && hours > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case days > 0:
+		return fmt.Sprintf("%dd", days)
+	case hours > 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dm", int(d/time.Minute))
+}
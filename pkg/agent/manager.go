@@ -0,0 +1,1004 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/i18n"
+	"github.com/nene-agent/nene/pkg/memory"
+	"github.com/nene-agent/nene/pkg/model"
+	"github.com/nene-agent/nene/pkg/redact"
+	"github.com/nene-agent/nene/pkg/tasks"
+	"github.com/nene-agent/nene/pkg/tool"
+)
+
+const DefaultPersona = "default"
+
+// Persona bundles the settings a chat session is created with. Empty fields
+// fall back to the manager's default persona; an empty Tools list allows
+// every registered tool.
+type Persona struct {
+	Name         string
+	SystemPrompt string
+	Model        string
+	Tools        []string
+	Temperature  *float64
+	TopP         *float64
+}
+
+// SessionManager owns one Session per session key and dispatches inbound
+// messages to them, handling chat commands such as /persona itself.
+type SessionManager struct {
+	provider model.Provider
+	bus      *bus.MessageBus
+	toolMgr  *tool.Manager
+	defaults Persona
+
+	mu          sync.Mutex
+	sessions    map[string]*Session
+	personas    map[string]Persona
+	selected    map[string]string
+	models      map[string]string
+	sampling    map[string]Sampling
+	budget      *BudgetTracker
+	approvals   *tool.ApprovalStore
+	toolStats   *tool.StatsStore
+	dryRun      *tool.DryRun
+	tasks       *tasks.Store
+	checkpoints *CheckpointStore
+	redactor    *redact.Redactor
+	memory      memory.Memory
+	lastError   *TurnError
+	started     time.Time
+	askCache    askCache
+	workers     int
+
+	chatPrompts    map[string]string
+	workspaceDir   string
+	toolListPrompt bool
+	promptSources  []promptSource
+	summaries      memory.Memory
+	profiles       memory.Memory
+	profileTurns   map[string]int
+	sessionUsers   map[string][]string
+	timezones      map[string]*time.Location
+	timezone       *time.Location
+	turnTokens     int
+	pruneAfter     int
+	idleReset      time.Duration
+	idleResets     map[string]time.Duration
+	topics         map[string]string
+
+	// disabled is consulted by every session's tool view on each lookup,
+	// so it has its own lock.
+	disabledMu sync.RWMutex
+	disabled   []string
+}
+
+func NewSessionManager(provider model.Provider, b *bus.MessageBus, toolMgr *tool.Manager, defaults Persona) *SessionManager {
+	if defaults.Name == "" {
+		defaults.Name = DefaultPersona
+	}
+	if toolMgr == nil {
+		toolMgr = tool.NewManager()
+	}
+	return &SessionManager{
+		provider: provider,
+		bus:      b,
+		toolMgr:  toolMgr,
+		defaults: defaults,
+		sessions: make(map[string]*Session),
+		personas: map[string]Persona{defaults.Name: defaults},
+		selected: make(map[string]string),
+		models:   make(map[string]string),
+		sampling: make(map[string]Sampling),
+		started:  time.Now(),
+
+		chatPrompts:  make(map[string]string),
+		profileTurns: make(map[string]int),
+		sessionUsers: make(map[string][]string),
+		timezones:    make(map[string]*time.Location),
+		idleResets:   make(map[string]time.Duration),
+		topics:       make(map[string]string),
+	}
+}
+
+func (m *SessionManager) AddPersona(p Persona) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p.SystemPrompt == "" {
+		p.SystemPrompt = m.defaults.SystemPrompt
+	}
+	if p.Model == "" {
+		p.Model = m.defaults.Model
+	}
+	if p.Temperature == nil {
+		p.Temperature = m.defaults.Temperature
+	}
+	if p.TopP == nil {
+		p.TopP = m.defaults.TopP
+	}
+	m.personas[p.Name] = p
+}
+
+func (m *SessionManager) Personas() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.personas))
+	for name := range m.personas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PersonaFor returns the persona selected for a session key.
+func (m *SessionManager) PersonaFor(sessionKey string) Persona {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.personaLocked(sessionKey)
+}
+
+func (m *SessionManager) personaLocked(sessionKey string) Persona {
+	if name, ok := m.selected[sessionKey]; ok {
+		if p, ok := m.personas[name]; ok {
+			return p
+		}
+	}
+	return m.defaults
+}
+
+// SetPersona binds a session key to a persona. The existing conversation is
+// dropped so the next message starts with the persona's system prompt.
+func (m *SessionManager) SetPersona(sessionKey, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.personas[name]; !ok {
+		return fmt.Errorf("unknown persona: %s", name)
+	}
+	if name == m.defaults.Name {
+		delete(m.selected, sessionKey)
+	} else {
+		m.selected[sessionKey] = name
+	}
+	delete(m.models, sessionKey)
+	m.dropSessionLocked(sessionKey)
+	return nil
+}
+
+// ModelFor returns the model reference a session key sends requests with.
+func (m *SessionManager) ModelFor(sessionKey string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.modelLocked(sessionKey, m.personaLocked(sessionKey))
+}
+
+func (m *SessionManager) modelLocked(sessionKey string, p Persona) string {
+	if ref, ok := m.models[sessionKey]; ok {
+		return ref
+	}
+	return p.Model
+}
+
+// SetModel overrides the persona's model for a session key, keeping the
+// conversation. ref is usually "providerID/modelID".
+func (m *SessionManager) SetModel(sessionKey, ref string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.models[sessionKey] = ref
+	if s, ok := m.sessions[sessionKey]; ok {
+		s.SetModelName(ref)
+	}
+}
+
+// Session returns the session for a key, creating it from the selected
+// persona on first use.
+func (m *SessionManager) Session(sessionKey string) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[sessionKey]; ok {
+		return s
+	}
+
+	p := m.personaLocked(sessionKey)
+	sampling := m.samplingLocked(sessionKey, p)
+	tools := m.toolsFor(p)
+	s := NewSession(m.provider,
+		WithModelName(m.modelLocked(sessionKey, p)),
+		WithSystemPrompt(m.composePromptLocked(sessionKey, p, tools).Text()),
+		WithTemperature(sampling.Temperature),
+		WithTopP(sampling.TopP),
+		WithMessageBus(m.bus),
+		WithToolManager(tools),
+		WithUsageFunc(func(ref string, u model.Usage) { m.recordUsage(sessionKey, ref, u) }),
+		WithTurnTokenBudget(m.turnTokens),
+		WithToolResultPruning(m.pruneAfter),
+	)
+	m.sessions[sessionKey] = s
+	return s
+}
+
+// toolsFor returns a session's own tool set: a view of the shared tools,
+// limited to the persona's tools and without the disabled ones. Tools
+// registered on it are offered to that session only.
+func (m *SessionManager) toolsFor(p Persona) *tool.Manager {
+	toolMgr := m.toolMgr
+	if len(p.Tools) > 0 {
+		toolMgr = toolMgr.Subset(p.Tools...)
+	}
+	return toolMgr.Filter(func(name string) bool { return !m.isDisabled(name) })
+}
+
+func (m *SessionManager) isDisabled(name string) bool {
+	m.disabledMu.RLock()
+	defer m.disabledMu.RUnlock()
+	return tool.Matches(m.disabled, name)
+}
+
+// SetProvider switches every session, existing and future, to p.
+func (m *SessionManager) SetProvider(p model.Provider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.provider = p
+	for _, s := range m.sessions {
+		s.SetProvider(p)
+	}
+}
+
+// SetDefaults replaces the default persona and applies it to sessions that
+// use it, keeping their conversation history.
+func (m *SessionManager) SetDefaults(p Persona) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p.Name = m.defaults.Name
+	m.defaults = p
+	m.personas[p.Name] = p
+	m.applyPersonaLocked(p)
+}
+
+// SetPersonas replaces all non-default personas. Sessions bound to a
+// persona that no longer exists fall back to the default.
+func (m *SessionManager) SetPersonas(personas []Persona) {
+	m.mu.Lock()
+	m.personas = map[string]Persona{m.defaults.Name: m.defaults}
+	m.mu.Unlock()
+
+	for _, p := range personas {
+		m.AddPersona(p)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, name := range m.selected {
+		if _, ok := m.personas[name]; !ok {
+			delete(m.selected, key)
+		}
+	}
+	for _, p := range m.personas {
+		m.applyPersonaLocked(p)
+	}
+}
+
+// SetDisabledTools hides the named tools from every session.
+func (m *SessionManager) SetDisabledTools(names []string) {
+	m.disabledMu.Lock()
+	defer m.disabledMu.Unlock()
+	m.disabled = names
+}
+
+func (m *SessionManager) applyPersonaLocked(p Persona) {
+	for key, s := range m.sessions {
+		if m.personaLocked(key).Name != p.Name {
+			continue
+		}
+		tools := m.toolsFor(p)
+		s.SetSystemPrompt(m.composePromptLocked(key, p, tools).Text())
+		s.SetModelName(m.modelLocked(key, p))
+		sampling := m.samplingLocked(key, p)
+		s.SetTemperature(sampling.Temperature)
+		s.SetTopP(sampling.TopP)
+		s.SetToolManager(tools)
+	}
+}
+
+// SetBudget enables spend tracking and budget enforcement.
+func (m *SessionManager) SetBudget(b *BudgetTracker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.budget = b
+}
+
+// SetTurnTokenBudget caps the tokens each turn may consume, including its
+// subagents. A turn that reaches it stops and answers with what it has.
+// Zero means unlimited.
+func (m *SessionManager) SetTurnTokenBudget(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.turnTokens = n
+	for _, s := range m.sessions {
+		s.SetTurnTokenBudget(n)
+	}
+}
+
+// SetToolResultPruning replaces each tool result in requests with a
+// one-line stub once the model has answered after it n times, keeping long
+// tool-heavy conversations small. Zero keeps every result.
+func (m *SessionManager) SetToolResultPruning(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneAfter = n
+	for _, s := range m.sessions {
+		s.SetToolResultPruning(n)
+	}
+}
+
+// SetApprovalStore enables the /approvals command for reviewing and
+// forgetting remembered approvals.
+func (m *SessionManager) SetApprovalStore(s *tool.ApprovalStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.approvals = s
+}
+
+// SetToolStats enables the /stats command. s should be the one set on the
+// tool manager.
+func (m *SessionManager) SetToolStats(s *tool.StatsStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.toolStats = s
+}
+
+// SetDryRun enables the /dryrun command for switching dry-run mode per chat.
+// d should be the one set on the tool manager.
+func (m *SessionManager) SetDryRun(d *tool.DryRun) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dryRun = d
+}
+
+// SetTaskStore enables the /tasks command for reviewing a chat's tasks.
+func (m *SessionManager) SetTaskStore(s *tasks.Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tasks = s
+}
+
+// SetCheckpointStore enables /checkpoint and lets /rewind keep the
+// conversation it rewinds away from.
+func (m *SessionManager) SetCheckpointStore(s *CheckpointStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoints = s
+}
+
+// SetRedactor masks secrets in exported conversations.
+func (m *SessionManager) SetRedactor(r *redact.Redactor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.redactor = r
+}
+
+func (m *SessionManager) Budget() *BudgetTracker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.budget
+}
+
+// recordUsage prices usage from the model catalog. Models without a catalog
+// entry are not counted.
+func (m *SessionManager) recordUsage(sessionKey, ref string, u model.Usage) {
+	b := m.Budget()
+	if b == nil {
+		return
+	}
+	if info, ok := model.DefaultRegistry().LookupModel(ref); ok {
+		b.Record(sessionKey, info.CostUSD(u))
+	}
+}
+
+type SessionInfo struct {
+	Key      string `json:"key"`
+	Persona  string `json:"persona"`
+	Model    string `json:"model"`
+	Messages int    `json:"messages"`
+}
+
+func (m *SessionManager) Sessions() []SessionInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	infos := make([]SessionInfo, 0, len(m.sessions))
+	for key, s := range m.sessions {
+		p := m.personaLocked(key)
+		infos = append(infos, SessionInfo{
+			Key:      key,
+			Persona:  p.Name,
+			Model:    m.modelLocked(key, p),
+			Messages: len(s.Messages()),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos
+}
+
+// ClearSession drops the conversation for a session key and reports whether
+// one existed. The persona selection is kept.
+func (m *SessionManager) ClearSession(sessionKey string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dropSessionLocked(sessionKey)
+}
+
+func (m *SessionManager) HandleMessage(ctx context.Context, msg bus.InboundMessage) error {
+	if reply, media, ok := m.handleCommand(msg); ok {
+		m.reply(msg, reply, media...)
+		return nil
+	}
+
+	ctx = tool.WithRole(ctx, tool.Role(msg.Role))
+	ctx = tool.WithSender(ctx, msg.SenderID)
+	ctx = tool.WithMessageID(ctx, msg.Metadata["message_id"])
+	m.mu.Lock()
+	if m.noteTopicLocked(msg) {
+		m.refreshPromptLocked(msg.SessionKey)
+	}
+	m.mu.Unlock()
+	if key := ProfileKey(msg.Channel, msg.SenderID); key != "" {
+		m.mu.Lock()
+		if m.profiles != nil && m.noteSenderLocked(msg.SessionKey, key) {
+			m.refreshPromptLocked(msg.SessionKey)
+		}
+		m.mu.Unlock()
+	}
+	ctx = tool.WithLocation(ctx, m.TimezoneFor(msg.SessionKey))
+	idle := m.resetIfIdle(msg.SessionKey)
+	s := m.Session(msg.SessionKey)
+	if idle > 0 {
+		s.SetFootnote(i18n.T(localeOf(msg), "session.idle_reset", formatIdle(idle)))
+	}
+	if b := m.Budget(); b != nil {
+		if reason, notify := b.Exceeded(msg.SessionKey); reason != "" {
+			limits := b.Limits()
+			if notify {
+				m.notifyOwner(limits.OwnerChat, fmt.Sprintf("💸 Budget alert: %s (chat %s).", reason, msg.SessionKey))
+			}
+			if limits.DowngradeModel == "" {
+				m.reply(msg, i18n.T(localeOf(msg), "budget.paused", reason))
+				return nil
+			}
+			s.SetModelName(limits.DowngradeModel)
+			defer s.SetModelName(m.ModelFor(msg.SessionKey))
+		}
+	}
+	if err := s.ProcessMessage(ctx, msg); err != nil {
+		return err
+	}
+	m.learnProfile(msg)
+	return nil
+}
+
+// notifyOwner sends content to a "channel:chatID" session key.
+func (m *SessionManager) notifyOwner(ownerChat, content string) {
+	channel, chatID, ok := strings.Cut(ownerChat, ":")
+	if !ok || m.bus == nil {
+		return
+	}
+	m.bus.PublishOutbound(bus.OutboundMessage{
+		Channel: channel,
+		ChatID:  chatID,
+		Content: content,
+	})
+}
+
+// ownerCommands change settings that affect cost or other users, so regular
+// users may not run them.
+var ownerCommands = map[string]bool{
+	"/model":   true,
+	"/persona": true,
+	"/dryrun":  true,
+	"/prompt":  true,
+	"/status":  true,
+	"/stats":   true,
+}
+
+// handleCommand runs a chat command and returns the reply text and any
+// files to send with it.
+func (m *SessionManager) handleCommand(msg bus.InboundMessage) (string, []string, bool) {
+	fields := strings.Fields(msg.Content)
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+
+	// Telegram appends the bot name in groups: /persona@nene_bot
+	cmd, _, _ := strings.Cut(fields[0], "@")
+	locale := localeOf(msg)
+	if ownerCommands[cmd] && tool.Role(msg.Role) == tool.RoleUser {
+		return i18n.T(locale, "command.owner_only", cmd), nil, true
+	}
+	switch cmd {
+	case "/help":
+		return i18n.T(locale, "help.text"), nil, true
+	case "/persona":
+		return m.personaCommand(locale, msg.SessionKey, fields[1:]), nil, true
+	case "/model":
+		return m.modelCommand(locale, msg.SessionKey, fields[1:]), nil, true
+	case "/export":
+		reply, media := m.exportCommand(locale, msg.SessionKey, fields[1:])
+		return reply, media, true
+	case "/approvals":
+		return m.approvalsCommand(locale, msg.SessionKey, fields[1:]), nil, true
+	case "/dryrun":
+		return m.dryRunCommand(locale, msg.SessionKey, fields[1:]), nil, true
+	case "/tasks":
+		return m.tasksCommand(locale, msg.SessionKey, fields[1:]), nil, true
+	case "/checkpoint":
+		return m.checkpointCommand(locale, msg.SessionKey, fields[1:]), nil, true
+	case "/checkpoints":
+		return m.checkpointsCommand(locale, msg.SessionKey, fields[1:]), nil, true
+	case "/rewind":
+		return m.rewindCommand(locale, msg.SessionKey, fields[1:]), nil, true
+	case "/settings":
+		return m.settingsCommand(msg.SessionKey, fields[1:]), nil, true
+	case "/prompt":
+		return m.promptCommand(msg.SessionKey, msg.Content), nil, true
+	case "/profile":
+		return m.profileCommand(msg, fields[1:]), nil, true
+	case "/status":
+		return m.statusCommand(context.Background()), nil, true
+	case "/stats":
+		return m.statsCommand(locale, fields[1:]), nil, true
+	}
+	return "", nil, false
+}
+
+func (m *SessionManager) personaCommand(locale, sessionKey string, args []string) string {
+	if len(args) == 0 {
+		current := m.PersonaFor(sessionKey).Name
+		var sb strings.Builder
+		sb.WriteString(i18n.T(locale, "persona.list") + "\n")
+		for _, name := range m.Personas() {
+			marker := "  "
+			if name == current {
+				marker = "▶ "
+			}
+			sb.WriteString(marker + name + "\n")
+		}
+		sb.WriteString("\n" + i18n.T(locale, "persona.hint"))
+		return sb.String()
+	}
+
+	if err := m.SetPersona(sessionKey, args[0]); err != nil {
+		return "❌ " + err.Error()
+	}
+	return i18n.T(locale, "persona.switched", args[0])
+}
+
+func (m *SessionManager) modelCommand(locale, sessionKey string, args []string) string {
+	current := m.ModelFor(sessionKey)
+	if len(args) == 0 {
+		var sb strings.Builder
+		sb.WriteString(i18n.T(locale, "model.list", current) + "\n")
+		for _, ref := range model.DefaultRegistry().ModelRefs() {
+			marker := "  "
+			if ref == current {
+				marker = "▶ "
+			}
+			sb.WriteString(marker + ref + "\n")
+		}
+		sb.WriteString("\n" + i18n.T(locale, "model.hint"))
+		return sb.String()
+	}
+
+	ref := args[0]
+	providerID, _, ok := strings.Cut(ref, "/")
+	if !ok {
+		return i18n.T(locale, "model.form")
+	}
+	if _, ok := model.GetProvider(providerID); !ok {
+		return i18n.T(locale, "model.unknown_provider", providerID)
+	}
+	m.SetModel(sessionKey, ref)
+	return i18n.T(locale, "model.switched", ref)
+}
+
+// Export renders the conversation for a session key. ok is false when the
+// key has no session.
+func (m *SessionManager) Export(sessionKey string, format ExportFormat) (data []byte, ok bool, err error) {
+	m.mu.Lock()
+	s, ok := m.sessions[sessionKey]
+	redactor := m.redactor
+	m.mu.Unlock()
+	if !ok {
+		return nil, false, nil
+	}
+	data, err = s.Export("Conversation "+sessionKey, format)
+	if err == nil && redactor != nil {
+		data = []byte(redactor.String(string(data)))
+	}
+	return data, true, err
+}
+
+func (m *SessionManager) exportCommand(locale, sessionKey string, args []string) (string, []string) {
+	format := ""
+	if len(args) > 0 {
+		format = args[0]
+	}
+	f, err := ParseExportFormat(format)
+	if err != nil {
+		return "❌ " + err.Error(), nil
+	}
+
+	data, ok, err := m.Export(sessionKey, f)
+	if !ok {
+		return i18n.T(locale, "export.empty"), nil
+	}
+	if err != nil {
+		return "❌ " + err.Error(), nil
+	}
+
+	dir := filepath.Join(os.TempDir(), "nene-exports")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return i18n.T(locale, "export.failed", err), nil
+	}
+	name := strings.NewReplacer(":", "-", "/", "-").Replace(sessionKey)
+	path := filepath.Join(dir, fmt.Sprintf("conversation-%s-%s.%s", name, time.Now().Format("20060102-150405"), f))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return i18n.T(locale, "export.failed", err), nil
+	}
+	return i18n.T(locale, "export.caption"), []string{path}
+}
+
+func (m *SessionManager) approvalsCommand(locale, sessionKey string, args []string) string {
+	m.mu.Lock()
+	store := m.approvals
+	m.mu.Unlock()
+	if store == nil {
+		return i18n.T(locale, "approvals.off")
+	}
+	ctx := context.Background()
+
+	if len(args) == 0 {
+		rules, err := store.List(ctx, sessionKey)
+		if err != nil {
+			return "❌ " + err.Error()
+		}
+		if len(rules) == 0 {
+			return i18n.T(locale, "approvals.none")
+		}
+		var sb strings.Builder
+		sb.WriteString(i18n.T(locale, "approvals.list") + "\n")
+		for _, r := range rules {
+			fmt.Fprintf(&sb, "%d. %s %s\n", r.ID, r.Tool, r.Pattern)
+		}
+		sb.WriteString("\n" + i18n.T(locale, "approvals.hint"))
+		return sb.String()
+	}
+
+	if args[0] != "forget" || len(args) != 2 {
+		return i18n.T(locale, "approvals.usage")
+	}
+	var id int64
+	if args[1] != "all" {
+		if _, err := fmt.Sscanf(args[1], "%d", &id); err != nil || id <= 0 {
+			return i18n.T(locale, "approvals.bad_number", args[1])
+		}
+	}
+	n, err := store.Remove(ctx, sessionKey, id)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	if n == 0 {
+		return i18n.T(locale, "approvals.not_found")
+	}
+	return i18n.N(locale, "approvals.forgot", int(n))
+}
+
+// statsCommand shows the tools by number of calls, over all time or the
+// last given number of days.
+func (m *SessionManager) statsCommand(locale string, args []string) string {
+	m.mu.Lock()
+	store := m.toolStats
+	m.mu.Unlock()
+	if store == nil {
+		return i18n.T(locale, "stats.off")
+	}
+
+	var since time.Time
+	period := i18n.T(locale, "stats.all_time")
+	if len(args) > 0 {
+		days, err := strconv.Atoi(args[0])
+		if err != nil || days <= 0 || len(args) > 1 {
+			return i18n.T(locale, "stats.usage")
+		}
+		since = time.Now().AddDate(0, 0, -days)
+		period = i18n.N(locale, "stats.days", days)
+	}
+	stats, err := store.Stats(context.Background(), since)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	if len(stats) == 0 {
+		return i18n.T(locale, "stats.none", period)
+	}
+	var sb strings.Builder
+	sb.WriteString(i18n.T(locale, "stats.list", period) + "\n")
+	for i, st := range stats {
+		fmt.Fprintf(&sb, "%d. %s: %s\n", i+1, st.Tool, i18n.N(locale, "stats.line", st.Calls, st.Success*100, st.P50Millis, st.P90Millis, st.P99Millis))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func (m *SessionManager) dryRunCommand(locale, sessionKey string, args []string) string {
+	m.mu.Lock()
+	d := m.dryRun
+	m.mu.Unlock()
+	if d == nil {
+		return i18n.T(locale, "dryrun.off")
+	}
+
+	if len(args) == 0 {
+		on, override := d.Enabled(sessionKey)
+		key := "dryrun.status_off"
+		if on {
+			key = "dryrun.status_on"
+		}
+		source := i18n.T(locale, "dryrun.source_default")
+		if override {
+			source = i18n.T(locale, "dryrun.source_chat")
+		}
+		return i18n.T(locale, key, source)
+	}
+
+	switch args[0] {
+	case "on":
+		d.SetChat(sessionKey, true)
+		return i18n.T(locale, "dryrun.set_on")
+	case "off":
+		d.SetChat(sessionKey, false)
+		return i18n.T(locale, "dryrun.set_off")
+	case "default":
+		d.ResetChat(sessionKey)
+		on, _ := d.Enabled(sessionKey)
+		if on {
+			return i18n.T(locale, "dryrun.default_on")
+		}
+		return i18n.T(locale, "dryrun.default_off")
+	}
+	return i18n.T(locale, "dryrun.usage")
+}
+
+func (m *SessionManager) tasksCommand(locale, sessionKey string, args []string) string {
+	m.mu.Lock()
+	store := m.tasks
+	m.mu.Unlock()
+	if store == nil {
+		return i18n.T(locale, "tasks.off")
+	}
+	ctx := context.Background()
+
+	if len(args) == 2 && (args[0] == "done" || args[0] == "cancel") {
+		var id int64
+		if _, err := fmt.Sscanf(strings.TrimPrefix(args[1], "#"), "%d", &id); err != nil || id <= 0 {
+			return i18n.T(locale, "tasks.bad_number", args[1])
+		}
+		st := tasks.StatusDone
+		if args[0] == "cancel" {
+			st = tasks.StatusCancelled
+		}
+		t, err := store.Update(ctx, sessionKey, id, tasks.Update{Status: &st})
+		if errors.Is(err, tasks.ErrNotFound) {
+			return i18n.T(locale, "tasks.not_found", id)
+		}
+		if err != nil {
+			return "❌ " + err.Error()
+		}
+		return "✅ " + t.StringIn(m.TimezoneFor(sessionKey))
+	}
+
+	var statuses []tasks.Status
+	switch {
+	case len(args) == 0:
+		statuses = []tasks.Status{tasks.StatusTodo, tasks.StatusInProgress}
+	case len(args) == 1 && args[0] == "all":
+	default:
+		return i18n.T(locale, "tasks.usage")
+	}
+	list, err := store.List(ctx, sessionKey, statuses...)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	if len(list) == 0 && len(statuses) == 0 {
+		return i18n.T(locale, "tasks.none")
+	}
+	if len(list) == 0 {
+		return i18n.T(locale, "tasks.none_open")
+	}
+	now := time.Now()
+	loc := m.TimezoneFor(sessionKey)
+	var sb strings.Builder
+	sb.WriteString(i18n.T(locale, "tasks.list") + "\n")
+	for _, t := range list {
+		marker := "  "
+		if t.Overdue(now) {
+			marker = "⚠️ "
+		}
+		sb.WriteString(marker + t.StringIn(loc) + "\n")
+	}
+	sb.WriteString("\n" + i18n.T(locale, "tasks.hint"))
+	return sb.String()
+}
+
+func (m *SessionManager) checkpointStore() *CheckpointStore {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.checkpoints
+}
+
+// existingSession returns the session for a key without creating one.
+func (m *SessionManager) existingSession(sessionKey string) (*Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[sessionKey]
+	return s, ok
+}
+
+func (m *SessionManager) checkpointCommand(locale, sessionKey string, args []string) string {
+	store := m.checkpointStore()
+	if store == nil {
+		return i18n.T(locale, "checkpoint.off")
+	}
+	name := strings.Join(args, " ")
+	if strings.HasPrefix(name, "#") {
+		return i18n.T(locale, "checkpoint.hash_name")
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return i18n.T(locale, "checkpoint.number_name")
+	}
+	s, ok := m.existingSession(sessionKey)
+	if !ok || s.Turns() == 0 {
+		return i18n.T(locale, "checkpoint.empty")
+	}
+	cp, err := store.Save(sessionKey, name, false, s.Messages())
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	return i18n.T(locale, "checkpoint.saved", cp, cp.ID)
+}
+
+func (m *SessionManager) checkpointsCommand(locale, sessionKey string, args []string) string {
+	store := m.checkpointStore()
+	if store == nil {
+		return i18n.T(locale, "checkpoint.off")
+	}
+	if len(args) >= 2 && args[0] == "delete" {
+		ok, err := store.Delete(sessionKey, strings.Join(args[1:], " "))
+		if err != nil {
+			return "❌ " + err.Error()
+		}
+		if !ok {
+			return i18n.T(locale, "checkpoint.not_found")
+		}
+		return i18n.T(locale, "checkpoint.deleted")
+	}
+	if len(args) > 0 {
+		return i18n.T(locale, "checkpoints.usage")
+	}
+
+	list, err := store.List(sessionKey)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	if len(list) == 0 {
+		return i18n.T(locale, "checkpoints.none")
+	}
+	var sb strings.Builder
+	sb.WriteString(i18n.T(locale, "checkpoints.list") + "\n")
+	for _, cp := range list {
+		sb.WriteString("  " + cp.String() + "\n")
+	}
+	sb.WriteString("\n" + i18n.T(locale, "checkpoints.hint"))
+	return sb.String()
+}
+
+// rewindCommand undoes turns or restores a checkpoint. Unless the
+// conversation is empty, it is first saved as an automatic checkpoint, so a
+// rewind can itself be undone.
+func (m *SessionManager) rewindCommand(locale, sessionKey string, args []string) string {
+	store := m.checkpointStore()
+	ref := strings.Join(args, " ")
+	turns, err := strconv.Atoi(ref)
+	if ref == "" {
+		turns, err = 1, nil
+	}
+
+	var target *Checkpoint
+	if err != nil {
+		if store == nil {
+			return i18n.T(locale, "rewind.no_checkpoints")
+		}
+		cp, err := store.Get(sessionKey, ref)
+		if errors.Is(err, ErrCheckpointNotFound) {
+			return i18n.T(locale, "rewind.not_found", ref)
+		}
+		if err != nil {
+			return "❌ " + err.Error()
+		}
+		target = &cp
+	}
+
+	s, ok := m.existingSession(sessionKey)
+	if target == nil && (!ok || s.Turns() == 0) {
+		return i18n.T(locale, "rewind.empty")
+	}
+	if target == nil && turns < 1 {
+		return i18n.T(locale, "rewind.too_few")
+	}
+	if target == nil && turns > s.Turns() {
+		return i18n.N(locale, "rewind.too_many", s.Turns())
+	}
+
+	saved := ""
+	if store != nil && ok && s.Turns() > 0 {
+		cp, err := store.Save(sessionKey, "", true, s.Messages())
+		if err != nil {
+			return "❌ " + err.Error()
+		}
+		saved = "\n\n" + i18n.T(locale, "rewind.saved", cp.ID, cp.ID)
+	}
+
+	if target != nil {
+		m.Session(sessionKey).Restore(target.Messages)
+		return i18n.T(locale, "rewind.restored", target) + saved
+	}
+	left, err := s.Rewind(turns)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	return i18n.N(locale, "rewind.undone", turns, left) + saved
+}
+
+// localeOf returns the locale the channel chose for the chat of msg.
+func localeOf(msg bus.InboundMessage) string {
+	return msg.Metadata["locale"]
+}
+
+// notice sends content to the chat of msg without ending a turn, for status
+// messages sent while a turn is running.
+func (m *SessionManager) notice(msg bus.InboundMessage, content string) {
+	if m.bus == nil {
+		return
+	}
+	m.bus.PublishOutbound(bus.OutboundMessage{
+		Channel: msg.Channel,
+		ChatID:  msg.ChatID,
+		Content: content,
+	})
+}
+
+func (m *SessionManager) reply(msg bus.InboundMessage, content string, media ...string) {
+	if m.bus == nil {
+		return
+	}
+	m.bus.PublishOutbound(bus.OutboundMessage{
+		Channel: msg.Channel,
+		ChatID:  msg.ChatID,
+		Content: content,
+		Media:   media,
+	})
+	// Commands end with a finish event like a normal turn so channels can
+	// release per-turn state such as rate limit slots.
+	m.bus.PublishStream(bus.StreamMessage{
+		Channel:    msg.Channel,
+		ChatID:     msg.ChatID,
+		SessionKey: msg.SessionKey,
+		Type:       bus.StreamEventFinish,
+	})
+}
@@ -0,0 +1,355 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/memory"
+	"github.com/nene-agent/nene/pkg/model"
+)
+
+const (
+	// profileEvery is how many turns of a user pass between profile updates.
+	profileEvery = 5
+	// profileWindow is how many recent messages a profile update reads.
+	profileWindow   = 10
+	profileTimeout  = time.Minute
+	profileMaxFacts = 20
+	// sessionUsersMax bounds the profiles added to a group chat's prompt.
+	sessionUsersMax = 5
+)
+
+// CategoryProfile is the memory category user profiles are stored in.
+const CategoryProfile memory.Category = "profile"
+
+// Profile is what the agent has learned about a user.
+type Profile struct {
+	Language   string   `json:"language,omitempty"`
+	Timezone   string   `json:"timezone,omitempty"`
+	Formatting string   `json:"formatting,omitempty"`
+	Facts      []string `json:"facts,omitempty"`
+}
+
+func (p Profile) empty() bool {
+	return p.Language == "" && p.Timezone == "" && p.Formatting == "" && len(p.Facts) == 0
+}
+
+func (p Profile) String() string {
+	var sb strings.Builder
+	if p.Language != "" {
+		fmt.Fprintf(&sb, "- Preferred language: %s\n", p.Language)
+	}
+	if p.Timezone != "" {
+		fmt.Fprintf(&sb, "- Timezone: %s\n", p.Timezone)
+	}
+	if p.Formatting != "" {
+		fmt.Fprintf(&sb, "- Formatting: %s\n", p.Formatting)
+	}
+	for i, f := range p.Facts {
+		fmt.Fprintf(&sb, "- Fact %d: %s\n", i+1, f)
+	}
+	return sb.String()
+}
+
+var profileSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"language": {"type": "string", "description": "The language the user prefers to be answered in, empty if unknown"},
+		"timezone": {"type": "string", "description": "IANA timezone such as Europe/Berlin, empty if unknown"},
+		"formatting": {"type": "string", "description": "How the user likes answers formatted, empty if unknown"},
+		"facts": {"type": "array", "items": {"type": "string"}, "description": "Short lasting facts about the user"}
+	},
+	"required": ["language", "timezone", "formatting", "facts"],
+	"additionalProperties": false
+}`)
+
+const profilePrompt = `You maintain a profile of a user from their conversations with an assistant. Given the current profile and recent messages, return the updated profile as JSON. Keep what is still true, change what the user corrected, and add only lasting facts the user stated about themselves, such as their job, location, or preferences; ignore one-off requests. Keep at most %d facts, each one short sentence.`
+
+// ProfileKey is the memory key of a user's profile. Sender IDs of the form
+// "id|username" are keyed by the ID, so renames keep the profile.
+func ProfileKey(channel, senderID string) string {
+	id, _, _ := strings.Cut(senderID, "|")
+	if id == "" {
+		return ""
+	}
+	return "profile:" + channel + ":" + id
+}
+
+// SetProfileMemory makes the manager learn a profile of each user from
+// their conversations, store it in mem, and add it to the system prompt of
+// the chats they write in.
+func (m *SessionManager) SetProfileMemory(mem memory.Memory) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.profiles = mem
+}
+
+// Profile returns the profile stored under key.
+func (m *SessionManager) Profile(ctx context.Context, key string) (Profile, error) {
+	m.mu.Lock()
+	mem := m.profiles
+	m.mu.Unlock()
+	if mem == nil {
+		return Profile{}, fmt.Errorf("user profiles are not enabled")
+	}
+	return loadProfile(ctx, mem, key)
+}
+
+// SetProfile replaces the profile stored under key and applies it to the
+// chats the user writes in.
+func (m *SessionManager) SetProfile(ctx context.Context, key string, p Profile) error {
+	m.mu.Lock()
+	mem := m.profiles
+	m.mu.Unlock()
+	if mem == nil {
+		return fmt.Errorf("user profiles are not enabled")
+	}
+	if err := storeProfile(ctx, mem, key, p); err != nil {
+		return err
+	}
+	m.refreshProfile(key)
+	return nil
+}
+
+func loadProfile(ctx context.Context, mem memory.Memory, key string) (Profile, error) {
+	var p Profile
+	e, err := mem.Get(ctx, key)
+	if err != nil || e == nil {
+		return p, err
+	}
+	if err := json.Unmarshal([]byte(e.Content), &p); err != nil {
+		return p, fmt.Errorf("decode profile %s: %w", key, err)
+	}
+	return p, nil
+}
+
+func storeProfile(ctx context.Context, mem memory.Memory, key string, p Profile) error {
+	if p.empty() {
+		_, err := mem.Forget(ctx, key)
+		return err
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = mem.Store(ctx, &memory.StoreRequest{
+		Key:      key,
+		Content:  string(data),
+		Category: CategoryProfile,
+	})
+	return err
+}
+
+// noteSenderLocked records that a user writes in a session, so their
+// profile is part of its prompt. It reports whether the user is new there.
+func (m *SessionManager) noteSenderLocked(sessionKey, profileKey string) bool {
+	users := m.sessionUsers[sessionKey]
+	for i, u := range users {
+		if u == profileKey {
+			// Move to the end so the least recent writer is dropped first.
+			users = append(append(users[:i:i], users[i+1:]...), u)
+			m.sessionUsers[sessionKey] = users
+			return false
+		}
+	}
+	users = append(users, profileKey)
+	if len(users) > sessionUsersMax {
+		users = users[len(users)-sessionUsersMax:]
+	}
+	m.sessionUsers[sessionKey] = users
+	return true
+}
+
+// profilesLocked is the prompt section with the profiles of a session's
+// users.
+func (m *SessionManager) profilesLocked(sessionKey string) string {
+	if m.profiles == nil {
+		return ""
+	}
+	users := m.sessionUsers[sessionKey]
+	var sb strings.Builder
+	for _, key := range users {
+		p, err := loadProfile(context.Background(), m.profiles, key)
+		if err != nil {
+			fmt.Printf("Failed to load %s: %v\n", key, err)
+			continue
+		}
+		if p.empty() {
+			continue
+		}
+		if sb.Len() == 0 {
+			sb.WriteString("## User Profile\n")
+		}
+		if len(users) > 1 {
+			fmt.Fprintf(&sb, "User %s:\n", strings.TrimPrefix(key, "profile:"))
+		}
+		sb.WriteString(p.String())
+	}
+	return sb.String()
+}
+
+// refreshPromptLocked recomposes the prompt of a running session.
+func (m *SessionManager) refreshPromptLocked(sessionKey string) {
+	if s, ok := m.sessions[sessionKey]; ok {
+		p := m.personaLocked(sessionKey)
+		s.SetSystemPrompt(m.composePromptLocked(sessionKey, p, m.toolsFor(p)).Text())
+	}
+}
+
+// refreshProfile recomposes the prompts of the sessions a user writes in.
+func (m *SessionManager) refreshProfile(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for sessionKey, users := range m.sessionUsers {
+		for _, u := range users {
+			if u == key {
+				m.refreshPromptLocked(sessionKey)
+				break
+			}
+		}
+	}
+}
+
+// learnProfile counts a finished turn and, every profileEvery turns of the
+// sender, updates their profile in the background.
+func (m *SessionManager) learnProfile(msg bus.InboundMessage) {
+	key := ProfileKey(msg.Channel, msg.SenderID)
+	m.mu.Lock()
+	mem := m.profiles
+	if mem == nil || key == "" {
+		m.mu.Unlock()
+		return
+	}
+	m.profileTurns[key]++
+	due := m.profileTurns[key]%profileEvery == 0
+	s := m.sessions[msg.SessionKey]
+	m.mu.Unlock()
+
+	if due && s != nil {
+		go m.updateProfile(mem, key, msg.SessionKey, s)
+	}
+}
+
+func (m *SessionManager) updateProfile(mem memory.Memory, key, sessionKey string, s *Session) {
+	if b := m.Budget(); b != nil {
+		if reason, _ := b.Exceeded(sessionKey); reason != "" {
+			return
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), profileTimeout)
+	defer cancel()
+
+	current, err := loadProfile(ctx, mem, key)
+	if err != nil {
+		fmt.Printf("Failed to load %s: %v\n", key, err)
+		return
+	}
+	currentJSON, _ := json.Marshal(current)
+
+	var lines []string
+	for _, e := range exportEntries(s.Messages()) {
+		if e.Kind == "user" || e.Kind == "assistant" {
+			lines = append(lines, e.Kind+": "+e.Content)
+		}
+	}
+	if len(lines) > profileWindow {
+		lines = lines[len(lines)-profileWindow:]
+	}
+
+	provider, modelRef := s.providerAndModel()
+	req := &model.Request{
+		Model: modelRef,
+		Messages: []model.Message{
+			{Role: "system", Content: fmt.Sprintf(profilePrompt, profileMaxFacts)},
+			{Role: "user", Content: fmt.Sprintf("Current profile:\n%s\n\nRecent messages:\n%s", currentJSON, strings.Join(lines, "\n\n"))},
+		},
+	}
+	var updated Profile
+	usage, err := CompleteJSON(ctx, provider, req, "user_profile", profileSchema, &updated)
+	m.recordUsage(sessionKey, modelRef, usage)
+	if err != nil {
+		fmt.Printf("Failed to update %s: %v\n", key, err)
+		return
+	}
+	if updated.Timezone != "" {
+		if _, err := time.LoadLocation(updated.Timezone); err != nil {
+			updated.Timezone = current.Timezone
+		}
+	}
+	if len(updated.Facts) > profileMaxFacts {
+		updated.Facts = updated.Facts[len(updated.Facts)-profileMaxFacts:]
+	}
+	if err := storeProfile(ctx, mem, key, updated); err != nil {
+		fmt.Printf("Failed to store %s: %v\n", key, err)
+		return
+	}
+	m.refreshProfile(key)
+}
+
+func (m *SessionManager) profileCommand(msg bus.InboundMessage, args []string) string {
+	key := ProfileKey(msg.Channel, msg.SenderID)
+	if key == "" {
+		return "Profiles need a known sender."
+	}
+	ctx := context.Background()
+	p, err := m.Profile(ctx, key)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	if len(args) == 0 {
+		if p.empty() {
+			return "Nothing is known about you yet."
+		}
+		return "Your profile:\n" + p.String() + "\nCorrect it with /profile set language|timezone|formatting <value>, /profile add <fact>, /profile forget <fact number>, or /profile clear."
+	}
+
+	switch args[0] {
+	case "set":
+		if len(args) < 2 {
+			return "Usage: /profile set language|timezone|formatting <value>"
+		}
+		value := strings.Join(args[2:], " ")
+		switch args[1] {
+		case "language":
+			p.Language = value
+		case "timezone":
+			if value != "" {
+				if _, err := time.LoadLocation(value); err != nil {
+					return fmt.Sprintf("❌ Unknown timezone %q. Use a name such as Europe/Berlin.", value)
+				}
+			}
+			p.Timezone = value
+		case "formatting":
+			p.Formatting = value
+		default:
+			return "Usage: /profile set language|timezone|formatting <value>"
+		}
+	case "add":
+		if len(args) < 2 {
+			return "Usage: /profile add <fact>"
+		}
+		p.Facts = append(p.Facts, strings.Join(args[1:], " "))
+	case "forget":
+		n, err := strconv.Atoi(strings.Join(args[1:], ""))
+		if err != nil || n < 1 || n > len(p.Facts) {
+			return "Usage: /profile forget <fact number>"
+		}
+		p.Facts = append(p.Facts[:n-1], p.Facts[n:]...)
+	case "clear":
+		p = Profile{}
+	default:
+		return "Usage: /profile [set language|timezone|formatting <value>|add <fact>|forget <n>|clear]"
+	}
+	if err := m.SetProfile(ctx, key, p); err != nil {
+		return "❌ " + err.Error()
+	}
+	if p.empty() {
+		return "🗑 Your profile is cleared."
+	}
+	return "✅ Profile updated:\n" + p.String()
+}
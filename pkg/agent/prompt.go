@@ -0,0 +1,216 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nene-agent/nene/pkg/memory"
+	"github.com/nene-agent/nene/pkg/tool"
+)
+
+// WorkspacePromptFiles are looked for, in order, in the workspace directory;
+// the first one found is added to every system prompt.
+var WorkspacePromptFiles = []string{"NENE.md", "AGENTS.md", "agents.md"}
+
+// PromptSection is one layer of a composed system prompt. Source says where
+// it came from: "persona", "workspace", "chat", "profile", "summary",
+// "time", "tools", or the name a section was added under.
+type PromptSection struct {
+	Source  string `json:"source"`
+	Content string `json:"content"`
+}
+
+// ComposedPrompt is a session's system prompt with its layers in the order
+// they are sent.
+type ComposedPrompt struct {
+	Sections []PromptSection `json:"sections"`
+}
+
+// Text joins the sections into the prompt sent to the model.
+func (c ComposedPrompt) Text() string {
+	parts := make([]string, 0, len(c.Sections))
+	for _, s := range c.Sections {
+		parts = append(parts, s.Content)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// PromptFunc generates a section of a session's system prompt when the
+// prompt is composed, or returns "" to add nothing. It runs with the
+// manager locked, so it must not call back into the SessionManager.
+type PromptFunc func(sessionKey string) string
+
+type promptSource struct {
+	name string
+	fn   PromptFunc
+}
+
+// SetWorkspaceDir sets the directory searched for WorkspacePromptFiles. The
+// file is read whenever a prompt is composed, so edits apply to new
+// conversations without a restart.
+func (m *SessionManager) SetWorkspaceDir(dir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.workspaceDir = dir
+}
+
+// SetToolListPrompt adds a list of the session's tools to its prompt.
+func (m *SessionManager) SetToolListPrompt(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.toolListPrompt = enabled
+}
+
+// AddPromptSection adds a generated section to the end of every prompt.
+func (m *SessionManager) AddPromptSection(name string, fn PromptFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.promptSources = append(m.promptSources, promptSource{name: name, fn: fn})
+}
+
+// ChatPrompt returns the extra instructions set for a session key.
+func (m *SessionManager) ChatPrompt(sessionKey string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.chatPrompts[sessionKey]
+}
+
+// SetChatPrompt sets instructions added to a session key's prompt after the
+// persona's and the workspace's, keeping the conversation. An empty prompt
+// removes them.
+func (m *SessionManager) SetChatPrompt(sessionKey, prompt string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		delete(m.chatPrompts, sessionKey)
+	} else {
+		m.chatPrompts[sessionKey] = prompt
+	}
+	m.refreshPromptLocked(sessionKey)
+}
+
+// SystemPromptFor composes the system prompt a new conversation under
+// sessionKey would start with.
+func (m *SessionManager) SystemPromptFor(sessionKey string) ComposedPrompt {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.personaLocked(sessionKey)
+	return m.composePromptLocked(sessionKey, p, m.toolsFor(p))
+}
+
+func (m *SessionManager) composePromptLocked(sessionKey string, p Persona, tools *tool.Manager) ComposedPrompt {
+	var c ComposedPrompt
+	add := func(source, content string) {
+		if content = strings.TrimSpace(content); content != "" {
+			c.Sections = append(c.Sections, PromptSection{Source: source, Content: content})
+		}
+	}
+
+	add("persona", p.SystemPrompt)
+	add("workspace", readWorkspacePrompt(m.workspaceDir))
+	add("chat", m.chatPrompts[sessionKey])
+	add("topic", m.topicPromptLocked(sessionKey))
+	add("profile", m.profilesLocked(sessionKey))
+	add("summary", m.lastSummaryLocked(sessionKey))
+	add("time", m.timePromptLocked(sessionKey))
+	if m.toolListPrompt {
+		add("tools", toolListPrompt(tools))
+	}
+	for _, src := range m.promptSources {
+		add(src.name, src.fn(sessionKey))
+	}
+	return c
+}
+
+func readWorkspacePrompt(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	for _, name := range WorkspacePromptFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(data)
+		}
+		if !os.IsNotExist(err) {
+			fmt.Printf("Failed to read %s: %v\n", name, err)
+		}
+	}
+	return ""
+}
+
+func toolListPrompt(tools *tool.Manager) string {
+	if tools == nil {
+		return ""
+	}
+	infos := tools.List()
+	if len(infos) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## Available Tools\n")
+	for _, info := range infos {
+		desc, _, _ := strings.Cut(info.Description, "\n")
+		fmt.Fprintf(&sb, "- %s: %s\n", tool.WireName(info.Name), desc)
+	}
+	return sb.String()
+}
+
+// MemoryDigest returns a PromptFunc listing up to limit core memories, so
+// the model knows the most important facts without recalling them first.
+func MemoryDigest(mem memory.Memory, limit int) PromptFunc {
+	return func(string) string {
+		entries, err := mem.List(context.Background(), &memory.ListRequest{
+			Category: memory.CategoryCore,
+			Limit:    limit,
+		})
+		if err != nil {
+			fmt.Printf("Failed to list memories for the prompt: %v\n", err)
+			return ""
+		}
+		if len(entries) == 0 {
+			return ""
+		}
+		var sb strings.Builder
+		sb.WriteString("## Remembered Facts\n")
+		for _, e := range entries {
+			fmt.Fprintf(&sb, "- %s: %s\n", e.Key, e.Content)
+		}
+		return sb.String()
+	}
+}
+
+// promptCommand handles /prompt. content is the whole message, so the text
+// after "set" keeps its line breaks.
+func (m *SessionManager) promptCommand(sessionKey, content string) string {
+	fields := strings.Fields(content)
+	args := fields[1:]
+	if len(args) == 0 {
+		c := m.SystemPromptFor(sessionKey)
+		var sb strings.Builder
+		for _, s := range c.Sections {
+			fmt.Fprintf(&sb, "[%s, %d chars]\n", s.Source, len(s.Content))
+		}
+		sb.WriteString("\nUse /prompt show for the full text, /prompt set <text> to add instructions for this chat, /prompt clear to remove them.")
+		return sb.String()
+	}
+	switch args[0] {
+	case "show":
+		return m.SystemPromptFor(sessionKey).Text()
+	case "set":
+		if len(args) < 2 {
+			return "Usage: /prompt set <instructions>"
+		}
+		text := strings.TrimPrefix(strings.TrimSpace(content), fields[0])
+		text = strings.TrimPrefix(strings.TrimSpace(text), "set")
+		m.SetChatPrompt(sessionKey, text)
+		return "Instructions for this chat updated."
+	case "clear":
+		m.SetChatPrompt(sessionKey, "")
+		return "Instructions for this chat removed."
+	}
+	return "Usage: /prompt [show|set <instructions>|clear]"
+}
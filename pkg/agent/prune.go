@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/nene-agent/nene/pkg/model"
+)
+
+// pruneStubMin is the size below which a tool result is kept, since a stub
+// would save next to nothing.
+const pruneStubMin = 200
+
+// pruneToolResults returns msgs with the results of tool calls that at
+// least `after` assistant messages have followed replaced by a one-line
+// stub. User and assistant text is kept. msgs itself is not changed, and
+// zero returns it as is.
+func pruneToolResults(msgs []model.Message, after int) []model.Message {
+	if after <= 0 {
+		return msgs
+	}
+	var out []model.Message
+	names := make(map[string]string)
+	seen := 0
+	for i := len(msgs) - 1; i >= 0; i-- {
+		switch msg := msgs[i]; msg.Role {
+		case "assistant":
+			seen++
+		case "tool":
+			if seen < after || len(msg.Content) < pruneStubMin {
+				continue
+			}
+			if out == nil {
+				out = make([]model.Message, len(msgs))
+				copy(out, msgs)
+				for _, m := range msgs {
+					for _, tc := range m.ToolCalls {
+						names[tc.ID] = tc.Function.Name
+					}
+				}
+			}
+			out[i].Content = pruneStub(names[msg.ToolCallID], msg.Content)
+		}
+	}
+	if out == nil {
+		return msgs
+	}
+	return out
+}
+
+func pruneStub(toolName, content string) string {
+	if toolName == "" {
+		toolName = "tool"
+	}
+	return fmt.Sprintf("[%s output omitted, %s]", toolName, formatBytes(len(content)))
+}
+
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nene-agent/nene/pkg/model"
+)
+
+// Inbound message metadata keys that set sampling for that message's turn
+// only, e.g. from an API request.
+const (
+	MetadataTemperature = "temperature"
+	MetadataTopP        = "top_p"
+)
+
+// Sampling holds sampling parameters. Nil fields are left to the next
+// level: chat settings, then the persona, then the provider and model
+// defaults.
+type Sampling struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+}
+
+// ValidateSampling checks a value for the named parameter, "temperature"
+// (0 to 2) or "top_p" (0 to 1).
+func ValidateSampling(name string, v float64) error {
+	limit := 2.0
+	if name == MetadataTopP {
+		limit = 1
+	}
+	if v < 0 || v > limit {
+		return fmt.Errorf("%s must be between 0 and %g", name, limit)
+	}
+	return nil
+}
+
+// samplingFromMetadata reads per-message sampling overrides. Invalid values
+// are ignored.
+func samplingFromMetadata(md map[string]string) Sampling {
+	var s Sampling
+	for name, dst := range map[string]**float64{MetadataTemperature: &s.Temperature, MetadataTopP: &s.TopP} {
+		raw, ok := md[name]
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err == nil {
+			err = ValidateSampling(name, v)
+		}
+		if err != nil {
+			fmt.Printf("Ignoring %s metadata %q: %v\n", name, raw, err)
+			continue
+		}
+		*dst = &v
+	}
+	return s
+}
+
+// SamplingFor returns the sampling parameters a session key sends requests
+// with: the chat's settings over the persona's.
+func (m *SessionManager) SamplingFor(sessionKey string) Sampling {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.samplingLocked(sessionKey, m.personaLocked(sessionKey))
+}
+
+func (m *SessionManager) samplingLocked(sessionKey string, p Persona) Sampling {
+	s := Sampling{Temperature: p.Temperature, TopP: p.TopP}
+	chat := m.sampling[sessionKey]
+	if chat.Temperature != nil {
+		s.Temperature = chat.Temperature
+	}
+	if chat.TopP != nil {
+		s.TopP = chat.TopP
+	}
+	return s
+}
+
+// SetSampling replaces a chat's sampling settings, keeping the
+// conversation. Nil fields fall back to the persona.
+func (m *SessionManager) SetSampling(sessionKey string, chat Sampling) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if chat.Temperature == nil && chat.TopP == nil {
+		delete(m.sampling, sessionKey)
+	} else {
+		m.sampling[sessionKey] = chat
+	}
+	if s, ok := m.sessions[sessionKey]; ok {
+		eff := m.samplingLocked(sessionKey, m.personaLocked(sessionKey))
+		s.SetTemperature(eff.Temperature)
+		s.SetTopP(eff.TopP)
+	}
+}
+
+func (m *SessionManager) settingsCommand(sessionKey string, args []string) string {
+	m.mu.Lock()
+	chat := m.sampling[sessionKey]
+	p := m.personaLocked(sessionKey)
+	m.mu.Unlock()
+
+	switch {
+	case len(args) == 0:
+		return m.describeSampling(sessionKey, chat, p)
+	case len(args) == 2 && args[0] == "timezone":
+		name := args[1]
+		if name == "default" {
+			name = ""
+		}
+		if err := m.SetTimezone(sessionKey, name); err != nil {
+			return "❌ " + err.Error()
+		}
+		loc := m.TimezoneFor(sessionKey)
+		return fmt.Sprintf("✅ This chat's timezone is now %s.", loc)
+	case len(args) == 2 && args[0] == "idle_reset":
+		return m.idleResetSetting(sessionKey, args[1])
+	case len(args) == 1 && args[0] == "reset":
+		m.SetSampling(sessionKey, Sampling{})
+		return "✅ Sampling settings reset to the persona's."
+	case len(args) == 2 && (args[0] == MetadataTemperature || args[0] == MetadataTopP):
+	default:
+		return "Usage: /settings, /settings temperature <value|default>, /settings top_p <value|default>, /settings timezone <name|default>, /settings idle_reset <hours|off|default>, or /settings reset"
+	}
+
+	name := args[0]
+	dst := &chat.Temperature
+	if name == MetadataTopP {
+		dst = &chat.TopP
+	}
+	if args[1] == "default" {
+		*dst = nil
+		m.SetSampling(sessionKey, chat)
+		return fmt.Sprintf("✅ %s follows the persona again.", name)
+	}
+	v, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return "❌ not a number: " + args[1]
+	}
+	if err := ValidateSampling(name, v); err != nil {
+		return "❌ " + err.Error()
+	}
+	*dst = &v
+	m.SetSampling(sessionKey, chat)
+
+	reply := fmt.Sprintf("✅ %s set to %g for this chat.", name, v)
+	if note := m.samplingNote(sessionKey); note != "" {
+		reply += "\n\n" + note
+	}
+	return reply
+}
+
+func (m *SessionManager) describeSampling(sessionKey string, chat Sampling, p Persona) string {
+	var sb strings.Builder
+	sb.WriteString("Sampling for this chat:\n")
+	for _, row := range []struct {
+		name          string
+		chat, persona *float64
+	}{
+		{MetadataTemperature, chat.Temperature, p.Temperature},
+		{MetadataTopP, chat.TopP, p.TopP},
+	} {
+		switch {
+		case row.chat != nil:
+			fmt.Fprintf(&sb, "  %s: %g (set for this chat)\n", row.name, *row.chat)
+		case row.persona != nil:
+			fmt.Fprintf(&sb, "  %s: %g (persona %s)\n", row.name, *row.persona, p.Name)
+		default:
+			fmt.Fprintf(&sb, "  %s: provider default\n", row.name)
+		}
+	}
+	m.mu.Lock()
+	loc, source := m.timezoneLocked(sessionKey)
+	m.mu.Unlock()
+	fmt.Fprintf(&sb, "Timezone: %s (%s)\n", loc, source)
+	m.mu.Lock()
+	idle, source := m.idleResetLocked(sessionKey)
+	m.mu.Unlock()
+	if idle > 0 {
+		fmt.Fprintf(&sb, "Idle reset: after %s (%s)\n", formatIdle(idle), source)
+	} else {
+		fmt.Fprintf(&sb, "Idle reset: off (%s)\n", source)
+	}
+	if note := m.samplingNote(sessionKey); note != "" {
+		sb.WriteString("\n" + note + "\n")
+	}
+	sb.WriteString("\nUse /settings temperature <value|default>, /settings top_p <value|default>, /settings timezone <name|default>, /settings idle_reset <hours|off|default>, or /settings reset.")
+	return sb.String()
+}
+
+// samplingNote warns when the chat's model ignores sampling parameters.
+func (m *SessionManager) samplingNote(sessionKey string) string {
+	ref := m.ModelFor(sessionKey)
+	info, ok := model.DefaultRegistry().LookupModel(ref)
+	if !ok || info.Capabilities.Temperature {
+		return ""
+	}
+	return fmt.Sprintf("⚠️ %s does not accept sampling parameters, so they are not sent to it.", ref)
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/nene-agent/nene/pkg/bus"
 	"github.com/nene-agent/nene/pkg/model"
@@ -17,14 +20,25 @@ type Session struct {
 	provider     model.Provider
 	toolMgr      *tool.Manager
 	systemPrompt string
+	temperature  *float64
+	topP         *float64
 	bus          *bus.MessageBus
-
-	mu       sync.Mutex
-	messages []model.Message
+	onUsage      UsageFunc
+	turnBudget   int
+	pruneAfter   int
+
+	mu         sync.Mutex
+	messages   []model.Message
+	lastActive time.Time
+	footnote   string
 }
 
 type SessionOption func(*Session)
 
+// UsageFunc is called after every model call with the model reference the
+// request was sent with. Usage is estimated when the provider reports none.
+type UsageFunc func(modelRef string, usage model.Usage)
+
 func WithModelName(name string) SessionOption {
 	return func(s *Session) { s.modelName = name }
 }
@@ -33,10 +47,34 @@ func WithSystemPrompt(prompt string) SessionOption {
 	return func(s *Session) { s.systemPrompt = prompt }
 }
 
+func WithTemperature(t *float64) SessionOption {
+	return func(s *Session) { s.temperature = t }
+}
+
+func WithTopP(p *float64) SessionOption {
+	return func(s *Session) { s.topP = p }
+}
+
 func WithMessageBus(b *bus.MessageBus) SessionOption {
 	return func(s *Session) { s.bus = b }
 }
 
+func WithUsageFunc(fn UsageFunc) SessionOption {
+	return func(s *Session) { s.onUsage = fn }
+}
+
+// WithTurnTokenBudget caps the tokens a single turn may consume, counting
+// every model call and the subagents it spawns. Zero means unlimited.
+func WithTurnTokenBudget(n int) SessionOption {
+	return func(s *Session) { s.turnBudget = n }
+}
+
+// WithToolResultPruning replaces tool results with a one-line stub once the
+// model has answered after them n times. Zero keeps them.
+func WithToolResultPruning(n int) SessionOption {
+	return func(s *Session) { s.pruneAfter = n }
+}
+
 func WithToolManager(tm *tool.Manager) SessionOption {
 	return func(s *Session) { s.toolMgr = tm }
 }
@@ -60,6 +98,67 @@ func NewSession(provider model.Provider, opts ...SessionOption) *Session {
 	return s
 }
 
+func (s *Session) SetProvider(p model.Provider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.provider = p
+}
+
+func (s *Session) SetModelName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.modelName = name
+}
+
+func (s *Session) SetTemperature(t *float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.temperature = t
+}
+
+func (s *Session) SetTopP(p *float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.topP = p
+}
+
+func (s *Session) SetTurnTokenBudget(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.turnBudget = n
+}
+
+func (s *Session) SetToolResultPruning(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneAfter = n
+}
+
+// Tools returns the session's tool set. Tools registered on it are offered
+// to this session only.
+func (s *Session) Tools() *tool.Manager {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.toolMgr
+}
+
+func (s *Session) SetToolManager(tm *tool.Manager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.toolMgr = tm
+}
+
+// SetSystemPrompt changes the prompt for the rest of the conversation,
+// replacing the system message if one was already sent.
+func (s *Session) SetSystemPrompt(prompt string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.systemPrompt = prompt
+	if len(s.messages) > 0 && s.messages[0].Role == "system" {
+		s.messages[0].Content = prompt
+	}
+}
+
 func (s *Session) recallMemories(ctx context.Context, query string) string {
 	memTool, ok := s.toolMgr.Get("memory_recall")
 	if !ok {
@@ -80,15 +179,18 @@ func (s *Session) ProcessMessage(ctx context.Context, msg bus.InboundMessage) er
 	sessionKey := msg.SessionKey
 
 	if s.bus != nil {
+		// The bus tags the turn's other events with this ID.
 		s.bus.PublishStream(bus.StreamMessage{
 			Channel:    msg.Channel,
 			ChatID:     chatID,
 			SessionKey: sessionKey,
 			Type:       bus.StreamEventStart,
+			TurnID:     uuid.NewString(),
 		})
 	}
 
 	s.mu.Lock()
+	s.lastActive = time.Now()
 
 	if s.systemPrompt != "" && len(s.messages) == 0 {
 		s.messages = append(s.messages, model.Message{
@@ -98,9 +200,17 @@ func (s *Session) ProcessMessage(ctx context.Context, msg bus.InboundMessage) er
 	}
 
 	memories := s.recallMemories(ctx, msg.Content)
+	if s.turnBudget > 0 && tool.TurnBudgetFrom(ctx) == nil {
+		ctx = tool.WithTurnBudget(ctx, tool.NewSpawnBudget(s.turnBudget))
+	}
+
 	userContent := msg.Content
+	if len(msg.Media) > 0 {
+		// Channels save what users send as files the tools can open.
+		userContent = strings.TrimSpace(userContent + "\n\n[Attached files]\n- " + strings.Join(msg.Media, "\n- "))
+	}
 	if memories != "" && !strings.Contains(memories, "No relevant memories found") {
-		userContent = fmt.Sprintf("%s\n\n[Retrieved memories]\n%s", msg.Content, memories)
+		userContent = fmt.Sprintf("%s\n\n[Retrieved memories]\n%s", userContent, memories)
 	}
 
 	s.messages = append(s.messages, model.Message{
@@ -109,7 +219,7 @@ func (s *Session) ProcessMessage(ctx context.Context, msg bus.InboundMessage) er
 	})
 	s.mu.Unlock()
 
-	err := s.processLoop(ctx, msg.Channel, chatID, sessionKey)
+	err := s.processLoop(ctx, msg.Channel, chatID, sessionKey, samplingFromMetadata(msg.Metadata))
 
 	if s.bus != nil {
 		s.bus.PublishStream(bus.StreamMessage{
@@ -123,8 +233,13 @@ func (s *Session) ProcessMessage(ctx context.Context, msg bus.InboundMessage) er
 	return err
 }
 
-func (s *Session) processLoop(ctx context.Context, channel, chatID, sessionKey string) error {
+// processLoop runs model calls until the turn is over. Sampling values set
+// in override take precedence over the session's for this turn only.
+func (s *Session) processLoop(ctx context.Context, channel, chatID, sessionKey string, override Sampling) error {
 	iteration := 0
+	turn := tool.TurnBudgetFrom(ctx)
+	var turnTokens int
+	var turnCost float64
 
 	for {
 		select {
@@ -133,6 +248,10 @@ func (s *Session) processLoop(ctx context.Context, channel, chatID, sessionKey s
 		default:
 		}
 
+		// Once the turn's budget is spent, the next call only writes the
+		// answer, from what the turn found so far.
+		wrapUp := turn != nil && turn.Exhausted()
+
 		iteration++
 		if s.bus != nil {
 			s.bus.PublishStream(bus.StreamMessage{
@@ -146,21 +265,37 @@ func (s *Session) processLoop(ctx context.Context, channel, chatID, sessionKey s
 
 		s.mu.Lock()
 		req := &model.Request{
-			Model:    s.modelName,
-			Messages: s.messages,
-			Tools:    s.toolMgr.Definitions(),
+			Model:       s.modelName,
+			Messages:    pruneToolResults(s.messages, s.pruneAfter),
+			Tools:       s.toolMgr.Definitions(),
+			Temperature: s.temperature,
+			TopP:        s.topP,
+		}
+		if override.Temperature != nil {
+			req.Temperature = override.Temperature
 		}
+		if override.TopP != nil {
+			req.TopP = override.TopP
+		}
+		provider := s.provider
+		onUsage := s.onUsage
 		s.mu.Unlock()
+		if wrapUp {
+			req.Tools = nil
+			req.Messages = wrapUpMessages(req.Messages)
+		}
 
-		stream, err := s.provider.SendStream(ctx, req)
+		stream, err := provider.SendStream(ctx, req)
 		if err != nil {
 			if s.bus != nil {
+				code, text := describeError(err)
 				s.bus.PublishStream(bus.StreamMessage{
 					Channel:    channel,
 					ChatID:     chatID,
 					SessionKey: sessionKey,
 					Type:       bus.StreamEventError,
-					Content:    err.Error(),
+					Content:    text,
+					ErrorCode:  code,
 				})
 			}
 			return err
@@ -169,7 +304,10 @@ func (s *Session) processLoop(ctx context.Context, channel, chatID, sessionKey s
 		var assistantMsg strings.Builder
 		var toolCalls []model.ToolCall
 		var finishReason model.FinishReason
-		var partID string = "main"
+		var usage *model.Usage
+		// Each model call streams into a text part of its own, so channels
+		// can tell the text of earlier steps from the answer.
+		partID := fmt.Sprintf("text-%d", iteration)
 
 		if s.bus != nil {
 			s.bus.PublishStream(bus.StreamMessage{
@@ -178,6 +316,7 @@ func (s *Session) processLoop(ctx context.Context, channel, chatID, sessionKey s
 				SessionKey: sessionKey,
 				Type:       bus.StreamEventTextStart,
 				Delta:      partID,
+				Iteration:  iteration,
 			})
 		}
 
@@ -195,12 +334,88 @@ func (s *Session) processLoop(ctx context.Context, channel, chatID, sessionKey s
 					})
 				}
 			}
+			if event.Reasoning != "" && s.bus != nil {
+				s.bus.PublishStream(bus.StreamMessage{
+					Channel:    channel,
+					ChatID:     chatID,
+					SessionKey: sessionKey,
+					Type:       bus.StreamEventReasoning,
+					Content:    event.Reasoning,
+					Iteration:  iteration,
+				})
+			}
 			if event.ToolCall != nil {
 				toolCalls = append(toolCalls, *event.ToolCall)
 			}
 			if event.FinishReason != "" && finishReason == "" {
 				finishReason = event.FinishReason
 			}
+			if event.Usage != nil {
+				usage = event.Usage
+			}
+		}
+
+		if usage == nil {
+			prompt := model.EstimateTokens(req.Messages)
+			completion := assistantMsg.Len() / 4
+			usage = &model.Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+		}
+		if onUsage != nil {
+			onUsage(req.Model, *usage)
+		}
+		if turn != nil {
+			turn.Add(usage.TotalTokens)
+			turnTokens += usage.TotalTokens
+			if info, ok := model.DefaultRegistry().LookupModel(req.Model); ok {
+				turnCost += info.CostUSD(*usage)
+			}
+		}
+
+		if wrapUp {
+			// Tools were not offered, so any calls are dropped rather than
+			// left unanswered in the history.
+			toolCalls = nil
+			note := budgetNote(turn, turnTokens, turnCost)
+			assistantMsg.WriteString(note)
+			if s.bus != nil {
+				s.bus.PublishStream(bus.StreamMessage{
+					Channel:    channel,
+					ChatID:     chatID,
+					SessionKey: sessionKey,
+					Type:       bus.StreamEventTextDelta,
+					Delta:      partID,
+					Content:    note,
+				})
+			}
+		}
+
+		// The footnote goes under the answer, not the steps before it.
+		if finishReason != model.FinishReasonToolCalls || len(toolCalls) == 0 {
+			if note := s.takeFootnote(); note != "" {
+				note = "\n\n" + note
+				assistantMsg.WriteString(note)
+				if s.bus != nil {
+					s.bus.PublishStream(bus.StreamMessage{
+						Channel:    channel,
+						ChatID:     chatID,
+						SessionKey: sessionKey,
+						Type:       bus.StreamEventTextDelta,
+						Delta:      partID,
+						Content:    note,
+					})
+				}
+			}
+		}
+
+		if s.bus != nil {
+			s.bus.PublishStream(bus.StreamMessage{
+				Channel:    channel,
+				ChatID:     chatID,
+				SessionKey: sessionKey,
+				Type:       bus.StreamEventTextEnd,
+				Delta:      partID,
+				Iteration:  iteration,
+			})
 		}
 
 		s.mu.Lock()
@@ -215,6 +430,10 @@ func (s *Session) processLoop(ctx context.Context, channel, chatID, sessionKey s
 		s.mu.Unlock()
 
 		if finishReason == model.FinishReasonToolCalls && len(toolCalls) > 0 {
+			if turn != nil && turn.Exhausted() {
+				s.skipToolCalls(toolCalls)
+				continue
+			}
 			if err := s.executeToolCalls(ctx, channel, chatID, sessionKey, iteration, toolCalls); err != nil {
 				return err
 			}
@@ -225,6 +444,45 @@ func (s *Session) processLoop(ctx context.Context, channel, chatID, sessionKey s
 	}
 }
 
+// skipToolCalls answers tool calls that will not run because the turn's
+// token budget is spent.
+func (s *Session) skipToolCalls(toolCalls []model.ToolCall) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tc := range toolCalls {
+		s.messages = append(s.messages, model.Message{
+			Role:       "tool",
+			Content:    "Error: not run; the turn's token budget is exhausted",
+			ToolCallID: tc.ID,
+		})
+	}
+}
+
+// wrapUpMessages asks the model to finish a turn whose token budget is
+// spent. The request gets a copy, so the note stays out of the history.
+func wrapUpMessages(msgs []model.Message) []model.Message {
+	out := make([]model.Message, len(msgs))
+	copy(out, msgs)
+	if len(out) > 0 {
+		out[len(out)-1].Content += "\n\n[The token budget for this turn is used up. Do not call tools. Summarize what you found so far, say what is left undone, and stop.]"
+	}
+	return out
+}
+
+// budgetNote tells the user a turn was cut short and what it cost. Cost is
+// known only for the agent's own calls to catalogued models.
+func budgetNote(turn *tool.SpawnBudget, ownTokens int, cost float64) string {
+	note := fmt.Sprintf("\n\n⚠️ Stopped early: this turn used %d tokens, over its budget of %d", turn.Used(), turn.Limit())
+	if cost > 0 {
+		note += fmt.Sprintf(" (about $%.4f", cost)
+		if turn.Used() > ownTokens {
+			note += " plus subagents"
+		}
+		note += ")"
+	}
+	return note + "."
+}
+
 func (s *Session) executeToolCalls(ctx context.Context, channel, chatID, sessionKey string, iteration int, toolCalls []model.ToolCall) error {
 	for _, tc := range toolCalls {
 		var args map[string]interface{}
@@ -250,9 +508,15 @@ func (s *Session) executeToolCalls(ctx context.Context, channel, chatID, session
 			argsJSON = json.RawMessage(tc.Function.Arguments)
 		}
 
-		result, err := s.toolMgr.ExecuteWithContext(ctx, tc.Function.Name, argsJSON, channel, chatID)
+		s.mu.Lock()
+		toolMgr := s.toolMgr
+		s.mu.Unlock()
+
+		result, err := toolMgr.ExecuteWithContext(ctx, tc.Function.Name, argsJSON, channel, chatID)
+		var code string
 		if err != nil {
 			result = tool.ErrorResult(fmt.Sprintf("Error executing tool: %v", err))
+			code, _ = describeError(err)
 		}
 
 		var content string
@@ -266,6 +530,7 @@ func (s *Session) executeToolCalls(ctx context.Context, channel, chatID, session
 					Type:       bus.StreamEventToolError,
 					ToolCallID: tc.ID,
 					Error:      result.Content,
+					ErrorCode:  code,
 				})
 			}
 		} else {
@@ -295,6 +560,29 @@ func (s *Session) executeToolCalls(ctx context.Context, channel, chatID, session
 	return nil
 }
 
+// LastActive returns when the session last received a message.
+func (s *Session) LastActive() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastActive
+}
+
+// SetFootnote adds a short note to the end of the next answer, such as
+// why the conversation started over.
+func (s *Session) SetFootnote(note string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.footnote = note
+}
+
+func (s *Session) takeFootnote() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	note := s.footnote
+	s.footnote = ""
+	return note
+}
+
 func (s *Session) Clear() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
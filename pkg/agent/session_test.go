@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/bus/bustest"
+	"github.com/nene-agent/nene/pkg/model/mockprovider"
+	"github.com/nene-agent/nene/pkg/tool"
+)
+
+type echoTool struct{}
+
+func (echoTool) Name() string        { return "echo" }
+func (echoTool) Description() string { return "Echo the text back" }
+func (echoTool) Parameters() json.RawMessage {
+	return json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`)
+}
+func (echoTool) MakeApproval(args json.RawMessage) (*tool.Approval, error) { return nil, nil }
+func (echoTool) Execute(ctx context.Context, args json.RawMessage) (tool.Result, error) {
+	var a struct{ Text string }
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ErrorResult(err.Error()), nil
+	}
+	return tool.OkResult(a.Text), nil
+}
+
+func TestSessionReplaysRecordedTurn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msg := bus.InboundMessage{
+		Channel:    "test",
+		ChatID:     "1",
+		SessionKey: "test:1",
+		Content:    "say hi",
+		StreamMode: true,
+	}
+
+	// Record a turn that calls a tool and then answers.
+	recorder := mockprovider.Record(mockprovider.New([]mockprovider.Exchange{
+		mockprovider.ToolCalls([2]string{"echo", `{"text":"hi"}`}),
+		mockprovider.Text("The tool said hi."),
+	}))
+	if err := NewSession(recorder, WithTools(echoTool{})).ProcessMessage(ctx, msg); err != nil {
+		t.Fatalf("recording: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "turn.json")
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("save fixture: %v", err)
+	}
+
+	// Replay it strictly, so a drifting conversation fails the call.
+	replay, err := mockprovider.FromFile(path, mockprovider.Strict())
+	if err != nil {
+		t.Fatalf("load fixture: %v", err)
+	}
+	mb := bus.NewMessageBus()
+	events := bustest.Collect(ctx, mb, "test")
+	s := NewSession(replay, WithTools(echoTool{}), WithMessageBus(mb))
+	if err := s.ProcessMessage(ctx, msg); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+
+	got := events.Wait(t, 5*time.Second)
+	bustest.ExpectTypes(t, got,
+		bus.StreamEventStart,
+		bus.StreamEventToolCall,
+		bus.StreamEventToolResult,
+		bus.StreamEventTextDelta,
+		bus.StreamEventFinish,
+	)
+	bustest.ExpectSeq(t, got)
+	bustest.ExpectText(t, got, "The tool said hi.")
+	if n := len(replay.Requests()); n != 2 {
+		t.Errorf("replay got %d model calls, want 2", n)
+	}
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/memory"
+	"github.com/nene-agent/nene/pkg/model"
+)
+
+// Version and Commit describe the build. Release builds set them with
+// -ldflags "-X github.com/nene-agent/nene/pkg/agent.Version=v1.2.3
+// -X github.com/nene-agent/nene/pkg/agent.Commit=<sha>".
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// Status is a snapshot of the running agent, for /status and the admin API.
+type Status struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit,omitempty"`
+	Started   time.Time `json:"started"`
+	Uptime    string    `json:"uptime"`
+	Sessions  int       `json:"sessions"`
+	Model     string    `json:"model"`
+	Providers []string  `json:"providers"`
+	Tools     int       `json:"tools"`
+	ReadOnly  bool      `json:"read_only"`
+	// MemoryEntries is nil when no memory is set or it cannot be counted.
+	MemoryEntries *int       `json:"memory_entries,omitempty"`
+	Queues        *bus.Stats `json:"queues,omitempty"`
+	LastError     *TurnError `json:"last_error,omitempty"`
+}
+
+// TurnError is the last turn that failed. Message is the description that
+// is safe to show, not the raw error.
+type TurnError struct {
+	Time       time.Time `json:"time"`
+	SessionKey string    `json:"session_key"`
+	Code       string    `json:"code,omitempty"`
+	Message    string    `json:"message"`
+}
+
+// SetMemory lets /status count the long-term memory entries.
+func (m *SessionManager) SetMemory(mem memory.Memory) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.memory = mem
+}
+
+// recordError keeps the failure of a turn for /status.
+func (m *SessionManager) recordError(sessionKey string, err error) {
+	code, text := describeError(err)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastError = &TurnError{Time: time.Now(), SessionKey: sessionKey, Code: code, Message: m.redactor.String(text)}
+}
+
+func (m *SessionManager) Status(ctx context.Context) Status {
+	m.mu.Lock()
+	st := Status{
+		Version:   Version,
+		Commit:    Commit,
+		Started:   m.started,
+		Uptime:    time.Since(m.started).Round(time.Second).String(),
+		Sessions:  len(m.sessions),
+		Model:     m.defaults.Model,
+		Providers: model.DefaultRegistry().ListProviders(),
+		Tools:     len(m.toolMgr.Names()),
+		ReadOnly:  m.toolMgr.ReadOnly(),
+		LastError: m.lastError,
+	}
+	mem := m.memory
+	m.mu.Unlock()
+
+	sort.Strings(st.Providers)
+	if mem != nil {
+		if n, err := mem.Count(ctx); err == nil {
+			st.MemoryEntries = &n
+		}
+	}
+	if m.bus != nil {
+		stats := m.bus.Stats()
+		st.Queues = &stats
+	}
+	return st
+}
+
+func (m *SessionManager) statusCommand(ctx context.Context) string {
+	st := m.Status(ctx)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "nene %s", st.Version)
+	if st.Commit != "" {
+		fmt.Fprintf(&sb, " (%s)", shortCommit(st.Commit))
+	}
+	fmt.Fprintf(&sb, ", up %s\n", st.Uptime)
+	fmt.Fprintf(&sb, "Model: %s\n", st.Model)
+	if len(st.Providers) > 0 {
+		fmt.Fprintf(&sb, "Providers: %s\n", strings.Join(st.Providers, ", "))
+	}
+	fmt.Fprintf(&sb, "Sessions: %d\nTools: %d\n", st.Sessions, st.Tools)
+	if st.ReadOnly {
+		sb.WriteString("Read-only mode: on\n")
+	}
+	if st.MemoryEntries != nil {
+		fmt.Fprintf(&sb, "Memory entries: %d\n", *st.MemoryEntries)
+	}
+	if q := st.Queues; q != nil {
+		fmt.Fprintf(&sb, "Queues: inbound %d/%d, outbound %d/%d, stream %d/%d\n",
+			q.Inbound.Depth, q.Inbound.Capacity, q.Outbound.Depth, q.Outbound.Capacity, q.Stream.Depth, q.Stream.Capacity)
+	}
+	if e := st.LastError; e != nil {
+		fmt.Fprintf(&sb, "Last error: %s in %s, %s ago", e.Message, e.SessionKey, time.Since(e.Time).Round(time.Second))
+	} else {
+		sb.WriteString("Last error: none")
+	}
+	return sb.String()
+}
+
+func shortCommit(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
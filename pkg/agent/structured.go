@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nene-agent/nene/pkg/model"
+)
+
+// jsonRetries is how many times CompleteJSON asks again after output that
+// does not decode.
+const jsonRetries = 1
+
+// CompleteJSON sends req with a JSON schema response format and decodes the
+// reply into v. It is for callers that act on model output rather than show
+// it, such as planning and memory consolidation. Providers that ignore the
+// format are covered by stripping code fences and by asking once more with
+// the decode error. The returned usage covers every attempt.
+func CompleteJSON(ctx context.Context, provider model.Provider, req *model.Request, name string, schema json.RawMessage, v interface{}) (model.Usage, error) {
+	var total model.Usage
+	req.ResponseFormat = model.JSONSchemaFormat(name, schema)
+	req.Stream = false
+	messages := append([]model.Message(nil), req.Messages...)
+
+	for attempt := 0; ; attempt++ {
+		req.Messages = messages
+		resp, err := provider.Send(ctx, req)
+		if err != nil {
+			return total, err
+		}
+		total.PromptTokens += resp.Usage.PromptTokens
+		total.CompletionTokens += resp.Usage.CompletionTokens
+		total.TotalTokens += resp.Usage.TotalTokens
+		if len(resp.Choices) == 0 {
+			return total, errors.New("empty response")
+		}
+
+		content := resp.Choices[0].Message.Content
+		err = json.Unmarshal([]byte(stripCodeFence(content)), v)
+		if err == nil {
+			return total, nil
+		}
+		if attempt == jsonRetries {
+			return total, fmt.Errorf("model output is not valid %s JSON: %w", name, err)
+		}
+		messages = append(messages,
+			model.Message{Role: "assistant", Content: content},
+			model.Message{Role: "user", Content: fmt.Sprintf("That is not valid JSON for the requested schema (%v). Reply with the JSON only.", err)},
+		)
+	}
+}
+
+// stripCodeFence removes a Markdown code fence around s, which models add
+// when they are not held to a response format.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/memory"
+	"github.com/nene-agent/nene/pkg/model"
+)
+
+const (
+	summaryTimeout   = 2 * time.Minute
+	summaryMaxTokens = 400
+	// summaryMaxChars bounds the transcript sent for a summary; longer
+	// conversations keep their end, which is what the next one follows on.
+	summaryMaxChars   = 24000
+	summaryEntryChars = 2000
+)
+
+const summaryPrompt = `Summarize the conversation below for your own future reference, so a later conversation with the same user can pick up where this one left off. In a few short bullet points, note the topics discussed, decisions made, and anything left open. Write only the summary.`
+
+// SummaryKey is the memory key a chat's latest conversation summary is
+// stored under.
+func SummaryKey(sessionKey string) string {
+	return "conversation:" + sessionKey
+}
+
+// SetSummaryMemory makes the manager summarize conversations when they are
+// dropped and store the summary in mem under SummaryKey, replacing the
+// previous one. The next conversation in the chat starts with it in its
+// system prompt.
+func (m *SessionManager) SetSummaryMemory(mem memory.Memory) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.summaries = mem
+}
+
+// dropSessionLocked removes a session and, when summaries are enabled,
+// summarizes it in the background.
+func (m *SessionManager) dropSessionLocked(sessionKey string) bool {
+	s, ok := m.sessions[sessionKey]
+	if !ok {
+		return false
+	}
+	delete(m.sessions, sessionKey)
+	if mem := m.summaries; mem != nil && s.Turns() > 0 {
+		go m.storeSummary(mem, sessionKey, s)
+	}
+	return true
+}
+
+func (m *SessionManager) storeSummary(mem memory.Memory, sessionKey string, s *Session) {
+	if b := m.Budget(); b != nil {
+		if reason, _ := b.Exceeded(sessionKey); reason != "" {
+			return
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), summaryTimeout)
+	defer cancel()
+
+	summary, err := s.summarize(ctx)
+	if err != nil {
+		fmt.Printf("Failed to summarize session %s: %v\n", sessionKey, err)
+		return
+	}
+	content := fmt.Sprintf("Conversation ending %s:\n%s", time.Now().Format("2006-01-02 15:04"), summary)
+	_, err = mem.Store(ctx, &memory.StoreRequest{
+		Key:       SummaryKey(sessionKey),
+		Content:   content,
+		Category:  memory.CategoryConversation,
+		SessionID: sessionKey,
+	})
+	if err != nil {
+		fmt.Printf("Failed to store summary of session %s: %v\n", sessionKey, err)
+	}
+}
+
+// summarize asks the session's model for a short summary of the
+// conversation's user and assistant messages.
+func (s *Session) summarize(ctx context.Context) (string, error) {
+	var lines []string
+	for _, e := range exportEntries(s.Messages()) {
+		if e.Kind != "user" && e.Kind != "assistant" {
+			continue
+		}
+		content := e.Content
+		if len(content) > summaryEntryChars {
+			content = strings.ToValidUTF8(content[:summaryEntryChars], "") + "…"
+		}
+		lines = append(lines, e.Kind+": "+content)
+	}
+	transcript := strings.Join(lines, "\n\n")
+	if len(transcript) > summaryMaxChars {
+		transcript = "…" + strings.ToValidUTF8(transcript[len(transcript)-summaryMaxChars:], "")
+	}
+
+	provider, modelRef := s.providerAndModel()
+	req := &model.Request{
+		Model: modelRef,
+		Messages: []model.Message{
+			{Role: "system", Content: summaryPrompt},
+			{Role: "user", Content: transcript},
+		},
+		MaxTokens: summaryMaxTokens,
+	}
+	resp, err := provider.Send(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	onUsage := s.onUsage
+	s.mu.Unlock()
+	if onUsage != nil {
+		onUsage(req.Model, resp.Usage)
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("empty response")
+	}
+	summary := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if summary == "" {
+		return "", errors.New("empty response")
+	}
+	return summary, nil
+}
+
+// providerAndModel returns what the session sends requests to, for model
+// calls made on its behalf outside a turn.
+func (s *Session) providerAndModel() (model.Provider, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.provider, s.modelName
+}
+
+// lastSummaryLocked is the prompt section that carries the chat's previous
+// conversation summary into a new conversation.
+func (m *SessionManager) lastSummaryLocked(sessionKey string) string {
+	if m.summaries == nil {
+		return ""
+	}
+	e, err := m.summaries.Get(context.Background(), SummaryKey(sessionKey))
+	if err != nil || e == nil {
+		return ""
+	}
+	return "## Previous Conversation\n" + e.Content
+}
@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SetDefaultTimezone sets the timezone of chats that have none of their own.
+// Nil means the server's.
+func (m *SessionManager) SetDefaultTimezone(loc *time.Location) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timezone = loc
+}
+
+// TimezoneFor returns the timezone of a session key's chat: the one set with
+// /settings, else the one learned for the user who wrote last, else the
+// default.
+func (m *SessionManager) TimezoneFor(sessionKey string) *time.Location {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	loc, _ := m.timezoneLocked(sessionKey)
+	return loc
+}
+
+// timezoneLocked returns the chat's timezone and where it comes from.
+func (m *SessionManager) timezoneLocked(sessionKey string) (*time.Location, string) {
+	if loc, ok := m.timezones[sessionKey]; ok {
+		return loc, "set for this chat"
+	}
+	if users := m.sessionUsers[sessionKey]; m.profiles != nil && len(users) > 0 {
+		p, err := loadProfile(context.Background(), m.profiles, users[len(users)-1])
+		if err == nil && p.Timezone != "" {
+			if loc, err := time.LoadLocation(p.Timezone); err == nil {
+				return loc, "from the user's profile"
+			}
+		}
+	}
+	if m.timezone != nil {
+		return m.timezone, "default"
+	}
+	return time.Local, "server"
+}
+
+// SetTimezone sets a chat's timezone by IANA name, such as "Asia/Tokyo".
+// An empty name goes back to the learned or default timezone.
+func (m *SessionManager) SetTimezone(sessionKey, name string) error {
+	var loc *time.Location
+	if name != "" {
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return fmt.Errorf("unknown timezone %q; use a name such as Europe/Berlin", name)
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if loc == nil {
+		delete(m.timezones, sessionKey)
+	} else {
+		m.timezones[sessionKey] = loc
+	}
+	m.refreshPromptLocked(sessionKey)
+	return nil
+}
+
+// timePromptLocked tells the model the chat's timezone and when the
+// conversation started.
+func (m *SessionManager) timePromptLocked(sessionKey string) string {
+	loc, _ := m.timezoneLocked(sessionKey)
+	now := time.Now().In(loc)
+	return fmt.Sprintf("## Time\nThe chat's timezone is %s (UTC%s). When this prompt was written it was %s there. Give dates and times in this timezone, and use current_time when the exact time matters.",
+		loc, now.Format("-07:00"), now.Format("Monday, 2006-01-02 15:04"))
+}
@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/nene-agent/nene/pkg/bus"
+)
+
+// Metadata keys channels set on messages written in a thread of a chat,
+// such as a Telegram forum topic, which is a conversation of its own.
+const (
+	MetadataThread = "thread_id"
+	MetadataTopic  = "topic"
+)
+
+// noteTopicLocked records the thread a message was written in and reports
+// whether the session's prompt should change.
+func (m *SessionManager) noteTopicLocked(msg bus.InboundMessage) bool {
+	if msg.Metadata[MetadataThread] == "" {
+		return false
+	}
+	name := msg.Metadata[MetadataTopic]
+	if old, ok := m.topics[msg.SessionKey]; ok && (old == name || name == "") {
+		return false
+	}
+	m.topics[msg.SessionKey] = name
+	return true
+}
+
+// topicPromptLocked tells the model which topic of a group it is serving.
+func (m *SessionManager) topicPromptLocked(sessionKey string) string {
+	name, ok := m.topics[sessionKey]
+	if !ok {
+		return ""
+	}
+	where := "a topic of a group chat"
+	if name != "" {
+		where = fmt.Sprintf("the topic %q of a group chat", name)
+	}
+	return fmt.Sprintf("## Topic\nThis conversation takes place in %s. Other topics of the group are separate conversations, so keep to this one.", where)
+}
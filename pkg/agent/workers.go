@@ -0,0 +1,253 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/i18n"
+)
+
+// DefaultWorkers is how many sessions Run serves at once unless SetWorkers
+// says otherwise.
+const DefaultWorkers = 4
+
+// SetWorkers sets how many sessions Run serves at once. It takes effect the
+// next time Run starts.
+func (m *SessionManager) SetWorkers(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.workers = n
+}
+
+// Run consumes the inbound queue until ctx is done. Messages of different
+// sessions are handled concurrently by a pool of workers, so a long turn in
+// one chat does not hold up the others; the messages of one session are
+// handled one after another, in the order they arrived. A message that has
+// to wait is acknowledged with its place in the queue, and /cancel stops the
+// turn in progress without waiting its own turn.
+func (m *SessionManager) Run(ctx context.Context) {
+	m.mu.Lock()
+	workers := m.workers
+	defaultModel, tools := m.defaults.Model, m.toolMgr
+	m.mu.Unlock()
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	version := Version
+	if Commit != "" {
+		version += " (" + shortCommit(Commit) + ")"
+	}
+	fmt.Printf("nene %s: model %s, %d tools, %d workers\n", version, defaultModel, len(tools.Names()), workers)
+
+	q := &inboundQueues{
+		pending: make(map[string][]bus.InboundMessage),
+		running: make(map[string]context.CancelFunc),
+		wake:    make(chan struct{}, 1),
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if key, ok := q.next(); ok {
+					m.drain(ctx, q, key)
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-q.wake:
+				}
+			}
+		}()
+	}
+	defer wg.Wait()
+
+	// This loop never waits for a worker, so /cancel and queue notices are
+	// handled even while every worker is busy.
+	for {
+		msg, ok := m.bus.ConsumeInbound(ctx)
+		if !ok {
+			return
+		}
+		key := queueKey(msg)
+		if isCancel(msg.Content) {
+			m.cancelTurn(q, key, msg)
+			continue
+		}
+		if ahead := q.push(key, msg); ahead > 0 {
+			m.notice(msg, i18n.T(localeOf(msg), "queue.queued", ahead))
+		}
+	}
+}
+
+// drain handles the queued messages of one session until none are left.
+func (m *SessionManager) drain(ctx context.Context, q *inboundQueues, key string) {
+	for {
+		msg, turnCtx, ok := q.pop(ctx, key)
+		if !ok {
+			return
+		}
+		err := m.handleSafely(turnCtx, msg)
+		cancelled := turnCtx.Err() != nil && ctx.Err() == nil
+		q.done(key)
+		switch {
+		case cancelled:
+			if s, ok := m.existingSession(msg.SessionKey); ok {
+				s.markCancelled()
+			}
+		case err != nil:
+			fmt.Printf("Error processing message for %s: %v\n", msg.SessionKey, err)
+			m.recordError(msg.SessionKey, err)
+		}
+	}
+}
+
+// handleSafely runs HandleMessage, turning a panic into an error event for
+// the chat so one broken turn does not stop the agent.
+func (m *SessionManager) handleSafely(ctx context.Context, msg bus.InboundMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Turn for %s panicked: %v\n%s", msg.SessionKey, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+			if m.bus != nil {
+				m.bus.PublishStream(bus.StreamMessage{
+					Channel:    msg.Channel,
+					ChatID:     msg.ChatID,
+					SessionKey: msg.SessionKey,
+					Type:       bus.StreamEventError,
+					Content:    "internal error",
+					ErrorCode:  bus.ErrorCodeInternal,
+				})
+			}
+		}
+	}()
+	return m.HandleMessage(ctx, msg)
+}
+
+// cancelTurn stops the turn a session is running, if any. Messages queued
+// behind it still run.
+func (m *SessionManager) cancelTurn(q *inboundQueues, key string, msg bus.InboundMessage) {
+	if !q.cancel(key) {
+		m.reply(msg, i18n.T(localeOf(msg), "queue.idle"))
+		return
+	}
+	// The cancelled turn ends with its own finish event.
+	m.notice(msg, i18n.T(localeOf(msg), "queue.stopping"))
+}
+
+func queueKey(msg bus.InboundMessage) string {
+	if msg.SessionKey != "" {
+		return msg.SessionKey
+	}
+	return msg.Channel + ":" + msg.ChatID
+}
+
+// inboundQueues holds the messages waiting for each session. A session has
+// an entry, possibly empty, exactly while it is waiting in ready or a worker
+// owns it, and a cancel func while a message of it is being handled.
+type inboundQueues struct {
+	mu      sync.Mutex
+	pending map[string][]bus.InboundMessage
+	running map[string]context.CancelFunc
+	// ready lists the sessions with messages and no worker, oldest first.
+	ready []string
+	// wake tells an idle worker that ready is not empty.
+	wake chan struct{}
+}
+
+// push queues msg and returns how many of the session's messages are ahead
+// of it, counting the one being handled. Zero means the session was idle; it
+// is then added to ready for the next free worker.
+func (q *inboundQueues) push(key string, msg bus.InboundMessage) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	msgs, active := q.pending[key]
+	q.pending[key] = append(msgs, msg)
+	if !active {
+		q.ready = append(q.ready, key)
+		q.signal()
+		return 0
+	}
+	ahead := len(msgs)
+	if _, ok := q.running[key]; ok {
+		ahead++
+	}
+	return ahead
+}
+
+// next takes the session that has waited longest for a worker.
+func (q *inboundQueues) next() (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.ready) == 0 {
+		return "", false
+	}
+	key := q.ready[0]
+	q.ready = q.ready[1:]
+	if len(q.ready) > 0 {
+		q.signal()
+	}
+	return key, true
+}
+
+// signal wakes one idle worker, if none is already about to wake.
+func (q *inboundQueues) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pop takes the session's next message and the context to handle it with.
+// When there is none, the session becomes idle and pop reports false.
+func (q *inboundQueues) pop(ctx context.Context, key string) (bus.InboundMessage, context.Context, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	msgs := q.pending[key]
+	if len(msgs) == 0 {
+		delete(q.pending, key)
+		return bus.InboundMessage{}, nil, false
+	}
+	q.pending[key] = msgs[1:]
+	turnCtx, cancel := context.WithCancel(ctx)
+	q.running[key] = cancel
+	return msgs[0], turnCtx, true
+}
+
+func (q *inboundQueues) done(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if cancel, ok := q.running[key]; ok {
+		cancel()
+		delete(q.running, key)
+	}
+}
+
+// cancel cancels the message being handled for a session and reports
+// whether there was one.
+func (q *inboundQueues) cancel(key string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cancel, ok := q.running[key]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// isCancel reports whether content is /cancel, with or without the bot
+// name Telegram appends in groups.
+func isCancel(content string) bool {
+	fields := strings.Fields(content)
+	if len(fields) != 1 {
+		return false
+	}
+	cmd, _, _ := strings.Cut(fields[0], "@")
+	return cmd == "/cancel"
+}
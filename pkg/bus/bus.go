@@ -2,8 +2,13 @@ package bus
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/nene-agent/nene/pkg/redact"
 )
 
 type StreamEventType string
@@ -12,14 +17,44 @@ const (
 	StreamEventTextStart  StreamEventType = "text-start"
 	StreamEventTextDelta  StreamEventType = "text-delta"
 	StreamEventTextEnd    StreamEventType = "text-end"
+	StreamEventReasoning  StreamEventType = "reasoning"
 	StreamEventToolCall   StreamEventType = "tool-call"
 	StreamEventToolResult StreamEventType = "tool-result"
 	StreamEventToolError  StreamEventType = "tool-error"
+	StreamEventSubagent   StreamEventType = "subagent"
 	StreamEventStart      StreamEventType = "start"
 	StreamEventFinish     StreamEventType = "finish"
 	StreamEventError      StreamEventType = "error"
+	// StreamEventApproval asks the user to approve a tool call; channels
+	// answer through tool.ApprovalGate.
+	StreamEventApproval StreamEventType = "approval"
+	// StreamEventPlan carries the whole current plan of a turn in Plan.
+	StreamEventPlan StreamEventType = "plan"
+	// StreamEventSuggestions carries replies the user may want to send next
+	// in Suggestions; channels can offer them as buttons.
+	StreamEventSuggestions StreamEventType = "suggestions"
+)
+
+// Codes of error and tool error events, so channels can explain what went
+// wrong in their own words. Events without a code carry a plain message.
+const (
+	ErrorCodeAuth            = "auth"
+	ErrorCodeRateLimit       = "rate_limit"
+	ErrorCodeContextOverflow = "context_overflow"
+	ErrorCodeUnavailable     = "unavailable"
+	ErrorCodeProvider        = "provider"
+	ErrorCodeToolTimeout     = "tool_timeout"
+	ErrorCodeCancelled       = "cancelled"
+	ErrorCodeInternal        = "internal"
 )
 
+// PlanStep is one step of an agent's plan. Status is "pending",
+// "in_progress", "done", or "skipped".
+type PlanStep struct {
+	Title  string `json:"title"`
+	Status string `json:"status"`
+}
+
 type InboundMessage struct {
 	Channel    string
 	SenderID   string
@@ -29,6 +64,9 @@ type InboundMessage struct {
 	SessionKey string
 	Metadata   map[string]string
 	StreamMode bool
+	// Role is the sender's role as resolved by the channel ("owner" or
+	// "user").
+	Role string
 }
 
 type OutboundMessage struct {
@@ -37,6 +75,41 @@ type OutboundMessage struct {
 	Content   string
 	Media     []string
 	SessionID string
+	// Format is how Content is shown: FormatMarkdown, FormatCode, FormatPre,
+	// or FormatPlain. Silent asks for no notification, and ReplyTo is the
+	// channel's ID of a message to reply to. Channels that cannot do one of
+	// these ignore it.
+	Format  string
+	Silent  bool
+	ReplyTo string
+}
+
+// Formats of an OutboundMessage's content.
+const (
+	FormatMarkdown = ""
+	FormatCode     = "code"
+	FormatPre      = "pre"
+	FormatPlain    = "plain"
+)
+
+// MarkdownContent returns Content as Markdown, set as code when Format asks
+// for it. Plain content is returned as it is.
+func (m OutboundMessage) MarkdownContent() string {
+	switch m.Format {
+	case FormatCode:
+		if !strings.ContainsAny(m.Content, "`\n") {
+			return "`" + m.Content + "`"
+		}
+		fallthrough
+	case FormatPre:
+		// The fence is longer than any run of backticks inside.
+		fence := "```"
+		for strings.Contains(m.Content, fence) {
+			fence += "`"
+		}
+		return fence + "\n" + strings.TrimRight(m.Content, "\n") + "\n" + fence
+	}
+	return m.Content
 }
 
 type StreamMessage struct {
@@ -52,33 +125,263 @@ type StreamMessage struct {
 	ToolCallID string
 	Error      string
 	Iteration  int
+	Label      string
+	Status     string
 	Timestamp  time.Time
+	// ApprovalID identifies an approval request. ApprovalRule is the
+	// pattern "always allow" would remember, or empty if it is not offered.
+	ApprovalID   string
+	ApprovalRule string
+	Plan         []PlanStep
+	Suggestions  []string
+	// ErrorCode says what kind of failure an error or tool error event is,
+	// as one of the ErrorCode constants. Content or Error then holds a
+	// short description that is safe to show, never a raw response.
+	ErrorCode string
+	// Seq numbers the events of a chat from 1 up, in the order they were
+	// queued; PublishStream sets it. TurnID is the same for every event of
+	// a turn: the agent sets it on the turn's first start event and
+	// PublishStream copies it to the chat's events until the finish event.
+	Seq    uint64
+	TurnID string
+}
+
+// StreamKey identifies the chat a stream event belongs to; sequence numbers
+// count per key.
+func (m StreamMessage) StreamKey() string {
+	return m.Channel + ":" + m.ChatID
+}
+
+// redacted returns a copy of m with secrets masked in its text and in the
+// string arguments of its tool call.
+func (m StreamMessage) redacted(r *redact.Redactor) StreamMessage {
+	m.Content = r.String(m.Content)
+	m.ToolResult = r.String(m.ToolResult)
+	m.Error = r.String(m.Error)
+	if len(m.ToolArgs) > 0 {
+		args := make(map[string]interface{}, len(m.ToolArgs))
+		for k, v := range m.ToolArgs {
+			if s, ok := v.(string); ok {
+				v = r.String(s)
+			}
+			args[k] = v
+		}
+		m.ToolArgs = args
+	}
+	return m
 }
 
 type StreamHandler interface {
 	OnStreamEvent(msg StreamMessage)
 }
 
+// OverflowPolicy decides what a publish does when the target queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock waits until the consumer makes room (the historical behavior).
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest evicts the oldest queued message to make room.
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowDropNew discards the message being published.
+	OverflowDropNew OverflowPolicy = "drop-new"
+	// OverflowBlockTimeout waits up to the block timeout, then discards the message.
+	OverflowBlockTimeout OverflowPolicy = "block-timeout"
+)
+
+const (
+	DefaultBufferSize   = 100
+	DefaultBlockTimeout = 5 * time.Second
+	// DefaultOutboundWait is how long the end of a turn waits for the
+	// chat's outbound messages to be sent.
+	DefaultOutboundWait = 10 * time.Second
+)
+
+func ParseOverflowPolicy(s string) OverflowPolicy {
+	switch OverflowPolicy(s) {
+	case OverflowDropOldest, OverflowDropNew, OverflowBlockTimeout:
+		return OverflowPolicy(s)
+	default:
+		return OverflowBlock
+	}
+}
+
+// QueueStats is a point-in-time snapshot of one queue for monitoring.
+type QueueStats struct {
+	Depth    int   `json:"depth"`
+	Capacity int   `json:"capacity"`
+	Dropped  int64 `json:"dropped"`
+}
+
+type Stats struct {
+	Inbound  QueueStats `json:"inbound"`
+	Outbound QueueStats `json:"outbound"`
+	Stream   QueueStats `json:"stream"`
+	// ChannelStreams has the stream queue of each channel that took one
+	// with ChannelStream.
+	ChannelStreams map[string]QueueStats `json:"channel_streams,omitempty"`
+}
+
+// chatStream is the ordering state of one chat's stream events.
+type chatStream struct {
+	mu   sync.Mutex
+	seq  uint64
+	turn string
+}
+
+// chatOutbound counts a chat's outbound messages that are queued but not
+// yet sent. drained is closed when the count drops to zero.
+type chatOutbound struct {
+	pending int
+	drained chan struct{}
+}
+
+type streamQueue struct {
+	ch      chan StreamMessage
+	dropped atomic.Int64
+}
+
 type MessageBus struct {
 	inbound        chan InboundMessage
 	outbound       chan OutboundMessage
 	stream         chan StreamMessage
 	handlers       map[string]func(context.Context, InboundMessage) error
 	streamHandlers sync.Map
-	mu             sync.RWMutex
+	// channelStreams maps a channel name to its own stream queue; events of
+	// other channels go to the shared stream queue.
+	channelStreams sync.Map
+	// chatStreams maps a StreamKey to its *chatStream.
+	chatStreams sync.Map
+	// chatOutbound maps the key of a chat, as in StreamKey, to its
+	// *chatOutbound while it has messages pending. Counts are only waited
+	// for once a consumer acknowledges sends with OutboundSent.
+	chatOutbound map[string]*chatOutbound
+	outboundMu   sync.Mutex
+	acksOutbound atomic.Bool
+	mu           sync.RWMutex
+
+	bufferSize   int
+	overflow     OverflowPolicy
+	blockTimeout time.Duration
+	outboundWait time.Duration
+	recorder     *Recorder
+	redactor     *redact.Redactor
+
+	droppedInbound  atomic.Int64
+	droppedOutbound atomic.Int64
+	droppedStream   atomic.Int64
+}
+
+type Option func(*MessageBus)
+
+func WithBufferSize(size int) Option {
+	return func(mb *MessageBus) {
+		if size > 0 {
+			mb.bufferSize = size
+		}
+	}
+}
+
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(mb *MessageBus) { mb.overflow = policy }
 }
 
-func NewMessageBus() *MessageBus {
-	return &MessageBus{
-		inbound:  make(chan InboundMessage, 100),
-		outbound: make(chan OutboundMessage, 100),
-		stream:   make(chan StreamMessage, 100),
-		handlers: make(map[string]func(context.Context, InboundMessage) error),
+func WithBlockTimeout(d time.Duration) Option {
+	return func(mb *MessageBus) {
+		if d > 0 {
+			mb.blockTimeout = d
+		}
+	}
+}
+
+// WithOutboundWait sets how long the finish or error event of a turn waits
+// for the chat's outbound messages to be sent; zero does not wait.
+func WithOutboundWait(d time.Duration) Option {
+	return func(mb *MessageBus) {
+		if d >= 0 {
+			mb.outboundWait = d
+		}
+	}
+}
+
+// WithRedactor masks secrets in what is sent to chats and in recordings.
+// Inbound messages reach the agent as they are, but are recorded masked.
+func WithRedactor(r *redact.Redactor) Option {
+	return func(mb *MessageBus) { mb.redactor = r }
+}
+
+// WithRecorder writes every published message to r.
+func WithRecorder(r *Recorder) Option {
+	return func(mb *MessageBus) { mb.recorder = r }
+}
+
+func NewMessageBus(opts ...Option) *MessageBus {
+	mb := &MessageBus{
+		handlers:     make(map[string]func(context.Context, InboundMessage) error),
+		chatOutbound: make(map[string]*chatOutbound),
+		bufferSize:   DefaultBufferSize,
+		overflow:     OverflowBlock,
+		blockTimeout: DefaultBlockTimeout,
+		outboundWait: DefaultOutboundWait,
+	}
+	for _, opt := range opts {
+		opt(mb)
+	}
+	mb.inbound = make(chan InboundMessage, mb.bufferSize)
+	mb.outbound = make(chan OutboundMessage, mb.bufferSize)
+	mb.stream = make(chan StreamMessage, mb.bufferSize)
+	return mb
+}
+
+// publish enqueues msg on ch according to the configured overflow policy and
+// reports whether the message was enqueued.
+func publish[T any](ch chan T, msg T, policy OverflowPolicy, timeout time.Duration, dropped *atomic.Int64) bool {
+	select {
+	case ch <- msg:
+		return true
+	default:
+	}
+
+	switch policy {
+	case OverflowDropNew:
+		dropped.Add(1)
+		return false
+	case OverflowDropOldest:
+		for {
+			select {
+			case ch <- msg:
+				return true
+			default:
+			}
+			select {
+			case <-ch:
+				dropped.Add(1)
+			default:
+			}
+		}
+	case OverflowBlockTimeout:
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case ch <- msg:
+			return true
+		case <-timer.C:
+			dropped.Add(1)
+			return false
+		}
+	default:
+		ch <- msg
+		return true
 	}
 }
 
 func (mb *MessageBus) PublishInbound(msg InboundMessage) {
-	mb.inbound <- msg
+	if mb.recorder != nil {
+		recorded := msg
+		recorded.Content = mb.redactor.String(msg.Content)
+		mb.recorder.Record(Record{Inbound: &recorded})
+	}
+	publish(mb.inbound, msg, mb.overflow, mb.blockTimeout, &mb.droppedInbound)
 }
 
 func (mb *MessageBus) ConsumeInbound(ctx context.Context) (InboundMessage, bool) {
@@ -90,8 +393,68 @@ func (mb *MessageBus) ConsumeInbound(ctx context.Context) (InboundMessage, bool)
 	}
 }
 
+// PublishOutbound queues msg. Until the consumer reports it sent with
+// OutboundSent, the finish or error event of the chat's turn waits for it,
+// so a message the agent sent during the turn does not land after the
+// turn's final answer.
 func (mb *MessageBus) PublishOutbound(msg OutboundMessage) {
-	mb.outbound <- msg
+	msg.Content = mb.redactor.String(msg.Content)
+	if mb.recorder != nil {
+		mb.recorder.Record(Record{Outbound: &msg})
+	}
+	key := msg.Channel + ":" + msg.ChatID
+	mb.addOutbound(key, 1)
+	if !publish(mb.outbound, msg, mb.overflow, mb.blockTimeout, &mb.droppedOutbound) {
+		mb.addOutbound(key, -1)
+	}
+}
+
+// OutboundSent tells the bus that the consumer is done with msg, whether it
+// was sent or not.
+func (mb *MessageBus) OutboundSent(msg OutboundMessage) {
+	mb.acksOutbound.Store(true)
+	mb.addOutbound(msg.Channel+":"+msg.ChatID, -1)
+}
+
+// addOutbound changes the count of a chat's pending outbound messages by n
+// and forgets the chat once none are left.
+func (mb *MessageBus) addOutbound(key string, n int) {
+	mb.outboundMu.Lock()
+	defer mb.outboundMu.Unlock()
+	co, ok := mb.chatOutbound[key]
+	if !ok {
+		if n <= 0 {
+			return
+		}
+		co = &chatOutbound{drained: make(chan struct{})}
+		mb.chatOutbound[key] = co
+	}
+	co.pending += n
+	if co.pending <= 0 {
+		close(co.drained)
+		delete(mb.chatOutbound, key)
+	}
+}
+
+// awaitOutbound waits up to the outbound wait for the chat's queued
+// outbound messages to be sent.
+func (mb *MessageBus) awaitOutbound(key string) {
+	if mb.outboundWait == 0 || !mb.acksOutbound.Load() {
+		return
+	}
+	mb.outboundMu.Lock()
+	co, ok := mb.chatOutbound[key]
+	mb.outboundMu.Unlock()
+	if !ok {
+		return
+	}
+	timer := time.NewTimer(mb.outboundWait)
+	defer timer.Stop()
+	select {
+	case <-co.drained:
+	case <-timer.C:
+		fmt.Printf("Outbound messages of %s were not sent within %s; ending the turn anyway\n", key, mb.outboundWait)
+	}
 }
 
 func (mb *MessageBus) SubscribeOutbound(ctx context.Context) (OutboundMessage, bool) {
@@ -103,11 +466,45 @@ func (mb *MessageBus) SubscribeOutbound(ctx context.Context) (OutboundMessage, b
 	}
 }
 
+// PublishStream numbers msg and queues it. A turn's finish and error events
+// are queued only once the chat's outbound messages are sent. The events of
+// a chat are queued one at a time, so they reach the queue in Seq order; a
+// consumer that sees a gap has lost events to the overflow policy.
 func (mb *MessageBus) PublishStream(msg StreamMessage) {
+	if msg.Type == StreamEventFinish || msg.Type == StreamEventError {
+		mb.awaitOutbound(msg.StreamKey())
+	}
 	if msg.Timestamp.IsZero() {
 		msg.Timestamp = time.Now()
 	}
-	mb.stream <- msg
+	if mb.redactor != nil {
+		msg = msg.redacted(mb.redactor)
+	}
+	v, _ := mb.chatStreams.LoadOrStore(msg.StreamKey(), &chatStream{})
+	cs := v.(*chatStream)
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.seq++
+	msg.Seq = cs.seq
+	switch {
+	case msg.TurnID == "":
+		msg.TurnID = cs.turn
+	case msg.Type == StreamEventStart:
+		cs.turn = msg.TurnID
+	}
+	if msg.Type == StreamEventFinish {
+		cs.turn = ""
+	}
+	if mb.recorder != nil {
+		mb.recorder.Record(Record{Time: msg.Timestamp, Stream: &msg})
+	}
+
+	if q, ok := mb.channelStreams.Load(msg.Channel); ok {
+		q := q.(*streamQueue)
+		publish(q.ch, msg, mb.overflow, mb.blockTimeout, &q.dropped)
+		return
+	}
+	publish(mb.stream, msg, mb.overflow, mb.blockTimeout, &mb.droppedStream)
 }
 
 func (mb *MessageBus) SubscribeStream(ctx context.Context) (StreamMessage, bool) {
@@ -127,6 +524,18 @@ func (mb *MessageBus) UnregisterStreamHandler(chatID string) {
 	mb.streamHandlers.Delete(chatID)
 }
 
+// ChannelStream gives a channel a stream queue of its own and returns it.
+// From then on the channel's events are delivered there rather than to
+// SubscribeStream, so channels running side by side do not take each other's
+// events. Calling it again returns the same queue.
+func (mb *MessageBus) ChannelStream(channel string) <-chan StreamMessage {
+	if q, ok := mb.channelStreams.Load(channel); ok {
+		return q.(*streamQueue).ch
+	}
+	q, _ := mb.channelStreams.LoadOrStore(channel, &streamQueue{ch: make(chan StreamMessage, mb.bufferSize)})
+	return q.(*streamQueue).ch
+}
+
 func (mb *MessageBus) RegisterHandler(channel string, handler func(context.Context, InboundMessage) error) {
 	mb.mu.Lock()
 	defer mb.mu.Unlock()
@@ -140,8 +549,46 @@ func (mb *MessageBus) GetHandler(channel string) (func(context.Context, InboundM
 	return handler, ok
 }
 
+func (mb *MessageBus) Stats() Stats {
+	var channels map[string]QueueStats
+	mb.channelStreams.Range(func(k, v any) bool {
+		q := v.(*streamQueue)
+		if channels == nil {
+			channels = make(map[string]QueueStats)
+		}
+		channels[k.(string)] = QueueStats{
+			Depth:    len(q.ch),
+			Capacity: cap(q.ch),
+			Dropped:  q.dropped.Load(),
+		}
+		return true
+	})
+	return Stats{
+		Inbound: QueueStats{
+			Depth:    len(mb.inbound),
+			Capacity: cap(mb.inbound),
+			Dropped:  mb.droppedInbound.Load(),
+		},
+		Outbound: QueueStats{
+			Depth:    len(mb.outbound),
+			Capacity: cap(mb.outbound),
+			Dropped:  mb.droppedOutbound.Load(),
+		},
+		Stream: QueueStats{
+			Depth:    len(mb.stream),
+			Capacity: cap(mb.stream),
+			Dropped:  mb.droppedStream.Load(),
+		},
+		ChannelStreams: channels,
+	}
+}
+
 func (mb *MessageBus) Close() {
 	close(mb.inbound)
 	close(mb.outbound)
 	close(mb.stream)
+	mb.channelStreams.Range(func(_, v any) bool {
+		close(v.(*streamQueue).ch)
+		return true
+	})
 }
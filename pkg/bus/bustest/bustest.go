@@ -0,0 +1,129 @@
+// Package bustest collects the stream events published on a bus and checks
+// them in tests.
+package bustest
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/bus"
+)
+
+// Collector keeps every stream event a bus delivers to one channel.
+type Collector struct {
+	mu       sync.Mutex
+	events   []bus.StreamMessage
+	finished chan struct{}
+}
+
+// Collect takes the channel's stream queue (see bus.MessageBus.ChannelStream)
+// and gathers its events until ctx is done.
+func Collect(ctx context.Context, mb *bus.MessageBus, channel string) *Collector {
+	c := &Collector{finished: make(chan struct{}, 64)}
+	stream := mb.ChannelStream(channel)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-stream:
+				if !ok {
+					return
+				}
+				c.mu.Lock()
+				c.events = append(c.events, msg)
+				c.mu.Unlock()
+				if msg.Type == bus.StreamEventFinish || msg.Type == bus.StreamEventError {
+					select {
+					case c.finished <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}
+	}()
+	return c
+}
+
+// Events returns the events collected so far.
+func (c *Collector) Events() []bus.StreamMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.events)
+}
+
+// Wait waits for a turn to finish or fail and returns the events collected
+// so far. It fails the test after timeout.
+func (c *Collector) Wait(t testing.TB, timeout time.Duration) []bus.StreamMessage {
+	t.Helper()
+	select {
+	case <-c.finished:
+	case <-time.After(timeout):
+		t.Fatalf("no finish event after %s; got %s", timeout, Types(c.Events()))
+	}
+	return c.Events()
+}
+
+// Types lists the events' types, for messages.
+func Types(events []bus.StreamMessage) []bus.StreamEventType {
+	types := make([]bus.StreamEventType, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+// ExpectTypes fails the test unless want appears in events' types in order.
+// Other events may come between them, so a test names only the events it
+// is about.
+func ExpectTypes(t testing.TB, events []bus.StreamMessage, want ...bus.StreamEventType) {
+	t.Helper()
+	i := 0
+	for _, e := range events {
+		if i < len(want) && e.Type == want[i] {
+			i++
+		}
+	}
+	if i < len(want) {
+		t.Errorf("missing %s (expected %v in order); got %v", want[i], want, Types(events))
+	}
+}
+
+// ExpectSeq fails the test when a chat's events are not numbered 1, 2, 3...
+// in the order they arrived.
+func ExpectSeq(t testing.TB, events []bus.StreamMessage) {
+	t.Helper()
+	checker := bus.NewSeqChecker()
+	for _, e := range events {
+		if missed, stale := checker.Check(e); missed > 0 || stale {
+			t.Errorf("event %d (%s) of %s is out of sequence: %d missed, stale %v", e.Seq, e.Type, e.StreamKey(), missed, stale)
+		}
+	}
+}
+
+// Text returns the text of the last model call in events, the part a
+// channel shows as the answer.
+func Text(events []bus.StreamMessage) string {
+	var sb strings.Builder
+	for _, e := range events {
+		switch e.Type {
+		case bus.StreamEventTextStart:
+			sb.Reset()
+		case bus.StreamEventTextDelta:
+			sb.WriteString(e.Content)
+		}
+	}
+	return sb.String()
+}
+
+// ExpectText fails the test unless the answer in events is want.
+func ExpectText(t testing.TB, events []bus.StreamMessage, want string) {
+	t.Helper()
+	if got := Text(events); got != want {
+		t.Errorf("answer is %q, want %q", got, want)
+	}
+}
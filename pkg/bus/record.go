@@ -0,0 +1,126 @@
+package bus
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRecordMaxBytes = 10 << 20
+	DefaultRecordBackups  = 3
+)
+
+// Record is one line of a recording: a message as it was published, with
+// exactly one of Inbound, Outbound, and Stream set.
+type Record struct {
+	Time     time.Time        `json:"time"`
+	Inbound  *InboundMessage  `json:"inbound,omitempty"`
+	Outbound *OutboundMessage `json:"outbound,omitempty"`
+	Stream   *StreamMessage   `json:"stream,omitempty"`
+}
+
+// Recorder writes every message published on a bus to a JSONL file, for
+// debugging and for replaying a chat's stream offline (see package replay).
+// When the file grows past MaxBytes it is renamed to path.1, older files
+// move up, and only Backups of them are kept.
+type Recorder struct {
+	path     string
+	maxBytes int64
+	backups  int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRecorder appends to the file at path. Zero maxBytes and backups use
+// the defaults.
+func NewRecorder(path string, maxBytes int64, backups int) (*Recorder, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultRecordMaxBytes
+	}
+	if backups <= 0 {
+		backups = DefaultRecordBackups
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create recording directory: %w", err)
+	}
+	r := &Recorder{path: path, maxBytes: maxBytes, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Recorder) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("open recording: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("open recording: %w", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// Record writes rec as one line. Errors are logged rather than returned, so
+// a full disk does not stop the bus.
+func (r *Recorder) Record(rec Record) {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		fmt.Printf("Failed to record bus message: %v\n", err)
+		return
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+	if r.size > 0 && r.size+int64(len(line)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			fmt.Printf("Failed to rotate bus recording: %v\n", err)
+			return
+		}
+	}
+	n, err := r.file.Write(line)
+	r.size += int64(n)
+	if err != nil {
+		fmt.Printf("Failed to record bus message: %v\n", err)
+	}
+}
+
+func (r *Recorder) rotate() error {
+	r.file.Close()
+	r.file = nil
+	for i := r.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
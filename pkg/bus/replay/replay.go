@@ -0,0 +1,147 @@
+// Package replay reads bus recordings (see bus.Recorder) and feeds their
+// stream events back into a channel's renderer, to reproduce display bugs
+// without a model or a live chat.
+package replay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/bus"
+)
+
+// Read parses a recording. Blank lines are skipped; a malformed line is an
+// error naming its line number.
+func Read(r io.Reader) ([]bus.Record, error) {
+	var records []bus.Record
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	line := 0
+	for sc.Scan() {
+		line++
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec bus.Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, sc.Err()
+}
+
+// ReadFile reads the recording at path.
+func ReadFile(path string) ([]bus.Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Filter picks the stream events to replay. Empty fields match anything.
+type Filter struct {
+	Channel string
+	ChatID  string
+	TurnID  string
+}
+
+func (f Filter) match(msg *bus.StreamMessage) bool {
+	return (f.Channel == "" || msg.Channel == f.Channel) &&
+		(f.ChatID == "" || msg.ChatID == f.ChatID) &&
+		(f.TurnID == "" || msg.TurnID == f.TurnID)
+}
+
+// Events returns the recorded stream events that match f, in order.
+func Events(records []bus.Record, f Filter) []bus.StreamMessage {
+	var events []bus.StreamMessage
+	for _, rec := range records {
+		if rec.Stream != nil && f.match(rec.Stream) {
+			events = append(events, *rec.Stream)
+		}
+	}
+	return events
+}
+
+// Options control how events are replayed.
+type Options struct {
+	// Speed replays events with their recorded gaps divided by Speed, so
+	// timing bugs such as throttled edits show up; zero sends them back to
+	// back.
+	Speed float64
+	// MaxGap caps a single wait when Speed is set; zero means 5 seconds.
+	MaxGap time.Duration
+}
+
+// Replay sends events to h in order, as the bus would deliver them to a
+// channel, and returns how many it sent before ctx was done.
+func Replay(ctx context.Context, events []bus.StreamMessage, h bus.StreamHandler, opts Options) (int, error) {
+	maxGap := opts.MaxGap
+	if maxGap <= 0 {
+		maxGap = 5 * time.Second
+	}
+	for i, msg := range events {
+		if opts.Speed > 0 && i > 0 {
+			gap := time.Duration(float64(msg.Timestamp.Sub(events[i-1].Timestamp)) / opts.Speed)
+			if gap > maxGap {
+				gap = maxGap
+			}
+			if gap > 0 {
+				select {
+				case <-ctx.Done():
+					return i, ctx.Err()
+				case <-time.After(gap):
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		h.OnStreamEvent(msg)
+	}
+	return len(events), nil
+}
+
+// HandlerFunc adapts a function to bus.StreamHandler, for example to feed
+// a channel.Replies or print events.
+type HandlerFunc func(msg bus.StreamMessage)
+
+func (f HandlerFunc) OnStreamEvent(msg bus.StreamMessage) { f(msg) }
+
+// ReadRotated reads the recording at path together with the files
+// rotated out of it, oldest first.
+func ReadRotated(path string) ([]bus.Record, error) {
+	var backups []int
+	matches, _ := filepath.Glob(path + ".*")
+	for _, m := range matches {
+		if n, err := strconv.Atoi(strings.TrimPrefix(m, path+".")); err == nil && n > 0 {
+			backups = append(backups, n)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(backups)))
+
+	var records []bus.Record
+	for _, n := range backups {
+		recs, err := ReadFile(fmt.Sprintf("%s.%d", path, n))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, recs...)
+	}
+	recs, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return append(records, recs...), nil
+}
@@ -0,0 +1,35 @@
+package bus
+
+import "sync"
+
+// SeqChecker follows the sequence numbers of the stream events a consumer
+// receives, per chat, so it can tell lost events from reordered ones.
+type SeqChecker struct {
+	mu   sync.Mutex
+	last map[string]uint64
+}
+
+func NewSeqChecker() *SeqChecker {
+	return &SeqChecker{last: make(map[string]uint64)}
+}
+
+// Check records msg and returns how many of the chat's events were skipped
+// since the last one, and whether msg is older than an event already seen
+// and so arrived out of order. Events without a sequence number pass.
+func (c *SeqChecker) Check(msg StreamMessage) (missed uint64, stale bool) {
+	if msg.Seq == 0 {
+		return 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := msg.StreamKey()
+	last := c.last[key]
+	if msg.Seq <= last {
+		return 0, true
+	}
+	c.last[key] = msg.Seq
+	if last == 0 {
+		return 0, false
+	}
+	return msg.Seq - last - 1, false
+}
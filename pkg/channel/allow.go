@@ -0,0 +1,76 @@
+package channel
+
+import "strings"
+
+// ChatPrefix marks an allow-list entry that names a chat rather than a
+// sender, such as "chat:-1001234567890" for a Telegram group.
+const ChatPrefix = "chat:"
+
+// matchList applies an allow-list to a message from senderID ("id" or
+// "id|username") in chatID. Entries are:
+//
+//	"*"           anyone
+//	"123"         the sender with that ID
+//	"@alice"      the sender with that username, in any case
+//	"123|alice"   the sender with ID 123 (the username is only a reminder)
+//	"alice"       the sender whose ID or username is alice
+//	"chat:-100…"  anyone in that chat, in any of its threads
+//	"!<entry>"    never the senders the entry matches
+//
+// A denial wins over any allowing entry, so "!@mallory" keeps one member of
+// an allowed group out. A list with no allowing entries, including an empty
+// one, allows everyone it does not deny.
+func matchList(list []string, senderID, chatID string) bool {
+	allows, allowed := false, false
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		deny := strings.HasPrefix(entry, "!")
+		entry = strings.TrimPrefix(entry, "!")
+		if entry == "" {
+			continue
+		}
+		if !deny {
+			allows = true
+		}
+		if matchEntry(entry, senderID, chatID) {
+			if deny {
+				return false
+			}
+			allowed = true
+		}
+	}
+	return allowed || !allows
+}
+
+func matchEntry(entry, senderID, chatID string) bool {
+	id, username, _ := strings.Cut(senderID, "|")
+	switch {
+	case entry == "*":
+		return true
+	case strings.HasPrefix(entry, ChatPrefix):
+		return chatID != "" && ParentChat(chatID) == strings.TrimPrefix(entry, ChatPrefix)
+	case strings.HasPrefix(entry, "@"):
+		name := strings.TrimPrefix(entry, "@")
+		if username == "" {
+			// Channels such as Mattermost identify senders by username.
+			return strings.EqualFold(id, name)
+		}
+		return strings.EqualFold(username, name)
+	case strings.Contains(entry, "|"):
+		entryID, _, _ := strings.Cut(entry, "|")
+		return id == entryID
+	default:
+		return id == entry || (username != "" && strings.EqualFold(username, entry))
+	}
+}
+
+// denied reports whether an entry of list denies senderID in chatID.
+func denied(list []string, senderID, chatID string) bool {
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		if rest, ok := strings.CutPrefix(entry, "!"); ok && rest != "" && matchEntry(rest, senderID, chatID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,379 @@
+// Package channel holds what chat channels share: the Channel interface and
+// a BaseChannel with allow-lists, owner roles, and rate limiting.
+package channel
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/i18n"
+	"github.com/nene-agent/nene/pkg/tool"
+)
+
+type Channel interface {
+	Name() string
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+	Send(ctx context.Context, msg bus.OutboundMessage) error
+	IsRunning() bool
+	IsAllowed(senderID string) bool
+}
+
+type BaseChannel struct {
+	bus       *bus.MessageBus
+	running   bool
+	name      string
+	allowList []string
+	owners    []string
+	limiter   *RateLimiter
+	approvals *tool.ApprovalGate
+	pairing   *PairingStore
+	locale    string
+	locales   map[string]string
+	mu        sync.RWMutex
+}
+
+func NewBaseChannel(name string, messageBus *bus.MessageBus, allowList []string) *BaseChannel {
+	return &BaseChannel{
+		bus:       messageBus,
+		name:      name,
+		allowList: allowList,
+		limiter:   NewRateLimiter(RateLimitConfig{}),
+		locale:    i18n.Default,
+		locales:   make(map[string]string),
+		running:   false,
+	}
+}
+
+func (c *BaseChannel) Name() string {
+	return c.name
+}
+
+func (c *BaseChannel) IsRunning() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.running
+}
+
+func (c *BaseChannel) SetRunning(running bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running = running
+}
+
+func (c *BaseChannel) Bus() *bus.MessageBus {
+	return c.bus
+}
+
+// ConsumeStream delivers the channel's stream events to h, one at a time,
+// until ctx is done.
+func (c *BaseChannel) ConsumeStream(ctx context.Context, h bus.StreamHandler) {
+	stream := c.bus.ChannelStream(c.name)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-stream:
+				if !ok {
+					return
+				}
+				deliver(h, msg)
+			}
+		}
+	}()
+}
+
+// deliver passes msg to h, logging a panic instead of letting it end the
+// channel's stream.
+func deliver(h bus.StreamHandler, msg bus.StreamMessage) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("%s event of %s panicked: %v\n%s", msg.Type, msg.StreamKey(), r, debug.Stack())
+		}
+	}()
+	h.OnStreamEvent(msg)
+}
+
+// Allow applies the rate limits to a message without publishing it, for
+// requests that bypass HandleMessage. See RateLimiter.Allow.
+func (c *BaseChannel) Allow(senderID, chatID string) (ok bool, notice string) {
+	return c.limiter.Allow(senderID, chatID, c.Locale(chatID))
+}
+
+// ThreadSep separates a chat ID from a thread within the chat, as in
+// "-1001234567890/42" for a Telegram forum topic. Each thread is a
+// conversation of its own, while chat settings such as the locale and
+// "chat:" allow-list entries cover all of a chat's threads.
+const ThreadSep = "/"
+
+// ParentChat returns the chat a thread's chat ID belongs to, or chatID
+// itself when it names no thread.
+func ParentChat(chatID string) string {
+	chat, _, _ := strings.Cut(chatID, ThreadSep)
+	return chat
+}
+
+// SetDefaultLocale sets the locale of chats that have not chosen one and
+// whose users' language is not known.
+func (c *BaseChannel) SetDefaultLocale(locale string) {
+	if locale = i18n.Normalize(locale); locale == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.locale = locale
+}
+
+// Locale returns the locale user-visible strings of a chat are shown in.
+func (c *BaseChannel) Locale(chatID string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if l, ok := c.locales[ParentChat(chatID)]; ok {
+		return l
+	}
+	return c.locale
+}
+
+func (c *BaseChannel) SetLocale(chatID, locale string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.locales[ParentChat(chatID)] = locale
+}
+
+// detectLocale adopts the language a client reports for a chat that has
+// no locale yet.
+func (c *BaseChannel) detectLocale(chatID, languageCode string) {
+	locale := i18n.Normalize(languageCode)
+	if locale == "" {
+		return
+	}
+	chatID = ParentChat(chatID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.locales[chatID]; !ok {
+		c.locales[chatID] = locale
+	}
+}
+
+// handleLangCommand shows or switches the locale of a chat with /lang.
+// isCancelCommand reports whether content is /cancel, as in
+// /cancel@nene_bot.
+func isCancelCommand(content string) bool {
+	fields := strings.Fields(content)
+	if len(fields) != 1 {
+		return false
+	}
+	cmd, _, _ := strings.Cut(fields[0], "@")
+	return cmd == "/cancel"
+}
+
+func (c *BaseChannel) handleLangCommand(chatID, content string) bool {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return false
+	}
+	if cmd, _, _ := strings.Cut(fields[0], "@"); cmd != "/lang" {
+		return false
+	}
+
+	var available []string
+	for _, l := range i18n.Supported() {
+		available = append(available, fmt.Sprintf("%s (%s)", l, i18n.Name(l)))
+	}
+	list := strings.Join(available, ", ")
+
+	current := c.Locale(chatID)
+	var reply string
+	if len(fields) == 1 {
+		reply = i18n.T(current, "lang.current", i18n.Name(current), list)
+	} else if locale := i18n.Normalize(fields[1]); locale != "" {
+		c.SetLocale(chatID, locale)
+		reply = i18n.T(locale, "lang.set")
+	} else {
+		reply = i18n.T(current, "lang.unknown", fields[1], list)
+	}
+	c.bus.PublishOutbound(bus.OutboundMessage{
+		Channel: c.name,
+		ChatID:  chatID,
+		Content: reply,
+	})
+	return true
+}
+
+// SetAllowList replaces the allow-list at runtime, e.g. on config reload.
+func (c *BaseChannel) SetAllowList(allowList []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.allowList = allowList
+}
+
+// SetRateLimit replaces the rate limits at runtime, e.g. on config reload.
+func (c *BaseChannel) SetRateLimit(cfg RateLimitConfig) {
+	c.limiter.SetConfig(cfg)
+}
+
+// FinishTurn tells the rate limiter that the oldest pending turn in a chat
+// has completed.
+func (c *BaseChannel) FinishTurn(chatID string) {
+	c.limiter.Done(chatID)
+}
+
+// SetApprovalGate lets senders answer approval requests in this channel
+// with approve, always, or reject, with or without a leading slash.
+func (c *BaseChannel) SetApprovalGate(g *tool.ApprovalGate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.approvals = g
+}
+
+func (c *BaseChannel) ApprovalGate() *tool.ApprovalGate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.approvals
+}
+
+// SetOwners replaces the owner list. Entries use the same format as the
+// allow-list, though chat entries never match. With no owners every sender
+// is an owner.
+func (c *BaseChannel) SetOwners(owners []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.owners = owners
+}
+
+func (c *BaseChannel) RoleOf(senderID string) tool.Role {
+	c.mu.RLock()
+	owners := c.owners
+	c.mu.RUnlock()
+
+	if len(owners) == 0 || matchList(owners, senderID, "") {
+		return tool.RoleOwner
+	}
+	return tool.RoleUser
+}
+
+// IsAllowed reports whether senderID may talk to the channel wherever they
+// are. Entries naming a chat do not apply; see IsAllowedIn.
+func (c *BaseChannel) IsAllowed(senderID string) bool {
+	return c.IsAllowedIn(senderID, "")
+}
+
+// IsAllowedIn reports whether senderID may talk to the channel in chatID:
+// the allow-list lets them in (see matchList), or an owner approved them
+// through pairing and the allow-list does not deny them.
+func (c *BaseChannel) IsAllowedIn(senderID, chatID string) bool {
+	c.mu.RLock()
+	allowList, pairing := c.allowList, c.pairing
+	c.mu.RUnlock()
+	if matchList(allowList, senderID, chatID) {
+		return true
+	}
+	return pairing != nil && !denied(allowList, senderID, chatID) &&
+		pairing.Status(c.name, senderID) == PairingApproved
+}
+
+func (c *BaseChannel) handleApprovalCommand(senderID, chatID, content string) bool {
+	gate := c.ApprovalGate()
+	if gate == nil {
+		return false
+	}
+	cmd, reason, _ := strings.Cut(strings.TrimSpace(content), " ")
+	cmd, _, _ = strings.Cut(cmd, "@")
+	d, ok := tool.ParseDecision(cmd)
+	if !ok {
+		return false
+	}
+	if gate.ResolveChat(c.name, chatID, senderID, c.RoleOf(senderID), d, strings.TrimSpace(reason)) {
+		return true
+	}
+	// A bare "approve" with nothing pending is an ordinary message.
+	if !strings.HasPrefix(cmd, "/") {
+		return false
+	}
+	c.bus.PublishOutbound(bus.OutboundMessage{
+		Channel: c.name,
+		ChatID:  chatID,
+		Content: i18n.T(c.Locale(chatID), "approval.none"),
+	})
+	return true
+}
+
+// ApprovalPrompt renders an approval request in locale for channels that
+// take the answer as a text command.
+func ApprovalPrompt(msg bus.StreamMessage, locale string) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(locale, "approval.prompt", msg.Label, msg.ToolName, msg.Content))
+	if msg.ApprovalRule != "" {
+		sb.WriteString(i18n.T(locale, "approval.prompt_always", DescribeRule(msg.ToolName, msg.ApprovalRule)))
+	}
+	sb.WriteString(i18n.T(locale, "approval.prompt_end"))
+	return sb.String()
+}
+
+// DescribeRule phrases what remembering an approval rule allows.
+func DescribeRule(toolName, rule string) string {
+	if rule == "*" {
+		return toolName
+	}
+	return fmt.Sprintf("%s %q", toolName, rule)
+}
+
+// HandleMessage publishes an inbound message and reports whether it was
+// accepted. Messages from senders that are not allowed or are over their
+// rate limit are dropped; the latter get a "slow down" reply.
+func (c *BaseChannel) HandleMessage(senderID, chatID, content string, media []string, metadata map[string]string, streamMode bool) bool {
+	if !c.IsAllowedIn(senderID, chatID) {
+		return false
+	}
+
+	// Approval answers bypass the queue: the turn waiting for them holds it.
+	if c.handleApprovalCommand(senderID, chatID, content) {
+		return false
+	}
+
+	if c.handleLangCommand(chatID, content) {
+		return false
+	}
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	c.detectLocale(chatID, metadata["language_code"])
+	metadata["locale"] = c.Locale(chatID)
+
+	// /cancel stops a turn instead of starting one, so it is not limited
+	// and takes no turn slot.
+	if !isCancelCommand(content) {
+		if ok, notice := c.Allow(senderID, chatID); !ok {
+			if notice != "" {
+				c.bus.PublishOutbound(bus.OutboundMessage{
+					Channel: c.name,
+					ChatID:  chatID,
+					Content: notice,
+				})
+			}
+			return false
+		}
+	}
+
+	sessionKey := fmt.Sprintf("%s:%s", c.name, chatID)
+
+	msg := bus.InboundMessage{
+		Channel:    c.name,
+		SenderID:   senderID,
+		ChatID:     chatID,
+		Content:    content,
+		Media:      media,
+		SessionKey: sessionKey,
+		Metadata:   metadata,
+		StreamMode: streamMode,
+		Role:       string(c.RoleOf(senderID)),
+	}
+
+	c.bus.PublishInbound(msg)
+	return true
+}
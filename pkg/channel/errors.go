@@ -0,0 +1,55 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/i18n"
+	"github.com/nene-agent/nene/pkg/model"
+	"github.com/nene-agent/nene/pkg/tool"
+)
+
+// DescribeError returns the code of an error event for err, and a short
+// description that is safe to show in the chat. Provider responses and
+// request URLs are left out, since they can carry parts of the API key; the
+// full error is only logged.
+func DescribeError(err error) (code, text string) {
+	var apiErr *model.APIError
+	var urlErr *url.Error
+	var timeout *tool.TimeoutError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return bus.ErrorCodeCancelled, "the turn was stopped"
+	case errors.As(err, &timeout):
+		return bus.ErrorCodeToolTimeout, timeout.Error()
+	case errors.Is(err, model.ErrAuth):
+		return bus.ErrorCodeAuth, model.ErrAuth.Error()
+	case errors.Is(err, model.ErrRateLimit):
+		return bus.ErrorCodeRateLimit, model.ErrRateLimit.Error()
+	case errors.Is(err, model.ErrContextOverflow):
+		return bus.ErrorCodeContextOverflow, model.ErrContextOverflow.Error()
+	case errors.Is(err, model.ErrUnavailable):
+		return bus.ErrorCodeUnavailable, model.ErrUnavailable.Error()
+	case errors.As(err, &apiErr):
+		return bus.ErrorCodeProvider, fmt.Sprintf("the provider answered with status %d", apiErr.StatusCode)
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &urlErr):
+		return bus.ErrorCodeUnavailable, "the provider could not be reached"
+	}
+	return "", err.Error()
+}
+
+// ErrorText returns what to tell the chat about an error or tool error
+// event in locale: advice fitting its code. ok is false for events without
+// a known code, which only have their description to show.
+func ErrorText(locale string, msg bus.StreamMessage) (text string, ok bool) {
+	switch msg.ErrorCode {
+	case bus.ErrorCodeAuth, bus.ErrorCodeRateLimit, bus.ErrorCodeContextOverflow,
+		bus.ErrorCodeUnavailable, bus.ErrorCodeProvider, bus.ErrorCodeToolTimeout,
+		bus.ErrorCodeCancelled, bus.ErrorCodeInternal:
+		return i18n.T(locale, "error."+msg.ErrorCode), true
+	}
+	return "", false
+}
@@ -0,0 +1,139 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/nene-agent/nene/pkg/bus"
+)
+
+// Manager runs several channels on one message bus. Every channel feeds the
+// same inbound queue and agent, and outbound messages are delivered to the
+// channel named in their Channel field.
+type Manager struct {
+	bus *bus.MessageBus
+
+	mu       sync.RWMutex
+	channels map[string]Channel
+	cancel   context.CancelFunc
+}
+
+func NewManager(messageBus *bus.MessageBus) *Manager {
+	return &Manager{
+		bus:      messageBus,
+		channels: make(map[string]Channel),
+	}
+}
+
+// Register adds a channel. Channel names must be unique.
+func (m *Manager) Register(ch Channel) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.channels[ch.Name()]; ok {
+		return fmt.Errorf("channel %q is already registered", ch.Name())
+	}
+	m.channels[ch.Name()] = ch
+	return nil
+}
+
+func (m *Manager) Get(name string) (Channel, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ch, ok := m.channels[name]
+	return ch, ok
+}
+
+// Names returns the registered channel names, sorted.
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.channels))
+	for name := range m.channels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Start starts every channel and begins routing outbound messages. If a
+// channel fails to start, the ones already started are stopped again.
+func (m *Manager) Start(ctx context.Context) error {
+	if len(m.Names()) == 0 {
+		return fmt.Errorf("no channels configured")
+	}
+	ctx, m.cancel = context.WithCancel(ctx)
+
+	var started []Channel
+	for _, name := range m.Names() {
+		ch, _ := m.Get(name)
+		if err := ch.Start(ctx); err != nil {
+			for _, s := range started {
+				s.Stop(context.Background())
+			}
+			m.cancel()
+			return fmt.Errorf("start %s channel: %w", name, err)
+		}
+		started = append(started, ch)
+	}
+
+	go m.dispatchOutbound(ctx)
+	return nil
+}
+
+func (m *Manager) Stop(ctx context.Context) {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	for _, name := range m.Names() {
+		ch, _ := m.Get(name)
+		if err := ch.Stop(ctx); err != nil {
+			fmt.Printf("Failed to stop %s channel: %v\n", name, err)
+		}
+	}
+}
+
+// dispatchOutbound hands each outbound message to a per-channel sender, so
+// a slow channel such as email delays only its own messages. Each channel
+// still receives its messages in order.
+func (m *Manager) dispatchOutbound(ctx context.Context) {
+	queues := make(map[string]chan bus.OutboundMessage)
+	for {
+		msg, ok := m.bus.SubscribeOutbound(ctx)
+		if !ok {
+			return
+		}
+		ch, ok := m.Get(msg.Channel)
+		if !ok {
+			fmt.Printf("Dropping outbound message for unknown channel %q\n", msg.Channel)
+			m.bus.OutboundSent(msg)
+			continue
+		}
+		q := queues[msg.Channel]
+		if q == nil {
+			q = make(chan bus.OutboundMessage, bus.DefaultBufferSize)
+			queues[msg.Channel] = q
+			go m.send(ctx, ch, q)
+		}
+		select {
+		case q <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Manager) send(ctx context.Context, ch Channel, queue <-chan bus.OutboundMessage) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-queue:
+			if err := ch.Send(ctx, msg); err != nil {
+				fmt.Printf("Failed to send to %s:%s: %v\n", msg.Channel, msg.ChatID, err)
+			}
+			m.bus.OutboundSent(msg)
+		}
+	}
+}
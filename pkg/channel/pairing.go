@@ -0,0 +1,171 @@
+package channel
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Pairing decisions.
+const (
+	PairingApproved = "approved"
+	PairingDenied   = "denied"
+)
+
+// Pairing is an owner's answer to a sender who asked for access.
+type Pairing struct {
+	Channel   string    `json:"channel"`
+	SenderID  string    `json:"sender_id"`
+	Name      string    `json:"name,omitempty"`
+	Status    string    `json:"status"`
+	DecidedBy string    `json:"decided_by,omitempty"`
+	Decided   time.Time `json:"decided"`
+}
+
+// PairingStore keeps the senders owners let in, or turned away, through
+// pairing in pairing.db. Decisions are also kept in memory, since every
+// message from a sender not on the allow-list is checked against them.
+type PairingStore struct {
+	db *sql.DB
+
+	mu     sync.RWMutex
+	status map[string]string
+}
+
+func OpenPairingStore(dataDir string) (*PairingStore, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("create data directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(dataDir, "pairing.db"))
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS pairings (
+		channel    TEXT NOT NULL,
+		sender_id  TEXT NOT NULL,
+		name       TEXT NOT NULL,
+		status     TEXT NOT NULL,
+		decided_by TEXT NOT NULL,
+		decided_at DATETIME NOT NULL,
+		PRIMARY KEY (channel, sender_id)
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init schema: %w", err)
+	}
+	s := &PairingStore{db: db, status: make(map[string]string)}
+	pairings, err := s.List(context.Background(), "")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("load pairings: %w", err)
+	}
+	for _, p := range pairings {
+		s.status[pairingKey(p.Channel, p.SenderID)] = p.Status
+	}
+	return s, nil
+}
+
+func pairingKey(channel, senderID string) string {
+	// Only the ID counts; usernames can change hands.
+	id, _, _ := strings.Cut(senderID, "|")
+	return channel + "\x00" + id
+}
+
+func (s *PairingStore) Close() error {
+	return s.db.Close()
+}
+
+// Status returns the decision about senderID in channel, or "" when none
+// was made.
+func (s *PairingStore) Status(channel, senderID string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status[pairingKey(channel, senderID)]
+}
+
+// Decide records p, replacing an earlier decision about the same sender.
+func (s *PairingStore) Decide(ctx context.Context, p Pairing) error {
+	if p.Decided.IsZero() {
+		p.Decided = time.Now().UTC()
+	}
+	id, _, _ := strings.Cut(p.SenderID, "|")
+	_, err := s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO pairings (channel, sender_id, name, status, decided_by, decided_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		p.Channel, id, p.Name, p.Status, p.DecidedBy, p.Decided)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status[pairingKey(p.Channel, id)] = p.Status
+	return nil
+}
+
+// List returns the decisions of channel, or of every channel when it is
+// empty, oldest first.
+func (s *PairingStore) List(ctx context.Context, channel string) ([]Pairing, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT channel, sender_id, name, status, decided_by, decided_at FROM pairings
+		WHERE ? = '' OR channel = ? ORDER BY decided_at`, channel, channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var pairings []Pairing
+	for rows.Next() {
+		var p Pairing
+		if err := rows.Scan(&p.Channel, &p.SenderID, &p.Name, &p.Status, &p.DecidedBy, &p.Decided); err != nil {
+			return nil, err
+		}
+		pairings = append(pairings, p)
+	}
+	return pairings, rows.Err()
+}
+
+// Remove forgets the decision about senderID, so an approved sender loses
+// access and a turned-away one may ask again. It reports whether there was
+// one.
+func (s *PairingStore) Remove(ctx context.Context, channel, senderID string) (bool, error) {
+	id, _, _ := strings.Cut(senderID, "|")
+	res, err := s.db.ExecContext(ctx, `DELETE FROM pairings WHERE channel = ? AND sender_id = ?`, channel, id)
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	delete(s.status, pairingKey(channel, id))
+	s.mu.Unlock()
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SetPairingStore lets senders an owner approved through pairing in, in
+// addition to the allow-list.
+func (c *BaseChannel) SetPairingStore(s *PairingStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pairing = s
+}
+
+func (c *BaseChannel) PairingStore() *PairingStore {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pairing
+}
+
+// CanPair reports whether senderID, who is not allowed, may ask an owner
+// for access: pairing is on, the allow-list does not deny them, and no
+// owner has turned them away.
+func (c *BaseChannel) CanPair(senderID string) bool {
+	c.mu.RLock()
+	store, allowList := c.pairing, c.allowList
+	c.mu.RUnlock()
+	return store != nil && !denied(allowList, senderID, "") && store.Status(c.name, senderID) == ""
+}
@@ -0,0 +1,200 @@
+package channel
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/i18n"
+)
+
+const (
+	rateWindow      = time.Minute
+	violationWindow = 10 * time.Minute
+	defaultBanTime  = 10 * time.Minute
+	// turnTimeout releases a concurrent-turn slot whose finish event never
+	// arrived.
+	turnTimeout = 10 * time.Minute
+)
+
+// RateLimitConfig bounds how fast a single sender or chat can drive the
+// agent. Zero values disable the corresponding limit.
+type RateLimitConfig struct {
+	UserPerMinute int `json:"user_per_minute"`
+	ChatPerMinute int `json:"chat_per_minute"`
+	MaxConcurrent int `json:"max_concurrent"`
+	// BanAfter temporarily bans a sender after this many rejected messages
+	// within ten minutes.
+	BanAfter   int `json:"ban_after"`
+	BanMinutes int `json:"ban_minutes"`
+}
+
+type senderState struct {
+	hits        []time.Time
+	violations  []time.Time
+	active      int
+	bannedUntil time.Time
+	warned      time.Time
+}
+
+type pendingTurn struct {
+	senderID string
+	at       time.Time
+}
+
+// RateLimiter enforces RateLimitConfig with sliding one-minute windows.
+type RateLimiter struct {
+	mu        sync.Mutex
+	cfg       RateLimitConfig
+	senders   map[string]*senderState
+	chats     map[string][]time.Time
+	pending   map[string][]pendingTurn
+	lastSweep time.Time
+}
+
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		cfg:     cfg,
+		senders: make(map[string]*senderState),
+		chats:   make(map[string][]time.Time),
+		pending: make(map[string][]pendingTurn),
+	}
+}
+
+// SetConfig replaces the limits, keeping the current counters.
+func (l *RateLimiter) SetConfig(cfg RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+}
+
+// Allow records a message and reports whether it may be processed. When it
+// may not, notice is the reply to send the sender; it is empty when the
+// sender was already told recently or is banned. The notice is in locale.
+func (l *RateLimiter) Allow(senderID, chatID, locale string) (ok bool, notice string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	s := l.senders[senderID]
+	if s == nil {
+		s = &senderState{}
+		l.senders[senderID] = s
+	}
+	if now.Before(s.bannedUntil) {
+		return false, ""
+	}
+
+	s.hits = prune(s.hits, now.Add(-rateWindow))
+	chat := prune(l.chats[chatID], now.Add(-rateWindow))
+	l.chats[chatID] = chat
+
+	var reason string
+	switch {
+	case l.cfg.UserPerMinute > 0 && len(s.hits) >= l.cfg.UserPerMinute:
+		reason = i18n.T(locale, "limit.user", l.cfg.UserPerMinute)
+	case l.cfg.ChatPerMinute > 0 && len(chat) >= l.cfg.ChatPerMinute:
+		reason = i18n.T(locale, "limit.chat", l.cfg.ChatPerMinute)
+	case l.cfg.MaxConcurrent > 0 && s.active >= l.cfg.MaxConcurrent:
+		reason = i18n.T(locale, "limit.concurrent")
+	}
+
+	if reason == "" {
+		s.hits = append(s.hits, now)
+		l.chats[chatID] = append(chat, now)
+		if l.cfg.MaxConcurrent > 0 {
+			s.active++
+			l.pending[chatID] = append(l.pending[chatID], pendingTurn{senderID: senderID, at: now})
+		}
+		return true, ""
+	}
+
+	s.violations = append(prune(s.violations, now.Add(-violationWindow)), now)
+	if l.cfg.BanAfter > 0 && len(s.violations) >= l.cfg.BanAfter {
+		banTime := time.Duration(l.cfg.BanMinutes) * time.Minute
+		if banTime <= 0 {
+			banTime = defaultBanTime
+		}
+		s.bannedUntil = now.Add(banTime)
+		s.violations = nil
+		return false, i18n.T(locale, "limit.banned", banTime)
+	}
+
+	if now.Sub(s.warned) < rateWindow {
+		return false, ""
+	}
+	s.warned = now
+	return false, "⏳ " + reason
+}
+
+// Done releases the oldest unfinished turn in a chat. Turns in a chat are
+// processed in order, so that is the turn that just finished.
+func (l *RateLimiter) Done(chatID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	turns := l.pending[chatID]
+	if len(turns) == 0 {
+		return
+	}
+	l.release(turns[0].senderID)
+	if len(turns) == 1 {
+		delete(l.pending, chatID)
+	} else {
+		l.pending[chatID] = turns[1:]
+	}
+}
+
+func (l *RateLimiter) release(senderID string) {
+	if s := l.senders[senderID]; s != nil && s.active > 0 {
+		s.active--
+	}
+}
+
+// sweep drops idle state and turns that never finished so the maps do not
+// grow without bound.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateWindow {
+		return
+	}
+	l.lastSweep = now
+
+	for chatID, turns := range l.pending {
+		kept := turns[:0]
+		for _, t := range turns {
+			if now.Sub(t.at) > turnTimeout {
+				l.release(t.senderID)
+				continue
+			}
+			kept = append(kept, t)
+		}
+		if len(kept) == 0 {
+			delete(l.pending, chatID)
+		} else {
+			l.pending[chatID] = kept
+		}
+	}
+
+	for chatID, hits := range l.chats {
+		if len(prune(hits, now.Add(-rateWindow))) == 0 {
+			delete(l.chats, chatID)
+		}
+	}
+	for id, s := range l.senders {
+		s.hits = prune(s.hits, now.Add(-rateWindow))
+		s.violations = prune(s.violations, now.Add(-violationWindow))
+		if len(s.hits) == 0 && len(s.violations) == 0 && s.active == 0 && now.After(s.bannedUntil) {
+			delete(l.senders, id)
+		}
+	}
+}
+
+// prune drops timestamps before cutoff. ts is in ascending order.
+func prune(ts []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(ts) && ts[i].Before(cutoff) {
+		i++
+	}
+	return ts[i:]
+}
@@ -0,0 +1,62 @@
+package channel
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/i18n"
+)
+
+// Replies assembles streamed answers for channels that cannot edit a message
+// as it grows and post each answer once, when its turn ends.
+type Replies struct {
+	mu      sync.Mutex
+	pending map[string]*reply
+}
+
+type reply struct {
+	text strings.Builder
+	last string
+}
+
+func NewReplies() *Replies {
+	return &Replies{pending: make(map[string]*reply)}
+}
+
+// Collect records a stream event and, when the turn is over, returns the
+// text to post. Only the text of the final model call is returned; earlier
+// text accompanied tool calls. Errors are returned as an apology or advice in locale.
+func (r *Replies) Collect(msg bus.StreamMessage, locale string) (text string, done bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := r.pending[msg.ChatID]
+	if p == nil {
+		p = &reply{}
+		r.pending[msg.ChatID] = p
+	}
+	switch msg.Type {
+	case bus.StreamEventTextStart:
+		if p.text.Len() > 0 {
+			p.last = p.text.String()
+			p.text.Reset()
+		}
+	case bus.StreamEventTextDelta:
+		p.text.WriteString(msg.Content)
+	case bus.StreamEventFinish:
+		text = p.text.String()
+		if strings.TrimSpace(text) == "" {
+			text = p.last
+		}
+		delete(r.pending, msg.ChatID)
+		return text, true
+	case bus.StreamEventError:
+		delete(r.pending, msg.ChatID)
+		if text, ok := ErrorText(locale, msg); ok {
+			return text, true
+		}
+		return i18n.T(locale, "reply.error", msg.Content), true
+	}
+	return "", false
+}
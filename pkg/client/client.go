@@ -0,0 +1,174 @@
+// Package client is a Go client for nene's gRPC API.
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/nene-agent/nene/pkg/rpc/nenepb"
+)
+
+type Client struct {
+	conn *grpc.ClientConn
+	api  nenepb.NeneClient
+}
+
+type options struct {
+	token    string
+	dialOpts []grpc.DialOption
+}
+
+type Option func(*options)
+
+// WithToken sends the API token with every call.
+func WithToken(token string) Option {
+	return func(o *options) { o.token = token }
+}
+
+// WithDialOptions adds gRPC dial options, e.g. transport credentials for a
+// server behind TLS. Without them the connection is not encrypted.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) { o.dialOpts = append(o.dialOpts, opts...) }
+}
+
+// Dial connects to a nene gRPC API at target, e.g. "127.0.0.1:8092".
+func Dial(target string, opts ...Option) (*Client, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if o.token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(bearer(o.token)))
+	}
+	dialOpts = append(dialOpts, o.dialOpts...)
+
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, api: nenepb.NewNeneClient(conn)}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// API returns the generated client for calls this package does not wrap.
+func (c *Client) API() nenepb.NeneClient {
+	return c.api
+}
+
+// Send sends a message to a chat and waits for the agent's reply.
+func (c *Client) Send(ctx context.Context, chatID, content string) (string, error) {
+	resp, err := c.api.SubmitMessage(ctx, &nenepb.SubmitMessageRequest{
+		ChatId:  chatID,
+		Content: content,
+		Wait:    true,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Reply, nil
+}
+
+// Submit sends a message to a chat without waiting for the turn; follow it
+// with Events. senderID may be empty.
+func (c *Client) Submit(ctx context.Context, chatID, senderID, content string) (string, error) {
+	resp, err := c.api.SubmitMessage(ctx, &nenepb.SubmitMessageRequest{
+		ChatId:   chatID,
+		SenderId: senderID,
+		Content:  content,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.SessionKey, nil
+}
+
+// Events calls fn with the events of a chat, or of every grpc chat when
+// chatID is empty, until ctx is cancelled or fn returns an error.
+func (c *Client) Events(ctx context.Context, chatID string, fn func(*nenepb.Event) error) error {
+	stream, err := c.api.StreamEvents(ctx, &nenepb.StreamEventsRequest{ChatId: chatID})
+	if err != nil {
+		return err
+	}
+	for {
+		ev, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) Sessions(ctx context.Context) ([]*nenepb.Session, error) {
+	resp, err := c.api.ListSessions(ctx, &nenepb.ListSessionsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Sessions, nil
+}
+
+// Remember stores content under key; an empty category means "core".
+func (c *Client) Remember(ctx context.Context, key, content, category string) (*nenepb.MemoryEntry, error) {
+	resp, err := c.api.ManageMemory(ctx, &nenepb.ManageMemoryRequest{
+		Action:   nenepb.ManageMemoryRequest_ACTION_STORE,
+		Key:      key,
+		Content:  content,
+		Category: category,
+	})
+	if err != nil || len(resp.Entries) == 0 {
+		return nil, err
+	}
+	return resp.Entries[0], nil
+}
+
+func (c *Client) Recall(ctx context.Context, query string, limit int) ([]*nenepb.MemoryEntry, error) {
+	resp, err := c.api.ManageMemory(ctx, &nenepb.ManageMemoryRequest{
+		Action: nenepb.ManageMemoryRequest_ACTION_RECALL,
+		Query:  query,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Entries, nil
+}
+
+// Forget deletes the memory stored under key and reports whether there was
+// one.
+func (c *Client) Forget(ctx context.Context, key string) (bool, error) {
+	resp, err := c.api.ManageMemory(ctx, &nenepb.ManageMemoryRequest{
+		Action: nenepb.ManageMemoryRequest_ACTION_FORGET,
+		Key:    key,
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.Forgotten, nil
+}
+
+type bearer string
+
+func (b bearer) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(b)}, nil
+}
+
+// RequireTransportSecurity is false so the token can be used on the
+// default plaintext connection to a local server.
+func (b bearer) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,278 @@
+// Package doctor checks that nene's config, providers, channels, and data
+// files work, for `nene doctor` and the admin API's readiness endpoint.
+package doctor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/nene-agent/nene/config"
+	"github.com/nene-agent/nene/pkg/model"
+	"github.com/nene-agent/nene/pkg/model/providers"
+)
+
+// CheckTimeout bounds each check.
+const CheckTimeout = 20 * time.Second
+
+// Check is one diagnostic. Run returns a short description of what it found,
+// or an error when the check failed.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (string, error)
+}
+
+type Result struct {
+	Name     string        `json:"name"`
+	OK       bool          `json:"ok"`
+	Detail   string        `json:"detail,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// OK reports whether every check passed.
+func (r Report) OK() bool {
+	for _, res := range r.Results {
+		if !res.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// Write prints the report, one line per check.
+func (r Report) Write(w io.Writer) {
+	failed := 0
+	for _, res := range r.Results {
+		if res.OK {
+			fmt.Fprintf(w, "✓ %s", res.Name)
+			if res.Detail != "" {
+				fmt.Fprintf(w, ": %s", res.Detail)
+			}
+		} else {
+			failed++
+			fmt.Fprintf(w, "✗ %s: %s", res.Name, res.Error)
+		}
+		fmt.Fprintf(w, " (%s)\n", res.Duration.Round(time.Millisecond))
+	}
+	if failed == 0 {
+		fmt.Fprintf(w, "\nAll %d checks passed.\n", len(r.Results))
+	} else {
+		fmt.Fprintf(w, "\n%d of %d checks failed.\n", failed, len(r.Results))
+	}
+}
+
+// Run runs the checks side by side and reports them in the order given.
+func Run(ctx context.Context, checks ...Check) Report {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, c)
+		}()
+	}
+	wg.Wait()
+	return Report{Results: results}
+}
+
+func run(ctx context.Context, c Check) (res Result) {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+	start := time.Now()
+	res.Name = c.Name
+	defer func() {
+		if r := recover(); r != nil {
+			res.OK = false
+			res.Error = fmt.Sprintf("check crashed: %v", r)
+		}
+		res.Duration = time.Since(start)
+	}()
+	detail, err := c.Run(ctx)
+	res.OK = err == nil
+	res.Detail = detail
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// ConfigFile validates the config at path as Load would.
+func ConfigFile(path string) Check {
+	return Check{Name: "config", Run: func(ctx context.Context) (string, error) {
+		if _, err := os.Stat(path); err != nil {
+			return "", err
+		}
+		if err := config.ValidateFile(path); err != nil {
+			return "", err
+		}
+		return path, nil
+	}}
+}
+
+// Provider sends the smallest possible request to a provider.
+func Provider(name string, p model.Provider, modelID string) Check {
+	return Check{Name: "provider " + name, Run: func(ctx context.Context) (string, error) {
+		resp, err := p.Send(ctx, &model.Request{
+			Model:     modelID,
+			Messages:  []model.Message{{Role: "user", Content: "ping"}},
+			MaxTokens: 1,
+		})
+		if err != nil {
+			return "", err
+		}
+		if resp.Model != "" {
+			modelID = resp.Model
+		}
+		return modelID + " answered", nil
+	}}
+}
+
+// TelegramAPI is the Bot API base URL the Telegram check calls.
+var TelegramAPI = "https://api.telegram.org"
+
+// Telegram checks a bot token with getMe, through proxy when one is set.
+func Telegram(token, proxy string) Check {
+	return Check{Name: "telegram", Run: func(ctx context.Context) (string, error) {
+		client := &http.Client{}
+		if proxy != "" {
+			u, err := url.Parse(proxy)
+			if err != nil {
+				return "", fmt.Errorf("invalid proxy: %w", err)
+			}
+			client.Transport = &http.Transport{Proxy: http.ProxyURL(u)}
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, TelegramAPI+"/bot"+token+"/getMe", nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			// The error includes the URL, and so the token.
+			return "", fmt.Errorf("cannot reach the Bot API: %s", strings.ReplaceAll(err.Error(), token, "<token>"))
+		}
+		defer resp.Body.Close()
+		var body struct {
+			OK          bool   `json:"ok"`
+			Description string `json:"description"`
+			Result      struct {
+				Username string `json:"username"`
+			} `json:"result"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return "", fmt.Errorf("unexpected answer (%s)", resp.Status)
+		}
+		if !body.OK {
+			return "", fmt.Errorf("token rejected: %s", body.Description)
+		}
+		return "@" + body.Result.Username, nil
+	}}
+}
+
+// SQLite checks that the database at path can be opened and written. A
+// missing database passes when its directory is writable, since it is
+// created on first use.
+func SQLite(path string) Check {
+	return Check{Name: "sqlite " + filepath.Base(path), Run: func(ctx context.Context) (string, error) {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := writableDir(filepath.Dir(path)); err != nil {
+				return "", err
+			}
+			return "not created yet", nil
+		}
+		db, err := sql.Open("sqlite", path)
+		if err != nil {
+			return "", err
+		}
+		defer db.Close()
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		// BEGIN IMMEDIATE takes the write lock without changing anything.
+		if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+			return "", fmt.Errorf("not writable: %w", err)
+		}
+		conn.ExecContext(ctx, "ROLLBACK")
+		var version sql.NullInt64
+		if conn.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&version) == nil && version.Valid {
+			return fmt.Sprintf("writable, schema version %d", version.Int64), nil
+		}
+		return "writable", nil
+	}}
+}
+
+// DataDir checks that dir exists or can be created, and is writable.
+func DataDir(dir string) Check {
+	return Check{Name: "data directory", Run: func(ctx context.Context) (string, error) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+		if err := writableDir(dir); err != nil {
+			return "", err
+		}
+		return dir, nil
+	}}
+}
+
+func writableDir(dir string) error {
+	f, err := os.CreateTemp(dir, ".nene-doctor-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// DataFiles are the SQLite databases nene keeps in its data directory.
+var DataFiles = []string{"memory.db", "tasks.db", "approvals.db", "kb.db", "pairing.db", "toolstats.db"}
+
+// ForConfig returns the checks the `nene doctor` command runs for the config
+// at path: the config itself, the data directory and its databases, a ping
+// of every provider, and the Telegram token when one is set.
+func ForConfig(cfg *config.Config, path string) []Check {
+	checks := []Check{ConfigFile(path), DataDir(config.DataDir())}
+	for _, name := range DataFiles {
+		checks = append(checks, SQLite(filepath.Join(config.DataDir(), name)))
+	}
+
+	reg := model.NewRegistry()
+	if err := providers.Setup(reg, cfg); err != nil {
+		checks = append(checks, Check{Name: "providers", Run: func(ctx context.Context) (string, error) {
+			return "", err
+		}})
+	} else {
+		ids := reg.ListProviders()
+		sort.Strings(ids)
+		for _, id := range ids {
+			p, modelID, err := reg.Resolve(id + "/")
+			if err != nil {
+				continue
+			}
+			checks = append(checks, Provider(id, p, modelID))
+		}
+	}
+
+	if cfg.Telegram.Token != "" {
+		checks = append(checks, Telegram(cfg.Telegram.Token, cfg.Telegram.Proxy))
+	}
+	return checks
+}
@@ -0,0 +1,380 @@
+// Package document extracts plain text from PDF, DOCX, and XLSX files.
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Document kinds whose text can be extracted.
+const (
+	PDF  = "pdf"
+	DOCX = "docx"
+	XLSX = "xlsx"
+)
+
+// Options selects part of a document: Pages is a page range such as
+// "1-3,7" for PDFs, Sheet a sheet name or 1-based index for spreadsheets.
+type Options struct {
+	Pages string
+	Sheet string
+}
+
+// Kind identifies a PDF, DOCX, or XLSX document from its name,
+// content type, or leading bytes. It returns "" for anything else.
+func Kind(name, contentType string, data []byte) string {
+	ext := strings.ToLower(path.Ext(name))
+	switch {
+	case ext == ".pdf" || strings.Contains(contentType, "application/pdf"):
+		return PDF
+	case ext == ".docx" || strings.Contains(contentType, "wordprocessingml"):
+		return DOCX
+	case ext == ".xlsx" || strings.Contains(contentType, "spreadsheetml"):
+		return XLSX
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return PDF
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return ""
+		}
+		for _, f := range zr.File {
+			switch f.Name {
+			case "word/document.xml":
+				return DOCX
+			case "xl/workbook.xml":
+				return XLSX
+			}
+		}
+	}
+	return ""
+}
+
+// Extract returns the text of a document of the given kind.
+func Extract(kind string, data []byte, opts Options) (string, error) {
+	switch kind {
+	case PDF:
+		return extractPDF(data, opts.Pages)
+	case DOCX:
+		return extractDOCX(data)
+	case XLSX:
+		return extractXLSX(data, opts.Sheet)
+	default:
+		return "", fmt.Errorf("unsupported document type %q", kind)
+	}
+}
+
+func extractPDF(data []byte, pages string) (text string, err error) {
+	// The parser panics on some malformed files.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("open PDF: %w", err)
+	}
+	total := r.NumPage()
+	selected, err := parsePageRange(pages, total)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "PDF with %d pages\n", total)
+	for _, n := range selected {
+		p := r.Page(n)
+		if p.V.IsNull() {
+			continue
+		}
+		content, err := p.GetPlainText(nil)
+		if err != nil {
+			fmt.Fprintf(&sb, "\n--- Page %d ---\n(could not extract text: %v)\n", n, err)
+			continue
+		}
+		fmt.Fprintf(&sb, "\n--- Page %d ---\n%s\n", n, strings.TrimSpace(content))
+	}
+	return sb.String(), nil
+}
+
+// parsePageRange expands a range like "1-3,7,10-" into page numbers within
+// 1..total. An empty range selects every page.
+func parsePageRange(spec string, total int) ([]int, error) {
+	if strings.TrimSpace(spec) == "" {
+		spec = "1-"
+	}
+	var pages []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("invalid page range %q", part)
+		}
+		end := start
+		if isRange {
+			end = total
+			if hi = strings.TrimSpace(hi); hi != "" {
+				if end, err = strconv.Atoi(hi); err != nil || end < start {
+					return nil, fmt.Errorf("invalid page range %q", part)
+				}
+			}
+		}
+		for n := start; n <= min(end, total); n++ {
+			if !seen[n] {
+				seen[n] = true
+				pages = append(pages, n)
+			}
+		}
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("page range %q is outside the document (%d pages)", spec, total)
+	}
+	return pages, nil
+}
+
+func openZipFile(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, 50*1024*1024))
+		}
+	}
+	return nil, fmt.Errorf("%s not found", name)
+}
+
+// extractDOCX returns the paragraphs of a Word document, with table cells
+// separated by tabs.
+func extractDOCX(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("open DOCX: %w", err)
+	}
+	doc, err := openZipFile(zr, "word/document.xml")
+	if err != nil {
+		return "", fmt.Errorf("open DOCX: %w", err)
+	}
+
+	var sb strings.Builder
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	inText := false
+	cellDepth := 0
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("parse DOCX: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tc":
+				cellDepth++
+			case "tab":
+				sb.WriteByte('\t')
+			case "br", "cr":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if cellDepth == 0 {
+					sb.WriteByte('\n')
+				} else {
+					sb.WriteByte(' ')
+				}
+			case "tc":
+				cellDepth--
+				sb.WriteByte('\t')
+			case "tr":
+				sb.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRels struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []struct {
+		T    string `xml:"t"`
+		Runs []struct {
+			T string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				T string `xml:"t"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// extractXLSX renders sheets as tab-separated rows. sheet selects one sheet
+// by name or 1-based index; empty means all sheets.
+func extractXLSX(data []byte, sheet string) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("open XLSX: %w", err)
+	}
+
+	var wb xlsxWorkbook
+	if err := unmarshalZipXML(zr, "xl/workbook.xml", &wb); err != nil {
+		return "", err
+	}
+	var rels xlsxRels
+	if err := unmarshalZipXML(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	targets := make(map[string]string)
+	for _, r := range rels.Relationships {
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join("xl", r.Target)
+		}
+	}
+
+	var shared []string
+	var ss xlsxSharedStrings
+	if err := unmarshalZipXML(zr, "xl/sharedStrings.xml", &ss); err == nil {
+		for _, si := range ss.Items {
+			s := si.T
+			for _, r := range si.Runs {
+				s += r.T
+			}
+			shared = append(shared, s)
+		}
+	}
+
+	var names []string
+	for _, s := range wb.Sheets {
+		names = append(names, s.Name)
+	}
+
+	var sb strings.Builder
+	found := false
+	for i, s := range wb.Sheets {
+		if sheet != "" && sheet != s.Name && sheet != strconv.Itoa(i+1) {
+			continue
+		}
+		found = true
+		var ws xlsxSheet
+		if err := unmarshalZipXML(zr, targets[s.RID], &ws); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "## Sheet %d: %s\n", i+1, s.Name)
+		for _, row := range ws.Rows {
+			var cells []string
+			for _, c := range row.Cells {
+				if col := columnIndex(c.Ref); col >= 0 {
+					for len(cells) < col {
+						cells = append(cells, "")
+					}
+				}
+				cells = append(cells, cellValue(c.Type, c.Value, c.Inline.T, shared))
+			}
+			sb.WriteString(strings.Join(cells, "\t"))
+			sb.WriteByte('\n')
+		}
+		sb.WriteByte('\n')
+	}
+	if !found {
+		return "", fmt.Errorf("sheet %q not found (sheets: %s)", sheet, strings.Join(names, ", "))
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func unmarshalZipXML(zr *zip.Reader, name string, v interface{}) error {
+	data, err := openZipFile(zr, name)
+	if err != nil {
+		return fmt.Errorf("open XLSX: %w", err)
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse %s: %w", name, err)
+	}
+	return nil
+}
+
+func cellValue(typ, value, inline string, shared []string) string {
+	switch typ {
+	case "s":
+		if i, err := strconv.Atoi(value); err == nil && i >= 0 && i < len(shared) {
+			return shared[i]
+		}
+		return value
+	case "inlineStr":
+		return inline
+	case "b":
+		if value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return value
+	}
+}
+
+// columnIndex converts the column letters of a cell reference like "C7" to
+// a 0-based index, or -1 when ref has none.
+func columnIndex(ref string) int {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
@@ -0,0 +1,70 @@
+package document
+
+import "strings"
+
+// LooksLikeHTML reports whether content appears to be an HTML page.
+func LooksLikeHTML(content string) bool {
+	trimmed := strings.TrimSpace(content)
+	return strings.HasPrefix(trimmed, "<!DOCTYPE") ||
+		strings.HasPrefix(strings.ToLower(trimmed), "<html") ||
+		(strings.Contains(trimmed, "<") && strings.Contains(trimmed, ">"))
+}
+
+// HTMLText returns the visible text of an HTML page, dropping scripts,
+// styles, tags, and blank lines.
+func HTMLText(html string) string {
+	result := html
+
+	for {
+		start := strings.Index(strings.ToLower(result), "<script")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(strings.ToLower(result[start:]), "</script>")
+		if end == -1 {
+			break
+		}
+		result = result[:start] + result[start+end+9:]
+	}
+
+	for {
+		start := strings.Index(strings.ToLower(result), "<style")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(strings.ToLower(result[start:]), "</style>")
+		if end == -1 {
+			break
+		}
+		result = result[:start] + result[start+end+8:]
+	}
+
+	var output strings.Builder
+	inTag := false
+	for _, r := range result {
+		if r == '<' {
+			inTag = true
+			continue
+		}
+		if r == '>' {
+			inTag = false
+			continue
+		}
+		if !inTag {
+			output.WriteRune(r)
+		}
+	}
+
+	text := output.String()
+
+	lines := strings.Split(text, "\n")
+	var cleanLines []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			cleanLines = append(cleanLines, line)
+		}
+	}
+
+	return strings.Join(cleanLines, "\n")
+}
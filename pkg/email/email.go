@@ -0,0 +1,187 @@
+// Package email is a channel that reads unseen mail over IMAP and answers
+// each message by email.
+package email
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/channel"
+	"github.com/nene-agent/nene/pkg/mail"
+	"github.com/nene-agent/nene/pkg/tool"
+)
+
+const (
+	DefaultPollInterval = time.Minute
+	// fetchLimit bounds the messages taken from the mailbox per poll.
+	fetchLimit = 20
+)
+
+type EmailConfig struct {
+	IMAP        mail.IMAPConfig `json:"imap"`
+	SMTP        mail.SMTPConfig `json:"smtp"`
+	AllowFrom   []string        `json:"allow_from"`
+	Owners      []string        `json:"owners"`
+	PollSeconds int             `json:"poll_seconds"`
+}
+
+// thread is what a reply needs to stay in the conversation a chat started.
+type thread struct {
+	subject    string
+	messageID  string
+	references string
+}
+
+type EmailChannel struct {
+	*channel.BaseChannel
+	config EmailConfig
+	cancel context.CancelFunc
+
+	replies *channel.Replies
+
+	mu      sync.Mutex
+	threads map[string]thread
+}
+
+func NewEmailChannel(cfg EmailConfig, messageBus *bus.MessageBus) *EmailChannel {
+	base := channel.NewBaseChannel("email", messageBus, cfg.AllowFrom)
+	base.SetOwners(cfg.Owners)
+	return &EmailChannel{
+		BaseChannel: base,
+		config:      cfg,
+		threads:     make(map[string]thread),
+		replies:     channel.NewReplies(),
+	}
+}
+
+func (c *EmailChannel) Start(ctx context.Context) error {
+	if c.config.IMAP.Host == "" {
+		return fmt.Errorf("email channel needs imap.host")
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.ConsumeStream(ctx, c)
+	c.SetRunning(true)
+	fmt.Printf("Email channel polling %s as %s\n", c.config.IMAP.Host, c.config.IMAP.Username)
+
+	interval := DefaultPollInterval
+	if c.config.PollSeconds > 0 {
+		interval = time.Duration(c.config.PollSeconds) * time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			c.poll()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+func (c *EmailChannel) Stop(ctx context.Context) error {
+	fmt.Println("Stopping email channel...")
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.SetRunning(false)
+	return nil
+}
+
+func (c *EmailChannel) poll() {
+	messages, err := mail.FetchUnseen(c.config.IMAP, fetchLimit)
+	if err != nil {
+		fmt.Printf("Email poll failed: %v\n", err)
+		return
+	}
+	self := strings.ToLower(c.config.SMTP.From)
+	for _, m := range messages {
+		if m.Automated || (self != "" && strings.Contains(self, m.From)) {
+			continue
+		}
+		c.mu.Lock()
+		c.threads[m.From] = thread{subject: m.Subject, messageID: m.MessageID, references: m.References}
+		c.mu.Unlock()
+
+		metadata := map[string]string{
+			"subject":    m.Subject,
+			"message_id": m.MessageID,
+			"from_name":  m.FromName,
+		}
+		// Commands such as "approve" are taken from the first line; the
+		// rest of a reply is usually the quoted message.
+		body := strings.TrimSpace(m.Body)
+		if line, _, _ := strings.Cut(body, "\n"); isCommand(line) {
+			c.HandleMessage(m.From, m.From, strings.TrimSpace(line), nil, metadata, false)
+			continue
+		}
+		content := fmt.Sprintf("[Email from %s <%s>, subject %q]\n\n%s", m.FromName, m.From, m.Subject, body)
+		c.HandleMessage(m.From, m.From, content, nil, metadata, false)
+	}
+}
+
+// isCommand reports whether a line is a chat command or an answer to an
+// approval request.
+func isCommand(line string) bool {
+	word, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+	_, ok := tool.ParseDecision(word)
+	return ok || strings.HasPrefix(word, "/")
+}
+
+// OnStreamEvent mails the agent's answer when the turn finishes.
+func (c *EmailChannel) OnStreamEvent(msg bus.StreamMessage) {
+	if msg.Type == bus.StreamEventApproval {
+		go c.send(msg.ChatID, channel.ApprovalPrompt(msg, c.Locale(msg.ChatID)))
+		return
+	}
+	body, done := c.replies.Collect(msg, c.Locale(msg.ChatID))
+	if !done {
+		return
+	}
+	if msg.Type == bus.StreamEventFinish {
+		c.FinishTurn(msg.ChatID)
+	}
+	if strings.TrimSpace(body) != "" {
+		go c.send(msg.ChatID, body)
+	}
+}
+
+// Send mails an outbound message, such as a command reply or a
+// notification, to the chat's address. Media files are not attached.
+func (c *EmailChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	return c.sendMail(ctx, msg.ChatID, msg.Content)
+}
+
+func (c *EmailChannel) send(to, body string) {
+	if err := c.sendMail(context.Background(), to, body); err != nil {
+		fmt.Printf("Email reply to %s failed: %v\n", to, err)
+	}
+}
+
+func (c *EmailChannel) sendMail(ctx context.Context, to, body string) error {
+	c.mu.Lock()
+	t := c.threads[to]
+	c.mu.Unlock()
+
+	subject := t.subject
+	if subject == "" {
+		subject = "Message from nene"
+	} else if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+	_, err := mail.Send(ctx, c.config.SMTP, mail.Message{
+		To:         []string{to},
+		Subject:    subject,
+		Body:       body,
+		InReplyTo:  t.messageID,
+		References: t.references,
+	})
+	return err
+}
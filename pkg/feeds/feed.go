@@ -0,0 +1,199 @@
+// Package feeds keeps chat subscriptions to RSS and Atom feeds and pushes
+// digests of new items to the subscribing chats.
+package feeds
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type Item struct {
+	ID        string
+	Title     string
+	Link      string
+	Summary   string
+	Published time.Time
+}
+
+type Feed struct {
+	Title string
+	Items []Item
+}
+
+type rssDoc struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			GUID        string `xml:"guid"`
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomDoc struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		ID        string     `xml:"id"`
+		Title     string     `xml:"title"`
+		Links     []atomLink `xml:"link"`
+		Summary   string     `xml:"summary"`
+		Content   string     `xml:"content"`
+		Updated   string     `xml:"updated"`
+		Published string     `xml:"published"`
+	} `xml:"entry"`
+}
+
+// Parse reads an RSS 2.0 or Atom document.
+func Parse(data []byte) (*Feed, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	var root xml.StartElement
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, errors.New("not an RSS or Atom feed")
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			root = se
+			break
+		}
+	}
+
+	switch root.Name.Local {
+	case "rss", "RDF":
+		var doc rssDoc
+		if err := xmlUnmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("parse RSS: %w", err)
+		}
+		feed := &Feed{Title: strings.TrimSpace(doc.Channel.Title)}
+		for _, it := range doc.Channel.Items {
+			feed.Items = append(feed.Items, Item{
+				ID:        firstNonEmpty(it.GUID, it.Link, it.Title),
+				Title:     strings.TrimSpace(it.Title),
+				Link:      strings.TrimSpace(it.Link),
+				Summary:   plainText(it.Description),
+				Published: parseTime(it.PubDate),
+			})
+		}
+		return feed, nil
+	case "feed":
+		var doc atomDoc
+		if err := xmlUnmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("parse Atom: %w", err)
+		}
+		feed := &Feed{Title: strings.TrimSpace(doc.Title)}
+		for _, e := range doc.Entries {
+			link := ""
+			for _, l := range e.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+					break
+				}
+			}
+			feed.Items = append(feed.Items, Item{
+				ID:        firstNonEmpty(e.ID, link, e.Title),
+				Title:     strings.TrimSpace(e.Title),
+				Link:      link,
+				Summary:   plainText(firstNonEmpty(e.Summary, e.Content)),
+				Published: parseTime(firstNonEmpty(e.Published, e.Updated)),
+			})
+		}
+		return feed, nil
+	default:
+		return nil, errors.New("not an RSS or Atom feed")
+	}
+}
+
+func xmlUnmarshal(data []byte, v interface{}) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	return dec.Decode(v)
+}
+
+// Fetch downloads and parses a feed.
+func Fetch(ctx context.Context, client *http.Client, url string) (*Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "nene feed reader")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.1")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+var timeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2006-01-02T15:04:05Z0700",
+}
+
+func parseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+var (
+	tagPattern   = regexp.MustCompile(`<[^>]*>`)
+	spacePattern = regexp.MustCompile(`\s+`)
+)
+
+// plainText strips markup from item descriptions, which are often HTML.
+func plainText(s string) string {
+	s = tagPattern.ReplaceAllString(s, " ")
+	s = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&#39;", "'", "&nbsp;", " ").Replace(s)
+	return strings.TrimSpace(spacePattern.ReplaceAllString(s, " "))
+}
+
+// itemKey is the deduplication key of an item.
+func itemKey(it Item) string {
+	sum := sha256.Sum256([]byte(it.ID))
+	return hex.EncodeToString(sum[:8])
+}
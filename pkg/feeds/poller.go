@@ -0,0 +1,208 @@
+package feeds
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/bus"
+)
+
+const (
+	DefaultInterval = 30 * time.Minute
+	// maxDigestItems caps the items listed in one digest message.
+	maxDigestItems = 10
+)
+
+// Summarizer condenses new items into a short digest, usually with a model.
+type Summarizer func(ctx context.Context, sub Subscription, feedTitle string, items []Item) (string, error)
+
+// AskSummarizer builds a Summarizer from a single-shot question function
+// such as agent.SessionManager.Ask. Spend is charged to the subscribing chat.
+func AskSummarizer(ask func(ctx context.Context, sessionKey, question string) (string, error)) Summarizer {
+	return func(ctx context.Context, sub Subscription, feedTitle string, items []Item) (string, error) {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Summarize these new items from the feed %q as a short digest of at most five bullet points. Reply with the digest only.\n", feedTitle)
+		for _, it := range items {
+			fmt.Fprintf(&sb, "\n- %s: %s", it.Title, truncate(it.Summary, 500))
+		}
+		return ask(ctx, sub.Channel+":"+sub.ChatID, sb.String())
+	}
+}
+
+// Poller checks every subscription on a fixed interval and publishes a
+// digest of new items to the subscribing chat.
+type Poller struct {
+	store      *Store
+	bus        *bus.MessageBus
+	client     *http.Client
+	interval   time.Duration
+	summarizer Summarizer
+}
+
+type PollerOption func(*Poller)
+
+func WithInterval(d time.Duration) PollerOption {
+	return func(p *Poller) {
+		if d > 0 {
+			p.interval = d
+		}
+	}
+}
+
+func WithSummarizer(s Summarizer) PollerOption {
+	return func(p *Poller) { p.summarizer = s }
+}
+
+func NewPoller(store *Store, b *bus.MessageBus, opts ...PollerOption) *Poller {
+	p := &Poller{
+		store:    store,
+		bus:      b,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		interval: DefaultInterval,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *Poller) Store() *Store {
+	return p.store
+}
+
+// Subscribe fetches a feed to validate it and stores a subscription for the
+// chat. Items already in the feed are marked seen, so only items published
+// afterwards are pushed.
+func (p *Poller) Subscribe(ctx context.Context, url, channel, chatID string, summarize bool) (Subscription, error) {
+	feed, err := Fetch(ctx, p.client, url)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	sub, err := p.store.Add(Subscription{
+		URL:       url,
+		Title:     cmp.Or(feed.Title, url),
+		Channel:   channel,
+		ChatID:    chatID,
+		Summarize: summarize,
+	})
+	if err != nil {
+		return Subscription{}, err
+	}
+	p.store.markSeen(sub.ID, itemKeys(feed.Items), nil)
+	return sub, nil
+}
+
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.CheckAll(ctx)
+		}
+	}
+}
+
+// CheckAll checks every subscription once.
+func (p *Poller) CheckAll(ctx context.Context) {
+	for _, sub := range p.store.List("", "") {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := p.check(ctx, sub); err != nil {
+			fmt.Printf("Feed %s (%s) failed: %v\n", sub.ID, sub.URL, err)
+		}
+	}
+}
+
+func (p *Poller) check(ctx context.Context, sub Subscription) error {
+	feed, err := Fetch(ctx, p.client, sub.URL)
+	if err != nil {
+		p.store.markSeen(sub.ID, nil, err)
+		return err
+	}
+
+	fresh := p.store.markSeen(sub.ID, itemKeys(feed.Items), nil)
+	if len(fresh) == 0 {
+		return nil
+	}
+	isFresh := make(map[string]bool, len(fresh))
+	for _, k := range fresh {
+		isFresh[k] = true
+	}
+	var items []Item
+	for _, it := range feed.Items {
+		if isFresh[itemKey(it)] {
+			items = append(items, it)
+		}
+	}
+
+	title := cmp.Or(feed.Title, sub.Title)
+	p.publish(sub, p.digest(ctx, sub, title, items))
+	return nil
+}
+
+func (p *Poller) digest(ctx context.Context, sub Subscription, title string, items []Item) string {
+	var sb strings.Builder
+	noun := "items"
+	if len(items) == 1 {
+		noun = "item"
+	}
+	fmt.Fprintf(&sb, "📰 **%s**: %d new %s\n", title, len(items), noun)
+
+	if sub.Summarize && p.summarizer != nil {
+		summary, err := p.summarizer(ctx, sub, title, items)
+		if err == nil {
+			fmt.Fprintf(&sb, "\n%s\n", strings.TrimSpace(summary))
+		} else if !errors.Is(err, context.Canceled) {
+			fmt.Printf("Feed %s summary failed: %v\n", sub.ID, err)
+		}
+	}
+
+	sb.WriteString("\n")
+	for i, it := range items {
+		if i == maxDigestItems {
+			fmt.Fprintf(&sb, "…and %d more\n", len(items)-maxDigestItems)
+			break
+		}
+		if it.Link != "" {
+			fmt.Fprintf(&sb, "• [%s](%s)\n", cmp.Or(it.Title, it.Link), it.Link)
+		} else {
+			fmt.Fprintf(&sb, "• %s\n", it.Title)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func (p *Poller) publish(sub Subscription, content string) {
+	if p.bus == nil {
+		return
+	}
+	p.bus.PublishOutbound(bus.OutboundMessage{
+		Channel: sub.Channel,
+		ChatID:  sub.ChatID,
+		Content: content,
+	})
+}
+
+func itemKeys(items []Item) []string {
+	keys := make([]string, 0, len(items))
+	for _, it := range items {
+		keys = append(keys, itemKey(it))
+	}
+	return keys
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
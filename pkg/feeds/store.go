@@ -0,0 +1,156 @@
+package feeds
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxSeen bounds the remembered item keys per subscription; feeds rarely
+// carry more than a few dozen items.
+const maxSeen = 1000
+
+type Subscription struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Title       string    `json:"title"`
+	Channel     string    `json:"channel"`
+	ChatID      string    `json:"chat_id"`
+	Summarize   bool      `json:"summarize"`
+	Seen        []string  `json:"seen"`
+	Created     time.Time `json:"created"`
+	LastChecked time.Time `json:"last_checked"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// Store persists subscriptions as JSON.
+type Store struct {
+	path string
+
+	mu   sync.Mutex
+	subs map[string]*Subscription
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, subs: make(map[string]*Subscription)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read feeds: %w", err)
+	}
+	var subs []*Subscription
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for _, sub := range subs {
+		s.subs[sub.ID] = sub
+	}
+	return s, nil
+}
+
+// Add stores a new subscription, refusing a second subscription of the same
+// chat to the same URL.
+func (s *Store) Add(sub Subscription) (Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.subs {
+		if existing.URL == sub.URL && existing.Channel == sub.Channel && existing.ChatID == sub.ChatID {
+			return Subscription{}, fmt.Errorf("already subscribed to %s (id %s)", sub.URL, existing.ID)
+		}
+	}
+	sub.ID = "feed-" + uuid.NewString()[:8]
+	sub.Created = time.Now()
+	s.subs[sub.ID] = &sub
+	return sub, s.saveLocked()
+}
+
+// List returns the subscriptions of a chat, or all subscriptions when
+// channel is empty, oldest first.
+func (s *Store) List(channel, chatID string) []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var subs []Subscription
+	for _, sub := range s.subs {
+		if channel == "" || (sub.Channel == channel && sub.ChatID == chatID) {
+			subs = append(subs, *sub)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Created.Before(subs[j].Created) })
+	return subs
+}
+
+// Remove deletes a chat's subscription by ID or URL.
+func (s *Store) Remove(channel, chatID, idOrURL string) (Subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, sub := range s.subs {
+		if sub.Channel == channel && sub.ChatID == chatID && (sub.ID == idOrURL || sub.URL == idOrURL) {
+			delete(s.subs, id)
+			if err := s.saveLocked(); err != nil {
+				fmt.Printf("Failed to save feeds: %v\n", err)
+			}
+			return *sub, true
+		}
+	}
+	return Subscription{}, false
+}
+
+// markSeen records item keys and the check result, returning the keys that
+// were not seen before in the order given.
+func (s *Store) markSeen(id string, keys []string, checkErr error) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subs[id]
+	if !ok {
+		return nil
+	}
+	sub.LastChecked = time.Now()
+	sub.LastError = ""
+	if checkErr != nil {
+		sub.LastError = checkErr.Error()
+	}
+
+	seen := make(map[string]bool, len(sub.Seen))
+	for _, k := range sub.Seen {
+		seen[k] = true
+	}
+	var fresh []string
+	for _, k := range keys {
+		if !seen[k] {
+			seen[k] = true
+			fresh = append(fresh, k)
+			sub.Seen = append(sub.Seen, k)
+		}
+	}
+	if len(sub.Seen) > maxSeen {
+		sub.Seen = sub.Seen[len(sub.Seen)-maxSeen:]
+	}
+	if err := s.saveLocked(); err != nil {
+		fmt.Printf("Failed to save feeds: %v\n", err)
+	}
+	return fresh
+}
+
+func (s *Store) saveLocked() error {
+	subs := make([]*Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Created.Before(subs[j].Created) })
+	data, err := json.MarshalIndent(subs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
@@ -0,0 +1,219 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/bus"
+)
+
+const (
+	DefaultInterval  = 30 * time.Minute
+	DefaultMaxPerDay = 5
+
+	// OK is the answer that means nothing needs attention.
+	OK = "HEARTBEAT_OK"
+
+	// maxRecent is how many earlier notices the prompt repeats so the agent
+	// does not report the same thing twice.
+	maxRecent = 5
+)
+
+const DefaultPrompt = "Heartbeat check: nobody wrote to you; this is your scheduled look around. " +
+	"Use your tools to check anything that may need the owner's attention, such as scheduled tasks, " +
+	"feed subscriptions, and follow-ups you promised or stored in memory. " +
+	"If something needs attention, reply with a short message for the owner. " +
+	"Otherwise reply with exactly " + OK + "."
+
+// TurnFunc runs one unattended agent turn for a "channel:chatID" session key
+// and returns its answer, e.g. agent.SessionManager.Background.
+type TurnFunc func(ctx context.Context, sessionKey, prompt string) (string, error)
+
+// Briefing returns extra context for a heartbeat prompt about a session key,
+// such as tasks.Store.Review, or "" when there is nothing to add.
+type Briefing func(ctx context.Context, sessionKey string) (string, error)
+
+// Heartbeat wakes the agent on a fixed interval and forwards its answer to
+// the owner chat unless the agent says nothing needs attention. At most
+// maxPerDay messages are sent per calendar day.
+type Heartbeat struct {
+	bus       *bus.MessageBus
+	ownerChat string
+	turn      TurnFunc
+	interval  time.Duration
+	prompt    string
+	maxPerDay int
+	briefings []Briefing
+	location  func(sessionKey string) *time.Location
+
+	mu     sync.Mutex
+	day    string
+	sent   int
+	recent []string
+}
+
+type Option func(*Heartbeat)
+
+func WithInterval(d time.Duration) Option {
+	return func(h *Heartbeat) {
+		if d > 0 {
+			h.interval = d
+		}
+	}
+}
+
+func WithPrompt(prompt string) Option {
+	return func(h *Heartbeat) {
+		if prompt != "" {
+			h.prompt = prompt
+		}
+	}
+}
+
+// WithMaxPerDay caps the messages sent per day. Zero or less means no
+// messages are sent, which turns the heartbeat off.
+func WithMaxPerDay(n int) Option {
+	return func(h *Heartbeat) { h.maxPerDay = n }
+}
+
+// WithBriefing adds context to every heartbeat prompt. Briefings that fail
+// are left out.
+func WithBriefing(fn Briefing) Option {
+	return func(h *Heartbeat) { h.briefings = append(h.briefings, fn) }
+}
+
+// WithLocation sets how the owner chat's timezone is found, e.g.
+// agent.SessionManager.TimezoneFor, so the daily limit resets at its
+// midnight. Without it the server's is used.
+func WithLocation(fn func(sessionKey string) *time.Location) Option {
+	return func(h *Heartbeat) { h.location = fn }
+}
+
+func New(b *bus.MessageBus, ownerChat string, turn TurnFunc, opts ...Option) *Heartbeat {
+	h := &Heartbeat{
+		bus:       b,
+		ownerChat: ownerChat,
+		turn:      turn,
+		interval:  DefaultInterval,
+		prompt:    DefaultPrompt,
+		maxPerDay: DefaultMaxPerDay,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *Heartbeat) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.Beat(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				fmt.Printf("Heartbeat failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// Beat runs one heartbeat turn and sends its answer if it needs attention.
+// Once the day's messages are used up, no turn is run at all.
+func (h *Heartbeat) Beat(ctx context.Context) error {
+	prompt, ok := h.nextPrompt(h.now())
+	if !ok {
+		return nil
+	}
+	for _, brief := range h.briefings {
+		extra, err := brief(ctx, h.ownerChat)
+		if err != nil {
+			fmt.Printf("Heartbeat briefing failed: %v\n", err)
+			continue
+		}
+		if extra != "" {
+			prompt += "\n\n" + extra
+		}
+	}
+	answer, err := h.turn(ctx, h.ownerChat, prompt)
+	if err != nil {
+		return err
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" || strings.Contains(answer, OK) {
+		return nil
+	}
+	if !h.take(h.now(), answer) {
+		return nil
+	}
+
+	channel, chatID, _ := strings.Cut(h.ownerChat, ":")
+	if h.bus != nil {
+		h.bus.PublishOutbound(bus.OutboundMessage{
+			Channel: channel,
+			ChatID:  chatID,
+			Content: "💓 " + answer,
+		})
+	}
+	return nil
+}
+
+// now is the current time in the owner chat's timezone.
+func (h *Heartbeat) now() time.Time {
+	if h.location != nil {
+		if loc := h.location(h.ownerChat); loc != nil {
+			return time.Now().In(loc)
+		}
+	}
+	return time.Now()
+}
+
+// nextPrompt returns the prompt for a turn at now, or false when no more
+// messages may be sent today.
+func (h *Heartbeat) nextPrompt(now time.Time) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rollover(now)
+	if h.sent >= h.maxPerDay {
+		return "", false
+	}
+	if len(h.recent) == 0 {
+		return h.prompt, true
+	}
+	var sb strings.Builder
+	sb.WriteString(h.prompt)
+	sb.WriteString("\n\nYou already told the owner the following; do not repeat it unless something changed:")
+	for _, r := range h.recent {
+		sb.WriteString("\n- " + strings.ReplaceAll(r, "\n", " "))
+	}
+	return sb.String(), true
+}
+
+// take counts a message against today's limit and reports whether it may be
+// sent.
+func (h *Heartbeat) take(now time.Time, answer string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rollover(now)
+	if h.sent >= h.maxPerDay {
+		return false
+	}
+	h.sent++
+	h.recent = append(h.recent, answer)
+	if len(h.recent) > maxRecent {
+		h.recent = h.recent[len(h.recent)-maxRecent:]
+	}
+	return true
+}
+
+func (h *Heartbeat) rollover(now time.Time) {
+	if day := now.Format(time.DateOnly); day != h.day {
+		h.day = day
+		h.sent = 0
+	}
+}
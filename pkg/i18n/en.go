@@ -0,0 +1,186 @@
+package i18n
+
+var en = map[string]string{
+	"approval.none":          "Nothing is waiting for your approval.",
+	"approval.prompt":        "🔐 %s (%s):\n\n%s\n\nReply \"approve\" or \"reject <reason>\"",
+	"approval.prompt_always": ", or \"always\" to allow %s in this chat from now on",
+	"approval.prompt_end":    ".",
+	"approval.approve":       "✅ Approve",
+	"approval.reject":        "❌ Reject",
+	"approval.always_button": "♾ Always allow %s",
+	"approval.approved":      "✅ Approved",
+	"approval.always":        "♾ Always allowed",
+	"approval.rejected":      "❌ Rejected",
+	"approval.gone":          "This request is no longer waiting for you",
+
+	"pairing.offer":       "🔒 You don't have access to this bot yet. Ask its owner?",
+	"pairing.request":     "🙋 Request access",
+	"pairing.sent":        "📨 Request sent. You will hear back when the owner decides.",
+	"pairing.waiting":     "Your request is already waiting for the owner",
+	"pairing.closed":      "Access requests are not open",
+	"pairing.prompt":      "🔑 %s asks to use the bot.",
+	"pairing.approve":     "✅ Let in",
+	"pairing.deny":        "🚫 Turn away",
+	"pairing.approved":    "✅ An owner gave you access. Say hello!",
+	"pairing.denied":      "🚫 An owner declined your request.",
+	"pairing.approved_by": "✅ Let in by %s",
+	"pairing.denied_by":   "🚫 Turned away by %s",
+	"pairing.owners_only": "Only owners can answer access requests",
+
+	"limit.user":       "You're sending messages too quickly (limit %d per minute). Please slow down.",
+	"limit.chat":       "This chat is sending messages too quickly (limit %d per minute). Please slow down.",
+	"limit.concurrent": "Please wait for the current reply to finish before sending another message.",
+	"limit.banned":     "⛔ Too many messages. You are blocked for %s.",
+
+	"stream.step":          "🔄 Step %d",
+	"stream.step_text":     "💬 Step %d: %s",
+	"stream.plan":          "📝 Plan",
+	"stream.more":          "📋 ... and %d more",
+	"stream.input":         "Input:",
+	"stream.output":        "Output:",
+	"stream.subagent_step": "step %d",
+	"stream.subagent_done": "(%d steps)",
+	"stream.thinking":      "💭 Thinking…",
+	"stream.thought":       "💭 Thought for %d words",
+	"stream.completed":     "✅ Completed",
+	"stream.truncated":     "[Message truncated]",
+	"stream.error":         "❌ Error: %s",
+	"reply.error":          "Sorry, something went wrong: %s",
+	"budget.paused":        "💸 Sorry, the %s. New requests are paused until the budget resets.",
+
+	"error.auth":             "🔑 The model provider did not accept the bot's credentials. Please tell the bot's operator.",
+	"error.rate_limit":       "⏳ The model provider is busy and asked us to slow down. Please try again in a minute.",
+	"error.context_overflow": "📚 This conversation no longer fits the model's context. Use /rewind to drop recent turns, or /model to pick a model with a larger context.",
+	"error.unavailable":      "🌐 The model provider is unavailable right now. Please try again shortly.",
+	"error.provider":         "❌ The model provider returned an error. Please try again, and tell the bot's operator if it keeps happening.",
+	"error.tool_timeout":     "⏱ Took too long and was stopped",
+	"error.cancelled":        "⏹ Stopped before the answer was finished.",
+	"error.internal":         "💥 Something went wrong on our side. Please try again.",
+
+	"progress.working":         "⏳ Working…",
+	"progress.working_step":    "⏳ Working… (step %d)",
+	"progress.running":         "🔧 running %s…",
+	"progress.done.one":        "✅ %d tool done",
+	"progress.done.other":      "✅ %d tools done",
+	"progress.failed.other":    "❌ %d failed",
+	"progress.subagents.one":   "🤖 %d subagent working",
+	"progress.subagents.other": "🤖 %d subagents working",
+
+	"details.view":           "📋 View Details",
+	"details.prev":           "◀ Prev",
+	"details.back":           "📋 Back",
+	"details.next":           "Next ▶",
+	"details.tool":           "🔧 Tool %d/%d: %s",
+	"details.input":          "Input:",
+	"details.output":         "Output:",
+	"details.error":          "Error:",
+	"details.not_found":      "Details not found",
+	"suggest.gone":           "These suggestions have expired.",
+	"details.no_message":     "Message not found",
+	"details.inaccessible":   "Cannot access message",
+	"format.current":         "Replies use %s. Switch with /format html or /format markdownv2.",
+	"format.set":             "Replies in this chat now use %s.",
+	"format.unknown":         "Unknown format %q. Use html or markdownv2.",
+	"display.current":        "Display: %s. Switch with /display stream, /display segments, /display progress or /display quiet.",
+	"display.set":            "Display in this chat is now %s.",
+	"display.unknown":        "Unknown display %q. Use stream, segments, progress or quiet.",
+	"digest.current":         "Tool digest: %s. Switch with /digest on or /digest off.",
+	"digest.set":             "Tool digest in this chat is now %s.",
+	"digest.unknown":         "Unknown digest setting %q. Use on or off.",
+	"digest.header.one":      "🧰 %d tool call",
+	"digest.header.other":    "🧰 %d tool calls",
+	"lang.current":           "Language: %s. Available: %s. Switch with /lang <code>.",
+	"lang.set":               "This chat now uses English.",
+	"lang.unknown":           "Unknown language %q. Available: %s.",
+	"queue.queued":           "⏳ Queued (%d ahead). Send /cancel to stop the current turn.",
+	"queue.stopping":         "⏹ Stopping the current turn.",
+	"queue.idle":             "Nothing is running in this chat.",
+	"command.owner_only":     "❌ %s is only available to owners.",
+	"session.idle_reset":     "💤 New conversation: the last one was idle for %s.",
+	"persona.list":           "Available personas:",
+	"persona.hint":           "Use /persona <name> to switch.",
+	"persona.switched":       "✅ Switched to persona %q. Conversation has been reset.",
+	"model.list":             "Current model: %s\n\nAvailable models:",
+	"model.hint":             "Use /model <provider>/<model> to switch.",
+	"model.form":             "❌ Use the form <provider>/<model>, e.g. /model default/gpt-4o-mini",
+	"model.unknown_provider": "❌ Unknown provider: %s",
+	"model.switched":         "✅ Switched to model %q.",
+	"export.empty":           "Nothing to export yet.",
+	"export.failed":          "❌ Could not save the export: %v",
+	"export.caption":         "📄 Conversation export",
+	"approvals.off":          "Approvals are not remembered.",
+	"approvals.none":         "Nothing is always allowed in this chat.",
+	"approvals.list":         "Always allowed in this chat:",
+	"approvals.hint":         "Use /approvals forget <number> or /approvals forget all.",
+	"approvals.usage":        "Usage: /approvals, /approvals forget <number>, or /approvals forget all",
+	"approvals.bad_number":   "❌ Not a rule number: %s",
+	"approvals.not_found":    "❌ No such rule",
+	"approvals.forgot.one":   "✅ Forgot %d rule. Those calls will ask for approval again.",
+	"approvals.forgot.other": "✅ Forgot %d rules. Those calls will ask for approval again.",
+	"stats.off":              "Tool statistics are not recorded.",
+	"stats.usage":            "Usage: /stats or /stats <days>",
+	"stats.all_time":         "all time",
+	"stats.days.one":         "last %d day",
+	"stats.days.other":       "last %d days",
+	"stats.none":             "No tool calls recorded (%s).",
+	"stats.list":             "Tool calls (%s):",
+	"stats.line.one":         "%d call, %.0f%% ok, p50 %dms, p90 %dms, p99 %dms",
+	"stats.line.other":       "%d calls, %.0f%% ok, p50 %dms, p90 %dms, p99 %dms",
+	"dryrun.off":             "Dry-run mode is not available.",
+	"dryrun.status_on":       "Dry-run mode is on (%s).\n\nUse /dryrun on, /dryrun off, or /dryrun default.",
+	"dryrun.status_off":      "Dry-run mode is off (%s).\n\nUse /dryrun on, /dryrun off, or /dryrun default.",
+	"dryrun.source_default":  "the global default",
+	"dryrun.source_chat":     "set for this chat",
+	"dryrun.set_on":          "✅ Dry-run mode on. Files and commands will be described, not changed or run.",
+	"dryrun.set_off":         "✅ Dry-run mode off. Tools will run for real again.",
+	"dryrun.default_on":      "✅ This chat follows the global default again, which is dry-run on.",
+	"dryrun.default_off":     "✅ This chat follows the global default again, which is dry-run off.",
+	"dryrun.usage":           "Usage: /dryrun, /dryrun on, /dryrun off, or /dryrun default",
+	"tasks.off":              "Tasks are not enabled.",
+	"tasks.bad_number":       "❌ Not a task number: %s",
+	"tasks.not_found":        "❌ No task #%d in this chat",
+	"tasks.usage":            "Usage: /tasks, /tasks all, /tasks done <number>, or /tasks cancel <number>",
+	"tasks.none":             "No tasks in this chat.",
+	"tasks.none_open":        "No open tasks in this chat.",
+	"tasks.list":             "Tasks:",
+	"tasks.hint":             "Use /tasks done <number> to complete one, or /tasks all to include finished ones.",
+	"checkpoint.off":         "Checkpoints are not enabled.",
+	"checkpoint.hash_name":   "❌ Checkpoint names cannot start with #.",
+	"checkpoint.number_name": "❌ Checkpoint names cannot be numbers; /rewind <number> rewinds turns.",
+	"checkpoint.empty":       "Nothing to checkpoint yet.",
+	"checkpoint.saved":       "📌 Saved checkpoint %s.\n\nUse /rewind #%d to return to it.",
+	"checkpoint.not_found":   "❌ No such checkpoint",
+	"checkpoint.deleted":     "✅ Checkpoint deleted.",
+	"checkpoints.usage":      "Usage: /checkpoints or /checkpoints delete <#number or name>",
+	"checkpoints.none":       "No checkpoints in this chat. Use /checkpoint [name] to save one.",
+	"checkpoints.list":       "Checkpoints:",
+	"checkpoints.hint":       "Use /rewind <#number or name> to return to one.",
+	"rewind.no_checkpoints":  "Checkpoints are not enabled; use /rewind <number of turns>.",
+	"rewind.not_found":       "❌ No such checkpoint: %s",
+	"rewind.empty":           "Nothing to rewind yet.",
+	"rewind.too_few":         "❌ The number of turns must be at least 1",
+	"rewind.too_many.one":    "❌ The conversation has only %d turn",
+	"rewind.too_many.other":  "❌ The conversation has only %d turns",
+	"rewind.saved":           "The conversation before the rewind was saved as checkpoint #%d; /rewind #%d goes back to it.",
+	"rewind.restored":        "⏪ Restored checkpoint %s.",
+	"rewind.undone.one":      "⏪ Undid %d turn; %d left.",
+	"rewind.undone.other":    "⏪ Undid %d turns; %d left.",
+	"help.text": `Commands:
+/help - Show this list
+/cancel - Stop the reply being written
+/model [name] - Show or switch the model
+/persona [name] - Show or switch the persona
+/settings - Show or change sampling settings
+/tasks - List tasks
+/checkpoint [name] - Save the conversation
+/checkpoints - List saved checkpoints
+/rewind [turns or name] - Undo turns or return to a checkpoint
+/export - Export the conversation
+/approvals - Manage remembered approvals
+/dryrun - Toggle dry-run mode for tools
+/lang [code] - Show or switch the language
+/prompt [show|set|clear] - Inspect or extend the system prompt
+/profile - Show or correct what is known about you
+/status - Show uptime, sessions, queues, and the last error
+/stats [days] - Show tool calls, success rates, and latency`,
+}
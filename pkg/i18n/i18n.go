@@ -0,0 +1,85 @@
+// Package i18n translates the strings channels and commands show to users.
+// Messages are looked up by key in the chat's locale and fall back to
+// English, so a missing translation shows English rather than the key.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Default is the locale used when a chat has not chosen one.
+const Default = "en"
+
+var catalogs = map[string]map[string]string{
+	"en": en,
+	"ja": ja,
+	"zh": zh,
+}
+
+// names are shown when listing locales, each in its own language.
+var names = map[string]string{
+	"en": "English",
+	"ja": "日本語",
+	"zh": "中文",
+}
+
+// T formats the message key in locale with args.
+func T(locale, key string, args ...interface{}) string {
+	format, ok := override(locale, key)
+	if !ok {
+		format, ok = lookup(locale, key)
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// N is T for messages that depend on a count: key+".one" is used when n is
+// 1 and the locale has it, key+".other" otherwise. n is the first argument.
+func N(locale, key string, n int, args ...interface{}) string {
+	args = append([]interface{}{n}, args...)
+	if n == 1 {
+		_, overridden := override(locale, key+".one")
+		if _, ok := catalogs[locale][key+".one"]; ok || overridden {
+			return T(locale, key+".one", args...)
+		}
+	}
+	return T(locale, key+".other", args...)
+}
+
+// Normalize maps a language tag such as "ja-JP" or "zh_Hans" to a supported
+// locale, or returns "" when there is none.
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if _, ok := catalogs[tag]; ok {
+		return tag
+	}
+	return ""
+}
+
+// Supported returns the supported locales, sorted.
+func Supported() []string {
+	locales := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Name returns the name of a locale in its own language.
+func Name(locale string) string {
+	if n, ok := names[locale]; ok {
+		return n
+	}
+	return locale
+}
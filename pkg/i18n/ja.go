@@ -0,0 +1,178 @@
+package i18n
+
+var ja = map[string]string{
+	"approval.none":          "承認待ちのリクエストはありません。",
+	"approval.prompt":        "🔐 %s (%s):\n\n%s\n\n「approve」または「reject <理由>」と返信してください",
+	"approval.prompt_always": "。今後このチャットで %s を許可する場合は「always」と返信してください",
+	"approval.prompt_end":    "。",
+	"approval.approve":       "✅ 承認",
+	"approval.reject":        "❌ 拒否",
+	"approval.always_button": "♾ %s を常に許可",
+	"approval.approved":      "✅ 承認しました",
+	"approval.always":        "♾ 常に許可しました",
+	"approval.rejected":      "❌ 拒否しました",
+	"approval.gone":          "このリクエストはもう承認を待っていません",
+
+	"pairing.offer":       "🔒 このボットを使う権限がまだありません。オーナーに申請しますか？",
+	"pairing.request":     "🙋 アクセスを申請",
+	"pairing.sent":        "📨 申請しました。オーナーが決めたらお知らせします。",
+	"pairing.waiting":     "申請はすでにオーナーの確認待ちです",
+	"pairing.closed":      "アクセス申請は受け付けていません",
+	"pairing.prompt":      "🔑 %s がボットの利用を申請しています。",
+	"pairing.approve":     "✅ 許可",
+	"pairing.deny":        "🚫 断る",
+	"pairing.approved":    "✅ オーナーがアクセスを許可しました。話しかけてみてください！",
+	"pairing.denied":      "🚫 オーナーが申請を断りました。",
+	"pairing.approved_by": "✅ %s が許可しました",
+	"pairing.denied_by":   "🚫 %s が断りました",
+	"pairing.owners_only": "アクセス申請に答えられるのはオーナーだけです",
+
+	"limit.user":       "メッセージの送信が速すぎます（1分あたり%d件まで）。少し間をあけてください。",
+	"limit.chat":       "このチャットのメッセージが多すぎます（1分あたり%d件まで）。少し間をあけてください。",
+	"limit.concurrent": "現在の返信が終わるまでお待ちください。",
+	"limit.banned":     "⛔ メッセージが多すぎます。%s の間ブロックされます。",
+
+	"stream.step":          "🔄 ステップ %d",
+	"stream.step_text":     "💬 ステップ %d: %s",
+	"stream.plan":          "📝 計画",
+	"stream.more":          "📋 ほか %d 件",
+	"stream.input":         "入力:",
+	"stream.output":        "出力:",
+	"stream.subagent_step": "ステップ %d",
+	"stream.subagent_done": "(%d ステップ)",
+	"stream.thinking":      "💭 考え中…",
+	"stream.thought":       "💭 %d 語分考えました",
+	"stream.completed":     "✅ 完了",
+	"stream.truncated":     "[メッセージを省略しました]",
+	"stream.error":         "❌ エラー: %s",
+	"reply.error":          "申し訳ありません、問題が発生しました: %s",
+	"budget.paused":        "💸 申し訳ありません、%s。予算がリセットされるまで新しいリクエストは停止しています。",
+
+	"error.auth":             "🔑 モデルプロバイダーがボットの認証情報を受け付けませんでした。ボットの管理者に連絡してください。",
+	"error.rate_limit":       "⏳ モデルプロバイダーが混み合っています。1 分ほどしてからもう一度お試しください。",
+	"error.context_overflow": "📚 この会話はモデルのコンテキストに収まらなくなりました。/rewind で最近のやり取りを取り消すか、/model でより大きなコンテキストのモデルを選んでください。",
+	"error.unavailable":      "🌐 モデルプロバイダーが現在利用できません。しばらくしてからもう一度お試しください。",
+	"error.provider":         "❌ モデルプロバイダーがエラーを返しました。もう一度お試しください。続く場合はボットの管理者に連絡してください。",
+	"error.tool_timeout":     "⏱ 時間がかかりすぎたため停止しました",
+	"error.cancelled":        "⏹ 回答の途中で停止しました。",
+	"error.internal":         "💥 内部でエラーが発生しました。もう一度お試しください。",
+
+	"progress.working":         "⏳ 処理中…",
+	"progress.working_step":    "⏳ 処理中…（ステップ %d）",
+	"progress.running":         "🔧 %s を実行中…",
+	"progress.done.other":      "✅ %d 個のツールが完了",
+	"progress.failed.other":    "❌ %d 個が失敗",
+	"progress.subagents.other": "🤖 %d 個のサブエージェントが作業中",
+
+	"details.view":           "📋 詳細を見る",
+	"details.prev":           "◀ 前へ",
+	"details.back":           "📋 戻る",
+	"details.next":           "次へ ▶",
+	"details.tool":           "🔧 ツール %d/%d: %s",
+	"details.input":          "入力:",
+	"details.output":         "出力:",
+	"details.error":          "エラー:",
+	"details.not_found":      "詳細が見つかりません",
+	"suggest.gone":           "この候補はもう使えません。",
+	"details.no_message":     "メッセージが見つかりません",
+	"details.inaccessible":   "メッセージにアクセスできません",
+	"format.current":         "返信の形式: %s。/format html または /format markdownv2 で切り替えられます。",
+	"format.set":             "このチャットの返信は %s になりました。",
+	"format.unknown":         "不明な形式 %q です。html または markdownv2 を指定してください。",
+	"display.current":        "表示: %s。/display stream、/display segments、/display progress、/display quiet で切り替えられます。",
+	"display.set":            "このチャットの表示は %s になりました。",
+	"display.unknown":        "不明な表示 %q です。stream、segments、progress、quiet のいずれかを指定してください。",
+	"digest.current":         "ツールのまとめ表示: %s。/digest on または /digest off で切り替えられます。",
+	"digest.set":             "このチャットのツールのまとめ表示は %s になりました。",
+	"digest.unknown":         "不明な設定 %q です。on または off を指定してください。",
+	"digest.header.other":    "🧰 ツール %d 回",
+	"lang.current":           "言語: %s。利用可能: %s。/lang <コード> で切り替えられます。",
+	"lang.set":               "このチャットは日本語になりました。",
+	"lang.unknown":           "不明な言語 %q です。利用可能: %s。",
+	"queue.queued":           "⏳ 順番待ち（前に %d 件）。/cancel で現在の処理を止められます。",
+	"queue.stopping":         "⏹ 現在の処理を止めています。",
+	"queue.idle":             "このチャットで実行中の処理はありません。",
+	"command.owner_only":     "❌ %s はオーナーのみ使用できます。",
+	"session.idle_reset":     "💤 前の会話から %s 経ったので、新しい会話を始めました。",
+	"persona.list":           "利用可能なペルソナ:",
+	"persona.hint":           "/persona <名前> で切り替えられます。",
+	"persona.switched":       "✅ ペルソナ %q に切り替えました。会話はリセットされました。",
+	"model.list":             "現在のモデル: %s\n\n利用可能なモデル:",
+	"model.hint":             "/model <プロバイダー>/<モデル> で切り替えられます。",
+	"model.form":             "❌ <プロバイダー>/<モデル> の形式で指定してください。例: /model default/gpt-4o-mini",
+	"model.unknown_provider": "❌ 不明なプロバイダーです: %s",
+	"model.switched":         "✅ モデル %q に切り替えました。",
+	"export.empty":           "まだエクスポートするものがありません。",
+	"export.failed":          "❌ エクスポートを保存できませんでした: %v",
+	"export.caption":         "📄 会話のエクスポート",
+	"approvals.off":          "承認は記憶されていません。",
+	"approvals.none":         "このチャットで常に許可されているものはありません。",
+	"approvals.list":         "このチャットで常に許可:",
+	"approvals.hint":         "/approvals forget <番号> または /approvals forget all で取り消せます。",
+	"approvals.usage":        "使い方: /approvals、/approvals forget <番号>、または /approvals forget all",
+	"approvals.bad_number":   "❌ ルール番号ではありません: %s",
+	"approvals.not_found":    "❌ そのルールはありません",
+	"approvals.forgot.other": "✅ %d 件のルールを取り消しました。該当する呼び出しには再び承認が必要です。",
+	"stats.off":              "ツールの統計は記録されていません。",
+	"stats.usage":            "使い方: /stats または /stats <日数>",
+	"stats.all_time":         "全期間",
+	"stats.days.other":       "過去 %d 日",
+	"stats.none":             "ツールの呼び出しは記録されていません（%s）。",
+	"stats.list":             "ツールの呼び出し（%s）:",
+	"stats.line.other":       "%d 回、成功率 %.0f%%、p50 %dms、p90 %dms、p99 %dms",
+	"dryrun.off":             "ドライランモードは利用できません。",
+	"dryrun.status_on":       "ドライランモードはオンです（%s）。\n\n/dryrun on、/dryrun off、/dryrun default で変更できます。",
+	"dryrun.status_off":      "ドライランモードはオフです（%s）。\n\n/dryrun on、/dryrun off、/dryrun default で変更できます。",
+	"dryrun.source_default":  "全体の既定値",
+	"dryrun.source_chat":     "このチャットで設定",
+	"dryrun.set_on":          "✅ ドライランモードをオンにしました。ファイルやコマンドは変更・実行されず、内容の説明だけになります。",
+	"dryrun.set_off":         "✅ ドライランモードをオフにしました。ツールは再び実際に実行されます。",
+	"dryrun.default_on":      "✅ このチャットは全体の既定値（ドライランオン）に戻りました。",
+	"dryrun.default_off":     "✅ このチャットは全体の既定値（ドライランオフ）に戻りました。",
+	"dryrun.usage":           "使い方: /dryrun、/dryrun on、/dryrun off、または /dryrun default",
+	"tasks.off":              "タスクは有効になっていません。",
+	"tasks.bad_number":       "❌ タスク番号ではありません: %s",
+	"tasks.not_found":        "❌ このチャットにタスク #%d はありません",
+	"tasks.usage":            "使い方: /tasks、/tasks all、/tasks done <番号>、または /tasks cancel <番号>",
+	"tasks.none":             "このチャットにタスクはありません。",
+	"tasks.none_open":        "このチャットに未完了のタスクはありません。",
+	"tasks.list":             "タスク:",
+	"tasks.hint":             "/tasks done <番号> で完了にし、/tasks all で完了したものも表示します。",
+	"checkpoint.off":         "チェックポイントは有効になっていません。",
+	"checkpoint.hash_name":   "❌ チェックポイント名を # で始めることはできません。",
+	"checkpoint.number_name": "❌ チェックポイント名に数字だけは使えません。/rewind <数> はターンを取り消します。",
+	"checkpoint.empty":       "まだ保存する会話がありません。",
+	"checkpoint.saved":       "📌 チェックポイント %s を保存しました。\n\n/rewind #%d で戻れます。",
+	"checkpoint.not_found":   "❌ そのチェックポイントはありません",
+	"checkpoint.deleted":     "✅ チェックポイントを削除しました。",
+	"checkpoints.usage":      "使い方: /checkpoints または /checkpoints delete <#番号または名前>",
+	"checkpoints.none":       "このチャットにチェックポイントはありません。/checkpoint [名前] で保存できます。",
+	"checkpoints.list":       "チェックポイント:",
+	"checkpoints.hint":       "/rewind <#番号または名前> で戻れます。",
+	"rewind.no_checkpoints":  "チェックポイントは有効になっていません。/rewind <ターン数> を使ってください。",
+	"rewind.not_found":       "❌ そのチェックポイントはありません: %s",
+	"rewind.empty":           "まだ取り消すものがありません。",
+	"rewind.too_few":         "❌ ターン数は 1 以上にしてください",
+	"rewind.too_many.other":  "❌ 会話には %d ターンしかありません",
+	"rewind.saved":           "取り消す前の会話はチェックポイント #%d に保存しました。/rewind #%d で戻れます。",
+	"rewind.restored":        "⏪ チェックポイント %s に戻しました。",
+	"rewind.undone.other":    "⏪ %d ターンを取り消しました。残りは %d ターンです。",
+	"help.text": `コマンド:
+/help - この一覧を表示
+/cancel - 書いている途中の返信を止める
+/model [名前] - モデルを表示・切り替え
+/persona [名前] - ペルソナを表示・切り替え
+/settings - サンプリング設定を表示・変更
+/tasks - タスクを一覧表示
+/checkpoint [名前] - 会話を保存
+/checkpoints - 保存したチェックポイントを一覧表示
+/rewind [ターン数または名前] - ターンを取り消す、またはチェックポイントに戻る
+/export - 会話をエクスポート
+/approvals - 記憶した承認を管理
+/dryrun - ツールのドライランを切り替え
+/lang [コード] - 言語を表示・切り替え
+/prompt [show|set|clear] - システムプロンプトを確認・追加
+/profile - あなたについて記憶した内容を表示・修正
+/status - 稼働時間、セッション、キュー、最後のエラーを表示
+/stats [日数] - ツールの呼び出し回数、成功率、レイテンシを表示`,
+}
@@ -0,0 +1,90 @@
+package i18n
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// AllLocales is the locale of overrides that apply in every locale.
+const AllLocales = "*"
+
+var (
+	overridesMu sync.RWMutex
+	overrides   map[string]map[string]string
+)
+
+// SetOverrides replaces bundled messages with a deployment's own, by locale
+// and key, so operators can change wording and branding without a rebuild.
+// Overrides for AllLocales apply wherever a locale has none of its own. A
+// message must keep the format verbs, such as %s, of the one it replaces.
+// Nothing is changed if any override is invalid.
+func SetOverrides(messages map[string]map[string]string) error {
+	var problems []string
+	for locale, msgs := range messages {
+		if _, ok := catalogs[locale]; !ok && locale != AllLocales {
+			problems = append(problems, fmt.Sprintf("unknown locale %q", locale))
+			continue
+		}
+		for key, text := range msgs {
+			original, ok := lookup(locale, key)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown message %q", locale, key))
+				continue
+			}
+			if got, want := verbs(text), verbs(original); !slices.Equal(got, want) {
+				problems = append(problems, fmt.Sprintf("%s: %q has the format verbs %v, but the original %q has %v", locale, key, got, original, want))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("message overrides: %s", strings.Join(problems, "; "))
+	}
+
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+	overrides = messages
+	return nil
+}
+
+// override returns the deployment's message for key in locale, if any.
+func override(locale, key string) (string, bool) {
+	overridesMu.RLock()
+	defer overridesMu.RUnlock()
+	if text, ok := overrides[locale][key]; ok {
+		return text, true
+	}
+	text, ok := overrides[AllLocales][key]
+	return text, ok
+}
+
+// lookup returns the bundled message for key in locale, or in English.
+func lookup(locale, key string) (string, bool) {
+	if format, ok := catalogs[locale][key]; ok {
+		return format, true
+	}
+	format, ok := en[key]
+	return format, ok
+}
+
+// verbs lists the format verbs of s in order; "%%" is not one.
+func verbs(s string) []string {
+	var out []string
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+1 >= len(s) {
+			continue
+		}
+		j := i + 1
+		for j < len(s) && strings.IndexByte("+-# 0123456789.", s[j]) >= 0 {
+			j++
+		}
+		if j < len(s) && s[j] != '%' {
+			out = append(out, s[i:j+1])
+		}
+		i = j
+	}
+	return out
+}
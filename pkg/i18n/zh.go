@@ -0,0 +1,178 @@
+package i18n
+
+var zh = map[string]string{
+	"approval.none":          "没有等待你批准的请求。",
+	"approval.prompt":        "🔐 %s (%s):\n\n%s\n\n请回复“approve”或“reject <原因>”",
+	"approval.prompt_always": "，或回复“always”以后在此聊天中允许 %s",
+	"approval.prompt_end":    "。",
+	"approval.approve":       "✅ 批准",
+	"approval.reject":        "❌ 拒绝",
+	"approval.always_button": "♾ 始终允许 %s",
+	"approval.approved":      "✅ 已批准",
+	"approval.always":        "♾ 已始终允许",
+	"approval.rejected":      "❌ 已拒绝",
+	"approval.gone":          "此请求已不再等待你的处理",
+
+	"pairing.offer":       "🔒 你还没有使用此机器人的权限。要向所有者申请吗？",
+	"pairing.request":     "🙋 申请访问",
+	"pairing.sent":        "📨 已发送申请。所有者决定后会通知你。",
+	"pairing.waiting":     "你的申请已在等待所有者处理",
+	"pairing.closed":      "目前不接受访问申请",
+	"pairing.prompt":      "🔑 %s 申请使用机器人。",
+	"pairing.approve":     "✅ 允许",
+	"pairing.deny":        "🚫 拒绝",
+	"pairing.approved":    "✅ 所有者已允许你访问。打个招呼吧！",
+	"pairing.denied":      "🚫 所有者拒绝了你的申请。",
+	"pairing.approved_by": "✅ 已由 %s 允许",
+	"pairing.denied_by":   "🚫 已由 %s 拒绝",
+	"pairing.owners_only": "只有所有者可以处理访问申请",
+
+	"limit.user":       "你发送消息太快了（每分钟最多 %d 条），请慢一点。",
+	"limit.chat":       "此聊天发送消息太快了（每分钟最多 %d 条），请慢一点。",
+	"limit.concurrent": "请等当前回复完成后再发送消息。",
+	"limit.banned":     "⛔ 消息过多，你已被屏蔽 %s。",
+
+	"stream.step":          "🔄 第 %d 步",
+	"stream.step_text":     "💬 第 %d 步：%s",
+	"stream.plan":          "📝 计划",
+	"stream.more":          "📋 还有 %d 个",
+	"stream.input":         "输入：",
+	"stream.output":        "输出：",
+	"stream.subagent_step": "第 %d 步",
+	"stream.subagent_done": "（%d 步）",
+	"stream.thinking":      "💭 思考中…",
+	"stream.thought":       "💭 思考了 %d 个词",
+	"stream.completed":     "✅ 已完成",
+	"stream.truncated":     "[消息已截断]",
+	"stream.error":         "❌ 错误：%s",
+	"reply.error":          "抱歉，出了点问题：%s",
+	"budget.paused":        "💸 抱歉，%s。预算重置前暂停处理新请求。",
+
+	"error.auth":             "🔑 模型服务商未接受机器人的凭据。请联系机器人的管理员。",
+	"error.rate_limit":       "⏳ 模型服务商繁忙，要求降低请求频率。请一分钟后再试。",
+	"error.context_overflow": "📚 此对话已超出模型的上下文长度。请用 /rewind 撤回最近的对话，或用 /model 选择上下文更大的模型。",
+	"error.unavailable":      "🌐 模型服务商暂时不可用。请稍后再试。",
+	"error.provider":         "❌ 模型服务商返回了错误。请重试，如持续出现请联系机器人的管理员。",
+	"error.tool_timeout":     "⏱ 耗时过长，已停止",
+	"error.cancelled":        "⏹ 回答未完成即已停止。",
+	"error.internal":         "💥 内部出错了。请重试。",
+
+	"progress.working":         "⏳ 处理中…",
+	"progress.working_step":    "⏳ 处理中…（第 %d 步）",
+	"progress.running":         "🔧 正在运行 %s…",
+	"progress.done.other":      "✅ 已完成 %d 个工具",
+	"progress.failed.other":    "❌ %d 个失败",
+	"progress.subagents.other": "🤖 %d 个子代理正在工作",
+
+	"details.view":           "📋 查看详情",
+	"details.prev":           "◀ 上一个",
+	"details.back":           "📋 返回",
+	"details.next":           "下一个 ▶",
+	"details.tool":           "🔧 工具 %d/%d：%s",
+	"details.input":          "输入：",
+	"details.output":         "输出：",
+	"details.error":          "错误：",
+	"details.not_found":      "找不到详情",
+	"suggest.gone":           "这些建议已失效。",
+	"details.no_message":     "找不到消息",
+	"details.inaccessible":   "无法访问消息",
+	"format.current":         "回复格式：%s。使用 /format html 或 /format markdownv2 切换。",
+	"format.set":             "此聊天的回复现在使用 %s。",
+	"format.unknown":         "未知格式 %q。请使用 html 或 markdownv2。",
+	"display.current":        "显示方式：%s。使用 /display stream、/display segments、/display progress 或 /display quiet 切换。",
+	"display.set":            "此聊天的显示方式现在是 %s。",
+	"display.unknown":        "未知显示方式 %q。请使用 stream、segments、progress 或 quiet。",
+	"digest.current":         "工具摘要：%s。使用 /digest on 或 /digest off 切换。",
+	"digest.set":             "此聊天的工具摘要现在是 %s。",
+	"digest.unknown":         "未知设置 %q。请使用 on 或 off。",
+	"digest.header.other":    "🧰 %d 次工具调用",
+	"lang.current":           "语言：%s。可用：%s。使用 /lang <代码> 切换。",
+	"lang.set":               "此聊天现在使用中文。",
+	"lang.unknown":           "未知语言 %q。可用：%s。",
+	"queue.queued":           "⏳ 已排队（前面还有 %d 条）。发送 /cancel 可停止当前回复。",
+	"queue.stopping":         "⏹ 正在停止当前回复。",
+	"queue.idle":             "此聊天中没有正在进行的回复。",
+	"command.owner_only":     "❌ %s 仅限所有者使用。",
+	"session.idle_reset":     "💤 上次对话已闲置 %s，已开始新的对话。",
+	"persona.list":           "可用的人设：",
+	"persona.hint":           "使用 /persona <名称> 切换。",
+	"persona.switched":       "✅ 已切换到人设 %q，对话已重置。",
+	"model.list":             "当前模型：%s\n\n可用模型：",
+	"model.hint":             "使用 /model <提供商>/<模型> 切换。",
+	"model.form":             "❌ 请使用 <提供商>/<模型> 的格式，例如 /model default/gpt-4o-mini",
+	"model.unknown_provider": "❌ 未知的提供商：%s",
+	"model.switched":         "✅ 已切换到模型 %q。",
+	"export.empty":           "还没有可导出的内容。",
+	"export.failed":          "❌ 无法保存导出文件：%v",
+	"export.caption":         "📄 对话导出",
+	"approvals.off":          "未启用记住审批。",
+	"approvals.none":         "此聊天中没有始终允许的操作。",
+	"approvals.list":         "此聊天中始终允许：",
+	"approvals.hint":         "使用 /approvals forget <编号> 或 /approvals forget all 取消。",
+	"approvals.usage":        "用法：/approvals、/approvals forget <编号> 或 /approvals forget all",
+	"approvals.bad_number":   "❌ 不是规则编号：%s",
+	"approvals.not_found":    "❌ 没有这条规则",
+	"approvals.forgot.other": "✅ 已取消 %d 条规则，这些调用将重新需要审批。",
+	"stats.off":              "未记录工具统计。",
+	"stats.usage":            "用法：/stats 或 /stats <天数>",
+	"stats.all_time":         "全部时间",
+	"stats.days.other":       "最近 %d 天",
+	"stats.none":             "没有记录到工具调用（%s）。",
+	"stats.list":             "工具调用（%s）：",
+	"stats.line.other":       "%d 次，成功率 %.0f%%，p50 %dms，p90 %dms，p99 %dms",
+	"dryrun.off":             "演练模式不可用。",
+	"dryrun.status_on":       "演练模式已开启（%s）。\n\n使用 /dryrun on、/dryrun off 或 /dryrun default 更改。",
+	"dryrun.status_off":      "演练模式已关闭（%s）。\n\n使用 /dryrun on、/dryrun off 或 /dryrun default 更改。",
+	"dryrun.source_default":  "全局默认",
+	"dryrun.source_chat":     "此聊天的设置",
+	"dryrun.set_on":          "✅ 已开启演练模式。文件和命令只会被描述，不会被修改或执行。",
+	"dryrun.set_off":         "✅ 已关闭演练模式。工具将重新实际执行。",
+	"dryrun.default_on":      "✅ 此聊天已恢复全局默认，即开启演练模式。",
+	"dryrun.default_off":     "✅ 此聊天已恢复全局默认，即关闭演练模式。",
+	"dryrun.usage":           "用法：/dryrun、/dryrun on、/dryrun off 或 /dryrun default",
+	"tasks.off":              "未启用任务。",
+	"tasks.bad_number":       "❌ 不是任务编号：%s",
+	"tasks.not_found":        "❌ 此聊天中没有任务 #%d",
+	"tasks.usage":            "用法：/tasks、/tasks all、/tasks done <编号> 或 /tasks cancel <编号>",
+	"tasks.none":             "此聊天中没有任务。",
+	"tasks.none_open":        "此聊天中没有未完成的任务。",
+	"tasks.list":             "任务：",
+	"tasks.hint":             "使用 /tasks done <编号> 完成任务，使用 /tasks all 显示已结束的任务。",
+	"checkpoint.off":         "未启用检查点。",
+	"checkpoint.hash_name":   "❌ 检查点名称不能以 # 开头。",
+	"checkpoint.number_name": "❌ 检查点名称不能是数字；/rewind <数字> 用于撤销轮次。",
+	"checkpoint.empty":       "还没有可保存的对话。",
+	"checkpoint.saved":       "📌 已保存检查点 %s。\n\n使用 /rewind #%d 返回。",
+	"checkpoint.not_found":   "❌ 没有这个检查点",
+	"checkpoint.deleted":     "✅ 已删除检查点。",
+	"checkpoints.usage":      "用法：/checkpoints 或 /checkpoints delete <#编号或名称>",
+	"checkpoints.none":       "此聊天中没有检查点。使用 /checkpoint [名称] 保存。",
+	"checkpoints.list":       "检查点：",
+	"checkpoints.hint":       "使用 /rewind <#编号或名称> 返回。",
+	"rewind.no_checkpoints":  "未启用检查点；请使用 /rewind <轮数>。",
+	"rewind.not_found":       "❌ 没有这个检查点：%s",
+	"rewind.empty":           "还没有可撤销的内容。",
+	"rewind.too_few":         "❌ 轮数至少为 1",
+	"rewind.too_many.other":  "❌ 对话只有 %d 轮",
+	"rewind.saved":           "撤销前的对话已保存为检查点 #%d；使用 /rewind #%d 返回。",
+	"rewind.restored":        "⏪ 已恢复检查点 %s。",
+	"rewind.undone.other":    "⏪ 已撤销 %d 轮，剩余 %d 轮。",
+	"help.text": `命令：
+/help - 显示此列表
+/cancel - 停止正在生成的回复
+/model [名称] - 查看或切换模型
+/persona [名称] - 查看或切换人设
+/settings - 查看或修改采样设置
+/tasks - 列出任务
+/checkpoint [名称] - 保存对话
+/checkpoints - 列出已保存的检查点
+/rewind [轮数或名称] - 撤销若干轮或回到检查点
+/export - 导出对话
+/approvals - 管理已记住的批准
+/dryrun - 切换工具的试运行模式
+/lang [代码] - 查看或切换语言
+/prompt [show|set|clear] - 查看或补充系统提示词
+/profile - 查看或更正关于你的资料
+/status - 显示运行时间、会话、队列和最近的错误
+/stats [天数] - 显示工具调用次数、成功率和延迟`,
+}
@@ -0,0 +1,56 @@
+package kb
+
+import "strings"
+
+// splitChunks cuts text into chunks of at most size runes. Chunks end at a
+// paragraph, line, or sentence break where one falls in the last third of
+// the window, and consecutive chunks share overlap runes of context.
+func splitChunks(text string, size, overlap int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) == 0 {
+		return nil
+	}
+	if overlap >= size {
+		overlap = size / 4
+	}
+
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			end = breakPoint(runes, start+size*2/3, end)
+		}
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+		start = max(end-overlap, start+1)
+	}
+	return chunks
+}
+
+// breakPoint returns the position just after the strongest break in
+// runes[lo:hi], or hi when there is none.
+func breakPoint(runes []rune, lo, hi int) int {
+	best, bestRank := hi, 0
+	for i := hi - 1; i >= lo; i-- {
+		rank := 0
+		switch {
+		case runes[i] == '\n' && i > 0 && runes[i-1] == '\n':
+			rank = 3
+		case runes[i] == '\n':
+			rank = 2
+		case (runes[i] == '.' || runes[i] == '!' || runes[i] == '?' || runes[i] == '。') && i+1 < len(runes) && (runes[i+1] == ' ' || runes[i+1] == '\n'):
+			rank = 1
+		}
+		if rank > bestRank {
+			best, bestRank = i+1, rank
+			if rank == 3 {
+				break
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,257 @@
+package kb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nene-agent/nene/pkg/document"
+)
+
+// maxSourceBytes bounds the size of a single file or download.
+const maxSourceBytes = 50 * 1024 * 1024
+
+var errBinary = errors.New("not a text or document file")
+
+// Report summarizes an ingestion run.
+type Report struct {
+	Added     []Document
+	Unchanged int
+	Removed   []string
+	Failed    map[string]string
+}
+
+func (r *Report) fail(source string, err error) {
+	if r.Failed == nil {
+		r.Failed = make(map[string]string)
+	}
+	r.Failed[source] = err.Error()
+}
+
+func (r Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d added, %d unchanged, %d removed, %d failed", len(r.Added), r.Unchanged, len(r.Removed), len(r.Failed))
+	for _, d := range r.Added {
+		fmt.Fprintf(&sb, "\n+ %s (%d chunks)", d.Source, d.Chunks)
+	}
+	for _, s := range r.Removed {
+		fmt.Fprintf(&sb, "\n- %s", s)
+	}
+	for _, s := range slices.Sorted(maps.Keys(r.Failed)) {
+		fmt.Fprintf(&sb, "\n! %s: %s", s, r.Failed[s])
+	}
+	return sb.String()
+}
+
+// Ingest indexes a file, every file below a directory, or a URL. Documents
+// from a directory whose files have since disappeared are removed.
+func (k *KB) Ingest(ctx context.Context, source string) Report {
+	var r Report
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		k.addURL(ctx, source, &r)
+		return r
+	}
+
+	abs, err := filepath.Abs(expandHome(source))
+	if err != nil {
+		r.fail(source, err)
+		return r
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		r.fail(source, err)
+		return r
+	}
+	if !info.IsDir() {
+		k.addFile(ctx, abs, &r, false)
+		return r
+	}
+
+	seen := make(map[string]bool)
+	err = filepath.WalkDir(abs, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			r.fail(p, err)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != abs {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			seen[p] = true
+			k.addFile(ctx, p, &r, true)
+		}
+		return nil
+	})
+	if err != nil {
+		r.fail(source, err)
+		return r
+	}
+
+	docs, err := k.List(ctx)
+	if err != nil {
+		r.fail(source, err)
+		return r
+	}
+	for _, d := range docs {
+		if strings.HasPrefix(d.Source, abs+string(filepath.Separator)) && !seen[d.Source] {
+			if _, err := k.Remove(ctx, d.Source); err != nil {
+				r.fail(d.Source, err)
+				continue
+			}
+			r.Removed = append(r.Removed, d.Source)
+		}
+	}
+	return r
+}
+
+// addFile indexes one file. Binary files are skipped silently while walking
+// a directory and reported otherwise.
+func (k *KB) addFile(ctx context.Context, p string, r *Report, walking bool) {
+	info, err := os.Stat(p)
+	if err != nil {
+		r.fail(p, err)
+		return
+	}
+	if info.Size() > maxSourceBytes {
+		r.fail(p, fmt.Errorf("file is larger than %d MB", maxSourceBytes/1024/1024))
+		return
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		r.fail(p, err)
+		return
+	}
+	text, err := toText(p, "", data)
+	if errors.Is(err, errBinary) && walking {
+		return
+	}
+	if err != nil {
+		r.fail(p, err)
+		return
+	}
+	k.add(ctx, p, filepath.Base(p), text, r)
+}
+
+func (k *KB) addURL(ctx context.Context, rawURL string, r *Report) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		r.fail(rawURL, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		r.fail(rawURL, err)
+		return
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; nene/1.0)")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		r.fail(rawURL, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		r.fail(rawURL, fmt.Errorf("status %d", resp.StatusCode))
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceBytes))
+	if err != nil {
+		r.fail(rawURL, err)
+		return
+	}
+
+	text, err := toText(u.Path, resp.Header.Get("Content-Type"), data)
+	if err != nil {
+		r.fail(rawURL, err)
+		return
+	}
+	title := path.Base(u.Path)
+	if title == "/" || title == "." {
+		title = u.Host
+	}
+	k.add(ctx, rawURL, title, text, r)
+}
+
+func (k *KB) add(ctx context.Context, source, title, text string, r *Report) {
+	doc, changed, err := k.AddText(ctx, source, title, text)
+	switch {
+	case err != nil:
+		r.fail(source, err)
+	case changed:
+		r.Added = append(r.Added, doc)
+	default:
+		r.Unchanged++
+	}
+}
+
+// toText extracts the text of a document, HTML page, or plain text file.
+func toText(name, contentType string, data []byte) (string, error) {
+	if kind := document.Kind(name, contentType, data); kind != "" {
+		return document.Extract(kind, data, document.Options{})
+	}
+	if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 || !utf8.Valid(data) {
+		return "", errBinary
+	}
+	text := string(data)
+	ext := strings.ToLower(path.Ext(name))
+	if strings.Contains(contentType, "text/html") || ext == ".html" || ext == ".htm" {
+		text = document.HTMLText(text)
+	}
+	return text, nil
+}
+
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[1:])
+		}
+	}
+	return p
+}
+
+// Sync ingests every source, then repeats every interval until ctx is done.
+// Local sources that do not exist yet are skipped.
+func (k *KB) Sync(ctx context.Context, sources []string, interval time.Duration) {
+	for {
+		for _, source := range sources {
+			if !strings.Contains(source, "://") {
+				if _, err := os.Stat(expandHome(source)); os.IsNotExist(err) {
+					continue
+				}
+			}
+			r := k.Ingest(ctx, source)
+			if len(r.Added) > 0 || len(r.Removed) > 0 || len(r.Failed) > 0 {
+				fmt.Printf("kb: synced %s: %s\n", source, r)
+			}
+		}
+		if interval <= 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,409 @@
+// Package kb indexes reference documents in chunks and retrieves the chunks
+// most relevant to a query. Chunks are ranked by embedding similarity when
+// an Embedder is configured and by full-text search otherwise.
+package kb
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+
+	"github.com/nene-agent/nene/pkg/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+const (
+	DefaultChunkSize    = 1000
+	DefaultChunkOverlap = 150
+)
+
+type Document struct {
+	ID      string    `json:"id"`
+	Source  string    `json:"source"`
+	Title   string    `json:"title"`
+	Chunks  int       `json:"chunks"`
+	Model   string    `json:"model,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+type Hit struct {
+	Source  string  `json:"source"`
+	Title   string  `json:"title"`
+	Seq     int     `json:"seq"`
+	Content string  `json:"content"`
+	Score   float64 `json:"score"`
+}
+
+type vector struct {
+	chunkID int64
+	v       []float32
+}
+
+type KB struct {
+	db       *sql.DB
+	embedder Embedder
+	size     int
+	overlap  int
+
+	mu sync.RWMutex
+	// vectors caches the normalized embeddings of the current model; nil
+	// until first loaded.
+	vectors []vector
+}
+
+type Option func(*KB)
+
+func WithEmbedder(e Embedder) Option {
+	return func(k *KB) { k.embedder = e }
+}
+
+// WithChunkSize sets the chunk length and the overlap between consecutive
+// chunks, both in characters. Non-positive values keep the defaults.
+func WithChunkSize(size, overlap int) Option {
+	return func(k *KB) {
+		if size > 0 {
+			k.size = size
+		}
+		if overlap > 0 {
+			k.overlap = overlap
+		}
+	}
+}
+
+func Open(dataDir string, opts ...Option) (*KB, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("create data directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(dataDir, "kb.db"))
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("enable WAL mode: %w", err)
+	}
+
+	k := &KB{db: db, size: DefaultChunkSize, overlap: DefaultChunkOverlap}
+	for _, opt := range opts {
+		opt(k)
+	}
+	if err := k.initSchema(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init schema: %w", err)
+	}
+	return k, nil
+}
+
+func (k *KB) initSchema() error {
+	ms, err := migrate.Load(migrations, "migrations")
+	if err != nil {
+		return err
+	}
+	_, err = migrate.Apply(context.Background(), k.db, ms)
+	return err
+}
+
+func (k *KB) Close() error {
+	return k.db.Close()
+}
+
+func (k *KB) model() string {
+	if k.embedder == nil {
+		return ""
+	}
+	return k.embedder.Model()
+}
+
+// AddText indexes text under source, replacing an earlier document with the
+// same source. It reports false when the source is already indexed with the
+// same text and embedding model.
+func (k *KB) AddText(ctx context.Context, source, title, text string) (Document, bool, error) {
+	sum := sha256.Sum256([]byte(text))
+	hash := hex.EncodeToString(sum[:])
+
+	var existing Document
+	var existingHash, created string
+	err := k.db.QueryRowContext(ctx,
+		"SELECT id, source, title, chunks, model, hash, created_at FROM documents WHERE source = ?", source,
+	).Scan(&existing.ID, &existing.Source, &existing.Title, &existing.Chunks, &existing.Model, &existingHash, &created)
+	if err == nil && existingHash == hash && existing.Model == k.model() {
+		existing.Created, _ = time.Parse(time.RFC3339, created)
+		return existing, false, nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Document{}, false, fmt.Errorf("look up document: %w", err)
+	}
+
+	chunks := splitChunks(text, k.size, k.overlap)
+	if len(chunks) == 0 {
+		return Document{}, false, errors.New("document has no text")
+	}
+	var vectors [][]float32
+	if k.embedder != nil {
+		if vectors, err = k.embedder.Embed(ctx, chunks); err != nil {
+			return Document{}, false, err
+		}
+	}
+
+	doc := Document{
+		ID:      uuid.NewString(),
+		Source:  source,
+		Title:   title,
+		Chunks:  len(chunks),
+		Model:   k.model(),
+		Created: time.Now().UTC(),
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	tx, err := k.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Document{}, false, err
+	}
+	defer tx.Rollback()
+
+	if err := deleteSource(ctx, tx, source); err != nil {
+		return Document{}, false, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO documents (id, source, title, hash, chunks, model, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		doc.ID, doc.Source, doc.Title, hash, doc.Chunks, doc.Model, doc.Created.Format(time.RFC3339),
+	); err != nil {
+		return Document{}, false, fmt.Errorf("insert document: %w", err)
+	}
+	for i, chunk := range chunks {
+		var blob []byte
+		if vectors != nil {
+			blob = encodeVector(vectors[i])
+		}
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO chunks (doc_id, seq, content, embedding) VALUES (?, ?, ?, ?)",
+			doc.ID, i, chunk, blob,
+		); err != nil {
+			return Document{}, false, fmt.Errorf("insert chunk: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Document{}, false, err
+	}
+	k.vectors = nil
+	return doc, true, nil
+}
+
+func deleteSource(ctx context.Context, tx *sql.Tx, source string) error {
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM chunks WHERE doc_id IN (SELECT id FROM documents WHERE source = ?)", source,
+	); err != nil {
+		return fmt.Errorf("delete chunks: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM documents WHERE source = ?", source); err != nil {
+		return fmt.Errorf("delete document: %w", err)
+	}
+	return nil
+}
+
+func (k *KB) List(ctx context.Context) ([]Document, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	rows, err := k.db.QueryContext(ctx,
+		"SELECT id, source, title, chunks, model, created_at FROM documents ORDER BY source")
+	if err != nil {
+		return nil, fmt.Errorf("list documents: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []Document
+	for rows.Next() {
+		var d Document
+		var created string
+		if err := rows.Scan(&d.ID, &d.Source, &d.Title, &d.Chunks, &d.Model, &created); err != nil {
+			return nil, fmt.Errorf("scan document: %w", err)
+		}
+		d.Created, _ = time.Parse(time.RFC3339, created)
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+// Remove deletes the document with the given ID or source.
+func (k *KB) Remove(ctx context.Context, idOrSource string) (bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	var source string
+	err := k.db.QueryRowContext(ctx,
+		"SELECT source FROM documents WHERE id = ? OR source = ?", idOrSource, idOrSource,
+	).Scan(&source)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("look up document: %w", err)
+	}
+
+	tx, err := k.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	if err := deleteSource(ctx, tx, source); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	k.vectors = nil
+	return true, nil
+}
+
+// Search returns the chunks most relevant to query, best first.
+func (k *KB) Search(ctx context.Context, query string, limit int) ([]Hit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+	if k.embedder != nil {
+		hits, err := k.searchVectors(ctx, query, limit)
+		if err == nil {
+			return hits, nil
+		}
+		fmt.Printf("kb: vector search failed, using full-text search: %v\n", err)
+	}
+	return k.searchText(ctx, query, limit)
+}
+
+func (k *KB) searchVectors(ctx context.Context, query string, limit int) ([]Hit, error) {
+	qv, err := k.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	q := normalize(qv[0])
+
+	vectors, err := k.loadVectors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		id    int64
+		score float64
+	}
+	var ranked []scored
+	for _, v := range vectors {
+		if len(v.v) == len(q) {
+			ranked = append(ranked, scored{v.chunkID, dot(q, v.v)})
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	hits := make([]Hit, 0, len(ranked))
+	for _, r := range ranked {
+		var h Hit
+		err := k.db.QueryRowContext(ctx, `
+			SELECT d.source, d.title, c.seq, c.content
+			FROM chunks c JOIN documents d ON d.id = c.doc_id
+			WHERE c.id = ?`, r.id,
+		).Scan(&h.Source, &h.Title, &h.Seq, &h.Content)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load chunk: %w", err)
+		}
+		h.Score = r.score
+		hits = append(hits, h)
+	}
+	return hits, nil
+}
+
+// loadVectors returns the cached embeddings made with the current model,
+// reading them from the database on first use after a change.
+func (k *KB) loadVectors(ctx context.Context) ([]vector, error) {
+	k.mu.RLock()
+	vectors := k.vectors
+	k.mu.RUnlock()
+	if vectors != nil {
+		return vectors, nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.vectors != nil {
+		return k.vectors, nil
+	}
+	rows, err := k.db.QueryContext(ctx, `
+		SELECT c.id, c.embedding
+		FROM chunks c JOIN documents d ON d.id = c.doc_id
+		WHERE d.model = ? AND c.embedding IS NOT NULL`, k.model())
+	if err != nil {
+		return nil, fmt.Errorf("load embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	vectors = []vector{}
+	for rows.Next() {
+		var id int64
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			return nil, fmt.Errorf("scan embedding: %w", err)
+		}
+		vectors = append(vectors, vector{id, normalize(decodeVector(blob))})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	k.vectors = vectors
+	return vectors, nil
+}
+
+func (k *KB) searchText(ctx context.Context, query string, limit int) ([]Hit, error) {
+	var terms []string
+	for _, w := range strings.Fields(query) {
+		terms = append(terms, `"`+strings.ReplaceAll(w, `"`, `""`)+`"`)
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	rows, err := k.db.QueryContext(ctx, `
+		SELECT d.source, d.title, c.seq, c.content, -bm25(chunks_fts)
+		FROM chunks_fts f
+		JOIN chunks c ON c.id = f.rowid
+		JOIN documents d ON d.id = c.doc_id
+		WHERE chunks_fts MATCH ?
+		ORDER BY bm25(chunks_fts)
+		LIMIT ?`, strings.Join(terms, " OR "), limit)
+	if err != nil {
+		return nil, fmt.Errorf("search chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []Hit
+	for rows.Next() {
+		var h Hit
+		if err := rows.Scan(&h.Source, &h.Title, &h.Seq, &h.Content, &h.Score); err != nil {
+			return nil, fmt.Errorf("scan chunk: %w", err)
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
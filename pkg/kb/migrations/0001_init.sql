@@ -0,0 +1,34 @@
+-- The schema from before migrations; IF NOT EXISTS lets it run on databases
+-- created back then.
+CREATE TABLE IF NOT EXISTS documents (
+	id          TEXT PRIMARY KEY,
+	source      TEXT NOT NULL UNIQUE,
+	title       TEXT NOT NULL,
+	hash        TEXT NOT NULL,
+	chunks      INTEGER NOT NULL,
+	model       TEXT NOT NULL DEFAULT '',
+	created_at  TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS chunks (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	doc_id      TEXT NOT NULL,
+	seq         INTEGER NOT NULL,
+	content     TEXT NOT NULL,
+	embedding   BLOB
+);
+
+CREATE INDEX IF NOT EXISTS idx_chunks_doc ON chunks(doc_id);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS chunks_fts USING fts5(
+	content, content=chunks, content_rowid=id
+);
+
+CREATE TRIGGER IF NOT EXISTS chunks_ai AFTER INSERT ON chunks BEGIN
+	INSERT INTO chunks_fts(rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS chunks_ad AFTER DELETE ON chunks BEGIN
+	INSERT INTO chunks_fts(chunks_fts, rowid, content)
+	VALUES ('delete', old.id, old.content);
+END;
@@ -0,0 +1,3 @@
+-- Re-index every chunk, including any stored before the triggers kept the
+-- index in step.
+INSERT INTO chunks_fts(chunks_fts) VALUES ('rebuild');
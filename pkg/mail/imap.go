@@ -0,0 +1,191 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	_ "github.com/emersion/go-message/charset"
+	gomail "github.com/emersion/go-message/mail"
+
+	"github.com/nene-agent/nene/pkg/document"
+)
+
+// maxBodyChars bounds the text kept from one incoming message.
+const maxBodyChars = 20000
+
+type IMAPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Mailbox defaults to INBOX.
+	Mailbox string `json:"mailbox"`
+	// Security is "tls" (default), "starttls", or "none".
+	Security string `json:"security"`
+}
+
+// Incoming is a received message reduced to what the agent needs.
+type Incoming struct {
+	MessageID  string
+	From       string
+	FromName   string
+	Subject    string
+	Body       string
+	Date       time.Time
+	References string
+	// Automated is set for auto-replies, bounces, and list mail, which
+	// should not be answered.
+	Automated bool
+}
+
+func (c IMAPConfig) addr() string {
+	port := c.Port
+	if port == 0 {
+		port = 993
+		if c.Security == SecurityNone || c.Security == SecuritySTARTTLS {
+			port = 143
+		}
+	}
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+// FetchUnseen returns up to limit unseen messages, oldest first, and marks
+// them seen.
+func FetchUnseen(cfg IMAPConfig, limit int) ([]Incoming, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var c *client.Client
+	var err error
+	if cfg.Security == "" || cfg.Security == SecurityTLS {
+		c, err = client.DialWithDialerTLS(dialer, cfg.addr(), nil)
+	} else {
+		c, err = client.DialWithDialer(dialer, cfg.addr())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect to IMAP server: %w", err)
+	}
+	defer c.Logout()
+	c.Timeout = time.Minute
+
+	if cfg.Security == SecuritySTARTTLS {
+		if err := c.StartTLS(nil); err != nil {
+			return nil, fmt.Errorf("STARTTLS: %w", err)
+		}
+	}
+	if err := c.Login(cfg.Username, cfg.Password); err != nil {
+		return nil, fmt.Errorf("IMAP login: %w", err)
+	}
+	mailbox := cfg.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	if _, err := c.Select(mailbox, false); err != nil {
+		return nil, fmt.Errorf("select %s: %w", mailbox, err)
+	}
+
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+	uids, err := c.UidSearch(criteria)
+	if err != nil {
+		return nil, fmt.Errorf("search unseen: %w", err)
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	if limit > 0 && len(uids) > limit {
+		uids = uids[:limit]
+	}
+
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(uids...)
+	// Fetching BODY[] rather than BODY.PEEK[] marks the messages seen.
+	section := &imap.BodySectionName{}
+	messages := make(chan *imap.Message, len(uids))
+	if err := c.UidFetch(seqset, []imap.FetchItem{section.FetchItem()}, messages); err != nil {
+		return nil, fmt.Errorf("fetch messages: %w", err)
+	}
+
+	var out []Incoming
+	for m := range messages {
+		r := m.GetBody(section)
+		if r == nil {
+			continue
+		}
+		in, err := parse(r)
+		if err != nil {
+			fmt.Printf("mail: skipping unreadable message %d: %v\n", m.Uid, err)
+			continue
+		}
+		out = append(out, in)
+	}
+	return out, nil
+}
+
+func parse(r io.Reader) (Incoming, error) {
+	mr, err := gomail.CreateReader(r)
+	if err != nil {
+		return Incoming{}, err
+	}
+	defer mr.Close()
+
+	h := mr.Header
+	var in Incoming
+	if from, err := h.AddressList("From"); err == nil && len(from) > 0 {
+		in.From = strings.ToLower(from[0].Address)
+		in.FromName = from[0].Name
+	}
+	if in.From == "" {
+		return Incoming{}, errors.New("no sender")
+	}
+	in.Subject, _ = h.Subject()
+	in.Date, _ = h.Date()
+	if id, err := h.MessageID(); err == nil && id != "" {
+		in.MessageID = "<" + id + ">"
+	}
+	in.References = strings.TrimSpace(h.Get("References") + " " + in.MessageID)
+	auto := strings.ToLower(h.Get("Auto-Submitted"))
+	precedence := strings.ToLower(h.Get("Precedence"))
+	in.Automated = (auto != "" && auto != "no") ||
+		precedence == "bulk" || precedence == "list" || precedence == "junk" ||
+		h.Get("List-Id") != "" || strings.HasPrefix(in.From, "mailer-daemon@")
+
+	var plain, html string
+	for {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Incoming{}, err
+		}
+		ih, ok := p.Header.(*gomail.InlineHeader)
+		if !ok {
+			continue
+		}
+		ct, _, _ := ih.ContentType()
+		data, err := io.ReadAll(io.LimitReader(p.Body, 4*maxBodyChars))
+		if err != nil {
+			continue
+		}
+		switch {
+		case ct == "text/plain" && plain == "":
+			plain = string(data)
+		case ct == "text/html" && html == "":
+			html = string(data)
+		}
+	}
+	in.Body = plain
+	if in.Body == "" && html != "" {
+		in.Body = document.HTMLText(html)
+	}
+	if r := []rune(in.Body); len(r) > maxBodyChars {
+		in.Body = string(r[:maxBodyChars]) + "\n[message truncated]"
+	}
+	return in, nil
+}
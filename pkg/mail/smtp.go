@@ -0,0 +1,171 @@
+// Package mail sends mail over SMTP and reads unseen mail over IMAP.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Connection security modes.
+const (
+	SecurityTLS      = "tls"
+	SecuritySTARTTLS = "starttls"
+	SecurityNone     = "none"
+)
+
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+	// Security is "starttls" (default), "tls", or "none".
+	Security string `json:"security"`
+}
+
+type Message struct {
+	To         []string
+	Cc         []string
+	Subject    string
+	Body       string
+	InReplyTo  string
+	References string
+}
+
+func (c SMTPConfig) addr() string {
+	port := c.Port
+	if port == 0 {
+		port = 587
+		if c.Security == SecurityTLS {
+			port = 465
+		}
+	}
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+// Send delivers msg and returns its Message-ID.
+func Send(ctx context.Context, cfg SMTPConfig, msg Message) (string, error) {
+	if cfg.Host == "" || cfg.From == "" {
+		return "", errors.New("SMTP is not configured")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return "", fmt.Errorf("invalid from address %q: %w", cfg.From, err)
+	}
+	var rcpts []string
+	for _, list := range [][]string{msg.To, msg.Cc} {
+		for _, a := range list {
+			addr, err := mail.ParseAddress(a)
+			if err != nil {
+				return "", fmt.Errorf("invalid recipient %q: %w", a, err)
+			}
+			rcpts = append(rcpts, addr.Address)
+		}
+	}
+	if len(rcpts) == 0 {
+		return "", errors.New("no recipients")
+	}
+
+	id := messageID(from.Address)
+	data := compose(from, id, msg)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", cfg.addr())
+	if err != nil {
+		return "", fmt.Errorf("connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+	if cfg.Security == SecurityTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return "", fmt.Errorf("SMTP handshake: %w", err)
+	}
+	defer c.Close()
+
+	if cfg.Security == "" || cfg.Security == SecuritySTARTTLS {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return "", fmt.Errorf("STARTTLS: %w", err)
+		}
+	}
+	if cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return "", fmt.Errorf("SMTP auth: %w", err)
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return "", fmt.Errorf("MAIL FROM: %w", err)
+	}
+	for _, r := range rcpts {
+		if err := c.Rcpt(r); err != nil {
+			return "", fmt.Errorf("RCPT TO %s: %w", r, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return "", fmt.Errorf("DATA: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", fmt.Errorf("write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("send message: %w", err)
+	}
+	return id, c.Quit()
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if _, d, ok := strings.Cut(from, "@"); ok {
+		domain = d
+	}
+	return "<" + uuid.NewString() + "@" + domain + ">"
+}
+
+// compose renders a plain-text UTF-8 message.
+func compose(from *mail.Address, id string, msg Message) []byte {
+	var b bytes.Buffer
+	header := func(k, v string) {
+		if v != "" {
+			fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+		}
+	}
+	header("From", from.String())
+	header("To", strings.Join(msg.To, ", "))
+	header("Cc", strings.Join(msg.Cc, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", id)
+	header("In-Reply-To", msg.InReplyTo)
+	header("References", msg.References)
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	b.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&b)
+	qp.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n")))
+	qp.Close()
+	return b.Bytes()
+}
@@ -0,0 +1,307 @@
+// Package mattermost is a channel for Mattermost and Rocket.Chat. Messages
+// arrive through an outgoing webhook and answers are posted with the REST
+// API.
+package mattermost
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/channel"
+)
+
+const (
+	FlavorMattermost = "mattermost"
+	FlavorRocketChat = "rocketchat"
+)
+
+// maxPostChars is below the smaller of the two servers' post limits
+// (Mattermost allows 16383 characters).
+const maxPostChars = 16000
+
+type MattermostConfig struct {
+	// Flavor is "mattermost" (default) or "rocketchat".
+	Flavor string `json:"flavor"`
+	// URL is the server's base URL, e.g. https://chat.example.com.
+	URL string `json:"url"`
+	// Token is a bot or personal access token. Rocket.Chat also needs the
+	// token owner's UserID.
+	Token  string `json:"token"`
+	UserID string `json:"user_id"`
+	// Listen is the address the outgoing webhook is served on, at /hook.
+	Listen string `json:"listen"`
+	// WebhookToken is the outgoing webhook's token; requests carrying any
+	// other token are rejected.
+	WebhookToken string   `json:"webhook_token"`
+	AllowFrom    []string `json:"allow_from"`
+	Owners       []string `json:"owners"`
+}
+
+// hook is an outgoing webhook request. Both servers send these fields,
+// Mattermost as JSON or a form and Rocket.Chat as JSON.
+type hook struct {
+	Token       string `json:"token"`
+	ChannelID   string `json:"channel_id"`
+	ChannelName string `json:"channel_name"`
+	UserID      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	PostID      string `json:"post_id"`
+	MessageID   string `json:"message_id"`
+	Text        string `json:"text"`
+	TriggerWord string `json:"trigger_word"`
+	Bot         any    `json:"bot"`
+}
+
+type MattermostChannel struct {
+	*channel.BaseChannel
+	config  MattermostConfig
+	client  *http.Client
+	replies *channel.Replies
+	srv     *http.Server
+	cancel  context.CancelFunc
+	// self is the user the token belongs to; its own posts are ignored.
+	self string
+}
+
+func NewMattermostChannel(cfg MattermostConfig, messageBus *bus.MessageBus) *MattermostChannel {
+	if cfg.Flavor == "" {
+		cfg.Flavor = FlavorMattermost
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	base := channel.NewBaseChannel(cfg.Flavor, messageBus, cfg.AllowFrom)
+	base.SetOwners(cfg.Owners)
+	return &MattermostChannel{
+		BaseChannel: base,
+		config:      cfg,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		replies:     channel.NewReplies(),
+		self:        cfg.UserID,
+	}
+}
+
+func (c *MattermostChannel) Start(ctx context.Context) error {
+	if c.config.URL == "" || c.config.Token == "" {
+		return fmt.Errorf("%s channel needs url and token", c.Name())
+	}
+	if c.self == "" && c.config.Flavor == FlavorMattermost {
+		id, err := c.me(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to look up bot user: %w", err)
+		}
+		c.self = id
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /hook", c.handleHook)
+	c.srv = &http.Server{
+		Addr:              c.config.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.ConsumeStream(ctx, c)
+	c.SetRunning(true)
+	fmt.Printf("%s webhook listening on %s/hook\n", c.Name(), c.config.Listen)
+	go func() {
+		if err := c.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("%s webhook error: %v\n", c.Name(), err)
+		}
+	}()
+	return nil
+}
+
+func (c *MattermostChannel) Stop(ctx context.Context) error {
+	fmt.Printf("Stopping %s channel...\n", c.Name())
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.SetRunning(false)
+	if c.srv == nil {
+		return nil
+	}
+	return c.srv.Shutdown(ctx)
+}
+
+func (c *MattermostChannel) handleHook(w http.ResponseWriter, r *http.Request) {
+	var h hook
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&h); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		h = hook{
+			Token:       r.PostForm.Get("token"),
+			ChannelID:   r.PostForm.Get("channel_id"),
+			ChannelName: r.PostForm.Get("channel_name"),
+			UserID:      r.PostForm.Get("user_id"),
+			UserName:    r.PostForm.Get("user_name"),
+			PostID:      r.PostForm.Get("post_id"),
+			Text:        r.PostForm.Get("text"),
+			TriggerWord: r.PostForm.Get("trigger_word"),
+		}
+	}
+	if c.config.WebhookToken != "" &&
+		subtle.ConstantTimeCompare([]byte(h.Token), []byte(c.config.WebhookToken)) != 1 {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	// The hook is answered at once; the reply is posted when the turn ends.
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+
+	if h.UserID == "" || h.ChannelID == "" || h.UserID == c.self || isBot(h.Bot) {
+		return
+	}
+	text := strings.TrimSpace(h.Text)
+	if h.TriggerWord != "" {
+		text = strings.TrimSpace(strings.TrimPrefix(text, h.TriggerWord))
+	}
+	if text == "" {
+		return
+	}
+	metadata := map[string]string{
+		"channel_name": h.ChannelName,
+		"user_name":    h.UserName,
+		"post_id":      h.PostID + h.MessageID,
+	}
+	senderID := h.UserID + "|" + h.UserName
+	c.HandleMessage(senderID, h.ChannelID, text, nil, metadata, false)
+}
+
+// isBot reports whether Rocket.Chat marked the message as sent by a bot; it
+// sends false or an object describing the bot.
+func isBot(v any) bool {
+	if v == nil {
+		return false
+	}
+	b, ok := v.(bool)
+	return !ok || b
+}
+
+// OnStreamEvent posts the agent's answer when the turn finishes.
+func (c *MattermostChannel) OnStreamEvent(msg bus.StreamMessage) {
+	text, done := c.replies.Collect(msg, c.Locale(msg.ChatID))
+	if msg.Type == bus.StreamEventApproval {
+		text, done = channel.ApprovalPrompt(msg, c.Locale(msg.ChatID)), true
+	}
+	if !done {
+		return
+	}
+	if msg.Type == bus.StreamEventFinish {
+		c.FinishTurn(msg.ChatID)
+	}
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	go func() {
+		if err := c.post(context.Background(), msg.ChatID, text); err != nil {
+			fmt.Printf("%s reply to %s failed: %v\n", c.Name(), msg.ChatID, err)
+		}
+	}()
+}
+
+// Send posts an outbound message to the channel. Media files are listed by
+// name, not uploaded.
+func (c *MattermostChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	text := msg.MarkdownContent()
+	for _, m := range msg.Media {
+		text += "\n[attachment: " + m + "]"
+	}
+	return c.post(ctx, msg.ChatID, text)
+}
+
+func (c *MattermostChannel) post(ctx context.Context, channelID, text string) error {
+	for _, part := range split(text, maxPostChars) {
+		var err error
+		if c.config.Flavor == FlavorRocketChat {
+			err = c.call(ctx, http.MethodPost, "/api/v1/chat.postMessage",
+				map[string]string{"roomId": channelID, "text": part}, nil)
+		} else {
+			err = c.call(ctx, http.MethodPost, "/api/v4/posts",
+				map[string]string{"channel_id": channelID, "message": part}, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *MattermostChannel) me(ctx context.Context) (string, error) {
+	var user struct {
+		ID string `json:"id"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/api/v4/users/me", nil, &user); err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
+
+func (c *MattermostChannel) call(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.config.URL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.Flavor == FlavorRocketChat {
+		req.Header.Set("X-Auth-Token", c.config.Token)
+		req.Header.Set("X-User-Id", c.config.UserID)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// split breaks text into posts of at most limit characters, at line breaks
+// where possible.
+func split(text string, limit int) []string {
+	var parts []string
+	runes := []rune(text)
+	for len(runes) > limit {
+		cut, next := limit, limit
+		for i := limit; i > limit/2; i-- {
+			if runes[i] == '\n' {
+				cut, next = i, i+1
+				break
+			}
+		}
+		parts = append(parts, string(runes[:cut]))
+		runes = runes[next:]
+	}
+	return append(parts, string(runes))
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// DefaultSimilarity is the similarity above which a new entry is taken for
+// another wording of an existing one.
+const DefaultSimilarity = 0.7
+
+// Reconciler merges an existing entry's content with new content about the
+// same thing into one, usually with a model. Spend is charged to
+// sessionKey.
+type Reconciler func(ctx context.Context, sessionKey, existing, incoming string) (string, error)
+
+// AskReconciler builds a Reconciler from a single-shot question function
+// such as agent.SessionManager.Ask.
+func AskReconciler(ask func(ctx context.Context, sessionKey, question string) (string, error)) Reconciler {
+	return func(ctx context.Context, sessionKey, existing, incoming string) (string, error) {
+		question := "Two notes in your long-term memory say nearly the same thing. Merge them into one note that keeps every fact, preferring the newer note where they disagree. Reply with the merged note only.\n\n" +
+			"Older note:\n" + existing + "\n\nNewer note:\n" + incoming
+		merged, err := ask(ctx, sessionKey, question)
+		return strings.TrimSpace(merged), err
+	}
+}
+
+// FindSimilar returns the entry of req's category most like req, and how
+// alike they are from 0 to 1, when that is at least threshold. An entry
+// already stored under req.Key is not looked for, since storing replaces it
+// anyway.
+func FindSimilar(ctx context.Context, mem Memory, req *StoreRequest, threshold float64) (*Entry, float64, error) {
+	category := req.Category
+	if category == "" {
+		category = CategoryCore
+	}
+	candidates, err := mem.Recall(ctx, &RecallRequest{Query: req.Content, Limit: 10})
+	if err != nil {
+		return nil, 0, err
+	}
+	text := shingles(req.Key + " " + req.Content)
+	var best *Entry
+	var bestScore float64
+	for _, e := range candidates {
+		if e.Key == req.Key || e.Category != category {
+			continue
+		}
+		if score := dice(text, shingles(e.Key+" "+e.Content)); score > bestScore {
+			best, bestScore = e, score
+		}
+	}
+	if best == nil || bestScore < threshold {
+		return nil, 0, nil
+	}
+	return best, bestScore, nil
+}
+
+// shingles returns the three-character runs of s with case, punctuation,
+// and spacing evened out. Unlike words, they work for text without spaces
+// and match "user_likes_coffee" with "user likes coffee".
+func shingles(s string) map[string]bool {
+	var runes []rune
+	space := true
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, r)
+			space = false
+		} else if !space {
+			runes = append(runes, ' ')
+			space = true
+		}
+	}
+	set := make(map[string]bool)
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = true
+	}
+	return set
+}
+
+// dice is the Sørensen–Dice coefficient of two sets.
+func dice(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for s := range a {
+		if b[s] {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
@@ -10,6 +10,9 @@ type Memory interface {
 	Get(ctx context.Context, key string) (*Entry, error)
 	List(ctx context.Context, req *ListRequest) ([]*Entry, error)
 	Forget(ctx context.Context, key string) (bool, error)
+	Rename(ctx context.Context, oldKey, newKey string) (bool, error)
+	SetCategory(ctx context.Context, key string, c Category) (bool, error)
+	ForgetPrefix(ctx context.Context, prefix string) (int, error)
 	Count(ctx context.Context) (int, error)
 	Close() error
 }
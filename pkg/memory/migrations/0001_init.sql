@@ -0,0 +1,36 @@
+-- The schema from before migrations; IF NOT EXISTS lets it run on databases
+-- created back then.
+CREATE TABLE IF NOT EXISTS memories (
+	id          TEXT PRIMARY KEY,
+	key         TEXT NOT NULL UNIQUE,
+	content     TEXT NOT NULL,
+	category    TEXT NOT NULL DEFAULT 'core',
+	session_id  TEXT,
+	created_at  TEXT NOT NULL,
+	updated_at  TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_memories_category ON memories(category);
+CREATE INDEX IF NOT EXISTS idx_memories_key ON memories(key);
+CREATE INDEX IF NOT EXISTS idx_memories_session ON memories(session_id);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS memories_fts USING fts5(
+	key, content, content=memories, content_rowid=rowid
+);
+
+CREATE TRIGGER IF NOT EXISTS memories_ai AFTER INSERT ON memories BEGIN
+	INSERT INTO memories_fts(rowid, key, content)
+	VALUES (new.rowid, new.key, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS memories_ad AFTER DELETE ON memories BEGIN
+	INSERT INTO memories_fts(memories_fts, rowid, key, content)
+	VALUES ('delete', old.rowid, old.key, old.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS memories_au AFTER UPDATE ON memories BEGIN
+	INSERT INTO memories_fts(memories_fts, rowid, key, content)
+	VALUES ('delete', old.rowid, old.key, old.content);
+	INSERT INTO memories_fts(rowid, key, content)
+	VALUES (new.rowid, new.key, new.content);
+END;
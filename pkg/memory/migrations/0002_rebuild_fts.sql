@@ -0,0 +1,3 @@
+-- Re-index every entry, including any stored before the triggers kept the
+-- index in step.
+INSERT INTO memories_fts(memories_fts) VALUES ('rebuild');
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// recallPool is how many candidates per requested result Recall fetches
+// before ranking, so weights and quotas have something to choose from.
+const recallPool = 4
+
+// Ranking orders Recall results. Each match's relevance is multiplied by
+// its category's weight and, except for core memories, which hold
+// long-lived facts, halved every HalfLife since it was last updated.
+type Ranking struct {
+	// Weights multiply relevance by category; categories not listed
+	// weigh 1.
+	Weights map[Category]float64
+	// HalfLife is how fast daily and conversation memories fade; zero
+	// turns decay off.
+	HalfLife time.Duration
+	// Quota caps the results of a category in one recall; categories not
+	// listed have none.
+	Quota map[Category]int
+}
+
+// DefaultRanking puts core memories first and lets the others fade over a
+// month.
+func DefaultRanking() Ranking {
+	return Ranking{
+		Weights: map[Category]float64{
+			CategoryCore:         1,
+			CategoryDaily:        0.6,
+			CategoryConversation: 0.4,
+		},
+		HalfLife: 30 * 24 * time.Hour,
+	}
+}
+
+// WithRanking replaces the default ranking. Weights left out keep their
+// defaults.
+func WithRanking(r Ranking) Option {
+	return func(m *SQLiteMemory) {
+		weights := make(map[Category]float64)
+		for c, w := range m.ranking.Weights {
+			weights[c] = w
+		}
+		for c, w := range r.Weights {
+			weights[c] = w
+		}
+		r.Weights = weights
+		m.ranking = r
+	}
+}
+
+func (r Ranking) weight(c Category) float64 {
+	if w, ok := r.Weights[c]; ok {
+		return w
+	}
+	return 1
+}
+
+// rank scores entries, whose Score holds their relevance, and returns the
+// best limit of them within the quotas.
+func (r Ranking) rank(entries []*Entry, limit int, now time.Time) []*Entry {
+	for _, e := range entries {
+		e.Score *= r.weight(e.Category)
+		if r.HalfLife > 0 && e.Category != CategoryCore {
+			age := now.Sub(e.UpdatedAt)
+			if age > 0 {
+				e.Score *= math.Pow(0.5, float64(age)/float64(r.HalfLife))
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Score > entries[j].Score })
+
+	taken := make(map[Category]int)
+	var out []*Entry
+	for _, e := range entries {
+		if len(out) == limit {
+			break
+		}
+		if q, ok := r.Quota[e.Category]; ok && q > 0 && taken[e.Category] >= q {
+			continue
+		}
+		taken[e.Category]++
+		out = append(out, e)
+	}
+	return out
+}
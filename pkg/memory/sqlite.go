@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 	"database/sql"
+	"embed"
 	"errors"
 	"fmt"
 	"os"
@@ -10,18 +11,51 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	_ "modernc.org/sqlite"
+
+	"github.com/nene-agent/nene/pkg/migrate"
+	"github.com/nene-agent/nene/pkg/seal"
 )
 
+//go:embed migrations/*.sql
+var migrations embed.FS
+
 type SQLiteMemory struct {
 	db   *sql.DB
 	path string
+	box  *seal.Box
 	mu   sync.RWMutex
+
+	tokenizer string
+	ranking   Ranking
+}
+
+// Full-text tokenizers. unicode61 splits on spaces and punctuation, which
+// suits most languages; trigram indexes every three characters, so it also
+// finds words in Japanese and Chinese text, which has no spaces, at the
+// cost of a larger index.
+const (
+	TokenizerUnicode = "unicode61"
+	TokenizerTrigram = "trigram"
+)
+
+type Option func(*SQLiteMemory)
+
+// WithTokenizer chooses the full-text tokenizer. The index is rebuilt when
+// it was created with another one.
+func WithTokenizer(name string) Option {
+	return func(m *SQLiteMemory) {
+		if name != "" {
+			m.tokenizer = name
+		}
+	}
 }
 
-func NewSQLiteMemory(dataDir string) (*SQLiteMemory, error) {
+func NewSQLiteMemory(dataDir string, opts ...Option) (*SQLiteMemory, error) {
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return nil, fmt.Errorf("create data directory: %w", err)
 	}
@@ -38,57 +72,128 @@ func NewSQLiteMemory(dataDir string) (*SQLiteMemory, error) {
 	}
 
 	mem := &SQLiteMemory{
-		db:   db,
-		path: dbPath,
+		db:        db,
+		path:      dbPath,
+		tokenizer: TokenizerUnicode,
+		ranking:   DefaultRanking(),
+	}
+	for _, opt := range opts {
+		opt(mem)
 	}
 
 	if err := mem.initSchema(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("init schema: %w", err)
 	}
+	if err := mem.useTokenizer(context.Background()); err != nil {
+		// The old index still works, only worse for the content it was
+		// meant to help with.
+		fmt.Printf("Memory: keeping the %s full-text index: %v\n", mem.tokenizer, err)
+		mem.tokenizer = ftsTokenizer(mem.ftsSchema(context.Background()))
+	}
 
 	return mem, nil
 }
 
-func (m *SQLiteMemory) initSchema() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS memories (
-		id          TEXT PRIMARY KEY,
-		key         TEXT NOT NULL UNIQUE,
-		content     TEXT NOT NULL,
-		category    TEXT NOT NULL DEFAULT 'core',
-		session_id  TEXT,
-		created_at  TEXT NOT NULL,
-		updated_at  TEXT NOT NULL
-	);
+func (m *SQLiteMemory) ftsSchema(ctx context.Context) string {
+	var ddl string
+	m.db.QueryRowContext(ctx, `SELECT sql FROM sqlite_master WHERE name = 'memories_fts'`).Scan(&ddl)
+	return ddl
+}
 
-	CREATE INDEX IF NOT EXISTS idx_memories_category ON memories(category);
-	CREATE INDEX IF NOT EXISTS idx_memories_key ON memories(key);
-	CREATE INDEX IF NOT EXISTS idx_memories_session ON memories(session_id);
+// ftsTokenizer returns the tokenizer an FTS5 table was created with.
+func ftsTokenizer(ddl string) string {
+	_, opt, ok := strings.Cut(ddl, "tokenize")
+	if !ok {
+		return TokenizerUnicode
+	}
+	opt = strings.TrimLeft(opt, " ='\"")
+	if end := strings.IndexAny(opt, " '\",)"); end >= 0 {
+		opt = opt[:end]
+	}
+	return opt
+}
 
-	CREATE VIRTUAL TABLE IF NOT EXISTS memories_fts USING fts5(
-		key, content, content=memories, content_rowid=rowid
+// useTokenizer recreates the full-text index with m.tokenizer when it was
+// created with another one, and re-indexes every entry.
+func (m *SQLiteMemory) useTokenizer(ctx context.Context) error {
+	if ftsTokenizer(m.ftsSchema(ctx)) == m.tokenizer {
+		return nil
+	}
+	switch m.tokenizer {
+	case TokenizerUnicode, TokenizerTrigram:
+	default:
+		return fmt.Errorf("unknown tokenizer %q", m.tokenizer)
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+	DROP TABLE IF EXISTS memories_fts;
+	CREATE VIRTUAL TABLE memories_fts USING fts5(
+		key, content, content=memories, content_rowid=rowid, tokenize='%s'
 	);
+	INSERT INTO memories_fts(memories_fts) VALUES ('rebuild');
+	`, m.tokenizer))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-	CREATE TRIGGER IF NOT EXISTS memories_ai AFTER INSERT ON memories BEGIN
-		INSERT INTO memories_fts(rowid, key, content)
-		VALUES (new.rowid, new.key, new.content);
-	END;
-
-	CREATE TRIGGER IF NOT EXISTS memories_ad AFTER DELETE ON memories BEGIN
-		INSERT INTO memories_fts(memories_fts, rowid, key, content)
-		VALUES ('delete', old.rowid, old.key, old.content);
-	END;
-
-	CREATE TRIGGER IF NOT EXISTS memories_au AFTER UPDATE ON memories BEGIN
-		INSERT INTO memories_fts(memories_fts, rowid, key, content)
-		VALUES ('delete', old.rowid, old.key, old.content);
-		INSERT INTO memories_fts(rowid, key, content)
-		VALUES (new.rowid, new.key, new.content);
-	END;
-	`
+// SetCipher encrypts the content of entries stored from now on. Keys and
+// categories stay readable, since entries are looked up by them. While a
+// cipher is set, Recall matches decrypted entries in memory instead of
+// using the full-text index, which would only hold ciphertext.
+func (m *SQLiteMemory) SetCipher(b *seal.Box) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.box = b
+}
 
-	_, err := m.db.Exec(schema)
+// Reseal encrypts the entries stored before a cipher was set and returns
+// how many it changed.
+func (m *SQLiteMemory) Reseal(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.box == nil {
+		return 0, errors.New("no cipher set")
+	}
+	rows, err := m.db.QueryContext(ctx, "SELECT id, content FROM memories")
+	if err != nil {
+		return 0, fmt.Errorf("reseal memories: %w", err)
+	}
+	plain := make(map[string]string)
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("reseal memories: %w", err)
+		}
+		if !seal.Sealed([]byte(content)) {
+			plain[id] = content
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("reseal memories: %w", err)
+	}
+	for id, content := range plain {
+		if _, err := m.db.ExecContext(ctx, "UPDATE memories SET content = ? WHERE id = ?", m.box.SealString(content), id); err != nil {
+			return 0, fmt.Errorf("reseal memories: %w", err)
+		}
+	}
+	return len(plain), nil
+}
+
+func (m *SQLiteMemory) initSchema() error {
+	ms, err := migrate.Load(migrations, "migrations")
+	if err != nil {
+		return err
+	}
+	_, err = migrate.Apply(context.Background(), m.db, ms)
 	return err
 }
 
@@ -114,7 +219,7 @@ func (m *SQLiteMemory) Store(ctx context.Context, req *StoreRequest) (*Entry, er
 	`
 
 	_, err := m.db.ExecContext(ctx, stmt,
-		id, req.Key, req.Content, string(req.Category), req.SessionID,
+		id, req.Key, m.box.SealString(req.Content), string(req.Category), req.SessionID,
 		now.Format(time.RFC3339), now.Format(time.RFC3339),
 	)
 	if err != nil {
@@ -145,10 +250,30 @@ func (m *SQLiteMemory) Recall(ctx context.Context, req *RecallRequest) ([]*Entry
 		return nil, nil
 	}
 
-	ftsQuery := buildFTSQuery(query)
+	var entries []*Entry
+	var err error
+	if m.box != nil {
+		entries, err = m.recallSealed(ctx, req)
+	} else {
+		entries, err = m.recallFTS(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.ranking.rank(entries, req.Limit, time.Now()), nil
+}
 
+// recallFTS returns the best full-text matches, scored by relevance, or the
+// fallback's when the index cannot help.
+func (m *SQLiteMemory) recallFTS(ctx context.Context, req *RecallRequest) ([]*Entry, error) {
+	ftsQuery := buildFTSQuery(req.Query, m.tokenizer == TokenizerTrigram)
+	if ftsQuery == "" {
+		return m.recallFallback(ctx, req)
+	}
+
+	// bm25 is lower for better matches.
 	sql := `
-	SELECT m.id, m.key, m.content, m.category, m.session_id, m.created_at, m.updated_at
+	SELECT m.id, m.key, m.content, m.category, m.session_id, m.created_at, m.updated_at, -bm25(memories_fts)
 	FROM memories m
 	JOIN memories_fts f ON m.rowid = f.rowid
 	WHERE memories_fts MATCH ?
@@ -156,21 +281,22 @@ func (m *SQLiteMemory) Recall(ctx context.Context, req *RecallRequest) ([]*Entry
 	LIMIT ?
 	`
 
-	rows, err := m.db.QueryContext(ctx, sql, ftsQuery, req.Limit)
+	rows, err := m.db.QueryContext(ctx, sql, ftsQuery, req.Limit*recallPool)
 	if err != nil {
-		if strings.Contains(err.Error(), "no such table") || strings.Contains(err.Error(), "fts") {
-			return m.recallFallback(ctx, req)
-		}
-		return nil, fmt.Errorf("recall memory: %w", err)
+		// A missing or broken index should not make memory unusable.
+		fmt.Printf("Memory: full-text search failed, searching without it: %v\n", err)
+		return m.recallFallback(ctx, req)
 	}
 	defer rows.Close()
 
 	var entries []*Entry
 	for rows.Next() {
-		e, err := scanEntry(rows)
+		var score float64
+		e, err := m.scanEntry(rows, &score)
 		if err != nil {
 			return nil, err
 		}
+		e.Score = score
 		entries = append(entries, e)
 	}
 
@@ -181,6 +307,8 @@ func (m *SQLiteMemory) Recall(ctx context.Context, req *RecallRequest) ([]*Entry
 	return entries, nil
 }
 
+// recallFallback finds entries containing any of the query's words, scored
+// by how many they contain.
 func (m *SQLiteMemory) recallFallback(ctx context.Context, req *RecallRequest) ([]*Entry, error) {
 	keywords := strings.Fields(req.Query)
 	if len(keywords) == 0 {
@@ -190,8 +318,9 @@ func (m *SQLiteMemory) recallFallback(ctx context.Context, req *RecallRequest) (
 	var conditions []string
 	var args []interface{}
 	for _, kw := range keywords {
-		conditions = append(conditions, "(content LIKE ? OR key LIKE ?)")
-		args = append(args, "%"+kw+"%", "%"+kw+"%")
+		pattern := "%" + likeEscaper.Replace(kw) + "%"
+		conditions = append(conditions, `(content LIKE ? ESCAPE '\' OR key LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
 	}
 
 	sql := fmt.Sprintf(`
@@ -202,7 +331,7 @@ func (m *SQLiteMemory) recallFallback(ctx context.Context, req *RecallRequest) (
 		LIMIT ?
 	`, strings.Join(conditions, " OR "))
 
-	args = append(args, req.Limit)
+	args = append(args, req.Limit*recallPool)
 
 	rows, err := m.db.QueryContext(ctx, sql, args...)
 	if err != nil {
@@ -212,16 +341,59 @@ func (m *SQLiteMemory) recallFallback(ctx context.Context, req *RecallRequest) (
 
 	var entries []*Entry
 	for rows.Next() {
-		e, err := scanEntry(rows)
+		e, err := m.scanEntry(rows)
 		if err != nil {
 			return nil, err
 		}
+		e.Score = matchCount(e, keywords)
 		entries = append(entries, e)
 	}
 
 	return entries, nil
 }
 
+// matchCount counts the words found in the entry's key or content.
+func matchCount(e *Entry, words []string) float64 {
+	text := strings.ToLower(e.Key + " " + e.Content)
+	var n float64
+	for _, w := range words {
+		if strings.Contains(text, strings.ToLower(w)) {
+			n++
+		}
+	}
+	return n
+}
+
+// recallSealed scores entries by how many of the query's words their key
+// and decrypted content contain.
+func (m *SQLiteMemory) recallSealed(ctx context.Context, req *RecallRequest) ([]*Entry, error) {
+	rows, err := m.db.QueryContext(ctx, `
+	SELECT id, key, content, category, session_id, created_at, updated_at
+	FROM memories
+	ORDER BY updated_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("recall memory: %w", err)
+	}
+	defer rows.Close()
+
+	words := strings.Fields(req.Query)
+	var entries []*Entry
+	for rows.Next() {
+		e, err := m.scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		if e.Score = matchCount(e, words); e.Score > 0 {
+			entries = append(entries, e)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("recall memory: %w", err)
+	}
+	return entries, nil
+}
+
 func (m *SQLiteMemory) Get(ctx context.Context, key string) (*Entry, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -233,7 +405,7 @@ func (m *SQLiteMemory) Get(ctx context.Context, key string) (*Entry, error) {
 	`
 
 	row := m.db.QueryRowContext(ctx, query, key)
-	e, err := scanEntryRow(row)
+	e, err := m.scanEntryRow(row)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -252,27 +424,29 @@ func (m *SQLiteMemory) List(ctx context.Context, req *ListRequest) ([]*Entry, er
 		req.Limit = 100
 	}
 
-	var query string
+	var conditions []string
 	var args []interface{}
-
 	if req.Category != "" {
-		query = `
-		SELECT id, key, content, category, session_id, created_at, updated_at
-		FROM memories
-		WHERE category = ?
-		ORDER BY updated_at DESC
-		LIMIT ?
-		`
-		args = []interface{}{string(req.Category), req.Limit}
-	} else {
-		query = `
+		conditions = append(conditions, "category = ?")
+		args = append(args, string(req.Category))
+	}
+	if req.Prefix != "" {
+		conditions = append(conditions, `key LIKE ? ESCAPE '\'`)
+		args = append(args, likeEscaper.Replace(req.Prefix)+"%")
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, req.Limit, req.Offset)
+
+	query := fmt.Sprintf(`
 		SELECT id, key, content, category, session_id, created_at, updated_at
 		FROM memories
-		ORDER BY updated_at DESC
-		LIMIT ?
-		`
-		args = []interface{}{req.Limit}
-	}
+		%s
+		ORDER BY updated_at DESC, key
+		LIMIT ? OFFSET ?
+	`, where)
 
 	rows, err := m.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -282,7 +456,7 @@ func (m *SQLiteMemory) List(ctx context.Context, req *ListRequest) ([]*Entry, er
 
 	var entries []*Entry
 	for rows.Next() {
-		e, err := scanEntry(rows)
+		e, err := m.scanEntry(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -309,6 +483,75 @@ func (m *SQLiteMemory) Forget(ctx context.Context, key string) (bool, error) {
 	return affected > 0, nil
 }
 
+// Rename moves the entry at oldKey to newKey, reporting whether there was
+// one. It fails when newKey is taken.
+func (m *SQLiteMemory) Rename(ctx context.Context, oldKey, newKey string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var taken int
+	if err := m.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM memories WHERE key = ?", newKey).Scan(&taken); err != nil {
+		return false, fmt.Errorf("rename memory: %w", err)
+	}
+	if taken > 0 && newKey != oldKey {
+		return false, fmt.Errorf("memory %q already exists", newKey)
+	}
+
+	result, err := m.db.ExecContext(ctx,
+		"UPDATE memories SET key = ?, updated_at = ? WHERE key = ?",
+		newKey, time.Now().UTC().Format(time.RFC3339), oldKey)
+	if err != nil {
+		return false, fmt.Errorf("rename memory: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// SetCategory moves the entry at key to category c, reporting whether there
+// was one.
+func (m *SQLiteMemory) SetCategory(ctx context.Context, key string, c Category) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result, err := m.db.ExecContext(ctx,
+		"UPDATE memories SET category = ?, updated_at = ? WHERE key = ?",
+		string(c), time.Now().UTC().Format(time.RFC3339), key)
+	if err != nil {
+		return false, fmt.Errorf("set memory category: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ForgetPrefix deletes every entry whose key starts with prefix and returns
+// how many there were. An empty prefix is refused rather than read as
+// "everything".
+func (m *SQLiteMemory) ForgetPrefix(ctx context.Context, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, errors.New("prefix is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result, err := m.db.ExecContext(ctx,
+		`DELETE FROM memories WHERE key LIKE ? ESCAPE '\'`, likeEscaper.Replace(prefix)+"%")
+	if err != nil {
+		return 0, fmt.Errorf("forget memories: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
 func (m *SQLiteMemory) Count(ctx context.Context) (int, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -326,25 +569,43 @@ func (m *SQLiteMemory) Close() error {
 	return m.db.Close()
 }
 
-func buildFTSQuery(query string) string {
-	words := strings.Fields(query)
+// likeEscaper makes LIKE match %, _, and \ literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// buildFTSQuery turns free text into an FTS5 query matching any of its
+// words. Each word is quoted with its quotes doubled, so characters FTS5
+// reads as syntax are searched for literally. The trigram tokenizer cannot
+// match fewer than three characters, so shorter words are left out there;
+// an empty result means the index cannot help.
+func buildFTSQuery(query string, trigram bool) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	})
 	var parts []string
 	for _, w := range words {
-		parts = append(parts, fmt.Sprintf("\"%s\"", w))
+		if trigram && utf8.RuneCountInString(w) < 3 {
+			continue
+		}
+		parts = append(parts, `"`+strings.ReplaceAll(w, `"`, `""`)+`"`)
 	}
 	return strings.Join(parts, " OR ")
 }
 
-func scanEntry(rows *sql.Rows) (*Entry, error) {
+// scanEntry scans an entry's columns and then any extra ones into extra.
+func (m *SQLiteMemory) scanEntry(rows *sql.Rows, extra ...interface{}) (*Entry, error) {
 	var e Entry
 	var createdAt, updatedAt string
-	err := rows.Scan(
+	dest := []interface{}{
 		&e.ID, &e.Key, &e.Content, &e.Category, &e.SessionID,
 		&createdAt, &updatedAt,
-	)
+	}
+	err := rows.Scan(append(dest, extra...)...)
 	if err != nil {
 		return nil, fmt.Errorf("scan entry: %w", err)
 	}
+	if e.Content, err = m.box.OpenString(e.Content); err != nil {
+		return nil, fmt.Errorf("memory %s: %w", e.Key, err)
+	}
 
 	e.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
 	e.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
@@ -352,7 +613,7 @@ func scanEntry(rows *sql.Rows) (*Entry, error) {
 	return &e, nil
 }
 
-func scanEntryRow(row *sql.Row) (*Entry, error) {
+func (m *SQLiteMemory) scanEntryRow(row *sql.Row) (*Entry, error) {
 	var e Entry
 	var createdAt, updatedAt string
 	err := row.Scan(
@@ -362,6 +623,9 @@ func scanEntryRow(row *sql.Row) (*Entry, error) {
 	if err != nil {
 		return nil, err
 	}
+	if e.Content, err = m.box.OpenString(e.Content); err != nil {
+		return nil, fmt.Errorf("memory %s: %w", e.Key, err)
+	}
 
 	e.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
 	e.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
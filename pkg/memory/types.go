@@ -37,7 +37,11 @@ type RecallRequest struct {
 type ListRequest struct {
 	Category  Category `json:"category,omitempty"`
 	SessionID string   `json:"session_id,omitempty"`
-	Limit     int      `json:"limit,omitempty"`
+	// Prefix keeps only the entries whose key starts with it.
+	Prefix string `json:"prefix,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+	// Offset skips that many entries, for paging.
+	Offset int `json:"offset,omitempty"`
 }
 
 func ParseCategory(s string) Category {
@@ -0,0 +1,135 @@
+// Package migrate brings a SQLite database's schema up to date with
+// numbered migrations, recording the ones applied in a schema_migrations
+// table.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration is one schema change. SQL runs as a script; Func, when set, runs
+// after it in the same transaction, for changes SQL alone cannot make.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+	Func    func(ctx context.Context, tx *sql.Tx) error
+}
+
+// Load reads the migrations in dir of fsys, usually an embed.FS. Files are
+// named "<version>_<name>.sql", such as "0002_rebuild_fts.sql".
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		num, rest, _ := strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+		version, err := strconv.Atoi(num)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: name must start with a positive version number", name)
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: rest, SQL: string(data)})
+	}
+	return migrations, nil
+}
+
+// Version returns the newest migration applied to db, or 0.
+func Version(ctx context.Context, db *sql.DB) (int, error) {
+	if err := ensureTable(ctx, db); err != nil {
+		return 0, err
+	}
+	var v sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&v); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return int(v.Int64), nil
+}
+
+func ensureTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// Apply runs the migrations newer than db's version, in order, each in a
+// transaction of its own, and returns how many it ran. A database with a
+// version newer than any migration was written by a newer nene, and is
+// refused rather than used with a schema this one does not know.
+func Apply(ctx context.Context, db *sql.DB, migrations []Migration) (int, error) {
+	migrations = append([]Migration(nil), migrations...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return 0, fmt.Errorf("two migrations have version %d", migrations[i].Version)
+		}
+	}
+
+	current, err := Version(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	if n := len(migrations); n > 0 && current > migrations[n-1].Version {
+		return 0, fmt.Errorf("database schema version %d is newer than this build supports (%d)", current, migrations[n-1].Version)
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := apply(ctx, db, m); err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+func apply(ctx context.Context, db *sql.DB, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if strings.TrimSpace(m.SQL) != "" {
+		if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+			return err
+		}
+	}
+	if m.Func != nil {
+		if err := m.Func(ctx, tx); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -9,14 +9,32 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/nene-agent/nene/pkg/model"
 )
 
+const (
+	defaultMaxTokens  = 4096
+	minThinkingBudget = 1024
+)
+
 type Config struct {
 	APIKey  string
 	BaseURL string
 	Model   string
+	Timeout time.Duration
+
+	MaxTokens   int
+	Temperature *float64
+	TopP        *float64
+	Stop        []string
+
+	// ThinkingBudget enables extended thinking with the given token budget
+	// when greater than zero. The thinking is streamed as reasoning but not
+	// kept: later turns do not send it back, so it is not carried across
+	// turns or tool calls.
+	ThinkingBudget int
 }
 
 type Provider struct {
@@ -30,17 +48,32 @@ func NewProvider(config Config) *Provider {
 	}
 	return &Provider{
 		config: config,
-		client: &http.Client{},
+		client: model.NewHTTPClient(config.Timeout),
 	}
 }
 
 type anthropicRequest struct {
-	Model     string          `json:"model"`
-	MaxTokens int             `json:"max_tokens"`
-	Messages  []anthropicMsg  `json:"messages"`
-	System    string          `json:"system,omitempty"`
-	Tools     []anthropicTool `json:"tools,omitempty"`
-	Stream    bool            `json:"stream"`
+	Model         string             `json:"model"`
+	MaxTokens     int                `json:"max_tokens"`
+	Messages      []anthropicMsg     `json:"messages"`
+	System        string             `json:"system,omitempty"`
+	Tools         []anthropicTool    `json:"tools,omitempty"`
+	Stream        bool               `json:"stream"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	TopP          *float64           `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Thinking      *anthropicThinking `json:"thinking,omitempty"`
+	ToolChoice    *anthropicChoice   `json:"tool_choice,omitempty"`
+}
+
+type anthropicChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type anthropicThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
 }
 
 type anthropicMsg struct {
@@ -49,8 +82,11 @@ type anthropicMsg struct {
 }
 
 type anthropicContent struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
+	Type  string          `json:"type"`
+	Text  string          `json:"text,omitempty"`
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
 }
 
 type anthropicTool struct {
@@ -79,21 +115,103 @@ type anthropicStreamEvent struct {
 	Delta        *anthropicDelta    `json:"delta,omitempty"`
 	ContentBlock *anthropicContent  `json:"content_block,omitempty"`
 	Message      *anthropicResponse `json:"message,omitempty"`
+	Usage        *struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage,omitempty"`
 }
 
 type anthropicDelta struct {
 	Type        string `json:"type"`
 	Text        string `json:"text,omitempty"`
+	Thinking    string `json:"thinking,omitempty"`
 	StopReason  string `json:"stop_reason,omitempty"`
 	PartialJSON string `json:"partial_json,omitempty"`
 }
 
+// prepare fills unset request fields from the provider config and the model
+// catalog, then converts the request and applies the thinking settings.
+func (p *Provider) prepare(req *model.Request) *anthropicRequest {
+	if req.Model == "" {
+		req.Model = p.config.Model
+	}
+	req.ApplyOptions(model.RequestOptions{
+		Temperature: p.config.Temperature,
+		TopP:        p.config.TopP,
+		MaxTokens:   p.config.MaxTokens,
+		Stop:        p.config.Stop,
+	})
+	info, known := model.DefaultModelDatabase().GetModel("anthropic", req.Model)
+	if known {
+		req.ApplyOptions(info.RequestOptions())
+		req.ApplyCapabilities(info)
+	}
+
+	ar := convertToAnthropicRequest(req)
+	if ar.MaxTokens == 0 {
+		ar.MaxTokens = defaultMaxTokens
+		if known && info.Limit.Output > 0 {
+			ar.MaxTokens = info.Limit.Output
+		}
+	}
+
+	// A forced tool call, which response formats use, cannot be combined
+	// with extended thinking.
+	if p.config.ThinkingBudget > 0 && (!known || info.Capabilities.Reasoning) && req.ResponseFormat == nil {
+		budget := max(p.config.ThinkingBudget, minThinkingBudget)
+		if ar.MaxTokens <= budget {
+			ar.MaxTokens = budget + defaultMaxTokens
+		}
+		ar.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: budget}
+		// Extended thinking rejects custom sampling parameters.
+		ar.Temperature = nil
+		ar.TopP = nil
+	}
+	if req.ResponseFormat != nil {
+		applyResponseFormat(ar, req.ResponseFormat)
+	}
+
+	return ar
+}
+
+// formatToolName is the name of the tool a response format is turned into
+// when the format does not name its schema.
+const formatToolName = "json_output"
+
+// applyResponseFormat gets JSON output the way the Messages API allows: the
+// schema becomes the input schema of a tool the model is made to call, whose
+// input is then returned as the response text.
+func applyResponseFormat(ar *anthropicRequest, f *model.ResponseFormat) {
+	tool := anthropicTool{
+		Name:        formatTool(f),
+		Description: "Respond with the requested JSON by calling this tool.",
+		InputSchema: f.Schema(),
+	}
+	if f.JSONSchema != nil && f.JSONSchema.Description != "" {
+		tool.Description = f.JSONSchema.Description
+	}
+	ar.Tools = append(ar.Tools, tool)
+	ar.ToolChoice = &anthropicChoice{Type: "tool", Name: tool.Name}
+}
+
+func formatTool(f *model.ResponseFormat) string {
+	if f == nil {
+		return ""
+	}
+	if f.JSONSchema != nil && f.JSONSchema.Name != "" {
+		return f.JSONSchema.Name
+	}
+	return formatToolName
+}
+
 func convertToAnthropicRequest(req *model.Request) *anthropicRequest {
 	ar := &anthropicRequest{
-		Model:     req.Model,
-		MaxTokens: 4096,
-		Messages:  make([]anthropicMsg, 0),
-		Stream:    req.Stream,
+		Model:         req.Model,
+		MaxTokens:     req.MaxTokens,
+		Messages:      make([]anthropicMsg, 0),
+		Stream:        req.Stream,
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		StopSequences: req.Stop,
 	}
 
 	for _, msg := range req.Messages {
@@ -133,7 +251,9 @@ func convertToAnthropicRequest(req *model.Request) *anthropicRequest {
 }
 
 func (p *Provider) Send(ctx context.Context, req *model.Request) (*model.Response, error) {
-	ar := convertToAnthropicRequest(req)
+	ctx, cancel := model.SendContext(ctx, p.config.Timeout)
+	defer cancel()
+	ar := p.prepare(req)
 	ar.Stream = false
 
 	body, err := json.Marshal(ar)
@@ -157,8 +277,7 @@ func (p *Provider) Send(ctx context.Context, req *model.Request) (*model.Respons
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, model.NewAPIError(resp)
 	}
 
 	var aResp anthropicResponse
@@ -166,10 +285,12 @@ func (p *Provider) Send(ctx context.Context, req *model.Request) (*model.Respons
 		return nil, fmt.Errorf("decode response: %w", err)
 	}
 
-	return convertToModelResponse(&aResp), nil
+	return convertToModelResponse(&aResp, formatTool(req.ResponseFormat)), nil
 }
 
-func convertToModelResponse(aResp *anthropicResponse) *model.Response {
+// convertToModelResponse converts a response. A call of the response format
+// tool named formatTool, if any, becomes the response text.
+func convertToModelResponse(aResp *anthropicResponse, formatTool string) *model.Response {
 	resp := &model.Response{
 		ID:      aResp.ID,
 		Model:   aResp.Model,
@@ -182,14 +303,19 @@ func convertToModelResponse(aResp *anthropicResponse) *model.Response {
 	}
 
 	var content string
+	formatted := false
 	for _, c := range aResp.Content {
-		if c.Type == "text" {
+		switch {
+		case c.Type == "text":
 			content += c.Text
+		case c.Type == "tool_use" && formatTool != "" && c.Name == formatTool:
+			content = string(c.Input)
+			formatted = true
 		}
 	}
 
 	finishReason := "stop"
-	if aResp.StopReason == "tool_use" {
+	if aResp.StopReason == "tool_use" && !formatted {
 		finishReason = "tool_calls"
 	}
 
@@ -205,7 +331,7 @@ func convertToModelResponse(aResp *anthropicResponse) *model.Response {
 }
 
 func (p *Provider) SendStream(ctx context.Context, req *model.Request) (<-chan *model.ResponseEvent, error) {
-	ar := convertToAnthropicRequest(req)
+	ar := p.prepare(req)
 	ar.Stream = true
 
 	body, err := json.Marshal(ar)
@@ -229,21 +355,24 @@ func (p *Provider) SendStream(ctx context.Context, req *model.Request) (<-chan *
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, model.NewAPIError(resp)
 	}
 
 	ch := make(chan *model.ResponseEvent, 100)
-	go p.readStream(resp.Body, ch)
+	go p.readStream(resp.Body, ch, formatTool(req.ResponseFormat))
 
 	return ch, nil
 }
 
-func (p *Provider) readStream(body io.ReadCloser, ch chan<- *model.ResponseEvent) {
+// readStream forwards stream events. The input of the response format tool
+// named formatTool, if any, is streamed as text.
+func (p *Provider) readStream(body io.ReadCloser, ch chan<- *model.ResponseEvent, formatTool string) {
 	defer body.Close()
 	defer close(ch)
 
+	inputTokens := 0
+	formatBlock := -1
+
 	reader := bufio.NewReader(body)
 	for {
 		line, err := reader.ReadString('\n')
@@ -270,10 +399,36 @@ func (p *Provider) readStream(body io.ReadCloser, ch chan<- *model.ResponseEvent
 		}
 
 		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				inputTokens = event.Message.Usage.InputTokens
+			}
+		case "content_block_start":
+			if cb := event.ContentBlock; cb != nil && cb.Type == "tool_use" && formatTool != "" && cb.Name == formatTool {
+				formatBlock = event.Index
+			}
 		case "content_block_delta":
-			if event.Delta != nil && event.Delta.Text != "" {
-				ch <- &model.ResponseEvent{
-					Delta: event.Delta.Text,
+			if event.Delta == nil {
+				continue
+			}
+			switch event.Delta.Type {
+			case "input_json_delta":
+				if event.Index == formatBlock && event.Delta.PartialJSON != "" {
+					ch <- &model.ResponseEvent{
+						Delta: event.Delta.PartialJSON,
+					}
+				}
+			case "thinking_delta":
+				if event.Delta.Thinking != "" {
+					ch <- &model.ResponseEvent{
+						Reasoning: event.Delta.Thinking,
+					}
+				}
+			default:
+				if event.Delta.Text != "" {
+					ch <- &model.ResponseEvent{
+						Delta: event.Delta.Text,
+					}
 				}
 			}
 		case "content_block_stop":
@@ -282,7 +437,14 @@ func (p *Provider) readStream(body io.ReadCloser, ch chan<- *model.ResponseEvent
 			ch <- &model.ResponseEvent{FinishReason: model.FinishReasonStop}
 			return
 		case "message_delta":
-			if event.Delta != nil && event.Delta.StopReason == "tool_use" {
+			if event.Usage != nil {
+				ch <- &model.ResponseEvent{Usage: &model.Usage{
+					PromptTokens:     inputTokens,
+					CompletionTokens: event.Usage.OutputTokens,
+					TotalTokens:      inputTokens + event.Usage.OutputTokens,
+				}}
+			}
+			if event.Delta != nil && event.Delta.StopReason == "tool_use" && formatBlock < 0 {
 				ch <- &model.ResponseEvent{FinishReason: model.FinishReasonToolCalls}
 			}
 		}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/nene-agent/nene/pkg/model"
 )
@@ -18,6 +19,13 @@ type Config struct {
 	BaseURL    string
 	APIVersion string
 	Deployment string
+	Timeout    time.Duration
+
+	MaxTokens       int
+	Temperature     *float64
+	TopP            *float64
+	Stop            []string
+	ReasoningEffort string
 }
 
 type Provider struct {
@@ -31,10 +39,21 @@ func NewProvider(config Config) *Provider {
 	}
 	return &Provider{
 		config: config,
-		client: &http.Client{},
+		client: model.NewHTTPClient(config.Timeout),
 	}
 }
 
+// prepare fills unset request fields from the provider config.
+func (p *Provider) prepare(req *model.Request) {
+	req.ApplyOptions(model.RequestOptions{
+		Temperature:     p.config.Temperature,
+		TopP:            p.config.TopP,
+		MaxTokens:       p.config.MaxTokens,
+		Stop:            p.config.Stop,
+		ReasoningEffort: p.config.ReasoningEffort,
+	})
+}
+
 func (p *Provider) buildURL() string {
 	baseURL := strings.TrimSuffix(p.config.BaseURL, "/")
 	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
@@ -42,6 +61,9 @@ func (p *Provider) buildURL() string {
 }
 
 func (p *Provider) Send(ctx context.Context, req *model.Request) (*model.Response, error) {
+	ctx, cancel := model.SendContext(ctx, p.config.Timeout)
+	defer cancel()
+	p.prepare(req)
 	req.Stream = false
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -63,8 +85,7 @@ func (p *Provider) Send(ctx context.Context, req *model.Request) (*model.Respons
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, model.NewAPIError(resp)
 	}
 
 	var response model.Response
@@ -76,6 +97,7 @@ func (p *Provider) Send(ctx context.Context, req *model.Request) (*model.Respons
 }
 
 func (p *Provider) SendStream(ctx context.Context, req *model.Request) (<-chan *model.ResponseEvent, error) {
+	p.prepare(req)
 	req.Stream = true
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -97,9 +119,7 @@ func (p *Provider) SendStream(ctx context.Context, req *model.Request) (<-chan *
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, model.NewAPIError(resp)
 	}
 
 	ch := make(chan *model.ResponseEvent, 100)
@@ -138,6 +158,10 @@ func (p *Provider) readStream(body io.ReadCloser, ch chan<- *model.ResponseEvent
 			continue
 		}
 
+		if chunk.Usage != nil {
+			ch <- &model.ResponseEvent{Usage: chunk.Usage}
+		}
+
 		for _, choice := range chunk.Choices {
 			if choice.Delta.Content != "" {
 				ch <- &model.ResponseEvent{
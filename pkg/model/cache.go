@@ -0,0 +1,181 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const defaultCacheEntries = 256
+
+// ResponseCache memoizes responses for identical requests within a TTL. It is
+// meant for idempotent fan-outs such as subagents repeating the same prompt.
+type ResponseCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	response *Response
+	events   []ResponseEvent
+	expires  time.Time
+}
+
+func NewResponseCache(ttl time.Duration, maxEntries int) *ResponseCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+	return &ResponseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*cacheEntry),
+	}
+}
+
+// CacheMiddleware returns a middleware backed by a new ResponseCache.
+func CacheMiddleware(ttl time.Duration) Middleware {
+	return NewResponseCache(ttl, 0).Middleware()
+}
+
+func (c *ResponseCache) Middleware() Middleware {
+	return func(next Provider) Provider {
+		return ProviderFuncs{
+			SendFunc: func(ctx context.Context, req *Request) (*Response, error) {
+				key := cacheKey("send", req)
+				if e, ok := c.get(key); ok {
+					return copyResponse(e.response), nil
+				}
+				resp, err := next.Send(ctx, req)
+				if err != nil {
+					return nil, err
+				}
+				c.put(key, &cacheEntry{response: copyResponse(resp)})
+				return resp, nil
+			},
+			SendStreamFunc: func(ctx context.Context, req *Request) (<-chan *ResponseEvent, error) {
+				key := cacheKey("stream", req)
+				if e, ok := c.get(key); ok {
+					return replayEvents(e.events), nil
+				}
+				in, err := next.SendStream(ctx, req)
+				if err != nil {
+					return nil, err
+				}
+				out := make(chan *ResponseEvent, cap(in))
+				go func() {
+					defer close(out)
+					var recorded []ResponseEvent
+					finished := false
+					for event := range in {
+						recorded = append(recorded, *event)
+						if event.FinishReason != "" {
+							finished = true
+						}
+						select {
+						case out <- event:
+						case <-ctx.Done():
+							go discard(in)
+							return
+						}
+					}
+					// Only complete streams are worth replaying.
+					if finished && ctx.Err() == nil {
+						c.put(key, &cacheEntry{events: recorded})
+					}
+				}()
+				return out, nil
+			},
+		}
+	}
+}
+
+func (c *ResponseCache) get(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e, true
+}
+
+func (c *ResponseCache) put(key string, e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	e.expires = now.Add(c.ttl)
+
+	if len(c.entries) >= c.maxEntries {
+		var oldestKey string
+		var oldest time.Time
+		for k, v := range c.entries {
+			if now.After(v.expires) {
+				delete(c.entries, k)
+				continue
+			}
+			if oldestKey == "" || v.expires.Before(oldest) {
+				oldestKey, oldest = k, v.expires
+			}
+		}
+		if len(c.entries) >= c.maxEntries && oldestKey != "" {
+			delete(c.entries, oldestKey)
+		}
+	}
+
+	c.entries[key] = e
+}
+
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *ResponseCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*cacheEntry)
+}
+
+func cacheKey(kind string, req *Request) string {
+	data, _ := json.Marshal(struct {
+		Kind            string          `json:"kind"`
+		Model           string          `json:"model"`
+		Messages        []Message       `json:"messages"`
+		Tools           []Tool          `json:"tools"`
+		Temperature     *float64        `json:"temperature"`
+		TopP            *float64        `json:"top_p"`
+		MaxTokens       int             `json:"max_tokens"`
+		Stop            []string        `json:"stop"`
+		ReasoningEffort string          `json:"reasoning_effort"`
+		ResponseFormat  *ResponseFormat `json:"response_format"`
+	}{kind, req.Model, req.Messages, req.Tools, req.Temperature, req.TopP, req.MaxTokens, req.Stop, req.ReasoningEffort, req.ResponseFormat})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func copyResponse(resp *Response) *Response {
+	cp := *resp
+	cp.Choices = append([]Choice(nil), resp.Choices...)
+	return &cp
+}
+
+func replayEvents(events []ResponseEvent) <-chan *ResponseEvent {
+	ch := make(chan *ResponseEvent, len(events))
+	for _, e := range events {
+		event := e
+		ch <- &event
+	}
+	close(ch)
+	return ch
+}
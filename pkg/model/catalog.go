@@ -0,0 +1,287 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	DefaultCatalogURL = "https://models.dev/api.json"
+	// DefaultCatalogRefresh is how old the cached catalog may get before it
+	// is fetched again.
+	DefaultCatalogRefresh = 24 * time.Hour
+
+	// maxCatalogBytes bounds the catalog download.
+	maxCatalogBytes = 32 << 20
+)
+
+// ParseCatalog decodes a model catalog keyed by provider ID. Models may be
+// written in this package's own format, with a "capabilities" object, or in
+// the flat format of models.dev.
+func ParseCatalog(data []byte) (map[string]*ProviderInfo, error) {
+	var raw map[string]struct {
+		ID      string                     `json:"id"`
+		Name    string                     `json:"name"`
+		Env     []string                   `json:"env"`
+		API     string                     `json:"api"`
+		Options map[string]interface{}     `json:"options"`
+		Models  map[string]json.RawMessage `json:"models"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	providers := make(map[string]*ProviderInfo, len(raw))
+	for key, rp := range raw {
+		info := &ProviderInfo{
+			ID:      rp.ID,
+			Name:    rp.Name,
+			Env:     rp.Env,
+			API:     rp.API,
+			Options: rp.Options,
+			Models:  make(map[string]*ModelInfo, len(rp.Models)),
+		}
+		if info.ID == "" {
+			info.ID = key
+		}
+		for modelID, data := range rp.Models {
+			m, err := parseCatalogModel(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s/%s: %w", info.ID, modelID, err)
+			}
+			if m.ID == "" {
+				m.ID = modelID
+			}
+			if m.ProviderID == "" {
+				m.ProviderID = info.ID
+			}
+			if m.Status == "" {
+				m.Status = "active"
+			}
+			info.Models[modelID] = m
+		}
+		providers[info.ID] = info
+	}
+	return providers, nil
+}
+
+// modelsDevModel is a model entry in the models.dev catalog.
+type modelsDevModel struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Family      string `json:"family"`
+	Status      string `json:"status"`
+	Attachment  bool   `json:"attachment"`
+	Reasoning   bool   `json:"reasoning"`
+	Temperature bool   `json:"temperature"`
+	ToolCall    bool   `json:"tool_call"`
+	Modalities  struct {
+		Input  []string `json:"input"`
+		Output []string `json:"output"`
+	} `json:"modalities"`
+	Cost struct {
+		Input      float64 `json:"input"`
+		Output     float64 `json:"output"`
+		CacheRead  float64 `json:"cache_read"`
+		CacheWrite float64 `json:"cache_write"`
+	} `json:"cost"`
+	Limit   Limit           `json:"limit"`
+	Options json.RawMessage `json:"options"`
+}
+
+func parseCatalogModel(data json.RawMessage) (*ModelInfo, error) {
+	var probe struct {
+		Capabilities json.RawMessage `json:"capabilities"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	if probe.Capabilities != nil {
+		var m ModelInfo
+		err := json.Unmarshal(data, &m)
+		return &m, err
+	}
+
+	var d modelsDevModel
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	m := &ModelInfo{
+		ID:      d.ID,
+		Name:    d.Name,
+		Family:  d.Family,
+		Status:  d.Status,
+		Limit:   d.Limit,
+		Options: d.Options,
+		Cost:    Cost{Input: d.Cost.Input, Output: d.Cost.Output},
+	}
+	m.Cost.Cache.Read = d.Cost.CacheRead
+	m.Cost.Cache.Write = d.Cost.CacheWrite
+	c := &m.Capabilities
+	c.Temperature = d.Temperature
+	c.Reasoning = d.Reasoning
+	c.Attachment = d.Attachment
+	c.ToolCall = d.ToolCall
+	in, out := d.Modalities.Input, d.Modalities.Output
+	c.Input.Text = slices.Contains(in, "text")
+	c.Input.Audio = slices.Contains(in, "audio")
+	c.Input.Image = slices.Contains(in, "image")
+	c.Input.Video = slices.Contains(in, "video")
+	c.Input.PDF = slices.Contains(in, "pdf")
+	c.Output.Text = slices.Contains(out, "text")
+	c.Output.Audio = slices.Contains(out, "audio")
+	c.Output.Image = slices.Contains(out, "image")
+	c.Output.Video = slices.Contains(out, "video")
+	c.Output.PDF = slices.Contains(out, "pdf")
+	return m, nil
+}
+
+// CatalogLoader keeps a ModelDatabase current with a remote catalog. The
+// last download is cached on disk, so the catalog is available at startup
+// without the network and is fetched again only once it is stale.
+type CatalogLoader struct {
+	db        *ModelDatabase
+	url       string
+	cachePath string
+	refresh   time.Duration
+	client    *http.Client
+}
+
+type CatalogOption func(*CatalogLoader)
+
+func WithCatalogURL(url string) CatalogOption {
+	return func(l *CatalogLoader) {
+		if url != "" {
+			l.url = url
+		}
+	}
+}
+
+func WithCatalogRefresh(d time.Duration) CatalogOption {
+	return func(l *CatalogLoader) {
+		if d > 0 {
+			l.refresh = d
+		}
+	}
+}
+
+func WithCatalogDatabase(db *ModelDatabase) CatalogOption {
+	return func(l *CatalogLoader) { l.db = db }
+}
+
+// NewCatalogLoader returns a loader that fills DefaultModelDatabase from
+// DefaultCatalogURL, caching it at cachePath.
+func NewCatalogLoader(cachePath string, opts ...CatalogOption) *CatalogLoader {
+	l := &CatalogLoader{
+		db:        DefaultModelDatabase(),
+		url:       DefaultCatalogURL,
+		cachePath: cachePath,
+		refresh:   DefaultCatalogRefresh,
+		client:    &http.Client{Timeout: 60 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Load applies the cached catalog and fetches a new one if the cache is
+// missing or stale. When the fetch fails, the cached catalog stays in use.
+func (l *CatalogLoader) Load(ctx context.Context) error {
+	fresh := false
+	if info, err := os.Stat(l.cachePath); err == nil {
+		if err := l.loadCache(); err != nil {
+			fmt.Printf("Ignoring unreadable model catalog cache %s: %v\n", l.cachePath, err)
+		} else {
+			fresh = time.Since(info.ModTime()) < l.refresh
+		}
+	}
+	if fresh {
+		return nil
+	}
+	return l.Fetch(ctx)
+}
+
+// Run refreshes the catalog on the refresh interval until ctx is done.
+func (l *CatalogLoader) Run(ctx context.Context) {
+	ticker := time.NewTicker(l.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.Fetch(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				fmt.Printf("Model catalog refresh failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// Fetch downloads the catalog, applies it, and replaces the cache.
+func (l *CatalogLoader) Fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch model catalog: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch model catalog: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCatalogBytes))
+	if err != nil {
+		return fmt.Errorf("fetch model catalog: %w", err)
+	}
+
+	providers, err := ParseCatalog(data)
+	if err != nil {
+		return fmt.Errorf("parse model catalog: %w", err)
+	}
+	l.apply(providers)
+
+	if l.cachePath == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.cachePath), 0755); err != nil {
+		return fmt.Errorf("cache model catalog: %w", err)
+	}
+	if err := os.WriteFile(l.cachePath, data, 0644); err != nil {
+		return fmt.Errorf("cache model catalog: %w", err)
+	}
+	return nil
+}
+
+func (l *CatalogLoader) loadCache() error {
+	data, err := os.ReadFile(l.cachePath)
+	if err != nil {
+		return err
+	}
+	providers, err := ParseCatalog(data)
+	if err != nil {
+		return err
+	}
+	l.apply(providers)
+	return nil
+}
+
+func (l *CatalogLoader) apply(providers map[string]*ProviderInfo) {
+	n := 0
+	for _, info := range providers {
+		l.db.MergeProvider(SourceCatalog, info)
+		n += len(info.Models)
+	}
+	fmt.Printf("Model catalog loaded: %d providers, %d models\n", len(providers), n)
+}
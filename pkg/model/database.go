@@ -1,33 +1,210 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
+	"sort"
 	"sync"
 )
 
+// Source is where provider and model entries come from. Entries of a later
+// source override those of an earlier one: user config over the remote
+// catalog over the built-in entries.
+type Source int
+
+const (
+	SourceBuiltin Source = iota
+	SourceCatalog
+	SourceUser
+
+	sourceCount
+)
+
+// ModelDatabase merges the entries of every source. Each source keeps its
+// own copy of a provider, so refreshing the catalog does not lose what the
+// user set, and lookups see the merged result.
 type ModelDatabase struct {
 	mu        sync.RWMutex
+	layers    [sourceCount]map[string]*ProviderInfo
 	providers map[string]*ProviderInfo
 	models    map[string]*ModelInfo
 }
 
 func NewModelDatabase() *ModelDatabase {
-	return &ModelDatabase{
+	db := &ModelDatabase{
 		providers: make(map[string]*ProviderInfo),
 		models:    make(map[string]*ModelInfo),
 	}
+	for i := range db.layers {
+		db.layers[i] = make(map[string]*ProviderInfo)
+	}
+	return db
 }
 
+// AddProvider adds a built-in provider; see MergeProvider.
 func (db *ModelDatabase) AddProvider(info *ProviderInfo) {
+	db.MergeProvider(SourceBuiltin, info)
+}
+
+// MergeProvider sets what source says about a provider, replacing what the
+// same source said before, and merges it with the other sources. Model
+// entries are merged field by field, so an override only needs the fields
+// it changes.
+func (db *ModelDatabase) MergeProvider(source Source, info *ProviderInfo) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.layers[source][info.ID] = info
+	db.rebuild(info.ID)
+}
+
+// ClearSource forgets everything source said, such as user-defined models
+// before the config is applied again.
+func (db *ModelDatabase) ClearSource(source Source) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
-	db.providers[info.ID] = info
-	for modelID, model := range info.Models {
-		key := info.ID + "/" + modelID
-		db.models[key] = model
+	ids := make([]string, 0, len(db.layers[source]))
+	for id := range db.layers[source] {
+		ids = append(ids, id)
+	}
+	db.layers[source] = make(map[string]*ProviderInfo)
+	for _, id := range ids {
+		db.rebuild(id)
+	}
+}
+
+// rebuild merges the sources of one provider. The merged entries are new
+// values, so those handed out before are never changed.
+func (db *ModelDatabase) rebuild(id string) {
+	if old, ok := db.providers[id]; ok {
+		for modelID := range old.Models {
+			delete(db.models, id+"/"+modelID)
+		}
+		delete(db.providers, id)
+	}
+
+	var merged *ProviderInfo
+	for _, layer := range db.layers {
+		if info, ok := layer[id]; ok {
+			merged = mergeProvider(merged, info)
+		}
+	}
+	if merged == nil {
+		return
+	}
+	db.providers[id] = merged
+	for modelID, m := range merged.Models {
+		db.models[id+"/"+modelID] = m
 	}
 }
 
+func mergeProvider(base, over *ProviderInfo) *ProviderInfo {
+	if base == nil {
+		base = &ProviderInfo{}
+	}
+	p := *base
+	p.ID = over.ID
+	if over.Name != "" {
+		p.Name = over.Name
+	}
+	if len(over.Env) > 0 {
+		p.Env = over.Env
+	}
+	if over.API != "" {
+		p.API = over.API
+	}
+	if len(over.Options) > 0 {
+		options := make(map[string]interface{}, len(base.Options)+len(over.Options))
+		for k, v := range base.Options {
+			options[k] = v
+		}
+		for k, v := range over.Options {
+			options[k] = v
+		}
+		p.Options = options
+	}
+
+	p.Models = make(map[string]*ModelInfo, len(base.Models)+len(over.Models))
+	for modelID, m := range base.Models {
+		p.Models[modelID] = m
+	}
+	for modelID, m := range over.Models {
+		merged := mergeModel(p.Models[modelID], m)
+		if merged.ID == "" {
+			merged.ID = modelID
+		}
+		merged.ProviderID = p.ID
+		p.Models[modelID] = merged
+	}
+	return &p
+}
+
+// mergeModel returns base with the fields over sets. Capabilities are taken
+// from over as a whole unless it sets none.
+func mergeModel(base, over *ModelInfo) *ModelInfo {
+	if base == nil {
+		base = &ModelInfo{}
+	}
+	m := *base
+	if over.ID != "" {
+		m.ID = over.ID
+	}
+	if over.Name != "" {
+		m.Name = over.Name
+	}
+	if over.Family != "" {
+		m.Family = over.Family
+	}
+	if over.Status != "" {
+		m.Status = over.Status
+	}
+	if over.Capabilities != (Capabilities{}) {
+		m.Capabilities = over.Capabilities
+	}
+	if over.Cost.Input != 0 {
+		m.Cost.Input = over.Cost.Input
+	}
+	if over.Cost.Output != 0 {
+		m.Cost.Output = over.Cost.Output
+	}
+	if over.Cost.Cache.Read != 0 {
+		m.Cost.Cache.Read = over.Cost.Cache.Read
+	}
+	if over.Cost.Cache.Write != 0 {
+		m.Cost.Cache.Write = over.Cost.Cache.Write
+	}
+	if over.Limit.Context != 0 {
+		m.Limit.Context = over.Limit.Context
+	}
+	if over.Limit.Input != 0 {
+		m.Limit.Input = over.Limit.Input
+	}
+	if over.Limit.Output != 0 {
+		m.Limit.Output = over.Limit.Output
+	}
+	m.Options = mergeOptions(base.Options, over.Options)
+	return &m
+}
+
+// mergeOptions merges two JSON objects key by key; anything else in over
+// replaces base.
+func mergeOptions(base, over json.RawMessage) json.RawMessage {
+	if len(bytes.TrimSpace(over)) == 0 {
+		return base
+	}
+	var b, o map[string]json.RawMessage
+	if json.Unmarshal(base, &b) != nil || json.Unmarshal(over, &o) != nil || b == nil || o == nil {
+		return over
+	}
+	for k, v := range o {
+		b[k] = v
+	}
+	merged, err := json.Marshal(b)
+	if err != nil {
+		return over
+	}
+	return merged
+}
+
 func (db *ModelDatabase) GetProvider(id string) (*ProviderInfo, bool) {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
@@ -43,6 +220,7 @@ func (db *ModelDatabase) GetModel(providerID, modelID string) (*ModelInfo, bool)
 	return info, ok
 }
 
+// ListProviders returns the provider IDs, sorted.
 func (db *ModelDatabase) ListProviders() []string {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
@@ -50,29 +228,39 @@ func (db *ModelDatabase) ListProviders() []string {
 	for id := range db.providers {
 		ids = append(ids, id)
 	}
+	sort.Strings(ids)
 	return ids
 }
 
+// ListModels returns the models of a provider, or of all providers when
+// providerID is empty, sorted by provider and model ID.
 func (db *ModelDatabase) ListModels(providerID string) []*ModelInfo {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 	models := make([]*ModelInfo, 0)
-	for key, m := range db.models {
+	for _, m := range db.models {
 		if providerID == "" || m.ProviderID == providerID {
-			_ = key
 			models = append(models, m)
 		}
 	}
+	sort.Slice(models, func(i, j int) bool {
+		if models[i].ProviderID != models[j].ProviderID {
+			return models[i].ProviderID < models[j].ProviderID
+		}
+		return models[i].ID < models[j].ID
+	})
 	return models
 }
 
+// LoadFromJSON merges the providers and models of a catalog in either
+// format ParseCatalog accepts as the remote catalog.
 func (db *ModelDatabase) LoadFromJSON(data []byte) error {
-	var providers map[string]*ProviderInfo
-	if err := json.Unmarshal(data, &providers); err != nil {
+	providers, err := ParseCatalog(data)
+	if err != nil {
 		return err
 	}
 	for _, info := range providers {
-		db.AddProvider(info)
+		db.MergeProvider(SourceCatalog, info)
 	}
 	return nil
 }
@@ -135,6 +323,36 @@ func GetBuiltinProviders() map[string]*ProviderInfo {
 					Cost:  Cost{Input: 10, Output: 30},
 					Limit: Limit{Context: 128000, Output: 4096},
 				},
+				"o3-mini": {
+					ID:         "o3-mini",
+					ProviderID: "openai",
+					Name:       "o3-mini",
+					Family:     "o",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: false,
+						Reasoning:   true,
+						Attachment:  false,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 1.1, Output: 4.4},
+					Limit: Limit{Context: 200000, Output: 100000},
+				},
+				"o4-mini": {
+					ID:         "o4-mini",
+					ProviderID: "openai",
+					Name:       "o4-mini",
+					Family:     "o",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: false,
+						Reasoning:   true,
+						Attachment:  true,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 1.1, Output: 4.4},
+					Limit: Limit{Context: 200000, Output: 100000},
+				},
 			},
 		},
 		"anthropic": {
@@ -195,6 +413,248 @@ func GetBuiltinProviders() map[string]*ProviderInfo {
 			Env:    []string{"AZURE_OPENAI_API_KEY"},
 			Models: map[string]*ModelInfo{},
 		},
+		"google-vertex": {
+			ID:   "google-vertex",
+			Name: "Vertex AI",
+			Env:  []string{"GOOGLE_APPLICATION_CREDENTIALS"},
+			Models: map[string]*ModelInfo{
+				"gemini-2.5-pro": {
+					ID:         "gemini-2.5-pro",
+					ProviderID: "google-vertex",
+					Name:       "Gemini 2.5 Pro",
+					Family:     "gemini",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: true,
+						Reasoning:   true,
+						Attachment:  true,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 1.25, Output: 10},
+					Limit: Limit{Context: 1048576, Output: 65536},
+				},
+				"gemini-2.5-flash": {
+					ID:         "gemini-2.5-flash",
+					ProviderID: "google-vertex",
+					Name:       "Gemini 2.5 Flash",
+					Family:     "gemini",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: true,
+						Reasoning:   true,
+						Attachment:  true,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 0.3, Output: 2.5},
+					Limit: Limit{Context: 1048576, Output: 65536},
+				},
+			},
+		},
+		"groq": {
+			ID:   "groq",
+			Name: "Groq",
+			Env:  []string{"GROQ_API_KEY"},
+			API:  "https://api.groq.com/openai/v1",
+			Models: map[string]*ModelInfo{
+				"llama-3.3-70b-versatile": {
+					ID:         "llama-3.3-70b-versatile",
+					ProviderID: "groq",
+					Name:       "Llama 3.3 70B Versatile",
+					Family:     "llama",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: true,
+						Reasoning:   false,
+						Attachment:  false,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 0.59, Output: 0.79},
+					Limit: Limit{Context: 131072, Output: 32768},
+				},
+				"llama-3.1-8b-instant": {
+					ID:         "llama-3.1-8b-instant",
+					ProviderID: "groq",
+					Name:       "Llama 3.1 8B Instant",
+					Family:     "llama",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: true,
+						Reasoning:   false,
+						Attachment:  false,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 0.05, Output: 0.08},
+					Limit: Limit{Context: 131072, Output: 131072},
+				},
+			},
+		},
+		"mistral": {
+			ID:   "mistral",
+			Name: "Mistral",
+			Env:  []string{"MISTRAL_API_KEY"},
+			API:  "https://api.mistral.ai/v1",
+			Models: map[string]*ModelInfo{
+				"mistral-large-latest": {
+					ID:         "mistral-large-latest",
+					ProviderID: "mistral",
+					Name:       "Mistral Large",
+					Family:     "mistral-large",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: true,
+						Reasoning:   false,
+						Attachment:  false,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 2, Output: 6},
+					Limit: Limit{Context: 131072, Output: 16384},
+				},
+				"mistral-small-latest": {
+					ID:         "mistral-small-latest",
+					ProviderID: "mistral",
+					Name:       "Mistral Small",
+					Family:     "mistral-small",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: true,
+						Reasoning:   false,
+						Attachment:  true,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 0.1, Output: 0.3},
+					Limit: Limit{Context: 128000, Output: 16384},
+				},
+				"codestral-latest": {
+					ID:         "codestral-latest",
+					ProviderID: "mistral",
+					Name:       "Codestral",
+					Family:     "codestral",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: true,
+						Reasoning:   false,
+						Attachment:  false,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 0.3, Output: 0.9},
+					Limit: Limit{Context: 256000, Output: 4096},
+				},
+			},
+		},
+		"xai": {
+			ID:   "xai",
+			Name: "xAI",
+			Env:  []string{"XAI_API_KEY"},
+			API:  "https://api.x.ai/v1",
+			Models: map[string]*ModelInfo{
+				"grok-4": {
+					ID:         "grok-4",
+					ProviderID: "xai",
+					Name:       "Grok 4",
+					Family:     "grok",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: true,
+						Reasoning:   true,
+						Attachment:  true,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 3, Output: 15},
+					Limit: Limit{Context: 256000, Output: 64000},
+				},
+				"grok-3-mini": {
+					ID:         "grok-3-mini",
+					ProviderID: "xai",
+					Name:       "Grok 3 Mini",
+					Family:     "grok",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: true,
+						Reasoning:   true,
+						Attachment:  false,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 0.3, Output: 0.5},
+					Limit: Limit{Context: 131072, Output: 8192},
+				},
+			},
+		},
+		"deepseek": {
+			ID:   "deepseek",
+			Name: "DeepSeek",
+			Env:  []string{"DEEPSEEK_API_KEY"},
+			API:  "https://api.deepseek.com/v1",
+			Models: map[string]*ModelInfo{
+				"deepseek-chat": {
+					ID:         "deepseek-chat",
+					ProviderID: "deepseek",
+					Name:       "DeepSeek Chat",
+					Family:     "deepseek",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: true,
+						Reasoning:   false,
+						Attachment:  false,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 0.28, Output: 0.42},
+					Limit: Limit{Context: 128000, Output: 8192},
+				},
+				"deepseek-reasoner": {
+					ID:         "deepseek-reasoner",
+					ProviderID: "deepseek",
+					Name:       "DeepSeek Reasoner",
+					Family:     "deepseek",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: false,
+						Reasoning:   true,
+						Attachment:  false,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 0.28, Output: 0.42},
+					Limit: Limit{Context: 128000, Output: 64000},
+				},
+			},
+		},
+		"togetherai": {
+			ID:   "togetherai",
+			Name: "Together AI",
+			Env:  []string{"TOGETHER_API_KEY"},
+			API:  "https://api.together.xyz/v1",
+			Models: map[string]*ModelInfo{
+				"meta-llama/Llama-3.3-70B-Instruct-Turbo": {
+					ID:         "meta-llama/Llama-3.3-70B-Instruct-Turbo",
+					ProviderID: "togetherai",
+					Name:       "Llama 3.3 70B Instruct Turbo",
+					Family:     "llama",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: true,
+						Reasoning:   false,
+						Attachment:  false,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 0.88, Output: 0.88},
+					Limit: Limit{Context: 131072, Output: 8192},
+				},
+				"deepseek-ai/DeepSeek-V3": {
+					ID:         "deepseek-ai/DeepSeek-V3",
+					ProviderID: "togetherai",
+					Name:       "DeepSeek V3",
+					Family:     "deepseek",
+					Status:     "active",
+					Capabilities: Capabilities{
+						Temperature: true,
+						Reasoning:   false,
+						Attachment:  false,
+						ToolCall:    true,
+					},
+					Cost:  Cost{Input: 1.25, Output: 1.25},
+					Limit: Limit{Context: 131072, Output: 8192},
+				},
+			},
+		},
 	}
 }
 
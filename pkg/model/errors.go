@@ -0,0 +1,79 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of provider failures. An *APIError wraps the one that fits its
+// response, so callers can test for them with errors.Is.
+var (
+	ErrAuth            = errors.New("the provider rejected the credentials")
+	ErrRateLimit       = errors.New("the provider is rate limiting requests")
+	ErrContextOverflow = errors.New("the request does not fit the model's context")
+	ErrUnavailable     = errors.New("the provider is unavailable")
+)
+
+// APIError is an error response of a provider. Body is kept for logs only;
+// it can echo parts of the request and even of the API key.
+type APIError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is how long the provider asked to wait, if it said.
+	RetryAfter time.Duration
+}
+
+// NewAPIError reads an error response and closes its body.
+func NewAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	e := &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		e.RetryAfter = time.Duration(secs) * time.Second
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// Unwrap returns the kind of failure, or nil if it is none of the known
+// ones.
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrAuth
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimit
+	case e.StatusCode == http.StatusRequestEntityTooLarge || (e.StatusCode == http.StatusBadRequest && overflowBody(e.Body)):
+		return ErrContextOverflow
+	case e.StatusCode >= 500:
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// overflowBody reports whether a bad request response says the prompt was
+// too long, as each provider words it.
+func overflowBody(body string) bool {
+	body = strings.ToLower(body)
+	for _, s := range []string{
+		"context_length_exceeded",
+		"maximum context length",
+		"prompt is too long",
+		"input is too long",
+		"exceeds the maximum number of tokens",
+		"too many tokens",
+	} {
+		if strings.Contains(body, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// NewHTTPClient returns a client for a provider's API. timeout only bounds
+// the wait for the response headers, so a long stream is not cut off once
+// it has started; Send bounds the whole call with SendContext. Zero means
+// no limit.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		return &http.Client{}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	return &http.Client{Transport: transport}
+}
+
+// SendContext returns ctx with a deadline timeout from now, for calls that
+// read the whole response before returning. Zero means no limit.
+func SendContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -50,20 +50,48 @@ type ModelInfo struct {
 	Options      json.RawMessage `json:"options,omitempty"`
 }
 
+// RequestOptions decodes the model's catalog options. Unknown keys are ignored.
+func (m *ModelInfo) RequestOptions() RequestOptions {
+	var opts RequestOptions
+	if len(m.Options) > 0 {
+		_ = json.Unmarshal(m.Options, &opts)
+	}
+	return opts
+}
+
+// CostUSD prices usage with the catalog's per-million-token rates.
+func (m *ModelInfo) CostUSD(u Usage) float64 {
+	return (float64(u.PromptTokens)*m.Cost.Input + float64(u.CompletionTokens)*m.Cost.Output) / 1e6
+}
+
 type ProviderInfo struct {
-	ID      string                 `json:"id"`
-	Name    string                 `json:"name"`
-	Env     []string               `json:"env"`
+	ID   string   `json:"id"`
+	Name string   `json:"name"`
+	Env  []string `json:"env"`
+	// API is the base URL of OpenAI-compatible providers.
+	API     string                 `json:"api,omitempty"`
 	Options map[string]interface{} `json:"options"`
 	Models  map[string]*ModelInfo  `json:"models"`
 }
 
 type ProviderConfig struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	APIKey    string `json:"api_key"`
-	BaseURL   string `json:"base_url"`
-	Model     string `json:"model"`
-	Timeout   int    `json:"timeout"`
-	MaxTokens int    `json:"max_tokens"`
+	ID              string   `json:"id"`
+	Type            string   `json:"type"`
+	Name            string   `json:"name"`
+	APIKey          string   `json:"api_key"`
+	BaseURL         string   `json:"base_url"`
+	Model           string   `json:"model"`
+	Timeout         int      `json:"timeout"`
+	MaxTokens       int      `json:"max_tokens"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"top_p,omitempty"`
+	Stop            []string `json:"stop,omitempty"`
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"`
+	ThinkingBudget  int      `json:"thinking_budget,omitempty"`
+	CacheTTL        int      `json:"cache_ttl,omitempty"`
+
+	// Vertex AI
+	Project     string `json:"project,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Credentials string `json:"credentials,omitempty"`
 }
@@ -0,0 +1,110 @@
+package model
+
+import "context"
+
+// Middleware wraps a Provider to add behavior such as logging, prompt
+// rewriting, caching, or guardrails without touching the provider itself.
+type Middleware func(next Provider) Provider
+
+// Wrap applies mws to p. The first middleware is the outermost, so it sees
+// requests first and responses last.
+func Wrap(p Provider, mws ...Middleware) Provider {
+	for i := len(mws) - 1; i >= 0; i-- {
+		if mws[i] != nil {
+			p = mws[i](p)
+		}
+	}
+	return p
+}
+
+// ProviderFuncs adapts a pair of functions to the Provider interface. It is
+// the usual building block for middlewares.
+type ProviderFuncs struct {
+	SendFunc       func(ctx context.Context, req *Request) (*Response, error)
+	SendStreamFunc func(ctx context.Context, req *Request) (<-chan *ResponseEvent, error)
+}
+
+func (p ProviderFuncs) Send(ctx context.Context, req *Request) (*Response, error) {
+	return p.SendFunc(ctx, req)
+}
+
+func (p ProviderFuncs) SendStream(ctx context.Context, req *Request) (<-chan *ResponseEvent, error) {
+	return p.SendStreamFunc(ctx, req)
+}
+
+// RequestHook returns a middleware that calls fn before every request. fn may
+// modify the request in place; a non-nil error aborts the call.
+func RequestHook(fn func(ctx context.Context, req *Request) error) Middleware {
+	return func(next Provider) Provider {
+		return ProviderFuncs{
+			SendFunc: func(ctx context.Context, req *Request) (*Response, error) {
+				if err := fn(ctx, req); err != nil {
+					return nil, err
+				}
+				return next.Send(ctx, req)
+			},
+			SendStreamFunc: func(ctx context.Context, req *Request) (<-chan *ResponseEvent, error) {
+				if err := fn(ctx, req); err != nil {
+					return nil, err
+				}
+				return next.SendStream(ctx, req)
+			},
+		}
+	}
+}
+
+// EventHook returns a middleware that calls fn for every response event. For
+// non-streaming calls fn sees a single event built from the first choice.
+// Returning nil from fn drops the event.
+func EventHook(fn func(ctx context.Context, req *Request, event *ResponseEvent) *ResponseEvent) Middleware {
+	return func(next Provider) Provider {
+		return ProviderFuncs{
+			SendFunc: func(ctx context.Context, req *Request) (*Response, error) {
+				resp, err := next.Send(ctx, req)
+				if err != nil || len(resp.Choices) == 0 {
+					return resp, err
+				}
+				choice := &resp.Choices[0]
+				event := fn(ctx, req, &ResponseEvent{
+					Delta:        choice.Message.Content,
+					FinishReason: FinishReason(choice.FinishReason),
+				})
+				if event == nil {
+					choice.Message.Content = ""
+				} else {
+					choice.Message.Content = event.Delta
+				}
+				return resp, nil
+			},
+			SendStreamFunc: func(ctx context.Context, req *Request) (<-chan *ResponseEvent, error) {
+				in, err := next.SendStream(ctx, req)
+				if err != nil {
+					return nil, err
+				}
+				out := make(chan *ResponseEvent, cap(in))
+				go func() {
+					defer close(out)
+					for event := range in {
+						if event = fn(ctx, req, event); event == nil {
+							continue
+						}
+						select {
+						case out <- event:
+						case <-ctx.Done():
+							go discard(in)
+							return
+						}
+					}
+				}()
+				return out, nil
+			},
+		}
+	}
+}
+
+// discard reads a stream its reader gave up on to the end, so the provider
+// sending it is not left blocked.
+func discard(in <-chan *ResponseEvent) {
+	for range in {
+	}
+}
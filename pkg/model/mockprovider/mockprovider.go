@@ -0,0 +1,337 @@
+// Package mockprovider records the calls made to a real model provider and
+// replays them deterministically, so sessions and channel rendering can be
+// tested without calling an API.
+package mockprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nene-agent/nene/pkg/model"
+)
+
+// Event is one streamed response event, in a fixture-friendly form.
+type Event struct {
+	Delta        string             `json:"delta,omitempty"`
+	Reasoning    string             `json:"reasoning,omitempty"`
+	ToolCall     *model.ToolCall    `json:"tool_call,omitempty"`
+	FinishReason model.FinishReason `json:"finish_reason,omitempty"`
+	Usage        *model.Usage       `json:"usage,omitempty"`
+}
+
+func fromEvent(e *model.ResponseEvent) Event {
+	return Event{
+		Delta:        e.Delta,
+		Reasoning:    e.Reasoning,
+		ToolCall:     e.ToolCall,
+		FinishReason: e.FinishReason,
+		Usage:        e.Usage,
+	}
+}
+
+func (e Event) event() *model.ResponseEvent {
+	return &model.ResponseEvent{
+		Delta:        e.Delta,
+		Reasoning:    e.Reasoning,
+		ToolCall:     e.ToolCall,
+		FinishReason: e.FinishReason,
+		Usage:        e.Usage,
+	}
+}
+
+// Exchange is one model call: the request and either the streamed events,
+// the response of a non-streaming call, or the error it failed with.
+type Exchange struct {
+	Request  *model.Request  `json:"request,omitempty"`
+	Events   []Event         `json:"events,omitempty"`
+	Response *model.Response `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// Fixture is a recorded sequence of calls, stored as JSON.
+type Fixture struct {
+	Exchanges []Exchange `json:"exchanges"`
+}
+
+func Load(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse fixture %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+func (f *Fixture) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Text is an exchange that answers with text.
+func Text(content string) Exchange {
+	return Exchange{Events: []Event{
+		{Delta: content},
+		{FinishReason: model.FinishReasonStop},
+	}}
+}
+
+// ToolCalls is an exchange that calls tools; each call is a name and its
+// JSON arguments, and gets the ID "call-<n>".
+func ToolCalls(calls ...[2]string) Exchange {
+	var events []Event
+	for i, c := range calls {
+		events = append(events, Event{ToolCall: &model.ToolCall{
+			ID:       fmt.Sprintf("call-%d", i+1),
+			Type:     "function",
+			Function: model.FunctionCall{Name: c[0], Arguments: c[1]},
+		}})
+	}
+	events = append(events, Event{FinishReason: model.FinishReasonToolCalls})
+	return Exchange{Events: events}
+}
+
+// Recorder passes calls through to a real provider and keeps them, so they
+// can be saved as a fixture.
+type Recorder struct {
+	next model.Provider
+
+	mu      sync.Mutex
+	fixture Fixture
+}
+
+func Record(next model.Provider) *Recorder {
+	return &Recorder{next: next}
+}
+
+func (r *Recorder) add(ex Exchange) {
+	// Sessions keep appending to and editing the slice a request was
+	// sent with.
+	if ex.Request != nil {
+		req := *ex.Request
+		req.Messages = slices.Clone(req.Messages)
+		ex.Request = &req
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Exchanges = append(r.fixture.Exchanges, ex)
+}
+
+func (r *Recorder) Send(ctx context.Context, req *model.Request) (*model.Response, error) {
+	resp, err := r.next.Send(ctx, req)
+	ex := Exchange{Request: req, Response: resp}
+	if err != nil {
+		ex.Error = err.Error()
+	}
+	r.add(ex)
+	return resp, err
+}
+
+func (r *Recorder) SendStream(ctx context.Context, req *model.Request) (<-chan *model.ResponseEvent, error) {
+	in, err := r.next.SendStream(ctx, req)
+	if err != nil {
+		r.add(Exchange{Request: req, Error: err.Error()})
+		return nil, err
+	}
+	out := make(chan *model.ResponseEvent)
+	go func() {
+		defer close(out)
+		ex := Exchange{Request: req}
+		for e := range in {
+			ex.Events = append(ex.Events, fromEvent(e))
+			select {
+			case out <- e:
+			case <-ctx.Done():
+				go func() {
+					for range in {
+					}
+				}()
+				return
+			}
+		}
+		r.add(ex)
+	}()
+	return out, nil
+}
+
+// Fixture returns the calls recorded so far.
+func (r *Recorder) Fixture() *Fixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := Fixture{Exchanges: make([]Exchange, len(r.fixture.Exchanges))}
+	copy(f.Exchanges, r.fixture.Exchanges)
+	return &f
+}
+
+// Save writes the calls recorded so far to path.
+func (r *Recorder) Save(path string) error {
+	return r.Fixture().Save(path)
+}
+
+// ErrExhausted is returned for calls past the end of the fixture.
+var ErrExhausted = errors.New("mockprovider: no recorded response left")
+
+// Provider answers calls with recorded exchanges, in order. A streaming call
+// may be answered with a recorded response and the other way round.
+type Provider struct {
+	strict bool
+
+	mu        sync.Mutex
+	exchanges []Exchange
+	requests  []*model.Request
+}
+
+type Option func(*Provider)
+
+// Strict makes a call fail when its last message differs from the recorded
+// request's, so a test notices when the conversation has drifted from the
+// recording.
+func Strict() Option {
+	return func(p *Provider) { p.strict = true }
+}
+
+func New(exchanges []Exchange, opts ...Option) *Provider {
+	p := &Provider{exchanges: exchanges}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// FromFile replays the fixture at path.
+func FromFile(path string, opts ...Option) (*Provider, error) {
+	f, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(f.Exchanges, opts...), nil
+}
+
+// Requests returns the requests the provider has been sent.
+func (p *Provider) Requests() []*model.Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*model.Request(nil), p.requests...)
+}
+
+// Remaining returns how many recorded exchanges have not been used.
+func (p *Provider) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.exchanges) - len(p.requests)
+}
+
+func (p *Provider) next(req *model.Request) (Exchange, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := len(p.requests)
+	p.requests = append(p.requests, req)
+	if n >= len(p.exchanges) {
+		return Exchange{}, fmt.Errorf("%w (call %d)", ErrExhausted, n+1)
+	}
+	ex := p.exchanges[n]
+	if p.strict && ex.Request != nil {
+		if want, got := lastMessage(ex.Request), lastMessage(req); want != got {
+			return Exchange{}, fmt.Errorf("mockprovider: call %d has last message %q, recorded %q", n+1, got, want)
+		}
+	}
+	if ex.Error != "" {
+		return Exchange{}, errors.New(ex.Error)
+	}
+	return ex, nil
+}
+
+func lastMessage(req *model.Request) string {
+	if len(req.Messages) == 0 {
+		return ""
+	}
+	return req.Messages[len(req.Messages)-1].Content
+}
+
+func (p *Provider) Send(ctx context.Context, req *model.Request) (*model.Response, error) {
+	ex, err := p.next(req)
+	if err != nil {
+		return nil, err
+	}
+	if ex.Response != nil {
+		return ex.Response, nil
+	}
+	return responseFrom(ex.Events), nil
+}
+
+func (p *Provider) SendStream(ctx context.Context, req *model.Request) (<-chan *model.ResponseEvent, error) {
+	ex, err := p.next(req)
+	if err != nil {
+		return nil, err
+	}
+	events := ex.Events
+	if events == nil && ex.Response != nil {
+		events = eventsFrom(ex.Response)
+	}
+	ch := make(chan *model.ResponseEvent, len(events))
+	for _, e := range events {
+		ch <- e.event()
+	}
+	close(ch)
+	return ch, nil
+}
+
+// responseFrom assembles streamed events into the response a
+// non-streaming call would have returned.
+func responseFrom(events []Event) *model.Response {
+	var content strings.Builder
+	msg := model.Message{Role: "assistant"}
+	var finish model.FinishReason
+	var usage model.Usage
+	for _, e := range events {
+		content.WriteString(e.Delta)
+		if e.ToolCall != nil {
+			msg.ToolCalls = append(msg.ToolCalls, *e.ToolCall)
+		}
+		if e.FinishReason != "" {
+			finish = e.FinishReason
+		}
+		if e.Usage != nil {
+			usage = *e.Usage
+		}
+	}
+	msg.Content = content.String()
+	return &model.Response{
+		Choices: []model.Choice{{Message: msg, FinishReason: string(finish)}},
+		Usage:   usage,
+	}
+}
+
+func eventsFrom(resp *model.Response) []Event {
+	var events []Event
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		if choice.Message.Content != "" {
+			events = append(events, Event{Delta: choice.Message.Content})
+		}
+		for _, tc := range choice.Message.ToolCalls {
+			events = append(events, Event{ToolCall: &tc})
+		}
+		events = append(events, Event{FinishReason: model.FinishReason(choice.FinishReason)})
+	}
+	if resp.Usage.TotalTokens > 0 {
+		usage := resp.Usage
+		events = append(events, Event{Usage: &usage})
+	}
+	return events
+}
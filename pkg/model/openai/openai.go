@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/nene-agent/nene/pkg/model"
 )
@@ -16,6 +17,17 @@ type Config struct {
 	APIKey  string
 	BaseURL string
 	Model   string
+	Timeout time.Duration
+
+	MaxTokens       int
+	Temperature     *float64
+	TopP            *float64
+	Stop            []string
+	ReasoningEffort string
+
+	// CatalogID is the model catalog provider whose entries shape requests;
+	// empty means "openai".
+	CatalogID string
 }
 
 type Provider struct {
@@ -27,13 +39,38 @@ func NewProvider(config Config) *Provider {
 	if config.BaseURL == "" {
 		config.BaseURL = "https://api.openai.com/v1"
 	}
+	if config.CatalogID == "" {
+		config.CatalogID = "openai"
+	}
 	return &Provider{
 		config: config,
-		client: &http.Client{},
+		client: model.NewHTTPClient(config.Timeout),
+	}
+}
+
+// prepare fills unset request fields from the provider config and then from
+// the model's catalog options.
+func (p *Provider) prepare(req *model.Request) {
+	if req.Model == "" {
+		req.Model = p.config.Model
+	}
+	req.ApplyOptions(model.RequestOptions{
+		Temperature:     p.config.Temperature,
+		TopP:            p.config.TopP,
+		MaxTokens:       p.config.MaxTokens,
+		Stop:            p.config.Stop,
+		ReasoningEffort: p.config.ReasoningEffort,
+	})
+	if info, ok := model.DefaultModelDatabase().GetModel(p.config.CatalogID, req.Model); ok {
+		req.ApplyOptions(info.RequestOptions())
+		req.ApplyCapabilities(info)
 	}
 }
 
 func (p *Provider) Send(ctx context.Context, req *model.Request) (*model.Response, error) {
+	ctx, cancel := model.SendContext(ctx, p.config.Timeout)
+	defer cancel()
+	p.prepare(req)
 	req.Stream = false
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -55,8 +92,7 @@ func (p *Provider) Send(ctx context.Context, req *model.Request) (*model.Respons
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, model.NewAPIError(resp)
 	}
 
 	var response model.Response
@@ -68,7 +104,9 @@ func (p *Provider) Send(ctx context.Context, req *model.Request) (*model.Respons
 }
 
 func (p *Provider) SendStream(ctx context.Context, req *model.Request) (<-chan *model.ResponseEvent, error) {
+	p.prepare(req)
 	req.Stream = true
+	req.StreamOptions = &model.StreamOptions{IncludeUsage: true}
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
@@ -89,9 +127,7 @@ func (p *Provider) SendStream(ctx context.Context, req *model.Request) (<-chan *
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, model.NewAPIError(resp)
 	}
 
 	ch := make(chan *model.ResponseEvent, 100)
@@ -108,9 +144,14 @@ type streamChunk struct {
 	Choices []struct {
 		Index int `json:"index"`
 		Delta struct {
-			Role      string `json:"role"`
-			Content   string `json:"content"`
-			ToolCalls []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+			// ReasoningContent carries the thinking of DeepSeek-R1 and
+			// other reasoning models served over this API; some servers
+			// name it reasoning instead.
+			ReasoningContent string `json:"reasoning_content"`
+			Reasoning        string `json:"reasoning"`
+			ToolCalls        []struct {
 				Index    int    `json:"index"`
 				ID       string `json:"id"`
 				Type     string `json:"type"`
@@ -122,6 +163,7 @@ type streamChunk struct {
 		} `json:"delta"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
+	Usage *model.Usage `json:"usage"`
 }
 
 func (p *Provider) readStream(body io.ReadCloser, ch chan<- *model.ResponseEvent) {
@@ -154,7 +196,16 @@ func (p *Provider) readStream(body io.ReadCloser, ch chan<- *model.ResponseEvent
 			continue
 		}
 
+		if chunk.Usage != nil {
+			ch <- &model.ResponseEvent{Usage: chunk.Usage}
+		}
+
 		for _, choice := range chunk.Choices {
+			if r := choice.Delta.ReasoningContent + choice.Delta.Reasoning; r != "" {
+				ch <- &model.ResponseEvent{
+					Reasoning: r,
+				}
+			}
 			if choice.Delta.Content != "" {
 				ch <- &model.ResponseEvent{
 					Delta: choice.Delta.Content,
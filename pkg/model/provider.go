@@ -15,8 +15,10 @@ const (
 
 type ResponseEvent struct {
 	Delta        string
+	Reasoning    string
 	ToolCall     *ToolCall
 	FinishReason FinishReason
+	Usage        *Usage
 }
 
 type Provider interface {
@@ -51,6 +53,10 @@ func (f ProviderFunc) SendStream(ctx context.Context, req *Request) (<-chan *Res
 				}
 			}
 		}
+		if resp.Usage.TotalTokens > 0 {
+			usage := resp.Usage
+			ch <- &ResponseEvent{Usage: &usage}
+		}
 	}()
 	return ch, nil
 }
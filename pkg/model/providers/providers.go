@@ -0,0 +1,230 @@
+// Package providers registers the built-in provider factories and creates
+// the providers declared in the config.
+package providers
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/nene-agent/nene/config"
+	"github.com/nene-agent/nene/pkg/model"
+	"github.com/nene-agent/nene/pkg/model/anthropic"
+	"github.com/nene-agent/nene/pkg/model/azure"
+	"github.com/nene-agent/nene/pkg/model/openai"
+	"github.com/nene-agent/nene/pkg/model/vertex"
+)
+
+// DefaultID is used for the primary provider when config.provider.id is
+// empty.
+const DefaultID = "default"
+
+// PresetTypes are the OpenAI-compatible services with a provider type of
+// their own; their base URL and API key variable come from the model catalog.
+var PresetTypes = []string{"groq", "mistral", "xai", "deepseek", "together"}
+
+// RegisterFactories registers a factory for each supported provider type.
+func RegisterFactories(r *model.Registry) {
+	r.RegisterFactory("openai", newOpenAI)
+	r.RegisterFactory("openai-compatible", newOpenAI)
+	r.RegisterFactory("anthropic", newAnthropic)
+	r.RegisterFactory("azure", newAzure)
+	r.RegisterFactory("vertex", newVertex)
+	for _, typ := range PresetTypes {
+		r.RegisterFactory(typ, newPreset)
+	}
+}
+
+// Setup creates the primary provider and every entry of cfg.Providers in r,
+// and makes the primary provider the default. Calling it again replaces the
+// providers, which is how a config reload applies new credentials.
+func Setup(r *model.Registry, cfg *config.Config) error {
+	RegisterFactories(r)
+
+	primary := ToModelConfig(cfg.Provider)
+	if primary.ID == "" {
+		primary.ID = DefaultID
+	}
+	if _, err := r.CreateProvider(primary); err != nil {
+		return fmt.Errorf("create provider %s: %w", primary.ID, err)
+	}
+
+	for _, pc := range cfg.Providers {
+		if pc.ID == primary.ID {
+			return fmt.Errorf("providers: id %q is already used by the primary provider", pc.ID)
+		}
+		if _, err := r.CreateProvider(ToModelConfig(pc)); err != nil {
+			return fmt.Errorf("create provider %s: %w", pc.ID, err)
+		}
+	}
+
+	r.SetDefault(primary.ID)
+	RegisterModels(model.DefaultModelDatabase(), cfg)
+	return nil
+}
+
+// RegisterModels puts the models declared in the config into db as user
+// entries, under the catalog provider of their provider's type, replacing
+// those of an earlier call.
+func RegisterModels(db *model.ModelDatabase, cfg *config.Config) {
+	db.ClearSource(model.SourceUser)
+
+	byCatalog := make(map[string]*model.ProviderInfo)
+	for _, pc := range append([]config.ProviderConfig{cfg.Provider}, cfg.Providers...) {
+		if len(pc.Models) == 0 {
+			continue
+		}
+		id := model.CatalogProviderID(cmp.Or(pc.Type, "openai"))
+		info, ok := byCatalog[id]
+		if !ok {
+			info = &model.ProviderInfo{ID: id, Models: make(map[string]*model.ModelInfo)}
+			byCatalog[id] = info
+		}
+		for _, mc := range pc.Models {
+			_, known := db.GetModel(id, mc.ID)
+			info.Models[mc.ID] = toModelInfo(id, mc, known)
+		}
+	}
+	for _, info := range byCatalog {
+		db.MergeProvider(model.SourceUser, info)
+	}
+}
+
+// toModelInfo converts a declared model. Capabilities are left to the
+// catalog when none are listed for a model it knows.
+func toModelInfo(providerID string, mc config.ModelConfig, known bool) *model.ModelInfo {
+	m := &model.ModelInfo{
+		ID:         mc.ID,
+		ProviderID: providerID,
+		Name:       mc.Name,
+		Cost:       model.Cost{Input: mc.InputCost, Output: mc.OutputCost},
+		Limit:      model.Limit{Context: mc.Context, Output: mc.Output},
+	}
+	if !known {
+		m.Name = cmp.Or(m.Name, mc.ID)
+		m.Status = "active"
+	}
+
+	caps := mc.Capabilities
+	if len(caps) == 0 && !known {
+		caps = []string{"temperature", "tool_call"}
+	}
+	c := &m.Capabilities
+	c.Temperature = slices.Contains(caps, "temperature")
+	c.ToolCall = slices.Contains(caps, "tool_call")
+	c.Reasoning = slices.Contains(caps, "reasoning")
+	c.Attachment = slices.Contains(caps, "attachment")
+	c.Input.Text = len(caps) > 0
+	c.Output.Text = len(caps) > 0
+	return m
+}
+
+// ToModelConfig converts a provider entry from the config file, defaulting
+// the type to openai.
+func ToModelConfig(pc config.ProviderConfig) model.ProviderConfig {
+	typ := pc.Type
+	if typ == "" {
+		typ = "openai"
+	}
+	return model.ProviderConfig{
+		ID:              pc.ID,
+		Type:            typ,
+		APIKey:          pc.APIKey,
+		BaseURL:         pc.BaseURL,
+		Model:           pc.Model,
+		Timeout:         pc.Timeout,
+		MaxTokens:       pc.MaxTokens,
+		Temperature:     pc.Temperature,
+		TopP:            pc.TopP,
+		Stop:            pc.Stop,
+		ReasoningEffort: pc.ReasoningEffort,
+		ThinkingBudget:  pc.ThinkingBudget,
+		CacheTTL:        pc.CacheTTL,
+		Project:         pc.Project,
+		Location:        pc.Location,
+		Credentials:     pc.Credentials,
+	}
+}
+
+func newOpenAI(c model.ProviderConfig) (model.Provider, error) {
+	return openai.NewProvider(openai.Config{
+		APIKey:          c.APIKey,
+		BaseURL:         c.BaseURL,
+		Model:           c.Model,
+		Timeout:         time.Duration(c.Timeout) * time.Second,
+		MaxTokens:       c.MaxTokens,
+		Temperature:     c.Temperature,
+		TopP:            c.TopP,
+		Stop:            c.Stop,
+		ReasoningEffort: c.ReasoningEffort,
+		CatalogID:       model.CatalogProviderID(c.Type),
+	}), nil
+}
+
+func newPreset(c model.ProviderConfig) (model.Provider, error) {
+	info, ok := model.DefaultModelDatabase().GetProvider(model.CatalogProviderID(c.Type))
+	if !ok {
+		return nil, fmt.Errorf("no catalog entry for provider type %s", c.Type)
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = info.API
+	}
+	if c.BaseURL == "" {
+		return nil, fmt.Errorf("%s provider %s needs base_url", c.Type, c.ID)
+	}
+	for _, env := range info.Env {
+		if c.APIKey == "" {
+			c.APIKey = os.Getenv(env)
+		}
+	}
+	return newOpenAI(c)
+}
+
+func newAnthropic(c model.ProviderConfig) (model.Provider, error) {
+	return anthropic.NewProvider(anthropic.Config{
+		APIKey:         c.APIKey,
+		BaseURL:        c.BaseURL,
+		Model:          c.Model,
+		Timeout:        time.Duration(c.Timeout) * time.Second,
+		MaxTokens:      c.MaxTokens,
+		Temperature:    c.Temperature,
+		TopP:           c.TopP,
+		Stop:           c.Stop,
+		ThinkingBudget: c.ThinkingBudget,
+	}), nil
+}
+
+func newAzure(c model.ProviderConfig) (model.Provider, error) {
+	if c.BaseURL == "" || c.Model == "" {
+		return nil, fmt.Errorf("azure provider %s needs base_url and model (deployment)", c.ID)
+	}
+	return azure.NewProvider(azure.Config{
+		APIKey:          c.APIKey,
+		BaseURL:         c.BaseURL,
+		Deployment:      c.Model,
+		Timeout:         time.Duration(c.Timeout) * time.Second,
+		MaxTokens:       c.MaxTokens,
+		Temperature:     c.Temperature,
+		TopP:            c.TopP,
+		Stop:            c.Stop,
+		ReasoningEffort: c.ReasoningEffort,
+	}), nil
+}
+
+func newVertex(c model.ProviderConfig) (model.Provider, error) {
+	return vertex.NewProvider(vertex.Config{
+		Project:        c.Project,
+		Location:       c.Location,
+		Credentials:    c.Credentials,
+		BaseURL:        c.BaseURL,
+		Model:          c.Model,
+		Timeout:        time.Duration(c.Timeout) * time.Second,
+		MaxTokens:      c.MaxTokens,
+		Temperature:    c.Temperature,
+		TopP:           c.TopP,
+		Stop:           c.Stop,
+		ThinkingBudget: c.ThinkingBudget,
+	})
+}
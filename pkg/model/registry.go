@@ -3,7 +3,10 @@ package model
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 type ProviderFactory func(config ProviderConfig) (Provider, error)
@@ -11,15 +14,19 @@ type ProviderFactory func(config ProviderConfig) (Provider, error)
 type Registry struct {
 	mu        sync.RWMutex
 	providers map[string]Provider
+	configs   map[string]ProviderConfig
 	factories map[string]ProviderFactory
 	infos     map[string]*ProviderInfo
 	models    map[string]*ModelInfo
 	defaultID string
+
+	middlewares []Middleware
 }
 
 func NewRegistry() *Registry {
 	return &Registry{
 		providers: make(map[string]Provider),
+		configs:   make(map[string]ProviderConfig),
 		factories: make(map[string]ProviderFactory),
 		infos:     make(map[string]*ProviderInfo),
 		models:    make(map[string]*ModelInfo),
@@ -32,6 +39,14 @@ func (r *Registry) RegisterFactory(id string, factory ProviderFactory) {
 	r.factories[id] = factory
 }
 
+// Use appends middlewares that CreateProvider applies to every provider it
+// builds from now on.
+func (r *Registry) Use(mws ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append(r.middlewares, mws...)
+}
+
 func (r *Registry) RegisterProvider(id string, provider Provider) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -71,24 +86,151 @@ func (r *Registry) GetModel(providerID, modelID string) (*ModelInfo, bool) {
 	return model, ok
 }
 
+// CreateProvider builds a provider with the factory registered for
+// config.Type, or for config.ID when no type is given, and registers it
+// under config.ID.
 func (r *Registry) CreateProvider(config ProviderConfig) (Provider, error) {
+	factoryID := config.Type
+	if factoryID == "" {
+		factoryID = config.ID
+	}
+
 	r.mu.RLock()
-	factory, ok := r.factories[config.ID]
+	factory, ok := r.factories[factoryID]
+	mws := append([]Middleware(nil), r.middlewares...)
 	r.mu.RUnlock()
 
 	if !ok {
-		return nil, fmt.Errorf("provider factory not found: %s", config.ID)
+		return nil, fmt.Errorf("provider factory not found: %s", factoryID)
 	}
 
 	provider, err := factory(config)
 	if err != nil {
 		return nil, err
 	}
+	if config.CacheTTL > 0 {
+		mws = append(mws, CacheMiddleware(time.Duration(config.CacheTTL)*time.Second))
+	}
+	provider = Wrap(provider, mws...)
 
 	r.RegisterProvider(config.ID, provider)
+	r.mu.Lock()
+	r.configs[config.ID] = config
+	r.mu.Unlock()
 	return provider, nil
 }
 
+// Resolve maps a model reference to a provider and the model ID to send it.
+// "providerID/modelID" selects a registered provider, and "providerID/" its
+// configured model. Anything else, including model IDs that themselves
+// contain a slash, goes to the default provider unchanged.
+func (r *Registry) Resolve(ref string) (Provider, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if providerID, modelID, ok := strings.Cut(ref, "/"); ok {
+		if p, ok := r.providers[providerID]; ok {
+			if modelID == "" {
+				modelID = r.configs[providerID].Model
+			}
+			return p, modelID, nil
+		}
+	}
+
+	p, ok := r.providers[r.defaultID]
+	if !ok {
+		return nil, "", fmt.Errorf("no provider registered for model %q", ref)
+	}
+	return p, ref, nil
+}
+
+// LookupModel finds the catalog entry for a model reference by the type of
+// the provider it resolves to.
+func (r *Registry) LookupModel(ref string) (*ModelInfo, bool) {
+	r.mu.RLock()
+	providerID, modelID, ok := strings.Cut(ref, "/")
+	if _, registered := r.providers[providerID]; !ok || !registered {
+		providerID, modelID = r.defaultID, ref
+	}
+	config := r.configs[providerID]
+	r.mu.RUnlock()
+
+	if modelID == "" {
+		modelID = config.Model
+	}
+	return DefaultModelDatabase().GetModel(CatalogProviderID(config.Type), modelID)
+}
+
+// CatalogProviderID returns the catalog provider ID of the models a provider
+// type serves, which differs from the type for some providers.
+func CatalogProviderID(typ string) string {
+	switch typ {
+	case "vertex":
+		return "google-vertex"
+	case "together":
+		return "togetherai"
+	}
+	return typ
+}
+
+// Router returns a provider that dispatches each request to the provider
+// named by its model reference (see Resolve).
+func (r *Registry) Router() Provider {
+	route := func(req *Request) (Provider, *Request, error) {
+		p, modelID, err := r.Resolve(req.Model)
+		if err != nil {
+			return nil, nil, err
+		}
+		routed := *req
+		routed.Model = modelID
+		return p, &routed, nil
+	}
+	return ProviderFuncs{
+		SendFunc: func(ctx context.Context, req *Request) (*Response, error) {
+			p, routed, err := route(req)
+			if err != nil {
+				return nil, err
+			}
+			return p.Send(ctx, routed)
+		},
+		SendStreamFunc: func(ctx context.Context, req *Request) (<-chan *ResponseEvent, error) {
+			p, routed, err := route(req)
+			if err != nil {
+				return nil, err
+			}
+			return p.SendStream(ctx, routed)
+		},
+	}
+}
+
+// ModelRefs lists "providerID/modelID" for the configured model of every
+// provider created from config and for the catalog models of its type,
+// sorted.
+func (r *Registry) ModelRefs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	for id, config := range r.configs {
+		if config.Model != "" {
+			seen[id+"/"+config.Model] = true
+		}
+		if config.Type == "" {
+			continue
+		}
+		for _, m := range DefaultModelDatabase().ListModels(CatalogProviderID(config.Type)) {
+			seen[id+"/"+m.ID] = true
+		}
+	}
+
+	refs := make([]string, 0, len(seen))
+	for ref := range seen {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
 func (r *Registry) DefaultProvider() (Provider, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -153,6 +295,10 @@ func RegisterFactory(id string, factory ProviderFactory) {
 	globalRegistry.RegisterFactory(id, factory)
 }
 
+func Use(mws ...Middleware) {
+	globalRegistry.Use(mws...)
+}
+
 func RegisterProvider(id string, provider Provider) {
 	globalRegistry.RegisterProvider(id, provider)
 }
@@ -164,3 +310,7 @@ func GetProvider(id string) (Provider, bool) {
 func CreateProvider(config ProviderConfig) (Provider, error) {
 	return globalRegistry.CreateProvider(config)
 }
+
+func Resolve(ref string) (Provider, string, error) {
+	return globalRegistry.Resolve(ref)
+}
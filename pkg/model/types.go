@@ -38,6 +38,90 @@ type Request struct {
 	Messages []Message `json:"messages"`
 	Tools    []Tool    `json:"tools,omitempty"`
 	Stream   bool      `json:"stream"`
+
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"top_p,omitempty"`
+	MaxTokens       int      `json:"max_tokens,omitempty"`
+	Stop            []string `json:"stop,omitempty"`
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"`
+
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// ResponseFormat asks for JSON output: any JSON object with type
+// "json_object", or one matching JSONSchema with type "json_schema".
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+type JSONSchema struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+	Strict      bool            `json:"strict,omitempty"`
+}
+
+// JSONSchemaFormat asks for output matching schema, named name.
+func JSONSchemaFormat(name string, schema json.RawMessage) *ResponseFormat {
+	return &ResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: &JSONSchema{Name: name, Schema: schema},
+	}
+}
+
+// Schema returns the schema the output must match; for "json_object" that is
+// any object.
+func (f *ResponseFormat) Schema() json.RawMessage {
+	if f.JSONSchema != nil && len(f.JSONSchema.Schema) > 0 {
+		return f.JSONSchema.Schema
+	}
+	return json.RawMessage(`{"type":"object"}`)
+}
+
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// RequestOptions are sampling and length settings that can be declared on a
+// provider config or in a model's catalog options and applied to requests.
+type RequestOptions struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"top_p,omitempty"`
+	MaxTokens       int      `json:"max_tokens,omitempty"`
+	Stop            []string `json:"stop,omitempty"`
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"`
+}
+
+// ApplyOptions fills in request fields that are still unset from opts, so
+// values set explicitly on the request always win.
+func (r *Request) ApplyOptions(opts RequestOptions) {
+	if r.Temperature == nil && opts.Temperature != nil {
+		r.Temperature = opts.Temperature
+	}
+	if r.TopP == nil && opts.TopP != nil {
+		r.TopP = opts.TopP
+	}
+	if r.MaxTokens == 0 && opts.MaxTokens > 0 {
+		r.MaxTokens = opts.MaxTokens
+	}
+	if len(r.Stop) == 0 && len(opts.Stop) > 0 {
+		r.Stop = opts.Stop
+	}
+	if r.ReasoningEffort == "" && opts.ReasoningEffort != "" {
+		r.ReasoningEffort = opts.ReasoningEffort
+	}
+}
+
+// ApplyCapabilities drops request fields the model does not accept, such as
+// the sampling parameters of reasoning models that reject them.
+func (r *Request) ApplyCapabilities(info *ModelInfo) {
+	if !info.Capabilities.Temperature {
+		r.Temperature = nil
+		r.TopP = nil
+	}
 }
 
 type Response struct {
@@ -62,12 +146,26 @@ type Usage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
+// EstimateTokens roughly approximates the prompt size of messages at four
+// characters per token, for providers that do not report usage.
+func EstimateTokens(messages []Message) int {
+	chars := 0
+	for _, m := range messages {
+		chars += len(m.Content)
+		for _, tc := range m.ToolCalls {
+			chars += len(tc.Function.Name) + len(tc.Function.Arguments)
+		}
+	}
+	return chars / 4
+}
+
 type StreamChunk struct {
 	ID      string         `json:"id"`
 	Object  string         `json:"object"`
 	Created int64          `json:"created"`
 	Model   string         `json:"model"`
 	Choices []StreamChoice `json:"choices"`
+	Usage   *Usage         `json:"usage,omitempty"`
 }
 
 type StreamChoice struct {
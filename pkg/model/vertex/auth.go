@@ -0,0 +1,266 @@
+package vertex
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	defaultTokenURI    = "https://oauth2.googleapis.com/token"
+	metadataTokenURL   = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	metadataProjectURL = "http://metadata.google.internal/computeMetadata/v1/project/project-id"
+
+	// tokenSlack renews tokens this long before they expire.
+	tokenSlack = time.Minute
+)
+
+// credentialsFile is a service account key or the authorized_user file
+// written by "gcloud auth application-default login".
+type credentialsFile struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	QuotaProject string `json:"quota_project_id"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// tokenSource hands out OAuth access tokens, fetching a new one when the
+// cached token is about to expire.
+type tokenSource struct {
+	client *http.Client
+	fetch  func(ctx context.Context, client *http.Client) (token string, expiresIn int, err error)
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (ts *tokenSource) Token(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.token != "" && time.Now().Before(ts.expires) {
+		return ts.token, nil
+	}
+	token, expiresIn, err := ts.fetch(ctx, ts.client)
+	if err != nil {
+		return "", fmt.Errorf("get access token: %w", err)
+	}
+	ts.token = token
+	ts.expires = time.Now().Add(time.Duration(expiresIn)*time.Second - tokenSlack)
+	return token, nil
+}
+
+// findCredentials resolves application default credentials: the given
+// service account key (a file path or the JSON itself), then
+// GOOGLE_APPLICATION_CREDENTIALS, then gcloud's application default
+// credentials file, then the metadata server of the Google Cloud machine nene
+// runs on. It also returns the project the credentials belong to, if known.
+func findCredentials(credentials string, client *http.Client) (*tokenSource, string, error) {
+	if credentials != "" {
+		data := []byte(credentials)
+		if !strings.HasPrefix(strings.TrimSpace(credentials), "{") {
+			var err error
+			if data, err = os.ReadFile(credentials); err != nil {
+				return nil, "", fmt.Errorf("read credentials: %w", err)
+			}
+		}
+		return fromJSON(data, client)
+	}
+
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("read GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+		}
+		return fromJSON(data, client)
+	}
+
+	if data, err := os.ReadFile(gcloudCredentialsPath()); err == nil {
+		return fromJSON(data, client)
+	}
+
+	ts := &tokenSource{client: client, fetch: metadataToken}
+	return ts, "", nil
+}
+
+func gcloudCredentialsPath() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gcloud", "application_default_credentials.json")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+}
+
+func fromJSON(data []byte, client *http.Client) (*tokenSource, string, error) {
+	var f credentialsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, "", fmt.Errorf("parse credentials: %w", err)
+	}
+	if f.TokenURI == "" {
+		f.TokenURI = defaultTokenURI
+	}
+
+	switch f.Type {
+	case "service_account":
+		key, err := parsePrivateKey(f.PrivateKey)
+		if err != nil {
+			return nil, "", err
+		}
+		ts := &tokenSource{client: client, fetch: func(ctx context.Context, client *http.Client) (string, int, error) {
+			assertion, err := signJWT(key, f.PrivateKeyID, f.ClientEmail, f.TokenURI)
+			if err != nil {
+				return "", 0, err
+			}
+			return exchangeToken(ctx, client, f.TokenURI, url.Values{
+				"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+				"assertion":  {assertion},
+			})
+		}}
+		return ts, f.ProjectID, nil
+	case "authorized_user":
+		ts := &tokenSource{client: client, fetch: func(ctx context.Context, client *http.Client) (string, int, error) {
+			return exchangeToken(ctx, client, f.TokenURI, url.Values{
+				"grant_type":    {"refresh_token"},
+				"client_id":     {f.ClientID},
+				"client_secret": {f.ClientSecret},
+				"refresh_token": {f.RefreshToken},
+			})
+		}}
+		return ts, f.QuotaProject, nil
+	}
+	return nil, "", fmt.Errorf("unsupported credentials type %q", f.Type)
+}
+
+func parsePrivateKey(s string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("credentials have no PEM private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+			return key, nil
+		}
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// signJWT builds the RS256-signed assertion a service account exchanges
+// for an access token.
+func signJWT(key *rsa.PrivateKey, keyID, email, audience string) (string, error) {
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   email,
+		"scope": cloudPlatformScope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	enc := base64.RawURLEncoding
+	signed := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("sign assertion: %w", err)
+	}
+	return signed + "." + enc.EncodeToString(sig), nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func exchangeToken(ctx context.Context, client *http.Client, tokenURI string, form url.Values) (string, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doTokenRequest(client, req)
+}
+
+func metadataToken(ctx context.Context, client *http.Client) (string, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataTokenURL, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	token, expiresIn, err := doTokenRequest(client, req)
+	if err != nil {
+		return "", 0, fmt.Errorf("no application default credentials found, and the metadata server is unavailable: %w", err)
+	}
+	return token, expiresIn, nil
+}
+
+func doTokenRequest(client *http.Client, req *http.Request) (string, int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return "", 0, fmt.Errorf("decode token response: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return "", 0, errors.New("token endpoint returned no access token")
+	}
+	return tr.AccessToken, tr.ExpiresIn, nil
+}
+
+// metadataProject asks the metadata server for the project of the machine
+// nene runs on.
+func metadataProject(ctx context.Context, client *http.Client) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataProjectURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return strings.TrimSpace(string(body)), err
+}
@@ -0,0 +1,457 @@
+// Package vertex is a model provider for Gemini models on Google Cloud
+// Vertex AI, authenticated with application default credentials or a
+// service account key instead of a Gemini API key.
+package vertex
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/model"
+)
+
+const defaultLocation = "us-central1"
+
+type Config struct {
+	Project  string
+	Location string
+	// Credentials is a service account key file or its JSON content. When
+	// empty, application default credentials are used.
+	Credentials string
+	// BaseURL overrides the regional endpoint, e.g. for a private endpoint.
+	BaseURL string
+	Model   string
+	Timeout time.Duration
+
+	MaxTokens   int
+	Temperature *float64
+	TopP        *float64
+	Stop        []string
+
+	// ThinkingBudget sets the thinking token budget of models that think
+	// when greater than zero.
+	ThinkingBudget int
+}
+
+type Provider struct {
+	config Config
+	client *http.Client
+	tokens *tokenSource
+
+	projectOnce sync.Once
+	projectErr  error
+}
+
+// NewProvider resolves the credentials right away, so a bad key file is
+// reported at startup; tokens are fetched on the first request.
+func NewProvider(config Config) (*Provider, error) {
+	if config.Location == "" {
+		config.Location = defaultLocation
+	}
+	client := model.NewHTTPClient(config.Timeout)
+	tokens, project, err := findCredentials(config.Credentials, client)
+	if err != nil {
+		return nil, err
+	}
+	if config.Project == "" {
+		config.Project = project
+	}
+	if config.BaseURL == "" {
+		host := config.Location + "-aiplatform.googleapis.com"
+		if config.Location == "global" {
+			host = "aiplatform.googleapis.com"
+		}
+		config.BaseURL = "https://" + host + "/v1"
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	return &Provider{config: config, client: client, tokens: tokens}, nil
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent   `json:"contents"`
+	SystemInstruction *geminiContent    `json:"systemInstruction,omitempty"`
+	Tools             []geminiTool      `json:"tools,omitempty"`
+	GenerationConfig  *generationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string            `json:"text,omitempty"`
+	Thought          bool              `json:"thought,omitempty"`
+	FunctionCall     *functionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *functionResponse `json:"functionResponse,omitempty"`
+}
+
+type functionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type functionResponse struct {
+	ID       string                 `json:"id,omitempty"`
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []functionDeclaration `json:"functionDeclarations"`
+}
+
+type functionDeclaration struct {
+	Name                 string          `json:"name"`
+	Description          string          `json:"description,omitempty"`
+	ParametersJSONSchema json.RawMessage `json:"parametersJsonSchema,omitempty"`
+}
+
+type generationConfig struct {
+	Temperature     *float64        `json:"temperature,omitempty"`
+	TopP            *float64        `json:"topP,omitempty"`
+	MaxOutputTokens int             `json:"maxOutputTokens,omitempty"`
+	StopSequences   []string        `json:"stopSequences,omitempty"`
+	ThinkingConfig  *thinkingConfig `json:"thinkingConfig,omitempty"`
+
+	ResponseMimeType   string          `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema json.RawMessage `json:"responseJsonSchema,omitempty"`
+}
+
+type thinkingConfig struct {
+	ThinkingBudget  int  `json:"thinkingBudget"`
+	IncludeThoughts bool `json:"includeThoughts"`
+}
+
+type geminiResponse struct {
+	ResponseID string `json:"responseId"`
+	ModelVer   string `json:"modelVersion"`
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+func (r *geminiResponse) usage() *model.Usage {
+	if r.UsageMetadata == nil {
+		return nil
+	}
+	u := r.UsageMetadata
+	return &model.Usage{
+		PromptTokens:     u.PromptTokenCount,
+		CompletionTokens: u.CandidatesTokenCount + u.ThoughtsTokenCount,
+		TotalTokens:      u.TotalTokenCount,
+	}
+}
+
+// prepare fills unset request fields from the provider config and the model
+// catalog, then converts the request.
+func (p *Provider) prepare(req *model.Request) *geminiRequest {
+	if req.Model == "" {
+		req.Model = p.config.Model
+	}
+	req.ApplyOptions(model.RequestOptions{
+		Temperature: p.config.Temperature,
+		TopP:        p.config.TopP,
+		MaxTokens:   p.config.MaxTokens,
+		Stop:        p.config.Stop,
+	})
+	info, known := model.DefaultModelDatabase().GetModel(model.CatalogProviderID("vertex"), req.Model)
+	if known {
+		req.ApplyOptions(info.RequestOptions())
+		req.ApplyCapabilities(info)
+	}
+
+	gr := convertRequest(req)
+	if p.config.ThinkingBudget > 0 && (!known || info.Capabilities.Reasoning) {
+		gr.GenerationConfig.ThinkingConfig = &thinkingConfig{
+			ThinkingBudget:  p.config.ThinkingBudget,
+			IncludeThoughts: true,
+		}
+	}
+	return gr
+}
+
+func convertRequest(req *model.Request) *geminiRequest {
+	gr := &geminiRequest{
+		Contents: make([]geminiContent, 0, len(req.Messages)),
+		GenerationConfig: &generationConfig{
+			Temperature:     req.Temperature,
+			TopP:            req.TopP,
+			MaxOutputTokens: req.MaxTokens,
+			StopSequences:   req.Stop,
+		},
+	}
+	if f := req.ResponseFormat; f != nil {
+		gr.GenerationConfig.ResponseMimeType = "application/json"
+		if f.JSONSchema != nil {
+			gr.GenerationConfig.ResponseJSONSchema = f.JSONSchema.Schema
+		}
+	}
+
+	// Gemini matches function responses by name, which tool messages only
+	// carry as the ID of the call they answer.
+	callNames := make(map[string]string)
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system":
+			gr.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: msg.Content}}}
+		case "user":
+			gr.Contents = append(gr.Contents, geminiContent{
+				Role:  "user",
+				Parts: []geminiPart{{Text: msg.Content}},
+			})
+		case "assistant":
+			var parts []geminiPart
+			if msg.Content != "" {
+				parts = append(parts, geminiPart{Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				callNames[tc.ID] = tc.Function.Name
+				args := json.RawMessage(tc.Function.Arguments)
+				if !json.Valid(args) {
+					args = json.RawMessage("{}")
+				}
+				parts = append(parts, geminiPart{FunctionCall: &functionCall{
+					Name: tc.Function.Name,
+					Args: args,
+				}})
+			}
+			if len(parts) == 0 {
+				continue
+			}
+			gr.Contents = append(gr.Contents, geminiContent{Role: "model", Parts: parts})
+		case "tool":
+			name := msg.Name
+			if name == "" {
+				name = callNames[msg.ToolCallID]
+			}
+			part := geminiPart{FunctionResponse: &functionResponse{
+				Name:     name,
+				Response: map[string]interface{}{"result": msg.Content},
+			}}
+			// Consecutive tool results belong in one user turn.
+			if n := len(gr.Contents); n > 0 && gr.Contents[n-1].Role == "user" && gr.Contents[n-1].Parts[0].FunctionResponse != nil {
+				gr.Contents[n-1].Parts = append(gr.Contents[n-1].Parts, part)
+				continue
+			}
+			gr.Contents = append(gr.Contents, geminiContent{Role: "user", Parts: []geminiPart{part}})
+		}
+	}
+
+	if len(req.Tools) > 0 {
+		decls := make([]functionDeclaration, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			decls = append(decls, functionDeclaration{
+				Name:                 tool.Function.Name,
+				Description:          tool.Function.Description,
+				ParametersJSONSchema: tool.Function.Parameters,
+			})
+		}
+		gr.Tools = []geminiTool{{FunctionDeclarations: decls}}
+	}
+	return gr
+}
+
+func (p *Provider) endpoint(modelID, method string) string {
+	return fmt.Sprintf("%s/projects/%s/locations/%s/publishers/google/models/%s:%s",
+		p.config.BaseURL, p.config.Project, p.config.Location, modelID, method)
+}
+
+// project fills in the project from the metadata server when neither the
+// config nor the credentials name one.
+func (p *Provider) project(ctx context.Context) error {
+	p.projectOnce.Do(func() {
+		if p.config.Project != "" {
+			return
+		}
+		project, err := metadataProject(ctx, p.client)
+		if err != nil || project == "" {
+			p.projectErr = errors.New("vertex: no project configured and none could be detected; set project in the provider config")
+			return
+		}
+		p.config.Project = project
+	})
+	return p.projectErr
+}
+
+func (p *Provider) post(ctx context.Context, url string, gr *geminiRequest) (*http.Response, error) {
+	if err := p.project(ctx); err != nil {
+		return nil, err
+	}
+	token, err := p.tokens.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(gr)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, model.NewAPIError(resp)
+	}
+	return resp, nil
+}
+
+func (p *Provider) Send(ctx context.Context, req *model.Request) (*model.Response, error) {
+	ctx, cancel := model.SendContext(ctx, p.config.Timeout)
+	defer cancel()
+	gr := p.prepare(req)
+	resp, err := p.post(ctx, p.endpoint(req.Model, "generateContent"), gr)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var gResp geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&gResp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return convertResponse(&gResp, req.Model), nil
+}
+
+func convertResponse(gResp *geminiResponse, modelID string) *model.Response {
+	resp := &model.Response{
+		ID:      gResp.ResponseID,
+		Model:   modelID,
+		Choices: make([]model.Choice, 1),
+	}
+	if u := gResp.usage(); u != nil {
+		resp.Usage = *u
+	}
+
+	msg := model.Message{Role: "assistant"}
+	finishReason := "stop"
+	if len(gResp.Candidates) > 0 {
+		for _, part := range gResp.Candidates[0].Content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				msg.ToolCalls = append(msg.ToolCalls, toToolCall(part.FunctionCall, len(msg.ToolCalls)))
+			case !part.Thought:
+				msg.Content += part.Text
+			}
+		}
+	}
+	if len(msg.ToolCalls) > 0 {
+		finishReason = "tool_calls"
+	}
+	resp.Choices[0] = model.Choice{Message: msg, FinishReason: finishReason}
+	return resp
+}
+
+// toToolCall converts a function call. Gemini does not always return call
+// IDs, so one is made up from the call's position when missing.
+func toToolCall(fc *functionCall, index int) model.ToolCall {
+	id := fc.ID
+	if id == "" {
+		id = fmt.Sprintf("call_%d_%d", time.Now().UnixNano(), index)
+	}
+	args := string(fc.Args)
+	if args == "" || args == "null" {
+		args = "{}"
+	}
+	return model.ToolCall{
+		ID:       id,
+		Type:     "function",
+		Function: model.FunctionCall{Name: fc.Name, Arguments: args},
+	}
+}
+
+func (p *Provider) SendStream(ctx context.Context, req *model.Request) (<-chan *model.ResponseEvent, error) {
+	gr := p.prepare(req)
+	resp, err := p.post(ctx, p.endpoint(req.Model, "streamGenerateContent")+"?alt=sse", gr)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *model.ResponseEvent, 100)
+	go p.readStream(resp.Body, ch)
+	return ch, nil
+}
+
+func (p *Provider) readStream(body io.ReadCloser, ch chan<- *model.ResponseEvent) {
+	defer body.Close()
+	defer close(ch)
+
+	var usage *model.Usage
+	calls := 0
+	finish := func() {
+		if usage != nil {
+			ch <- &model.ResponseEvent{Usage: usage}
+		}
+		reason := model.FinishReasonStop
+		if calls > 0 {
+			reason = model.FinishReasonToolCalls
+		}
+		ch <- &model.ResponseEvent{FinishReason: reason}
+	}
+
+	reader := bufio.NewReader(body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			finish()
+			return
+		}
+
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		line = bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+
+		var chunk geminiResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			continue
+		}
+		if u := chunk.usage(); u != nil {
+			usage = u
+		}
+		if len(chunk.Candidates) == 0 {
+			continue
+		}
+
+		for _, part := range chunk.Candidates[0].Content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				tc := toToolCall(part.FunctionCall, calls)
+				calls++
+				ch <- &model.ResponseEvent{ToolCall: &tc}
+			case part.Thought:
+				if part.Text != "" {
+					ch <- &model.ResponseEvent{Reasoning: part.Text}
+				}
+			case part.Text != "":
+				ch <- &model.ResponseEvent{Delta: part.Text}
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package redact masks secrets, such as API keys and tokens, in text that
+// is logged, stored, or shown in a chat.
+package redact
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces each secret found.
+const Mask = "[REDACTED]"
+
+// minSecretLen keeps short configured values, which would also match
+// ordinary words, from being masked.
+const minSecretLen = 8
+
+// builtin matches common key formats. Where a pattern has a group, the
+// group is kept and only the rest is masked.
+var builtin = []string{
+	`\bsk-(?:proj-|ant-[a-z0-9]+-)?[A-Za-z0-9_-]{20,}`,                           // OpenAI and Anthropic
+	`\bAIza[0-9A-Za-z_-]{35}`,                                                    // Google
+	`\b[0-9]{8,10}:[A-Za-z0-9_-]{35}\b`,                                          // Telegram bots
+	`\bgh[pousr]_[A-Za-z0-9]{36,}`,                                               // GitHub
+	`\bgithub_pat_[A-Za-z0-9_]{22,}`,                                             // GitHub fine-grained
+	`\bxox[abprs]-[A-Za-z0-9-]{10,}`,                                             // Slack
+	`\bAKIA[0-9A-Z]{16}\b`,                                                       // AWS access keys
+	`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`,            // JWTs
+	`(?i)(\bbearer\s+)[A-Za-z0-9._~+/-]{16,}=*`,                                  // Authorization headers
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`, // PEM keys
+}
+
+// Redactor masks configured secrets and anything matching its patterns. A
+// nil Redactor leaves text as it is, so callers can use it whether or not
+// redaction is on.
+type Redactor struct {
+	patterns []*regexp.Regexp
+
+	mu       sync.RWMutex
+	secrets  []string
+	replacer *strings.Replacer
+}
+
+// New returns a Redactor with the built-in patterns and the given ones,
+// which are regular expressions.
+func New(patterns ...string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range append(append([]string(nil), builtin...), patterns...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// AddSecrets masks each value wherever it appears, such as the bot token
+// and provider keys from the config. Values shorter than 8 characters are
+// ignored.
+func (r *Redactor) AddSecrets(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setSecrets(append(r.secrets, values...))
+}
+
+// SetSecrets replaces the masked values with the given ones, as on a config
+// reload, so rotated secrets stop being masked and none are kept twice.
+func (r *Redactor) SetSecrets(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setSecrets(values)
+}
+
+func (r *Redactor) setSecrets(values []string) {
+	seen := make(map[string]bool, len(values))
+	var secrets []string
+	for _, v := range values {
+		if len(v) >= minSecretLen && !seen[v] {
+			seen[v] = true
+			secrets = append(secrets, v)
+		}
+	}
+	// Longer secrets first, so one that contains another is masked whole.
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	r.secrets = secrets
+	r.replacer = nil
+	if len(secrets) > 0 {
+		pairs := make([]string, 0, 2*len(secrets))
+		for _, s := range secrets {
+			pairs = append(pairs, s, Mask)
+		}
+		r.replacer = strings.NewReplacer(pairs...)
+	}
+}
+
+// String returns s with every secret masked.
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	r.mu.RLock()
+	replacer := r.replacer
+	r.mu.RUnlock()
+	if replacer != nil {
+		s = replacer.Replace(s)
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, "${1}"+Mask)
+	}
+	return s
+}
+
+// Writer returns a writer that masks what it is given a line at a time
+// before passing it to w, so a secret split across writes is still found.
+// Text after the last newline is held until the next one.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	if r == nil {
+		return w
+	}
+	return &lineWriter{r: r, w: w}
+}
+
+type lineWriter struct {
+	r   *Redactor
+	w   io.Writer
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	lw.buf = append(lw.buf, p...)
+	i := bytes.LastIndexByte(lw.buf, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+	lines := string(lw.buf[:i+1])
+	lw.buf = append(lw.buf[:0], lw.buf[i+1:]...)
+	if _, err := io.WriteString(lw.w, lw.r.String(lines)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// RedirectStdout sends everything written to os.Stdout, such as the log
+// lines of fmt.Printf, through the redactor. restore puts the original
+// back once what was written has been passed on.
+func (r *Redactor) RedirectStdout() (restore func(), err error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	orig := os.Stdout
+	os.Stdout = pw
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		reader := bufio.NewReader(pr)
+		for {
+			line, err := reader.ReadString('\n')
+			if line != "" {
+				io.WriteString(orig, r.String(line))
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return func() {
+		os.Stdout = orig
+		pw.Close()
+		<-done
+		pr.Close()
+	}, nil
+}
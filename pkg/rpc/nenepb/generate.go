@@ -0,0 +1,5 @@
+// Package nenepb holds the protobuf messages and gRPC stubs generated from
+// nene.proto.
+package nenepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative nene.proto
@@ -0,0 +1,1080 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: nene.proto
+
+package nenepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ManageMemoryRequest_Action int32
+
+const (
+	ManageMemoryRequest_ACTION_UNSPECIFIED ManageMemoryRequest_Action = 0
+	// Store content under key, replacing an existing entry.
+	ManageMemoryRequest_ACTION_STORE ManageMemoryRequest_Action = 1
+	// Search for entries matching query.
+	ManageMemoryRequest_ACTION_RECALL ManageMemoryRequest_Action = 2
+	// Read the entry stored under key.
+	ManageMemoryRequest_ACTION_GET ManageMemoryRequest_Action = 3
+	// List entries, optionally of one category.
+	ManageMemoryRequest_ACTION_LIST ManageMemoryRequest_Action = 4
+	// Delete the entry stored under key.
+	ManageMemoryRequest_ACTION_FORGET ManageMemoryRequest_Action = 5
+)
+
+// Enum value maps for ManageMemoryRequest_Action.
+var (
+	ManageMemoryRequest_Action_name = map[int32]string{
+		0: "ACTION_UNSPECIFIED",
+		1: "ACTION_STORE",
+		2: "ACTION_RECALL",
+		3: "ACTION_GET",
+		4: "ACTION_LIST",
+		5: "ACTION_FORGET",
+	}
+	ManageMemoryRequest_Action_value = map[string]int32{
+		"ACTION_UNSPECIFIED": 0,
+		"ACTION_STORE":       1,
+		"ACTION_RECALL":      2,
+		"ACTION_GET":         3,
+		"ACTION_LIST":        4,
+		"ACTION_FORGET":      5,
+	}
+)
+
+func (x ManageMemoryRequest_Action) Enum() *ManageMemoryRequest_Action {
+	p := new(ManageMemoryRequest_Action)
+	*p = x
+	return p
+}
+
+func (x ManageMemoryRequest_Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ManageMemoryRequest_Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_nene_proto_enumTypes[0].Descriptor()
+}
+
+func (ManageMemoryRequest_Action) Type() protoreflect.EnumType {
+	return &file_nene_proto_enumTypes[0]
+}
+
+func (x ManageMemoryRequest_Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ManageMemoryRequest_Action.Descriptor instead.
+func (ManageMemoryRequest_Action) EnumDescriptor() ([]byte, []int) {
+	return file_nene_proto_rawDescGZIP(), []int{8, 0}
+}
+
+type SubmitMessageRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The chat to talk in; its session key is "grpc:<chat_id>".
+	ChatId string `protobuf:"bytes,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	// Who is talking, matched against grpc.allow_from and grpc.owners.
+	// Defaults to "grpc".
+	SenderId string `protobuf:"bytes,2,opt,name=sender_id,json=senderId,proto3" json:"sender_id,omitempty"`
+	Content  string `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Wait     bool   `protobuf:"varint,4,opt,name=wait,proto3" json:"wait,omitempty"`
+	// Sampling parameters for this message's turn only, overriding the
+	// chat's settings.
+	Temperature   *float64 `protobuf:"fixed64,5,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	TopP          *float64 `protobuf:"fixed64,6,opt,name=top_p,json=topP,proto3,oneof" json:"top_p,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitMessageRequest) Reset() {
+	*x = SubmitMessageRequest{}
+	mi := &file_nene_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitMessageRequest) ProtoMessage() {}
+
+func (x *SubmitMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nene_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitMessageRequest.ProtoReflect.Descriptor instead.
+func (*SubmitMessageRequest) Descriptor() ([]byte, []int) {
+	return file_nene_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubmitMessageRequest) GetChatId() string {
+	if x != nil {
+		return x.ChatId
+	}
+	return ""
+}
+
+func (x *SubmitMessageRequest) GetSenderId() string {
+	if x != nil {
+		return x.SenderId
+	}
+	return ""
+}
+
+func (x *SubmitMessageRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *SubmitMessageRequest) GetWait() bool {
+	if x != nil {
+		return x.Wait
+	}
+	return false
+}
+
+func (x *SubmitMessageRequest) GetTemperature() float64 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *SubmitMessageRequest) GetTopP() float64 {
+	if x != nil && x.TopP != nil {
+		return *x.TopP
+	}
+	return 0
+}
+
+type SubmitMessageResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	SessionKey string                 `protobuf:"bytes,1,opt,name=session_key,json=sessionKey,proto3" json:"session_key,omitempty"`
+	// The agent's reply, when wait was set.
+	Reply         string `protobuf:"bytes,2,opt,name=reply,proto3" json:"reply,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitMessageResponse) Reset() {
+	*x = SubmitMessageResponse{}
+	mi := &file_nene_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitMessageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitMessageResponse) ProtoMessage() {}
+
+func (x *SubmitMessageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_nene_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitMessageResponse.ProtoReflect.Descriptor instead.
+func (*SubmitMessageResponse) Descriptor() ([]byte, []int) {
+	return file_nene_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubmitMessageResponse) GetSessionKey() string {
+	if x != nil {
+		return x.SessionKey
+	}
+	return ""
+}
+
+func (x *SubmitMessageResponse) GetReply() string {
+	if x != nil {
+		return x.Reply
+	}
+	return ""
+}
+
+type StreamEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only stream events of this chat; all grpc chats when empty.
+	ChatId        string `protobuf:"bytes,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEventsRequest) Reset() {
+	*x = StreamEventsRequest{}
+	mi := &file_nene_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEventsRequest) ProtoMessage() {}
+
+func (x *StreamEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nene_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEventsRequest.ProtoReflect.Descriptor instead.
+func (*StreamEventsRequest) Descriptor() ([]byte, []int) {
+	return file_nene_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamEventsRequest) GetChatId() string {
+	if x != nil {
+		return x.ChatId
+	}
+	return ""
+}
+
+type Event struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ChatId     string                 `protobuf:"bytes,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	SessionKey string                 `protobuf:"bytes,2,opt,name=session_key,json=sessionKey,proto3" json:"session_key,omitempty"`
+	// A stream event type such as "text-delta", "tool-call", "plan", or
+	// "finish", or "message" for a message sent to the chat.
+	Type       string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Content    string `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	Delta      string `protobuf:"bytes,5,opt,name=delta,proto3" json:"delta,omitempty"`
+	ToolName   string `protobuf:"bytes,6,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	ToolCallId string `protobuf:"bytes,7,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
+	// Tool arguments as a JSON object.
+	ToolArgs     string      `protobuf:"bytes,8,opt,name=tool_args,json=toolArgs,proto3" json:"tool_args,omitempty"`
+	ToolResult   string      `protobuf:"bytes,9,opt,name=tool_result,json=toolResult,proto3" json:"tool_result,omitempty"`
+	Error        string      `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	Iteration    int32       `protobuf:"varint,11,opt,name=iteration,proto3" json:"iteration,omitempty"`
+	Label        string      `protobuf:"bytes,12,opt,name=label,proto3" json:"label,omitempty"`
+	Status       string      `protobuf:"bytes,13,opt,name=status,proto3" json:"status,omitempty"`
+	ApprovalId   string      `protobuf:"bytes,14,opt,name=approval_id,json=approvalId,proto3" json:"approval_id,omitempty"`
+	ApprovalRule string      `protobuf:"bytes,15,opt,name=approval_rule,json=approvalRule,proto3" json:"approval_rule,omitempty"`
+	Plan         []*PlanStep `protobuf:"bytes,16,rep,name=plan,proto3" json:"plan,omitempty"`
+	// Files attached to a message, as paths on the nene host.
+	Media      []string `protobuf:"bytes,17,rep,name=media,proto3" json:"media,omitempty"`
+	TimeUnixMs int64    `protobuf:"varint,18,opt,name=time_unix_ms,json=timeUnixMs,proto3" json:"time_unix_ms,omitempty"`
+	// Numbers the events of a chat from 1 up in the order they were queued;
+	// a gap means events were dropped. Message events have no number.
+	Seq uint64 `protobuf:"varint,19,opt,name=seq,proto3" json:"seq,omitempty"`
+	// The same for every event of one turn.
+	TurnId        string `protobuf:"bytes,20,opt,name=turn_id,json=turnId,proto3" json:"turn_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_nene_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_nene_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_nene_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Event) GetChatId() string {
+	if x != nil {
+		return x.ChatId
+	}
+	return ""
+}
+
+func (x *Event) GetSessionKey() string {
+	if x != nil {
+		return x.SessionKey
+	}
+	return ""
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Event) GetDelta() string {
+	if x != nil {
+		return x.Delta
+	}
+	return ""
+}
+
+func (x *Event) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+func (x *Event) GetToolCallId() string {
+	if x != nil {
+		return x.ToolCallId
+	}
+	return ""
+}
+
+func (x *Event) GetToolArgs() string {
+	if x != nil {
+		return x.ToolArgs
+	}
+	return ""
+}
+
+func (x *Event) GetToolResult() string {
+	if x != nil {
+		return x.ToolResult
+	}
+	return ""
+}
+
+func (x *Event) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Event) GetIteration() int32 {
+	if x != nil {
+		return x.Iteration
+	}
+	return 0
+}
+
+func (x *Event) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Event) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Event) GetApprovalId() string {
+	if x != nil {
+		return x.ApprovalId
+	}
+	return ""
+}
+
+func (x *Event) GetApprovalRule() string {
+	if x != nil {
+		return x.ApprovalRule
+	}
+	return ""
+}
+
+func (x *Event) GetPlan() []*PlanStep {
+	if x != nil {
+		return x.Plan
+	}
+	return nil
+}
+
+func (x *Event) GetMedia() []string {
+	if x != nil {
+		return x.Media
+	}
+	return nil
+}
+
+func (x *Event) GetTimeUnixMs() int64 {
+	if x != nil {
+		return x.TimeUnixMs
+	}
+	return 0
+}
+
+func (x *Event) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Event) GetTurnId() string {
+	if x != nil {
+		return x.TurnId
+	}
+	return ""
+}
+
+type PlanStep struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanStep) Reset() {
+	*x = PlanStep{}
+	mi := &file_nene_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanStep) ProtoMessage() {}
+
+func (x *PlanStep) ProtoReflect() protoreflect.Message {
+	mi := &file_nene_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanStep.ProtoReflect.Descriptor instead.
+func (*PlanStep) Descriptor() ([]byte, []int) {
+	return file_nene_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PlanStep) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *PlanStep) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type ListSessionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	mi := &file_nene_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nene_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_nene_proto_rawDescGZIP(), []int{5}
+}
+
+type ListSessionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*Session             `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	mi := &file_nene_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsResponse) ProtoMessage() {}
+
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_nene_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_nene_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListSessionsResponse) GetSessions() []*Session {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type Session struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Persona       string                 `protobuf:"bytes,2,opt,name=persona,proto3" json:"persona,omitempty"`
+	Model         string                 `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	Messages      int32                  `protobuf:"varint,4,opt,name=messages,proto3" json:"messages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	mi := &file_nene_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_nene_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_nene_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Session) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Session) GetPersona() string {
+	if x != nil {
+		return x.Persona
+	}
+	return ""
+}
+
+func (x *Session) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *Session) GetMessages() int32 {
+	if x != nil {
+		return x.Messages
+	}
+	return 0
+}
+
+type ManageMemoryRequest struct {
+	state   protoimpl.MessageState     `protogen:"open.v1"`
+	Action  ManageMemoryRequest_Action `protobuf:"varint,1,opt,name=action,proto3,enum=nene.v1.ManageMemoryRequest_Action" json:"action,omitempty"`
+	Key     string                     `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Content string                     `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	// "core", "daily", "conversation", or a custom category.
+	Category      string `protobuf:"bytes,4,opt,name=category,proto3" json:"category,omitempty"`
+	Query         string `protobuf:"bytes,5,opt,name=query,proto3" json:"query,omitempty"`
+	Limit         int32  `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ManageMemoryRequest) Reset() {
+	*x = ManageMemoryRequest{}
+	mi := &file_nene_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ManageMemoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ManageMemoryRequest) ProtoMessage() {}
+
+func (x *ManageMemoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nene_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ManageMemoryRequest.ProtoReflect.Descriptor instead.
+func (*ManageMemoryRequest) Descriptor() ([]byte, []int) {
+	return file_nene_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ManageMemoryRequest) GetAction() ManageMemoryRequest_Action {
+	if x != nil {
+		return x.Action
+	}
+	return ManageMemoryRequest_ACTION_UNSPECIFIED
+}
+
+func (x *ManageMemoryRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ManageMemoryRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *ManageMemoryRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *ManageMemoryRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *ManageMemoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ManageMemoryResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Entries []*MemoryEntry         `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	// Whether ACTION_FORGET found an entry to delete.
+	Forgotten     bool `protobuf:"varint,2,opt,name=forgotten,proto3" json:"forgotten,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ManageMemoryResponse) Reset() {
+	*x = ManageMemoryResponse{}
+	mi := &file_nene_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ManageMemoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ManageMemoryResponse) ProtoMessage() {}
+
+func (x *ManageMemoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_nene_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ManageMemoryResponse.ProtoReflect.Descriptor instead.
+func (*ManageMemoryResponse) Descriptor() ([]byte, []int) {
+	return file_nene_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ManageMemoryResponse) GetEntries() []*MemoryEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *ManageMemoryResponse) GetForgotten() bool {
+	if x != nil {
+		return x.Forgotten
+	}
+	return false
+}
+
+type MemoryEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Category      string                 `protobuf:"bytes,4,opt,name=category,proto3" json:"category,omitempty"`
+	SessionId     string                 `protobuf:"bytes,5,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Score         float64                `protobuf:"fixed64,6,opt,name=score,proto3" json:"score,omitempty"`
+	CreatedUnixMs int64                  `protobuf:"varint,7,opt,name=created_unix_ms,json=createdUnixMs,proto3" json:"created_unix_ms,omitempty"`
+	UpdatedUnixMs int64                  `protobuf:"varint,8,opt,name=updated_unix_ms,json=updatedUnixMs,proto3" json:"updated_unix_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MemoryEntry) Reset() {
+	*x = MemoryEntry{}
+	mi := &file_nene_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MemoryEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemoryEntry) ProtoMessage() {}
+
+func (x *MemoryEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_nene_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemoryEntry.ProtoReflect.Descriptor instead.
+func (*MemoryEntry) Descriptor() ([]byte, []int) {
+	return file_nene_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *MemoryEntry) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *MemoryEntry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *MemoryEntry) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *MemoryEntry) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *MemoryEntry) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *MemoryEntry) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *MemoryEntry) GetCreatedUnixMs() int64 {
+	if x != nil {
+		return x.CreatedUnixMs
+	}
+	return 0
+}
+
+func (x *MemoryEntry) GetUpdatedUnixMs() int64 {
+	if x != nil {
+		return x.UpdatedUnixMs
+	}
+	return 0
+}
+
+var File_nene_proto protoreflect.FileDescriptor
+
+const file_nene_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"nene.proto\x12\anene.v1\"\xd5\x01\n" +
+	"\x14SubmitMessageRequest\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\tR\x06chatId\x12\x1b\n" +
+	"\tsender_id\x18\x02 \x01(\tR\bsenderId\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12\x12\n" +
+	"\x04wait\x18\x04 \x01(\bR\x04wait\x12%\n" +
+	"\vtemperature\x18\x05 \x01(\x01H\x00R\vtemperature\x88\x01\x01\x12\x18\n" +
+	"\x05top_p\x18\x06 \x01(\x01H\x01R\x04topP\x88\x01\x01B\x0e\n" +
+	"\f_temperatureB\b\n" +
+	"\x06_top_p\"N\n" +
+	"\x15SubmitMessageResponse\x12\x1f\n" +
+	"\vsession_key\x18\x01 \x01(\tR\n" +
+	"sessionKey\x12\x14\n" +
+	"\x05reply\x18\x02 \x01(\tR\x05reply\".\n" +
+	"\x13StreamEventsRequest\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\tR\x06chatId\"\xb4\x04\n" +
+	"\x05Event\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\tR\x06chatId\x12\x1f\n" +
+	"\vsession_key\x18\x02 \x01(\tR\n" +
+	"sessionKey\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x18\n" +
+	"\acontent\x18\x04 \x01(\tR\acontent\x12\x14\n" +
+	"\x05delta\x18\x05 \x01(\tR\x05delta\x12\x1b\n" +
+	"\ttool_name\x18\x06 \x01(\tR\btoolName\x12 \n" +
+	"\ftool_call_id\x18\a \x01(\tR\n" +
+	"toolCallId\x12\x1b\n" +
+	"\ttool_args\x18\b \x01(\tR\btoolArgs\x12\x1f\n" +
+	"\vtool_result\x18\t \x01(\tR\n" +
+	"toolResult\x12\x14\n" +
+	"\x05error\x18\n" +
+	" \x01(\tR\x05error\x12\x1c\n" +
+	"\titeration\x18\v \x01(\x05R\titeration\x12\x14\n" +
+	"\x05label\x18\f \x01(\tR\x05label\x12\x16\n" +
+	"\x06status\x18\r \x01(\tR\x06status\x12\x1f\n" +
+	"\vapproval_id\x18\x0e \x01(\tR\n" +
+	"approvalId\x12#\n" +
+	"\rapproval_rule\x18\x0f \x01(\tR\fapprovalRule\x12%\n" +
+	"\x04plan\x18\x10 \x03(\v2\x11.nene.v1.PlanStepR\x04plan\x12\x14\n" +
+	"\x05media\x18\x11 \x03(\tR\x05media\x12 \n" +
+	"\ftime_unix_ms\x18\x12 \x01(\x03R\n" +
+	"timeUnixMs\x12\x10\n" +
+	"\x03seq\x18\x13 \x01(\x04R\x03seq\x12\x17\n" +
+	"\aturn_id\x18\x14 \x01(\tR\x06turnId\"8\n" +
+	"\bPlanStep\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"\x15\n" +
+	"\x13ListSessionsRequest\"D\n" +
+	"\x14ListSessionsResponse\x12,\n" +
+	"\bsessions\x18\x01 \x03(\v2\x10.nene.v1.SessionR\bsessions\"g\n" +
+	"\aSession\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\apersona\x18\x02 \x01(\tR\apersona\x12\x14\n" +
+	"\x05model\x18\x03 \x01(\tR\x05model\x12\x1a\n" +
+	"\bmessages\x18\x04 \x01(\x05R\bmessages\"\xc1\x02\n" +
+	"\x13ManageMemoryRequest\x12;\n" +
+	"\x06action\x18\x01 \x01(\x0e2#.nene.v1.ManageMemoryRequest.ActionR\x06action\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12\x1a\n" +
+	"\bcategory\x18\x04 \x01(\tR\bcategory\x12\x14\n" +
+	"\x05query\x18\x05 \x01(\tR\x05query\x12\x14\n" +
+	"\x05limit\x18\x06 \x01(\x05R\x05limit\"y\n" +
+	"\x06Action\x12\x16\n" +
+	"\x12ACTION_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fACTION_STORE\x10\x01\x12\x11\n" +
+	"\rACTION_RECALL\x10\x02\x12\x0e\n" +
+	"\n" +
+	"ACTION_GET\x10\x03\x12\x0f\n" +
+	"\vACTION_LIST\x10\x04\x12\x11\n" +
+	"\rACTION_FORGET\x10\x05\"d\n" +
+	"\x14ManageMemoryResponse\x12.\n" +
+	"\aentries\x18\x01 \x03(\v2\x14.nene.v1.MemoryEntryR\aentries\x12\x1c\n" +
+	"\tforgotten\x18\x02 \x01(\bR\tforgotten\"\xea\x01\n" +
+	"\vMemoryEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12\x1a\n" +
+	"\bcategory\x18\x04 \x01(\tR\bcategory\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x05 \x01(\tR\tsessionId\x12\x14\n" +
+	"\x05score\x18\x06 \x01(\x01R\x05score\x12&\n" +
+	"\x0fcreated_unix_ms\x18\a \x01(\x03R\rcreatedUnixMs\x12&\n" +
+	"\x0fupdated_unix_ms\x18\b \x01(\x03R\rupdatedUnixMs2\xb0\x02\n" +
+	"\x04Nene\x12N\n" +
+	"\rSubmitMessage\x12\x1d.nene.v1.SubmitMessageRequest\x1a\x1e.nene.v1.SubmitMessageResponse\x12>\n" +
+	"\fStreamEvents\x12\x1c.nene.v1.StreamEventsRequest\x1a\x0e.nene.v1.Event0\x01\x12K\n" +
+	"\fListSessions\x12\x1c.nene.v1.ListSessionsRequest\x1a\x1d.nene.v1.ListSessionsResponse\x12K\n" +
+	"\fManageMemory\x12\x1c.nene.v1.ManageMemoryRequest\x1a\x1d.nene.v1.ManageMemoryResponseB2Z0github.com/nene-agent/nene/pkg/rpc/nenepb;nenepbb\x06proto3"
+
+var (
+	file_nene_proto_rawDescOnce sync.Once
+	file_nene_proto_rawDescData []byte
+)
+
+func file_nene_proto_rawDescGZIP() []byte {
+	file_nene_proto_rawDescOnce.Do(func() {
+		file_nene_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_nene_proto_rawDesc), len(file_nene_proto_rawDesc)))
+	})
+	return file_nene_proto_rawDescData
+}
+
+var file_nene_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_nene_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_nene_proto_goTypes = []any{
+	(ManageMemoryRequest_Action)(0), // 0: nene.v1.ManageMemoryRequest.Action
+	(*SubmitMessageRequest)(nil),    // 1: nene.v1.SubmitMessageRequest
+	(*SubmitMessageResponse)(nil),   // 2: nene.v1.SubmitMessageResponse
+	(*StreamEventsRequest)(nil),     // 3: nene.v1.StreamEventsRequest
+	(*Event)(nil),                   // 4: nene.v1.Event
+	(*PlanStep)(nil),                // 5: nene.v1.PlanStep
+	(*ListSessionsRequest)(nil),     // 6: nene.v1.ListSessionsRequest
+	(*ListSessionsResponse)(nil),    // 7: nene.v1.ListSessionsResponse
+	(*Session)(nil),                 // 8: nene.v1.Session
+	(*ManageMemoryRequest)(nil),     // 9: nene.v1.ManageMemoryRequest
+	(*ManageMemoryResponse)(nil),    // 10: nene.v1.ManageMemoryResponse
+	(*MemoryEntry)(nil),             // 11: nene.v1.MemoryEntry
+}
+var file_nene_proto_depIdxs = []int32{
+	5,  // 0: nene.v1.Event.plan:type_name -> nene.v1.PlanStep
+	8,  // 1: nene.v1.ListSessionsResponse.sessions:type_name -> nene.v1.Session
+	0,  // 2: nene.v1.ManageMemoryRequest.action:type_name -> nene.v1.ManageMemoryRequest.Action
+	11, // 3: nene.v1.ManageMemoryResponse.entries:type_name -> nene.v1.MemoryEntry
+	1,  // 4: nene.v1.Nene.SubmitMessage:input_type -> nene.v1.SubmitMessageRequest
+	3,  // 5: nene.v1.Nene.StreamEvents:input_type -> nene.v1.StreamEventsRequest
+	6,  // 6: nene.v1.Nene.ListSessions:input_type -> nene.v1.ListSessionsRequest
+	9,  // 7: nene.v1.Nene.ManageMemory:input_type -> nene.v1.ManageMemoryRequest
+	2,  // 8: nene.v1.Nene.SubmitMessage:output_type -> nene.v1.SubmitMessageResponse
+	4,  // 9: nene.v1.Nene.StreamEvents:output_type -> nene.v1.Event
+	7,  // 10: nene.v1.Nene.ListSessions:output_type -> nene.v1.ListSessionsResponse
+	10, // 11: nene.v1.Nene.ManageMemory:output_type -> nene.v1.ManageMemoryResponse
+	8,  // [8:12] is the sub-list for method output_type
+	4,  // [4:8] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_nene_proto_init() }
+func file_nene_proto_init() {
+	if File_nene_proto != nil {
+		return
+	}
+	file_nene_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_nene_proto_rawDesc), len(file_nene_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_nene_proto_goTypes,
+		DependencyIndexes: file_nene_proto_depIdxs,
+		EnumInfos:         file_nene_proto_enumTypes,
+		MessageInfos:      file_nene_proto_msgTypes,
+	}.Build()
+	File_nene_proto = out.File
+	file_nene_proto_goTypes = nil
+	file_nene_proto_depIdxs = nil
+}
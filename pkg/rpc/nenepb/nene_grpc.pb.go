@@ -0,0 +1,263 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: nene.proto
+
+package nenepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Nene_SubmitMessage_FullMethodName = "/nene.v1.Nene/SubmitMessage"
+	Nene_StreamEvents_FullMethodName  = "/nene.v1.Nene/StreamEvents"
+	Nene_ListSessions_FullMethodName  = "/nene.v1.Nene/ListSessions"
+	Nene_ManageMemory_FullMethodName  = "/nene.v1.Nene/ManageMemory"
+)
+
+// NeneClient is the client API for Nene service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Nene drives the agent from another program. Messages are sent as chats of
+// the "grpc" channel, so they get sessions, tools, approvals, and rate limits
+// like chats on any other channel.
+type NeneClient interface {
+	// SubmitMessage sends a message to the agent. With wait set, the call
+	// returns once the turn is over, with the agent's reply.
+	SubmitMessage(ctx context.Context, in *SubmitMessageRequest, opts ...grpc.CallOption) (*SubmitMessageResponse, error)
+	// StreamEvents streams the events of the agent's turns in grpc chats as
+	// they happen: text deltas, tool calls and results, plans, approval
+	// requests, and messages sent to the chat.
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// ListSessions lists the agent's sessions on every channel.
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	// ManageMemory stores, recalls, lists, reads, or forgets long-term
+	// memories.
+	ManageMemory(ctx context.Context, in *ManageMemoryRequest, opts ...grpc.CallOption) (*ManageMemoryResponse, error)
+}
+
+type neneClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNeneClient(cc grpc.ClientConnInterface) NeneClient {
+	return &neneClient{cc}
+}
+
+func (c *neneClient) SubmitMessage(ctx context.Context, in *SubmitMessageRequest, opts ...grpc.CallOption) (*SubmitMessageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitMessageResponse)
+	err := c.cc.Invoke(ctx, Nene_SubmitMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *neneClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Nene_ServiceDesc.Streams[0], Nene_StreamEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Nene_StreamEventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *neneClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, Nene_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *neneClient) ManageMemory(ctx context.Context, in *ManageMemoryRequest, opts ...grpc.CallOption) (*ManageMemoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ManageMemoryResponse)
+	err := c.cc.Invoke(ctx, Nene_ManageMemory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NeneServer is the server API for Nene service.
+// All implementations must embed UnimplementedNeneServer
+// for forward compatibility.
+//
+// Nene drives the agent from another program. Messages are sent as chats of
+// the "grpc" channel, so they get sessions, tools, approvals, and rate limits
+// like chats on any other channel.
+type NeneServer interface {
+	// SubmitMessage sends a message to the agent. With wait set, the call
+	// returns once the turn is over, with the agent's reply.
+	SubmitMessage(context.Context, *SubmitMessageRequest) (*SubmitMessageResponse, error)
+	// StreamEvents streams the events of the agent's turns in grpc chats as
+	// they happen: text deltas, tool calls and results, plans, approval
+	// requests, and messages sent to the chat.
+	StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error
+	// ListSessions lists the agent's sessions on every channel.
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	// ManageMemory stores, recalls, lists, reads, or forgets long-term
+	// memories.
+	ManageMemory(context.Context, *ManageMemoryRequest) (*ManageMemoryResponse, error)
+	mustEmbedUnimplementedNeneServer()
+}
+
+// UnimplementedNeneServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNeneServer struct{}
+
+func (UnimplementedNeneServer) SubmitMessage(context.Context, *SubmitMessageRequest) (*SubmitMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitMessage not implemented")
+}
+func (UnimplementedNeneServer) StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedNeneServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedNeneServer) ManageMemory(context.Context, *ManageMemoryRequest) (*ManageMemoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ManageMemory not implemented")
+}
+func (UnimplementedNeneServer) mustEmbedUnimplementedNeneServer() {}
+func (UnimplementedNeneServer) testEmbeddedByValue()              {}
+
+// UnsafeNeneServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NeneServer will
+// result in compilation errors.
+type UnsafeNeneServer interface {
+	mustEmbedUnimplementedNeneServer()
+}
+
+func RegisterNeneServer(s grpc.ServiceRegistrar, srv NeneServer) {
+	// If the following call pancis, it indicates UnimplementedNeneServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Nene_ServiceDesc, srv)
+}
+
+func _Nene_SubmitMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NeneServer).SubmitMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Nene_SubmitMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NeneServer).SubmitMessage(ctx, req.(*SubmitMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Nene_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NeneServer).StreamEvents(m, &grpc.GenericServerStream[StreamEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Nene_StreamEventsServer = grpc.ServerStreamingServer[Event]
+
+func _Nene_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NeneServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Nene_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NeneServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Nene_ManageMemory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ManageMemoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NeneServer).ManageMemory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Nene_ManageMemory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NeneServer).ManageMemory(ctx, req.(*ManageMemoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Nene_ServiceDesc is the grpc.ServiceDesc for Nene service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Nene_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "nene.v1.Nene",
+	HandlerType: (*NeneServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitMessage",
+			Handler:    _Nene_SubmitMessage_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _Nene_ListSessions_Handler,
+		},
+		{
+			MethodName: "ManageMemory",
+			Handler:    _Nene_ManageMemory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _Nene_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "nene.proto",
+}
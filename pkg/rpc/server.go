@@ -0,0 +1,479 @@
+// Package rpc serves the gRPC API defined in nenepb/nene.proto, so other
+// programs can drive the agent the way chat channels do.
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/nene-agent/nene/pkg/agent"
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/channel"
+	"github.com/nene-agent/nene/pkg/memory"
+	"github.com/nene-agent/nene/pkg/rpc/nenepb"
+	"github.com/nene-agent/nene/pkg/tool"
+)
+
+// ChannelName is the channel gRPC chats belong to; their session keys are
+// "grpc:<chat id>".
+const ChannelName = "grpc"
+
+const defaultSender = "grpc"
+
+type Config struct {
+	// Listen is the address the API is served on, e.g. 127.0.0.1:8092.
+	Listen string `json:"listen"`
+	// Token is required of every call as "authorization: Bearer <token>"
+	// metadata.
+	Token     string   `json:"token"`
+	AllowFrom []string `json:"allow_from"`
+	Owners    []string `json:"owners"`
+}
+
+// Server is both the gRPC service and the "grpc" channel: submitted
+// messages enter the bus like chat messages, and the channel's stream and
+// outbound messages are passed on to StreamEvents subscribers and waiting
+// SubmitMessage calls. Register it with the channel manager.
+type Server struct {
+	nenepb.UnimplementedNeneServer
+	*channel.BaseChannel
+
+	config   Config
+	sessions *agent.SessionManager
+	mem      memory.Memory
+	replies  *channel.Replies
+
+	mu     sync.Mutex
+	subs   map[*subscriber]struct{}
+	turns  map[string][]*turn
+	srv    *grpc.Server
+	cancel context.CancelFunc
+}
+
+type subscriber struct {
+	chatID string
+	events chan *nenepb.Event
+}
+
+// turn is a submitted message whose reply has not finished yet. Turns of a
+// chat finish in the order they were submitted.
+type turn struct {
+	sent strings.Builder
+	done chan string
+}
+
+type Option func(*Server)
+
+func WithSessionManager(m *agent.SessionManager) Option {
+	return func(s *Server) { s.sessions = m }
+}
+
+func WithMemory(m memory.Memory) Option {
+	return func(s *Server) { s.mem = m }
+}
+
+func NewServer(cfg Config, messageBus *bus.MessageBus, opts ...Option) *Server {
+	base := channel.NewBaseChannel(ChannelName, messageBus, cfg.AllowFrom)
+	base.SetOwners(cfg.Owners)
+	s := &Server{
+		BaseChannel: base,
+		config:      cfg,
+		replies:     channel.NewReplies(),
+		subs:        make(map[*subscriber]struct{}),
+		turns:       make(map[string][]*turn),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	lis, err := net.Listen("tcp", s.config.Listen)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.config.Listen, err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(s.authUnary),
+		grpc.StreamInterceptor(s.authStream),
+	)
+	nenepb.RegisterNeneServer(srv, s)
+
+	s.mu.Lock()
+	s.srv = srv
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	s.ConsumeStream(ctx, s)
+	s.SetRunning(true)
+	fmt.Printf("gRPC API listening on %s\n", s.config.Listen)
+	go func() {
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			fmt.Printf("gRPC API error: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	srv, cancel := s.srv, s.cancel
+	s.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	cancel()
+	s.SetRunning(false)
+
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		srv.Stop()
+	}
+	return nil
+}
+
+func (s *Server) authUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) authStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize checks the "authorization: Bearer <token>" metadata when a
+// token is configured.
+func (s *Server) authorize(ctx context.Context) error {
+	if s.config.Token == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	got := ""
+	if v := md.Get("authorization"); len(v) > 0 {
+		got = v[0]
+	}
+	if subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+s.config.Token)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid or missing token")
+	}
+	return nil
+}
+
+func (s *Server) SubmitMessage(ctx context.Context, req *nenepb.SubmitMessageRequest) (*nenepb.SubmitMessageResponse, error) {
+	if req.ChatId == "" {
+		return nil, status.Error(codes.InvalidArgument, "chat_id is required")
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		return nil, status.Error(codes.InvalidArgument, "content is required")
+	}
+	sender := req.SenderId
+	if sender == "" {
+		sender = defaultSender
+	}
+	if !s.IsAllowed(sender) {
+		return nil, status.Errorf(codes.PermissionDenied, "sender %q is not allowed", sender)
+	}
+	md, err := samplingMetadata(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	resp := &nenepb.SubmitMessageResponse{SessionKey: ChannelName + ":" + req.ChatId}
+
+	// The turn is queued before the message is published so that its
+	// finish event cannot arrive first.
+	t := &turn{done: make(chan string, 1)}
+	s.mu.Lock()
+	s.turns[req.ChatId] = append(s.turns[req.ChatId], t)
+	s.mu.Unlock()
+
+	if !s.HandleMessage(sender, req.ChatId, req.Content, nil, md, true) {
+		s.dropTurn(req.ChatId, t)
+		// Approval answers are consumed by the channel and start no turn.
+		if d, ok := tool.ParseDecision(strings.Fields(req.Content)[0]); ok && d != "" {
+			return resp, nil
+		}
+		return nil, status.Error(codes.ResourceExhausted, "message was not accepted; the sender may be rate limited")
+	}
+	if !req.Wait {
+		return resp, nil
+	}
+
+	select {
+	case resp.Reply = <-t.done:
+		return resp, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// samplingMetadata passes a request's sampling parameters to the agent as
+// message metadata.
+func samplingMetadata(req *nenepb.SubmitMessageRequest) (map[string]string, error) {
+	var md map[string]string
+	for name, v := range map[string]*float64{agent.MetadataTemperature: req.Temperature, agent.MetadataTopP: req.TopP} {
+		if v == nil {
+			continue
+		}
+		if err := agent.ValidateSampling(name, *v); err != nil {
+			return nil, err
+		}
+		if md == nil {
+			md = make(map[string]string)
+		}
+		md[name] = strconv.FormatFloat(*v, 'g', -1, 64)
+	}
+	return md, nil
+}
+
+func (s *Server) dropTurn(chatID string, t *turn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	turns := s.turns[chatID]
+	for i, tt := range turns {
+		if tt == t {
+			s.turns[chatID] = append(turns[:i:i], turns[i+1:]...)
+			break
+		}
+	}
+	if len(s.turns[chatID]) == 0 {
+		delete(s.turns, chatID)
+	}
+}
+
+func (s *Server) StreamEvents(req *nenepb.StreamEventsRequest, stream grpc.ServerStreamingServer[nenepb.Event]) error {
+	sub := &subscriber{chatID: req.ChatId, events: make(chan *nenepb.Event, 256)}
+	s.mu.Lock()
+	s.subs[sub] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subs, sub)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev := <-sub.events:
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// broadcast passes an event to the subscribers of its chat. Subscribers
+// that fall behind lose events rather than stall the channel.
+func (s *Server) broadcast(ev *nenepb.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subs {
+		if sub.chatID != "" && sub.chatID != ev.ChatId {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+		}
+	}
+}
+
+// OnStreamEvent receives the channel's stream events.
+func (s *Server) OnStreamEvent(msg bus.StreamMessage) {
+	s.broadcast(streamEvent(msg))
+
+	text, done := s.replies.Collect(msg, s.Locale(msg.ChatID))
+	if !done {
+		return
+	}
+	if msg.Type == bus.StreamEventFinish {
+		s.FinishTurn(msg.ChatID)
+	}
+
+	s.mu.Lock()
+	turns := s.turns[msg.ChatID]
+	if len(turns) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	t := turns[0]
+	if len(turns) == 1 {
+		delete(s.turns, msg.ChatID)
+	} else {
+		s.turns[msg.ChatID] = turns[1:]
+	}
+	// Commands reply with an outbound message instead of streamed text.
+	if strings.TrimSpace(text) == "" {
+		text = t.sent.String()
+	}
+	s.mu.Unlock()
+	t.done <- text
+}
+
+// Send delivers outbound messages for grpc chats, such as command replies
+// and send_to messages, as "message" events.
+func (s *Server) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	s.mu.Lock()
+	if turns := s.turns[msg.ChatID]; len(turns) > 0 {
+		t := turns[0]
+		if t.sent.Len() > 0 {
+			t.sent.WriteString("\n\n")
+		}
+		t.sent.WriteString(msg.Content)
+	}
+	s.mu.Unlock()
+
+	s.broadcast(&nenepb.Event{
+		ChatId:     msg.ChatID,
+		SessionKey: ChannelName + ":" + msg.ChatID,
+		Type:       "message",
+		Content:    msg.Content,
+		Media:      msg.Media,
+	})
+	return nil
+}
+
+func streamEvent(msg bus.StreamMessage) *nenepb.Event {
+	ev := &nenepb.Event{
+		ChatId:       msg.ChatID,
+		SessionKey:   msg.SessionKey,
+		Type:         string(msg.Type),
+		Content:      msg.Content,
+		Delta:        msg.Delta,
+		ToolName:     msg.ToolName,
+		ToolCallId:   msg.ToolCallID,
+		ToolResult:   msg.ToolResult,
+		Error:        msg.Error,
+		Iteration:    int32(msg.Iteration),
+		Label:        msg.Label,
+		Status:       msg.Status,
+		ApprovalId:   msg.ApprovalID,
+		ApprovalRule: msg.ApprovalRule,
+		TimeUnixMs:   msg.Timestamp.UnixMilli(),
+		Seq:          msg.Seq,
+		TurnId:       msg.TurnID,
+	}
+	if ev.SessionKey == "" {
+		ev.SessionKey = ChannelName + ":" + msg.ChatID
+	}
+	if msg.ToolArgs != nil {
+		args, _ := json.Marshal(msg.ToolArgs)
+		ev.ToolArgs = string(args)
+	}
+	for _, step := range msg.Plan {
+		ev.Plan = append(ev.Plan, &nenepb.PlanStep{Title: step.Title, Status: step.Status})
+	}
+	return ev
+}
+
+func (s *Server) ListSessions(ctx context.Context, req *nenepb.ListSessionsRequest) (*nenepb.ListSessionsResponse, error) {
+	if s.sessions == nil {
+		return nil, status.Error(codes.Unimplemented, "session manager not configured")
+	}
+	resp := &nenepb.ListSessionsResponse{}
+	for _, info := range s.sessions.Sessions() {
+		resp.Sessions = append(resp.Sessions, &nenepb.Session{
+			Key:      info.Key,
+			Persona:  info.Persona,
+			Model:    info.Model,
+			Messages: int32(info.Messages),
+		})
+	}
+	return resp, nil
+}
+
+func (s *Server) ManageMemory(ctx context.Context, req *nenepb.ManageMemoryRequest) (*nenepb.ManageMemoryResponse, error) {
+	if s.mem == nil {
+		return nil, status.Error(codes.Unimplemented, "memory not configured")
+	}
+	resp := &nenepb.ManageMemoryResponse{}
+	var entries []*memory.Entry
+	var err error
+
+	switch req.Action {
+	case nenepb.ManageMemoryRequest_ACTION_STORE:
+		if req.Key == "" || req.Content == "" {
+			return nil, status.Error(codes.InvalidArgument, "key and content are required")
+		}
+		var e *memory.Entry
+		e, err = s.mem.Store(ctx, &memory.StoreRequest{
+			Key:      req.Key,
+			Content:  req.Content,
+			Category: memory.ParseCategory(req.Category),
+		})
+		entries = []*memory.Entry{e}
+	case nenepb.ManageMemoryRequest_ACTION_RECALL:
+		if req.Query == "" {
+			return nil, status.Error(codes.InvalidArgument, "query is required")
+		}
+		entries, err = s.mem.Recall(ctx, &memory.RecallRequest{Query: req.Query, Limit: int(req.Limit)})
+	case nenepb.ManageMemoryRequest_ACTION_GET:
+		if req.Key == "" {
+			return nil, status.Error(codes.InvalidArgument, "key is required")
+		}
+		var e *memory.Entry
+		e, err = s.mem.Get(ctx, req.Key)
+		if err == nil && e == nil {
+			return nil, status.Errorf(codes.NotFound, "no memory stored under %q", req.Key)
+		}
+		entries = []*memory.Entry{e}
+	case nenepb.ManageMemoryRequest_ACTION_LIST:
+		list := &memory.ListRequest{Limit: int(req.Limit)}
+		if req.Category != "" {
+			list.Category = memory.ParseCategory(req.Category)
+		}
+		entries, err = s.mem.List(ctx, list)
+	case nenepb.ManageMemoryRequest_ACTION_FORGET:
+		if req.Key == "" {
+			return nil, status.Error(codes.InvalidArgument, "key is required")
+		}
+		resp.Forgotten, err = s.mem.Forget(ctx, req.Key)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown action %v", req.Action)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	for _, e := range entries {
+		if e == nil {
+			continue
+		}
+		resp.Entries = append(resp.Entries, &nenepb.MemoryEntry{
+			Id:            e.ID,
+			Key:           e.Key,
+			Content:       e.Content,
+			Category:      string(e.Category),
+			SessionId:     e.SessionID,
+			Score:         e.Score,
+			CreatedUnixMs: e.CreatedAt.UnixMilli(),
+			UpdatedUnixMs: e.UpdatedAt.UnixMilli(),
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,104 @@
+// Package seal encrypts data at rest, such as memory entries and saved
+// conversations, with AES-256-GCM under a key kept in the secrets backend.
+package seal
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// prefix marks sealed data, so data written before encryption was turned
+// on is still read as it is.
+const prefix = "enc:v1:"
+
+// MinKeyLen is the shortest key New accepts.
+const MinKeyLen = 16
+
+// ErrNoKey is returned when sealed data is read without a key.
+var ErrNoKey = errors.New("data is encrypted but no encryption key is set")
+
+// Box seals and opens data under one key. A nil Box leaves data as it is,
+// so stores can call it whether or not encryption is on.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New returns a Box for key, any string of at least MinKeyLen bytes; a
+// random one such as `openssl rand -base64 32` is best. It is hashed into
+// the AES key.
+func New(key string) (*Box, error) {
+	if len(key) < MinKeyLen {
+		return nil, fmt.Errorf("encryption key must be at least %d characters", MinKeyLen)
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Sealed reports whether data was sealed by a Box.
+func Sealed(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(prefix))
+}
+
+// Seal encrypts plaintext under a fresh nonce. The result is text, so it
+// fits a TEXT column as well as a file.
+func (b *Box) Seal(plaintext []byte) []byte {
+	if b == nil {
+		return plaintext
+	}
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(fmt.Sprintf("seal: read random nonce: %v", err))
+	}
+	ct := b.aead.Seal(nonce, nonce, plaintext, nil)
+	out := make([]byte, len(prefix)+base64.StdEncoding.EncodedLen(len(ct)))
+	copy(out, prefix)
+	base64.StdEncoding.Encode(out[len(prefix):], ct)
+	return out
+}
+
+// Open decrypts data sealed with the same key. Data that was never sealed
+// is returned as it is.
+func (b *Box) Open(data []byte) ([]byte, error) {
+	if !Sealed(data) {
+		return data, nil
+	}
+	if b == nil {
+		return nil, ErrNoKey
+	}
+	ct, err := base64.StdEncoding.DecodeString(string(data[len(prefix):]))
+	if err != nil {
+		return nil, fmt.Errorf("decode sealed data: %w", err)
+	}
+	n := b.aead.NonceSize()
+	if len(ct) < n {
+		return nil, errors.New("sealed data is truncated")
+	}
+	plaintext, err := b.aead.Open(nil, ct[:n], ct[n:], nil)
+	if err != nil {
+		return nil, errors.New("cannot decrypt data: wrong encryption key or corrupted data")
+	}
+	return plaintext, nil
+}
+
+func (b *Box) SealString(s string) string {
+	return string(b.Seal([]byte(s)))
+}
+
+func (b *Box) OpenString(s string) (string, error) {
+	out, err := b.Open([]byte(s))
+	return string(out), err
+}
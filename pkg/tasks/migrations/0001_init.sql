@@ -0,0 +1,13 @@
+-- The schema from before migrations; IF NOT EXISTS lets it run on databases
+-- created back then.
+CREATE TABLE IF NOT EXISTS tasks (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	chat       TEXT NOT NULL,
+	title      TEXT NOT NULL,
+	status     TEXT NOT NULL,
+	due        DATETIME,
+	notes      TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_tasks_chat ON tasks(chat, status);
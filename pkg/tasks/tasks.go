@@ -0,0 +1,302 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/nene-agent/nene/pkg/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+type Status string
+
+const (
+	StatusTodo       Status = "todo"
+	StatusInProgress Status = "in_progress"
+	StatusDone       Status = "done"
+	StatusCancelled  Status = "cancelled"
+)
+
+var Statuses = []Status{StatusTodo, StatusInProgress, StatusDone, StatusCancelled}
+
+func ParseStatus(s string) (Status, bool) {
+	for _, st := range Statuses {
+		if string(st) == s {
+			return st, true
+		}
+	}
+	return "", false
+}
+
+// Open reports whether a task with this status still needs doing.
+func (s Status) Open() bool {
+	return s == StatusTodo || s == StatusInProgress
+}
+
+// Task is a structured TODO belonging to one "channel:chatID" chat.
+type Task struct {
+	ID      int64
+	Chat    string
+	Title   string
+	Status  Status
+	Due     time.Time // zero when the task has no due date
+	Notes   string
+	Created time.Time
+	Updated time.Time
+}
+
+// Overdue reports whether an open task is past its due date at now.
+func (t Task) Overdue(now time.Time) bool {
+	return t.Status.Open() && !t.Due.IsZero() && t.Due.Before(now)
+}
+
+// String renders a task on one line, e.g.
+// "#3 [todo] Renew passport (due 2024-07-01)".
+func (t Task) String() string {
+	return t.StringIn(time.Local)
+}
+
+// StringIn is String with the due date shown in loc.
+func (t Task) StringIn(loc *time.Location) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#%d [%s] %s", t.ID, t.Status, t.Title)
+	if !t.Due.IsZero() {
+		sb.WriteString(" (due " + FormatDue(t.Due, loc) + ")")
+	}
+	return sb.String()
+}
+
+var ErrNotFound = errors.New("task not found")
+
+// dueLayouts are the accepted due date formats; dates without a time are due
+// at the end of the day.
+var dueLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02T15:04", time.DateOnly}
+
+// ParseDue parses a due date in loc, the chat's timezone; nil means the
+// server's. An empty string means no due date.
+func ParseDue(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	for _, layout := range dueLayouts {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err != nil {
+			continue
+		}
+		if layout == time.DateOnly {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid due date %q; use YYYY-MM-DD or YYYY-MM-DD HH:MM", s)
+}
+
+// FormatDue is the inverse of ParseDue.
+func FormatDue(t time.Time, loc *time.Location) string {
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	if t.Hour() == 23 && t.Minute() == 59 && t.Second() == 59 {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// Store keeps tasks in tasks.db.
+type Store struct {
+	db       *sql.DB
+	location func(chat string) *time.Location
+}
+
+func Open(dataDir string) (*Store, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("create data directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(dataDir, "tasks.db"))
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	ms, err := migrate.Load(migrations, "migrations")
+	if err == nil {
+		_, err = migrate.Apply(context.Background(), db, ms)
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// SetLocation sets how Review finds a chat's timezone, e.g.
+// agent.SessionManager.TimezoneFor. Without it the server's is used.
+func (s *Store) SetLocation(fn func(chat string) *time.Location) {
+	s.location = fn
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Create stores a new task and returns it with its ID. The status defaults
+// to todo.
+func (s *Store) Create(ctx context.Context, t Task) (Task, error) {
+	if strings.TrimSpace(t.Title) == "" {
+		return Task{}, errors.New("title is required")
+	}
+	if t.Status == "" {
+		t.Status = StatusTodo
+	}
+	now := time.Now().UTC()
+	t.Created, t.Updated = now, now
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO tasks (chat, title, status, due, notes, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		t.Chat, t.Title, string(t.Status), nullTime(t.Due), t.Notes, t.Created, t.Updated)
+	if err != nil {
+		return Task{}, err
+	}
+	t.ID, err = res.LastInsertId()
+	return t, err
+}
+
+// Update changes the given fields of a chat's task. Nil fields are kept; a
+// zero Due clears the due date.
+type Update struct {
+	Title  *string
+	Status *Status
+	Due    *time.Time
+	Notes  *string
+}
+
+func (s *Store) Update(ctx context.Context, chat string, id int64, u Update) (Task, error) {
+	t, err := s.Get(ctx, chat, id)
+	if err != nil {
+		return Task{}, err
+	}
+	if u.Title != nil {
+		if strings.TrimSpace(*u.Title) == "" {
+			return Task{}, errors.New("title must not be empty")
+		}
+		t.Title = *u.Title
+	}
+	if u.Status != nil {
+		t.Status = *u.Status
+	}
+	if u.Due != nil {
+		t.Due = *u.Due
+	}
+	if u.Notes != nil {
+		t.Notes = *u.Notes
+	}
+	t.Updated = time.Now().UTC()
+	_, err = s.db.ExecContext(ctx,
+		`UPDATE tasks SET title = ?, status = ?, due = ?, notes = ?, updated_at = ? WHERE chat = ? AND id = ?`,
+		t.Title, string(t.Status), nullTime(t.Due), t.Notes, t.Updated, chat, id)
+	return t, err
+}
+
+func (s *Store) Get(ctx context.Context, chat string, id int64) (Task, error) {
+	rows, err := s.db.QueryContext(ctx, selectTasks+` WHERE chat = ? AND id = ?`, chat, id)
+	if err != nil {
+		return Task{}, err
+	}
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return Task{}, err
+	}
+	if len(tasks) == 0 {
+		return Task{}, ErrNotFound
+	}
+	return tasks[0], nil
+}
+
+// List returns a chat's tasks with one of the given statuses, or all of them
+// when none are given: open tasks first, then by due date and ID.
+func (s *Store) List(ctx context.Context, chat string, statuses ...Status) ([]Task, error) {
+	query := selectTasks + ` WHERE chat = ?`
+	args := []any{chat}
+	if len(statuses) > 0 {
+		query += ` AND status IN (?` + strings.Repeat(`, ?`, len(statuses)-1) + `)`
+		for _, st := range statuses {
+			args = append(args, string(st))
+		}
+	}
+	query += ` ORDER BY status IN ('done', 'cancelled'), due IS NULL, due, id`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// Review summarizes a chat's open tasks that are overdue or due within a
+// day, for the heartbeat prompt. It returns "" when there are none.
+func (s *Store) Review(ctx context.Context, chat string) (string, error) {
+	open, err := s.List(ctx, chat, StatusTodo, StatusInProgress)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	loc := time.Local
+	if s.location != nil {
+		loc = s.location(chat)
+	}
+	var lines []string
+	for _, t := range open {
+		switch {
+		case t.Due.IsZero() || t.Due.After(now.Add(24*time.Hour)):
+			continue
+		case t.Overdue(now):
+			lines = append(lines, "- overdue: "+t.StringIn(loc))
+		default:
+			lines = append(lines, "- due soon: "+t.StringIn(loc))
+		}
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return "Open tasks that are overdue or due within a day:\n" + strings.Join(lines, "\n"), nil
+}
+
+const selectTasks = `SELECT id, chat, title, status, due, notes, created_at, updated_at FROM tasks`
+
+func scanTasks(rows *sql.Rows) ([]Task, error) {
+	defer rows.Close()
+	var tasks []Task
+	for rows.Next() {
+		var t Task
+		var status string
+		var due sql.NullTime
+		if err := rows.Scan(&t.ID, &t.Chat, &t.Title, &status, &due, &t.Notes, &t.Created, &t.Updated); err != nil {
+			return nil, err
+		}
+		t.Status = Status(status)
+		if due.Valid {
+			t.Due = due.Time
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
@@ -0,0 +1,137 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mymmrac/telego"
+)
+
+// albumWait is how long after an album's latest part it is taken to be
+// complete. Telegram sends the parts as separate updates in quick
+// succession.
+const albumWait = 1500 * time.Millisecond
+
+// album collects the messages of a media group so they start one turn.
+type album struct {
+	senderID string
+	chatID   int64
+	threadID int
+	metadata map[string]string
+	captions []string
+	media    []string
+	timer    *time.Timer
+	// gen counts the parts; a timer fired for an earlier part finds it
+	// changed and leaves the album to the newer timer.
+	gen int
+}
+
+// albums buffers media groups by chat and group ID.
+type albums struct {
+	mu      sync.Mutex
+	pending map[string]*album
+}
+
+// addToAlbum adds a message of a media group and restarts the group's
+// wait; the last part to arrive sends the whole album.
+func (c *TelegramChannel) addToAlbum(ctx context.Context, message *telego.Message, senderID, caption string, media []string, metadata map[string]string) {
+	threadID := messageThread(message)
+	key := chatKey(message.Chat.ID, threadID) + ":" + message.MediaGroupID
+
+	c.albums.mu.Lock()
+	defer c.albums.mu.Unlock()
+	a, ok := c.albums.pending[key]
+	if !ok {
+		// The first part's metadata, such as its message ID, stands for
+		// the album.
+		a = &album{senderID: senderID, chatID: message.Chat.ID, threadID: threadID, metadata: metadata}
+		c.albums.pending[key] = a
+	} else {
+		a.timer.Stop()
+	}
+	if caption != "" {
+		a.captions = append(a.captions, caption)
+	}
+	a.media = append(a.media, media...)
+	a.gen++
+	gen := a.gen
+	a.timer = time.AfterFunc(albumWait, func() {
+		c.albums.mu.Lock()
+		if c.albums.pending[key] != a || a.gen != gen {
+			c.albums.mu.Unlock()
+			return
+		}
+		delete(c.albums.pending, key)
+		text := strings.Join(a.captions, "\n")
+		files := append([]string(nil), a.media...)
+		c.albums.mu.Unlock()
+		c.dispatch(ctx, a.senderID, a.chatID, a.threadID, text, files, a.metadata)
+	})
+}
+
+// downloadMedia saves the photo or file of a message to MediaDir and
+// returns its path. Without MediaDir nothing is downloaded.
+func (c *TelegramChannel) downloadMedia(ctx context.Context, m *telego.Message) []string {
+	if c.config.MediaDir == "" {
+		return nil
+	}
+	var fileID, name string
+	switch {
+	case len(m.Photo) > 0:
+		// The last size is the largest.
+		p := m.Photo[len(m.Photo)-1]
+		fileID, name = p.FileID, p.FileUniqueID+".jpg"
+	case m.Document != nil:
+		fileID, name = m.Document.FileID, m.Document.FileUniqueID
+		if m.Document.FileName != "" {
+			name += "-" + filepath.Base(m.Document.FileName)
+		}
+	default:
+		return nil
+	}
+	path, err := c.downloadFile(ctx, fileID, filepath.Join(c.config.MediaDir, fmt.Sprintf("%d", m.Chat.ID), name))
+	if err != nil {
+		fmt.Printf("Telegram: failed to download %s: %v\n", name, err)
+		return nil
+	}
+	return []string{path}
+}
+
+func (c *TelegramChannel) downloadFile(ctx context.Context, fileID, path string) (string, error) {
+	f, err := c.bot.GetFile(ctx, &telego.GetFileParams{FileID: fileID})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.bot.FileDownloadURL(f.FilePath), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.files.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download: %s", resp.Status)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		os.Remove(path)
+		return "", err
+	}
+	return path, out.Close()
+}
@@ -1,108 +0,0 @@
-package telegram
-
-import (
-	"context"
-	"fmt"
-	"strings"
-	"sync"
-
-	"github.com/nene-agent/nene/pkg/bus"
-)
-
-type Channel interface {
-	Name() string
-	Start(ctx context.Context) error
-	Stop(ctx context.Context) error
-	Send(ctx context.Context, msg bus.OutboundMessage) error
-	IsRunning() bool
-	IsAllowed(senderID string) bool
-}
-
-type BaseChannel struct {
-	bus       *bus.MessageBus
-	running   bool
-	name      string
-	allowList []string
-	mu        sync.RWMutex
-}
-
-func NewBaseChannel(name string, messageBus *bus.MessageBus, allowList []string) *BaseChannel {
-	return &BaseChannel{
-		bus:       messageBus,
-		name:      name,
-		allowList: allowList,
-		running:   false,
-	}
-}
-
-func (c *BaseChannel) Name() string {
-	return c.name
-}
-
-func (c *BaseChannel) IsRunning() bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.running
-}
-
-func (c *BaseChannel) setRunning(running bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.running = running
-}
-
-func (c *BaseChannel) IsAllowed(senderID string) bool {
-	if len(c.allowList) == 0 {
-		return true
-	}
-
-	idPart := senderID
-	userPart := ""
-	if idx := strings.Index(senderID, "|"); idx > 0 {
-		idPart = senderID[:idx]
-		userPart = senderID[idx+1:]
-	}
-
-	for _, allowed := range c.allowList {
-		trimmed := strings.TrimPrefix(allowed, "@")
-		allowedID := trimmed
-		allowedUser := ""
-		if idx := strings.Index(trimmed, "|"); idx > 0 {
-			allowedID = trimmed[:idx]
-			allowedUser = trimmed[idx+1:]
-		}
-
-		if senderID == allowed ||
-			idPart == allowed ||
-			senderID == trimmed ||
-			idPart == trimmed ||
-			idPart == allowedID ||
-			(allowedUser != "" && senderID == allowedUser) ||
-			(userPart != "" && (userPart == allowed || userPart == trimmed || userPart == allowedUser)) {
-			return true
-		}
-	}
-
-	return false
-}
-
-func (c *BaseChannel) HandleMessage(senderID, chatID, content string, media []string, metadata map[string]string, streamMode bool) {
-	if !c.IsAllowed(senderID) {
-		return
-	}
-
-	sessionKey := fmt.Sprintf("%s:%s", c.name, chatID)
-
-	msg := bus.InboundMessage{
-		Channel:    c.name,
-		SenderID:   senderID,
-		ChatID:     chatID,
-		Content:    content,
-		Media:      media,
-		SessionKey: sessionKey,
-		Metadata:   metadata,
-		StreamMode: streamMode,
-	}
-
-	c.bus.PublishInbound(msg)
-}
@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const defaultDetailsTTL = 7 * 24 * time.Hour
+
+// detailStore keeps the tool details behind "View Details" buttons, keyed
+// by chat and message. With a database they outlive restarts until the TTL
+// prunes them; without one they are kept in memory.
+type detailStore struct {
+	db  *sql.DB
+	ttl time.Duration
+	mem sync.Map
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+func openDetailStore(path string, ttl time.Duration) (*detailStore, error) {
+	if ttl <= 0 {
+		ttl = defaultDetailsTTL
+	}
+	s := &detailStore{ttl: ttl}
+	if path == "" {
+		return s, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return s, fmt.Errorf("create data directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return s, fmt.Errorf("open database: %w", err)
+	}
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS tool_details (
+		chat_id    INTEGER NOT NULL,
+		message_id INTEGER NOT NULL,
+		payload    TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (chat_id, message_id)
+	)`)
+	if err != nil {
+		db.Close()
+		return s, fmt.Errorf("init schema: %w", err)
+	}
+	s.db = db
+	return s, nil
+}
+
+func (s *detailStore) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func detailKey(chatID int64, messageID int) string {
+	return fmt.Sprintf("%d:%d", chatID, messageID)
+}
+
+func (s *detailStore) Put(ctx context.Context, chatID int64, messageID int, d *ToolDetails) {
+	if s.db == nil {
+		s.mem.Store(detailKey(chatID, messageID), d)
+		return
+	}
+	payload, err := json.Marshal(d)
+	if err != nil {
+		return
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO tool_details (chat_id, message_id, payload, created_at) VALUES (?, ?, ?, ?)`,
+		chatID, messageID, string(payload), time.Now().UTC())
+	if err != nil {
+		fmt.Printf("Failed to save tool details: %v\n", err)
+	}
+	s.prune(ctx)
+}
+
+func (s *detailStore) Get(ctx context.Context, chatID int64, messageID int) (*ToolDetails, bool) {
+	if s.db == nil {
+		d, ok := s.mem.Load(detailKey(chatID, messageID))
+		if !ok {
+			return nil, false
+		}
+		return d.(*ToolDetails), true
+	}
+	var payload string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT payload FROM tool_details WHERE chat_id = ? AND message_id = ? AND created_at > ?`,
+		chatID, messageID, time.Now().UTC().Add(-s.ttl)).Scan(&payload)
+	if err != nil {
+		return nil, false
+	}
+	var d ToolDetails
+	if err := json.Unmarshal([]byte(payload), &d); err != nil {
+		return nil, false
+	}
+	return &d, true
+}
+
+// prune deletes expired details, at most once an hour.
+func (s *detailStore) prune(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastPruned) < time.Hour {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPruned = time.Now()
+	s.mu.Unlock()
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM tool_details WHERE created_at <= ?`, time.Now().UTC().Add(-s.ttl)); err != nil {
+		fmt.Printf("Failed to prune tool details: %v\n", err)
+	}
+}
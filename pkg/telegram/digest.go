@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tu "github.com/mymmrac/telego/telegoutil"
+
+	"github.com/nene-agent/nene/pkg/i18n"
+)
+
+// In digest mode a chat is not shown tool calls while they run. The answer
+// ends with a small table of the tools used instead, and "View Details"
+// still has every call.
+
+// digest reports whether chatID is in digest mode: the /digest choice, else
+// the configured default.
+func (c *TelegramChannel) digest(chatID int64) bool {
+	if on, ok := c.digests.Load(chatID); ok {
+		return on.(bool)
+	}
+	return c.config.Digest
+}
+
+func (c *TelegramChannel) handleDigestCommand(ctx context.Context, chatID int64, threadID int, arg string) {
+	locale := c.locale(chatID)
+	var reply string
+	switch arg {
+	case "":
+		reply = i18n.T(locale, "digest.current", onOff(c.digest(chatID)))
+	case "on", "off":
+		c.digests.Store(chatID, arg == "on")
+		reply = i18n.T(locale, "digest.set", arg)
+	default:
+		reply = i18n.T(locale, "digest.unknown", arg)
+	}
+	m := tu.Message(tu.ID(chatID), reply)
+	m.MessageThreadID = threadID
+	c.bot.SendMessage(ctx, m)
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// toolDigest is the table of the turn's tools for the end of the answer:
+// one row per tool with how often it ran, how it went, and for how long.
+// It is empty when no tools were used.
+func (s *StreamState) toolDigest(locale string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type row struct {
+		name         string
+		calls, fails int
+		took         time.Duration
+	}
+	var rows []*row
+	byName := make(map[string]*row)
+	var total time.Duration
+	for _, id := range s.toolCallList {
+		part := s.toolCalls[id]
+		if part == nil {
+			continue
+		}
+		r, ok := byName[part.ToolName]
+		if !ok {
+			r = &row{name: part.ToolName}
+			byName[part.ToolName] = r
+			rows = append(rows, r)
+		}
+		r.calls++
+		if part.State["status"] == "error" {
+			r.fails++
+		}
+		started, _ := part.State["started"].(time.Time)
+		finished, _ := part.State["finished"].(time.Time)
+		if !started.IsZero() && finished.After(started) {
+			r.took += finished.Sub(started)
+			total += finished.Sub(started)
+		}
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	width := 0
+	for _, r := range rows {
+		width = max(width, len(r.name))
+	}
+	var b strings.Builder
+	b.WriteString(i18n.N(locale, "digest.header", len(s.toolCallList)) + " · " + formatTook(total) + "\n```\n")
+	for _, r := range rows {
+		status := "✅"
+		switch {
+		case r.fails == r.calls:
+			status = "❌"
+		case r.fails > 0:
+			status = "⚠️"
+		}
+		fmt.Fprintf(&b, "%s %-*s ×%-2d %6s\n", status, width, r.name, r.calls, formatTook(r.took))
+	}
+	b.WriteString("```")
+	return b.String()
+}
+
+func formatTook(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return d.Round(time.Second).String()
+}
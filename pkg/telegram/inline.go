@@ -0,0 +1,181 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+
+	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/channel"
+	"github.com/nene-agent/nene/pkg/i18n"
+)
+
+const (
+	// inlineDebounce waits for the user to stop typing before asking the
+	// model, since Telegram sends a new inline query on every keystroke.
+	inlineDebounce = 700 * time.Millisecond
+	inlineTimeout  = 30 * time.Second
+	// inlineCacheTime is how long Telegram may serve an answer from its own
+	// cache, in seconds.
+	inlineCacheTime   = 300
+	maxInlineSnippets = 4
+)
+
+// InlineAsker answers an inline query with a single model call. sessionKey
+// identifies who the spend is charged to.
+type InlineAsker func(ctx context.Context, sessionKey, question string) (string, error)
+
+// SetInlineAsker sets the function answering inline queries (@bot question).
+// Inline queries are ignored unless TelegramConfig.Inline is set and an
+// asker is configured.
+func (c *TelegramChannel) SetInlineAsker(fn InlineAsker) {
+	c.inlineMu.Lock()
+	defer c.inlineMu.Unlock()
+	c.asker = fn
+}
+
+func (c *TelegramChannel) handleInlineQuery(ctx context.Context, update telego.Update) {
+	query := update.InlineQuery
+	question := strings.TrimSpace(query.Query)
+	if !c.config.Inline || question == "" {
+		return
+	}
+
+	userID := fmt.Sprintf("%d", query.From.ID)
+	senderID := userID
+	if query.From.Username != "" {
+		senderID = fmt.Sprintf("%s|%s", userID, query.From.Username)
+	}
+	if !c.IsAllowed(senderID) {
+		return
+	}
+
+	c.inlineMu.Lock()
+	asker := c.asker
+	c.inlineMu.Unlock()
+	if asker == nil {
+		return
+	}
+
+	// A newer query from the same user supersedes the one still waiting.
+	qctx, cancel := context.WithTimeout(ctx, inlineTimeout)
+	req := &inlineRequest{cancel: cancel}
+	c.inlineMu.Lock()
+	if prev, ok := c.inlinePending[userID]; ok {
+		prev.cancel()
+	}
+	c.inlinePending[userID] = req
+	c.inlineMu.Unlock()
+
+	go func() {
+		defer c.finishInline(userID, req)
+
+		select {
+		case <-qctx.Done():
+			return
+		case <-time.After(inlineDebounce):
+		}
+
+		chatID := "inline:" + userID
+		if ok, notice := c.Allow(senderID, chatID); !ok {
+			if notice != "" {
+				c.answerInline(qctx, query.ID, tu.ResultArticle("limit", notice, tu.TextMessage(notice)))
+			}
+			return
+		}
+		defer c.FinishTurn(chatID)
+
+		answer, err := asker(qctx, "telegram:"+chatID, question)
+		if qctx.Err() != nil {
+			return
+		}
+		locale := i18n.Normalize(query.From.LanguageCode)
+		if locale == "" {
+			locale = i18n.Default
+		}
+		if err != nil {
+			fmt.Printf("Inline query from %s failed: %v\n", senderID, err)
+			code, detail := channel.DescribeError(err)
+			text, ok := channel.ErrorText(locale, bus.StreamMessage{ErrorCode: code})
+			if !ok {
+				text = i18n.T(locale, "stream.error", detail)
+			}
+			c.answerInline(qctx, query.ID, tu.ResultArticle("error", text, tu.TextMessage(text)))
+			return
+		}
+		c.answerInline(qctx, query.ID, inlineResults(question, answer, locale)...)
+	}()
+}
+
+type inlineRequest struct {
+	cancel context.CancelFunc
+}
+
+// finishInline removes the pending entry for a user unless a newer query
+// has already replaced it.
+func (c *TelegramChannel) finishInline(userID string, req *inlineRequest) {
+	req.cancel()
+	c.inlineMu.Lock()
+	defer c.inlineMu.Unlock()
+	if c.inlinePending[userID] == req {
+		delete(c.inlinePending, userID)
+	}
+}
+
+func (c *TelegramChannel) answerInline(ctx context.Context, queryID string, results ...telego.InlineQueryResult) {
+	params := tu.InlineQuery(queryID, results...)
+	params.CacheTime = inlineCacheTime
+	if err := c.bot.AnswerInlineQuery(ctx, params); err != nil {
+		fmt.Printf("Failed to answer inline query: %v\n", err)
+	}
+}
+
+// inlineResults offers the whole answer first, followed by its paragraphs
+// as separate snippets when there are several.
+func inlineResults(question, answer, locale string) []telego.InlineQueryResult {
+	full := fmt.Sprintf("<b>❓ %s</b>\n\n%s", escapeHTML(question), markdownToTelegramHTML(answer))
+	results := []telego.InlineQueryResult{
+		tu.ResultArticle("answer", snippet(answer, 60), tu.TextMessage(truncateInline(full, locale)).WithParseMode(telego.ModeHTML)).
+			WithDescription(snippet(answer, 200)),
+	}
+
+	var paragraphs []string
+	for _, p := range strings.Split(answer, "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	if len(paragraphs) < 2 {
+		return results
+	}
+	for i, p := range paragraphs {
+		if i == maxInlineSnippets {
+			break
+		}
+		results = append(results,
+			tu.ResultArticle(fmt.Sprintf("part%d", i), snippet(p, 60), tu.TextMessage(truncateInline(markdownToTelegramHTML(p), locale)).WithParseMode(telego.ModeHTML)).
+				WithDescription(snippet(p, 200)))
+	}
+	return results
+}
+
+// snippet returns the first n runes of s on a single line.
+func snippet(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+func truncateInline(s, locale string) string {
+	const maxLength = 4000
+	if len(s) > maxLength {
+		return s[:maxLength] + "\n\n<i>" + escapeHTML(i18n.T(locale, "stream.truncated")) + "</i>"
+	}
+	return s
+}
@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mymmrac/telego"
+)
+
+// sharedContent describes a shared location, venue, or contact as text the
+// agent can read, so "what's near me?" works with the location it was sent.
+// It is empty for other messages.
+func sharedContent(m *telego.Message) string {
+	var b strings.Builder
+	switch {
+	case m.Venue != nil:
+		// Venues carry a location as well; the venue says more.
+		v := m.Venue
+		b.WriteString("[Shared venue]\n")
+		writeField(&b, "name", v.Title)
+		writeField(&b, "address", v.Address)
+		writeCoordinates(&b, &v.Location)
+	case m.Location != nil:
+		b.WriteString("[Shared location]\n")
+		writeCoordinates(&b, m.Location)
+		if m.Location.HorizontalAccuracy > 0 {
+			writeField(&b, "accuracy", fmt.Sprintf("%.0f m", m.Location.HorizontalAccuracy))
+		}
+		if m.Location.LivePeriod > 0 {
+			writeField(&b, "live", "yes, only this first position is known")
+		}
+	case m.Contact != nil:
+		c := m.Contact
+		b.WriteString("[Shared contact]\n")
+		writeField(&b, "name", strings.TrimSpace(c.FirstName+" "+c.LastName))
+		writeField(&b, "phone", c.PhoneNumber)
+		if c.UserID != 0 {
+			writeField(&b, "telegram_user_id", strconv.FormatInt(c.UserID, 10))
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// sharedMetadata adds the coordinates of a shared location or venue to a
+// message's metadata.
+func sharedMetadata(m *telego.Message, metadata map[string]string) {
+	loc := m.Location
+	if m.Venue != nil {
+		loc = &m.Venue.Location
+	}
+	if loc == nil {
+		return
+	}
+	metadata["latitude"] = strconv.FormatFloat(loc.Latitude, 'f', -1, 64)
+	metadata["longitude"] = strconv.FormatFloat(loc.Longitude, 'f', -1, 64)
+}
+
+func writeCoordinates(b *strings.Builder, loc *telego.Location) {
+	writeField(b, "latitude", strconv.FormatFloat(loc.Latitude, 'f', 6, 64))
+	writeField(b, "longitude", strconv.FormatFloat(loc.Longitude, 'f', 6, 64))
+}
+
+func writeField(b *strings.Builder, name, value string) {
+	if value != "" {
+		fmt.Fprintf(b, "%s: %s\n", name, value)
+	}
+}
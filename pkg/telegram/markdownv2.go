@@ -0,0 +1,158 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+
+	"github.com/nene-agent/nene/pkg/i18n"
+)
+
+// Sentinels stand in for MarkdownV2 markup while the rest of the text is
+// escaped.
+const (
+	v2Bold    = "\x01"
+	v2Italic  = "\x02"
+	v2Strike  = "\x03"
+	v2Spoiler = "\x04"
+	v2Quote   = "\x05"
+)
+
+var (
+	v2Link      = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+	v2Heading   = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+	v2QuoteLine = regexp.MustCompile(`(?m)^>\s?`)
+	v2Rule      = regexp.MustCompile(`(?m)^---+\s*$`)
+	v2Bullet    = regexp.MustCompile(`(?m)^[-*]\s+`)
+	v2BoldStar  = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	v2BoldUnder = regexp.MustCompile(`__(.+?)__`)
+	v2ItalStar  = regexp.MustCompile(`(^|[^\*])\*([^\*]+?)\*([^\*]|$)`)
+	v2ItalUnder = regexp.MustCompile(`(^|[^_])_([^_]+?)_([^_]|$)`)
+	v2StrikeRe  = regexp.MustCompile(`~~(.+?)~~`)
+	v2SpoilerRe = regexp.MustCompile(`\|\|(.+?)\|\|`)
+
+	v2Escaper   = strings.NewReplacer(escapePairs("_*[]()~`>#+-=|{}.!\\")...)
+	v2CodeEsc   = strings.NewReplacer(escapePairs("`\\")...)
+	v2URLEsc    = strings.NewReplacer(escapePairs(")\\")...)
+	v2Sentinels = strings.NewReplacer(v2Bold, "*", v2Italic, "_", v2Strike, "~", v2Spoiler, "||", v2Quote, ">")
+)
+
+func escapePairs(chars string) []string {
+	var pairs []string
+	for _, r := range chars {
+		pairs = append(pairs, string(r), `\`+string(r))
+	}
+	return pairs
+}
+
+// escapeMarkdownV2 escapes every character MarkdownV2 treats as markup.
+func escapeMarkdownV2(text string) string {
+	return v2Escaper.Replace(text)
+}
+
+// markdownToTelegramV2 converts Markdown to Telegram's MarkdownV2, keeping
+// code blocks and ||spoilers||, which the HTML renderer flattens.
+func markdownToTelegramV2(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	codeBlocks := extractCodeBlocks(text)
+	text = codeBlocks.text
+
+	inlineCodes := extractInlineCodes(text)
+	text = inlineCodes.text
+
+	var links []string
+	text = v2Link.ReplaceAllStringFunc(text, func(m string) string {
+		parts := v2Link.FindStringSubmatch(m)
+		links = append(links, fmt.Sprintf("[%s](%s)", escapeMarkdownV2(parts[1]), v2URLEsc.Replace(parts[2])))
+		return fmt.Sprintf("\x00LK%d\x00", len(links)-1)
+	})
+
+	text = v2Heading.ReplaceAllString(text, v2Bold+"$1"+v2Bold)
+	text = v2QuoteLine.ReplaceAllString(text, v2Quote)
+	text = v2Rule.ReplaceAllString(text, strings.Repeat("─", 31))
+	text = v2Bullet.ReplaceAllString(text, "• ")
+	text = v2BoldStar.ReplaceAllString(text, v2Bold+"$1"+v2Bold)
+	text = v2BoldUnder.ReplaceAllString(text, v2Bold+"$1"+v2Bold)
+	text = v2ItalStar.ReplaceAllString(text, "$1"+v2Italic+"$2"+v2Italic+"$3")
+	text = v2ItalUnder.ReplaceAllString(text, "$1"+v2Italic+"$2"+v2Italic+"$3")
+	text = v2StrikeRe.ReplaceAllString(text, v2Strike+"$1"+v2Strike)
+	text = v2SpoilerRe.ReplaceAllString(text, v2Spoiler+"$1"+v2Spoiler)
+
+	text = v2Sentinels.Replace(escapeMarkdownV2(text))
+
+	for i, link := range links {
+		text = strings.ReplaceAll(text, fmt.Sprintf("\x00LK%d\x00", i), link)
+	}
+	for i, code := range inlineCodes.codes {
+		text = strings.ReplaceAll(text, fmt.Sprintf("\x00IC%d\x00", i), "`"+v2CodeEsc.Replace(code)+"`")
+	}
+	for i, code := range codeBlocks.codes {
+		text = strings.ReplaceAll(text, fmt.Sprintf("\x00CB%d\x00", i), "```\n"+v2CodeEsc.Replace(code)+"```")
+	}
+
+	return text
+}
+
+// parseMode is the parse mode replies to chatID are rendered in.
+func (c *TelegramChannel) parseMode(chatID int64) string {
+	if mode, ok := c.parseModes.Load(chatID); ok {
+		return mode.(string)
+	}
+	if mode, ok := parseModeName(c.config.ParseMode); ok {
+		return mode
+	}
+	return telego.ModeHTML
+}
+
+func parseModeName(name string) (string, bool) {
+	switch strings.ToLower(name) {
+	case "html":
+		return telego.ModeHTML, true
+	case "markdownv2":
+		return telego.ModeMarkdownV2, true
+	}
+	return "", false
+}
+
+// render converts Markdown for chatID in the chat's parse mode, cut to
+// Telegram's message size.
+func (c *TelegramChannel) render(chatID int64, text string) (string, string) {
+	const maxLength = 4000
+	truncated := i18n.T(c.locale(chatID), "stream.truncated")
+	if c.parseMode(chatID) == telego.ModeMarkdownV2 {
+		out := markdownToTelegramV2(text)
+		if len(out) > maxLength {
+			out = out[:maxLength] + "\n\n_" + escapeMarkdownV2(truncated) + "_"
+		}
+		return out, telego.ModeMarkdownV2
+	}
+	out := markdownToTelegramHTML(text)
+	if len(out) > maxLength {
+		out = out[:maxLength] + "\n\n<i>" + truncated + "</i>"
+	}
+	return out, telego.ModeHTML
+}
+
+// handleFormatCommand switches a chat between HTML and MarkdownV2 replies.
+func (c *TelegramChannel) handleFormatCommand(ctx context.Context, chatID int64, threadID int, arg string) {
+	locale := c.locale(chatID)
+	var reply string
+	if arg == "" {
+		reply = i18n.T(locale, "format.current", c.parseMode(chatID))
+	} else if mode, ok := parseModeName(arg); ok {
+		c.parseModes.Store(chatID, mode)
+		reply = i18n.T(locale, "format.set", mode)
+	} else {
+		reply = i18n.T(locale, "format.unknown", arg)
+	}
+	m := tu.Message(tu.ID(chatID), reply)
+	m.MessageThreadID = threadID
+	c.bot.SendMessage(ctx, m)
+}
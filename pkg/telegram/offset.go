@@ -0,0 +1,110 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// offsetMaxAge is how long a saved offset is trusted. Telegram picks update
+// IDs at random again after a week without updates, so an older offset
+// could skip new updates.
+const offsetMaxAge = 6 * 24 * time.Hour
+
+// recentUpdates is how many update IDs are remembered to spot an update
+// delivered twice.
+const recentUpdates = 256
+
+// updateLog remembers which updates were handled. The highest ID is saved to
+// a file so long polling resumes after it on restart instead of fetching
+// unconfirmed updates again.
+type updateLog struct {
+	mu     sync.Mutex
+	path   string
+	last   int
+	saved  time.Time
+	seen   map[int]bool
+	recent []int
+}
+
+type offsetFile struct {
+	LastUpdateID int       `json:"last_update_id"`
+	Saved        time.Time `json:"saved"`
+}
+
+func newUpdateLog(path string) *updateLog {
+	l := &updateLog{path: path, seen: make(map[int]bool)}
+	l.load()
+	return l
+}
+
+// offset is the offset for the first getUpdates call, or 0 to take whatever
+// Telegram has pending.
+func (l *updateLog) offset() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.last == 0 || time.Since(l.saved) > offsetMaxAge {
+		return 0
+	}
+	return l.last + 1
+}
+
+// seenBefore records an update ID and reports whether it was already
+// handled.
+func (l *updateLog) seenBefore(id int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.seen[id] {
+		return true
+	}
+	if l.last != 0 && id <= l.last && time.Since(l.saved) <= offsetMaxAge {
+		return true
+	}
+
+	l.seen[id] = true
+	l.recent = append(l.recent, id)
+	if len(l.recent) > recentUpdates {
+		delete(l.seen, l.recent[0])
+		l.recent = l.recent[1:]
+	}
+	l.last = id
+	l.saved = time.Now()
+	l.save()
+	return false
+}
+
+func (l *updateLog) load() {
+	if l.path == "" {
+		return
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return
+	}
+	var f offsetFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		fmt.Printf("Ignoring unreadable Telegram offset file %s: %v\n", l.path, err)
+		return
+	}
+	l.last, l.saved = f.LastUpdateID, f.Saved
+}
+
+func (l *updateLog) save() {
+	if l.path == "" {
+		return
+	}
+	data, err := json.Marshal(offsetFile{LastUpdateID: l.last, Saved: l.saved})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		fmt.Printf("Failed to save Telegram offset: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(l.path, data, 0644); err != nil {
+		fmt.Printf("Failed to save Telegram offset: %v\n", err)
+	}
+}
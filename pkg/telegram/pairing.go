@@ -0,0 +1,209 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+
+	"github.com/nene-agent/nene/pkg/channel"
+	"github.com/nene-agent/nene/pkg/i18n"
+	"github.com/nene-agent/nene/pkg/tool"
+)
+
+// pairingReoffer is how long an unknown user who ignored the "request
+// access" button waits before being offered it again.
+const pairingReoffer = time.Hour
+
+// pairingRequest is an unknown user being offered access or waiting for an
+// owner to answer.
+type pairingRequest struct {
+	name      string
+	offered   time.Time
+	requested bool
+}
+
+// offerPairing answers a private message from a user who is not allowed
+// with a "request access" button, when pairing is on.
+func (c *TelegramChannel) offerPairing(ctx context.Context, user *telego.User, senderID string) {
+	if !c.config.Pairing || !c.CanPair(senderID) {
+		return
+	}
+	c.pairingMu.Lock()
+	req, ok := c.pairingPending[user.ID]
+	if ok && (req.requested || time.Since(req.offered) < pairingReoffer) {
+		c.pairingMu.Unlock()
+		return
+	}
+	c.pairingPending[user.ID] = &pairingRequest{name: describeUser(user), offered: time.Now()}
+	c.pairingMu.Unlock()
+
+	locale := c.locale(user.ID)
+	m := tu.Message(tu.ID(user.ID), i18n.T(locale, "pairing.offer"))
+	m.ReplyMarkup = tu.InlineKeyboard(tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton(i18n.T(locale, "pairing.request")).WithCallbackData("pair:request"),
+	))
+	if _, err := c.bot.SendMessage(ctx, m); err != nil {
+		fmt.Printf("Failed to offer pairing: %v\n", err)
+	}
+}
+
+// describeUser names a user for owners: their name, username, and ID.
+func describeUser(user *telego.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if user.Username != "" {
+		name += " (@" + user.Username + ")"
+	}
+	return fmt.Sprintf("%s [%d]", strings.TrimSpace(name), user.ID)
+}
+
+// pairingChats are the chats access requests go to: PairingChat, or else
+// the private chats of the owners listed by ID.
+func (c *TelegramChannel) pairingChats() []int64 {
+	if c.config.PairingChat != 0 {
+		return []int64{c.config.PairingChat}
+	}
+	var chats []int64
+	for _, owner := range c.config.Owners {
+		id, _, _ := strings.Cut(strings.TrimSpace(owner), "|")
+		if n, err := strconv.ParseInt(id, 10, 64); err == nil && n > 0 {
+			chats = append(chats, n)
+		}
+	}
+	return chats
+}
+
+func (c *TelegramChannel) handlePairingCallback(ctx context.Context, callback *telego.CallbackQuery) {
+	action, arg, _ := strings.Cut(strings.TrimPrefix(callback.Data, "pair:"), ":")
+	if action == "request" {
+		c.requestPairing(ctx, callback)
+		return
+	}
+	userID, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || (action != "approve" && action != "deny") {
+		return
+	}
+	c.decidePairing(ctx, callback, userID, action == "approve")
+}
+
+// requestPairing passes an unknown user's press of "request access" on to
+// the owners.
+func (c *TelegramChannel) requestPairing(ctx context.Context, callback *telego.CallbackQuery) {
+	user := callback.From
+	senderID := strconv.FormatInt(user.ID, 10)
+	if user.Username != "" {
+		senderID += "|" + user.Username
+	}
+	locale := c.callbackLocale(callback)
+	answer := func(key string) {
+		c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{
+			CallbackQueryID: callback.ID,
+			Text:            i18n.T(locale, key),
+		})
+	}
+	chats := c.pairingChats()
+	if !c.config.Pairing || !c.CanPair(senderID) || len(chats) == 0 {
+		answer("pairing.closed")
+		return
+	}
+
+	c.pairingMu.Lock()
+	req, ok := c.pairingPending[user.ID]
+	if !ok {
+		req = &pairingRequest{name: describeUser(&user), offered: time.Now()}
+		c.pairingPending[user.ID] = req
+	}
+	already := req.requested
+	req.requested = true
+	c.pairingMu.Unlock()
+	if already {
+		answer("pairing.waiting")
+		return
+	}
+	answer("pairing.sent")
+
+	if msg, ok := callback.Message.(*telego.Message); ok {
+		c.bot.EditMessageText(ctx, tu.EditMessageText(tu.ID(msg.Chat.ID), msg.MessageID, i18n.T(locale, "pairing.sent")))
+	}
+	id := strconv.FormatInt(user.ID, 10)
+	for _, chatID := range chats {
+		ownerLocale := c.locale(chatID)
+		m := tu.Message(tu.ID(chatID), i18n.T(ownerLocale, "pairing.prompt", req.name))
+		m.ReplyMarkup = tu.InlineKeyboard(tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(i18n.T(ownerLocale, "pairing.approve")).WithCallbackData("pair:approve:"+id),
+			tu.InlineKeyboardButton(i18n.T(ownerLocale, "pairing.deny")).WithCallbackData("pair:deny:"+id),
+		))
+		if _, err := c.bot.SendMessage(ctx, m); err != nil {
+			fmt.Printf("Failed to send access request to chat %d: %v\n", chatID, err)
+		}
+	}
+}
+
+// decidePairing records an owner's answer to an access request and tells
+// the user.
+func (c *TelegramChannel) decidePairing(ctx context.Context, callback *telego.CallbackQuery, userID int64, approve bool) {
+	owner := strconv.FormatInt(callback.From.ID, 10)
+	if callback.From.Username != "" {
+		owner += "|" + callback.From.Username
+	}
+	locale := c.callbackLocale(callback)
+	chatID, _, _ := extractChatAndMessageID(callback.Message)
+	if !c.IsAllowedIn(owner, strconv.FormatInt(chatID, 10)) || c.RoleOf(owner) != tool.RoleOwner {
+		c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{
+			CallbackQueryID: callback.ID,
+			Text:            i18n.T(locale, "pairing.owners_only"),
+			ShowAlert:       true,
+		})
+		return
+	}
+	store := c.PairingStore()
+	if store == nil {
+		return
+	}
+
+	c.pairingMu.Lock()
+	name := strconv.FormatInt(userID, 10)
+	if req, ok := c.pairingPending[userID]; ok {
+		name = req.name
+	}
+	delete(c.pairingPending, userID)
+	c.pairingMu.Unlock()
+
+	status, userKey, outcomeKey := channel.PairingDenied, "pairing.denied", "pairing.denied_by"
+	if approve {
+		status, userKey, outcomeKey = channel.PairingApproved, "pairing.approved", "pairing.approved_by"
+	}
+	err := store.Decide(ctx, channel.Pairing{
+		Channel:   c.Name(),
+		SenderID:  strconv.FormatInt(userID, 10),
+		Name:      name,
+		Status:    status,
+		DecidedBy: owner,
+	})
+	if err != nil {
+		fmt.Printf("Failed to save pairing of %d: %v\n", userID, err)
+		c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{
+			CallbackQueryID: callback.ID,
+			Text:            err.Error(),
+			ShowAlert:       true,
+		})
+		return
+	}
+	c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{CallbackQueryID: callback.ID})
+
+	decidedBy := callback.From.FirstName
+	if callback.From.Username != "" {
+		decidedBy = "@" + callback.From.Username
+	}
+	if msg, ok := callback.Message.(*telego.Message); ok {
+		edit := tu.EditMessageText(tu.ID(msg.Chat.ID), msg.MessageID,
+			escapeHTML(msg.Text)+"\n\n<b>"+escapeHTML(i18n.T(locale, outcomeKey, decidedBy))+"</b>")
+		edit.ParseMode = telego.ModeHTML
+		c.bot.EditMessageText(ctx, edit)
+	}
+	c.bot.SendMessage(ctx, tu.Message(tu.ID(userID), i18n.T(c.locale(userID), userKey)))
+}
@@ -0,0 +1,102 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+
+	tu "github.com/mymmrac/telego/telegoutil"
+
+	"github.com/nene-agent/nene/pkg/i18n"
+)
+
+// Display modes decide what a chat sees while a turn runs: the reply as it
+// is written, the reply a paragraph at a time in messages of their own, one
+// compact status message, or nothing until the answer.
+const (
+	DisplayStream   = "stream"
+	DisplaySegments = "segments"
+	DisplayProgress = "progress"
+	DisplayQuiet    = "quiet"
+)
+
+// displayMode is the display mode of chatID: the one chosen with /display,
+// else stream when stream mode is on, else the configured Display.
+func (c *TelegramChannel) displayMode(chatID int64) string {
+	if mode, ok := c.displayModes.Load(chatID); ok {
+		return mode.(string)
+	}
+	if c.StreamMode() {
+		return DisplayStream
+	}
+	if c.config.Display == DisplayProgress || c.config.Display == DisplaySegments {
+		return c.config.Display
+	}
+	return DisplayQuiet
+}
+
+func (c *TelegramChannel) handleDisplayCommand(ctx context.Context, chatID int64, threadID int, arg string) {
+	locale := c.locale(chatID)
+	var reply string
+	switch arg {
+	case "":
+		reply = i18n.T(locale, "display.current", c.displayMode(chatID))
+	case DisplayStream, DisplaySegments, DisplayProgress, DisplayQuiet:
+		c.displayModes.Store(chatID, arg)
+		reply = i18n.T(locale, "display.set", arg)
+	default:
+		reply = i18n.T(locale, "display.unknown", arg)
+	}
+	m := tu.Message(tu.ID(chatID), reply)
+	m.MessageThreadID = threadID
+	c.bot.SendMessage(ctx, m)
+}
+
+// GetProgressContent summarizes the turn in a few lines for the progress
+// display: the step, the tools running, and how many are done.
+func (s *StreamState) GetProgressContent(locale string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	header := i18n.T(locale, "progress.working")
+	if s.iteration > 1 {
+		header = i18n.T(locale, "progress.working_step", s.iteration)
+	}
+	lines := []string{header}
+
+	var running []string
+	var done, failed int
+	for _, id := range s.toolCallList {
+		part := s.toolCalls[id]
+		if part == nil {
+			continue
+		}
+		switch part.State["status"] {
+		case "completed":
+			done++
+		case "error":
+			failed++
+		default:
+			running = append(running, "`"+part.ToolName+"`")
+		}
+	}
+	if len(running) > 0 {
+		lines = append(lines, i18n.T(locale, "progress.running", strings.Join(running, ", ")))
+	}
+	if done > 0 {
+		lines = append(lines, i18n.N(locale, "progress.done", done))
+	}
+	if failed > 0 {
+		lines = append(lines, i18n.N(locale, "progress.failed", failed))
+	}
+
+	var agents int
+	for _, label := range s.subagentList {
+		if status, _ := s.subagents[label].State["status"].(string); status == "started" || status == "running" {
+			agents++
+		}
+	}
+	if agents > 0 {
+		lines = append(lines, i18n.N(locale, "progress.subagents", agents))
+	}
+	return strings.Join(lines, "\n")
+}
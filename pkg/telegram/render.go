@@ -0,0 +1,130 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// renderBuffer sits between a chat's stream events and its messages. Events
+// update the chat's StreamState right away, and a goroutine of the chat's own
+// edits the message as fast as its throttle allows, so a rate-limited chat
+// never holds up the bus or other chats. Deltas that arrive while an edit
+// waits are coalesced into the next frame and the frames in between are
+// dropped; tool events only make that frame urgent, since every frame shows
+// the whole state. Sends such as approvals, errors, and the final answer are
+// queued and always delivered, in order.
+type renderBuffer struct {
+	c      *TelegramChannel
+	chatID int64
+
+	mu      sync.Mutex
+	state   *StreamState
+	dirty   bool
+	urgent  bool
+	sends   []func(context.Context)
+	running bool
+	wake    chan struct{}
+}
+
+// renderBuffer returns the buffer of a chat, or of a forum topic in it;
+// topics stream side by side and share only the chat's throttle.
+func (c *TelegramChannel) renderBuffer(chatID int64, threadID int) *renderBuffer {
+	key := chatKey(chatID, threadID)
+	if b, ok := c.renderers.Load(key); ok {
+		return b.(*renderBuffer)
+	}
+	b, _ := c.renderers.LoadOrStore(key, &renderBuffer{c: c, chatID: chatID, wake: make(chan struct{}, 1)})
+	return b.(*renderBuffer)
+}
+
+// frame asks for the message to show state. Only the latest request counts.
+func (b *renderBuffer) frame(ctx context.Context, state *StreamState, urgent bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = state
+	b.dirty = true
+	b.urgent = b.urgent || urgent
+	b.start(ctx)
+}
+
+// send queues fn behind the sends before it; sends go ahead of a waiting
+// frame. The final answer and errors end the turn, so they drop that frame.
+func (b *renderBuffer) send(ctx context.Context, fn func(context.Context), final bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if final {
+		b.state, b.dirty, b.urgent = nil, false, false
+	}
+	b.sends = append(b.sends, fn)
+	b.start(ctx)
+}
+
+func (b *renderBuffer) start(ctx context.Context) {
+	if b.running {
+		select {
+		case b.wake <- struct{}{}:
+		default:
+		}
+		return
+	}
+	b.running = true
+	go b.run(ctx)
+}
+
+func (b *renderBuffer) run(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Telegram: rendering chat %d panicked: %v\n%s", b.chatID, r, debug.Stack())
+			b.mu.Lock()
+			b.state, b.dirty, b.urgent, b.sends = nil, false, false, nil
+			b.running = false
+			b.mu.Unlock()
+		}
+	}()
+	for {
+		b.mu.Lock()
+		if ctx.Err() != nil || (!b.dirty && len(b.sends) == 0) {
+			b.running = false
+			b.mu.Unlock()
+			return
+		}
+		if sends := b.sends; len(sends) > 0 {
+			b.sends = nil
+			b.mu.Unlock()
+			for _, fn := range sends {
+				fn(ctx)
+			}
+			continue
+		}
+		state := b.state
+		wait := b.c.throttle(b.chatID).wait(time.Now(), b.urgent)
+		if wait == 0 {
+			b.dirty, b.urgent = false, false
+		}
+		b.mu.Unlock()
+
+		if wait == 0 {
+			b.c.updateStreamMessage(ctx, b.chatID, state)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		case <-b.wake:
+		}
+	}
+}
+
+// waitBlocked waits out a 429 back-off of the chat before a send that has to
+// go through.
+func (c *TelegramChannel) waitBlocked(ctx context.Context, chatID int64) {
+	if wait := c.throttle(chatID).blocked(time.Now()); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+		}
+	}
+}
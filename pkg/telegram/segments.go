@@ -0,0 +1,198 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+
+	"github.com/nene-agent/nene/pkg/i18n"
+)
+
+// The segments display posts the reply in pieces as they are finished, a
+// paragraph or a tool at a time, instead of editing one message over and
+// over. Each piece shows up in notifications with its own preview, and no
+// edits means no edit storms.
+
+// postSegments queues the finished pieces of the turn behind the chat's
+// other sends.
+func (c *TelegramChannel) postSegments(ctx context.Context, chatID int64, state *StreamState) {
+	threadID := state.GetThreadID()
+	for _, segment := range state.takeSegments(false) {
+		c.renderBuffer(chatID, threadID).send(ctx, func(ctx context.Context) {
+			if id := c.sendSegment(ctx, chatID, threadID, segment, nil); id != 0 {
+				state.setLastSegment(id, segment)
+			}
+		}, false)
+	}
+}
+
+// finalizeSegments posts what is left of the reply at the end of the turn.
+// The last piece carries the suggested replies, and the "View Details"
+// button when tools were used; when nothing is left, the piece posted last
+// gets them.
+func (c *TelegramChannel) finalizeSegments(ctx context.Context, chatID int64, state *StreamState) {
+	threadID := state.GetThreadID()
+	keyboard := c.finalKeyboard(chatID, state, len(state.toolCalls) > 0)
+	segments := state.takeSegments(true)
+	if state.Digest() {
+		if digest := state.toolDigest(c.locale(chatID)); digest != "" {
+			segments = append(segments, digest)
+		}
+	}
+	if len(segments) == 0 {
+		if keyboard == nil {
+			return
+		}
+		id, last := state.lastSegmentSent()
+		if id == 0 {
+			segments = []string{i18n.T(c.locale(chatID), "stream.completed")}
+		} else {
+			c.bot.EditMessageReplyMarkup(ctx, &telego.EditMessageReplyMarkupParams{
+				ChatID:      tu.ID(chatID),
+				MessageID:   id,
+				ReplyMarkup: keyboard,
+			})
+			c.finishSegment(ctx, chatID, threadID, id, last, state)
+			return
+		}
+	}
+	for i, segment := range segments {
+		if i < len(segments)-1 {
+			c.sendSegment(ctx, chatID, threadID, segment, nil)
+			continue
+		}
+		c.finishSegment(ctx, chatID, threadID, c.sendSegment(ctx, chatID, threadID, segment, keyboard), segment, state)
+	}
+}
+
+// finishSegment keeps the details and suggestions of the last piece.
+func (c *TelegramChannel) finishSegment(ctx context.Context, chatID int64, threadID, messageID int, segment string, state *StreamState) {
+	if messageID != 0 {
+		rendered, mode := c.render(chatID, segment)
+		c.saveDetails(ctx, chatID, messageID, state, rendered, mode)
+	}
+	c.offerSuggestions(chatID, threadID, messageID, state)
+}
+
+// sendSegment sends one piece of a reply and returns its message ID, or 0
+// if it could not be sent.
+func (c *TelegramChannel) sendSegment(ctx context.Context, chatID int64, threadID int, text string, markup *telego.InlineKeyboardMarkup) int {
+	rendered, mode := c.render(chatID, text)
+	msg := tu.Message(tu.ID(chatID), rendered)
+	msg.MessageThreadID = threadID
+	msg.ParseMode = mode
+	if markup != nil {
+		msg.ReplyMarkup = markup
+	}
+
+	t := c.throttle(chatID)
+	c.waitBlocked(ctx, chatID)
+	sent, err := c.bot.SendMessage(ctx, msg)
+	t.sent(time.Now())
+	if wait, ok := retryAfter(err); ok {
+		t.limited(time.Now(), wait)
+		c.waitBlocked(ctx, chatID)
+		sent, err = c.bot.SendMessage(ctx, msg)
+	}
+	if err != nil && mode != "" {
+		msg.Text, msg.ParseMode = text, ""
+		sent, err = c.bot.SendMessage(ctx, msg)
+	}
+	if err != nil {
+		fmt.Printf("Telegram: failed to send reply segment: %v\n", err)
+		return 0
+	}
+	return sent.MessageID
+}
+
+func (s *StreamState) setLastSegment(messageID int, segment string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messageID = messageID
+	s.lastSegment = segment
+}
+
+func (s *StreamState) lastSegmentSent() (int, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.messageID, s.lastSegment
+}
+
+// takeSegments returns the pieces of the reply that are finished and not
+// yet posted: whole paragraphs of text, and tools that are done unless the
+// chat gets a digest instead. With final set, whatever text is left counts
+// as finished.
+func (s *StreamState) takeSegments(final bool) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.posted == nil {
+		s.posted = make(map[*Part]int)
+		s.postedTools = make(map[string]bool)
+	}
+
+	var segments []string
+	for _, part := range s.textParts {
+		start, end := s.posted[part], len(part.Text)
+		if !part.Done && !final {
+			end = paragraphEnd(part.Text, start)
+		}
+		if end <= start {
+			continue
+		}
+		s.posted[part] = end
+		if text := strings.TrimSpace(part.Text[start:end]); text != "" {
+			segments = append(segments, text)
+		}
+	}
+	for _, id := range s.toolCallList {
+		part := s.toolCalls[id]
+		if part == nil || s.postedTools[id] || s.digest {
+			continue
+		}
+		switch part.State["status"] {
+		case "completed":
+			segments = append(segments, "🔧 "+part.ToolName+" ✅")
+		case "error":
+			line := "🔧 " + part.ToolName + " ❌"
+			if msg, _ := part.State["error"].(string); msg != "" {
+				line += " " + firstLine(msg)
+			}
+			segments = append(segments, line)
+		default:
+			continue
+		}
+		s.postedTools[id] = true
+	}
+	return segments
+}
+
+// paragraphEnd returns where the last finished paragraph after start ends
+// in text, or start if none has. Blank lines inside code blocks do not end
+// a paragraph.
+func paragraphEnd(text string, start int) int {
+	end := len(text)
+	for end > start {
+		i := strings.LastIndex(text[start:end], "\n\n")
+		if i < 0 {
+			break
+		}
+		i += start
+		if strings.Count(text[:i], "```")%2 == 0 {
+			return i + 2
+		}
+		end = i
+	}
+	return start
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	if runes := []rune(line); len(runes) > 100 {
+		line = string(runes[:100]) + "…"
+	}
+	return line
+}
@@ -0,0 +1,81 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mymmrac/telego"
+)
+
+// What happens to messages that are only emoji, or a sticker.
+const (
+	// EmojiAnswer sends them to the agent like any other message.
+	EmojiAnswer = "answer"
+	// EmojiPrivate answers them in private chats only; in groups they are
+	// usually reactions to someone else.
+	EmojiPrivate = "private"
+	// EmojiIgnore leaves them unanswered everywhere.
+	EmojiIgnore = "ignore"
+)
+
+// stickerContent describes a sticker or GIF as text for the agent, which
+// cannot see either: a sticker by the emoji it stands for, a GIF by its
+// file name and length. It is empty for other messages.
+func stickerContent(m *telego.Message) string {
+	switch {
+	case m.Sticker != nil:
+		s := m.Sticker
+		desc := "[Sticker"
+		if s.Emoji != "" {
+			desc += " " + s.Emoji
+		}
+		if s.SetName != "" {
+			desc += " from the set " + s.SetName
+		}
+		return desc + "]"
+	case m.Animation != nil:
+		a := m.Animation
+		desc := "[GIF"
+		if a.FileName != "" {
+			desc += " " + a.FileName
+		}
+		if a.Duration > 0 {
+			desc += fmt.Sprintf(", %ds", a.Duration)
+		}
+		return desc + "]"
+	}
+	return ""
+}
+
+// skipEmoji reports whether a message should go unanswered under the
+// telegram.emoji_only setting.
+func (c *TelegramChannel) skipEmoji(m *telego.Message, content string) bool {
+	if m.Sticker == nil && !isEmojiOnly(content) {
+		return false
+	}
+	switch c.config.EmojiOnly {
+	case EmojiIgnore:
+		return true
+	case EmojiPrivate:
+		return m.Chat.Type != telego.ChatTypePrivate
+	}
+	return false
+}
+
+// isEmojiOnly reports whether s has at least one emoji and nothing else
+// but spaces and the joiners, selectors, and modifiers emoji are built of.
+func isEmojiOnly(s string) bool {
+	found := false
+	for _, r := range strings.TrimSpace(s) {
+		switch {
+		case unicode.Is(unicode.So, r):
+			found = true
+		case r == '\u200d' || unicode.Is(unicode.Variation_Selector, r),
+			unicode.Is(unicode.Sk, r), unicode.IsSpace(r):
+		default:
+			return false
+		}
+	}
+	return found
+}
@@ -0,0 +1,143 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+
+	"github.com/nene-agent/nene/pkg/i18n"
+)
+
+// suggestionSet is the replies offered under a chat's latest answer. Only
+// the latest answer's buttons work, so a chat keeps one set.
+type suggestionSet struct {
+	messageID int
+	replies   []string
+}
+
+func (s *StreamState) SetSuggestions(replies []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.suggestions = replies
+}
+
+func (s *StreamState) Suggestions() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.suggestions
+}
+
+// finalKeyboard is the keyboard of a turn's answer: a button for each
+// suggested reply, then "View Details" when details is set. It is nil when
+// there is neither.
+func (c *TelegramChannel) finalKeyboard(chatID int64, state *StreamState, details bool) *telego.InlineKeyboardMarkup {
+	rows := suggestionRows(state.Suggestions())
+	if details {
+		rows = append(rows, c.detailsButton(chatID).InlineKeyboard...)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tu.InlineKeyboard(rows...)
+}
+
+func suggestionRows(replies []string) [][]telego.InlineKeyboardButton {
+	var rows [][]telego.InlineKeyboardButton
+	for i, r := range replies {
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(r).WithCallbackData("suggest:"+strconv.Itoa(i)),
+		))
+	}
+	return rows
+}
+
+// offerSuggestions makes the suggestion buttons of the answer messageID
+// work, replacing those of the chat's earlier answers.
+func (c *TelegramChannel) offerSuggestions(chatID int64, threadID, messageID int, state *StreamState) {
+	key := chatKey(chatID, threadID)
+	if replies := state.Suggestions(); len(replies) > 0 && messageID != 0 {
+		c.suggestions.Store(key, &suggestionSet{messageID: messageID, replies: replies})
+	} else {
+		c.suggestions.Delete(key)
+	}
+}
+
+// pendingSuggestions returns the replies still offered under messageID.
+func (c *TelegramChannel) pendingSuggestions(chatID int64, threadID, messageID int) []string {
+	v, ok := c.suggestions.Load(chatKey(chatID, threadID))
+	if !ok || v.(*suggestionSet).messageID != messageID {
+		return nil
+	}
+	return v.(*suggestionSet).replies
+}
+
+// handleSuggestionCallback sends the reply behind a suggestion button as
+// the next message of whoever pressed it, and takes the suggestions away.
+func (c *TelegramChannel) handleSuggestionCallback(ctx context.Context, callback *telego.CallbackQuery) {
+	locale := c.callbackLocale(callback)
+	msg, ok := callback.Message.(*telego.Message)
+	if !ok {
+		c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{
+			CallbackQueryID: callback.ID,
+			Text:            i18n.T(locale, "details.inaccessible"),
+			ShowAlert:       true,
+		})
+		return
+	}
+	chatID, threadID := msg.Chat.ID, messageThread(msg)
+
+	user := callback.From
+	senderID := fmt.Sprintf("%d", user.ID)
+	if user.Username != "" {
+		senderID += "|" + user.Username
+	}
+	if !c.IsAllowedIn(senderID, chatKey(chatID, threadID)) {
+		c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{CallbackQueryID: callback.ID})
+		return
+	}
+
+	replies := c.pendingSuggestions(chatID, threadID, msg.MessageID)
+	i, err := strconv.Atoi(strings.TrimPrefix(callback.Data, "suggest:"))
+	if err != nil || i < 0 || i >= len(replies) {
+		c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{
+			CallbackQueryID: callback.ID,
+			Text:            i18n.T(locale, "suggest.gone"),
+			ShowAlert:       true,
+		})
+		return
+	}
+	reply := replies[i]
+	c.suggestions.Delete(chatKey(chatID, threadID))
+	c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{CallbackQueryID: callback.ID, Text: reply})
+
+	// Only the buttons of other kinds, such as "View Details", stay.
+	var rows [][]telego.InlineKeyboardButton
+	if msg.ReplyMarkup != nil {
+		for _, row := range msg.ReplyMarkup.InlineKeyboard {
+			if len(row) > 0 && !strings.HasPrefix(row[0].CallbackData, "suggest:") {
+				rows = append(rows, row)
+			}
+		}
+	}
+	edit := &telego.EditMessageReplyMarkupParams{ChatID: tu.ID(chatID), MessageID: msg.MessageID}
+	if len(rows) > 0 {
+		edit.ReplyMarkup = tu.InlineKeyboard(rows...)
+	}
+	c.bot.EditMessageReplyMarkup(ctx, edit)
+
+	metadata := map[string]string{
+		"language_code": user.LanguageCode,
+		"user_id":       fmt.Sprintf("%d", user.ID),
+		"username":      user.Username,
+		"first_name":    user.FirstName,
+	}
+	if threadID != 0 {
+		metadata["thread_id"] = strconv.Itoa(threadID)
+		metadata["topic"] = c.topicName(msg)
+	}
+	c.dispatch(ctx, senderID, chatID, threadID, reply, nil, metadata)
+}
@@ -6,36 +6,96 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"regexp"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mymmrac/telego"
 	tu "github.com/mymmrac/telego/telegoutil"
 
 	"github.com/nene-agent/nene/pkg/bus"
+	"github.com/nene-agent/nene/pkg/channel"
+	"github.com/nene-agent/nene/pkg/i18n"
+	"github.com/nene-agent/nene/pkg/tool"
 )
 
 type TelegramConfig struct {
-	Token      string   `json:"token"`
-	Proxy      string   `json:"proxy"`
-	AllowFrom  []string `json:"allow_from"`
-	StreamMode bool     `json:"stream_mode"`
+	Token      string                  `json:"token"`
+	Proxy      string                  `json:"proxy"`
+	AllowFrom  []string                `json:"allow_from"`
+	Owners     []string                `json:"owners"`
+	StreamMode bool                    `json:"stream_mode"`
+	Inline     bool                    `json:"inline"`
+	RateLimit  channel.RateLimitConfig `json:"rate_limit"`
+	// Display is what chats see during a turn when stream mode is off:
+	// "quiet" (default), "progress", or "segments"; /display changes it per
+	// chat.
+	Display string `json:"display"`
+	// ParseMode is "html" (default) or "markdownv2"; /format changes it per
+	// chat.
+	ParseMode string `json:"parse_mode"`
+	// EditIntervalMs is the starting gap between edits of a streamed reply
+	// (default 1000); it grows while Telegram answers 429.
+	EditIntervalMs int `json:"edit_interval_ms"`
+	// MaxEditsPerMinute caps stream edits per chat (default 20).
+	MaxEditsPerMinute int `json:"max_edits_per_minute"`
+	// DetailsDB is the SQLite file tool details are kept in so "View
+	// Details" keeps working after a restart; empty keeps them in memory.
+	DetailsDB string `json:"details_db"`
+	// DetailsTTLHours is how long tool details are kept (default 168).
+	DetailsTTLHours int `json:"details_ttl_hours"`
+	// OffsetFile keeps the last handled update ID across restarts.
+	OffsetFile string `json:"offset_file"`
+	// Pairing offers users who are not allowed a "request access" button
+	// in private chats; owners answer in PairingChat, or in their own
+	// private chats when it is zero. See channel.PairingStore.
+	Pairing     bool  `json:"pairing"`
+	PairingChat int64 `json:"pairing_chat"`
+	// MediaDir is where photos and files sent to the bot are saved so the
+	// agent can open them; empty leaves them out.
+	MediaDir string `json:"media_dir"`
+	// EmojiOnly is what happens to messages that are only emoji, and to
+	// stickers: "answer" (default), "private", or "ignore".
+	EmojiOnly string `json:"emoji_only"`
+	// Digest hides tool calls while a turn runs and sums them up in a table
+	// under the answer instead; /digest changes it per chat.
+	Digest bool `json:"digest"`
 }
 
 type StreamState struct {
 	messageID       int
 	chatID          int64
+	threadID        int
 	mu              sync.RWMutex
 	parts           map[string]*Part
 	toolCalls       map[string]*Part
 	toolCallList    []string
+	subagents       map[string]*Part
+	subagentList    []string
 	currentText     *Part
+	textParts       []*Part
+	plan            []bus.PlanStep
+	reasoning       strings.Builder
 	iteration       int
 	isStreaming     bool
 	lastUpdate      time.Time
 	lastMessageSent time.Time
+	// posted tracks what the segments display has sent: how much of each
+	// text part, which tools, and the text of the last piece, whose
+	// message is messageID.
+	posted      map[*Part]int
+	postedTools map[string]bool
+	lastSegment string
+	// digest hides tool calls until the answer; see toolDigest.
+	digest bool
+	// suggestions are replies offered as buttons under the answer.
+	suggestions []string
 }
 
 type Part struct {
@@ -45,6 +105,10 @@ type Part struct {
 	ToolName   string
 	ToolCallID string
 	State      map[string]interface{}
+	// Iteration is the agent step a text part belongs to, and Done is set
+	// once its text is complete.
+	Iteration int
+	Done      bool
 }
 
 func NewStreamState() *StreamState {
@@ -52,6 +116,7 @@ func NewStreamState() *StreamState {
 		parts:           make(map[string]*Part),
 		toolCalls:       make(map[string]*Part),
 		toolCallList:    make([]string, 0),
+		subagents:       make(map[string]*Part),
 		lastUpdate:      time.Now(),
 		lastMessageSent: time.Time{},
 	}
@@ -87,10 +152,40 @@ func (s *StreamState) GetChatID() int64 {
 	return s.chatID
 }
 
+// SetThreadID sets the forum topic the stream's messages go to.
+func (s *StreamState) SetThreadID(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.threadID = id
+}
+
+func (s *StreamState) GetThreadID() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.threadID
+}
+
 func (s *StreamState) AddPart(part *Part) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.parts[part.ID] = part
+	if part.Type == "text" {
+		s.textParts = append(s.textParts, part)
+	}
+}
+
+func (s *StreamState) EndPart(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if part, ok := s.parts[id]; ok {
+		part.Done = true
+	}
+}
+
+func (s *StreamState) SetIteration(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.iteration = n
 }
 
 func (s *StreamState) GetPart(id string) *Part {
@@ -114,6 +209,13 @@ func (s *StreamState) UpdatePartDelta(partID string, delta string) {
 	}
 }
 
+func (s *StreamState) AppendReasoning(delta string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reasoning.WriteString(delta)
+	s.lastUpdate = time.Now()
+}
+
 func (s *StreamState) AddToolCall(id string, part *Part) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -121,40 +223,126 @@ func (s *StreamState) AddToolCall(id string, part *Part) {
 	s.toolCallList = append(s.toolCallList, id)
 }
 
+func (s *StreamState) UpdateSubagent(label, status string, iteration int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	part, ok := s.subagents[label]
+	if !ok {
+		part = &Part{
+			ID:    label,
+			Type:  "subagent",
+			State: make(map[string]interface{}),
+		}
+		s.subagents[label] = part
+		s.subagentList = append(s.subagentList, label)
+	}
+	part.State["status"] = status
+	if iteration > 0 {
+		part.State["iteration"] = iteration
+	}
+}
+
+func (s *StreamState) SetPlan(plan []bus.PlanStep) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plan = plan
+}
+
 func (s *StreamState) GetToolCall(id string) *Part {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.toolCalls[id]
 }
 
+// SetToolStatus records how a tool call ended, with its output or error
+// under key.
+func (s *StreamState) SetToolStatus(id, status, key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if part, ok := s.toolCalls[id]; ok {
+		part.State["status"] = status
+		part.State[key] = value
+		part.State["finished"] = time.Now()
+	}
+}
+
+func (s *StreamState) SetDigest(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.digest = on
+}
+
+func (s *StreamState) Digest() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.digest
+}
+
 func (s *StreamState) GetFinalText() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	_, current := s.textSections()
+	if current == nil {
+		return ""
+	}
+	return current.Text
+}
 
+// textSections splits the text parts into those of earlier steps and the
+// one being written, which is the latest with any text.
+func (s *StreamState) textSections() (history []*Part, current *Part) {
 	if s.currentText != nil && s.currentText.Text != "" {
-		return s.currentText.Text
+		current = s.currentText
+	} else {
+		for i := len(s.textParts) - 1; i >= 0; i-- {
+			if s.textParts[i].Text != "" {
+				current = s.textParts[i]
+				break
+			}
+		}
 	}
-
-	var finalText string
-	for _, part := range s.parts {
-		if part.Type == "text" && len(part.Text) > len(finalText) {
-			finalText = part.Text
+	for _, part := range s.textParts {
+		if part == current {
+			break
+		}
+		if strings.TrimSpace(part.Text) != "" {
+			history = append(history, part)
 		}
 	}
-	return finalText
+	return history, current
 }
 
-func (s *StreamState) GetDisplayContent() string {
+// collapsedText shows the text of an earlier step as one line.
+func collapsedText(part *Part, locale string) string {
+	const maxLen = 60
+	line := strings.TrimSpace(part.Text)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	if runes := []rune(line); len(runes) > maxLen {
+		line = string(runes[:maxLen]) + "…"
+	}
+	if part.Iteration > 0 {
+		return i18n.T(locale, "stream.step_text", part.Iteration, line)
+	}
+	return "💬 " + line
+}
+
+func (s *StreamState) GetDisplayContent(locale string) string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	var parts []string
 
 	if s.iteration > 0 {
-		parts = append(parts, fmt.Sprintf("🔄 Step %d", s.iteration))
+		parts = append(parts, i18n.T(locale, "stream.step", s.iteration))
+	}
+
+	if len(s.plan) > 0 {
+		parts = append(parts, i18n.T(locale, "stream.plan")+"\n"+tool.FormatPlan(s.plan))
 	}
 
-	if len(s.toolCalls) > 0 {
+	if len(s.toolCalls) > 0 && !s.digest {
 		var toolIDsToShow []string
 		if len(s.toolCallList) <= 3 {
 			toolIDsToShow = s.toolCallList
@@ -192,7 +380,7 @@ func (s *StreamState) GetDisplayContent() string {
 				if len(argsStr) > maxInputLen {
 					argsStr = argsStr[:maxInputLen] + "..."
 				}
-				toolBlock.WriteString("```\nInput:\n")
+				toolBlock.WriteString("```\n" + i18n.T(locale, "stream.input") + "\n")
 				toolBlock.WriteString(argsStr)
 				toolBlock.WriteString("\n```")
 			}
@@ -203,7 +391,7 @@ func (s *StreamState) GetDisplayContent() string {
 				if len(output) > maxOutputLen {
 					displayOutput = output[:maxOutputLen] + "..."
 				}
-				toolBlock.WriteString("```\nOutput:\n")
+				toolBlock.WriteString("```\n" + i18n.T(locale, "stream.output") + "\n")
 				toolBlock.WriteString(displayOutput)
 				toolBlock.WriteString("\n```")
 			}
@@ -212,19 +400,39 @@ func (s *StreamState) GetDisplayContent() string {
 		}
 
 		if len(s.toolCallList) > 3 {
-			parts = append(parts, fmt.Sprintf("📋 ... and %d more", len(s.toolCallList)-3))
+			parts = append(parts, i18n.T(locale, "stream.more", len(s.toolCallList)-3))
 		}
 	}
 
-	finalText := ""
-	if s.currentText != nil && s.currentText.Text != "" {
-		finalText = s.currentText.Text
-	} else {
-		for _, part := range s.parts {
-			if part.Type == "text" && len(part.Text) > len(finalText) {
-				finalText = part.Text
-			}
+	for _, label := range s.subagentList {
+		part := s.subagents[label]
+		status, _ := part.State["status"].(string)
+		iteration, _ := part.State["iteration"].(int)
+		line := "🤖 " + label
+		switch status {
+		case "started":
+			line += " ⏳"
+		case "running":
+			line += " 🔄 " + i18n.T(locale, "stream.subagent_step", iteration)
+		case "finished":
+			line += " ✅ " + i18n.T(locale, "stream.subagent_done", iteration)
+		case "failed":
+			line += " ❌"
 		}
+		parts = append(parts, line)
+	}
+
+	history, current := s.textSections()
+	finalText := ""
+	if current != nil {
+		finalText = current.Text
+	}
+	for _, part := range history {
+		parts = append(parts, collapsedText(part, locale))
+	}
+
+	if reasoning := s.reasoning.String(); reasoning != "" {
+		parts = append(parts, reasoningSummary(reasoning, finalText != "", locale))
 	}
 
 	if finalText != "" {
@@ -237,16 +445,55 @@ func (s *StreamState) GetDisplayContent() string {
 	return strings.Join(parts, "\n")
 }
 
+// reasoningSummary renders model reasoning as an abbreviated section: the
+// tail of the thought while thinking, collapsed to one line once the answer
+// has started streaming.
+func reasoningSummary(reasoning string, answered bool, locale string) string {
+	if answered {
+		return i18n.T(locale, "stream.thought", len(strings.Fields(reasoning)))
+	}
+
+	const maxReasoningLen = 300
+	tail := strings.TrimSpace(reasoning)
+	if runes := []rune(tail); len(runes) > maxReasoningLen {
+		tail = "…" + string(runes[len(runes)-maxReasoningLen:])
+	}
+	return i18n.T(locale, "stream.thinking") + "\n" + tail
+}
+
 type TelegramChannel struct {
-	*BaseChannel
+	*channel.BaseChannel
 	bot          *telego.Bot
 	config       TelegramConfig
+	streamMode   atomic.Bool
 	streamStates sync.Map
-	toolDetails  sync.Map
+	details      *detailStore
+	seq          *bus.SeqChecker
+	updates      *updateLog
+	parseModes   sync.Map
+	displayModes sync.Map
+	throttles    sync.Map
+	renderers    sync.Map
+	digests      sync.Map
+	// suggestions holds the latest suggestionSet of each chatKey.
+	suggestions sync.Map
+	// topics holds forum topic names by chatKey.
+	topics sync.Map
+	// files downloads media sent to the bot, through the proxy if set.
+	files  *http.Client
+	albums albums
+
+	inlineMu      sync.Mutex
+	asker         InlineAsker
+	inlinePending map[string]*inlineRequest
+
+	pairingMu      sync.Mutex
+	pairingPending map[int64]*pairingRequest
 }
 
 type ToolDetails struct {
 	OriginalContent string
+	ParseMode       string
 	Tools           []ToolDetailItem
 }
 
@@ -260,17 +507,19 @@ type ToolDetailItem struct {
 
 func NewTelegramChannel(cfg TelegramConfig, messageBus *bus.MessageBus) (*TelegramChannel, error) {
 	var opts []telego.BotOption
+	files := http.DefaultClient
 
 	if cfg.Proxy != "" {
 		proxyURL, parseErr := url.Parse(cfg.Proxy)
 		if parseErr != nil {
 			return nil, fmt.Errorf("invalid proxy URL %q: %w", cfg.Proxy, parseErr)
 		}
-		opts = append(opts, telego.WithHTTPClient(&http.Client{
+		files = &http.Client{
 			Transport: &http.Transport{
 				Proxy: http.ProxyURL(proxyURL),
 			},
-		}))
+		}
+		opts = append(opts, telego.WithHTTPClient(files))
 	}
 
 	bot, err := telego.NewBot(cfg.Token, opts...)
@@ -278,27 +527,52 @@ func NewTelegramChannel(cfg TelegramConfig, messageBus *bus.MessageBus) (*Telegr
 		return nil, fmt.Errorf("failed to create telegram bot: %w", err)
 	}
 
-	base := NewBaseChannel("telegram", messageBus, cfg.AllowFrom)
+	base := channel.NewBaseChannel("telegram", messageBus, cfg.AllowFrom)
+	base.SetRateLimit(cfg.RateLimit)
+	base.SetOwners(cfg.Owners)
+
+	details, err := openDetailStore(cfg.DetailsDB, time.Duration(cfg.DetailsTTLHours)*time.Hour)
+	if err != nil {
+		fmt.Printf("Tool details will not survive a restart: %v\n", err)
+	}
+
+	c := &TelegramChannel{
+		BaseChannel:    base,
+		bot:            bot,
+		files:          files,
+		config:         cfg,
+		seq:            bus.NewSeqChecker(),
+		updates:        newUpdateLog(cfg.OffsetFile),
+		details:        details,
+		inlinePending:  make(map[string]*inlineRequest),
+		pairingPending: make(map[int64]*pairingRequest),
+		albums:         albums{pending: make(map[string]*album)},
+	}
+	c.streamMode.Store(cfg.StreamMode)
+	return c, nil
+}
 
-	return &TelegramChannel{
-		BaseChannel: base,
-		bot:         bot,
-		config:      cfg,
-	}, nil
+func (c *TelegramChannel) StreamMode() bool {
+	return c.streamMode.Load()
+}
+
+func (c *TelegramChannel) SetStreamMode(enabled bool) {
+	c.streamMode.Store(enabled)
 }
 
 func (c *TelegramChannel) Start(ctx context.Context) error {
 	updates, err := c.bot.UpdatesViaLongPolling(ctx, &telego.GetUpdatesParams{
+		Offset:  c.updates.offset(),
 		Timeout: 30,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to start long polling: %w", err)
 	}
 
-	c.setRunning(true)
+	c.SetRunning(true)
 	fmt.Printf("Telegram bot connected: @%s\n", c.bot.Username())
 
-	go c.handleStreamMessages(ctx)
+	go c.handleStreamMessages(ctx, c.Bus().ChannelStream(c.Name()))
 
 	go func() {
 		for {
@@ -310,11 +584,10 @@ func (c *TelegramChannel) Start(ctx context.Context) error {
 					fmt.Println("Updates channel closed")
 					return
 				}
-				if update.Message != nil {
-					c.handleMessage(ctx, update)
-				} else if update.CallbackQuery != nil {
-					c.handleCallbackQuery(ctx, update)
+				if c.updates.seenBefore(update.UpdateID) {
+					continue
 				}
+				c.handleUpdate(ctx, update)
 			}
 		}
 	}()
@@ -322,50 +595,97 @@ func (c *TelegramChannel) Start(ctx context.Context) error {
 	return nil
 }
 
+// handleUpdate dispatches one update. A panic while handling it is logged
+// and the update skipped, so one bad update does not stop the bot.
+func (c *TelegramChannel) handleUpdate(ctx context.Context, update telego.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Telegram: update %d panicked: %v\n%s", update.UpdateID, r, debug.Stack())
+		}
+	}()
+	if update.Message != nil {
+		c.handleMessage(ctx, update)
+	} else if update.CallbackQuery != nil {
+		c.handleCallbackQuery(ctx, update)
+	} else if update.InlineQuery != nil {
+		c.handleInlineQuery(ctx, update)
+	}
+}
+
 func (c *TelegramChannel) Stop(ctx context.Context) error {
 	fmt.Println("Stopping Telegram bot...")
-	c.setRunning(false)
+	c.SetRunning(false)
+	c.details.Close()
 	return nil
 }
 
-func (c *TelegramChannel) handleStreamMessages(ctx context.Context) {
+func (c *TelegramChannel) handleStreamMessages(ctx context.Context, stream <-chan bus.StreamMessage) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		default:
-			msg, ok := c.bus.SubscribeStream(ctx)
+		case msg, ok := <-stream:
 			if !ok {
-				continue
-			}
-
-			if msg.Channel != "telegram" {
-				continue
+				return
 			}
-
 			c.handleStreamEvent(ctx, msg)
 		}
 	}
 }
 
 func (c *TelegramChannel) handleStreamEvent(ctx context.Context, msg bus.StreamMessage) {
-	chatID, err := parseChatID(msg.ChatID)
+	chatID, threadID, err := parseChatRef(msg.ChatID)
 	if err != nil {
 		return
 	}
+	// A renderer bug ends the chat's display of the turn with an error
+	// instead of stopping every chat's stream.
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Telegram: %s event of chat %s panicked: %v\n%s", msg.Type, msg.ChatID, r, debug.Stack())
+			c.streamStates.Delete(msg.ChatID)
+			if msg.Type != bus.StreamEventError {
+				c.sendErrorMessage(ctx, chatID, threadID, bus.StreamMessage{Content: fmt.Sprintf("display failed: %v", r)})
+			}
+		}
+	}()
+	// A late delta would be appended in the wrong place, so it is dropped;
+	// lost events leave a gap that is only logged.
+	missed, stale := c.seq.Check(msg)
+	if stale {
+		fmt.Printf("Telegram: dropping out-of-order stream event %d of chat %s\n", msg.Seq, msg.ChatID)
+		return
+	}
+	if missed > 0 {
+		fmt.Printf("Telegram: %d stream event(s) of chat %s were lost before event %d\n", missed, msg.ChatID, msg.Seq)
+	}
 
 	stateInterface, _ := c.streamStates.LoadOrStore(msg.ChatID, NewStreamState())
 	state := stateInterface.(*StreamState)
+	state.SetThreadID(threadID)
+	if msg.Type == bus.StreamEventStart {
+		state.SetDigest(c.digest(chatID))
+	}
+	// Tool events change nothing on screen in digest mode.
+	toolUpdate := func() {
+		if !state.Digest() {
+			c.streamUpdate(ctx, chatID, state, true)
+		}
+	}
 
 	switch msg.Type {
 	case bus.StreamEventTextStart:
 		part := &Part{
-			ID:   msg.Delta,
-			Type: "text",
-			Text: "",
+			ID:        msg.Delta,
+			Type:      "text",
+			Text:      "",
+			Iteration: msg.Iteration,
 		}
 		state.AddPart(part)
 		state.SetCurrentText(part)
+		if msg.Iteration > 0 {
+			state.SetIteration(msg.Iteration)
+		}
 
 	case bus.StreamEventTextDelta:
 		if part := state.GetPart(msg.Delta); part != nil {
@@ -373,80 +693,181 @@ func (c *TelegramChannel) handleStreamEvent(ctx context.Context, msg bus.StreamM
 		} else {
 			state.UpdatePartDelta("main", msg.Content)
 		}
-		if state.lastMessageSent.IsZero() || time.Since(state.lastMessageSent) > 500*time.Millisecond {
-			c.updateStreamMessage(ctx, chatID, state)
-		}
+		c.streamUpdate(ctx, chatID, state, false)
 
 	case bus.StreamEventTextEnd:
-		c.updateStreamMessage(ctx, chatID, state)
+		state.EndPart(msg.Delta)
+		c.streamUpdate(ctx, chatID, state, true)
+
+	case bus.StreamEventReasoning:
+		state.AppendReasoning(msg.Content)
+		c.streamUpdate(ctx, chatID, state, false)
 
 	case bus.StreamEventToolCall:
+		// The plan and suggestions are shown by themselves; their tool
+		// calls would only crowd out the others.
+		if msg.ToolName == tool.PlanToolName || msg.ToolName == tool.SuggestToolName {
+			return
+		}
 		part := &Part{
 			ID:         msg.ToolCallID,
 			Type:       "tool",
 			ToolName:   msg.ToolName,
 			ToolCallID: msg.ToolCallID,
 			State: map[string]interface{}{
-				"status": "running",
-				"input":  msg.ToolArgs,
+				"status":  "running",
+				"input":   msg.ToolArgs,
+				"started": time.Now(),
 			},
 		}
 		state.AddPart(part)
 		state.AddToolCall(msg.ToolCallID, part)
-		c.updateStreamMessage(ctx, chatID, state)
+		toolUpdate()
 
 	case bus.StreamEventToolResult:
-		if part := state.GetToolCall(msg.ToolCallID); part != nil {
-			part.State["status"] = "completed"
-			part.State["output"] = msg.ToolResult
-		}
-		c.updateStreamMessage(ctx, chatID, state)
+		state.SetToolStatus(msg.ToolCallID, "completed", "output", msg.ToolResult)
+		toolUpdate()
+
+	case bus.StreamEventSubagent:
+		state.UpdateSubagent(msg.Label, msg.Status, msg.Iteration)
+		c.streamUpdate(ctx, chatID, state, msg.Status != "running")
 
 	case bus.StreamEventToolError:
-		if part := state.GetToolCall(msg.ToolCallID); part != nil {
-			part.State["status"] = "error"
-			part.State["error"] = msg.Error
+		errText := msg.Error
+		if text, ok := channel.ErrorText(c.locale(chatID), msg); ok {
+			errText = text
 		}
-		c.updateStreamMessage(ctx, chatID, state)
+		state.SetToolStatus(msg.ToolCallID, "error", "error", errText)
+		toolUpdate()
 
 	case bus.StreamEventFinish:
-		c.finalizeStreamMessage(ctx, chatID, state)
 		c.streamStates.Delete(msg.ChatID)
+		// The state is no longer shared, so the final edit can wait out a
+		// back-off in the chat's render buffer.
+		c.renderBuffer(chatID, threadID).send(ctx, func(ctx context.Context) {
+			c.waitBlocked(ctx, chatID)
+			c.finalizeStreamMessage(ctx, chatID, state)
+		}, true)
+		c.FinishTurn(msg.ChatID)
 
 	case bus.StreamEventError:
-		c.sendErrorMessage(ctx, chatID, msg.Content)
 		c.streamStates.Delete(msg.ChatID)
+		c.renderBuffer(chatID, threadID).send(ctx, func(ctx context.Context) {
+			c.sendErrorMessage(ctx, chatID, threadID, msg)
+		}, true)
+
+	case bus.StreamEventApproval:
+		c.renderBuffer(chatID, threadID).send(ctx, func(ctx context.Context) {
+			c.sendApprovalRequest(ctx, chatID, threadID, msg)
+		}, false)
+
+	case bus.StreamEventPlan:
+		state.SetPlan(msg.Plan)
+		c.streamUpdate(ctx, chatID, state, true)
+
+	case bus.StreamEventSuggestions:
+		state.SetSuggestions(msg.Suggestions)
+	}
+}
+
+func (c *TelegramChannel) sendApprovalRequest(ctx context.Context, chatID int64, threadID int, msg bus.StreamMessage) {
+	locale := c.locale(chatID)
+	text := fmt.Sprintf("🔐 <b>%s</b> (%s)\n\n<pre>%s</pre>", escapeHTML(msg.Label), escapeHTML(msg.ToolName), escapeHTML(msg.Content))
+	buttons := []telego.InlineKeyboardButton{
+		tu.InlineKeyboardButton(i18n.T(locale, "approval.approve")).WithCallbackData("approval:" + msg.ApprovalID + ":" + string(tool.DecisionApprove)),
+		tu.InlineKeyboardButton(i18n.T(locale, "approval.reject")).WithCallbackData("approval:" + msg.ApprovalID + ":" + string(tool.DecisionReject)),
+	}
+	rows := [][]telego.InlineKeyboardButton{buttons}
+	if msg.ApprovalRule != "" {
+		label := i18n.T(locale, "approval.always_button", channel.DescribeRule(msg.ToolName, msg.ApprovalRule))
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(label).WithCallbackData("approval:"+msg.ApprovalID+":"+string(tool.DecisionAlways)),
+		))
+	}
+	m := tu.Message(tu.ID(chatID), text)
+	m.MessageThreadID = threadID
+	m.ParseMode = telego.ModeHTML
+	m.ReplyMarkup = tu.InlineKeyboard(rows...)
+	if _, err := c.bot.SendMessage(ctx, m); err != nil {
+		fmt.Printf("Failed to send approval request: %v\n", err)
+	}
+}
+
+// handleApprovalCallback passes an approval button press to the gate and
+// replaces the buttons with the outcome.
+func (c *TelegramChannel) handleApprovalCallback(ctx context.Context, callback *telego.CallbackQuery) {
+	rest := strings.TrimPrefix(callback.Data, "approval:")
+	id, choice, _ := strings.Cut(rest, ":")
+	d, ok := tool.ParseDecision(choice)
+	locale := c.callbackLocale(callback)
+
+	senderID := fmt.Sprintf("%d", callback.From.ID)
+	if callback.From.Username != "" {
+		senderID += "|" + callback.From.Username
+	}
+	gate := c.ApprovalGate()
+	if !ok || gate == nil || !gate.Resolve(id, senderID, c.RoleOf(senderID), d, "") {
+		c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{
+			CallbackQueryID: callback.ID,
+			Text:            i18n.T(locale, "approval.gone"),
+			ShowAlert:       true,
+		})
+		return
+	}
+	c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{CallbackQueryID: callback.ID})
+
+	outcome := i18n.T(locale, map[tool.Decision]string{
+		tool.DecisionApprove: "approval.approved",
+		tool.DecisionAlways:  "approval.always",
+		tool.DecisionReject:  "approval.rejected",
+	}[d])
+	if msg, ok := callback.Message.(*telego.Message); ok {
+		edit := tu.EditMessageText(tu.ID(msg.Chat.ID), msg.MessageID, escapeHTML(msg.Text)+"\n\n<b>"+outcome+"</b>")
+		edit.ParseMode = telego.ModeHTML
+		c.bot.EditMessageText(ctx, edit)
 	}
 }
 
 func (c *TelegramChannel) updateStreamMessage(ctx context.Context, chatID int64, state *StreamState) {
-	content := state.GetDisplayContent()
+	locale := c.locale(chatID)
+	content := state.GetDisplayContent(locale)
+	if c.displayMode(chatID) == DisplayProgress {
+		content = state.GetProgressContent(locale)
+	}
 	if content == "" {
 		return
 	}
 
-	htmlContent := markdownToTelegramHTML(content)
+	rendered, mode := c.render(chatID, content)
 
-	const maxLength = 4000
-	if len(htmlContent) > maxLength {
-		htmlContent = htmlContent[:maxLength] + "\n\n<i>[Message truncated]</i>"
+	t := c.throttle(chatID)
+	messageID := state.GetMessageID()
+	if messageID == 0 {
+		t.sent(time.Now())
+		c.sendNewStreamMessage(ctx, chatID, state, rendered, mode)
+		return
 	}
 
-	messageID := state.GetMessageID()
-	if messageID != 0 {
-		editMsg := tu.EditMessageText(tu.ID(chatID), messageID, htmlContent)
-		editMsg.ParseMode = telego.ModeHTML
-		if _, err := c.bot.EditMessageText(ctx, editMsg); err != nil {
-			c.sendNewStreamMessage(ctx, chatID, state, htmlContent)
-		}
-	} else {
-		c.sendNewStreamMessage(ctx, chatID, state, htmlContent)
+	editMsg := tu.EditMessageText(tu.ID(chatID), messageID, rendered)
+	editMsg.ParseMode = mode
+	_, err := c.bot.EditMessageText(ctx, editMsg)
+	t.sent(time.Now())
+	if wait, ok := retryAfter(err); ok {
+		// Keep the message and try again with everything that arrived by
+		// then; replacing it would only cost more requests.
+		t.limited(time.Now(), wait)
+		c.streamUpdate(ctx, chatID, state, false)
+		return
+	}
+	if err != nil && !notModified(err) {
+		c.sendNewStreamMessage(ctx, chatID, state, rendered, mode)
 	}
 }
 
-func (c *TelegramChannel) sendNewStreamMessage(ctx context.Context, chatID int64, state *StreamState, htmlContent string) {
-	msg := tu.Message(tu.ID(chatID), htmlContent)
-	msg.ParseMode = telego.ModeHTML
+func (c *TelegramChannel) sendNewStreamMessage(ctx context.Context, chatID int64, state *StreamState, content, parseMode string) {
+	msg := tu.Message(tu.ID(chatID), content)
+	msg.MessageThreadID = state.GetThreadID()
+	msg.ParseMode = parseMode
 
 	if oldMsgID := state.GetMessageID(); oldMsgID != 0 {
 		c.bot.DeleteMessage(ctx, &telego.DeleteMessageParams{
@@ -470,85 +891,155 @@ func (c *TelegramChannel) sendNewStreamMessage(ctx context.Context, chatID int64
 }
 
 func (c *TelegramChannel) finalizeStreamMessage(ctx context.Context, chatID int64, state *StreamState) {
+	if c.displayMode(chatID) == DisplaySegments {
+		c.finalizeSegments(ctx, chatID, state)
+		return
+	}
 	messageID := state.GetMessageID()
 	finalContent := state.GetFinalText()
+	if state.Digest() {
+		if digest := state.toolDigest(c.locale(chatID)); digest != "" {
+			finalContent = strings.TrimSpace(finalContent + "\n\n" + digest)
+		}
+	}
+
+	// The answer replaces the status message rather than editing it, so
+	// the chat is notified when a long turn is done.
+	if messageID != 0 && c.displayMode(chatID) == DisplayProgress {
+		c.bot.DeleteMessage(ctx, &telego.DeleteMessageParams{
+			ChatID:    telego.ChatID{ID: chatID},
+			MessageID: messageID,
+		})
+		state.SetMessageID(0)
+		messageID = 0
+	}
 
 	if messageID != 0 {
-		finalHTML := markdownToTelegramHTML(finalContent)
-		const maxLength = 4000
-		if len(finalHTML) > maxLength {
-			finalHTML = finalHTML[:maxLength] + "\n\n<i>[Message truncated]</i>"
-		}
-		if finalHTML == "" {
-			finalHTML = "✅ Completed"
+		final, mode := c.render(chatID, finalContent)
+		if final == "" {
+			final = i18n.T(c.locale(chatID), "stream.completed")
 		}
 
-		editMsg := tu.EditMessageText(tu.ID(chatID), messageID, finalHTML)
-		editMsg.ParseMode = telego.ModeHTML
+		editMsg := tu.EditMessageText(tu.ID(chatID), messageID, final)
+		editMsg.ParseMode = mode
 
-		if len(state.toolCalls) > 0 {
-			editMsg.ReplyMarkup = tu.InlineKeyboard(
-				tu.InlineKeyboardRow(
-					tu.InlineKeyboardButton("📋 View Details").WithCallbackData("view_details:0"),
-				),
-			)
-		}
+		editMsg.ReplyMarkup = c.finalKeyboard(chatID, state, len(state.toolCalls) > 0)
 
 		if _, err := c.bot.EditMessageText(ctx, editMsg); err == nil {
-			if len(state.toolCalls) > 0 {
-				var tools []ToolDetailItem
-				for toolID, tool := range state.toolCalls {
-					item := ToolDetailItem{
-						ToolName: tool.ToolName,
-						ToolID:   toolID,
-					}
-					if input, ok := tool.State["input"].(map[string]interface{}); ok {
-						item.Input = input
-					}
-					if output, ok := tool.State["output"].(string); ok {
-						item.Output = output
-					}
-					if errMsg, ok := tool.State["error"].(string); ok {
-						item.Error = errMsg
-					}
-					tools = append(tools, item)
-				}
-				c.toolDetails.Store(fmt.Sprintf("%d", messageID), &ToolDetails{
-					OriginalContent: finalHTML,
-					Tools:           tools,
-				})
-			}
+			c.saveDetails(ctx, chatID, messageID, state, final, mode)
+			c.offerSuggestions(chatID, state.GetThreadID(), messageID, state)
 		}
-	} else {
-		if finalContent != "" {
-			c.sendNewStreamMessage(ctx, chatID, state, markdownToTelegramHTML(finalContent))
+	} else if finalContent != "" {
+		if keyboard := c.finalKeyboard(chatID, state, false); keyboard != nil {
+			id := c.sendSegment(ctx, chatID, state.GetThreadID(), finalContent, keyboard)
+			c.offerSuggestions(chatID, state.GetThreadID(), id, state)
+			return
 		}
+		rendered, mode := c.render(chatID, finalContent)
+		c.sendNewStreamMessage(ctx, chatID, state, rendered, mode)
 	}
 }
 
+func (c *TelegramChannel) detailsButton(chatID int64) *telego.InlineKeyboardMarkup {
+	return tu.InlineKeyboard(
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(i18n.T(c.locale(chatID), "details.view")).WithCallbackData("view_details:0"),
+		),
+	)
+}
+
+// saveDetails keeps the turn's tool calls for the "View Details" button of
+// the message that shows final.
+func (c *TelegramChannel) saveDetails(ctx context.Context, chatID int64, messageID int, state *StreamState, final, mode string) {
+	if len(state.toolCalls) == 0 {
+		return
+	}
+	var tools []ToolDetailItem
+	for toolID, tool := range state.toolCalls {
+		item := ToolDetailItem{
+			ToolName: tool.ToolName,
+			ToolID:   toolID,
+		}
+		if input, ok := tool.State["input"].(map[string]interface{}); ok {
+			item.Input = input
+		}
+		if output, ok := tool.State["output"].(string); ok {
+			item.Output = output
+		}
+		if errMsg, ok := tool.State["error"].(string); ok {
+			item.Error = errMsg
+		}
+		tools = append(tools, item)
+	}
+	c.details.Put(ctx, chatID, messageID, &ToolDetails{
+		OriginalContent: final,
+		ParseMode:       mode,
+		Tools:           tools,
+	})
+}
+
 func (c *TelegramChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
 	if !c.IsRunning() {
 		return fmt.Errorf("telegram bot not running")
 	}
 
-	chatID, err := parseChatID(msg.ChatID)
+	chatID, threadID, err := parseChatRef(msg.ChatID)
 	if err != nil {
 		return fmt.Errorf("invalid chat ID: %w", err)
 	}
 
+	// During a turn the message queues behind the stream's own sends, and
+	// the streaming message moves below it, so the answer still comes last.
+	v, streaming := c.streamStates.Load(msg.ChatID)
+	if !streaming {
+		return c.deliver(ctx, chatID, threadID, msg)
+	}
+	state := v.(*StreamState)
+	b := c.renderBuffer(chatID, threadID)
+	done := make(chan error, 1)
+	b.send(ctx, func(ctx context.Context) {
+		var err error
+		defer func() { done <- err }()
+		if err = c.deliver(ctx, chatID, threadID, msg); err == nil {
+			c.moveStreamBelow(ctx, chatID, state, b)
+		}
+	}, false)
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliver sends an outbound message as its format, notification, and reply
+// options ask.
+func (c *TelegramChannel) deliver(ctx context.Context, chatID int64, threadID int, msg bus.OutboundMessage) error {
+	var reply *telego.ReplyParameters
+	if msg.ReplyTo != "" {
+		if id, err := strconv.Atoi(msg.ReplyTo); err == nil {
+			reply = &telego.ReplyParameters{MessageID: id, AllowSendingWithoutReply: true}
+		}
+	}
+
+	if len(msg.Media) > 0 {
+		return c.sendDocuments(ctx, chatID, threadID, msg, reply)
+	}
+
 	if msg.Content == "" {
 		return nil
 	}
 
-	finalContent := markdownToTelegramHTML(msg.Content)
-
-	const maxLength = 4000
-	if len(finalContent) > maxLength {
-		finalContent = finalContent[:maxLength] + "\n\n<i>[Message truncated]</i>"
+	finalContent, mode := msg.Content, ""
+	if msg.Format != bus.FormatPlain {
+		finalContent, mode = c.render(chatID, msg.MarkdownContent())
 	}
 
 	tgMsg := tu.Message(tu.ID(chatID), finalContent)
-	tgMsg.ParseMode = telego.ModeHTML
+	tgMsg.MessageThreadID = threadID
+	tgMsg.ParseMode = mode
+	tgMsg.DisableNotification = msg.Silent
+	tgMsg.ReplyParameters = reply
 
 	if _, err := c.bot.SendMessage(ctx, tgMsg); err != nil {
 		tgMsg.ParseMode = ""
@@ -561,6 +1052,47 @@ func (c *TelegramChannel) Send(ctx context.Context, msg bus.OutboundMessage) err
 	return nil
 }
 
+// moveStreamBelow replaces a chat's streaming message with a new one, after
+// something else was posted during the turn.
+func (c *TelegramChannel) moveStreamBelow(ctx context.Context, chatID int64, state *StreamState, b *renderBuffer) {
+	if mode := c.displayMode(chatID); mode != DisplayStream && mode != DisplayProgress {
+		return
+	}
+	messageID := state.GetMessageID()
+	if messageID == 0 {
+		return
+	}
+	c.bot.DeleteMessage(ctx, &telego.DeleteMessageParams{
+		ChatID:    telego.ChatID{ID: chatID},
+		MessageID: messageID,
+	})
+	state.SetMessageID(0)
+	b.frame(ctx, state, true)
+}
+
+// sendDocuments uploads each file as a document, captioning the first.
+func (c *TelegramChannel) sendDocuments(ctx context.Context, chatID int64, threadID int, msg bus.OutboundMessage, reply *telego.ReplyParameters) error {
+	for i, path := range msg.Media {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", path, err)
+		}
+		doc := tu.Document(tu.ID(chatID), tu.File(f))
+		doc.MessageThreadID = threadID
+		doc.DisableNotification = msg.Silent
+		if i == 0 {
+			doc.Caption = msg.Content
+			doc.ReplyParameters = reply
+		}
+		_, err = c.bot.SendDocument(ctx, doc)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("send %s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
 func (c *TelegramChannel) handleMessage(ctx context.Context, update telego.Update) {
 	message := update.Message
 	if message == nil {
@@ -578,11 +1110,17 @@ func (c *TelegramChannel) handleMessage(ctx context.Context, update telego.Updat
 		senderID = fmt.Sprintf("%s|%s", userID, user.Username)
 	}
 
-	if !c.IsAllowed(userID) && !c.IsAllowed(senderID) {
+	chatID := message.Chat.ID
+	threadID := messageThread(message)
+	key := chatKey(chatID, threadID)
+	if !c.IsAllowedIn(senderID, key) {
+		if message.Chat.Type == telego.ChatTypePrivate {
+			c.offerPairing(ctx, user, senderID)
+		}
 		return
 	}
+	topic := c.topicName(message)
 
-	chatID := message.Chat.ID
 	content := ""
 	if message.Text != "" {
 		content = message.Text
@@ -593,25 +1131,74 @@ func (c *TelegramChannel) handleMessage(ctx context.Context, update telego.Updat
 		}
 		content += message.Caption
 	}
-
-	if content == "" {
+	if c.skipEmoji(message, content) {
 		return
 	}
+	for _, extra := range []string{sharedContent(message), stickerContent(message)} {
+		if extra == "" {
+			continue
+		}
+		if content != "" {
+			content += "\n"
+		}
+		content += extra
+	}
 
-	c.bot.SendChatAction(ctx, tu.ChatAction(tu.ID(chatID), telego.ChatActionTyping))
-
-	stateInterface, _ := c.streamStates.LoadOrStore(fmt.Sprintf("%d", chatID), NewStreamState())
-	state := stateInterface.(*StreamState)
-	state.SetChatID(chatID)
+	if fields := strings.Fields(content); len(fields) > 0 {
+		cmd, _, _ := strings.Cut(fields[0], "@")
+		switch cmd {
+		case "/format":
+			c.handleFormatCommand(ctx, chatID, threadID, strings.Join(fields[1:], " "))
+			return
+		case "/display":
+			c.handleDisplayCommand(ctx, chatID, threadID, strings.Join(fields[1:], " "))
+			return
+		case "/digest":
+			c.handleDigestCommand(ctx, chatID, threadID, strings.Join(fields[1:], " "))
+			return
+		}
+	}
 
 	metadata := map[string]string{
-		"message_id": fmt.Sprintf("%d", message.MessageID),
-		"user_id":    fmt.Sprintf("%d", user.ID),
-		"username":   user.Username,
-		"first_name": user.FirstName,
+		"language_code": user.LanguageCode,
+		"message_id":    fmt.Sprintf("%d", message.MessageID),
+		"user_id":       fmt.Sprintf("%d", user.ID),
+		"username":      user.Username,
+		"first_name":    user.FirstName,
+	}
+	if threadID != 0 {
+		metadata["thread_id"] = strconv.Itoa(threadID)
+		metadata["topic"] = topic
 	}
+	sharedMetadata(message, metadata)
 
-	c.HandleMessage(senderID, fmt.Sprintf("%d", chatID), content, nil, metadata, c.config.StreamMode)
+	media := c.downloadMedia(ctx, message)
+	if message.MediaGroupID != "" {
+		c.addToAlbum(ctx, message, senderID, content, media, metadata)
+		return
+	}
+	c.dispatch(ctx, senderID, chatID, threadID, content, media, metadata)
+}
+
+// dispatch hands a message to the agent and shows the chat that it is
+// being answered.
+func (c *TelegramChannel) dispatch(ctx context.Context, senderID string, chatID int64, threadID int, content string, media []string, metadata map[string]string) {
+	if content == "" && len(media) == 0 {
+		return
+	}
+	key := chatKey(chatID, threadID)
+	if !c.HandleMessage(senderID, key, content, media, metadata, c.StreamMode()) {
+		return
+	}
+
+	action := tu.ChatAction(tu.ID(chatID), telego.ChatActionTyping)
+	action.MessageThreadID = threadID
+	c.bot.SendChatAction(ctx, action)
+
+	stateInterface, _ := c.streamStates.LoadOrStore(key, NewStreamState())
+	state := stateInterface.(*StreamState)
+	state.SetChatID(chatID)
+	state.SetThreadID(threadID)
 }
 
 func (c *TelegramChannel) handleCallbackQuery(ctx context.Context, update telego.Update) {
@@ -622,12 +1209,27 @@ func (c *TelegramChannel) handleCallbackQuery(ctx context.Context, update telego
 	callback := update.CallbackQuery
 	data := callback.Data
 
+	if strings.HasPrefix(data, "approval:") {
+		c.handleApprovalCallback(ctx, callback)
+		return
+	}
+
+	if strings.HasPrefix(data, "pair:") {
+		c.handlePairingCallback(ctx, callback)
+		return
+	}
+
+	if strings.HasPrefix(data, "suggest:") {
+		c.handleSuggestionCallback(ctx, callback)
+		return
+	}
+
 	if strings.HasPrefix(data, "view_details:") {
 		msg := callback.Message
 		if msg == nil {
 			c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{
 				CallbackQueryID: callback.ID,
-				Text:            "Message not found",
+				Text:            i18n.T(c.callbackLocale(callback), "details.no_message"),
 				ShowAlert:       true,
 			})
 			return
@@ -637,32 +1239,36 @@ func (c *TelegramChannel) handleCallbackQuery(ctx context.Context, update telego
 		if !ok {
 			c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{
 				CallbackQueryID: callback.ID,
-				Text:            "Cannot access message",
+				Text:            i18n.T(c.callbackLocale(callback), "details.inaccessible"),
 				ShowAlert:       true,
 			})
 			return
 		}
 
-		detailsInterface, ok := c.toolDetails.Load(fmt.Sprintf("%d", messageID))
+		details, ok := c.details.Get(ctx, chatID, messageID)
 		if !ok {
 			c.bot.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{
 				CallbackQueryID: callback.ID,
-				Text:            "Details not found",
+				Text:            i18n.T(c.locale(chatID), "details.not_found"),
 				ShowAlert:       true,
 			})
 			return
 		}
-		details := detailsInterface.(*ToolDetails)
 
 		pageStr := strings.TrimPrefix(data, "view_details:")
 		page := 0
 		fmt.Sscanf(pageStr, "%d", &page)
 
-		c.showToolDetailPage(ctx, chatID, int64(messageID), details, page, callback.ID)
+		var suggestions []string
+		if m, ok := msg.(*telego.Message); ok {
+			suggestions = c.pendingSuggestions(chatID, messageThread(m), messageID)
+		}
+		c.showToolDetailPage(ctx, chatID, int64(messageID), details, page, suggestions, callback.ID)
 	}
 }
 
-func (c *TelegramChannel) showToolDetailPage(ctx context.Context, chatID, messageID int64, details *ToolDetails, page int, callbackID string) {
+func (c *TelegramChannel) showToolDetailPage(ctx context.Context, chatID, messageID int64, details *ToolDetails, page int, suggestions []string, callbackID string) {
+	locale := c.locale(chatID)
 	if page < 0 {
 		page = 0
 	}
@@ -672,15 +1278,22 @@ func (c *TelegramChannel) showToolDetailPage(ctx context.Context, chatID, messag
 
 	var content string
 	var keyboard *telego.InlineKeyboardMarkup
+	parseMode := telego.ModeHTML
 
 	if page == 0 {
 		content = details.OriginalContent
+		if details.ParseMode != "" {
+			parseMode = details.ParseMode
+		}
+		// Suggestions not yet used come back with the answer.
+		rows := suggestionRows(suggestions)
 		if len(details.Tools) > 0 {
-			keyboard = tu.InlineKeyboard(
-				tu.InlineKeyboardRow(
-					tu.InlineKeyboardButton("📋 View Details").WithCallbackData("view_details:1"),
-				),
-			)
+			rows = append(rows, tu.InlineKeyboardRow(
+				tu.InlineKeyboardButton(i18n.T(locale, "details.view")).WithCallbackData("view_details:1"),
+			))
+		}
+		if len(rows) > 0 {
+			keyboard = tu.InlineKeyboard(rows...)
 		}
 	} else {
 		toolIdx := page - 1
@@ -690,7 +1303,7 @@ func (c *TelegramChannel) showToolDetailPage(ctx context.Context, chatID, messag
 		tool := details.Tools[toolIdx]
 
 		var sb strings.Builder
-		sb.WriteString(fmt.Sprintf("<b>🔧 Tool %d/%d: %s</b>\n", page, len(details.Tools), tool.ToolName))
+		sb.WriteString("<b>" + i18n.T(locale, "details.tool", page, len(details.Tools), tool.ToolName) + "</b>\n")
 		sb.WriteString(fmt.Sprintf("ID: <code>%s</code>\n", tool.ToolID))
 
 		if tool.Input != nil && len(tool.Input) > 0 {
@@ -699,7 +1312,7 @@ func (c *TelegramChannel) showToolDetailPage(ctx context.Context, chatID, messag
 			if len(argsStr) > 1500 {
 				argsStr = argsStr[:1500] + "\n...[truncated]"
 			}
-			sb.WriteString(fmt.Sprintf("\n<b>Input:</b>\n<pre>%s</pre>\n", argsStr))
+			sb.WriteString(fmt.Sprintf("\n<b>%s</b>\n<pre>%s</pre>\n", i18n.T(locale, "details.input"), argsStr))
 		}
 
 		if tool.Output != "" {
@@ -707,7 +1320,7 @@ func (c *TelegramChannel) showToolDetailPage(ctx context.Context, chatID, messag
 			if len(output) > 1500 {
 				output = output[:1500] + "\n...[truncated]"
 			}
-			sb.WriteString(fmt.Sprintf("\n<b>Output:</b>\n<pre>%s</pre>\n", output))
+			sb.WriteString(fmt.Sprintf("\n<b>%s</b>\n<pre>%s</pre>\n", i18n.T(locale, "details.output"), output))
 		}
 
 		if tool.Error != "" {
@@ -715,24 +1328,24 @@ func (c *TelegramChannel) showToolDetailPage(ctx context.Context, chatID, messag
 			if len(errMsg) > 1000 {
 				errMsg = errMsg[:1000] + "\n...[truncated]"
 			}
-			sb.WriteString(fmt.Sprintf("\n<b>Error:</b>\n<pre>%s</pre>\n", errMsg))
+			sb.WriteString(fmt.Sprintf("\n<b>%s</b>\n<pre>%s</pre>\n", i18n.T(locale, "details.error"), errMsg))
 		}
 
 		content = sb.String()
 
 		var buttons []telego.InlineKeyboardButton
 		if page > 1 {
-			buttons = append(buttons, tu.InlineKeyboardButton("◀ Prev").WithCallbackData(fmt.Sprintf("view_details:%d", page-1)))
+			buttons = append(buttons, tu.InlineKeyboardButton(i18n.T(locale, "details.prev")).WithCallbackData(fmt.Sprintf("view_details:%d", page-1)))
 		}
-		buttons = append(buttons, tu.InlineKeyboardButton("📋 Back").WithCallbackData("view_details:0"))
+		buttons = append(buttons, tu.InlineKeyboardButton(i18n.T(locale, "details.back")).WithCallbackData("view_details:0"))
 		if page < len(details.Tools) {
-			buttons = append(buttons, tu.InlineKeyboardButton("Next ▶").WithCallbackData(fmt.Sprintf("view_details:%d", page+1)))
+			buttons = append(buttons, tu.InlineKeyboardButton(i18n.T(locale, "details.next")).WithCallbackData(fmt.Sprintf("view_details:%d", page+1)))
 		}
 		keyboard = tu.InlineKeyboard(tu.InlineKeyboardRow(buttons...))
 	}
 
 	editMsg := tu.EditMessageText(tu.ID(chatID), int(messageID), content)
-	editMsg.ParseMode = telego.ModeHTML
+	editMsg.ParseMode = parseMode
 	if keyboard != nil {
 		editMsg.ReplyMarkup = keyboard
 	}
@@ -753,13 +1366,33 @@ func extractChatAndMessageID(msg telego.MaybeInaccessibleMessage) (int64, int, b
 	}
 }
 
-func (c *TelegramChannel) sendErrorMessage(ctx context.Context, chatID int64, errorMsg string) {
-	htmlContent := markdownToTelegramHTML(fmt.Sprintf("❌ Error: %s", errorMsg))
-	msg := tu.Message(tu.ID(chatID), htmlContent)
-	msg.ParseMode = telego.ModeHTML
+// sendErrorMessage tells the chat about an error event, with advice when
+// the event has a code.
+func (c *TelegramChannel) sendErrorMessage(ctx context.Context, chatID int64, threadID int, event bus.StreamMessage) {
+	text, ok := channel.ErrorText(c.locale(chatID), event)
+	if !ok {
+		text = i18n.T(c.locale(chatID), "stream.error", event.Content)
+	}
+	content, mode := c.render(chatID, text)
+	msg := tu.Message(tu.ID(chatID), content)
+	msg.MessageThreadID = threadID
+	msg.ParseMode = mode
 	c.bot.SendMessage(ctx, msg)
 }
 
+func (c *TelegramChannel) locale(chatID int64) string {
+	return c.Locale(fmt.Sprintf("%d", chatID))
+}
+
+// callbackLocale is the locale of the chat a button was pressed in, or of
+// the user's private chat when the message is out of reach.
+func (c *TelegramChannel) callbackLocale(callback *telego.CallbackQuery) string {
+	if chatID, _, ok := extractChatAndMessageID(callback.Message); ok {
+		return c.locale(chatID)
+	}
+	return c.locale(callback.From.ID)
+}
+
 func parseChatID(chatIDStr string) (int64, error) {
 	var id int64
 	_, err := fmt.Sscanf(chatIDStr, "%d", &id)
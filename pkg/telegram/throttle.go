@@ -0,0 +1,142 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mymmrac/telego/telegoapi"
+)
+
+const (
+	defaultEditInterval      = time.Second
+	defaultMaxEditsPerMinute = 20
+	maxEditInterval          = 10 * time.Second
+)
+
+// editThrottle paces the stream message edits of one chat. Edits are spaced
+// by an interval that doubles whenever Telegram answers 429 and eases back
+// after successful edits, nothing is sent before a retry_after has passed,
+// and at most perMinute edits go out in any minute.
+type editThrottle struct {
+	mu           sync.Mutex
+	base         time.Duration
+	interval     time.Duration
+	perMinute    int
+	last         time.Time
+	blockedUntil time.Time
+	recent       []time.Time
+}
+
+func newEditThrottle(interval time.Duration, perMinute int) *editThrottle {
+	if interval <= 0 {
+		interval = defaultEditInterval
+	}
+	if perMinute <= 0 {
+		perMinute = defaultMaxEditsPerMinute
+	}
+	return &editThrottle{base: interval, interval: interval, perMinute: perMinute}
+}
+
+// wait returns how long an edit has to be held back. Urgent edits, such as
+// a tool starting, skip the interval but not a 429 back-off or the cap.
+func (t *editThrottle) wait(now time.Time, urgent bool) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var d time.Duration
+	later := func(at time.Time) {
+		if w := at.Sub(now); w > d {
+			d = w
+		}
+	}
+	later(t.blockedUntil)
+	if !urgent {
+		later(t.last.Add(t.interval))
+	}
+	for len(t.recent) > 0 && now.Sub(t.recent[0]) >= time.Minute {
+		t.recent = t.recent[1:]
+	}
+	if len(t.recent) >= t.perMinute {
+		later(t.recent[0].Add(time.Minute))
+	}
+	return d
+}
+
+// blocked returns how long Telegram asked this chat to wait.
+func (t *editThrottle) blocked(now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Before(t.blockedUntil) {
+		return t.blockedUntil.Sub(now)
+	}
+	return 0
+}
+
+func (t *editThrottle) sent(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = now
+	t.recent = append(t.recent, now)
+	if t.interval > t.base {
+		t.interval = max(t.base, t.interval*3/4)
+	}
+}
+
+func (t *editThrottle) limited(now time.Time, retryAfter time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.blockedUntil = now.Add(retryAfter)
+	t.interval = min(t.interval*2, maxEditInterval)
+}
+
+// retryAfter reports whether err is Telegram's 429 and how long it asked to
+// wait.
+func retryAfter(err error) (time.Duration, bool) {
+	var apiErr *telegoapi.Error
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode != 429 {
+		return 0, false
+	}
+	wait := time.Second
+	if apiErr.Parameters != nil && apiErr.Parameters.RetryAfter > 0 {
+		wait = time.Duration(apiErr.Parameters.RetryAfter) * time.Second
+	}
+	return wait, true
+}
+
+// notModified reports whether an edit failed only because the text did not
+// change.
+func notModified(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "message is not modified")
+}
+
+func (c *TelegramChannel) throttle(chatID int64) *editThrottle {
+	if t, ok := c.throttles.Load(chatID); ok {
+		return t.(*editThrottle)
+	}
+	interval := time.Duration(c.config.EditIntervalMs) * time.Millisecond
+	t, _ := c.throttles.LoadOrStore(chatID, newEditThrottle(interval, c.config.MaxEditsPerMinute))
+	return t.(*editThrottle)
+}
+
+// streamUpdate has the chat's render buffer show state in the stream
+// message, now or once the throttle allows. The message always shows the
+// whole state, so the deltas that arrive in the meantime go out together in
+// that one edit.
+func (c *TelegramChannel) streamUpdate(ctx context.Context, chatID int64, state *StreamState, urgent bool) {
+	// The progress display only changes when a tool or subagent does.
+	switch c.displayMode(chatID) {
+	case DisplayQuiet:
+		return
+	case DisplayProgress:
+		if !urgent {
+			return
+		}
+	case DisplaySegments:
+		c.postSegments(ctx, chatID, state)
+		return
+	}
+	c.renderBuffer(chatID, state.GetThreadID()).frame(ctx, state, urgent)
+}
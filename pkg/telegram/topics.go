@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mymmrac/telego"
+
+	"github.com/nene-agent/nene/pkg/channel"
+)
+
+// Forum supergroups split a group into topics. Each topic is a chat of its
+// own to the agent, with the chat ID "<chat>/<topic>" (see
+// channel.ThreadSep), while display, format, and language settings stay
+// per group. Messages in the General topic carry no topic and belong to the
+// group itself.
+
+// chatKey is the channel chat ID of a chat, or of a topic in it when
+// threadID is not zero.
+func chatKey(chatID int64, threadID int) string {
+	if threadID == 0 {
+		return strconv.FormatInt(chatID, 10)
+	}
+	return fmt.Sprintf("%d%s%d", chatID, channel.ThreadSep, threadID)
+}
+
+// parseChatRef splits a channel chat ID into the Telegram chat and topic.
+func parseChatRef(s string) (int64, int, error) {
+	chat, thread, hasThread := strings.Cut(s, channel.ThreadSep)
+	chatID, err := parseChatID(chat)
+	if err != nil || !hasThread {
+		return chatID, 0, err
+	}
+	threadID, err := strconv.Atoi(thread)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid topic %q", thread)
+	}
+	return chatID, threadID, nil
+}
+
+// messageThread returns the forum topic a message was written in, or 0.
+// Replies in ordinary groups carry a thread ID too, so only topic messages
+// count.
+func messageThread(m *telego.Message) int {
+	if m.IsTopicMessage {
+		return m.MessageThreadID
+	}
+	return 0
+}
+
+// topicName returns the name of the topic a message was written in, if it
+// is known. Telegram only names a topic in the message that created it,
+// which topic messages that are not replies point to, so names are
+// remembered as they are seen.
+func (c *TelegramChannel) topicName(m *telego.Message) string {
+	thread := messageThread(m)
+	if thread == 0 {
+		return ""
+	}
+	key := chatKey(m.Chat.ID, thread)
+	if r := m.ReplyToMessage; r != nil && r.ForumTopicCreated != nil {
+		c.topics.Store(key, r.ForumTopicCreated.Name)
+		return r.ForumTopicCreated.Name
+	}
+	if name, ok := c.topics.Load(key); ok {
+		return name.(string)
+	}
+	return ""
+}
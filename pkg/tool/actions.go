@@ -0,0 +1,337 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	actionTimeout     = 15 * time.Second
+	actionOutputChars = 2000
+
+	// ActionSignatureHeader carries the HMAC-SHA256 of the request body when
+	// an action has a secret, as "sha256=<hex>".
+	ActionSignatureHeader = "X-Nene-Signature"
+)
+
+// ActionParam is a value the model fills in when triggering an action.
+type ActionParam struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Required    bool     `json:"required"`
+	Enum        []string `json:"enum"`
+}
+
+// Action is a named webhook the run_action tool can trigger. {{name}}
+// placeholders in URL and Body are replaced with parameters, escaped for
+// where they appear: path-escaped in the URL's path, query-escaped in its
+// query and in a form body. In a JSON body a placeholder inside a string is
+// JSON-escaped, and one outside a string becomes a single JSON value: the
+// number or boolean itself, or else a string. Either way a value cannot
+// change the request's structure. Placeholders in any other body are
+// refused.
+type Action struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	URL         string            `json:"url"`
+	Method      string            `json:"method"`
+	Headers     map[string]string `json:"headers"`
+	Body        string            `json:"body"`
+	Params      []ActionParam     `json:"params"`
+	// Token is sent as a bearer token, e.g. a Home Assistant access token.
+	Token string `json:"token"`
+	// Secret signs the body; see ActionSignatureHeader.
+	Secret string `json:"secret"`
+	// SkipApproval lets the action run without asking. Leave it off for
+	// anything that unlocks, opens, or spends.
+	SkipApproval bool `json:"skip_approval"`
+}
+
+var (
+	placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+	jsonNumber  = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+)
+
+type RunActionTool struct {
+	parameters json.RawMessage
+	actions    map[string]Action
+	client     *http.Client
+}
+
+func NewRunActionTool(actions []Action) *RunActionTool {
+	t := &RunActionTool{
+		actions: make(map[string]Action),
+		client:  &http.Client{Timeout: actionTimeout},
+	}
+	for _, a := range actions {
+		if a.Method == "" {
+			a.Method = http.MethodPost
+		}
+		t.actions[a.Name] = a
+	}
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        t.names(),
+				"description": "Name of the action to run",
+			},
+			"params": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": []string{"string", "number", "boolean"}},
+				"description":          "Values for the action's parameters, by name",
+			},
+		},
+		"required": []string{"action"},
+	}
+	t.parameters, _ = json.Marshal(params)
+	return t
+}
+
+func (t *RunActionTool) names() []string {
+	names := make([]string, 0, len(t.actions))
+	for name := range t.actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t *RunActionTool) Name() string { return "run_action" }
+func (t *RunActionTool) Description() string {
+	var sb strings.Builder
+	sb.WriteString("Trigger one of the user's configured automations, such as smart-home scenes or webhooks. Actions:")
+	for _, name := range t.names() {
+		a := t.actions[name]
+		sb.WriteString("\n- " + name)
+		if a.Description != "" {
+			sb.WriteString(": " + a.Description)
+		}
+		for _, p := range a.Params {
+			fmt.Fprintf(&sb, "\n  - %s", p.Name)
+			if p.Required {
+				sb.WriteString(" (required)")
+			}
+			if p.Description != "" {
+				sb.WriteString(": " + p.Description)
+			}
+			if len(p.Enum) > 0 {
+				sb.WriteString(" [one of " + strings.Join(p.Enum, ", ") + "]")
+			}
+		}
+	}
+	return sb.String()
+}
+func (t *RunActionTool) Parameters() json.RawMessage { return t.parameters }
+
+type runActionArgs struct {
+	Action string                 `json:"action"`
+	Params map[string]interface{} `json:"params"`
+}
+
+func (t *RunActionTool) Mutates(args json.RawMessage) bool { return true }
+
+func (t *RunActionTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	var a runActionArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return nil, err
+	}
+	action, ok := t.actions[a.Action]
+	if !ok || action.SkipApproval {
+		return nil, nil
+	}
+	what := a.Action
+	if len(a.Params) > 0 {
+		p, _ := json.Marshal(a.Params)
+		what += " " + string(p)
+	}
+	return NewApproval("Agent wants to run an action", what).
+		WithRule(a.Action, a.Action), nil
+}
+
+// actionValues checks the call's parameters against the action's and
+// returns them as strings.
+func actionValues(action Action, params map[string]interface{}) (map[string]string, error) {
+	values := make(map[string]string, len(params))
+	for name, v := range params {
+		i := slices.IndexFunc(action.Params, func(p ActionParam) bool { return p.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("action %s has no parameter %q", action.Name, name)
+		}
+		var s string
+		switch v := v.(type) {
+		case string:
+			s = v
+		case float64, bool:
+			s = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("parameter %s must be a string, number, or boolean", name)
+		}
+		if enum := action.Params[i].Enum; len(enum) > 0 && !slices.Contains(enum, s) {
+			return nil, fmt.Errorf("parameter %s must be one of %s", name, strings.Join(enum, ", "))
+		}
+		values[name] = s
+	}
+	for _, p := range action.Params {
+		if _, ok := values[p.Name]; !ok && p.Required {
+			return nil, fmt.Errorf("parameter %s is required", p.Name)
+		}
+	}
+	return values, nil
+}
+
+func substitute(tmpl string, values map[string]string, escape func(string) string) string {
+	return placeholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		name := placeholder.FindStringSubmatch(m)[1]
+		return escape(values[name])
+	})
+}
+
+// substituteURL escapes values in the path with url.PathEscape, so a value
+// cannot add or climb path segments, and in the query with url.QueryEscape.
+func substituteURL(tmpl string, values map[string]string) string {
+	path, query, hasQuery := strings.Cut(tmpl, "?")
+	rawURL := substitute(path, values, func(s string) string {
+		if s == "." || s == ".." {
+			return strings.Repeat("%2E", len(s))
+		}
+		return url.PathEscape(s)
+	})
+	if hasQuery {
+		rawURL += "?" + substitute(query, values, url.QueryEscape)
+	}
+	return rawURL
+}
+
+// substituteJSON replaces placeholders in a JSON template, escaping values
+// inside strings and encoding those outside as one JSON value each.
+func substituteJSON(tmpl string, values map[string]string) string {
+	var sb strings.Builder
+	inString, escaped := false, false
+	last := 0
+	scan := func(s string) {
+		for i := 0; i < len(s); i++ {
+			switch {
+			case escaped:
+				escaped = false
+			case inString && s[i] == '\\':
+				escaped = true
+			case s[i] == '"':
+				inString = !inString
+			}
+		}
+	}
+	for _, m := range placeholder.FindAllStringSubmatchIndex(tmpl, -1) {
+		scan(tmpl[last:m[0]])
+		sb.WriteString(tmpl[last:m[0]])
+		value := values[tmpl[m[2]:m[3]]]
+		if inString {
+			sb.WriteString(jsonEscape(value))
+		} else {
+			sb.WriteString(jsonValue(value))
+		}
+		last = m[1]
+	}
+	sb.WriteString(tmpl[last:])
+	return sb.String()
+}
+
+func jsonEscape(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b[1 : len(b)-1])
+}
+
+// jsonValue encodes s as a JSON number or boolean when it is one, and as a
+// string otherwise.
+func jsonValue(s string) string {
+	var f float64
+	if s == "true" || s == "false" || (jsonNumber.MatchString(s) && json.Unmarshal([]byte(s), &f) == nil) {
+		return s
+	}
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func (t *RunActionTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a runActionArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	action, ok := t.actions[a.Action]
+	if !ok {
+		return ErrorResult(fmt.Sprintf("unknown action %q; use one of %s", a.Action, strings.Join(t.names(), ", "))), nil
+	}
+	values, err := actionValues(action, a.Params)
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+
+	rawURL := substituteURL(action.URL, values)
+	contentType := action.Headers["Content-Type"]
+	trimmed := strings.TrimSpace(action.Body)
+	isJSON := strings.Contains(contentType, "json") ||
+		(contentType == "" && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")))
+	body := action.Body
+	switch {
+	case isJSON:
+		body = substituteJSON(action.Body, values)
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		body = substitute(action.Body, values, url.QueryEscape)
+	case placeholder.MatchString(action.Body):
+		return ErrorResult(fmt.Sprintf("action %s has placeholders in a body that is neither JSON nor a form; set its Content-Type header", action.Name)), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, action.Method, rawURL, strings.NewReader(body))
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+	for k, v := range action.Headers {
+		req.Header.Set(k, v)
+	}
+	if isJSON && contentType == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", "nene")
+	if action.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+action.Token)
+	}
+	if action.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(action.Secret))
+		mac.Write([]byte(body))
+		req.Header.Set(ActionSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("action %s failed: %v", action.Name, err)), nil
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(io.LimitReader(resp.Body, actionOutputChars+1))
+	out = bytes.TrimSpace(out)
+	text := string(out)
+	if len(out) > actionOutputChars {
+		text = strings.ToValidUTF8(string(out[:actionOutputChars]), "") + "…"
+	}
+	summary := fmt.Sprintf("Action %s: %s", action.Name, resp.Status)
+	if text != "" {
+		summary += "\n" + text
+	}
+	if resp.StatusCode >= 300 {
+		return ErrorResult(summary), nil
+	}
+	return OkResult(summary), nil
+}
@@ -0,0 +1,221 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nene-agent/nene/pkg/bus"
+)
+
+// Approver decides on calls that need approval. Approve blocks until the
+// decision is made and records it on a with Approve or Reject.
+type Approver interface {
+	Approve(ctx context.Context, channel, chatID, toolName string, a *Approval) error
+}
+
+type Decision string
+
+const (
+	DecisionApprove Decision = "approve"
+	// DecisionAlways approves the call and remembers the approval's rule
+	// for the chat.
+	DecisionAlways Decision = "always"
+	DecisionReject Decision = "reject"
+)
+
+// ParseDecision accepts a decision name or its chat command, such as
+// "/approve".
+func ParseDecision(s string) (Decision, bool) {
+	switch Decision(strings.TrimPrefix(strings.ToLower(s), "/")) {
+	case DecisionApprove:
+		return DecisionApprove, true
+	case DecisionAlways:
+		return DecisionAlways, true
+	case DecisionReject:
+		return DecisionReject, true
+	}
+	return "", false
+}
+
+const DefaultApprovalTimeout = 5 * time.Minute
+
+// ApprovalGate asks the user of a chat to approve tool calls. Requests are
+// published as approval stream events; channels show them and pass the
+// answer back with Resolve or ResolveChat. Decisions to always allow are kept
+// in an ApprovalStore and applied to later matching calls in the chat.
+type ApprovalGate struct {
+	bus     *bus.MessageBus
+	store   *ApprovalStore
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingApproval
+}
+
+type pendingApproval struct {
+	channel, chatID string
+	requester       string
+	created         time.Time
+	decision        chan answer
+}
+
+type answer struct {
+	decision Decision
+	reason   string
+}
+
+type ApprovalOption func(*ApprovalGate)
+
+func WithApprovalTimeout(d time.Duration) ApprovalOption {
+	return func(g *ApprovalGate) {
+		if d > 0 {
+			g.timeout = d
+		}
+	}
+}
+
+// NewApprovalGate creates a gate. store may be nil, in which case nothing is
+// remembered.
+func NewApprovalGate(b *bus.MessageBus, store *ApprovalStore, opts ...ApprovalOption) *ApprovalGate {
+	g := &ApprovalGate{
+		bus:     b,
+		store:   store,
+		timeout: DefaultApprovalTimeout,
+		pending: make(map[string]*pendingApproval),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+func (g *ApprovalGate) Store() *ApprovalStore { return g.store }
+
+func (g *ApprovalGate) Approve(ctx context.Context, channel, chatID, toolName string, a *Approval) error {
+	chat := channel + ":" + chatID
+	if g.store != nil && a.Rule() != "" {
+		ok, err := g.store.Allowed(ctx, chat, toolName, a.subject)
+		if err != nil {
+			fmt.Printf("Approval rules lookup failed: %v\n", err)
+		} else if ok {
+			a.Approve()
+			return nil
+		}
+	}
+
+	id := uuid.NewString()
+	p := &pendingApproval{
+		channel:   channel,
+		chatID:    chatID,
+		requester: SenderFrom(ctx),
+		created:   time.Now(),
+		decision:  make(chan answer, 1),
+	}
+	g.mu.Lock()
+	g.pending[id] = p
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		delete(g.pending, id)
+		g.mu.Unlock()
+	}()
+
+	rule := ""
+	if g.store != nil {
+		rule = a.Rule()
+	}
+	g.bus.PublishStream(bus.StreamMessage{
+		Channel:      channel,
+		ChatID:       chatID,
+		Type:         bus.StreamEventApproval,
+		ToolName:     toolName,
+		Label:        a.Justification(),
+		Content:      a.What(),
+		ApprovalID:   id,
+		ApprovalRule: rule,
+	})
+
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+	select {
+	case ans := <-p.decision:
+		switch ans.decision {
+		case DecisionApprove:
+			a.Approve()
+		case DecisionAlways:
+			a.Approve()
+			if rule != "" {
+				if err := g.store.Add(ctx, chat, toolName, rule); err != nil {
+					fmt.Printf("Failed to remember approval: %v\n", err)
+				}
+			}
+		default:
+			a.Reject(ans.reason)
+		}
+	case <-timer.C:
+		a.Reject(fmt.Sprintf("no answer within %s", g.timeout))
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Resolve answers a pending approval by ID and reports whether it was
+// waiting. Owners may answer any approval; other senders only their own.
+func (g *ApprovalGate) Resolve(id, senderID string, role Role, d Decision, reason string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, ok := g.pending[id]
+	if !ok || !p.answerableBy(senderID, role) {
+		return false
+	}
+	return p.answer(d, reason)
+}
+
+// ResolveChat answers the oldest pending approval in a chat, for channels
+// that take the answer as a text command.
+func (g *ApprovalGate) ResolveChat(channel, chatID, senderID string, role Role, d Decision, reason string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var oldest *pendingApproval
+	for _, p := range g.pending {
+		if p.channel != channel || p.chatID != chatID || !p.answerableBy(senderID, role) {
+			continue
+		}
+		if oldest == nil || p.created.Before(oldest.created) {
+			oldest = p
+		}
+	}
+	return oldest != nil && oldest.answer(d, reason)
+}
+
+func (p *pendingApproval) answerableBy(senderID string, role Role) bool {
+	return role == RoleOwner || p.requester == "" || p.requester == senderID
+}
+
+func (p *pendingApproval) answer(d Decision, reason string) bool {
+	select {
+	case p.decision <- answer{d, reason}:
+		return true
+	default:
+		return false
+	}
+}
+
+// MatchRule reports whether a remembered rule covers a call's subject. "*"
+// covers everything, "prefix *" covers the prefix alone or followed by
+// arguments, and any other rule must equal the subject.
+func MatchRule(rule, subject string) bool {
+	if rule == "*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(rule, " *"); ok {
+		return subject == prefix || strings.HasPrefix(subject, prefix+" ")
+	}
+	return rule == subject
+}
@@ -0,0 +1,117 @@
+package tool
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ApprovalRule is a remembered "always allow" decision for one chat.
+type ApprovalRule struct {
+	ID      int64
+	Chat    string
+	Tool    string
+	Pattern string
+	Created time.Time
+}
+
+// ApprovalStore keeps remembered approval decisions in approvals.db.
+type ApprovalStore struct {
+	db *sql.DB
+}
+
+func OpenApprovalStore(dataDir string) (*ApprovalStore, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("create data directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(dataDir, "approvals.db"))
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS approval_rules (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		chat       TEXT NOT NULL,
+		tool       TEXT NOT NULL,
+		pattern    TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		UNIQUE (chat, tool, pattern)
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init schema: %w", err)
+	}
+	return &ApprovalStore{db: db}, nil
+}
+
+func (s *ApprovalStore) Close() error {
+	return s.db.Close()
+}
+
+// Add remembers that calls of tool matching pattern are allowed in chat.
+func (s *ApprovalStore) Add(ctx context.Context, chat, tool, pattern string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT OR IGNORE INTO approval_rules (chat, tool, pattern, created_at) VALUES (?, ?, ?, ?)`,
+		chat, tool, pattern, time.Now().UTC())
+	return err
+}
+
+// Allowed reports whether a remembered rule covers a call in chat.
+func (s *ApprovalStore) Allowed(ctx context.Context, chat, tool, subject string) (bool, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT pattern FROM approval_rules WHERE chat = ? AND tool = ?`, chat, tool)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pattern string
+		if err := rows.Scan(&pattern); err != nil {
+			return false, err
+		}
+		if MatchRule(pattern, subject) {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// List returns the rules of a chat, oldest first.
+func (s *ApprovalStore) List(ctx context.Context, chat string) ([]ApprovalRule, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, chat, tool, pattern, created_at FROM approval_rules WHERE chat = ? ORDER BY id`, chat)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var rules []ApprovalRule
+	for rows.Next() {
+		var r ApprovalRule
+		if err := rows.Scan(&r.ID, &r.Chat, &r.Tool, &r.Pattern, &r.Created); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// Remove forgets one rule of a chat, or all of them when id is 0, and
+// returns how many were removed.
+func (s *ApprovalStore) Remove(ctx context.Context, chat string, id int64) (int64, error) {
+	var res sql.Result
+	var err error
+	if id == 0 {
+		res, err = s.db.ExecContext(ctx, `DELETE FROM approval_rules WHERE chat = ?`, chat)
+	} else {
+		res, err = s.db.ExecContext(ctx, `DELETE FROM approval_rules WHERE chat = ? AND id = ?`, chat, id)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
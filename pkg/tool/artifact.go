@@ -0,0 +1,221 @@
+package tool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var artifactIDPattern = regexp.MustCompile(`^art-[0-9a-f]{8}$`)
+
+const (
+	// DefaultArtifactTTL is how long an artifact is kept after it is saved.
+	DefaultArtifactTTL = 24 * time.Hour
+	artifactSweepEvery = time.Hour
+)
+
+// ArtifactStore keeps full subagent outputs and oversized tool results on
+// disk so the agent only needs a reference and a preview in its context.
+// Artifacts belong to the chat they were saved for, and only that chat can
+// read them. They are deleted once they are older than the TTL.
+type ArtifactStore struct {
+	dir       string
+	mu        sync.RWMutex
+	ttl       time.Duration
+	lastSweep time.Time
+}
+
+// NewArtifactStore keeps artifacts in dir, normally the data directory's
+// "artifacts", readable only by the user. With no dir they go to a new
+// private temporary directory.
+func NewArtifactStore(dir string) (*ArtifactStore, error) {
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "nene-artifacts-")
+		if err != nil {
+			return nil, fmt.Errorf("create artifact directory: %w", err)
+		}
+		return &ArtifactStore{dir: tmp, ttl: DefaultArtifactTTL}, nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create artifact directory: %w", err)
+	}
+	return &ArtifactStore{dir: dir, ttl: DefaultArtifactTTL}, nil
+}
+
+// SetTTL changes how long artifacts are kept; zero or less keeps the
+// default.
+func (s *ArtifactStore) SetTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ttl <= 0 {
+		ttl = DefaultArtifactTTL
+	}
+	s.ttl = ttl
+}
+
+// chatDir is the directory of a chat's artifacts, as in "telegram:123".
+func (s *ArtifactStore) chatDir(chat string) string {
+	sum := sha256.Sum256([]byte(chat))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:8]))
+}
+
+// Save stores content for chat and returns its ID.
+func (s *ArtifactStore) Save(chat, content string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := time.Now(); now.Sub(s.lastSweep) >= artifactSweepEvery {
+		s.lastSweep = now
+		s.sweep(now)
+	}
+	dir := s.chatDir(chat)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("save artifact: %w", err)
+	}
+	id := "art-" + uuid.NewString()[:8]
+	if err := os.WriteFile(filepath.Join(dir, id+".txt"), []byte(content), 0600); err != nil {
+		return "", fmt.Errorf("save artifact: %w", err)
+	}
+	return id, nil
+}
+
+// Get returns an artifact that was saved for chat and has not expired.
+func (s *ArtifactStore) Get(chat, id string) (string, error) {
+	if !artifactIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid artifact id: %s", id)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	path := filepath.Join(s.chatDir(chat), id+".txt")
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > s.ttl {
+		return "", fmt.Errorf("artifact not found: %s", id)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("artifact not found: %s", id)
+	}
+	return string(data), nil
+}
+
+// sweep deletes expired artifacts and the chat directories left empty.
+func (s *ArtifactStore) sweep(now time.Time) {
+	chats, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, c := range chats {
+		if !c.IsDir() {
+			continue
+		}
+		dir := filepath.Join(s.dir, c.Name())
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		left := len(files)
+		for _, f := range files {
+			info, err := f.Info()
+			if err != nil || now.Sub(info.ModTime()) <= s.ttl {
+				continue
+			}
+			if os.Remove(filepath.Join(dir, f.Name())) == nil {
+				left--
+			}
+		}
+		if left == 0 {
+			os.Remove(dir)
+		}
+	}
+}
+
+type GetArtifactTool struct {
+	parameters json.RawMessage
+	store      *ArtifactStore
+}
+
+func NewGetArtifactTool(store *ArtifactStore) *GetArtifactTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The artifact ID, e.g. art-1a2b3c4d",
+			},
+			"offset": map[string]interface{}{
+				"type":        "integer",
+				"description": "Character offset to start reading from (default: 0)",
+			},
+			"max_chars": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum characters to return (default: 10000)",
+			},
+		},
+		"required": []string{"id"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &GetArtifactTool{parameters: paramsJSON, store: store}
+}
+
+func (t *GetArtifactTool) Name() string { return "get_artifact" }
+func (t *GetArtifactTool) Description() string {
+	return "Retrieve the full content of an artifact, such as a subagent result referenced by spawn or a long tool result that was cut short. Use offset and max_chars to page through long artifacts."
+}
+func (t *GetArtifactTool) Parameters() json.RawMessage { return t.parameters }
+
+type getArtifactArgs struct {
+	ID       string `json:"id"`
+	Offset   int    `json:"offset"`
+	MaxChars int    `json:"max_chars"`
+}
+
+func (t *GetArtifactTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *GetArtifactTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a getArtifactArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+
+	if a.ID == "" {
+		return ErrorResult("id is required"), nil
+	}
+
+	if t.store == nil {
+		return ErrorResult("artifact store not configured"), nil
+	}
+
+	channel, chatID := chatFrom(ctx)
+	content, err := t.store.Get(channel+":"+chatID, a.ID)
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+
+	if a.MaxChars <= 0 {
+		a.MaxChars = 10000
+	}
+	// Offsets count characters, not bytes.
+	runes := []rune(content)
+	if a.Offset < 0 || a.Offset > len(runes) {
+		a.Offset = 0
+	}
+
+	end := min(a.Offset+a.MaxChars, len(runes))
+	chunk := string(runes[a.Offset:end])
+	if end < len(runes) {
+		chunk += fmt.Sprintf("\n... (%d more characters, continue with offset %d)", len(runes)-end, end)
+	}
+
+	return OkResult(chunk), nil
+}
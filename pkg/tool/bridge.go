@@ -0,0 +1,148 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nene-agent/nene/pkg/bus"
+)
+
+// BridgeDestination is a chat on another channel that the agent may post to,
+// addressed by Name.
+type BridgeDestination struct {
+	Name        string `json:"name"`
+	Channel     string `json:"channel"`
+	ChatID      string `json:"chat_id"`
+	Description string `json:"description"`
+}
+
+// BridgeTool posts to chats other than the one being served. Only the
+// configured destinations can be reached.
+type BridgeTool struct {
+	bus *bus.MessageBus
+
+	mu           sync.RWMutex
+	parameters   json.RawMessage
+	destinations map[string]BridgeDestination
+	names        []string
+	channel      string
+	chatID       string
+}
+
+func NewBridgeTool(destinations []BridgeDestination) *BridgeTool {
+	t := &BridgeTool{}
+	t.SetDestinations(destinations)
+	return t
+}
+
+func (t *BridgeTool) SetBus(b *bus.MessageBus) {
+	t.bus = b
+}
+
+// SetDestinations replaces the allowed destinations, e.g. on config reload.
+func (t *BridgeTool) SetDestinations(destinations []BridgeDestination) {
+	byName := make(map[string]BridgeDestination, len(destinations))
+	var names []string
+	var desc strings.Builder
+	for _, d := range destinations {
+		byName[d.Name] = d
+		names = append(names, d.Name)
+		fmt.Fprintf(&desc, "\n- %s (%s)", d.Name, d.Channel)
+		if d.Description != "" {
+			desc.WriteString(": " + d.Description)
+		}
+	}
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"destination": map[string]interface{}{
+				"type":        "string",
+				"enum":        names,
+				"description": "Where to send the message:" + desc.String(),
+			},
+			"content": map[string]interface{}{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The message to send",
+			},
+		},
+		"required": []string{"destination", "content"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.parameters = paramsJSON
+	t.destinations = byName
+	t.names = names
+}
+
+func (t *BridgeTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *BridgeTool) Name() string { return "send_to" }
+func (t *BridgeTool) Description() string {
+	return "Send a message to another configured chat or channel, such as a team or ops channel, instead of the current chat."
+}
+
+func (t *BridgeTool) Parameters() json.RawMessage {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.parameters
+}
+
+type bridgeArgs struct {
+	Destination string `json:"destination"`
+	Content     string `json:"content"`
+}
+
+func (t *BridgeTool) Mutates(args json.RawMessage) bool { return true }
+
+func (t *BridgeTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *BridgeTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a bridgeArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	if a.Content == "" {
+		return ErrorResult("content is required"), nil
+	}
+
+	t.mu.RLock()
+	dest, ok := t.destinations[a.Destination]
+	names := t.names
+	channel, chatID := t.channel, t.chatID
+	t.mu.RUnlock()
+	channel, chatID = callChat(ctx, channel, chatID)
+	from := channel + ":" + chatID
+
+	if !ok {
+		if len(names) == 0 {
+			return ErrorResult("no destinations are configured"), nil
+		}
+		return ErrorResult(fmt.Sprintf("unknown destination %q; allowed: %s", a.Destination, strings.Join(names, ", "))), nil
+	}
+	if t.bus == nil {
+		return ErrorResult("send_to tool not properly configured with a message bus"), nil
+	}
+	if dest.Channel+":"+dest.ChatID == from {
+		return ErrorResult(a.Destination + " is the current chat; answer directly instead"), nil
+	}
+
+	t.bus.PublishOutbound(bus.OutboundMessage{
+		Channel: dest.Channel,
+		ChatID:  dest.ChatID,
+		Content: a.Content,
+	})
+	return OkResult("Message sent to " + a.Destination), nil
+}
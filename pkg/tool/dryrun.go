@@ -0,0 +1,89 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// DryRunner is implemented by tools that can describe what a call would do,
+// such as the command it would run or the diff it would write, without
+// doing it.
+type DryRunner interface {
+	Tool
+	DryRun(ctx context.Context, args json.RawMessage) (Result, error)
+}
+
+// ReadOnlyTool is implemented by tools that ask for approval but change
+// nothing, so they run normally in dry-run mode.
+type ReadOnlyTool interface {
+	Tool
+	ReadOnly() bool
+}
+
+// DryRun holds the global dry-run switch and per-chat overrides. While it
+// is on for a chat, DryRunner tools report what they would do and other
+// tools that need approval are described instead of run.
+type DryRun struct {
+	mu     sync.RWMutex
+	global bool
+	chats  map[string]bool
+}
+
+func NewDryRun(global bool) *DryRun {
+	return &DryRun{global: global, chats: make(map[string]bool)}
+}
+
+func (d *DryRun) SetGlobal(on bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.global = on
+}
+
+// SetChat overrides the global switch for a "channel:chatID" chat.
+func (d *DryRun) SetChat(chat string, on bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.chats[chat] = on
+}
+
+// ResetChat makes a chat follow the global switch again.
+func (d *DryRun) ResetChat(chat string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.chats, chat)
+}
+
+// Enabled reports whether dry-run is on for a chat, and whether that comes
+// from a per-chat override.
+func (d *DryRun) Enabled(chat string) (on, override bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if on, ok := d.chats[chat]; ok {
+		return on, true
+	}
+	return d.global, false
+}
+
+// dryRun handles a call in dry-run mode. handled is false for calls that
+// should run normally.
+func dryRun(ctx context.Context, name string, t Tool, args json.RawMessage) (result Result, handled bool, err error) {
+	if r, ok := t.(DryRunner); ok {
+		result, err = r.DryRun(ctx, args)
+		if err == nil && !result.IsError {
+			result.Content = "[dry run, nothing was changed]\n" + result.Content
+		}
+		return result, true, err
+	}
+	if r, ok := t.(ReadOnlyTool); ok && r.ReadOnly() {
+		return Result{}, false, nil
+	}
+	a, err := t.MakeApproval(args)
+	if err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), true, nil
+	}
+	if a == nil {
+		return Result{}, false, nil
+	}
+	return OkResult("[dry run, nothing was done] " + name + " would: " + a.Justification() + "\n" + a.What()), true, nil
+}
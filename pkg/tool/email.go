@@ -0,0 +1,115 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nene-agent/nene/pkg/mail"
+)
+
+type SendEmailTool struct {
+	parameters json.RawMessage
+
+	mu  sync.RWMutex
+	cfg mail.SMTPConfig
+}
+
+func NewSendEmailTool(cfg mail.SMTPConfig) *SendEmailTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"to": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Recipient addresses",
+			},
+			"cc": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Carbon-copy addresses",
+			},
+			"subject": map[string]interface{}{
+				"type":        "string",
+				"description": "Subject line",
+			},
+			"body": map[string]interface{}{
+				"type":        "string",
+				"description": "Plain-text body",
+			},
+			"in_reply_to": map[string]interface{}{
+				"type":        "string",
+				"description": "Message-ID of the email being answered, to keep the reply in its thread",
+			},
+		},
+		"required": []string{"to", "subject", "body"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &SendEmailTool{parameters: paramsJSON, cfg: cfg}
+}
+
+// SetConfig replaces the SMTP settings, e.g. on config reload.
+func (t *SendEmailTool) SetConfig(cfg mail.SMTPConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cfg = cfg
+}
+
+func (t *SendEmailTool) Name() string { return "send_email" }
+func (t *SendEmailTool) Description() string {
+	return "Send a plain-text email. The full draft is shown to the user for approval before it is sent."
+}
+func (t *SendEmailTool) Parameters() json.RawMessage { return t.parameters }
+
+type sendEmailArgs struct {
+	To        []string `json:"to"`
+	Cc        []string `json:"cc"`
+	Subject   string   `json:"subject"`
+	Body      string   `json:"body"`
+	InReplyTo string   `json:"in_reply_to"`
+}
+
+func (t *SendEmailTool) Mutates(args json.RawMessage) bool { return true }
+
+func (t *SendEmailTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	var a sendEmailArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return nil, err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "To: %s\n", strings.Join(a.To, ", "))
+	if len(a.Cc) > 0 {
+		fmt.Fprintf(&sb, "Cc: %s\n", strings.Join(a.Cc, ", "))
+	}
+	fmt.Fprintf(&sb, "Subject: %s\n\n%s", a.Subject, a.Body)
+	return NewApproval("Agent wants to send an email", sb.String()), nil
+}
+
+func (t *SendEmailTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a sendEmailArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	if len(a.To) == 0 {
+		return ErrorResult("at least one recipient is required"), nil
+	}
+
+	t.mu.RLock()
+	cfg := t.cfg
+	t.mu.RUnlock()
+
+	id, err := mail.Send(ctx, cfg, mail.Message{
+		To:         a.To,
+		Cc:         a.Cc,
+		Subject:    a.Subject,
+		Body:       a.Body,
+		InReplyTo:  a.InReplyTo,
+		References: a.InReplyTo,
+	})
+	if err != nil {
+		return ErrorResult("failed to send email: " + err.Error()), nil
+	}
+	return OkResult(fmt.Sprintf("Email sent to %s (Message-ID %s)", strings.Join(a.To, ", "), id)), nil
+}
@@ -0,0 +1,171 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nene-agent/nene/pkg/feeds"
+)
+
+type SubscribeFeedTool struct {
+	toolChat
+	parameters json.RawMessage
+	poller     *feeds.Poller
+}
+
+func NewSubscribeFeedTool(p *feeds.Poller) *SubscribeFeedTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "URL of the RSS or Atom feed",
+			},
+			"summarize": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Summarize new items with the model instead of only listing them (default: false)",
+			},
+		},
+		"required": []string{"url"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &SubscribeFeedTool{parameters: paramsJSON, poller: p}
+}
+
+func (t *SubscribeFeedTool) Name() string { return "subscribe_feed" }
+func (t *SubscribeFeedTool) Description() string {
+	return "Subscribe the current chat to an RSS or Atom feed. New items are checked periodically and sent to the chat as a digest."
+}
+func (t *SubscribeFeedTool) Parameters() json.RawMessage { return t.parameters }
+
+type subscribeFeedArgs struct {
+	URL       string `json:"url"`
+	Summarize bool   `json:"summarize"`
+}
+
+func (t *SubscribeFeedTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	var a subscribeFeedArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return nil, err
+	}
+	return NewApproval("Agent wants to subscribe to a feed", "Subscribe: "+a.URL), nil
+}
+
+func (t *SubscribeFeedTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a subscribeFeedArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	if !strings.HasPrefix(a.URL, "http://") && !strings.HasPrefix(a.URL, "https://") {
+		return ErrorResult("URL must start with http:// or https://"), nil
+	}
+	channel, chatID := t.chat(ctx)
+	if channel == "" || chatID == "" {
+		return ErrorResult("subscribe_feed needs a chat to deliver to"), nil
+	}
+
+	sub, err := t.poller.Subscribe(ctx, a.URL, channel, chatID, a.Summarize)
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+	return OkResult(fmt.Sprintf("Subscribed to %q (id %s). New items will be posted to this chat.", sub.Title, sub.ID)), nil
+}
+
+type ListFeedsTool struct {
+	toolChat
+	parameters json.RawMessage
+	store      *feeds.Store
+}
+
+func NewListFeedsTool(s *feeds.Store) *ListFeedsTool {
+	params := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &ListFeedsTool{parameters: paramsJSON, store: s}
+}
+
+func (t *ListFeedsTool) Name() string { return "list_feeds" }
+func (t *ListFeedsTool) Description() string {
+	return "List the feeds the current chat is subscribed to."
+}
+func (t *ListFeedsTool) Parameters() json.RawMessage { return t.parameters }
+
+func (t *ListFeedsTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *ListFeedsTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	subs := t.store.List(t.chat(ctx))
+	if len(subs) == 0 {
+		return OkResult("This chat has no feed subscriptions."), nil
+	}
+
+	var lines []string
+	for _, s := range subs {
+		line := fmt.Sprintf("- %s: %s <%s>", s.ID, s.Title, s.URL)
+		if s.Summarize {
+			line += " (summarized)"
+		}
+		if s.LastError != "" {
+			line += " — last check failed: " + s.LastError
+		}
+		lines = append(lines, line)
+	}
+	return OkResult(strings.Join(lines, "\n")), nil
+}
+
+type UnsubscribeFeedTool struct {
+	toolChat
+	parameters json.RawMessage
+	store      *feeds.Store
+}
+
+func NewUnsubscribeFeedTool(s *feeds.Store) *UnsubscribeFeedTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"feed": map[string]interface{}{
+				"type":        "string",
+				"description": "Subscription ID from list_feeds, or the feed URL",
+			},
+		},
+		"required": []string{"feed"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &UnsubscribeFeedTool{parameters: paramsJSON, store: s}
+}
+
+func (t *UnsubscribeFeedTool) Name() string { return "unsubscribe_feed" }
+func (t *UnsubscribeFeedTool) Description() string {
+	return "Unsubscribe the current chat from a feed."
+}
+func (t *UnsubscribeFeedTool) Parameters() json.RawMessage { return t.parameters }
+
+type unsubscribeFeedArgs struct {
+	Feed string `json:"feed"`
+}
+
+func (t *UnsubscribeFeedTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	var a unsubscribeFeedArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return nil, err
+	}
+	return NewApproval("Agent wants to unsubscribe from a feed", "Unsubscribe: "+a.Feed), nil
+}
+
+func (t *UnsubscribeFeedTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a unsubscribeFeedArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	channel, chatID := t.chat(ctx)
+	sub, ok := t.store.Remove(channel, chatID, a.Feed)
+	if !ok {
+		return ErrorResult("no subscription matches " + a.Feed), nil
+	}
+	return OkResult(fmt.Sprintf("Unsubscribed from %q.", sub.Title)), nil
+}
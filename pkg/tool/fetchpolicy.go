@@ -0,0 +1,151 @@
+package tool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WebFetchConfig tunes webfetch. Zero values use the defaults; negative
+// values disable the cache, the per-domain interval, or the concurrency
+// limit.
+type WebFetchConfig struct {
+	CacheDir         string `json:"cache_dir"`
+	CacheTTLMinutes  int    `json:"cache_ttl_minutes"`
+	DomainIntervalMs int    `json:"domain_interval_ms"`
+	MaxConcurrent    int    `json:"max_concurrent"`
+	TimeoutSeconds   int    `json:"timeout_seconds"`
+}
+
+const (
+	defaultFetchCacheTTL       = time.Hour
+	defaultFetchDomainInterval = time.Second
+	defaultMaxConcurrentFetch  = 4
+	defaultFetchTimeout        = 30 * time.Second
+)
+
+// fetchSetting converts a WebFetchConfig value to a duration in unit.
+func fetchSetting(v int, def, unit time.Duration) time.Duration {
+	switch {
+	case v < 0:
+		return 0
+	case v == 0:
+		return def
+	default:
+		return time.Duration(v) * unit
+	}
+}
+
+// fetchCache stores fetched content on disk keyed by URL and max_chars.
+// Entries older than ttl are ignored and overwritten.
+type fetchCache struct {
+	dir string
+	ttl time.Duration
+}
+
+func newFetchCache(dir string, ttl time.Duration) *fetchCache {
+	if ttl <= 0 {
+		return nil
+	}
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "nene-webcache")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Printf("Webfetch cache disabled: %v\n", err)
+		return nil
+	}
+	return &fetchCache{dir: dir, ttl: ttl}
+}
+
+func (c *fetchCache) path(url string, maxChars int) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%d", url, maxChars))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".txt")
+}
+
+func (c *fetchCache) get(url string, maxChars int) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	p := c.path(url, maxChars)
+	info, err := os.Stat(p)
+	if err != nil || time.Since(info.ModTime()) > c.ttl {
+		return "", false
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+func (c *fetchCache) put(url string, maxChars int, content string) {
+	if c == nil {
+		return
+	}
+	if err := os.WriteFile(c.path(url, maxChars), []byte(content), 0644); err != nil {
+		fmt.Printf("Failed to cache %s: %v\n", url, err)
+	}
+}
+
+// fetchLimiter spaces out requests to the same host and caps the number of
+// fetches in flight.
+type fetchLimiter struct {
+	interval time.Duration
+	slots    chan struct{}
+
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+func newFetchLimiter(interval time.Duration, maxConcurrent int) *fetchLimiter {
+	l := &fetchLimiter{interval: interval, next: make(map[string]time.Time)}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// acquire waits for the host's turn and then for a free slot. The returned
+// func releases the slot.
+func (l *fetchLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	if l.interval > 0 {
+		l.mu.Lock()
+		now := time.Now()
+		at := l.next[host]
+		if at.Before(now) {
+			at = now
+		}
+		l.next[host] = at.Add(l.interval)
+		for h, t := range l.next {
+			if t.Before(now) {
+				delete(l.next, h)
+			}
+		}
+		l.mu.Unlock()
+
+		if wait := time.Until(at); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	if l.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
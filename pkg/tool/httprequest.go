@@ -0,0 +1,271 @@
+package tool
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHTTPMaxBytes = 20000
+	maxHTTPMaxBytes     = 200000
+	httpRequestTimeout  = 30 * time.Second
+)
+
+var httpMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// HTTPCredential is attached to requests that name it. Secrets never reach
+// the model: it only sees credential names. Hosts restricts where the
+// credential may be sent; a leading "*." matches any subdomain.
+type HTTPCredential struct {
+	Type     string   `json:"type"` // bearer or basic
+	Token    string   `json:"token"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Hosts    []string `json:"hosts"`
+}
+
+func (c HTTPCredential) allows(host string) bool {
+	host = strings.ToLower(host)
+	for _, h := range c.Hosts {
+		h = strings.ToLower(h)
+		if host == h || (strings.HasPrefix(h, "*.") && strings.HasSuffix(host, h[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+type HTTPRequestTool struct {
+	parameters json.RawMessage
+	client     *http.Client
+
+	mu    sync.RWMutex
+	creds map[string]HTTPCredential
+}
+
+func NewHTTPRequestTool(creds map[string]HTTPCredential) *HTTPRequestTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"method": map[string]interface{}{
+				"type":        "string",
+				"description": "HTTP method (default: GET)",
+				"enum":        httpMethods,
+			},
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "The request URL",
+			},
+			"headers": map[string]interface{}{
+				"type":                 "object",
+				"description":          "Request headers",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+			"body": map[string]interface{}{
+				"type":        "string",
+				"description": "Raw request body",
+			},
+			"json": map[string]interface{}{
+				"description": "JSON request body; sets Content-Type to application/json. Use instead of body.",
+			},
+			"credential": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of a configured credential to authenticate with",
+			},
+			"max_bytes": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum response body bytes to return (default: 20000)",
+				"minimum":     100.0,
+				"maximum":     float64(maxHTTPMaxBytes),
+			},
+		},
+		"required": []string{"url"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &HTTPRequestTool{
+		parameters: paramsJSON,
+		client:     &http.Client{Timeout: httpRequestTimeout},
+		creds:      creds,
+	}
+}
+
+// SetCredentials replaces the credential store, e.g. on config reload.
+func (t *HTTPRequestTool) SetCredentials(creds map[string]HTTPCredential) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.creds = creds
+}
+
+func (t *HTTPRequestTool) credential(name string) (HTTPCredential, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	c, ok := t.creds[name]
+	return c, ok
+}
+
+func (t *HTTPRequestTool) credentialNames() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	names := make([]string, 0, len(t.creds))
+	for name := range t.creds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t *HTTPRequestTool) Name() string { return "http_request" }
+func (t *HTTPRequestTool) Description() string {
+	desc := "Send an HTTP request with any method, headers and body, e.g. to call a REST API. Returns the status, response headers and body (truncated to max_bytes) as JSON. Use webfetch instead to read web pages."
+	if names := t.credentialNames(); len(names) > 0 {
+		desc += " Available credentials: " + strings.Join(names, ", ") + "."
+	}
+	return desc
+}
+func (t *HTTPRequestTool) Parameters() json.RawMessage { return t.parameters }
+
+type httpRequestArgs struct {
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"`
+	JSON       json.RawMessage   `json:"json"`
+	Credential string            `json:"credential"`
+	MaxBytes   int               `json:"max_bytes"`
+}
+
+type httpResponse struct {
+	Status    string            `json:"status"`
+	Code      int               `json:"status_code"`
+	Headers   map[string]string `json:"headers"`
+	Body      string            `json:"body"`
+	Truncated bool              `json:"truncated,omitempty"`
+}
+
+// Mutates is false only for methods that should not change anything.
+func (t *HTTPRequestTool) Mutates(args json.RawMessage) bool {
+	var a httpRequestArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return true
+	}
+	switch strings.ToUpper(cmp.Or(a.Method, "GET")) {
+	case "GET", "HEAD", "OPTIONS":
+		return false
+	}
+	return true
+}
+
+func (t *HTTPRequestTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	var a httpRequestArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return nil, err
+	}
+	what := strings.ToUpper(cmp.Or(a.Method, "GET")) + " " + a.URL
+	if a.Credential != "" {
+		what += " (credential: " + a.Credential + ")"
+	}
+	return NewApproval("Agent wants to send an HTTP request", what), nil
+}
+
+func (t *HTTPRequestTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a httpRequestArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+
+	method := strings.ToUpper(cmp.Or(a.Method, "GET"))
+	if !slices.Contains(httpMethods, method) {
+		return ErrorResult(fmt.Sprintf("unsupported method %q", a.Method)), nil
+	}
+	u, err := url.Parse(a.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrorResult("url must be an absolute http:// or https:// URL"), nil
+	}
+	if a.Body != "" && len(a.JSON) > 0 {
+		return ErrorResult("set either body or json, not both"), nil
+	}
+	maxBytes := a.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultHTTPMaxBytes
+	}
+	maxBytes = min(maxBytes, maxHTTPMaxBytes)
+
+	var body io.Reader
+	if len(a.JSON) > 0 {
+		body = bytes.NewReader(a.JSON)
+	} else if a.Body != "" {
+		body = strings.NewReader(a.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return ErrorResult("failed to create request: " + err.Error()), nil
+	}
+	req.Header.Set("User-Agent", "nene")
+	if len(a.JSON) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range a.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if a.Credential != "" {
+		cred, ok := t.credential(a.Credential)
+		if !ok {
+			return ErrorResult(fmt.Sprintf("unknown credential %q", a.Credential)), nil
+		}
+		if !cred.allows(u.Hostname()) {
+			return ErrorResult(fmt.Sprintf("credential %q may not be sent to %s", a.Credential, u.Hostname())), nil
+		}
+		switch cred.Type {
+		case "bearer":
+			req.Header.Set("Authorization", "Bearer "+cred.Token)
+		case "basic":
+			req.SetBasicAuth(cred.Username, cred.Password)
+		default:
+			return ErrorResult(fmt.Sprintf("credential %q has unsupported type %q", a.Credential, cred.Type)), nil
+		}
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return ErrorResult("request failed: " + err.Error()), nil
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+	if err != nil {
+		return ErrorResult("failed to read response: " + err.Error()), nil
+	}
+
+	out := httpResponse{
+		Status:  resp.Status,
+		Code:    resp.StatusCode,
+		Headers: make(map[string]string, len(resp.Header)),
+	}
+	for k, v := range resp.Header {
+		out.Headers[k] = strings.Join(v, ", ")
+	}
+	if len(data) > maxBytes {
+		data = data[:maxBytes]
+		out.Truncated = true
+	}
+	out.Body = string(data)
+
+	result, _ := json.MarshalIndent(out, "", "  ")
+	if resp.StatusCode >= 400 {
+		return ErrorResult(string(result)), nil
+	}
+	return OkResult(string(result)), nil
+}
@@ -0,0 +1,225 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nene-agent/nene/pkg/kb"
+)
+
+type KBSearchTool struct {
+	parameters json.RawMessage
+	kb         *kb.KB
+}
+
+func NewKBSearchTool(k *kb.KB) *KBSearchTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"minLength":   1,
+				"description": "What to look for, phrased as a question or keywords",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of passages to return (default 5)",
+			},
+		},
+		"required": []string{"query"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &KBSearchTool{parameters: paramsJSON, kb: k}
+}
+
+func (t *KBSearchTool) Name() string { return "kb_search" }
+func (t *KBSearchTool) Description() string {
+	return "Search the knowledge base of indexed reference documents and return the most relevant passages with their sources. Use this before answering questions the documents may cover."
+}
+func (t *KBSearchTool) Parameters() json.RawMessage { return t.parameters }
+
+type kbSearchArgs struct {
+	Query string `json:"query"`
+	Limit int    `json:"limit"`
+}
+
+func (t *KBSearchTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *KBSearchTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a kbSearchArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	if a.Query == "" {
+		return ErrorResult("query is required"), nil
+	}
+	if a.Limit > 20 {
+		a.Limit = 20
+	}
+
+	hits, err := t.kb.Search(ctx, a.Query, a.Limit)
+	if err != nil {
+		return ErrorResult("failed to search knowledge base: " + err.Error()), nil
+	}
+	if len(hits) == 0 {
+		return OkResult("No relevant passages found in the knowledge base."), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Found %d relevant passages:\n", len(hits))
+	for i, h := range hits {
+		fmt.Fprintf(&sb, "\n%d. %s (part %d, score %.2f)\n%s\n", i+1, h.Source, h.Seq+1, h.Score, h.Content)
+	}
+	return OkResult(sb.String()), nil
+}
+
+type KBAddTool struct {
+	parameters json.RawMessage
+	kb         *kb.KB
+}
+
+func NewKBAddTool(k *kb.KB) *KBAddTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"source": map[string]interface{}{
+				"type":        "string",
+				"minLength":   1,
+				"description": "File, directory, or http(s) URL to index. Directories are indexed recursively.",
+			},
+		},
+		"required": []string{"source"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &KBAddTool{parameters: paramsJSON, kb: k}
+}
+
+func (t *KBAddTool) Name() string { return "kb_add" }
+func (t *KBAddTool) Description() string {
+	return "Add a file, directory, or URL to the knowledge base so kb_search can find it. Text, Markdown, HTML, PDF, DOCX, and XLSX are supported; unchanged documents are skipped."
+}
+func (t *KBAddTool) Parameters() json.RawMessage { return t.parameters }
+
+type kbAddArgs struct {
+	Source string `json:"source"`
+}
+
+func (t *KBAddTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	var a kbAddArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return nil, err
+	}
+	return NewApproval("Agent wants to add to the knowledge base", "Index: "+a.Source), nil
+}
+
+func (t *KBAddTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a kbAddArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	if a.Source == "" {
+		return ErrorResult("source is required"), nil
+	}
+
+	r := t.kb.Ingest(ctx, a.Source)
+	if len(r.Added) == 0 && r.Unchanged == 0 && len(r.Failed) > 0 {
+		return ErrorResult("nothing indexed: " + r.String()), nil
+	}
+	return OkResult("Knowledge base updated: " + r.String()), nil
+}
+
+type KBListTool struct {
+	parameters json.RawMessage
+	kb         *kb.KB
+}
+
+func NewKBListTool(k *kb.KB) *KBListTool {
+	params := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &KBListTool{parameters: paramsJSON, kb: k}
+}
+
+func (t *KBListTool) Name() string                { return "kb_list" }
+func (t *KBListTool) Description() string         { return "List the documents in the knowledge base." }
+func (t *KBListTool) Parameters() json.RawMessage { return t.parameters }
+
+func (t *KBListTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *KBListTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	docs, err := t.kb.List(ctx)
+	if err != nil {
+		return ErrorResult("failed to list knowledge base: " + err.Error()), nil
+	}
+	if len(docs) == 0 {
+		return OkResult("The knowledge base is empty."), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d documents:\n", len(docs))
+	for _, d := range docs {
+		fmt.Fprintf(&sb, "- %s (%d chunks, added %s)\n", d.Source, d.Chunks, d.Created.Format("2006-01-02"))
+	}
+	return OkResult(sb.String()), nil
+}
+
+type KBRemoveTool struct {
+	parameters json.RawMessage
+	kb         *kb.KB
+}
+
+func NewKBRemoveTool(k *kb.KB) *KBRemoveTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"source": map[string]interface{}{
+				"type":        "string",
+				"description": "Source path or URL of the document, as shown by kb_list",
+			},
+		},
+		"required": []string{"source"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &KBRemoveTool{parameters: paramsJSON, kb: k}
+}
+
+func (t *KBRemoveTool) Name() string { return "kb_remove" }
+func (t *KBRemoveTool) Description() string {
+	return "Remove a document from the knowledge base."
+}
+func (t *KBRemoveTool) Parameters() json.RawMessage { return t.parameters }
+
+type kbRemoveArgs struct {
+	Source string `json:"source"`
+}
+
+func (t *KBRemoveTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	var a kbRemoveArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return nil, err
+	}
+	return NewApproval("Agent wants to remove from the knowledge base", "Remove: "+a.Source), nil
+}
+
+func (t *KBRemoveTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a kbRemoveArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	ok, err := t.kb.Remove(ctx, a.Source)
+	if err != nil {
+		return ErrorResult("failed to remove document: " + err.Error()), nil
+	}
+	if !ok {
+		return ErrorResult("no document matches " + a.Source), nil
+	}
+	return OkResult("Removed " + a.Source + " from the knowledge base."), nil
+}
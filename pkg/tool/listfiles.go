@@ -40,6 +40,8 @@ type listFilesArgs struct {
 	Pattern string `json:"pattern,omitempty"`
 }
 
+func (t *ListFilesTool) ReadOnly() bool { return true }
+
 func (t *ListFilesTool) MakeApproval(args json.RawMessage) (*Approval, error) {
 	var a listFilesArgs
 	if err := json.Unmarshal(args, &a); err != nil {
@@ -19,6 +19,7 @@ func NewMemoryForgetTool(m memory.Memory) *MemoryForgetTool {
 		"properties": map[string]interface{}{
 			"key": map[string]interface{}{
 				"type":        "string",
+				"minLength":   1,
 				"description": "The key of the memory entry to delete",
 			},
 		},
@@ -0,0 +1,115 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nene-agent/nene/pkg/memory"
+)
+
+// memoryListPreview is how much of each entry's content memory_list shows.
+const memoryListPreview = 200
+
+type MemoryListTool struct {
+	parameters json.RawMessage
+	mem        memory.Memory
+}
+
+func NewMemoryListTool(m memory.Memory) *MemoryListTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"category": map[string]interface{}{
+				"type":        "string",
+				"description": "Only list entries of this category, e.g. core, daily, or conversation",
+			},
+			"prefix": map[string]interface{}{
+				"type":        "string",
+				"description": "Only list entries whose key starts with this",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of entries to return (default 20)",
+			},
+			"offset": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of entries to skip, for the next page (default 0)",
+			},
+		},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &MemoryListTool{parameters: paramsJSON, mem: m}
+}
+
+func (t *MemoryListTool) Name() string { return "memory_list" }
+func (t *MemoryListTool) Description() string {
+	return "List long-term memory entries, most recently updated first, optionally by category or key prefix. Use this to review what is stored before tidying it up."
+}
+func (t *MemoryListTool) Parameters() json.RawMessage { return t.parameters }
+
+type memoryListArgs struct {
+	Category string `json:"category"`
+	Prefix   string `json:"prefix"`
+	Limit    int    `json:"limit"`
+	Offset   int    `json:"offset"`
+}
+
+func (t *MemoryListTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *MemoryListTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a memoryListArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	if a.Limit <= 0 {
+		a.Limit = 20
+	}
+	if a.Offset < 0 {
+		a.Offset = 0
+	}
+
+	req := &memory.ListRequest{
+		Prefix: a.Prefix,
+		// One more than asked tells whether there is another page.
+		Limit:  a.Limit + 1,
+		Offset: a.Offset,
+	}
+	if a.Category != "" {
+		req.Category = memory.ParseCategory(a.Category)
+	}
+	entries, err := t.mem.List(ctx, req)
+	if err != nil {
+		fmt.Printf("memory_list error: %v\n", err)
+		return ErrorResult("failed to list memories: " + err.Error()), nil
+	}
+
+	more := len(entries) > a.Limit
+	if more {
+		entries = entries[:a.Limit]
+	}
+	if len(entries) == 0 {
+		if a.Offset > 0 {
+			return OkResult("No more memories."), nil
+		}
+		return OkResult("No memories found."), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Memories %d-%d:\n\n", a.Offset+1, a.Offset+len(entries))
+	for i, e := range entries {
+		content := e.Content
+		if r := []rune(content); len(r) > memoryListPreview {
+			content = string(r[:memoryListPreview]) + "..."
+		}
+		fmt.Fprintf(&sb, "%d. [%s] %s (updated %s)\n   %s\n\n",
+			a.Offset+i+1, e.Category, e.Key, e.UpdatedAt.Format("2006-01-02"), content)
+	}
+	if more {
+		fmt.Fprintf(&sb, "More entries follow; list again with offset %d.", a.Offset+len(entries))
+	}
+	return OkResult(strings.TrimRight(sb.String(), "\n")), nil
+}
@@ -20,6 +20,7 @@ func NewMemoryRecallTool(m memory.Memory) *MemoryRecallTool {
 		"properties": map[string]interface{}{
 			"query": map[string]interface{}{
 				"type":        "string",
+				"minLength":   1,
 				"description": "Search query to find relevant memories",
 			},
 			"limit": map[string]interface{}{
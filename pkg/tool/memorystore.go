@@ -12,6 +12,9 @@ type MemoryStoreTool struct {
 	parameters json.RawMessage
 	mem        memory.Memory
 	sessionID  string
+
+	similarity float64
+	reconciler memory.Reconciler
 }
 
 func NewMemoryStoreTool(m memory.Memory) *MemoryStoreTool {
@@ -20,10 +23,12 @@ func NewMemoryStoreTool(m memory.Memory) *MemoryStoreTool {
 		"properties": map[string]interface{}{
 			"key": map[string]interface{}{
 				"type":        "string",
+				"minLength":   1,
 				"description": "Unique identifier for this memory entry",
 			},
 			"content": map[string]interface{}{
 				"type":        "string",
+				"minLength":   1,
 				"description": "The information to store in long-term memory",
 			},
 			"category": map[string]interface{}{
@@ -40,6 +45,15 @@ func NewMemoryStoreTool(m memory.Memory) *MemoryStoreTool {
 
 func (t *MemoryStoreTool) SetSessionID(id string) { t.sessionID = id }
 
+// SetDedup makes storing under a new key update an existing entry of the
+// category instead when the two are at least similarity alike (see
+// memory.FindSimilar). With a reconciler, the two contents are merged;
+// without one, the new content replaces the old. Zero turns it off.
+func (t *MemoryStoreTool) SetDedup(similarity float64, r memory.Reconciler) {
+	t.similarity = similarity
+	t.reconciler = r
+}
+
 func (t *MemoryStoreTool) Name() string { return "memory_store" }
 func (t *MemoryStoreTool) Description() string {
 	return "Store information in long-term memory. Use this to remember important facts, user preferences, or context for future conversations."
@@ -75,6 +89,22 @@ func (t *MemoryStoreTool) Execute(ctx context.Context, args json.RawMessage) (Re
 		req.SessionID = t.sessionID
 	}
 
+	var similar *memory.Entry
+	if t.similarity > 0 {
+		similar = t.findSimilar(ctx, req)
+	}
+	if similar != nil {
+		req.Key = similar.Key
+		if t.reconciler != nil {
+			merged, err := t.reconciler(ctx, t.sessionID, similar.Content, req.Content)
+			if err != nil || merged == "" {
+				fmt.Printf("memory_store: failed to merge with %s, replacing it: %v\n", similar.Key, err)
+			} else {
+				req.Content = merged
+			}
+		}
+	}
+
 	entry, err := t.mem.Store(ctx, req)
 	if err != nil {
 		fmt.Printf("memory_store error: %v\n", err)
@@ -82,5 +112,26 @@ func (t *MemoryStoreTool) Execute(ctx context.Context, args json.RawMessage) (Re
 	}
 
 	fmt.Printf("memory_store success: key=%s, category=%s\n", entry.Key, entry.Category)
+	if similar != nil {
+		return OkResult(fmt.Sprintf("✅ Updated memory %s, which already held much the same as %s, instead of adding a new one. Stored content:\n%s",
+			entry.Key, a.Key, entry.Content)), nil
+	}
 	return OkResult("✅ Stored memory: " + entry.Key), nil
 }
+
+// findSimilar returns the entry a store under a new key should update
+// instead, if any.
+func (t *MemoryStoreTool) findSimilar(ctx context.Context, req *memory.StoreRequest) *memory.Entry {
+	if existing, err := t.mem.Get(ctx, req.Key); err != nil || existing != nil {
+		return nil
+	}
+	similar, score, err := memory.FindSimilar(ctx, t.mem, req, t.similarity)
+	if err != nil {
+		fmt.Printf("memory_store: failed to look for similar memories: %v\n", err)
+		return nil
+	}
+	if similar != nil {
+		fmt.Printf("memory_store: %s is %.0f%% like %s\n", req.Key, score*100, similar.Key)
+	}
+	return similar
+}
@@ -0,0 +1,128 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nene-agent/nene/pkg/memory"
+)
+
+type MemoryUpdateTool struct {
+	parameters json.RawMessage
+	mem        memory.Memory
+}
+
+func NewMemoryUpdateTool(m memory.Memory) *MemoryUpdateTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"rename", "set_category", "forget_prefix"},
+				"description": "rename: give the entry at key the key new_key; set_category: move the entry at key to category; forget_prefix: delete every entry whose key starts with prefix",
+			},
+			"key": map[string]interface{}{
+				"type":        "string",
+				"description": "Key of the entry to rename or recategorize",
+			},
+			"new_key": map[string]interface{}{
+				"type":        "string",
+				"description": "New key, for rename",
+			},
+			"category": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"core", "daily", "conversation"},
+				"description": "New category, for set_category",
+			},
+			"prefix": map[string]interface{}{
+				"type":        "string",
+				"description": "Key prefix of the entries to delete, for forget_prefix",
+			},
+		},
+		"required": []string{"action"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &MemoryUpdateTool{parameters: paramsJSON, mem: m}
+}
+
+func (t *MemoryUpdateTool) Name() string { return "memory_update" }
+func (t *MemoryUpdateTool) Description() string {
+	return "Reorganize long-term memory: rename an entry's key, change its category, or delete all entries whose key starts with a prefix. Use memory_list first to see what is stored."
+}
+func (t *MemoryUpdateTool) Parameters() json.RawMessage { return t.parameters }
+
+type memoryUpdateArgs struct {
+	Action   string `json:"action"`
+	Key      string `json:"key"`
+	NewKey   string `json:"new_key"`
+	Category string `json:"category"`
+	Prefix   string `json:"prefix"`
+}
+
+// MakeApproval asks before bulk deletes only; renames and category changes
+// lose nothing.
+func (t *MemoryUpdateTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	var a memoryUpdateArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return nil, err
+	}
+	if a.Action != "forget_prefix" {
+		return nil, nil
+	}
+	return NewApproval("Agent wants to delete memories", "Forget every memory whose key starts with: "+a.Prefix).
+		WithRule(a.Prefix, ""), nil
+}
+
+func (t *MemoryUpdateTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a memoryUpdateArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+
+	switch a.Action {
+	case "rename":
+		if a.Key == "" || a.NewKey == "" {
+			return ErrorResult("key and new_key are required"), nil
+		}
+		ok, err := t.mem.Rename(ctx, a.Key, a.NewKey)
+		if err != nil {
+			fmt.Printf("memory_update error: %v\n", err)
+			return ErrorResult("failed to rename memory: " + err.Error()), nil
+		}
+		if !ok {
+			return OkResult("Memory '" + a.Key + "' was not found."), nil
+		}
+		fmt.Printf("memory_update: renamed %s to %s\n", a.Key, a.NewKey)
+		return OkResult("Memory '" + a.Key + "' is now '" + a.NewKey + "'."), nil
+
+	case "set_category":
+		if a.Key == "" || a.Category == "" {
+			return ErrorResult("key and category are required"), nil
+		}
+		category := memory.ParseCategory(a.Category)
+		ok, err := t.mem.SetCategory(ctx, a.Key, category)
+		if err != nil {
+			fmt.Printf("memory_update error: %v\n", err)
+			return ErrorResult("failed to change category: " + err.Error()), nil
+		}
+		if !ok {
+			return OkResult("Memory '" + a.Key + "' was not found."), nil
+		}
+		fmt.Printf("memory_update: moved %s to %s\n", a.Key, category)
+		return OkResult(fmt.Sprintf("Memory '%s' is now in %s.", a.Key, category)), nil
+
+	case "forget_prefix":
+		if a.Prefix == "" {
+			return ErrorResult("prefix is required"), nil
+		}
+		n, err := t.mem.ForgetPrefix(ctx, a.Prefix)
+		if err != nil {
+			fmt.Printf("memory_update error: %v\n", err)
+			return ErrorResult("failed to forget memories: " + err.Error()), nil
+		}
+		fmt.Printf("memory_update: forgot %d entries with prefix %s\n", n, a.Prefix)
+		return OkResult(fmt.Sprintf("Forgot %d memories starting with '%s'.", n, a.Prefix)), nil
+	}
+	return ErrorResult("action must be rename, set_category, or forget_prefix"), nil
+}
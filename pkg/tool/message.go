@@ -8,10 +8,9 @@ import (
 )
 
 type MessageTool struct {
-	parameters     json.RawMessage
-	bus            *bus.MessageBus
-	currentChannel string
-	currentChatID  string
+	toolChat
+	parameters json.RawMessage
+	bus        *bus.MessageBus
 }
 
 func NewMessageTool() *MessageTool {
@@ -20,8 +19,22 @@ func NewMessageTool() *MessageTool {
 		"properties": map[string]interface{}{
 			"content": map[string]interface{}{
 				"type":        "string",
+				"minLength":   1,
 				"description": "The message content to send to the user",
 			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"markdown", "code", "pre", "plain"},
+				"description": "markdown (default); code or pre to show the content as code; plain to show it exactly as written",
+			},
+			"silent": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Deliver without a notification sound",
+			},
+			"reply_to": map[string]interface{}{
+				"type":        "string",
+				"description": "ID of a message to reply to, or \"current\" for the message you are answering",
+			},
 		},
 		"required": []string{"content"},
 	}
@@ -33,11 +46,6 @@ func (t *MessageTool) SetBus(b *bus.MessageBus) {
 	t.bus = b
 }
 
-func (t *MessageTool) SetContext(channel, chatID string) {
-	t.currentChannel = channel
-	t.currentChatID = chatID
-}
-
 func (t *MessageTool) Name() string { return "message" }
 func (t *MessageTool) Description() string {
 	return "Send a message to the user. Use this to communicate information, ask questions, or provide updates. The message will be sent immediately to the current chat."
@@ -46,6 +54,9 @@ func (t *MessageTool) Parameters() json.RawMessage { return t.parameters }
 
 type messageArgs struct {
 	Content string `json:"content"`
+	Format  string `json:"format"`
+	Silent  bool   `json:"silent"`
+	ReplyTo string `json:"reply_to"`
 }
 
 func (t *MessageTool) MakeApproval(args json.RawMessage) (*Approval, error) {
@@ -62,14 +73,31 @@ func (t *MessageTool) Execute(ctx context.Context, args json.RawMessage) (Result
 		return ErrorResult("content is required"), nil
 	}
 
-	if t.bus == nil || t.currentChannel == "" || t.currentChatID == "" {
+	format := a.Format
+	switch format {
+	case "markdown":
+		format = bus.FormatMarkdown
+	case bus.FormatMarkdown, bus.FormatCode, bus.FormatPre, bus.FormatPlain:
+	default:
+		return ErrorResult("format must be markdown, code, pre, or plain"), nil
+	}
+	replyTo := a.ReplyTo
+	if replyTo == "current" {
+		replyTo = MessageIDFrom(ctx)
+	}
+
+	channel, chatID := t.chat(ctx)
+	if t.bus == nil || channel == "" || chatID == "" {
 		return ErrorResult("message tool not properly configured with channel context"), nil
 	}
 
 	t.bus.PublishOutbound(bus.OutboundMessage{
-		Channel: t.currentChannel,
-		ChatID:  t.currentChatID,
+		Channel: channel,
+		ChatID:  chatID,
 		Content: a.Content,
+		Format:  format,
+		Silent:  a.Silent,
+		ReplyTo: replyTo,
 	})
 
 	return OkResult("Message sent to user"), nil
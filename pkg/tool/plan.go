@@ -0,0 +1,187 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nene-agent/nene/pkg/bus"
+)
+
+const PlanToolName = "plan_update"
+
+const (
+	PlanPending    = "pending"
+	PlanInProgress = "in_progress"
+	PlanDone       = "done"
+	PlanSkipped    = "skipped"
+)
+
+var planStatuses = []string{PlanPending, PlanInProgress, PlanDone, PlanSkipped}
+
+// PlanTool lets the model lay out a plan for a long turn and mark its steps
+// as it goes. Every change is published as a plan stream event so channels
+// can show the plan while the turn runs.
+type PlanTool struct {
+	parameters json.RawMessage
+	bus        *bus.MessageBus
+
+	mu      sync.Mutex
+	plans   map[string][]bus.PlanStep
+	channel string
+	chatID  string
+}
+
+func NewPlanTool() *PlanTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"steps": map[string]interface{}{
+				"type":        "array",
+				"description": "The whole plan, replacing the current one. Give it before starting the work.",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"title": map[string]interface{}{
+							"type":        "string",
+							"description": "What the step does, in a few words",
+						},
+						"status": map[string]interface{}{
+							"type":        "string",
+							"enum":        planStatuses,
+							"description": "Step status (default: pending)",
+						},
+					},
+					"required": []string{"title"},
+				},
+			},
+			"step": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of the step to update, starting at 1",
+			},
+			"status": map[string]interface{}{
+				"type":        "string",
+				"enum":        planStatuses,
+				"description": "New status of that step",
+			},
+		},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &PlanTool{parameters: paramsJSON, plans: make(map[string][]bus.PlanStep)}
+}
+
+func (t *PlanTool) SetBus(b *bus.MessageBus) {
+	t.bus = b
+}
+
+func (t *PlanTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *PlanTool) Name() string { return PlanToolName }
+func (t *PlanTool) Description() string {
+	return "Show the user your plan for a task that needs several steps or tool calls. " +
+		"Call it with steps before you start, then with step and status as you begin and finish each step " +
+		"(in_progress, done, or skipped). The user sees the plan update live."
+}
+func (t *PlanTool) Parameters() json.RawMessage { return t.parameters }
+
+type planArgs struct {
+	Steps  []bus.PlanStep `json:"steps"`
+	Step   int            `json:"step"`
+	Status string         `json:"status"`
+}
+
+func (t *PlanTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *PlanTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a planArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	if a.Steps == nil && a.Step == 0 {
+		return ErrorResult("give either steps for a new plan, or step and status to update one"), nil
+	}
+
+	t.mu.Lock()
+	channel, chatID := callChat(ctx, t.channel, t.chatID)
+	chat := channel + ":" + chatID
+	plan := t.plans[chat]
+
+	if a.Steps != nil {
+		plan = make([]bus.PlanStep, 0, len(a.Steps))
+		for i, s := range a.Steps {
+			if strings.TrimSpace(s.Title) == "" {
+				t.mu.Unlock()
+				return ErrorResult(fmt.Sprintf("step %d has no title", i+1)), nil
+			}
+			if s.Status == "" {
+				s.Status = PlanPending
+			}
+			if !slices.Contains(planStatuses, s.Status) {
+				t.mu.Unlock()
+				return ErrorResult(fmt.Sprintf("step %d: invalid status %q", i+1, s.Status)), nil
+			}
+			plan = append(plan, s)
+		}
+	}
+	if a.Step != 0 {
+		if a.Step < 1 || a.Step > len(plan) {
+			t.mu.Unlock()
+			if len(plan) == 0 {
+				return ErrorResult("there is no plan yet; give the steps first"), nil
+			}
+			return ErrorResult(fmt.Sprintf("step must be between 1 and %d", len(plan))), nil
+		}
+		if !slices.Contains(planStatuses, a.Status) {
+			t.mu.Unlock()
+			return ErrorResult(fmt.Sprintf("status must be one of %s", strings.Join(planStatuses, ", "))), nil
+		}
+		plan = slices.Clone(plan)
+		plan[a.Step-1].Status = a.Status
+	}
+	t.plans[chat] = plan
+	t.mu.Unlock()
+
+	if t.bus != nil && chatID != "" {
+		t.bus.PublishStream(bus.StreamMessage{
+			Channel: channel,
+			ChatID:  chatID,
+			Type:    bus.StreamEventPlan,
+			Plan:    plan,
+		})
+	}
+	return OkResult(FormatPlan(plan)), nil
+}
+
+// FormatPlan renders a plan as a numbered checklist.
+func FormatPlan(plan []bus.PlanStep) string {
+	var sb strings.Builder
+	for i, s := range plan {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		fmt.Fprintf(&sb, "%s %d. %s", planMarker(s.Status), i+1, s.Title)
+	}
+	return sb.String()
+}
+
+func planMarker(status string) string {
+	switch status {
+	case PlanInProgress:
+		return "🔄"
+	case PlanDone:
+		return "✅"
+	case PlanSkipped:
+		return "⏭️"
+	}
+	return "⬜"
+}
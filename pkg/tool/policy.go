@@ -0,0 +1,132 @@
+package tool
+
+import (
+	"context"
+	"sync"
+)
+
+type Role string
+
+const (
+	RoleOwner Role = "owner"
+	RoleUser  Role = "user"
+)
+
+type roleKey struct{}
+
+// WithRole records the role of the sender whose message is being handled.
+// An empty role leaves ctx unchanged.
+func WithRole(ctx context.Context, role Role) context.Context {
+	if role == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// RoleFrom returns the sender role recorded in ctx. Calls without one, such
+// as those made by the admin API, are treated as coming from an owner.
+func RoleFrom(ctx context.Context) Role {
+	if role, ok := ctx.Value(roleKey{}).(Role); ok {
+		return role
+	}
+	return RoleOwner
+}
+
+type senderKey struct{}
+
+// WithSender records the sender whose message is being handled.
+func WithSender(ctx context.Context, senderID string) context.Context {
+	return context.WithValue(ctx, senderKey{}, senderID)
+}
+
+// SenderFrom returns the sender recorded in ctx, or "".
+func SenderFrom(ctx context.Context) string {
+	id, _ := ctx.Value(senderKey{}).(string)
+	return id
+}
+
+type messageKey struct{}
+
+// WithMessageID records the channel's ID of the message being handled.
+func WithMessageID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, messageKey{}, id)
+}
+
+// MessageIDFrom returns the message ID recorded in ctx, or "".
+func MessageIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(messageKey{}).(string)
+	return id
+}
+
+type chatKey struct{}
+
+type chat struct{ channel, chatID string }
+
+func withChat(ctx context.Context, channel, chatID string) context.Context {
+	return context.WithValue(ctx, chatKey{}, chat{channel, chatID})
+}
+
+func chatFrom(ctx context.Context) (channel, chatID string) {
+	c, _ := ctx.Value(chatKey{}).(chat)
+	return c.channel, c.chatID
+}
+
+// callChat returns the chat a call is made for: the one in ctx, or else the
+// one a contextual tool was last given. Sessions run concurrently and share
+// tool instances, so the latter may belong to another session's call.
+func callChat(ctx context.Context, channel, chatID string) (string, string) {
+	if c, id := chatFrom(ctx); c != "" && id != "" {
+		return c, id
+	}
+	return channel, chatID
+}
+
+// toolChat holds the chat a contextual tool was last given, for tools that
+// embed it.
+type toolChat struct {
+	mu      sync.RWMutex
+	channel string
+	chatID  string
+}
+
+func (c *toolChat) SetContext(channel, chatID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.channel = channel
+	c.chatID = chatID
+}
+
+// chat returns the chat a call is made for, as callChat does.
+func (c *toolChat) chat(ctx context.Context) (string, string) {
+	c.mu.RLock()
+	channel, chatID := c.channel, c.chatID
+	c.mu.RUnlock()
+	return callChat(ctx, channel, chatID)
+}
+
+// Policy restricts tools to owners. It is shared by a manager and every
+// view derived from it, so updates apply everywhere. Entries may be
+// "namespace:*".
+type Policy struct {
+	mu        sync.RWMutex
+	ownerOnly []string
+}
+
+func NewPolicy(ownerOnly ...string) *Policy {
+	return &Policy{ownerOnly: ownerOnly}
+}
+
+func (p *Policy) SetOwnerOnly(names ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ownerOnly = names
+}
+
+func (p *Policy) Allowed(role Role, toolName string) bool {
+	if role == RoleOwner {
+		return true
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return !Matches(p.ownerOnly, toolName)
+}
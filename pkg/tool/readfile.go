@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/nene-agent/nene/pkg/document"
 )
 
 type ReadFileTool struct {
@@ -20,6 +22,14 @@ func NewReadFileTool() *ReadFileTool {
 				"type":        "string",
 				"description": "The path to the file to read",
 			},
+			"pages": map[string]interface{}{
+				"type":        "string",
+				"description": "PDF only: pages to read, e.g. \"1-3,7\" (default: all)",
+			},
+			"sheet": map[string]interface{}{
+				"type":        "string",
+				"description": "XLSX only: sheet name or 1-based index to read (default: all)",
+			},
 		},
 		"required": []string{"path"},
 	}
@@ -27,14 +37,20 @@ func NewReadFileTool() *ReadFileTool {
 	return &ReadFileTool{parameters: paramsJSON}
 }
 
-func (t *ReadFileTool) Name() string                { return "read_file" }
-func (t *ReadFileTool) Description() string         { return "Read the contents of a file" }
+func (t *ReadFileTool) Name() string { return "read_file" }
+func (t *ReadFileTool) Description() string {
+	return "Read the contents of a file. Text is extracted from PDF, DOCX, and XLSX files."
+}
 func (t *ReadFileTool) Parameters() json.RawMessage { return t.parameters }
 
 type readFileArgs struct {
-	Path string `json:"path"`
+	Path  string `json:"path"`
+	Pages string `json:"pages"`
+	Sheet string `json:"sheet"`
 }
 
+func (t *ReadFileTool) ReadOnly() bool { return true }
+
 func (t *ReadFileTool) MakeApproval(args json.RawMessage) (*Approval, error) {
 	var a readFileArgs
 	if err := json.Unmarshal(args, &a); err != nil {
@@ -59,5 +75,13 @@ func (t *ReadFileTool) Execute(ctx context.Context, args json.RawMessage) (Resul
 		return ErrorResult("failed to read file: " + err.Error()), nil
 	}
 
+	if kind := document.Kind(path, "", content); kind != "" {
+		text, err := document.Extract(kind, content, document.Options{Pages: a.Pages, Sheet: a.Sheet})
+		if err != nil {
+			return ErrorResult(err.Error()), nil
+		}
+		return OkResult(text), nil
+	}
+
 	return OkResult(string(content)), nil
 }
@@ -0,0 +1,52 @@
+package tool
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// MutatingTool is implemented by tools that can change the host or the
+// outside world, such as by running code or sending a request. Mutates
+// reports whether a call with args would.
+type MutatingTool interface {
+	Tool
+	Mutates(args json.RawMessage) bool
+}
+
+// ReadOnlyTools are refused in read-only mode under any namespace, so
+// plugin tools with these names are covered too.
+var ReadOnlyTools = []string{"shell", "write_file", "edit_file"}
+
+// SetReadOnly refuses calls that would change anything, here and in the
+// views derived from this manager: the tools in ReadOnlyTools and the
+// calls a MutatingTool says would mutate.
+func (m *Manager) SetReadOnly(on bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readOnly = on
+}
+
+func (m *Manager) currentReadOnly() bool {
+	m.mu.RLock()
+	on := m.readOnly
+	m.mu.RUnlock()
+	if !on && m.parent != nil {
+		return m.parent.currentReadOnly()
+	}
+	return on
+}
+
+// ReadOnly reports whether read-only mode is on.
+func (m *Manager) ReadOnly() bool {
+	return m.currentReadOnly()
+}
+
+func mutates(name string, t Tool, args json.RawMessage) bool {
+	if _, base := splitName(name); slices.Contains(ReadOnlyTools, base) {
+		return true
+	}
+	if mt, ok := t.(MutatingTool); ok {
+		return mt.Mutates(args)
+	}
+	return false
+}
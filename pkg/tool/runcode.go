@@ -0,0 +1,225 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRunTimeout = 30 * time.Second
+	maxRunTimeout     = 5 * time.Minute
+	maxRunOutput      = 64 * 1024
+)
+
+// runtimeSpec describes how to run a source file for one language.
+type runtimeSpec struct {
+	file        string
+	interpreter []string // candidates, the first one found on PATH is used
+	args        []string // placed between the interpreter and the file
+}
+
+var runtimes = map[string]runtimeSpec{
+	"python":     {file: "main.py", interpreter: []string{"python3", "python"}},
+	"javascript": {file: "main.js", interpreter: []string{"node"}},
+	"go":         {file: "main.go", interpreter: []string{"go"}, args: []string{"run"}},
+}
+
+var languageAliases = map[string]string{
+	"py":      "python",
+	"python3": "python",
+	"js":      "javascript",
+	"node":    "javascript",
+	"golang":  "go",
+}
+
+// RunCodeTool runs a source snippet in a throwaway directory with the
+// language's interpreter or compiler.
+type RunCodeTool struct {
+	parameters json.RawMessage
+}
+
+func NewRunCodeTool() *RunCodeTool {
+	languages := make([]string, 0, len(runtimes))
+	for lang := range runtimes {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"language": map[string]interface{}{
+				"type":        "string",
+				"description": "Language of the source code",
+				"enum":        languages,
+			},
+			"code": map[string]interface{}{
+				"type":        "string",
+				"description": "Complete program source. Go code must be package main with a main function.",
+			},
+			"stdin": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional input passed on standard input",
+			},
+			"timeout_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum run time in seconds (default: 30, max: 300)",
+				"minimum":     1.0,
+				"maximum":     300.0,
+			},
+		},
+		"required": []string{"language", "code"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &RunCodeTool{parameters: paramsJSON}
+}
+
+func (t *RunCodeTool) Name() string { return "run_code" }
+func (t *RunCodeTool) Description() string {
+	return "Runs a program written in Python, JavaScript (Node.js) or Go in a fresh temporary directory and returns its exit code, stdout and stderr as JSON. Prefer this over shell heredocs for computing, data processing, or testing snippets."
+}
+func (t *RunCodeTool) Parameters() json.RawMessage { return t.parameters }
+
+type runCodeArgs struct {
+	Language       string `json:"language"`
+	Code           string `json:"code"`
+	Stdin          string `json:"stdin"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+}
+
+type runCodeResult struct {
+	Language   string `json:"language"`
+	ExitCode   int    `json:"exit_code"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	TimedOut   bool   `json:"timed_out,omitempty"`
+	Truncated  bool   `json:"truncated,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+func (t *RunCodeTool) Mutates(args json.RawMessage) bool { return true }
+
+func (t *RunCodeTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	var a runCodeArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return nil, err
+	}
+	return NewApproval(fmt.Sprintf("Agent wants to run %s code", a.Language), a.Code), nil
+}
+
+func (t *RunCodeTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a runCodeArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	if strings.TrimSpace(a.Code) == "" {
+		return ErrorResult("code is required"), nil
+	}
+
+	lang := strings.ToLower(a.Language)
+	if alias, ok := languageAliases[lang]; ok {
+		lang = alias
+	}
+	spec, ok := runtimes[lang]
+	if !ok {
+		return ErrorResult(fmt.Sprintf("unsupported language %q", a.Language)), nil
+	}
+	interpreter, err := lookInterpreter(spec.interpreter)
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+
+	timeout := defaultRunTimeout
+	if a.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(a.TimeoutSeconds)*time.Second, maxRunTimeout)
+	}
+
+	dir, err := os.MkdirTemp("", "nene-run-*")
+	if err != nil {
+		return ErrorResult("create workspace: " + err.Error()), nil
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, spec.file), []byte(a.Code), 0600); err != nil {
+		return ErrorResult("write source: " + err.Error()), nil
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmdArgs := append(append([]string{}, spec.args...), spec.file)
+	cmd := exec.CommandContext(runCtx, interpreter, cmdArgs...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(a.Stdin)
+	// Give orphaned children a moment to release the pipes after a kill.
+	cmd.WaitDelay = 2 * time.Second
+	stdout := &limitedBuffer{limit: maxRunOutput}
+	stderr := &limitedBuffer{limit: maxRunOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	res := runCodeResult{
+		Language:   lang,
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+		Truncated:  stdout.truncated || stderr.truncated,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case runCtx.Err() == context.DeadlineExceeded:
+		res.TimedOut = true
+		res.ExitCode = -1
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return ErrorResult(fmt.Sprintf("run %s: %v", lang, err)), nil
+	}
+
+	out, _ := json.MarshalIndent(res, "", "  ")
+	if res.TimedOut || res.ExitCode != 0 {
+		return ErrorResult(string(out)), nil
+	}
+	return OkResult(string(out)), nil
+}
+
+func lookInterpreter(candidates []string) (string, error) {
+	for _, name := range candidates {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not installed", candidates[0])
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the
+// rest, so a chatty program cannot flood the context.
+type limitedBuffer struct {
+	buf       []byte
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.buf); room < len(p) {
+		b.buf = append(b.buf, p[:max(room, 0)]...)
+		b.truncated = true
+	} else {
+		b.buf = append(b.buf, p...)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return string(b.buf)
+}
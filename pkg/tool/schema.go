@@ -0,0 +1,137 @@
+package tool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// SchemaProblem is one way a call's arguments break the tool's schema. Path
+// names the argument, as in "steps[0].title"; it is empty for the arguments
+// as a whole.
+type SchemaProblem struct {
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// SchemaError is returned by ValidateArgs.
+type SchemaError struct {
+	Problems []SchemaProblem
+}
+
+func (e *SchemaError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.Message
+		if p.Path != "" {
+			msgs[i] = p.Path + ": " + p.Message
+		}
+	}
+	return "invalid arguments: " + strings.Join(msgs, "; ")
+}
+
+// result describes the problems to the model as JSON, so it can fix the
+// call and try again.
+func (e *SchemaError) result(name string) Result {
+	data, _ := json.Marshal(map[string]interface{}{
+		"error":    "invalid_arguments",
+		"tool":     name,
+		"problems": e.Problems,
+		"hint":     "Fix the arguments to match the tool's parameters and call it again.",
+	})
+	return ErrorResult(string(data))
+}
+
+type compiledSchema struct {
+	schema *jsonschema.Schema
+	err    error
+}
+
+// schemas caches compiled schemas by their text, so each is compiled once.
+var schemas sync.Map
+
+func compileSchema(schema json.RawMessage) (*jsonschema.Schema, error) {
+	key := string(schema)
+	if c, ok := schemas.Load(key); ok {
+		return c.(*compiledSchema).schema, c.(*compiledSchema).err
+	}
+	c := &compiledSchema{}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err == nil {
+		compiler := jsonschema.NewCompiler()
+		compiler.AssertFormat()
+		if err = compiler.AddResource("mem:///tool.json", doc); err == nil {
+			c.schema, err = compiler.Compile("mem:///tool.json")
+		}
+	}
+	if err != nil {
+		c.err = fmt.Errorf("invalid schema: %w", err)
+		fmt.Printf("Tool arguments will not be validated: %v\n", c.err)
+	}
+	actual, _ := schemas.LoadOrStore(key, c)
+	return actual.(*compiledSchema).schema, actual.(*compiledSchema).err
+}
+
+// ValidateArgs checks a call's arguments against a tool's JSON Schema and
+// returns a *SchemaError listing every problem. Empty args count as {}. An
+// error of another kind means the schema itself is broken.
+func ValidateArgs(schema, args json.RawMessage) error {
+	if len(bytes.TrimSpace(schema)) == 0 {
+		return nil
+	}
+	sch, err := compileSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	var value interface{} = map[string]interface{}{}
+	if trimmed := bytes.TrimSpace(args); len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null")) {
+		if value, err = jsonschema.UnmarshalJSON(bytes.NewReader(trimmed)); err != nil {
+			return &SchemaError{Problems: []SchemaProblem{{Message: "not valid JSON: " + err.Error()}}}
+		}
+	}
+
+	err = sch.Validate(value)
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	var problems []SchemaProblem
+	for _, unit := range verr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		problems = append(problems, SchemaProblem{Path: argPath(unit.InstanceLocation), Message: unit.Error.String()})
+	}
+	if len(problems) == 0 {
+		problems = []SchemaProblem{{Message: verr.Error()}}
+	}
+	return &SchemaError{Problems: problems}
+}
+
+// argPath turns a JSON pointer such as "/steps/0/title" into
+// "steps[0].title".
+func argPath(pointer string) string {
+	var sb strings.Builder
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		if _, err := strconv.Atoi(token); err == nil {
+			sb.WriteString("[" + token + "]")
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString(".")
+		}
+		sb.WriteString(token)
+	}
+	return sb.String()
+}
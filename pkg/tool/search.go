@@ -0,0 +1,261 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type SearchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// SearchBackend is a web search provider used by the websearch tool.
+type SearchBackend interface {
+	Name() string
+	Search(ctx context.Context, query string, count int) ([]SearchResult, error)
+}
+
+// SearchBackendConfig selects and configures a backend. Type is one of
+// duckduckgo, brave, searxng or google.
+type SearchBackendConfig struct {
+	Type    string `json:"type"`
+	APIKey  string `json:"api_key"`
+	BaseURL string `json:"base_url"`
+	CX      string `json:"cx"`
+}
+
+// NewSearchBackends creates backends in configured order, which is also the
+// fallback order.
+func NewSearchBackends(configs []SearchBackendConfig) ([]SearchBackend, error) {
+	backends := make([]SearchBackend, 0, len(configs))
+	for _, c := range configs {
+		b, err := NewSearchBackend(c)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, b)
+	}
+	return backends, nil
+}
+
+func NewSearchBackend(c SearchBackendConfig) (SearchBackend, error) {
+	switch c.Type {
+	case "duckduckgo", "":
+		return &DuckDuckGoBackend{}, nil
+	case "brave":
+		if c.APIKey == "" {
+			return nil, errors.New("brave search needs an api_key")
+		}
+		return &BraveBackend{APIKey: c.APIKey}, nil
+	case "searxng":
+		if c.BaseURL == "" {
+			return nil, errors.New("searxng needs a base_url")
+		}
+		return &SearxNGBackend{BaseURL: c.BaseURL}, nil
+	case "google":
+		if c.APIKey == "" || c.CX == "" {
+			return nil, errors.New("google custom search needs an api_key and cx")
+		}
+		return &GoogleCSEBackend{APIKey: c.APIKey, CX: c.CX}, nil
+	default:
+		return nil, fmt.Errorf("unknown search backend %q", c.Type)
+	}
+}
+
+var searchClient = &http.Client{Timeout: 15 * time.Second}
+
+// getSearch performs a GET request and returns the body, treating any
+// non-200 status as an error so the next backend is tried.
+func getSearch(ctx context.Context, rawURL string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := searchClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+// DuckDuckGoBackend scrapes the DuckDuckGo HTML endpoint. It needs no key
+// but is often rate limited with a captcha.
+type DuckDuckGoBackend struct{}
+
+func (b *DuckDuckGoBackend) Name() string { return "duckduckgo" }
+
+var (
+	ddgLink    = regexp.MustCompile(`<a[^>]*class="[^"]*result__a[^"]*"[^>]*href="([^"]+)"[^>]*>([\s\S]*?)</a>`)
+	ddgSnippet = regexp.MustCompile(`<a class="result__snippet[^"]*".*?>([\s\S]*?)</a>`)
+)
+
+func (b *DuckDuckGoBackend) Search(ctx context.Context, query string, count int) ([]SearchResult, error) {
+	header := http.Header{}
+	header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	body, err := getSearch(ctx, "https://html.duckduckgo.com/html/?q="+url.QueryEscape(query), header)
+	if err != nil {
+		return nil, err
+	}
+	html := string(body)
+	if strings.Contains(html, "anomaly-modal") || strings.Contains(html, "challenge-form") {
+		return nil, errors.New("blocked by captcha")
+	}
+
+	links := ddgLink.FindAllStringSubmatch(html, count)
+	snippets := ddgSnippet.FindAllStringSubmatch(html, count)
+	results := make([]SearchResult, 0, len(links))
+	for i, m := range links {
+		r := SearchResult{
+			Title: strings.TrimSpace(stripTags(m[2])),
+			URL:   ddgTarget(m[1]),
+		}
+		if i < len(snippets) {
+			r.Snippet = strings.TrimSpace(stripTags(snippets[i][1]))
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// ddgTarget unwraps DuckDuckGo's redirect links.
+func ddgTarget(link string) string {
+	if !strings.Contains(link, "uddg=") {
+		return link
+	}
+	u, err := url.QueryUnescape(link)
+	if err != nil {
+		return link
+	}
+	if idx := strings.Index(u, "uddg="); idx != -1 {
+		return u[idx+5:]
+	}
+	return link
+}
+
+// BraveBackend uses the Brave Search API.
+type BraveBackend struct {
+	APIKey string
+}
+
+func (b *BraveBackend) Name() string { return "brave" }
+
+func (b *BraveBackend) Search(ctx context.Context, query string, count int) ([]SearchResult, error) {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	header.Set("X-Subscription-Token", b.APIKey)
+	body, err := getSearch(ctx, fmt.Sprintf("https://api.search.brave.com/res/v1/web/search?q=%s&count=%d", url.QueryEscape(query), count), header)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	results := make([]SearchResult, 0, len(resp.Web.Results))
+	for _, r := range resp.Web.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: stripTags(r.Description)})
+	}
+	return results, nil
+}
+
+// SearxNGBackend queries a SearxNG instance, which must have the JSON
+// output format enabled.
+type SearxNGBackend struct {
+	BaseURL string
+}
+
+func (b *SearxNGBackend) Name() string { return "searxng" }
+
+func (b *SearxNGBackend) Search(ctx context.Context, query string, count int) ([]SearchResult, error) {
+	searchURL := strings.TrimRight(b.BaseURL, "/") + "/search?format=json&q=" + url.QueryEscape(query)
+	body, err := getSearch(ctx, searchURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	results := make([]SearchResult, 0, min(len(resp.Results), count))
+	for _, r := range resp.Results {
+		if len(results) == count {
+			break
+		}
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return results, nil
+}
+
+// GoogleCSEBackend uses the Google Custom Search JSON API.
+type GoogleCSEBackend struct {
+	APIKey string
+	CX     string
+}
+
+func (b *GoogleCSEBackend) Name() string { return "google" }
+
+func (b *GoogleCSEBackend) Search(ctx context.Context, query string, count int) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("key", b.APIKey)
+	params.Set("cx", b.CX)
+	params.Set("q", query)
+	params.Set("num", fmt.Sprint(min(count, 10)))
+	body, err := getSearch(ctx, "https://www.googleapis.com/customsearch/v1?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Items []struct {
+			Title   string `json:"title"`
+			Link    string `json:"link"`
+			Snippet string `json:"snippet"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	results := make([]SearchResult, 0, len(resp.Items))
+	for _, r := range resp.Items {
+		results = append(results, SearchResult{Title: r.Title, URL: r.Link, Snippet: r.Snippet})
+	}
+	return results, nil
+}
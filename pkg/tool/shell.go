@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 )
 
 type ShellTool struct {
@@ -42,7 +43,8 @@ func (t *ShellTool) MakeApproval(args json.RawMessage) (*Approval, error) {
 	if err := json.Unmarshal(args, &a); err != nil {
 		return nil, err
 	}
-	return NewApproval("Agent wants to run the command", a.Cmdline), nil
+	return NewApproval("Agent wants to run the command", a.Cmdline).
+		WithRule(a.Cmdline, commandRule(a.Cmdline)), nil
 }
 
 func (t *ShellTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
@@ -74,3 +76,34 @@ func (t *ShellTool) Execute(ctx context.Context, args json.RawMessage) (Result,
 
 	return OkResult(string(output)), nil
 }
+
+func (t *ShellTool) DryRun(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a shellArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		dir = "(unknown)"
+	}
+	return OkResult("Would run: " + a.Cmdline + "\nIn directory: " + dir), nil
+}
+
+// commandRule suggests the "always allow" rule for a command: the program
+// and its subcommand, if any, followed by " *", e.g. "git status *" for
+// "git status -s". Commands that chain, redirect, or substitute can do more
+// than their first words say, so none is offered for them.
+func commandRule(cmdline string) string {
+	if strings.ContainsAny(cmdline, ";&|`$()<>\n\\") {
+		return ""
+	}
+	fields := strings.Fields(cmdline)
+	if len(fields) == 0 {
+		return ""
+	}
+	prefix := fields[0]
+	if len(fields) > 1 && !strings.HasPrefix(fields[1], "-") && !strings.ContainsAny(fields[1], "/.=~*?") {
+		prefix += " " + fields[1]
+	}
+	return prefix + " *"
+}
@@ -6,13 +6,16 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"unicode/utf8"
+
+	"github.com/nene-agent/nene/pkg/bus"
 )
 
 type SpawnTool struct {
+	toolChat
 	parameters json.RawMessage
 	manager    *SubagentManager
-	channel    string
-	chatID     string
+	bus        *bus.MessageBus
 }
 
 func NewSpawnTool(manager *SubagentManager) *SpawnTool {
@@ -33,6 +36,14 @@ func NewSpawnTool(manager *SubagentManager) *SpawnTool {
 							"type":        "string",
 							"description": "Unique label to identify this task result",
 						},
+						"max_iterations": map[string]interface{}{
+							"type":        "integer",
+							"description": "Optional cap on tool-use iterations for this subagent",
+						},
+						"token_budget": map[string]interface{}{
+							"type":        "integer",
+							"description": "Optional cap on tokens this subagent may consume",
+						},
 					},
 					"required": []string{"task"},
 				},
@@ -58,14 +69,15 @@ Each subagent runs concurrently and results are returned after all complete.
 }
 func (t *SpawnTool) Parameters() json.RawMessage { return t.parameters }
 
-func (t *SpawnTool) SetContext(channel, chatID string) {
-	t.channel = channel
-	t.chatID = chatID
+func (t *SpawnTool) SetBus(b *bus.MessageBus) {
+	t.bus = b
 }
 
 type spawnTask struct {
-	Task  string `json:"task"`
-	Label string `json:"label"`
+	Task          string `json:"task"`
+	Label         string `json:"label"`
+	MaxIterations int    `json:"max_iterations"`
+	TokenBudget   int    `json:"token_budget"`
 }
 
 type spawnArgs struct {
@@ -98,9 +110,15 @@ func (t *SpawnTool) Execute(ctx context.Context, args json.RawMessage) (Result,
 	results := make([]SubagentResult, len(a.Tasks))
 	var wg sync.WaitGroup
 
-	subCtx, cancel := context.WithCancel(ctx)
+	subCtx, errMsg := t.manager.enter(ctx)
+	if errMsg != "" {
+		return ErrorResult(errMsg), nil
+	}
+	subCtx, cancel := context.WithCancel(subCtx)
 	defer cancel()
 
+	progress := t.progressFunc(ctx)
+
 	for i, task := range a.Tasks {
 		wg.Add(1)
 		label := task.Label
@@ -108,11 +126,16 @@ func (t *SpawnTool) Execute(ctx context.Context, args json.RawMessage) (Result,
 			label = fmt.Sprintf("task-%d", i+1)
 		}
 
-		go func(index int, taskStr, labelStr string) {
+		go func(index int, st SubagentTask) {
 			defer wg.Done()
-			result := t.manager.RunSync(subCtx, taskStr, labelStr)
+			result := t.manager.Run(subCtx, st, progress)
 			results[index] = result
-		}(i, task.Task, label)
+		}(i, SubagentTask{
+			Task:          task.Task,
+			Label:         label,
+			MaxIterations: task.MaxIterations,
+			TokenBudget:   task.TokenBudget,
+		})
 	}
 
 	wg.Wait()
@@ -128,9 +151,35 @@ func (t *SpawnTool) Execute(ctx context.Context, args json.RawMessage) (Result,
 			if len(preview) > 300 {
 				preview = preview[:300] + "..."
 			}
-			summary.WriteString(fmt.Sprintf("✅ %s (iterations: %d):\n%s\n\n", r.Label, r.Iteration, preview))
+			ref := ""
+			if t.manager.artifacts != nil && len(r.Content) > 300 {
+				channel, chatID := t.chat(ctx)
+				if id, err := t.manager.artifacts.Save(channel+":"+chatID, r.Content); err == nil {
+					ref = fmt.Sprintf(" [artifact: %s, %d chars, use get_artifact for the full result]", id, utf8.RuneCountInString(r.Content))
+				}
+			}
+			summary.WriteString(fmt.Sprintf("✅ %s (iterations: %d, tokens: %d)%s:\n%s\n\n", r.Label, r.Iteration, r.Tokens, ref, preview))
 		}
 	}
 
 	return OkResult(summary.String()), nil
 }
+
+// progressFunc forwards subagent progress to the current chat's stream so
+// channels can show live status for each parallel task.
+func (t *SpawnTool) progressFunc(ctx context.Context) ProgressFunc {
+	channel, chatID := t.chat(ctx)
+	if t.bus == nil || channel == "" || chatID == "" {
+		return nil
+	}
+	return func(p SubagentProgress) {
+		t.bus.PublishStream(bus.StreamMessage{
+			Channel:   channel,
+			ChatID:    chatID,
+			Type:      bus.StreamEventSubagent,
+			Label:     p.Label,
+			Status:    p.Status,
+			Iteration: p.Iteration,
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package tool
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+const defaultMaxSpawnDepth = 1
+
+// SpawnBudget tracks tokens consumed by a whole tree of subagents, shared by
+// every subagent descended from the same top-level spawn call.
+type SpawnBudget struct {
+	limit int64
+	used  atomic.Int64
+}
+
+func NewSpawnBudget(limit int) *SpawnBudget {
+	return &SpawnBudget{limit: int64(limit)}
+}
+
+// Add records tokens and reports whether the budget still has room.
+func (b *SpawnBudget) Add(tokens int) bool {
+	used := b.used.Add(int64(tokens))
+	return b.limit <= 0 || used < b.limit
+}
+
+func (b *SpawnBudget) Exhausted() bool {
+	return b.limit > 0 && b.used.Load() >= b.limit
+}
+
+func (b *SpawnBudget) Used() int  { return int(b.used.Load()) }
+func (b *SpawnBudget) Limit() int { return int(b.limit) }
+
+type spawnStateKey struct{}
+
+type spawnState struct {
+	depth  int
+	budget *SpawnBudget
+}
+
+// SpawnDepth returns how many spawn levels deep ctx is; the main agent is 0.
+func SpawnDepth(ctx context.Context) int {
+	if st, ok := ctx.Value(spawnStateKey{}).(spawnState); ok {
+		return st.depth
+	}
+	return 0
+}
+
+// SpawnBudgetFrom returns the budget shared by the current subagent tree.
+func SpawnBudgetFrom(ctx context.Context) *SpawnBudget {
+	if st, ok := ctx.Value(spawnStateKey{}).(spawnState); ok {
+		return st.budget
+	}
+	return nil
+}
+
+func withSpawnState(ctx context.Context, depth int, budget *SpawnBudget) context.Context {
+	return context.WithValue(ctx, spawnStateKey{}, spawnState{depth: depth, budget: budget})
+}
+
+type turnBudgetKey struct{}
+
+// WithTurnBudget records the token budget of the user turn ctx belongs to.
+// The agent and every subagent spawned during the turn draw from it.
+func WithTurnBudget(ctx context.Context, budget *SpawnBudget) context.Context {
+	return context.WithValue(ctx, turnBudgetKey{}, budget)
+}
+
+// TurnBudgetFrom returns the budget of the current turn, or nil when the
+// turn has none.
+func TurnBudgetFrom(ctx context.Context) *SpawnBudget {
+	b, _ := ctx.Value(turnBudgetKey{}).(*SpawnBudget)
+	return b
+}
@@ -0,0 +1,325 @@
+package tool
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	sqlDefaultRows = 50
+	sqlMaxRows     = 500
+	sqlCellChars   = 200
+	sqlTimeout     = 30 * time.Second
+)
+
+// SQLDatabase is a database the sql_query tool may query. Driver defaults
+// to sqlite, whose DSN is the database file; other drivers work when the
+// binary registers them with database/sql.
+type SQLDatabase struct {
+	Name        string `json:"name"`
+	Driver      string `json:"driver"`
+	DSN         string `json:"dsn"`
+	Description string `json:"description"`
+	// AllowWrite lets the tool change data after the call is approved.
+	AllowWrite bool `json:"allow_write"`
+}
+
+// SQLQueryTool runs queries against the configured databases. Queries that
+// only read run without approval; anything else needs AllowWrite and an
+// approval.
+type SQLQueryTool struct {
+	parameters json.RawMessage
+	databases  map[string]SQLDatabase
+
+	mu    sync.Mutex
+	conns map[string]*sql.DB
+}
+
+func NewSQLQueryTool(databases []SQLDatabase) *SQLQueryTool {
+	t := &SQLQueryTool{
+		databases: make(map[string]SQLDatabase),
+		conns:     make(map[string]*sql.DB),
+	}
+	for _, db := range databases {
+		if db.Driver == "" {
+			db.Driver = "sqlite"
+		}
+		t.databases[db.Name] = db
+	}
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"database": map[string]interface{}{
+				"type":        "string",
+				"enum":        t.names(),
+				"description": "Name of the database to query",
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "A single SQL statement",
+			},
+			"max_rows": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum rows to return (default: %d, at most %d)", sqlDefaultRows, sqlMaxRows),
+			},
+		},
+		"required": []string{"database", "query"},
+	}
+	t.parameters, _ = json.Marshal(params)
+	return t
+}
+
+func (t *SQLQueryTool) names() []string {
+	names := make([]string, 0, len(t.databases))
+	for name := range t.databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t *SQLQueryTool) Name() string { return "sql_query" }
+func (t *SQLQueryTool) Description() string {
+	var sb strings.Builder
+	sb.WriteString("Run a SQL query against a configured database and return the rows as a table. Read-only queries (SELECT, WITH, EXPLAIN) run directly; changes need approval and are only allowed on writable databases. Databases:")
+	for _, name := range t.names() {
+		db := t.databases[name]
+		fmt.Fprintf(&sb, "\n- %s (%s", name, db.Driver)
+		if db.AllowWrite {
+			sb.WriteString(", writable")
+		}
+		sb.WriteString(")")
+		if db.Description != "" {
+			sb.WriteString(": " + db.Description)
+		}
+	}
+	return sb.String()
+}
+func (t *SQLQueryTool) Parameters() json.RawMessage { return t.parameters }
+
+type sqlQueryArgs struct {
+	Database string `json:"database"`
+	Query    string `json:"query"`
+	MaxRows  int    `json:"max_rows"`
+}
+
+// readOnlySQL reports whether query is a single statement that only reads.
+// Anything it cannot tell is treated as a write.
+func readOnlySQL(query string) bool {
+	q := strings.TrimSpace(query)
+	q = strings.TrimSpace(strings.TrimSuffix(q, ";"))
+	if q == "" || strings.Contains(q, ";") {
+		return false
+	}
+	lower := strings.ToLower(q)
+	switch strings.Fields(lower)[0] {
+	case "select", "with", "explain", "values", "show", "describe":
+	default:
+		return false
+	}
+	for _, kw := range []string{"insert ", "update ", "delete ", "drop ", "alter ", "create ", "replace ", "attach ", "pragma "} {
+		if strings.Contains(lower, kw) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *SQLQueryTool) Mutates(args json.RawMessage) bool {
+	var a sqlQueryArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return true
+	}
+	return !readOnlySQL(a.Query)
+}
+
+func (t *SQLQueryTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	var a sqlQueryArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return nil, err
+	}
+	if readOnlySQL(a.Query) {
+		return nil, nil
+	}
+	return NewApproval("Agent wants to change data in "+a.Database, a.Query).
+		WithRule(a.Database, a.Database), nil
+}
+
+// conn opens a database on first use. SQLite databases that are not
+// writable are opened read-only, whatever mode their DSN asks for.
+func (t *SQLQueryTool) conn(db SQLDatabase) (*sql.DB, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if c, ok := t.conns[db.Name]; ok {
+		return c, nil
+	}
+	dsn := db.DSN
+	if db.Driver == "sqlite" && !db.AllowWrite {
+		dsn = readOnlyDSN(dsn)
+	}
+	c, err := sql.Open(db.Driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", db.Name, err)
+	}
+	t.conns[db.Name] = c
+	return c, nil
+}
+
+// readOnlyDSN turns a SQLite DSN into a file: URI with mode=ro, keeping
+// its other parameters.
+func readOnlyDSN(dsn string) string {
+	if !strings.HasPrefix(dsn, "file:") {
+		dsn = "file:" + dsn
+	}
+	path, query, _ := strings.Cut(dsn, "?")
+	var params []string
+	for _, p := range strings.Split(query, "&") {
+		if p != "" && !strings.HasPrefix(p, "mode=") {
+			params = append(params, p)
+		}
+	}
+	return path + "?" + strings.Join(append(params, "mode=ro"), "&")
+}
+
+func (t *SQLQueryTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a sqlQueryArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	db, ok := t.databases[a.Database]
+	if !ok {
+		return ErrorResult(fmt.Sprintf("unknown database %q; use one of %s", a.Database, strings.Join(t.names(), ", "))), nil
+	}
+	readOnly := readOnlySQL(a.Query)
+	if !readOnly && !db.AllowWrite {
+		return ErrorResult(fmt.Sprintf("database %s is read-only; only single SELECT, WITH, or EXPLAIN statements are allowed", db.Name)), nil
+	}
+	if a.MaxRows <= 0 {
+		a.MaxRows = sqlDefaultRows
+	}
+	if a.MaxRows > sqlMaxRows {
+		a.MaxRows = sqlMaxRows
+	}
+
+	c, err := t.conn(db)
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, sqlTimeout)
+	defer cancel()
+
+	if !readOnly {
+		res, err := c.ExecContext(ctx, a.Query)
+		if err != nil {
+			return ErrorResult(err.Error()), nil
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return OkResult("Statement executed."), nil
+		}
+		return OkResult(fmt.Sprintf("Statement executed; %d rows affected.", n)), nil
+	}
+
+	// The transaction is never committed, so even a misjudged statement
+	// leaves the data as it was.
+	tx, err := c.BeginTx(ctx, &sql.TxOptions{ReadOnly: db.Driver != "sqlite"})
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, a.Query)
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+	defer rows.Close()
+	out, err := formatSQLRows(rows, a.MaxRows)
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+	return OkResult(out), nil
+}
+
+// formatSQLRows renders up to max rows as a Markdown table.
+func formatSQLRows(rows *sql.Rows, max int) (string, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("| " + strings.Join(cols, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat("---|", len(cols)) + "\n")
+
+	values := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	n := 0
+	more := false
+	for rows.Next() {
+		if n == max {
+			more = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return "", err
+		}
+		cells := make([]string, len(cols))
+		for i, v := range values {
+			cells[i] = formatSQLValue(v)
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if more {
+		fmt.Fprintf(&sb, "\n%d rows shown; there are more. Narrow the query or raise max_rows.", n)
+	} else {
+		fmt.Fprintf(&sb, "\n%d rows.", n)
+	}
+	return sb.String(), nil
+}
+
+func formatSQLValue(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if !utf8.Valid(v) {
+			return fmt.Sprintf("<%d bytes>", len(v))
+		}
+		s = string(v)
+	case time.Time:
+		s = v.Format(time.RFC3339)
+	default:
+		s = fmt.Sprint(v)
+	}
+	s = strings.NewReplacer("\n", " ", "|", "\\|").Replace(s)
+	if len([]rune(s)) > sqlCellChars {
+		s = string([]rune(s)[:sqlCellChars]) + "…"
+	}
+	return s
+}
+
+// Close closes the databases that were opened.
+func (t *SQLQueryTool) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, c := range t.conns {
+		c.Close()
+		delete(t.conns, name)
+	}
+	return nil
+}
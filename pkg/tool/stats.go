@@ -0,0 +1,157 @@
+package tool
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ToolStats summarizes the calls of one tool.
+type ToolStats struct {
+	Tool      string  `json:"tool"`
+	Calls     int     `json:"calls"`
+	Failures  int     `json:"failures"`
+	Success   float64 `json:"success_rate"`
+	P50Millis int64   `json:"p50_ms"`
+	P90Millis int64   `json:"p90_ms"`
+	P99Millis int64   `json:"p99_ms"`
+}
+
+// StatsStore records every tool call, its outcome, and how long it ran in
+// toolstats.db.
+type StatsStore struct {
+	db *sql.DB
+}
+
+func OpenStatsStore(dataDir string) (*StatsStore, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("create data directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(dataDir, "toolstats.db"))
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS tool_calls (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		tool        TEXT NOT NULL,
+		ok          INTEGER NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		created_at  DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS tool_calls_created_at ON tool_calls (created_at)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init schema: %w", err)
+	}
+	return &StatsStore{db: db}, nil
+}
+
+func (s *StatsStore) Close() error {
+	return s.db.Close()
+}
+
+// Record adds a call of tool that took d.
+func (s *StatsStore) Record(ctx context.Context, tool string, ok bool, d time.Duration) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO tool_calls (tool, ok, duration_ms, created_at) VALUES (?, ?, ?, ?)`,
+		tool, ok, d.Milliseconds(), time.Now().UTC())
+	return err
+}
+
+// Stats summarizes the calls made since the given time, or all of them when
+// since is zero, most called tools first.
+func (s *StatsStore) Stats(ctx context.Context, since time.Time) ([]ToolStats, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT tool, ok, duration_ms FROM tool_calls WHERE created_at >= ? ORDER BY tool, duration_ms`,
+		since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []ToolStats
+	var durations []int64
+	flush := func() {
+		if len(stats) == 0 {
+			return
+		}
+		st := &stats[len(stats)-1]
+		st.Success = float64(st.Calls-st.Failures) / float64(st.Calls)
+		st.P50Millis = percentile(durations, 50)
+		st.P90Millis = percentile(durations, 90)
+		st.P99Millis = percentile(durations, 99)
+		durations = durations[:0]
+	}
+	for rows.Next() {
+		var name string
+		var ok bool
+		var ms int64
+		if err := rows.Scan(&name, &ok, &ms); err != nil {
+			return nil, err
+		}
+		if len(stats) == 0 || stats[len(stats)-1].Tool != name {
+			flush()
+			stats = append(stats, ToolStats{Tool: name})
+		}
+		st := &stats[len(stats)-1]
+		st.Calls++
+		if !ok {
+			st.Failures++
+		}
+		durations = append(durations, ms)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].Calls > stats[j].Calls })
+	return stats, nil
+}
+
+// Prune deletes the calls made before the given time.
+func (s *StatsStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM tool_calls WHERE created_at < ?`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations.
+func percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// SetStats records every call that runs, here and in the views derived
+// from this manager. Calls refused before running, such as those rejected
+// by the user, are not recorded.
+func (m *Manager) SetStats(s *StatsStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = s
+}
+
+func (m *Manager) currentStats() *StatsStore {
+	m.mu.RLock()
+	s := m.stats
+	m.mu.RUnlock()
+	if s == nil && m.parent != nil {
+		return m.parent.currentStats()
+	}
+	return s
+}
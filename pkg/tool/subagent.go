@@ -10,23 +10,110 @@ import (
 	"github.com/nene-agent/nene/pkg/model"
 )
 
+const defaultSubagentIterations = 10
+
 type SubagentManager struct {
 	provider      model.Provider
 	modelName     string
 	toolMgr       *Manager
 	systemPrompt  string
 	maxIterations int
+	tokenBudget   int
+	maxDepth      int
+	totalBudget   int
+	artifacts     *ArtifactStore
 	mu            sync.RWMutex
 }
 
-func NewSubagentManager(provider model.Provider, modelName, systemPrompt string, toolMgr *Manager) *SubagentManager {
-	return &SubagentManager{
+type SubagentOption func(*SubagentManager)
+
+// WithSubagentModel runs subagents on a different (usually cheaper) model.
+func WithSubagentModel(provider model.Provider, modelName string) SubagentOption {
+	return func(sm *SubagentManager) {
+		if provider != nil {
+			sm.provider = provider
+		}
+		if modelName != "" {
+			sm.modelName = modelName
+		}
+	}
+}
+
+// WithSubagentTools restricts subagents to the named tools.
+func WithSubagentTools(names ...string) SubagentOption {
+	return func(sm *SubagentManager) {
+		if len(names) > 0 {
+			sm.toolMgr = sm.toolMgr.Subset(names...)
+		}
+	}
+}
+
+// WithoutSubagentTools hides the named tools from subagents.
+func WithoutSubagentTools(names ...string) SubagentOption {
+	return func(sm *SubagentManager) {
+		if len(names) > 0 {
+			sm.toolMgr = sm.toolMgr.Without(names...)
+		}
+	}
+}
+
+func WithSubagentMaxIterations(n int) SubagentOption {
+	return func(sm *SubagentManager) {
+		if n > 0 {
+			sm.maxIterations = n
+		}
+	}
+}
+
+// WithSubagentTokenBudget caps the tokens a single subagent may consume.
+// Zero means unlimited.
+func WithSubagentTokenBudget(n int) SubagentOption {
+	return func(sm *SubagentManager) {
+		if n > 0 {
+			sm.tokenBudget = n
+		}
+	}
+}
+
+// WithMaxSpawnDepth limits how deeply subagents may spawn further
+// subagents. The default of 1 lets only the main agent spawn.
+func WithMaxSpawnDepth(n int) SubagentOption {
+	return func(sm *SubagentManager) {
+		if n > 0 {
+			sm.maxDepth = n
+		}
+	}
+}
+
+// WithTotalTokenBudget caps the tokens consumed by all subagents descended
+// from a single top-level spawn call. Zero means unlimited.
+func WithTotalTokenBudget(n int) SubagentOption {
+	return func(sm *SubagentManager) {
+		if n > 0 {
+			sm.totalBudget = n
+		}
+	}
+}
+
+// WithArtifactStore saves full subagent results as artifacts so spawn can
+// return references instead of truncated output.
+func WithArtifactStore(store *ArtifactStore) SubagentOption {
+	return func(sm *SubagentManager) { sm.artifacts = store }
+}
+
+func NewSubagentManager(provider model.Provider, modelName, systemPrompt string, toolMgr *Manager, opts ...SubagentOption) *SubagentManager {
+	sm := &SubagentManager{
 		provider:      provider,
 		modelName:     modelName,
 		toolMgr:       toolMgr,
 		systemPrompt:  systemPrompt,
-		maxIterations: 10,
+		maxIterations: defaultSubagentIterations,
+		maxDepth:      defaultMaxSpawnDepth,
+	}
+	for _, opt := range opts {
+		opt(sm)
 	}
+	return sm
 }
 
 type SubagentResult struct {
@@ -34,9 +121,80 @@ type SubagentResult struct {
 	Content   string
 	IsError   bool
 	Iteration int
+	Tokens    int
+}
+
+const (
+	SubagentStarted  = "started"
+	SubagentRunning  = "running"
+	SubagentFinished = "finished"
+	SubagentFailed   = "failed"
+)
+
+type SubagentProgress struct {
+	Label     string
+	Status    string
+	Iteration int
+}
+
+type ProgressFunc func(SubagentProgress)
+
+// SubagentTask describes one subagent run. Zero limits use the manager's
+// defaults; non-zero limits can only tighten them.
+type SubagentTask struct {
+	Task          string
+	Label         string
+	MaxIterations int
+	TokenBudget   int
+}
+
+func (sm *SubagentManager) MaxDepth() int { return sm.maxDepth }
+
+// enter returns the context for subagents spawned from ctx, or an error
+// message when the depth or shared token budget does not allow it.
+func (sm *SubagentManager) enter(ctx context.Context) (context.Context, string) {
+	depth := SpawnDepth(ctx)
+	if depth >= sm.maxDepth {
+		return nil, fmt.Sprintf("spawn depth limit reached (%d of %d): subagents at this level cannot spawn further subagents, so complete the task directly", depth, sm.maxDepth)
+	}
+
+	budget := SpawnBudgetFrom(ctx)
+	if budget == nil {
+		budget = NewSpawnBudget(sm.totalBudget)
+	}
+	if budget.Exhausted() {
+		return nil, fmt.Sprintf("subagent token budget exhausted (%d/%d tokens used): summarize what you have instead of spawning more subagents", budget.Used(), budget.Limit())
+	}
+	if turn := TurnBudgetFrom(ctx); turn != nil && turn.Exhausted() {
+		return nil, fmt.Sprintf("the turn's token budget is exhausted (%d/%d tokens used): summarize what you have instead of spawning subagents", turn.Used(), turn.Limit())
+	}
+
+	return withSpawnState(ctx, depth+1, budget), ""
 }
 
 func (sm *SubagentManager) RunSync(ctx context.Context, task, label string) SubagentResult {
+	return sm.Run(ctx, SubagentTask{Task: task, Label: label}, nil)
+}
+
+// Run executes a subagent like RunSync and reports each iteration to progress
+// when it is non-nil.
+func (sm *SubagentManager) Run(ctx context.Context, t SubagentTask, progress ProgressFunc) SubagentResult {
+	label := t.Label
+	report := func(status string, iteration int) {
+		if progress != nil {
+			progress(SubagentProgress{Label: label, Status: status, Iteration: iteration})
+		}
+	}
+
+	maxIterations := sm.maxIterations
+	if t.MaxIterations > 0 && t.MaxIterations < maxIterations {
+		maxIterations = t.MaxIterations
+	}
+	tokenBudget := sm.tokenBudget
+	if t.TokenBudget > 0 && (tokenBudget == 0 || t.TokenBudget < tokenBudget) {
+		tokenBudget = t.TokenBudget
+	}
+
 	systemPrompt := `You are a subagent tasked with completing a specific task.
 Complete the task independently and report a clear, concise result.
 You have access to tools - use them as needed.
@@ -44,14 +202,18 @@ After completing the task, provide a summary of what was done.`
 
 	messages := []model.Message{
 		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: task},
+		{Role: "user", Content: t.Task},
 	}
 
 	iteration := 0
+	tokens := 0
 	var finalContent strings.Builder
 
-	for iteration < sm.maxIterations {
+	report(SubagentStarted, 0)
+
+	for iteration < maxIterations {
 		iteration++
+		report(SubagentRunning, iteration)
 
 		sm.mu.RLock()
 		tools := sm.toolMgr.Definitions()
@@ -65,16 +227,20 @@ After completing the task, provide a summary of what was done.`
 
 		stream, err := sm.provider.SendStream(ctx, req)
 		if err != nil {
+			report(SubagentFailed, iteration)
 			return SubagentResult{
-				Label:   label,
-				Content: fmt.Sprintf("Error: %v", err),
-				IsError: true,
+				Label:     label,
+				Content:   fmt.Sprintf("Error: %v", err),
+				IsError:   true,
+				Iteration: iteration,
+				Tokens:    tokens,
 			}
 		}
 
 		var assistantMsg strings.Builder
 		var toolCalls []model.ToolCall
 		var finishReason model.FinishReason
+		var usage *model.Usage
 
 		for event := range stream {
 			if event.Delta != "" {
@@ -86,8 +252,23 @@ After completing the task, provide a summary of what was done.`
 			if event.FinishReason != "" {
 				finishReason = event.FinishReason
 			}
+			if event.Usage != nil {
+				usage = event.Usage
+			}
 		}
 
+		used := 0
+		if usage != nil {
+			used = usage.TotalTokens
+		} else {
+			used = model.EstimateTokens(messages) + len(assistantMsg.String())/4
+		}
+		tokens += used
+		shared := SpawnBudgetFrom(ctx)
+		sharedOK := shared == nil || shared.Add(used)
+		turn := TurnBudgetFrom(ctx)
+		turnOK := turn == nil || turn.Add(used)
+
 		messages = append(messages, model.Message{
 			Role:      "assistant",
 			Content:   assistantMsg.String(),
@@ -99,6 +280,39 @@ After completing the task, provide a summary of what was done.`
 			break
 		}
 
+		if !sharedOK {
+			report(SubagentFailed, iteration)
+			return SubagentResult{
+				Label:     label,
+				Content:   fmt.Sprintf("Shared subagent token budget exhausted (%d/%d tokens). Partial result:\n%s", shared.Used(), shared.Limit(), assistantMsg.String()),
+				IsError:   true,
+				Iteration: iteration,
+				Tokens:    tokens,
+			}
+		}
+
+		if !turnOK {
+			report(SubagentFailed, iteration)
+			return SubagentResult{
+				Label:     label,
+				Content:   fmt.Sprintf("The turn's token budget is exhausted (%d/%d tokens). Partial result:\n%s", turn.Used(), turn.Limit(), assistantMsg.String()),
+				IsError:   true,
+				Iteration: iteration,
+				Tokens:    tokens,
+			}
+		}
+
+		if tokenBudget > 0 && tokens >= tokenBudget {
+			report(SubagentFailed, iteration)
+			return SubagentResult{
+				Label:     label,
+				Content:   fmt.Sprintf("Token budget exhausted (%d/%d tokens). Partial result:\n%s", tokens, tokenBudget, assistantMsg.String()),
+				IsError:   true,
+				Iteration: iteration,
+				Tokens:    tokens,
+			}
+		}
+
 		for _, tc := range toolCalls {
 			var argsJSON json.RawMessage
 			if tc.Function.Arguments != "" {
@@ -123,10 +337,12 @@ After completing the task, provide a summary of what was done.`
 		}
 	}
 
+	report(SubagentFinished, iteration)
 	return SubagentResult{
 		Label:     label,
 		Content:   finalContent.String(),
 		IsError:   false,
 		Iteration: iteration,
+		Tokens:    tokens,
 	}
 }
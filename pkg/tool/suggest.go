@@ -0,0 +1,119 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nene-agent/nene/pkg/bus"
+)
+
+const SuggestToolName = "suggest_replies"
+
+// DefaultMaxSuggestions is how many replies suggest_replies offers at most
+// unless set otherwise.
+const DefaultMaxSuggestions = 3
+
+// maxSuggestionLen keeps suggestions short enough for a button.
+const maxSuggestionLen = 64
+
+// SuggestRepliesTool lets the model offer the user a few follow-up replies.
+// They are published as a suggestions stream event; channels that can show
+// buttons put them under the answer, and choosing one sends its text as the
+// user's next message.
+type SuggestRepliesTool struct {
+	parameters json.RawMessage
+	bus        *bus.MessageBus
+	limit      int
+
+	mu      sync.Mutex
+	channel string
+	chatID  string
+}
+
+func NewSuggestRepliesTool(limit int) *SuggestRepliesTool {
+	if limit <= 0 {
+		limit = DefaultMaxSuggestions
+	}
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"replies": map[string]interface{}{
+				"type":        "array",
+				"description": fmt.Sprintf("Up to %d short replies the user is likely to send next, written as the user would say them", limit),
+				"items":       map[string]interface{}{"type": "string"},
+				"maxItems":    limit,
+			},
+		},
+		"required": []string{"replies"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &SuggestRepliesTool{parameters: paramsJSON, limit: limit}
+}
+
+func (t *SuggestRepliesTool) SetBus(b *bus.MessageBus) {
+	t.bus = b
+}
+
+func (t *SuggestRepliesTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *SuggestRepliesTool) Name() string { return SuggestToolName }
+func (t *SuggestRepliesTool) Description() string {
+	return "Offer the user a few one-tap follow-up replies, shown as buttons under your answer. " +
+		"Use it when there are obvious next questions or choices; call it once, right before you answer."
+}
+func (t *SuggestRepliesTool) Parameters() json.RawMessage { return t.parameters }
+
+type suggestArgs struct {
+	Replies []string `json:"replies"`
+}
+
+func (t *SuggestRepliesTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *SuggestRepliesTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a suggestArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+
+	var replies []string
+	for _, r := range a.Replies {
+		r = strings.Join(strings.Fields(r), " ")
+		if r == "" {
+			continue
+		}
+		if runes := []rune(r); len(runes) > maxSuggestionLen {
+			return ErrorResult(fmt.Sprintf("replies must be at most %d characters: %q", maxSuggestionLen, r)), nil
+		}
+		replies = append(replies, r)
+	}
+	if len(replies) == 0 {
+		return ErrorResult("replies is required"), nil
+	}
+	if len(replies) > t.limit {
+		replies = replies[:t.limit]
+	}
+
+	t.mu.Lock()
+	channel, chatID := callChat(ctx, t.channel, t.chatID)
+	t.mu.Unlock()
+	if t.bus == nil || chatID == "" {
+		return ErrorResult("suggest_replies is not configured with a chat"), nil
+	}
+	t.bus.PublishStream(bus.StreamMessage{
+		Channel:     channel,
+		ChatID:      chatID,
+		Type:        bus.StreamEventSuggestions,
+		Suggestions: replies,
+	})
+	return OkResult(fmt.Sprintf("The user will be offered %d replies under your answer. Now give the answer.", len(replies))), nil
+}
@@ -0,0 +1,285 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nene-agent/nene/pkg/tasks"
+)
+
+// taskChat holds the chat a task tool is working for; tasks belong to the
+// chat they were created in.
+type taskChat struct {
+	mu   sync.RWMutex
+	chat string
+}
+
+func (c *taskChat) SetContext(channel, chatID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chat = channel + ":" + chatID
+}
+
+func (c *taskChat) current(ctx context.Context) string {
+	if channel, chatID := chatFrom(ctx); channel != "" && chatID != "" {
+		return channel + ":" + chatID
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.chat
+}
+
+func statusNames() []string {
+	names := make([]string, len(tasks.Statuses))
+	for i, st := range tasks.Statuses {
+		names[i] = string(st)
+	}
+	return names
+}
+
+type CreateTaskTool struct {
+	taskChat
+	parameters json.RawMessage
+	store      *tasks.Store
+}
+
+func NewCreateTaskTool(s *tasks.Store) *CreateTaskTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "Short description of what needs doing",
+			},
+			"due": map[string]interface{}{
+				"type":        "string",
+				"description": "Due date as YYYY-MM-DD or YYYY-MM-DD HH:MM in the chat's timezone (optional)",
+			},
+			"notes": map[string]interface{}{
+				"type":        "string",
+				"description": "Details, links, or context (optional)",
+			},
+		},
+		"required": []string{"title"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &CreateTaskTool{parameters: paramsJSON, store: s}
+}
+
+func (t *CreateTaskTool) Name() string { return "create_task" }
+func (t *CreateTaskTool) Description() string {
+	return "Create a task (a TODO) for the current chat, with an optional due date and notes. Use it to track goals and follow-ups the user asks you to remember."
+}
+func (t *CreateTaskTool) Parameters() json.RawMessage { return t.parameters }
+
+type createTaskArgs struct {
+	Title string `json:"title"`
+	Due   string `json:"due"`
+	Notes string `json:"notes"`
+}
+
+func (t *CreateTaskTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *CreateTaskTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a createTaskArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	chat := t.current(ctx)
+	if chat == "" {
+		return ErrorResult("create_task needs a chat to keep the task for"), nil
+	}
+	loc := LocationFrom(ctx)
+	due, err := tasks.ParseDue(a.Due, loc)
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+
+	task, err := t.store.Create(ctx, tasks.Task{Chat: chat, Title: a.Title, Due: due, Notes: a.Notes})
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+	return OkResult("Created " + task.StringIn(loc)), nil
+}
+
+type UpdateTaskTool struct {
+	taskChat
+	parameters json.RawMessage
+	store      *tasks.Store
+}
+
+func NewUpdateTaskTool(s *tasks.Store) *UpdateTaskTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "integer",
+				"description": "Task ID from list_tasks",
+			},
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "New title (optional)",
+			},
+			"status": map[string]interface{}{
+				"type":        "string",
+				"enum":        statusNames(),
+				"description": "New status (optional)",
+			},
+			"due": map[string]interface{}{
+				"type":        "string",
+				"description": "New due date as YYYY-MM-DD or YYYY-MM-DD HH:MM, or \"none\" to clear it (optional)",
+			},
+			"notes": map[string]interface{}{
+				"type":        "string",
+				"description": "New notes, replacing the old ones (optional)",
+			},
+		},
+		"required": []string{"id"},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &UpdateTaskTool{parameters: paramsJSON, store: s}
+}
+
+func (t *UpdateTaskTool) Name() string { return "update_task" }
+func (t *UpdateTaskTool) Description() string {
+	return "Update a task of the current chat: change its title, status, due date, or notes. Mark tasks done when they are finished."
+}
+func (t *UpdateTaskTool) Parameters() json.RawMessage { return t.parameters }
+
+type updateTaskArgs struct {
+	ID     int64   `json:"id"`
+	Title  *string `json:"title"`
+	Status *string `json:"status"`
+	Due    *string `json:"due"`
+	Notes  *string `json:"notes"`
+}
+
+func (t *UpdateTaskTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *UpdateTaskTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a updateTaskArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	chat := t.current(ctx)
+	if chat == "" {
+		return ErrorResult("update_task needs a chat whose tasks to update"), nil
+	}
+
+	loc := LocationFrom(ctx)
+	u := tasks.Update{Title: a.Title, Notes: a.Notes}
+	if a.Status != nil {
+		st, ok := tasks.ParseStatus(*a.Status)
+		if !ok {
+			return ErrorResult(fmt.Sprintf("invalid status %q; use one of %s", *a.Status, strings.Join(statusNames(), ", "))), nil
+		}
+		u.Status = &st
+	}
+	if a.Due != nil {
+		s := *a.Due
+		if s == "none" {
+			s = ""
+		}
+		due, err := tasks.ParseDue(s, loc)
+		if err != nil {
+			return ErrorResult(err.Error()), nil
+		}
+		u.Due = &due
+	}
+
+	task, err := t.store.Update(ctx, chat, a.ID, u)
+	if errors.Is(err, tasks.ErrNotFound) {
+		return ErrorResult(fmt.Sprintf("no task #%d in this chat", a.ID)), nil
+	}
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+	return OkResult("Updated " + task.StringIn(loc)), nil
+}
+
+type ListTasksTool struct {
+	taskChat
+	parameters json.RawMessage
+	store      *tasks.Store
+}
+
+func NewListTasksTool(s *tasks.Store) *ListTasksTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"status": map[string]interface{}{
+				"type":        "string",
+				"enum":        append([]string{"open", "all"}, statusNames()...),
+				"description": "Which tasks to list (default: open, meaning todo and in_progress)",
+			},
+		},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &ListTasksTool{parameters: paramsJSON, store: s}
+}
+
+func (t *ListTasksTool) Name() string { return "list_tasks" }
+func (t *ListTasksTool) Description() string {
+	return "List the tasks of the current chat with their IDs, status, due dates, and notes."
+}
+func (t *ListTasksTool) Parameters() json.RawMessage { return t.parameters }
+
+type listTasksArgs struct {
+	Status string `json:"status"`
+}
+
+func (t *ListTasksTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *ListTasksTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a listTasksArgs
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &a); err != nil {
+			return ErrorResult("invalid arguments: " + err.Error()), nil
+		}
+	}
+	chat := t.current(ctx)
+	if chat == "" {
+		return ErrorResult("list_tasks needs a chat whose tasks to list"), nil
+	}
+
+	var statuses []tasks.Status
+	switch a.Status {
+	case "", "open":
+		statuses = []tasks.Status{tasks.StatusTodo, tasks.StatusInProgress}
+	case "all":
+	default:
+		st, ok := tasks.ParseStatus(a.Status)
+		if !ok {
+			return ErrorResult(fmt.Sprintf("invalid status %q", a.Status)), nil
+		}
+		statuses = []tasks.Status{st}
+	}
+
+	list, err := t.store.List(ctx, chat, statuses...)
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+	if len(list) == 0 {
+		return OkResult("No matching tasks in this chat."), nil
+	}
+	loc := LocationFrom(ctx)
+	var lines []string
+	for _, task := range list {
+		line := "- " + task.StringIn(loc)
+		if task.Notes != "" {
+			line += "\n  " + strings.ReplaceAll(task.Notes, "\n", "\n  ")
+		}
+		lines = append(lines, line)
+	}
+	return OkResult(strings.Join(lines, "\n")), nil
+}
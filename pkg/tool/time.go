@@ -0,0 +1,76 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type locationKey struct{}
+
+// WithLocation records the timezone of the chat a call is made for.
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	if loc == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// LocationFrom returns the chat's timezone recorded in ctx, or the server's.
+func LocationFrom(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(locationKey{}).(*time.Location); ok {
+		return loc
+	}
+	return time.Local
+}
+
+type CurrentTimeTool struct {
+	parameters json.RawMessage
+}
+
+func NewCurrentTimeTool() *CurrentTimeTool {
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"timezone": map[string]interface{}{
+				"type":        "string",
+				"description": "IANA timezone to show the time in, such as Europe/Berlin (optional; defaults to the chat's timezone)",
+			},
+		},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &CurrentTimeTool{parameters: paramsJSON}
+}
+
+func (t *CurrentTimeTool) Name() string { return "current_time" }
+func (t *CurrentTimeTool) Description() string {
+	return "Get the current date and time in the chat's timezone, or in another timezone. Use it before answering anything that depends on today's date or the time of day."
+}
+func (t *CurrentTimeTool) Parameters() json.RawMessage { return t.parameters }
+
+type currentTimeArgs struct {
+	Timezone string `json:"timezone"`
+}
+
+func (t *CurrentTimeTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *CurrentTimeTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a currentTimeArgs
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &a); err != nil {
+			return ErrorResult("invalid arguments: " + err.Error()), nil
+		}
+	}
+	loc := LocationFrom(ctx)
+	if a.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(a.Timezone); err != nil {
+			return ErrorResult(fmt.Sprintf("unknown timezone %q", a.Timezone)), nil
+		}
+	}
+	now := time.Now().In(loc)
+	return OkResult(fmt.Sprintf("%s (%s, UTC%s)", now.Format("Monday, 2006-01-02 15:04:05"), loc, now.Format("-07:00"))), nil
+}
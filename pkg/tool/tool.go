@@ -3,8 +3,16 @@ package tool
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/nene-agent/nene/pkg/model"
+	"github.com/nene-agent/nene/pkg/redact"
 )
 
 type Approval struct {
@@ -13,15 +21,33 @@ type Approval struct {
 	approved      bool
 	rejected      bool
 	reason        string
+
+	subject string
+	rule    string
 }
 
+// NewApproval describes a call that needs the user's approval. By default
+// the user may allow every later call of the tool in the chat.
 func NewApproval(justification, what string) *Approval {
 	return &Approval{
 		justification: justification,
 		what:          what,
+		rule:          "*",
 	}
 }
 
+// WithRule narrows what "always allow" covers: later calls are approved
+// when their subject matches rule (see MatchRule). An empty rule means the
+// decision cannot be remembered, and remembered rules do not apply.
+func (a *Approval) WithRule(subject, rule string) *Approval {
+	a.subject = subject
+	a.rule = rule
+	return a
+}
+
+// Rule is the pattern offered for "always allow", or "" if none is.
+func (a *Approval) Rule() string { return a.rule }
+
 func (a *Approval) Justification() string { return a.justification }
 func (a *Approval) What() string          { return a.what }
 func (a *Approval) IsApproved() bool      { return a.approved }
@@ -69,8 +95,41 @@ type ContextualTool interface {
 	SetContext(channel, chatID string)
 }
 
+// NamespaceSep separates a namespace from a tool name, as in
+// "mcp:github.search". Models see the name as "mcp__github_search"; see
+// WireName.
+const NamespaceSep = ":"
+
+// Info describes a registered tool for listings.
+type Info struct {
+	Name        string          `json:"name"`
+	Namespace   string          `json:"namespace,omitempty"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+	Contextual  bool            `json:"contextual"`
+	OwnerOnly   bool            `json:"owner_only"`
+}
+
+// Manager holds the tools offered to a model. It is safe for concurrent use,
+// so tools can be registered and unregistered while sessions run.
+//
+// A manager may be a view of a parent manager (see Child, Subset, and
+// Without): it sees the parent's tools as they change, and tools registered
+// on the view stay private to it.
 type Manager struct {
-	tools map[string]Tool
+	mu       sync.RWMutex
+	tools    map[string]Tool
+	policy   *Policy
+	approver Approver
+	dryRun   *DryRun
+	limit    *ResultLimit
+	timeout  time.Duration
+	redactor *redact.Redactor
+	readOnly bool
+	stats    *StatsStore
+
+	parent *Manager
+	filter func(name string) bool
 }
 
 func NewManager() *Manager {
@@ -79,20 +138,302 @@ func NewManager() *Manager {
 	}
 }
 
+// Register adds a tool under its own name, replacing any tool of that name.
 func (m *Manager) Register(tool Tool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.tools[tool.Name()] = tool
 }
 
+// RegisterNamespaced adds a tool as "namespace:name", so tools from different
+// sources, such as several MCP servers, cannot collide.
+func (m *Manager) RegisterNamespaced(namespace string, tool Tool) error {
+	if namespace == "" || strings.Contains(namespace, NamespaceSep) {
+		return fmt.Errorf("invalid tool namespace %q", namespace)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tools[namespace+NamespaceSep+tool.Name()] = tool
+	return nil
+}
+
+// Unregister removes a tool registered on this manager and reports whether
+// it was there. Tools seen through a parent are not affected.
+func (m *Manager) Unregister(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.tools[name]; !ok {
+		return false
+	}
+	delete(m.tools, name)
+	return true
+}
+
+// UnregisterNamespace removes every tool registered on this manager in a
+// namespace and returns how many there were.
+func (m *Manager) UnregisterNamespace(namespace string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for name := range m.tools {
+		if ns, _ := splitName(name); ns == namespace {
+			delete(m.tools, name)
+			n++
+		}
+	}
+	return n
+}
+
+// SetPolicy enforces p on every tool call made through this manager and the
+// views derived from it that have no policy of their own.
+func (m *Manager) SetPolicy(p *Policy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policy = p
+}
+
+// SetApprover makes calls that need approval wait for a's decision, here
+// and in the views derived from this manager. Without an approver such
+// calls run unasked.
+func (m *Manager) SetApprover(a Approver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.approver = a
+}
+
+func (m *Manager) currentApprover() Approver {
+	m.mu.RLock()
+	a := m.approver
+	m.mu.RUnlock()
+	if a == nil && m.parent != nil {
+		return m.parent.currentApprover()
+	}
+	return a
+}
+
+// SetDryRun makes calls in chats where d is on report what they would do
+// instead of doing it, here and in the views derived from this manager.
+func (m *Manager) SetDryRun(d *DryRun) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dryRun = d
+}
+
+func (m *Manager) currentDryRun() *DryRun {
+	m.mu.RLock()
+	d := m.dryRun
+	m.mu.RUnlock()
+	if d == nil && m.parent != nil {
+		return m.parent.currentDryRun()
+	}
+	return d
+}
+
+// SetResultLimit stores results longer than l allows as artifacts, here and
+// in the views derived from this manager, so they do not fill the context.
+func (m *Manager) SetResultLimit(l *ResultLimit) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.limit = l
+}
+
+func (m *Manager) currentResultLimit() *ResultLimit {
+	m.mu.RLock()
+	l := m.limit
+	m.mu.RUnlock()
+	if l == nil && m.parent != nil {
+		return m.parent.currentResultLimit()
+	}
+	return l
+}
+
+// SetRedactor masks secrets in tool results, here and in the views derived
+// from this manager, before the model, the chat, or the stored conversation
+// sees them.
+func (m *Manager) SetRedactor(r *redact.Redactor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.redactor = r
+}
+
+func (m *Manager) currentRedactor() *redact.Redactor {
+	m.mu.RLock()
+	r := m.redactor
+	m.mu.RUnlock()
+	if r == nil && m.parent != nil {
+		return m.parent.currentRedactor()
+	}
+	return r
+}
+
+// SetCallTimeout stops tool calls that run longer than d, here and in the
+// views derived from this manager; zero lets them run. Waiting for approval
+// does not count. Tools must honor their context to be stopped.
+func (m *Manager) SetCallTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timeout = d
+}
+
+func (m *Manager) currentCallTimeout() time.Duration {
+	m.mu.RLock()
+	d := m.timeout
+	m.mu.RUnlock()
+	if d == 0 && m.parent != nil {
+		return m.parent.currentCallTimeout()
+	}
+	return d
+}
+
+// TimeoutError is returned for a call stopped by the call timeout.
+type TimeoutError struct {
+	Tool  string
+	After time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("tool %s timed out after %s", e.Tool, e.After)
+}
+
+func (m *Manager) currentPolicy() *Policy {
+	m.mu.RLock()
+	p := m.policy
+	m.mu.RUnlock()
+	if p == nil && m.parent != nil {
+		return m.parent.currentPolicy()
+	}
+	return p
+}
+
+// Get looks a tool up by name or by the wire name models call it with.
 func (m *Manager) Get(name string) (Tool, bool) {
+	if t, ok := m.get(name); ok {
+		return t, true
+	}
+	if _, full, ok := m.resolve(name); ok {
+		return m.get(full)
+	}
+	return nil, false
+}
+
+func (m *Manager) get(name string) (Tool, bool) {
+	m.mu.RLock()
 	t, ok := m.tools[name]
-	return t, ok
+	m.mu.RUnlock()
+	if ok {
+		return t, true
+	}
+	if m.parent != nil && (m.filter == nil || m.filter(name)) {
+		return m.parent.get(name)
+	}
+	return nil, false
+}
+
+// resolve maps a wire name back to the tool and its full name.
+func (m *Manager) resolve(wire string) (Tool, string, bool) {
+	for name, t := range m.entries() {
+		if WireName(name) == wire {
+			return t, name, true
+		}
+	}
+	return nil, "", false
+}
+
+// entries returns every tool visible through m by full name.
+func (m *Manager) entries() map[string]Tool {
+	out := make(map[string]Tool)
+	if m.parent != nil {
+		for name, t := range m.parent.entries() {
+			if m.filter == nil || m.filter(name) {
+				out[name] = t
+			}
+		}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for name, t := range m.tools {
+		out[name] = t
+	}
+	return out
+}
+
+// Child returns a view of every tool in m, for example one session's tool
+// set: tools registered on the child are offered only through it.
+func (m *Manager) Child() *Manager {
+	return m.view(nil)
+}
+
+// Subset returns a view holding only the named tools. A name may be
+// "namespace:*" to take a whole namespace.
+func (m *Manager) Subset(names ...string) *Manager {
+	return m.view(func(name string) bool { return Matches(names, name) })
 }
 
+// Without returns a view holding every tool except the named ones. A name
+// may be "namespace:*" to hide a whole namespace.
+func (m *Manager) Without(names ...string) *Manager {
+	return m.view(func(name string) bool { return !Matches(names, name) })
+}
+
+// Filter returns a view holding the tools for which keep returns true. keep
+// is consulted on every lookup, so it may change its answer over time.
+func (m *Manager) Filter(keep func(name string) bool) *Manager {
+	return m.view(keep)
+}
+
+func (m *Manager) view(filter func(string) bool) *Manager {
+	sub := NewManager()
+	sub.parent = m
+	sub.filter = filter
+	return sub
+}
+
+func (m *Manager) Names() []string {
+	entries := m.entries()
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// List describes every tool, sorted by name.
+func (m *Manager) List() []Info {
+	entries := m.entries()
+	policy := m.currentPolicy()
+	infos := make([]Info, 0, len(entries))
+	for _, name := range m.Names() {
+		t, ok := entries[name]
+		if !ok {
+			continue
+		}
+		ns, _ := splitName(name)
+		_, contextual := t.(ContextualTool)
+		infos = append(infos, Info{
+			Name:        name,
+			Namespace:   ns,
+			Description: t.Description(),
+			Parameters:  t.Parameters(),
+			Contextual:  contextual,
+			OwnerOnly:   policy != nil && !policy.Allowed(RoleUser, name),
+		})
+	}
+	return infos
+}
+
+// Definitions returns the tools as offered to a model, sorted by name and
+// under their wire names.
 func (m *Manager) Definitions() []model.Tool {
-	defs := make([]model.Tool, 0, len(m.tools))
-	for _, t := range m.tools {
+	entries := m.entries()
+	defs := make([]model.Tool, 0, len(entries))
+	for _, name := range m.Names() {
+		t, ok := entries[name]
+		if !ok {
+			continue
+		}
 		defs = append(defs, model.NewFunctionTool(
-			t.Name(),
+			WireName(name),
 			t.Description(),
 			t.Parameters(),
 		))
@@ -100,17 +441,105 @@ func (m *Manager) Definitions() []model.Tool {
 	return defs
 }
 
-func (m *Manager) ExecuteWithContext(ctx context.Context, name string, args json.RawMessage, channel, chatID string) (Result, error) {
-	tool, ok := m.Get(name)
+func (m *Manager) ExecuteWithContext(ctx context.Context, name string, args json.RawMessage, channel, chatID string) (result Result, err error) {
+	// A crashing tool fails its call, not the process.
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Tool %s panicked: %v\n%s", name, r, debug.Stack())
+			result, err = ErrorResult(fmt.Sprintf("tool %s crashed: %v", name, r)), nil
+		}
+	}()
+
+	tool, ok := m.get(name)
 	if !ok {
-		return ErrorResult("unknown tool: " + name), nil
+		var full string
+		if tool, full, ok = m.resolve(name); !ok {
+			return ErrorResult("unknown tool: " + name), nil
+		}
+		name = full
+	}
+
+	if policy := m.currentPolicy(); policy != nil && !policy.Allowed(RoleFrom(ctx), name) {
+		return ErrorResult("tool " + name + " is restricted to owners"), nil
+	}
+
+	// A broken schema is reported once when it is compiled, and the call
+	// runs unchecked; the tool's own checks still apply.
+	var schemaErr *SchemaError
+	if err := ValidateArgs(tool.Parameters(), args); errors.As(err, &schemaErr) {
+		return schemaErr.result(name), nil
+	}
+
+	if m.currentReadOnly() && mutates(name, tool, args) {
+		return ErrorResult("read-only mode: " + name + " is disabled"), nil
+	}
+
+	// Calls made without a chat, such as a subagent's, belong to the chat
+	// whose turn started them.
+	if channel != "" && chatID != "" {
+		ctx = withChat(ctx, channel, chatID)
+	} else {
+		channel, chatID = chatFrom(ctx)
+	}
+
+	if d := m.currentDryRun(); d != nil {
+		if on, _ := d.Enabled(channel + ":" + chatID); on {
+			if result, handled, err := dryRun(ctx, name, tool, args); handled {
+				return result, err
+			}
+		}
+	}
+
+	if approver := m.currentApprover(); approver != nil {
+		a, err := tool.MakeApproval(args)
+		if err != nil {
+			return ErrorResult("invalid arguments: " + err.Error()), nil
+		}
+		if a != nil {
+			if channel == "" || chatID == "" {
+				return ErrorResult("tool " + name + " needs approval, but there is no chat to ask"), nil
+			}
+			if err := approver.Approve(ctx, channel, chatID, name, a); err != nil {
+				return ErrorResult("approval failed: " + err.Error()), nil
+			}
+			if !a.IsApproved() {
+				msg := "the user rejected this call"
+				if a.Reason() != "" {
+					msg += ": " + a.Reason()
+				}
+				return ErrorResult(msg), nil
+			}
+		}
 	}
 
 	if contextualTool, ok := tool.(ContextualTool); ok && channel != "" && chatID != "" {
 		contextualTool.SetContext(channel, chatID)
 	}
 
-	return tool.Execute(ctx, args)
+	callCtx := ctx
+	timeout := m.currentCallTimeout()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	result, err = tool.Execute(callCtx, args)
+	timedOut := timeout > 0 && callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+	if stats := m.currentStats(); stats != nil {
+		ok := err == nil && !result.IsError && !timedOut
+		if recErr := stats.Record(context.Background(), name, ok, time.Since(start)); recErr != nil {
+			fmt.Printf("Failed to record tool call: %v\n", recErr)
+		}
+	}
+	if timedOut {
+		return Result{}, &TimeoutError{Tool: name, After: timeout}
+	}
+	result.Content = m.currentRedactor().String(result.Content)
+	if l := m.currentResultLimit(); l != nil && err == nil {
+		result = l.apply(name, channel+":"+chatID, result)
+	}
+	return result, err
 }
 
 func (m *Manager) Execute(ctx context.Context, name string, args json.RawMessage) (Result, error) {
@@ -124,3 +553,43 @@ func (m *Manager) MakeApproval(name string, args json.RawMessage) (*Approval, er
 	}
 	return tool.MakeApproval(args)
 }
+
+// WireName turns a tool name into one every provider accepts
+// ([a-zA-Z0-9_-]): "mcp:github.search" becomes "mcp__github_search".
+func WireName(name string) string {
+	sanitize := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r == '_' || r == '-' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+				return r
+			}
+			return '_'
+		}, s)
+	}
+	if ns, base := splitName(name); ns != "" {
+		return sanitize(ns) + "__" + sanitize(base)
+	}
+	return sanitize(name)
+}
+
+func splitName(name string) (namespace, base string) {
+	if ns, base, ok := strings.Cut(name, NamespaceSep); ok {
+		return ns, base
+	}
+	return "", name
+}
+
+// Matches reports whether name is listed in patterns, either exactly or
+// through a "namespace:*" pattern.
+func Matches(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if p == name {
+			return true
+		}
+		if ns, ok := strings.CutSuffix(p, NamespaceSep+"*"); ok {
+			if n, _ := splitName(name); n == ns {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package tool
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// DefaultMaxResultChars is the result size above which a ResultLimit
+// without its own MaxChars stores the result as an artifact.
+const DefaultMaxResultChars = 16000
+
+// ResultLimit keeps oversized tool results out of the conversation. The full
+// result is saved as an artifact, and the model gets its head and tail with
+// the artifact's ID to page through the rest with get_artifact.
+type ResultLimit struct {
+	Store    *ArtifactStore
+	MaxChars int
+}
+
+func (l *ResultLimit) maxChars() int {
+	if l.MaxChars > 0 {
+		return l.MaxChars
+	}
+	return DefaultMaxResultChars
+}
+
+// apply returns r, or its head and tail when it is too long. Results of
+// get_artifact are left alone, since they are pages of an artifact already.
+func (l *ResultLimit) apply(name, chat string, r Result) Result {
+	max := l.maxChars()
+	if l.Store == nil || name == "get_artifact" || utf8.RuneCountInString(r.Content) <= max {
+		return r
+	}
+	id, err := l.Store.Save(chat, r.Content)
+	if err != nil {
+		fmt.Printf("Failed to store the result of %s: %v\n", name, err)
+		return r
+	}
+
+	runes := []rune(r.Content)
+	head := max * 2 / 3
+	tail := max - head
+	omitted := runes[head : len(runes)-tail]
+	r.Content = fmt.Sprintf("%s\n\n[... %d characters omitted. The full result (%d characters) is artifact %s; read the rest with get_artifact from offset %d ...]\n\n%s",
+		string(runes[:head]), len(omitted), len(runes), id, head, string(runes[len(runes)-tail:]))
+	return r
+}
@@ -0,0 +1,455 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Open-Meteo and Nominatim need no API key. Nominatim's usage policy asks
+// for an identifying User-Agent and at most one request per second.
+const (
+	NominatimURL = "https://nominatim.openstreetmap.org"
+	OpenMeteoURL = "https://api.open-meteo.com/v1/forecast"
+
+	geoUserAgent     = "nene (https://github.com/nene-agent/nene)"
+	nominatimSpacing = time.Second
+	maxForecastDays  = 7
+)
+
+var geoClient = &http.Client{Timeout: 15 * time.Second}
+
+// nominatimGate spaces out requests to Nominatim across tools and sessions.
+var nominatimGate struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+func waitNominatim(ctx context.Context) error {
+	nominatimGate.mu.Lock()
+	defer nominatimGate.mu.Unlock()
+	if wait := time.Until(nominatimGate.last.Add(nominatimSpacing)); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	nominatimGate.last = time.Now()
+	return nil
+}
+
+func getGeoJSON(ctx context.Context, rawURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", geoUserAgent)
+	resp, err := geoClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// Place is a geocoding result.
+type Place struct {
+	Name string
+	Lat  float64
+	Lon  float64
+	Kind string
+}
+
+func (p Place) String() string {
+	s := fmt.Sprintf("%s (%.4f, %.4f)", p.Name, p.Lat, p.Lon)
+	if p.Kind != "" {
+		s += " [" + p.Kind + "]"
+	}
+	return s
+}
+
+type nominatimPlace struct {
+	DisplayName string `json:"display_name"`
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	Category    string `json:"category"`
+	Type        string `json:"type"`
+}
+
+func (n nominatimPlace) place() Place {
+	lat, _ := strconv.ParseFloat(n.Lat, 64)
+	lon, _ := strconv.ParseFloat(n.Lon, 64)
+	kind := n.Type
+	if n.Category != "" && n.Type != "" {
+		kind = n.Category + "/" + n.Type
+	}
+	return Place{Name: n.DisplayName, Lat: lat, Lon: lon, Kind: kind}
+}
+
+// Geocoder looks up places with Nominatim.
+type Geocoder struct {
+	BaseURL string
+}
+
+func (g *Geocoder) baseURL() string {
+	if g.BaseURL != "" {
+		return strings.TrimSuffix(g.BaseURL, "/")
+	}
+	return NominatimURL
+}
+
+// Search returns up to limit places matching query.
+func (g *Geocoder) Search(ctx context.Context, query string, limit int) ([]Place, error) {
+	if err := waitNominatim(ctx); err != nil {
+		return nil, err
+	}
+	q := url.Values{"q": {query}, "format": {"jsonv2"}, "limit": {strconv.Itoa(limit)}}
+	var results []nominatimPlace
+	if err := getGeoJSON(ctx, g.baseURL()+"/search?"+q.Encode(), &results); err != nil {
+		return nil, fmt.Errorf("geocode %q: %w", query, err)
+	}
+	places := make([]Place, len(results))
+	for i, r := range results {
+		places[i] = r.place()
+	}
+	return places, nil
+}
+
+// Reverse returns the place at a coordinate.
+func (g *Geocoder) Reverse(ctx context.Context, lat, lon float64) (Place, error) {
+	if err := waitNominatim(ctx); err != nil {
+		return Place{}, err
+	}
+	q := url.Values{
+		"lat":    {strconv.FormatFloat(lat, 'f', -1, 64)},
+		"lon":    {strconv.FormatFloat(lon, 'f', -1, 64)},
+		"format": {"jsonv2"},
+	}
+	var result struct {
+		nominatimPlace
+		Error string `json:"error"`
+	}
+	if err := getGeoJSON(ctx, g.baseURL()+"/reverse?"+q.Encode(), &result); err != nil {
+		return Place{}, fmt.Errorf("reverse geocode: %w", err)
+	}
+	if result.Error != "" {
+		return Place{}, fmt.Errorf("reverse geocode: %s", result.Error)
+	}
+	return result.place(), nil
+}
+
+type GeocodeTool struct {
+	parameters json.RawMessage
+	geocoder   *Geocoder
+}
+
+func NewGeocodeTool(g *Geocoder) *GeocodeTool {
+	if g == nil {
+		g = &Geocoder{}
+	}
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Place name or address to look up, such as \"Eiffel Tower\" or \"Shibuya, Tokyo\"",
+			},
+			"latitude": map[string]interface{}{
+				"type":        "number",
+				"description": "Latitude to look up the place at, instead of a query",
+			},
+			"longitude": map[string]interface{}{
+				"type":        "number",
+				"description": "Longitude to look up the place at, instead of a query",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of matches for a query (default: 5)",
+			},
+		},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &GeocodeTool{parameters: paramsJSON, geocoder: g}
+}
+
+func (t *GeocodeTool) Name() string { return "geocode" }
+func (t *GeocodeTool) Description() string {
+	return "Find the coordinates of a place or address, or the place at given coordinates, using OpenStreetMap."
+}
+func (t *GeocodeTool) Parameters() json.RawMessage { return t.parameters }
+
+type geocodeArgs struct {
+	Query     string   `json:"query"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Limit     int      `json:"limit"`
+}
+
+func (t *GeocodeTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+func (t *GeocodeTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a geocodeArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	if a.Latitude != nil && a.Longitude != nil {
+		p, err := t.geocoder.Reverse(ctx, *a.Latitude, *a.Longitude)
+		if err != nil {
+			return ErrorResult(err.Error()), nil
+		}
+		return OkResult(p.String()), nil
+	}
+	if strings.TrimSpace(a.Query) == "" {
+		return ErrorResult("give a query, or a latitude and longitude"), nil
+	}
+	if a.Limit <= 0 || a.Limit > 10 {
+		a.Limit = 5
+	}
+	places, err := t.geocoder.Search(ctx, a.Query, a.Limit)
+	if err != nil {
+		return ErrorResult(err.Error()), nil
+	}
+	if len(places) == 0 {
+		return OkResult(fmt.Sprintf("No places found for %q.", a.Query)), nil
+	}
+	lines := make([]string, len(places))
+	for i, p := range places {
+		lines[i] = fmt.Sprintf("%d. %s", i+1, p)
+	}
+	return OkResult(strings.Join(lines, "\n")), nil
+}
+
+type WeatherTool struct {
+	parameters json.RawMessage
+	geocoder   *Geocoder
+	// BaseURL overrides the Open-Meteo forecast endpoint.
+	BaseURL string
+}
+
+func NewWeatherTool(g *Geocoder) *WeatherTool {
+	if g == nil {
+		g = &Geocoder{}
+	}
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"location": map[string]interface{}{
+				"type":        "string",
+				"description": "Place to get the weather for, such as \"Berlin\" or \"Osaka, Japan\"",
+			},
+			"latitude": map[string]interface{}{
+				"type":        "number",
+				"description": "Latitude, instead of a location name",
+			},
+			"longitude": map[string]interface{}{
+				"type":        "number",
+				"description": "Longitude, instead of a location name",
+			},
+			"days": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Days of forecast, 1 to %d (default: 3)", maxForecastDays),
+			},
+			"units": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"metric", "imperial"},
+				"description": "metric (°C, km/h, mm) or imperial (°F, mph, inch); default metric",
+			},
+		},
+	}
+	paramsJSON, _ := json.Marshal(params)
+	return &WeatherTool{parameters: paramsJSON, geocoder: g}
+}
+
+func (t *WeatherTool) Name() string { return "weather" }
+func (t *WeatherTool) Description() string {
+	return "Get the current weather and a daily forecast for a place, from Open-Meteo. Prefer this over web search for weather questions."
+}
+func (t *WeatherTool) Parameters() json.RawMessage { return t.parameters }
+
+type weatherArgs struct {
+	Location  string   `json:"location"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Days      int      `json:"days"`
+	Units     string   `json:"units"`
+}
+
+func (t *WeatherTool) MakeApproval(args json.RawMessage) (*Approval, error) {
+	return nil, nil
+}
+
+type openMeteoResponse struct {
+	Timezone     string                 `json:"timezone"`
+	Current      map[string]interface{} `json:"current"`
+	CurrentUnits map[string]string      `json:"current_units"`
+	Daily        struct {
+		Time          []string   `json:"time"`
+		WeatherCode   []int      `json:"weather_code"`
+		TempMax       []float64  `json:"temperature_2m_max"`
+		TempMin       []float64  `json:"temperature_2m_min"`
+		PrecipSum     []float64  `json:"precipitation_sum"`
+		PrecipProbMax []*float64 `json:"precipitation_probability_max"`
+	} `json:"daily"`
+	DailyUnits map[string]string `json:"daily_units"`
+	Reason     string            `json:"reason"`
+}
+
+func (t *WeatherTool) Execute(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a weatherArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+	if a.Days <= 0 {
+		a.Days = 3
+	}
+	if a.Days > maxForecastDays {
+		a.Days = maxForecastDays
+	}
+
+	var place Place
+	switch {
+	case a.Latitude != nil && a.Longitude != nil:
+		place = Place{Name: fmt.Sprintf("%.4f, %.4f", *a.Latitude, *a.Longitude), Lat: *a.Latitude, Lon: *a.Longitude}
+	case strings.TrimSpace(a.Location) != "":
+		places, err := t.geocoder.Search(ctx, a.Location, 1)
+		if err != nil {
+			return ErrorResult(err.Error()), nil
+		}
+		if len(places) == 0 {
+			return ErrorResult(fmt.Sprintf("no place found for %q", a.Location)), nil
+		}
+		place = places[0]
+	default:
+		return ErrorResult("give a location, or a latitude and longitude"), nil
+	}
+
+	q := url.Values{
+		"latitude":      {strconv.FormatFloat(place.Lat, 'f', -1, 64)},
+		"longitude":     {strconv.FormatFloat(place.Lon, 'f', -1, 64)},
+		"current":       {"temperature_2m,apparent_temperature,relative_humidity_2m,precipitation,weather_code,wind_speed_10m"},
+		"daily":         {"weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max"},
+		"timezone":      {"auto"},
+		"forecast_days": {strconv.Itoa(a.Days)},
+	}
+	if a.Units == "imperial" {
+		q.Set("temperature_unit", "fahrenheit")
+		q.Set("wind_speed_unit", "mph")
+		q.Set("precipitation_unit", "inch")
+	}
+	base := OpenMeteoURL
+	if t.BaseURL != "" {
+		base = t.BaseURL
+	}
+	var resp openMeteoResponse
+	if err := getGeoJSON(ctx, base+"?"+q.Encode(), &resp); err != nil {
+		return ErrorResult("weather: " + err.Error()), nil
+	}
+	if resp.Reason != "" {
+		return ErrorResult("weather: " + resp.Reason), nil
+	}
+	return OkResult(formatWeather(place, &resp)), nil
+}
+
+func formatWeather(place Place, r *openMeteoResponse) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Weather for %s (timezone %s)\n", place.Name, r.Timezone)
+
+	cur := func(key string) string {
+		v, ok := r.Current[key].(float64)
+		if !ok {
+			return "?"
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64) + r.CurrentUnits[key]
+	}
+	if len(r.Current) > 0 {
+		code, _ := r.Current["weather_code"].(float64)
+		when, _ := r.Current["time"].(string)
+		fmt.Fprintf(&sb, "Now (%s): %s, %s (feels like %s), humidity %s, wind %s, precipitation %s\n",
+			strings.Replace(when, "T", " ", 1), weatherCodeText(int(code)),
+			cur("temperature_2m"), cur("apparent_temperature"), cur("relative_humidity_2m"),
+			cur("wind_speed_10m"), cur("precipitation"))
+	}
+
+	d := r.Daily
+	if len(d.Time) > 0 {
+		sb.WriteString("Forecast:\n")
+	}
+	tempUnit := r.DailyUnits["temperature_2m_max"]
+	for i, day := range d.Time {
+		fmt.Fprintf(&sb, "- %s: %s", day, weatherCodeText(at(d.WeatherCode, i)))
+		if i < len(d.TempMin) && i < len(d.TempMax) {
+			fmt.Fprintf(&sb, ", %g to %g%s", d.TempMin[i], d.TempMax[i], tempUnit)
+		}
+		if i < len(d.PrecipProbMax) && d.PrecipProbMax[i] != nil {
+			fmt.Fprintf(&sb, ", %g%% chance of precipitation", *d.PrecipProbMax[i])
+		}
+		if i < len(d.PrecipSum) && d.PrecipSum[i] > 0 {
+			fmt.Fprintf(&sb, " (%g%s)", d.PrecipSum[i], r.DailyUnits["precipitation_sum"])
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func at(s []int, i int) int {
+	if i < len(s) {
+		return s[i]
+	}
+	return -1
+}
+
+// weatherCodes describe WMO weather interpretation codes.
+var weatherCodes = map[int]string{
+	0:  "Clear sky",
+	1:  "Mainly clear",
+	2:  "Partly cloudy",
+	3:  "Overcast",
+	45: "Fog",
+	48: "Depositing rime fog",
+	51: "Light drizzle",
+	53: "Moderate drizzle",
+	55: "Dense drizzle",
+	56: "Light freezing drizzle",
+	57: "Dense freezing drizzle",
+	61: "Slight rain",
+	63: "Moderate rain",
+	65: "Heavy rain",
+	66: "Light freezing rain",
+	67: "Heavy freezing rain",
+	71: "Slight snowfall",
+	73: "Moderate snowfall",
+	75: "Heavy snowfall",
+	77: "Snow grains",
+	80: "Slight rain showers",
+	81: "Moderate rain showers",
+	82: "Violent rain showers",
+	85: "Slight snow showers",
+	86: "Heavy snow showers",
+	95: "Thunderstorm",
+	96: "Thunderstorm with slight hail",
+	99: "Thunderstorm with heavy hail",
+}
+
+func weatherCodeText(code int) string {
+	if s, ok := weatherCodes[code]; ok {
+		return s
+	}
+	return fmt.Sprintf("Weather code %d", code)
+}
@@ -9,14 +9,23 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/nene-agent/nene/pkg/document"
 )
 
 type WebSearchTool struct {
 	parameters json.RawMessage
+
+	mu       sync.RWMutex
+	backends []SearchBackend
 }
 
-func NewWebSearchTool() *WebSearchTool {
+// NewWebSearchTool searches with the given backends, falling back to the
+// next one when a backend fails or finds nothing. Without backends it uses
+// DuckDuckGo.
+func NewWebSearchTool(backends ...SearchBackend) *WebSearchTool {
 	params := map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
@@ -34,12 +43,24 @@ func NewWebSearchTool() *WebSearchTool {
 		"required": []string{"query"},
 	}
 	paramsJSON, _ := json.Marshal(params)
-	return &WebSearchTool{parameters: paramsJSON}
+	t := &WebSearchTool{parameters: paramsJSON}
+	t.SetBackends(backends)
+	return t
+}
+
+// SetBackends replaces the backends, e.g. on config reload.
+func (t *WebSearchTool) SetBackends(backends []SearchBackend) {
+	if len(backends) == 0 {
+		backends = []SearchBackend{&DuckDuckGoBackend{}}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.backends = backends
 }
 
 func (t *WebSearchTool) Name() string { return "websearch" }
 func (t *WebSearchTool) Description() string {
-	return "Search the web using DuckDuckGo. Returns search results with titles, URLs, and snippets. Use this to find current information, news, or any content beyond your knowledge cutoff."
+	return "Search the web. Returns search results with titles, URLs, and snippets. Use this to find current information, news, or any content beyond your knowledge cutoff."
 }
 func (t *WebSearchTool) Parameters() json.RawMessage { return t.parameters }
 
@@ -48,6 +69,8 @@ type webSearchArgs struct {
 	NumResults int    `json:"num_results"`
 }
 
+func (t *WebSearchTool) ReadOnly() bool { return true }
+
 func (t *WebSearchTool) MakeApproval(args json.RawMessage) (*Approval, error) {
 	var a webSearchArgs
 	if err := json.Unmarshal(args, &a); err != nil {
@@ -70,80 +93,57 @@ func (t *WebSearchTool) Execute(ctx context.Context, args json.RawMessage) (Resu
 		a.NumResults = 5
 	}
 
-	searchURL := fmt.Sprintf("https://html.duckduckgo.com/html/?q=%s", url.QueryEscape(a.Query))
+	t.mu.RLock()
+	backends := t.backends
+	t.mu.RUnlock()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
-	if err != nil {
-		return ErrorResult("failed to create request: " + err.Error()), nil
+	var failures []string
+	for _, b := range backends {
+		results, err := b.Search(ctx, a.Query, a.NumResults)
+		if err != nil {
+			fmt.Printf("Search backend %s failed: %v\n", b.Name(), err)
+			failures = append(failures, fmt.Sprintf("%s: %v", b.Name(), err))
+			continue
+		}
+		if len(results) == 0 {
+			continue
+		}
+		return OkResult(formatSearchResults(a.Query, results, a.NumResults)), nil
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return ErrorResult("request failed: " + err.Error()), nil
+	if len(failures) == len(backends) {
+		return ErrorResult("search failed: " + strings.Join(failures, "; ")), nil
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return ErrorResult("failed to read response: " + err.Error()), nil
-	}
-
-	return OkResult(t.extractResults(string(body), a.NumResults, a.Query)), nil
+	return OkResult(fmt.Sprintf("No results found for: %s", a.Query)), nil
 }
 
-func (t *WebSearchTool) extractResults(html string, count int, query string) string {
-	reLink := regexp.MustCompile(`<a[^>]*class="[^"]*result__a[^"]*"[^>]*href="([^"]+)"[^>]*>([\s\S]*?)</a>`)
-	matches := reLink.FindAllStringSubmatch(html, count+5)
-
-	if len(matches) == 0 {
-		return fmt.Sprintf("No results found for: %s", query)
-	}
-
-	reSnippet := regexp.MustCompile(`<a class="result__snippet[^"]*".*?>([\s\S]*?)</a>`)
-	snippetMatches := reSnippet.FindAllStringSubmatch(html, count+5)
-
+func formatSearchResults(query string, results []SearchResult, count int) string {
 	var lines []string
 	lines = append(lines, fmt.Sprintf("Search results for: %s", query))
 
-	maxItems := min(len(matches), count)
-
-	for i := 0; i < maxItems; i++ {
-		urlStr := matches[i][1]
-		title := stripTags(matches[i][2])
-		title = strings.TrimSpace(title)
-
-		if strings.Contains(urlStr, "uddg=") {
-			if u, err := url.QueryUnescape(urlStr); err == nil {
-				idx := strings.Index(u, "uddg=")
-				if idx != -1 {
-					urlStr = u[idx+5:]
-				}
-			}
-		}
-
-		lines = append(lines, fmt.Sprintf("\n%d. %s", i+1, title))
-		lines = append(lines, fmt.Sprintf("   URL: %s", urlStr))
-
-		if i < len(snippetMatches) {
-			snippet := stripTags(snippetMatches[i][1])
-			snippet = strings.TrimSpace(snippet)
-			if snippet != "" {
-				lines = append(lines, fmt.Sprintf("   %s", snippet))
-			}
+	for i, r := range results[:min(len(results), count)] {
+		lines = append(lines, fmt.Sprintf("\n%d. %s", i+1, r.Title))
+		lines = append(lines, fmt.Sprintf("   URL: %s", r.URL))
+		if r.Snippet != "" {
+			lines = append(lines, fmt.Sprintf("   %s", r.Snippet))
 		}
 	}
 
 	return strings.Join(lines, "\n")
 }
 
+// WebFetchTool fetches pages with an on-disk cache, a minimum interval
+// between requests to the same host, and a cap on concurrent fetches.
 type WebFetchTool struct {
 	parameters json.RawMessage
+
+	mu      sync.RWMutex
+	cache   *fetchCache
+	limiter *fetchLimiter
+	client  *http.Client
 }
 
-func NewWebFetchTool() *WebFetchTool {
+func NewWebFetchTool(cfg WebFetchConfig) *WebFetchTool {
 	params := map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
@@ -157,24 +157,56 @@ func NewWebFetchTool() *WebFetchTool {
 				"minimum":     1000.0,
 				"maximum":     50000.0,
 			},
+			"pages": map[string]interface{}{
+				"type":        "string",
+				"description": "PDF only: pages to read, e.g. \"1-3,7\" (default: all)",
+			},
+			"sheet": map[string]interface{}{
+				"type":        "string",
+				"description": "XLSX only: sheet name or 1-based index to read (default: all)",
+			},
 		},
 		"required": []string{"url"},
 	}
 	paramsJSON, _ := json.Marshal(params)
-	return &WebFetchTool{parameters: paramsJSON}
+	t := &WebFetchTool{parameters: paramsJSON}
+	t.SetConfig(cfg)
+	return t
+}
+
+// SetConfig applies new cache, rate limit, and timeout settings, e.g. on
+// config reload.
+func (t *WebFetchTool) SetConfig(cfg WebFetchConfig) {
+	cache := newFetchCache(cfg.CacheDir, fetchSetting(cfg.CacheTTLMinutes, defaultFetchCacheTTL, time.Minute))
+	maxConcurrent := cfg.MaxConcurrent
+	if maxConcurrent == 0 {
+		maxConcurrent = defaultMaxConcurrentFetch
+	}
+	limiter := newFetchLimiter(fetchSetting(cfg.DomainIntervalMs, defaultFetchDomainInterval, time.Millisecond), maxConcurrent)
+	timeout := fetchSetting(cfg.TimeoutSeconds, defaultFetchTimeout, time.Second)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cache = cache
+	t.limiter = limiter
+	t.client = &http.Client{Timeout: timeout}
 }
 
 func (t *WebFetchTool) Name() string { return "webfetch" }
 func (t *WebFetchTool) Description() string {
-	return "Fetch content from a URL. Extracts readable text from web pages. Use this to get detailed content from a specific URL found via web search."
+	return "Fetch content from a URL. Extracts readable text from web pages and from PDF, DOCX, and XLSX documents. Use this to get detailed content from a specific URL found via web search."
 }
 func (t *WebFetchTool) Parameters() json.RawMessage { return t.parameters }
 
 type webFetchArgs struct {
 	URL      string `json:"url"`
 	MaxChars int    `json:"max_chars"`
+	Pages    string `json:"pages"`
+	Sheet    string `json:"sheet"`
 }
 
+func (t *WebFetchTool) ReadOnly() bool { return true }
+
 func (t *WebFetchTool) MakeApproval(args json.RawMessage) (*Approval, error) {
 	var a webFetchArgs
 	if err := json.Unmarshal(args, &a); err != nil {
@@ -201,6 +233,29 @@ func (t *WebFetchTool) Execute(ctx context.Context, args json.RawMessage) (Resul
 		a.MaxChars = 10000
 	}
 
+	u, err := url.Parse(a.URL)
+	if err != nil {
+		return ErrorResult("invalid URL: " + err.Error()), nil
+	}
+
+	t.mu.RLock()
+	cache, limiter, client := t.cache, t.limiter, t.client
+	t.mu.RUnlock()
+
+	cacheKey := a.URL
+	if a.Pages != "" || a.Sheet != "" {
+		cacheKey += "\x00" + a.Pages + "\x00" + a.Sheet
+	}
+	if content, ok := cache.get(cacheKey, a.MaxChars); ok {
+		return OkResult(content), nil
+	}
+
+	release, err := limiter.acquire(ctx, u.Hostname())
+	if err != nil {
+		return ErrorResult("request cancelled: " + err.Error()), nil
+	}
+	defer release()
+
 	req, err := http.NewRequestWithContext(ctx, "GET", a.URL, nil)
 	if err != nil {
 		return ErrorResult("failed to create request: " + err.Error()), nil
@@ -209,7 +264,6 @@ func (t *WebFetchTool) Execute(ctx context.Context, args json.RawMessage) (Resul
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,text/plain;q=0.8,*/*;q=0.1")
 
-	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
 		return ErrorResult("request failed: " + err.Error()), nil
@@ -220,88 +274,39 @@ func (t *WebFetchTool) Execute(ctx context.Context, args json.RawMessage) (Resul
 		return ErrorResult(fmt.Sprintf("request failed with status: %d", resp.StatusCode)), nil
 	}
 
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 5*1024*1024))
+	contentType := resp.Header.Get("Content-Type")
+	limit := int64(5 * 1024 * 1024)
+	if document.Kind(u.Path, contentType, nil) != "" {
+		limit = 50 * 1024 * 1024
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
 	if err != nil {
 		return ErrorResult("failed to read response: " + err.Error()), nil
 	}
 
-	content := string(body)
-	if strings.Contains(resp.Header.Get("Content-Type"), "text/html") || looksLikeHTML(content) {
-		content = extractTextFromHTML(content)
+	var content string
+	if kind := document.Kind(u.Path, contentType, body); kind != "" {
+		content, err = document.Extract(kind, body, document.Options{Pages: a.Pages, Sheet: a.Sheet})
+		if err != nil {
+			return ErrorResult(err.Error()), nil
+		}
+	} else {
+		content = string(body)
+		if strings.Contains(contentType, "text/html") || document.LooksLikeHTML(content) {
+			content = document.HTMLText(content)
+		}
 	}
 
 	if len(content) > a.MaxChars {
 		content = content[:a.MaxChars] + "\n... (truncated)"
 	}
 
-	return OkResult(fmt.Sprintf("Content from %s:\n\n%s", a.URL, content)), nil
+	result := fmt.Sprintf("Content from %s:\n\n%s", a.URL, content)
+	cache.put(cacheKey, a.MaxChars, result)
+	return OkResult(result), nil
 }
 
 func stripTags(content string) string {
 	re := regexp.MustCompile(`<[^>]+>`)
 	return re.ReplaceAllString(content, "")
 }
-
-func looksLikeHTML(content string) bool {
-	trimmed := strings.TrimSpace(content)
-	return strings.HasPrefix(trimmed, "<!DOCTYPE") ||
-		strings.HasPrefix(strings.ToLower(trimmed), "<html") ||
-		(strings.Contains(trimmed, "<") && strings.Contains(trimmed, ">"))
-}
-
-func extractTextFromHTML(html string) string {
-	result := html
-
-	for {
-		start := strings.Index(strings.ToLower(result), "<script")
-		if start == -1 {
-			break
-		}
-		end := strings.Index(strings.ToLower(result[start:]), "</script>")
-		if end == -1 {
-			break
-		}
-		result = result[:start] + result[start+end+9:]
-	}
-
-	for {
-		start := strings.Index(strings.ToLower(result), "<style")
-		if start == -1 {
-			break
-		}
-		end := strings.Index(strings.ToLower(result[start:]), "</style>")
-		if end == -1 {
-			break
-		}
-		result = result[:start] + result[start+end+8:]
-	}
-
-	var output strings.Builder
-	inTag := false
-	for _, r := range result {
-		if r == '<' {
-			inTag = true
-			continue
-		}
-		if r == '>' {
-			inTag = false
-			continue
-		}
-		if !inTag {
-			output.WriteRune(r)
-		}
-	}
-
-	text := output.String()
-
-	lines := strings.Split(text, "\n")
-	var cleanLines []string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			cleanLines = append(cleanLines, line)
-		}
-	}
-
-	return strings.Join(cleanLines, "\n")
-}
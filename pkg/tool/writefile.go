@@ -3,6 +3,7 @@ package tool
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -74,3 +75,32 @@ func (t *WriteFileTool) Execute(ctx context.Context, args json.RawMessage) (Resu
 
 	return OkResult("File written successfully: " + path), nil
 }
+
+func (t *WriteFileTool) DryRun(ctx context.Context, args json.RawMessage) (Result, error) {
+	var a writeFileArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return ErrorResult("invalid arguments: " + err.Error()), nil
+	}
+
+	path := filepath.Clean(a.Path)
+	if strings.Contains(path, "..") {
+		return ErrorResult("path traversal not allowed"), nil
+	}
+
+	old, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		preview := a.Content
+		if len(preview) > 2000 {
+			preview = preview[:2000] + "\n..."
+		}
+		return OkResult(fmt.Sprintf("Would create %s (%d bytes):\n%s", path, len(a.Content), preview)), nil
+	}
+	if err != nil {
+		return ErrorResult("failed to read file: " + err.Error()), nil
+	}
+	diff := unifiedDiff(path, string(old), a.Content)
+	if diff == "" {
+		return OkResult("Would leave " + path + " unchanged"), nil
+	}
+	return OkResult("Would change " + path + ":\n" + diff), nil
+}
@@ -0,0 +1,211 @@
+// Package update checks GitHub releases for a newer version and tells the
+// owner chat about it. It never installs anything.
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nene-agent/nene/pkg/bus"
+)
+
+const (
+	DefaultRepo     = "nene-agent/nene"
+	DefaultInterval = 24 * time.Hour
+	DefaultAPIURL   = "https://api.github.com"
+)
+
+// Release is a published release.
+type Release struct {
+	Version string `json:"tag_name"`
+	URL     string `json:"html_url"`
+}
+
+// Checker looks for a release newer than the running version every
+// interval, and notifies the owner chat once per new release.
+type Checker struct {
+	bus       *bus.MessageBus
+	ownerChat string
+	current   string
+	repo      string
+	apiURL    string
+	interval  time.Duration
+	client    *http.Client
+
+	mu       sync.Mutex
+	latest   *Release
+	notified string
+}
+
+type Option func(*Checker)
+
+// WithRepo sets the GitHub repository, as "owner/name", whose releases are
+// checked.
+func WithRepo(repo string) Option {
+	return func(c *Checker) {
+		if repo != "" {
+			c.repo = repo
+		}
+	}
+}
+
+func WithInterval(d time.Duration) Option {
+	return func(c *Checker) {
+		if d > 0 {
+			c.interval = d
+		}
+	}
+}
+
+// WithAPIURL points the checker at another GitHub API, such as GitHub
+// Enterprise's.
+func WithAPIURL(url string) Option {
+	return func(c *Checker) {
+		if url != "" {
+			c.apiURL = strings.TrimRight(url, "/")
+		}
+	}
+}
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Checker) { c.client = client }
+}
+
+// New returns a checker for the running version current, usually
+// agent.Version. Builds without a release version, such as "dev", are
+// never told to update.
+func New(b *bus.MessageBus, ownerChat, current string, opts ...Option) *Checker {
+	c := &Checker{
+		bus:       b,
+		ownerChat: ownerChat,
+		current:   current,
+		repo:      DefaultRepo,
+		apiURL:    DefaultAPIURL,
+		interval:  DefaultInterval,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run checks once at start and then every interval until ctx is done.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Check(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			fmt.Printf("Update check failed: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check fetches the latest release and notifies the owner chat if it is
+// newer than the running version and was not announced before.
+func (c *Checker) Check(ctx context.Context) error {
+	if _, ok := parseVersion(c.current); !ok {
+		return nil
+	}
+	rel, err := c.fetchLatest(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.latest = rel
+	announce := newer(rel.Version, c.current) && rel.Version != c.notified
+	if announce {
+		c.notified = rel.Version
+	}
+	c.mu.Unlock()
+
+	if announce && c.bus != nil && c.ownerChat != "" {
+		channel, chatID, _ := strings.Cut(c.ownerChat, ":")
+		c.bus.PublishOutbound(bus.OutboundMessage{
+			Channel: channel,
+			ChatID:  chatID,
+			Content: fmt.Sprintf("⬆️ nene %s is available; this is %s.\n%s", rel.Version, c.current, rel.URL),
+		})
+	}
+	return nil
+}
+
+// Latest returns the release found by the last check, or nil.
+func (c *Checker) Latest() *Release {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.latest
+}
+
+func (c *Checker) fetchLatest(ctx context.Context) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+"/repos/"+c.repo+"/releases/latest", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("releases of %s: status %d", c.repo, resp.StatusCode)
+	}
+	var rel Release
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return nil, fmt.Errorf("decode release: %w", err)
+	}
+	if _, ok := parseVersion(rel.Version); !ok {
+		return nil, fmt.Errorf("latest release of %s has no version tag: %q", c.repo, rel.Version)
+	}
+	return &rel, nil
+}
+
+// newer reports whether version a is later than b.
+func newer(a, b string) bool {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	if !okA || !okB {
+		return false
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] > vb[i]
+		}
+	}
+	return false
+}
+
+// parseVersion reads "v1.2.3" or "1.2.3"; a suffix such as "-rc1" is
+// ignored.
+func parseVersion(s string) ([3]int, bool) {
+	var v [3]int
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "-")
+	s, _, _ = strings.Cut(s, "+")
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}